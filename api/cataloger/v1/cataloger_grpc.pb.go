@@ -0,0 +1,256 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             (unknown)
+// source: cataloger/v1/cataloger.proto
+
+package catalogerv1
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	CatalogerService_GenerateFromImage_FullMethodName = "/cataloger.v1.CatalogerService/GenerateFromImage"
+	CatalogerService_GenerateFromOCR_FullMethodName   = "/cataloger.v1.CatalogerService/GenerateFromOCR"
+	CatalogerService_Validate_FullMethodName          = "/cataloger.v1.CatalogerService/Validate"
+	CatalogerService_Compare_FullMethodName           = "/cataloger.v1.CatalogerService/Compare"
+)
+
+// CatalogerServiceClient is the client API for CatalogerService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// CatalogerService generates, validates and scores MARC records
+type CatalogerServiceClient interface {
+	// GenerateFromImage transcribes the images and generates a MARC record from their text.
+	// Progress events are streamed while OCR and generation run; the last message is the result.
+	GenerateFromImage(ctx context.Context, in *GenerateFromImageRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[GenerateResponse], error)
+	// GenerateFromOCR generates a MARC record from already transcribed text
+	GenerateFromOCR(ctx context.Context, in *GenerateFromOCRRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[GenerateResponse], error)
+	// Validate checks a MARC record for structural errors and common content problems
+	Validate(ctx context.Context, in *ValidateRequest, opts ...grpc.CallOption) (*ValidateResponse, error)
+	// Compare scores a generated MARC record against a reference record
+	Compare(ctx context.Context, in *CompareRequest, opts ...grpc.CallOption) (*CompareResponse, error)
+}
+
+type catalogerServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewCatalogerServiceClient(cc grpc.ClientConnInterface) CatalogerServiceClient {
+	return &catalogerServiceClient{cc}
+}
+
+func (c *catalogerServiceClient) GenerateFromImage(ctx context.Context, in *GenerateFromImageRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[GenerateResponse], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &CatalogerService_ServiceDesc.Streams[0], CatalogerService_GenerateFromImage_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[GenerateFromImageRequest, GenerateResponse]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type CatalogerService_GenerateFromImageClient = grpc.ServerStreamingClient[GenerateResponse]
+
+func (c *catalogerServiceClient) GenerateFromOCR(ctx context.Context, in *GenerateFromOCRRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[GenerateResponse], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &CatalogerService_ServiceDesc.Streams[1], CatalogerService_GenerateFromOCR_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[GenerateFromOCRRequest, GenerateResponse]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type CatalogerService_GenerateFromOCRClient = grpc.ServerStreamingClient[GenerateResponse]
+
+func (c *catalogerServiceClient) Validate(ctx context.Context, in *ValidateRequest, opts ...grpc.CallOption) (*ValidateResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ValidateResponse)
+	err := c.cc.Invoke(ctx, CatalogerService_Validate_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *catalogerServiceClient) Compare(ctx context.Context, in *CompareRequest, opts ...grpc.CallOption) (*CompareResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CompareResponse)
+	err := c.cc.Invoke(ctx, CatalogerService_Compare_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// CatalogerServiceServer is the server API for CatalogerService service.
+// All implementations must embed UnimplementedCatalogerServiceServer
+// for forward compatibility.
+//
+// CatalogerService generates, validates and scores MARC records
+type CatalogerServiceServer interface {
+	// GenerateFromImage transcribes the images and generates a MARC record from their text.
+	// Progress events are streamed while OCR and generation run; the last message is the result.
+	GenerateFromImage(*GenerateFromImageRequest, grpc.ServerStreamingServer[GenerateResponse]) error
+	// GenerateFromOCR generates a MARC record from already transcribed text
+	GenerateFromOCR(*GenerateFromOCRRequest, grpc.ServerStreamingServer[GenerateResponse]) error
+	// Validate checks a MARC record for structural errors and common content problems
+	Validate(context.Context, *ValidateRequest) (*ValidateResponse, error)
+	// Compare scores a generated MARC record against a reference record
+	Compare(context.Context, *CompareRequest) (*CompareResponse, error)
+	mustEmbedUnimplementedCatalogerServiceServer()
+}
+
+// UnimplementedCatalogerServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedCatalogerServiceServer struct{}
+
+func (UnimplementedCatalogerServiceServer) GenerateFromImage(*GenerateFromImageRequest, grpc.ServerStreamingServer[GenerateResponse]) error {
+	return status.Errorf(codes.Unimplemented, "method GenerateFromImage not implemented")
+}
+func (UnimplementedCatalogerServiceServer) GenerateFromOCR(*GenerateFromOCRRequest, grpc.ServerStreamingServer[GenerateResponse]) error {
+	return status.Errorf(codes.Unimplemented, "method GenerateFromOCR not implemented")
+}
+func (UnimplementedCatalogerServiceServer) Validate(context.Context, *ValidateRequest) (*ValidateResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Validate not implemented")
+}
+func (UnimplementedCatalogerServiceServer) Compare(context.Context, *CompareRequest) (*CompareResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Compare not implemented")
+}
+func (UnimplementedCatalogerServiceServer) mustEmbedUnimplementedCatalogerServiceServer() {}
+func (UnimplementedCatalogerServiceServer) testEmbeddedByValue()                          {}
+
+// UnsafeCatalogerServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to CatalogerServiceServer will
+// result in compilation errors.
+type UnsafeCatalogerServiceServer interface {
+	mustEmbedUnimplementedCatalogerServiceServer()
+}
+
+func RegisterCatalogerServiceServer(s grpc.ServiceRegistrar, srv CatalogerServiceServer) {
+	// If the following call pancis, it indicates UnimplementedCatalogerServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&CatalogerService_ServiceDesc, srv)
+}
+
+func _CatalogerService_GenerateFromImage_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(GenerateFromImageRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(CatalogerServiceServer).GenerateFromImage(m, &grpc.GenericServerStream[GenerateFromImageRequest, GenerateResponse]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type CatalogerService_GenerateFromImageServer = grpc.ServerStreamingServer[GenerateResponse]
+
+func _CatalogerService_GenerateFromOCR_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(GenerateFromOCRRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(CatalogerServiceServer).GenerateFromOCR(m, &grpc.GenericServerStream[GenerateFromOCRRequest, GenerateResponse]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type CatalogerService_GenerateFromOCRServer = grpc.ServerStreamingServer[GenerateResponse]
+
+func _CatalogerService_Validate_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ValidateRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CatalogerServiceServer).Validate(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: CatalogerService_Validate_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CatalogerServiceServer).Validate(ctx, req.(*ValidateRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CatalogerService_Compare_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CompareRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CatalogerServiceServer).Compare(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: CatalogerService_Compare_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CatalogerServiceServer).Compare(ctx, req.(*CompareRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// CatalogerService_ServiceDesc is the grpc.ServiceDesc for CatalogerService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var CatalogerService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "cataloger.v1.CatalogerService",
+	HandlerType: (*CatalogerServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Validate",
+			Handler:    _CatalogerService_Validate_Handler,
+		},
+		{
+			MethodName: "Compare",
+			Handler:    _CatalogerService_Compare_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "GenerateFromImage",
+			Handler:       _CatalogerService_GenerateFromImage_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "GenerateFromOCR",
+			Handler:       _CatalogerService_GenerateFromOCR_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "cataloger/v1/cataloger.proto",
+}
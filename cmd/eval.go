@@ -15,7 +15,25 @@ func newEvalCmd() *cobra.Command {
 	// Add eval subcommands
 	cmd.AddCommand(evalcmd.NewIBCmd())
 	cmd.AddCommand(evalcmd.NewInspectCmd())
+	cmd.AddCommand(evalcmd.NewFetchCmd())
+	cmd.AddCommand(evalcmd.NewEnrichCmd())
 	cmd.AddCommand(evalcmd.NewDownloadImagesCmd())
+	cmd.AddCommand(evalcmd.NewExportHFCmd())
+	cmd.AddCommand(evalcmd.NewRedactCmd())
+	cmd.AddCommand(evalcmd.NewCompareCmd())
+	cmd.AddCommand(evalcmd.NewRunCmd())
+	cmd.AddCommand(evalcmd.NewRerunFailuresCmd())
+	cmd.AddCommand(evalcmd.NewReportCmd())
+	cmd.AddCommand(evalcmd.NewClusterCmd())
+	cmd.AddCommand(evalcmd.NewDaemonCmd())
+	cmd.AddCommand(evalcmd.NewSourcesCmd())
+	cmd.AddCommand(evalcmd.NewBaselineCmd())
+	cmd.AddCommand(evalcmd.NewRoundTripCmd())
+	cmd.AddCommand(evalcmd.NewQualityCmd())
+	cmd.AddCommand(evalcmd.NewScoreCmd())
+	cmd.AddCommand(evalcmd.NewAgreementCmd())
+	cmd.AddCommand(evalcmd.NewAuditCmd())
+	cmd.AddCommand(evalcmd.NewSelftestCmd())
 
 	return cmd
 }
@@ -0,0 +1,75 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/lehigh-university-libraries/cataloger/internal/cataloging"
+	"github.com/lehigh-university-libraries/cataloger/internal/ocr"
+	"github.com/spf13/cobra"
+)
+
+func newGenerateCmd() *cobra.Command {
+	var (
+		provider      string
+		model         string
+		material      string
+		copyrightPage string
+		quiet         bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "generate <image>...",
+		Short: "Generate a MARC record from title page images",
+		Long: `Run OCR on the images (title page first) and generate a MARC record from their text.
+
+The model's metadata response is streamed to stderr as it is generated; the record is
+printed to stdout in MARC mnemonic format.`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ocrService := ocr.NewService()
+			var texts []string
+			for _, image := range args {
+				text, err := ocrService.ExtractTextFromImage(cmd.Context(), image, provider, model)
+				if err != nil {
+					return fmt.Errorf("failed to OCR %s: %w", image, err)
+				}
+				texts = append(texts, text)
+			}
+			pages := cataloging.OCRPages{Text: strings.Join(texts, "\n\n"), Material: material}
+			if copyrightPage != "" {
+				text, err := ocrService.ExtractTextFromImage(cmd.Context(), copyrightPage, provider, model)
+				if err != nil {
+					return fmt.Errorf("failed to OCR %s: %w", copyrightPage, err)
+				}
+				pages.CopyrightPage = text
+			}
+
+			var onChunk func(string)
+			if !quiet {
+				onChunk = func(chunk string) { fmt.Fprint(os.Stderr, chunk) }
+			}
+			rec, notes, err := cataloging.NewService().StreamMARCFromPages(cmd.Context(), pages, provider, model, onChunk)
+			if !quiet {
+				fmt.Fprintln(os.Stderr)
+			}
+			if err != nil {
+				return fmt.Errorf("failed to generate MARC: %w", err)
+			}
+			for _, w := range notes.Warnings {
+				fmt.Fprintf(os.Stderr, "warning: %s: %s\n", w.Code, w.Message)
+			}
+			fmt.Fprint(os.Stdout, rec.Mnemonic())
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&provider, "provider", "", "LLM provider (default from CATALOGING_PROVIDER)")
+	cmd.Flags().StringVar(&model, "model", "", "Model (default: the provider's default model)")
+	cmd.Flags().StringVar(&material, "material", "", "Material type, selecting its metadata prompt (default book)")
+	cmd.Flags().StringVar(&copyrightPage, "copyright-page", "", "Image of the copyright page, for a second pass over its copyright data")
+	cmd.Flags().BoolVar(&quiet, "quiet", false, "Don't stream the model's response to stderr")
+
+	return cmd
+}
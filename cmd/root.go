@@ -20,6 +20,8 @@ It supports a powerful CLI for evaluating metadata extraction accuracy against p
 
 	// Add subcommands
 	cmd.AddCommand(newEvalCmd())
+	cmd.AddCommand(newServeCmd())
+	cmd.AddCommand(newGenerateCmd())
 
 	return cmd
 }
@@ -0,0 +1,361 @@
+package cmd
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/lehigh-university-libraries/cataloger/internal/batch"
+	"github.com/lehigh-university-libraries/cataloger/internal/grpcserver"
+	"github.com/lehigh-university-libraries/cataloger/internal/handlers"
+	"github.com/lehigh-university-libraries/cataloger/internal/ratelimit"
+	"github.com/lehigh-university-libraries/cataloger/internal/roles"
+	"github.com/lehigh-university-libraries/cataloger/internal/schedule"
+	"github.com/lehigh-university-libraries/cataloger/internal/storage"
+	"github.com/lehigh-university-libraries/cataloger/internal/uploads"
+	"github.com/spf13/cobra"
+	"golang.org/x/crypto/acme/autocert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+)
+
+// serveOptions holds the flags for the serve command. Each flag falls back to an
+// environment variable (see serveEnv) when it is not given on the command line.
+type serveOptions struct {
+	addr            string
+	port            int
+	dataDir         string
+	uploadsDir      string
+	tlsCert         string
+	tlsKey          string
+	autocertDomains []string
+	autocertCache   string
+	readTimeout     time.Duration
+	writeTimeout    time.Duration
+	idleTimeout     time.Duration
+	maxRequestSize  string
+	rateLimit       float64
+	rateBurst       int
+	trustProxy      bool
+	maxGenerations  int
+	generationWait  time.Duration
+	grpcPort        int
+	evalHistory     string
+	marcFromImages  bool
+	roles           string
+	batchDir        string
+	batchFormat     string
+	batchCron       string
+}
+
+// serveEnv maps serve flags to the environment variables they fall back to
+var serveEnv = map[string]string{
+	"addr":             "SERVE_ADDR",
+	"port":             "PORT",
+	"tls-cert":         "SERVE_TLS_CERT",
+	"tls-key":          "SERVE_TLS_KEY",
+	"autocert-domains": "SERVE_AUTOCERT_DOMAINS",
+	"autocert-cache":   "SERVE_AUTOCERT_CACHE",
+	"read-timeout":     "SERVE_READ_TIMEOUT",
+	"write-timeout":    "SERVE_WRITE_TIMEOUT",
+	"idle-timeout":     "SERVE_IDLE_TIMEOUT",
+	"max-request-size": "SERVE_MAX_REQUEST_SIZE",
+	"rate-limit":       "SERVE_RATE_LIMIT",
+	"rate-burst":       "SERVE_RATE_BURST",
+	"trust-proxy":      "SERVE_TRUST_PROXY",
+	"max-generations":  "SERVE_MAX_GENERATIONS",
+	"generation-wait":  "SERVE_GENERATION_WAIT",
+	"grpc-port":        "SERVE_GRPC_PORT",
+	"eval-history":     "SERVE_EVAL_HISTORY",
+	"marc-from-images": "SERVE_MARC_FROM_IMAGES",
+	"roles":            "SERVE_ROLES",
+	"batch-dir":        "SERVE_BATCH_DIR",
+	"batch-format":     "SERVE_BATCH_FORMAT",
+	"batch-cron":       "SERVE_BATCH_CRON",
+}
+
+func newServeCmd() *cobra.Command {
+	var opts serveOptions
+
+	cmd := &cobra.Command{
+		Use:   "serve",
+		Short: "Run the cataloging web API",
+		Long: `Run the HTTP API used by the cataloging web UI.
+
+Every listener flag can also be set through the environment (SERVE_ADDR, PORT, SERVE_TLS_CERT,
+SERVE_TLS_KEY, SERVE_AUTOCERT_DOMAINS, SERVE_AUTOCERT_CACHE, SERVE_READ_TIMEOUT,
+SERVE_WRITE_TIMEOUT, SERVE_IDLE_TIMEOUT, SERVE_MAX_REQUEST_SIZE, SERVE_RATE_LIMIT,
+SERVE_RATE_BURST, SERVE_TRUST_PROXY, SERVE_MAX_GENERATIONS, SERVE_GENERATION_WAIT,
+SERVE_GRPC_PORT, SERVE_EVAL_HISTORY, SERVE_MARC_FROM_IMAGES, SERVE_ROLES, SERVE_BATCH_DIR,
+SERVE_BATCH_FORMAT, SERVE_BATCH_CRON), which suits containers. Flags take precedence.
+
+Uploads and generation requests are limited per client IP address (with --trust-proxy, the
+last X-Forwarded-For address); clients over the limit get 429 with Retry-After. At most
+--max-generations OCR/MARC generations run at once; further requests wait up to
+--generation-wait for a slot, then get 503 with Retry-After.
+
+With --grpc-port the cataloging service is also served over gRPC (see
+api/cataloger/v1/cataloger.proto), with the same TLS settings and generation cap.
+
+With --marc-from-images a session's MARC is generated by sending all its page images (title
+page, copyright page, cover) to the model in one request, instead of transcribing each and
+generating from the text. Requests can choose either way with "from_images".
+
+With --roles, users signed in by an authenticating proxy (X-Remote-User) get the role the
+roles file gives them: catalogers create and edit sessions, reviewers also approve and reject
+records, and admins also push records, import sessions and choose providers and models.
+The headers are only trusted from the proxy's address (trusted_proxies in the roles file,
+loopback by default); other requests get the default role. Without it everyone may do
+everything. Over gRPC the proxy passes the same headers as call
+metadata; generating takes the cataloger role and choosing the provider or model the admin
+role.
+
+With --batch-dir, approved records not yet exported are written to one batch file there
+(binary MARC, or a MARCXML collection with --batch-format xml) for library systems that load
+records in batches: on request with POST /api/batches, and on the --batch-cron schedule. Each
+exported session records its batch file, so no record is exported twice.
+
+With --eval-history pointing at the output of cataloger eval daemon, each model's scheduled
+evaluation scores are shown at /eval/trends and served as JSON at /api/eval/trends, to spot
+model drift.
+
+With --tls-cert/--tls-key the server speaks HTTPS with that certificate. With
+--autocert-domains it obtains certificates from Let's Encrypt (TLS-ALPN challenge, so it must
+be reachable on port 443) and caches them in --autocert-cache.`,
+		Example: `  # Serve on the default port
+  cataloger serve
+
+  # Serve on a different port
+  cataloger serve --port 9000
+
+  # Persist sessions and their audit logs across restarts
+  cataloger serve --data-dir ./sessions
+
+  # Keep uploads per deployment, capped at 5GB
+  UPLOADS_QUOTA=5GB cataloger serve --data-dir ./sessions --uploads-dir ./sessions/uploads
+
+  # Only listen on localhost
+  cataloger serve --addr 127.0.0.1
+
+  # HTTPS with your own certificate
+  cataloger serve --port 8443 --tls-cert /etc/cataloger/tls.crt --tls-key /etc/cataloger/tls.key
+
+  # Behind a reverse proxy, 10 generation requests a minute per client, 2 at a time
+  cataloger serve --trust-proxy --rate-limit 10 --max-generations 2
+
+  # Behind a single sign-on proxy, with roles by user and group
+  cataloger serve --roles /etc/cataloger/roles.yaml
+
+  # Export approved records for batch loading every night at 2am
+  cataloger serve --data-dir ./sessions --batch-dir ./batches --batch-cron "0 2 * * *"
+
+  # Also serve gRPC on port 9090
+  cataloger serve --grpc-port 9090
+
+  # HTTPS with Let's Encrypt
+  cataloger serve --port 443 --autocert-domains cataloger.example.edu --autocert-cache /var/lib/cataloger/certs`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := applyServeEnv(cmd); err != nil {
+				return err
+			}
+			return runServer(opts)
+		},
+	}
+
+	cmd.Flags().StringVar(&opts.addr, "addr", "", "Address to bind (all interfaces when empty)")
+	cmd.Flags().IntVar(&opts.port, "port", 8888, "Port to listen on")
+	cmd.Flags().StringVar(&opts.dataDir, "data-dir", "", "Directory to persist sessions and audit logs (in-memory when empty)")
+	cmd.Flags().StringVar(&opts.uploadsDir, "uploads-dir", "", "Directory or s3://, gs:// bucket URL for uploaded images (default $UPLOADS_DIR or ./uploads)")
+	cmd.Flags().StringVar(&opts.tlsCert, "tls-cert", "", "TLS certificate file (PEM)")
+	cmd.Flags().StringVar(&opts.tlsKey, "tls-key", "", "TLS private key file (PEM)")
+	cmd.Flags().StringSliceVar(&opts.autocertDomains, "autocert-domains", nil, "Obtain TLS certificates for these domains from Let's Encrypt")
+	cmd.Flags().StringVar(&opts.autocertCache, "autocert-cache", "autocert", "Directory to cache Let's Encrypt certificates")
+	cmd.Flags().DurationVar(&opts.readTimeout, "read-timeout", 60*time.Second, "Maximum time to read a request, including uploads")
+	cmd.Flags().DurationVar(&opts.writeTimeout, "write-timeout", 10*time.Minute, "Maximum time to write a response; MARC generation can take minutes")
+	cmd.Flags().DurationVar(&opts.idleTimeout, "idle-timeout", 2*time.Minute, "Maximum time to keep idle connections open")
+	cmd.Flags().StringVar(&opts.maxRequestSize, "max-request-size", "32MB", "Maximum request body size, e.g. 32MB")
+	cmd.Flags().Float64Var(&opts.rateLimit, "rate-limit", 30, "Upload and generation requests per minute per client (0 for no limit)")
+	cmd.Flags().IntVar(&opts.rateBurst, "rate-burst", 10, "Requests a client may make in a burst before --rate-limit applies")
+	cmd.Flags().BoolVar(&opts.trustProxy, "trust-proxy", false, "Trust X-Forwarded-For and X-Forwarded-Proto (only behind a reverse proxy)")
+	cmd.Flags().IntVar(&opts.maxGenerations, "max-generations", 4, "Maximum concurrent OCR/MARC generations (0 for no limit)")
+	cmd.Flags().DurationVar(&opts.generationWait, "generation-wait", 30*time.Second, "How long a generation request waits for a free slot before 503")
+	cmd.Flags().IntVar(&opts.grpcPort, "grpc-port", 0, "Also serve the gRPC API on this port (0 to disable)")
+	cmd.Flags().StringVar(&opts.evalHistory, "eval-history", "", "eval daemon output directory whose trends to serve at /eval/trends")
+	cmd.Flags().StringVar(&opts.roles, "roles", "", "YAML file giving users and groups the cataloger, reviewer or admin role (everyone is an admin when empty)")
+	cmd.Flags().StringVar(&opts.batchDir, "batch-dir", "", "Directory to export approved records to as batch files (disabled when empty)")
+	cmd.Flags().StringVar(&opts.batchFormat, "batch-format", batch.FormatMARC, "Batch file format: mrc (binary MARC) or xml (MARCXML collection)")
+	cmd.Flags().StringVar(&opts.batchCron, "batch-cron", "", "Cron schedule of batch exports, e.g. \"0 2 * * *\" (on request only when empty)")
+	cmd.Flags().BoolVar(&opts.marcFromImages, "marc-from-images", false, "Generate MARC from all of a session's page images in one request by default, instead of from their OCR text")
+
+	return cmd
+}
+
+// applyServeEnv sets flags not given on the command line from their environment variables.
+// This runs after .env is loaded, so .env values apply too.
+func applyServeEnv(cmd *cobra.Command) error {
+	for flag, env := range serveEnv {
+		v := os.Getenv(env)
+		if v == "" || cmd.Flags().Changed(flag) {
+			continue
+		}
+		if err := cmd.Flags().Set(flag, v); err != nil {
+			return fmt.Errorf("invalid %s: %w", env, err)
+		}
+	}
+	return nil
+}
+
+func runServer(opts serveOptions) error {
+	maxRequestSize, err := uploads.ParseSize(opts.maxRequestSize)
+	if err != nil || maxRequestSize <= 0 {
+		return fmt.Errorf("invalid --max-request-size %q", opts.maxRequestSize)
+	}
+	if (opts.tlsCert == "") != (opts.tlsKey == "") {
+		return fmt.Errorf("--tls-cert and --tls-key must be given together")
+	}
+	if opts.tlsCert != "" && len(opts.autocertDomains) > 0 {
+		return fmt.Errorf("use either --tls-cert/--tls-key or --autocert-domains, not both")
+	}
+
+	store := storage.New()
+	if opts.dataDir != "" {
+		store, err = storage.Open(opts.dataDir)
+		if err != nil {
+			return err
+		}
+	}
+	uploadStore, err := uploads.FromEnv(opts.uploadsDir)
+	if err != nil {
+		return err
+	}
+	handler := handlers.New(store, uploadStore)
+	handler.SetMaxRequestSize(maxRequestSize)
+	handler.SetMARCFromImages(opts.marcFromImages)
+	handler.SetTrustProxy(opts.trustProxy)
+	if opts.rateLimit > 0 {
+		handler.SetRateLimit(ratelimit.New(opts.rateLimit, opts.rateBurst))
+	}
+	var generations *ratelimit.Gate
+	if opts.maxGenerations > 0 {
+		generations = ratelimit.NewGate(opts.maxGenerations, opts.generationWait)
+		handler.SetGenerationLimit(generations)
+	}
+	if opts.evalHistory != "" {
+		handler.SetEvalHistory(opts.evalHistory)
+	}
+	var exporter *batch.Exporter
+	var batchSchedule *schedule.Schedule
+	if opts.batchDir != "" {
+		if exporter, err = batch.New(store, opts.batchDir, opts.batchFormat); err != nil {
+			return err
+		}
+		handler.SetBatchExporter(exporter)
+		if opts.batchCron != "" {
+			if batchSchedule, err = schedule.Parse(opts.batchCron); err != nil {
+				return fmt.Errorf("invalid --batch-cron: %w", err)
+			}
+		}
+	} else if opts.batchCron != "" {
+		return fmt.Errorf("--batch-cron requires --batch-dir")
+	}
+	var resolver *roles.Static
+	if opts.roles != "" {
+		var err error
+		if resolver, err = roles.Load(opts.roles); err != nil {
+			return err
+		}
+		handler.SetRoles(resolver)
+	}
+
+	server := &http.Server{
+		Addr:              net.JoinHostPort(opts.addr, strconv.Itoa(opts.port)),
+		Handler:           http.MaxBytesHandler(handler.Routes(), maxRequestSize),
+		ReadHeaderTimeout: 10 * time.Second,
+		ReadTimeout:       opts.readTimeout,
+		WriteTimeout:      opts.writeTimeout,
+		IdleTimeout:       opts.idleTimeout,
+	}
+
+	if len(opts.autocertDomains) > 0 {
+		manager := &autocert.Manager{
+			Prompt:     autocert.AcceptTOS,
+			HostPolicy: autocert.HostWhitelist(opts.autocertDomains...),
+			Cache:      autocert.DirCache(opts.autocertCache),
+		}
+		server.TLSConfig = manager.TLSConfig()
+	} else if opts.tlsCert != "" {
+		cert, err := tls.LoadX509KeyPair(opts.tlsCert, opts.tlsKey)
+		if err != nil {
+			return fmt.Errorf("failed to load TLS certificate: %w", err)
+		}
+		server.TLSConfig = &tls.Config{MinVersion: tls.VersionTLS12, Certificates: []tls.Certificate{cert}}
+	}
+
+	var grpcServer *grpc.Server
+	if opts.grpcPort > 0 {
+		var grpcOpts []grpc.ServerOption
+		if server.TLSConfig != nil {
+			grpcOpts = append(grpcOpts, grpc.Creds(credentials.NewTLS(server.TLSConfig)))
+		}
+		grpcService := grpcserver.New(generations)
+		if resolver != nil {
+			grpcService.SetRoles(resolver)
+		}
+		grpcServer = grpcService.Register(grpcOpts...)
+		grpcAddr := net.JoinHostPort(opts.addr, strconv.Itoa(opts.grpcPort))
+		lis, err := net.Listen("tcp", grpcAddr)
+		if err != nil {
+			return fmt.Errorf("failed to listen for gRPC: %w", err)
+		}
+		go func() {
+			slog.Info("Starting gRPC server", "addr", grpcAddr, "tls", server.TLSConfig != nil)
+			if err := grpcServer.Serve(lis); err != nil {
+				slog.Error("gRPC server failed", "error", err)
+			}
+		}()
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	go uploadStore.Run(ctx, handler.ReferencedUploads)
+	if batchSchedule != nil {
+		go exporter.Run(ctx, batchSchedule)
+	}
+
+	go func() {
+		<-ctx.Done()
+		slog.Info("Shutting down server")
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		if err := server.Shutdown(shutdownCtx); err != nil {
+			slog.Error("Server shutdown failed", "error", err)
+		}
+		if grpcServer != nil {
+			grpcServer.GracefulStop()
+		}
+	}()
+
+	if server.TLSConfig != nil {
+		slog.Info("Starting server", "addr", server.Addr, "tls", true, "autocert", strings.Join(opts.autocertDomains, ","), "uploads", uploadStore)
+		err = server.ListenAndServeTLS("", "")
+	} else {
+		slog.Info("Starting server", "addr", server.Addr, "uploads", uploadStore)
+		err = server.ListenAndServe()
+	}
+	if err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("server failed: %w", err)
+	}
+
+	return nil
+}
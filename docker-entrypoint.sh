@@ -2,4 +2,4 @@
 
 set -eou pipefail
 
-exec gosu cataloger /app/cataloger
+exec gosu cataloger /app/cataloger serve
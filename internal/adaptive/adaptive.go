@@ -0,0 +1,146 @@
+// Package adaptive limits the requests in flight to a provider to what it can take. The limit
+// grows while latency stays near the lowest seen and requests succeed, and is cut when latency
+// climbs or requests fail (additive increase, multiplicative decrease), so a local Ollama is
+// kept busy without queueing requests until they time out.
+package adaptive
+
+import (
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// Defaults for Options
+const (
+	DefaultMax       = 8
+	DefaultTolerance = 2.0
+)
+
+const (
+	smoothing     = 0.2  // Weight of a new latency sample in the moving average
+	baselineDrift = 0.01 // Share of the gap the baseline closes per sample, so it follows a slower provider
+	latencyCut    = 0.8  // Limit kept when latency climbs
+	errorCut      = 0.5  // Limit kept when a request fails
+)
+
+// Options configures a Limiter
+type Options struct {
+	Min       int     // Lowest limit; at least 1
+	Max       int     // Highest limit; Min when lower
+	Tolerance float64 // Average latency above the baseline times this counts as overload; DefaultTolerance when not above 1
+}
+
+// Limiter is a counting semaphore whose limit adapts to the latency and errors of the
+// requests it admits. With Min equal to Max it is a fixed semaphore.
+type Limiter struct {
+	opts Options
+
+	mu       sync.Mutex
+	cond     *sync.Cond
+	limit    float64
+	inFlight int
+	epoch    int  // Bumped on each cut; results of requests admitted earlier can't cut again
+	growing  bool // Slow start: the limit grows by one per success until the first cut
+	smoothed time.Duration
+	baseline time.Duration
+	peak     int
+	cuts     int
+}
+
+// Token is an admitted request, returned to Release
+type Token struct {
+	epoch int
+}
+
+// New returns a Limiter starting at opts.Min
+func New(opts Options) *Limiter {
+	opts.Min = max(opts.Min, 1)
+	opts.Max = max(opts.Max, opts.Min)
+	if opts.Tolerance <= 1 {
+		opts.Tolerance = DefaultTolerance
+	}
+	l := &Limiter{opts: opts, limit: float64(opts.Min), growing: true, peak: opts.Min}
+	l.cond = sync.NewCond(&l.mu)
+	return l
+}
+
+// Fixed returns a Limiter admitting n requests at a time
+func Fixed(n int) *Limiter {
+	return New(Options{Min: n, Max: n})
+}
+
+// Acquire waits until a request may start
+func (l *Limiter) Acquire() Token {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for l.inFlight >= int(l.limit) {
+		l.cond.Wait()
+	}
+	l.inFlight++
+	return Token{epoch: l.epoch}
+}
+
+// Release ends a request that took latency, adjusting the limit. failed reports a failure
+// that suggests overload, such as a timeout or server error; other failures should pass false.
+func (l *Limiter) Release(t Token, latency time.Duration, failed bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.inFlight--
+	defer l.cond.Broadcast()
+
+	if failed {
+		l.cut(t, errorCut, "request failed")
+		return
+	}
+
+	if l.smoothed == 0 {
+		l.smoothed = latency
+	} else {
+		l.smoothed += time.Duration(smoothing * float64(latency-l.smoothed))
+	}
+	if l.baseline == 0 || l.smoothed < l.baseline {
+		l.baseline = l.smoothed
+	} else {
+		l.baseline += time.Duration(baselineDrift * float64(l.smoothed-l.baseline))
+	}
+
+	if float64(l.smoothed) > l.opts.Tolerance*float64(l.baseline) {
+		l.cut(t, latencyCut, "latency rising")
+		return
+	}
+	if l.growing {
+		l.limit++
+	} else {
+		l.limit += 1 / l.limit // About one more per round of requests
+	}
+	l.limit = min(l.limit, float64(l.opts.Max))
+	l.peak = max(l.peak, int(l.limit))
+}
+
+// cut lowers the limit by factor, once for the requests admitted before the last cut
+func (l *Limiter) cut(t Token, factor float64, reason string) {
+	if t.epoch != l.epoch || l.opts.Min == l.opts.Max {
+		return
+	}
+	before := int(l.limit)
+	l.limit = max(l.limit*factor, float64(l.opts.Min))
+	l.growing = false
+	l.epoch++
+	l.cuts++
+	l.smoothed = 0 // Measured afresh at the new limit
+	slog.Info("Lowering concurrency", "reason", reason, "from", before, "to", int(l.limit), "latency", l.smoothed.Round(time.Millisecond), "baseline", l.baseline.Round(time.Millisecond))
+}
+
+// Limit returns the current limit
+func (l *Limiter) Limit() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return int(l.limit)
+}
+
+// Stats returns the highest limit reached and the number of cuts
+func (l *Limiter) Stats() (peak, cuts int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.peak, l.cuts
+}
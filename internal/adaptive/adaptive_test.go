@@ -0,0 +1,67 @@
+package adaptive
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestLimiterGrowsAndCuts(t *testing.T) {
+	l := New(Options{Min: 1, Max: 4})
+	for range 10 {
+		l.Release(l.Acquire(), 100*time.Millisecond, false)
+	}
+	if got := l.Limit(); got != 4 {
+		t.Fatalf("limit after fast successes = %d, want the max 4", got)
+	}
+
+	// Two requests admitted together fail: only the first cuts
+	a, b := l.Acquire(), l.Acquire()
+	l.Release(a, time.Second, true)
+	l.Release(b, time.Second, true)
+	if got := l.Limit(); got != 2 {
+		t.Errorf("limit after failures = %d, want 2", got)
+	}
+
+	// Latency far above the baseline cuts too
+	for range 5 {
+		l.Release(l.Acquire(), 10*time.Second, false)
+	}
+	if got := l.Limit(); got != 1 {
+		t.Errorf("limit after slow requests = %d, want the min 1", got)
+	}
+	if peak, cuts := l.Stats(); peak != 4 || cuts < 2 {
+		t.Errorf("Stats() = %d, %d", peak, cuts)
+	}
+}
+
+func TestLimiterBoundsInFlight(t *testing.T) {
+	l := Fixed(3)
+	var inFlight, most atomic.Int32
+	var wg sync.WaitGroup
+	for range 20 {
+		tok := l.Acquire()
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			n := inFlight.Add(1)
+			for {
+				m := most.Load()
+				if n <= m || most.CompareAndSwap(m, n) {
+					break
+				}
+			}
+			time.Sleep(time.Millisecond)
+			inFlight.Add(-1)
+			l.Release(tok, time.Millisecond, true) // A fixed limiter never cuts
+		}()
+	}
+	wg.Wait()
+	if got := most.Load(); got > 3 {
+		t.Errorf("%d requests in flight, want at most 3", got)
+	}
+	if got := l.Limit(); got != 3 {
+		t.Errorf("fixed limit changed to %d", got)
+	}
+}
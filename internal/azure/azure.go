@@ -0,0 +1,257 @@
+package azure
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/lehigh-university-libraries/cataloger/internal/providers"
+)
+
+const (
+	defaultAPIVersion    = "2024-10-21"
+	defaultAuthorityHost = "https://login.microsoftonline.com"
+	// tokenScope is the Microsoft Entra ID scope for Azure OpenAI
+	tokenScope = "https://cognitiveservices.azure.com/.default"
+)
+
+// Registration registers the provider as "azure-openai"; its default model is the
+// AZURE_DEPLOYMENT deployment
+var Registration = providers.Registration{
+	Name:         "azure-openai",
+	New:          func() providers.Provider { return New() },
+	DefaultModel: providers.EnvModel("AZURE_DEPLOYMENT", ""),
+	Configured:   Configured,
+	Vision:       true,
+}
+
+// AzureOpenAI is a provider for Azure OpenAI, addressing models by deployment name
+type AzureOpenAI struct{}
+
+// New returns a new Azure OpenAI provider
+//
+// Configured with:
+//   - AZURE_ENDPOINT: resource endpoint, e.g. https://your-instance.openai.azure.com (required)
+//   - AZURE_DEPLOYMENT: deployment used when no model is given
+//   - AZURE_API_VERSION: api-version query parameter (default 2024-10-21)
+//   - AZURE_API_KEY: API key; or Microsoft Entra ID (AAD) auth with either
+//   - AZURE_AD_TOKEN: a bearer token, or
+//   - AZURE_TENANT_ID, AZURE_CLIENT_ID, AZURE_CLIENT_SECRET: a service principal whose
+//     tokens are requested and renewed as needed
+func New() *AzureOpenAI {
+	return &AzureOpenAI{}
+}
+
+// Configured reports whether an endpoint and credentials are set
+func Configured() bool {
+	if os.Getenv("AZURE_ENDPOINT") == "" {
+		return false
+	}
+	return os.Getenv("AZURE_API_KEY") != "" || os.Getenv("AZURE_AD_TOKEN") != "" ||
+		(os.Getenv("AZURE_TENANT_ID") != "" && os.Getenv("AZURE_CLIENT_ID") != "" && os.Getenv("AZURE_CLIENT_SECRET") != "")
+}
+
+// ExtractText extracts text from the given prompt with the deployment named by config.Model
+func (a *AzureOpenAI) ExtractText(ctx context.Context, config providers.Config) (string, error) {
+	return a.send(ctx, config, config.Prompt)
+}
+
+// GenerateFromImage sends an image with a prompt, for title page OCR
+func (a *AzureOpenAI) GenerateFromImage(ctx context.Context, config providers.Config, image []byte) (string, error) {
+	return a.GenerateFromImages(ctx, config, [][]byte{image})
+}
+
+// GenerateFromImages sends several images after the prompt, in one message
+func (a *AzureOpenAI) GenerateFromImages(ctx context.Context, config providers.Config, images [][]byte) (string, error) {
+	content := []map[string]any{{"type": "text", "text": config.Prompt}}
+	for _, image := range images {
+		mediaType := http.DetectContentType(image)
+		switch mediaType {
+		case "image/jpeg", "image/png", "image/gif", "image/webp":
+		default:
+			return "", fmt.Errorf("unsupported image type for Azure OpenAI: %s", mediaType)
+		}
+		content = append(content, map[string]any{"type": "image_url", "image_url": map[string]string{"url": "data:" + mediaType + ";base64," + base64.StdEncoding.EncodeToString(image)}})
+	}
+	return a.send(ctx, config, content)
+}
+
+func (a *AzureOpenAI) send(ctx context.Context, config providers.Config, content any) (string, error) {
+	endpoint := strings.TrimSuffix(os.Getenv("AZURE_ENDPOINT"), "/")
+	if endpoint == "" {
+		return "", fmt.Errorf("AZURE_ENDPOINT environment variable not set")
+	}
+	deployment := config.Model
+	if deployment == "" {
+		deployment = os.Getenv("AZURE_DEPLOYMENT")
+	}
+	if deployment == "" {
+		return "", fmt.Errorf("no Azure OpenAI deployment: pass a model or set AZURE_DEPLOYMENT")
+	}
+	apiVersion := os.Getenv("AZURE_API_VERSION")
+	if apiVersion == "" {
+		apiVersion = defaultAPIVersion
+	}
+
+	body := map[string]any{
+		"messages":    []map[string]any{{"role": "user", "content": content}},
+		"temperature": config.Temperature,
+	}
+	switch {
+	case config.ResponseSchema != nil:
+		body["response_format"] = map[string]any{
+			"type":        "json_schema",
+			"json_schema": map[string]any{"name": "metadata", "strict": true, "schema": config.ResponseSchema},
+		}
+	case config.JSONMode:
+		body["response_format"] = map[string]string{"type": "json_object"}
+	}
+	requestBody, err := json.Marshal(body)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal request body: %w", err)
+	}
+
+	u := endpoint + "/openai/deployments/" + url.PathEscape(deployment) + "/chat/completions?api-version=" + url.QueryEscape(apiVersion)
+	req, err := http.NewRequestWithContext(ctx, "POST", u, bytes.NewBuffer(requestBody))
+	if err != nil {
+		return "", fmt.Errorf("failed to create new request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if err := authorize(ctx, req); err != nil {
+		return "", err
+	}
+
+	resp, err := providers.HTTPClient().Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		if resp.StatusCode == http.StatusBadRequest && strings.Contains(string(body), "content_filter") {
+			return "", &providers.RefusalError{Provider: "azure-openai", Category: providers.RefusalContentFilter, Reason: "prompt rejected by the content filter"}
+		}
+		return "", fmt.Errorf("received non-200 status code: %d - %s", resp.StatusCode, string(body))
+	}
+
+	var response struct {
+		Choices []struct {
+			Message struct {
+				Content string `json:"content"`
+				Refusal string `json:"refusal"`
+			} `json:"message"`
+			FinishReason string `json:"finish_reason"`
+		} `json:"choices"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return "", fmt.Errorf("failed to decode response body: %w", err)
+	}
+	if len(response.Choices) == 0 {
+		return "", fmt.Errorf("no choices returned from Azure OpenAI")
+	}
+
+	choice := response.Choices[0]
+	if choice.Message.Refusal != "" {
+		return "", &providers.RefusalError{Provider: "azure-openai", Category: providers.RefusalModel, Reason: choice.Message.Refusal}
+	}
+	if choice.FinishReason == "content_filter" {
+		return "", &providers.RefusalError{Provider: "azure-openai", Category: providers.RefusalContentFilter, Reason: "response stopped by the content filter"}
+	}
+	return choice.Message.Content, nil
+}
+
+// authorize sets the api-key header, or a bearer token for Entra ID auth
+func authorize(ctx context.Context, req *http.Request) error {
+	if key := os.Getenv("AZURE_API_KEY"); key != "" {
+		req.Header.Set("api-key", key)
+		return nil
+	}
+	if token := os.Getenv("AZURE_AD_TOKEN"); token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+		return nil
+	}
+	tenant, clientID, secret := os.Getenv("AZURE_TENANT_ID"), os.Getenv("AZURE_CLIENT_ID"), os.Getenv("AZURE_CLIENT_SECRET")
+	if tenant == "" || clientID == "" || secret == "" {
+		return fmt.Errorf("no Azure OpenAI credentials: set AZURE_API_KEY, AZURE_AD_TOKEN, or AZURE_TENANT_ID, AZURE_CLIENT_ID and AZURE_CLIENT_SECRET")
+	}
+	token, err := tokens.get(ctx, tenant, clientID, secret)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	return nil
+}
+
+// tokenCache keeps a service principal's access token until shortly before it expires
+type tokenCache struct {
+	mu      sync.Mutex
+	key     string
+	token   string
+	expires time.Time
+}
+
+var tokens tokenCache
+
+func (c *tokenCache) get(ctx context.Context, tenant, clientID, secret string) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	key := tenant + "/" + clientID
+	if c.key == key && time.Now().Before(c.expires) {
+		return c.token, nil
+	}
+
+	authority := strings.TrimSuffix(os.Getenv("AZURE_AUTHORITY_HOST"), "/")
+	if authority == "" {
+		authority = defaultAuthorityHost
+	}
+	form := url.Values{
+		"grant_type":    {"client_credentials"},
+		"client_id":     {clientID},
+		"client_secret": {secret},
+		"scope":         {tokenScope},
+	}
+	req, err := http.NewRequestWithContext(ctx, "POST", authority+"/"+url.PathEscape(tenant)+"/oauth2/v2.0/token", strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", fmt.Errorf("failed to create token request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	client := providers.HTTPClient()
+	client.Timeout = 30 * time.Second
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to request Entra ID token: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return "", fmt.Errorf("entra ID token request failed with status %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	var token struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int    `json:"expires_in"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&token); err != nil {
+		return "", fmt.Errorf("failed to decode Entra ID token: %w", err)
+	}
+	if token.AccessToken == "" {
+		return "", fmt.Errorf("entra ID token response had no access_token")
+	}
+
+	// Renew a minute early so a token doesn't expire in flight
+	c.key, c.token = key, token.AccessToken
+	c.expires = time.Now().Add(time.Duration(token.ExpiresIn)*time.Second - time.Minute)
+	return c.token, nil
+}
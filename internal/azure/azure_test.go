@@ -0,0 +1,87 @@
+package azure
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/lehigh-university-libraries/cataloger/internal/providers"
+)
+
+func TestExtractTextWithKey(t *testing.T) {
+	var got map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/openai/deployments/catalog-4o/chat/completions" || r.URL.Query().Get("api-version") != "2025-01-01-preview" {
+			t.Errorf("unexpected request %s", r.URL)
+		}
+		if r.Header.Get("api-key") != "test-key" {
+			t.Errorf("api-key = %q", r.Header.Get("api-key"))
+		}
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Error(err)
+		}
+		_, _ = w.Write([]byte(`{"choices":[{"message":{"content":"{\"title\":\"Bridges\"}"},"finish_reason":"stop"}]}`))
+	}))
+	defer server.Close()
+	t.Setenv("AZURE_ENDPOINT", server.URL+"/")
+	t.Setenv("AZURE_API_KEY", "test-key")
+	t.Setenv("AZURE_API_VERSION", "2025-01-01-preview")
+	t.Setenv("AZURE_DEPLOYMENT", "catalog-4o")
+
+	text, err := New().ExtractText(context.Background(), providers.Config{Prompt: "p", JSONMode: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if text != `{"title":"Bridges"}` {
+		t.Errorf("ExtractText() = %s", text)
+	}
+	format, _ := got["response_format"].(map[string]any)
+	if format["type"] != "json_object" {
+		t.Errorf("response_format = %v, want json_object", got["response_format"])
+	}
+	if _, ok := got["model"]; ok {
+		t.Error("the deployment, not the request body, selects the model")
+	}
+}
+
+func TestServicePrincipalToken(t *testing.T) {
+	tokenRequests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/tenant-1/oauth2/v2.0/token":
+			tokenRequests++
+			if r.FormValue("client_id") != "client-1" || r.FormValue("scope") != tokenScope {
+				t.Errorf("token request form = %v", r.Form)
+			}
+			_, _ = w.Write([]byte(`{"access_token":"aad-token","expires_in":3600}`))
+		default:
+			if r.Header.Get("Authorization") != "Bearer aad-token" {
+				t.Errorf("Authorization = %q", r.Header.Get("Authorization"))
+			}
+			_, _ = w.Write([]byte(`{"choices":[{"message":{"content":"","refusal":"I can't help with that"}}]}`))
+		}
+	}))
+	defer server.Close()
+	tokens = tokenCache{}
+	t.Setenv("AZURE_ENDPOINT", server.URL)
+	t.Setenv("AZURE_API_KEY", "")
+	t.Setenv("AZURE_AD_TOKEN", "")
+	t.Setenv("AZURE_AUTHORITY_HOST", server.URL)
+	t.Setenv("AZURE_TENANT_ID", "tenant-1")
+	t.Setenv("AZURE_CLIENT_ID", "client-1")
+	t.Setenv("AZURE_CLIENT_SECRET", "secret")
+
+	for range 2 {
+		_, err := New().ExtractText(context.Background(), providers.Config{Model: "catalog-4o", Prompt: "p"})
+		var refusal *providers.RefusalError
+		if !errors.As(err, &refusal) || refusal.Category != providers.RefusalModel {
+			t.Fatalf("err = %v, want a model refusal", err)
+		}
+	}
+	if tokenRequests != 1 {
+		t.Errorf("token requested %d times, want it cached", tokenRequests)
+	}
+}
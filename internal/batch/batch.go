@@ -0,0 +1,194 @@
+// Package batch exports approved records as one MARC file, for library systems that load
+// records in batches rather than through an API
+package batch
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/lehigh-university-libraries/cataloger/internal/marc"
+	"github.com/lehigh-university-libraries/cataloger/internal/models"
+	"github.com/lehigh-university-libraries/cataloger/internal/schedule"
+	"github.com/lehigh-university-libraries/cataloger/internal/storage"
+)
+
+// Batch file formats
+const (
+	FormatMARC = "mrc" // Binary MARC (ISO 2709) in UTF-8
+	FormatXML  = "xml" // MARCXML <collection>
+)
+
+// Formats lists the batch file formats
+var Formats = []string{FormatMARC, FormatXML}
+
+// ScheduledActor is who scheduled exports are attributed to in session histories
+const ScheduledActor = "scheduled-batch"
+
+// Exporter writes the approved sessions not yet in a batch to a file in its directory
+type Exporter struct {
+	store  *storage.SessionStore
+	dir    string
+	format string
+	mu     sync.Mutex // One export at a time, so no session lands in two batches
+}
+
+// Result describes an export
+type Result struct {
+	File     string            `json:"file,omitempty"` // Name of the batch file; empty when no session was exported
+	Sessions []string          `json:"sessions"`
+	Skipped  map[string]string `json:"skipped,omitempty"` // Sessions whose record could not be written, and why
+}
+
+// New returns an exporter writing batch files of the format to dir
+func New(store *storage.SessionStore, dir, format string) (*Exporter, error) {
+	if !slices.Contains(Formats, format) {
+		return nil, fmt.Errorf("unknown batch format %q (%s)", format, strings.Join(Formats, ", "))
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create batch directory: %w", err)
+	}
+	return &Exporter{store: store, dir: dir, format: format}, nil
+}
+
+// Export writes every approved session not yet in a batch to a new batch file, oldest first,
+// and records the file on each session and in its audit log as done by actor. No file is
+// written when there is nothing to export. Records that can't be written are skipped and
+// left for the next export.
+func (e *Exporter) Export(actor string) (Result, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	var pending []*models.CatalogSession
+	for _, session := range e.store.GetAll() {
+		if session.Status == models.StatusApproved && session.Batch == "" {
+			pending = append(pending, session)
+		}
+	}
+	slices.SortFunc(pending, func(a, b *models.CatalogSession) int {
+		if c := a.CreatedAt.Compare(b.CreatedAt); c != 0 {
+			return c
+		}
+		return strings.Compare(a.ID, b.ID)
+	})
+
+	result := Result{Sessions: []string{}}
+	var records []*marc.Record
+	var data []byte
+	for _, session := range pending {
+		rec, err := marc.ParseXML([]byte(session.MARC))
+		if err == nil && e.format == FormatMARC {
+			var raw []byte
+			if raw, err = rec.ISO2709(); err == nil {
+				data = append(data, raw...)
+			}
+		}
+		if err != nil {
+			if result.Skipped == nil {
+				result.Skipped = make(map[string]string)
+			}
+			result.Skipped[session.ID] = err.Error()
+			slog.Warn("Skipping record in batch export", "session", session.ID, "error", err)
+			continue
+		}
+		records = append(records, rec)
+		result.Sessions = append(result.Sessions, session.ID)
+	}
+	if len(records) == 0 {
+		return result, nil
+	}
+	if e.format == FormatXML {
+		var err error
+		if data, err = marc.CollectionXML(records); err != nil {
+			return Result{}, err
+		}
+	}
+
+	now := time.Now()
+	name, err := e.write(now, data)
+	if err != nil {
+		return Result{}, err
+	}
+	result.File = name
+	for _, session := range pending {
+		if !slices.Contains(result.Sessions, session.ID) {
+			continue
+		}
+		// Recorded on the stored session, which may have changed while the file was written
+		unlock := e.store.Lock(session.ID)
+		if current, ok := e.store.Get(session.ID); ok {
+			current.Batch = name
+			e.store.Set(session.ID, current)
+		}
+		unlock()
+		e.store.AppendEvent(session.ID, models.AuditEvent{
+			Time:    now,
+			Actor:   actor,
+			Action:  models.ActionBatch,
+			Details: map[string]string{"file": name, "format": e.format},
+		})
+	}
+	slog.Info("Exported batch", "file", name, "records", len(result.Sessions), "skipped", len(result.Skipped))
+	return result, nil
+}
+
+// write writes a new batch file through a temp file, naming it by the time of the export
+func (e *Exporter) write(now time.Time, data []byte) (string, error) {
+	stamp := now.UTC().Format("20060102T150405Z")
+	name := fmt.Sprintf("approved-%s.%s", stamp, e.format)
+	for i := 2; ; i++ {
+		if _, err := os.Stat(filepath.Join(e.dir, name)); os.IsNotExist(err) {
+			break
+		}
+		name = fmt.Sprintf("approved-%s-%d.%s", stamp, i, e.format)
+	}
+
+	path := filepath.Join(e.dir, name)
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return "", fmt.Errorf("failed to write batch file: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return "", fmt.Errorf("failed to replace batch file: %w", err)
+	}
+	return name, nil
+}
+
+// Path returns the path of a batch file written by the exporter, or false when there is none
+func (e *Exporter) Path(name string) (string, bool) {
+	if name != filepath.Base(name) || !strings.HasPrefix(name, "approved-") || filepath.Ext(name) != "."+e.format {
+		return "", false
+	}
+	path := filepath.Join(e.dir, name)
+	if _, err := os.Stat(path); err != nil {
+		return "", false
+	}
+	return path, true
+}
+
+// Run exports on the schedule until ctx is done
+func (e *Exporter) Run(ctx context.Context, sched *schedule.Schedule) {
+	for {
+		next := sched.Next(time.Now())
+		if next.IsZero() {
+			slog.Error("Batch export schedule never matches", "schedule", sched.String())
+			return
+		}
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+		if _, err := e.Export(ScheduledActor); err != nil {
+			slog.Error("Scheduled batch export failed", "error", err)
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package batch
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/lehigh-university-libraries/cataloger/internal/marc"
+	"github.com/lehigh-university-libraries/cataloger/internal/models"
+	"github.com/lehigh-university-libraries/cataloger/internal/storage"
+)
+
+// record returns a MARCXML record with the leader and title
+func record(leader, title string) string {
+	return `<record xmlns="http://www.loc.gov/MARC21/slim"><leader>` + leader +
+		`</leader><datafield tag="245" ind1="1" ind2="0"><subfield code="a">` + title + `</subfield></datafield></record>`
+}
+
+func TestExport(t *testing.T) {
+	store := storage.New()
+	created := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	for _, s := range []struct {
+		id, status, marc string
+		age              time.Duration
+	}{
+		{"b", models.StatusApproved, record("00000nam a2200000 a 4500", "Second"), time.Hour},
+		{"a", models.StatusApproved, record("00000nam a2200000 a 4500", "First"), 2 * time.Hour},
+		{"c", models.StatusInReview, record("00000nam a2200000 a 4500", "Unreviewed"), 3 * time.Hour},
+		{"d", models.StatusApproved, record("short", "Bad leader"), 4 * time.Hour},
+	} {
+		store.Set(s.id, &models.CatalogSession{ID: s.id, Status: s.status, MARC: s.marc, CreatedAt: created.Add(-s.age)})
+	}
+
+	dir := t.TempDir()
+	e, err := New(store, dir, FormatMARC)
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := e.Export("jdoe")
+	if err != nil {
+		t.Fatalf("Export failed: %v", err)
+	}
+	if len(result.Sessions) != 2 || result.Sessions[0] != "a" || result.Sessions[1] != "b" {
+		t.Errorf("Exported %v, want [a b]", result.Sessions)
+	}
+	if _, ok := result.Skipped["d"]; !ok || len(result.Skipped) != 1 {
+		t.Errorf("Skipped %v, want d", result.Skipped)
+	}
+
+	path, ok := e.Path(result.File)
+	if !ok {
+		t.Fatalf("Batch file %q not found", result.File)
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	reader, err := marc.NewReader(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"First", "Second"} {
+		rec, err := reader.Next()
+		if err != nil {
+			t.Fatalf("Reading batch: %v", err)
+		}
+		if got := rec.SubfieldValue("245", "a"); got != want {
+			t.Errorf("245 $a = %q, want %q", got, want)
+		}
+	}
+
+	if session, _ := store.Get("a"); session.Batch != result.File {
+		t.Errorf("Session batch = %q, want %q", session.Batch, result.File)
+	}
+	if history := store.History("a"); len(history) != 1 || history[0].Action != models.ActionBatch || history[0].Actor != "jdoe" {
+		t.Errorf("History = %+v", history)
+	}
+
+	again, err := e.Export("jdoe")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again.File != "" || len(again.Sessions) != 0 {
+		t.Errorf("Second export = %+v, want nothing exported", again)
+	}
+	if _, ok := e.Path(filepath.Join("..", result.File)); ok {
+		t.Error("Path accepted a name outside the batch directory")
+	}
+}
@@ -0,0 +1,195 @@
+// Package bundle packs a cataloging session into a zip file that another instance can
+// import, so a record started at one library can be finished at another. A bundle holds the
+// session (OCR text, generated or edited MARC, corrections), its audit log and its images:
+//
+//	manifest.json    format version, export time and source instance
+//	session.json     the session
+//	history.jsonl    the audit log, oldest first
+//	record.xml       the session's MARCXML, when it has any, for reading without importing
+//	images/<name>    each image, named as in the uploads store
+package bundle
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"time"
+
+	"github.com/lehigh-university-libraries/cataloger/internal/models"
+	"github.com/lehigh-university-libraries/cataloger/internal/utils"
+)
+
+// Version is the bundle format written by Write
+const Version = 1
+
+// maxEntrySize limits each file read from a bundle
+const maxEntrySize = 64 << 20
+
+// Manifest describes a bundle
+type Manifest struct {
+	Version    int       `json:"version"`
+	ExportedAt time.Time `json:"exported_at"`
+	Source     string    `json:"source,omitempty"` // Instance the session was exported from
+	SessionID  string    `json:"session_id"`
+}
+
+// Bundle is an exported session
+type Bundle struct {
+	Manifest Manifest
+	Session  *models.CatalogSession
+	History  []models.AuditEvent
+	Images   map[string][]byte // By file name (the base of each image's ImagePath)
+}
+
+// Write writes b as a zip file
+func Write(w io.Writer, b *Bundle) error {
+	if b.Session == nil {
+		return errors.New("bundle has no session")
+	}
+	b.Manifest.Version = Version
+	b.Manifest.SessionID = b.Session.ID
+
+	zw := zip.NewWriter(w)
+	writeJSON := func(name string, v any) error {
+		data, err := json.MarshalIndent(v, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal %s: %w", name, err)
+		}
+		return writeEntry(zw, name, data, b.Manifest.ExportedAt)
+	}
+
+	if err := writeJSON("manifest.json", b.Manifest); err != nil {
+		return err
+	}
+	if err := writeJSON("session.json", b.Session); err != nil {
+		return err
+	}
+	var history bytes.Buffer
+	for _, event := range b.History {
+		data, err := json.Marshal(event)
+		if err != nil {
+			return fmt.Errorf("failed to marshal audit event: %w", err)
+		}
+		history.Write(append(data, '\n'))
+	}
+	if err := writeEntry(zw, "history.jsonl", history.Bytes(), b.Manifest.ExportedAt); err != nil {
+		return err
+	}
+	if b.Session.MARC != "" {
+		if err := writeEntry(zw, "record.xml", []byte(b.Session.MARC), b.Manifest.ExportedAt); err != nil {
+			return err
+		}
+	}
+	for _, img := range b.Session.Images {
+		name := filepath.Base(img.ImagePath)
+		data, ok := b.Images[name]
+		if !ok {
+			return fmt.Errorf("missing image %s", name)
+		}
+		if err := writeEntry(zw, "images/"+name, data, b.Manifest.ExportedAt); err != nil {
+			return err
+		}
+	}
+
+	if err := zw.Close(); err != nil {
+		return fmt.Errorf("failed to write bundle: %w", err)
+	}
+	return nil
+}
+
+func writeEntry(zw *zip.Writer, name string, data []byte, modified time.Time) error {
+	f, err := zw.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Deflate, Modified: modified})
+	if err != nil {
+		return fmt.Errorf("failed to add %s to bundle: %w", name, err)
+	}
+	if _, err := f.Write(data); err != nil {
+		return fmt.Errorf("failed to write %s to bundle: %w", name, err)
+	}
+	return nil
+}
+
+// Read reads a bundle from a zip file, checking that it holds every image of its session and
+// that each image matches its content-hash ID
+func Read(r io.ReaderAt, size int64) (*Bundle, error) {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open bundle: %w", err)
+	}
+	files := make(map[string]*zip.File, len(zr.File))
+	for _, f := range zr.File {
+		files[f.Name] = f
+	}
+	read := func(name string) ([]byte, error) {
+		f, ok := files[name]
+		if !ok {
+			return nil, fmt.Errorf("bundle has no %s", name)
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return nil, fmt.Errorf("failed to open %s: %w", name, err)
+		}
+		defer rc.Close()
+		data, err := io.ReadAll(io.LimitReader(rc, maxEntrySize+1))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", name, err)
+		}
+		if len(data) > maxEntrySize {
+			return nil, fmt.Errorf("%s is larger than %d bytes", name, maxEntrySize)
+		}
+		return data, nil
+	}
+
+	b := &Bundle{Images: make(map[string][]byte)}
+	data, err := read("manifest.json")
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &b.Manifest); err != nil {
+		return nil, fmt.Errorf("failed to parse manifest: %w", err)
+	}
+	if b.Manifest.Version > Version {
+		return nil, fmt.Errorf("bundle version %d is newer than this version of cataloger reads (%d)", b.Manifest.Version, Version)
+	}
+
+	if data, err = read("session.json"); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &b.Session); err != nil {
+		return nil, fmt.Errorf("failed to parse session: %w", err)
+	}
+	if b.Session == nil || b.Session.ID == "" {
+		return nil, errors.New("bundle session has no ID")
+	}
+
+	if data, err = read("history.jsonl"); err != nil {
+		return nil, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	for dec.More() {
+		var event models.AuditEvent
+		if err := dec.Decode(&event); err != nil {
+			return nil, fmt.Errorf("failed to parse audit log: %w", err)
+		}
+		b.History = append(b.History, event)
+	}
+
+	for _, img := range b.Session.Images {
+		name := filepath.Base(img.ImagePath)
+		if name == "." || name == ".." || name == string(filepath.Separator) {
+			return nil, fmt.Errorf("invalid image name %q", name)
+		}
+		data, err := read("images/" + name)
+		if err != nil {
+			return nil, err
+		}
+		if sum := utils.CalculateDataMD5(data); sum != img.ID {
+			return nil, fmt.Errorf("image %s does not match its ID %s", name, img.ID)
+		}
+		b.Images[name] = data
+	}
+	return b, nil
+}
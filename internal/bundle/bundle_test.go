@@ -0,0 +1,124 @@
+package bundle
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/lehigh-university-libraries/cataloger/internal/models"
+	"github.com/lehigh-university-libraries/cataloger/internal/utils"
+)
+
+func testBundle() *Bundle {
+	image := []byte("title page")
+	id := utils.CalculateDataMD5(image)
+	return &Bundle{
+		Manifest: Manifest{ExportedAt: time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC), Source: "branch"},
+		Session: &models.CatalogSession{
+			ID:     "abc123",
+			Images: []models.ImageItem{{ID: id, ImagePath: "/uploads/" + id + ".jpg", ImageType: "title_page", OCRText: "WALDEN"}},
+			MARC:   "<record/>",
+		},
+		History: []models.AuditEvent{
+			{Actor: "jdoe", Action: models.ActionUpload},
+			{Actor: "jdoe", Action: models.ActionGenerate, Provider: "ollama"},
+		},
+		Images: map[string][]byte{id + ".jpg": image},
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Write(&buf, testBundle()); err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := Read(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b.Manifest.Version != Version || b.Manifest.Source != "branch" || b.Manifest.SessionID != "abc123" {
+		t.Errorf("manifest = %+v", b.Manifest)
+	}
+	if b.Session.ID != "abc123" || b.Session.MARC != "<record/>" || b.Session.Images[0].OCRText != "WALDEN" {
+		t.Errorf("session = %+v", b.Session)
+	}
+	if len(b.History) != 2 || b.History[1].Provider != "ollama" {
+		t.Errorf("history = %+v", b.History)
+	}
+	for name, data := range testBundle().Images {
+		if !bytes.Equal(b.Images[name], data) {
+			t.Errorf("image %s = %q", name, b.Images[name])
+		}
+	}
+}
+
+func TestReadRejects(t *testing.T) {
+	tests := map[string]func(*Bundle) []byte{
+		"missing image": func(b *Bundle) []byte {
+			var buf bytes.Buffer
+			b.Session.Images = append(b.Session.Images, models.ImageItem{ID: "x", ImagePath: "x.jpg"})
+			b.Images["x.jpg"] = []byte("x")
+			_ = Write(&buf, b)
+			return rewrite(t, buf.Bytes(), "images/x.jpg", nil)
+		},
+		"tampered image": func(b *Bundle) []byte {
+			var buf bytes.Buffer
+			for name := range b.Images {
+				b.Images[name] = []byte("another page")
+			}
+			_ = Write(&buf, b)
+			return buf.Bytes()
+		},
+		"newer version": func(b *Bundle) []byte {
+			var buf bytes.Buffer
+			_ = Write(&buf, b)
+			return rewrite(t, buf.Bytes(), "manifest.json", []byte(`{"version": 99}`))
+		},
+	}
+	for name, build := range tests {
+		t.Run(name, func(t *testing.T) {
+			data := build(testBundle())
+			if _, err := Read(bytes.NewReader(data), int64(len(data))); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
+
+// rewrite copies a zip file, replacing the content of the named entry, or dropping it when
+// content is nil
+func rewrite(t *testing.T, data []byte, name string, content []byte) []byte {
+	t.Helper()
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, f := range zr.File {
+		if f.Name == name && content == nil {
+			continue
+		}
+		w, err := zw.Create(f.Name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if f.Name == name {
+			_, _ = w.Write(content)
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, _ = io.Copy(w, rc)
+		rc.Close()
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
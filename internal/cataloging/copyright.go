@@ -0,0 +1,207 @@
+package cataloging
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/lehigh-university-libraries/cataloger/internal/eval/failure"
+	"github.com/lehigh-university-libraries/cataloger/internal/eval/metadata"
+	"github.com/lehigh-university-libraries/cataloger/internal/identifiers"
+	"github.com/lehigh-university-libraries/cataloger/internal/images"
+	"github.com/lehigh-university-libraries/cataloger/internal/marc"
+	"github.com/lehigh-university-libraries/cataloger/internal/prompts"
+	"github.com/lehigh-university-libraries/cataloger/internal/providers"
+)
+
+// copyrightResponseSchema describes the JSON object requested by the copyright page prompt
+var copyrightResponseSchema = providers.SchemaFor(metadata.CopyrightMetadata{})
+
+// ExtractCopyrightMetadata runs the copyright page pass on the OCR text of a copyright page:
+// copyright date, printing history, LCCN, ISBNs and the CIP data block
+func (s *Service) ExtractCopyrightMetadata(ctx context.Context, ocrText, provider, model string) (metadata.CopyrightMetadata, error) {
+	systemPrompt, err := s.prompts.Get(prompts.CopyrightPage)
+	if err != nil {
+		return metadata.CopyrightMetadata{}, err
+	}
+	userPrompt := fmt.Sprintf("Here is the OCR text from a book's copyright page:\n\n%s\n\nExtract the copyright page data as JSON.", ocrText)
+	data, _, err := s.extractJSON(ctx, systemPrompt, userPrompt, copyrightResponseSchema, provider, model, nil)
+	if err != nil {
+		return metadata.CopyrightMetadata{}, err
+	}
+
+	var cm metadata.CopyrightMetadata
+	data = strings.TrimSpace(data)
+	data = strings.TrimSuffix(strings.TrimPrefix(strings.TrimPrefix(data, "```json"), "```"), "```")
+	if err := json.Unmarshal([]byte(strings.TrimSpace(data)), &cm); err != nil {
+		return cm, failure.Wrap(failure.ParseFailure, fmt.Errorf("failed to parse copyright page JSON: %w", err))
+	}
+	return cm, nil
+}
+
+var (
+	cipNumberPattern = regexp.MustCompile(`^\s*(\d+|[IVX]+)\.\s*`)
+	periodPattern    = regexp.MustCompile(`^\d{1,2}(st|nd|rd|th) century$|^\d{3,4}(-\d{0,4})?$|^To \d{3,4}$`)
+	lcClassPattern   = regexp.MustCompile(`^([A-Z]{1,3}\s?\d+(?:\.\d+)?)\s*(.*)$`)
+	lifeDatesPattern = regexp.MustCompile(`, (?:ca\. )?\d{4}-(?:\d{4})?$`)
+	isbnPattern      = regexp.MustCompile(`(?i)^(?:ISBN[:\s-]*)?([0-9Xx-]{10,17})\s*(?:\((.*)\))?`)
+)
+
+// formSubdivisions are form subdivisions coded $v in subject headings
+var formSubdivisions = []string{
+	"bibliography", "biography", "catalogs", "congresses", "dictionaries", "drama",
+	"encyclopedias", "exhibitions", "fiction", "handbooks, manuals, etc", "juvenile fiction",
+	"juvenile literature", "periodicals", "poetry", "textbooks",
+}
+
+// ApplyCopyrightMetadata merges copyright page data into a record and returns the tags it
+// wrote. CIP data is cataloging done by the Library of Congress, so it replaces what the
+// title page pass inferred: 010, 020, 050, 082 and subject headings. The copyright date is
+// added to 264 _4, and the edition and series fill in 250 and 490 when missing.
+func ApplyCopyrightMetadata(rec *marc.Record, cm metadata.CopyrightMetadata) []string {
+	cip := metadata.CIPData{}
+	if cm.CIP != nil {
+		cip = *cm.CIP
+	}
+	var tags []string
+	set := func(tag string, fields ...marc.DataField) {
+		if len(fields) == 0 {
+			return
+		}
+		rec.RemoveTags([]string{tag})
+		for _, f := range fields {
+			insertDataField(rec, f)
+		}
+		tags = append(tags, tag)
+	}
+
+	if lccn := identifiers.NormalizeLCCN(firstNonBlank(cip.LCCN, cm.LCCN)); lccn != "" {
+		set("010", dataField("010", " ", " ", "a", identifiers.LCCN010(lccn)))
+	}
+
+	var isbns []marc.DataField
+	seen := make(map[string]bool)
+	for _, raw := range append(slices.Clone(cip.ISBN), cm.ISBN...) {
+		m := isbnPattern.FindStringSubmatch(strings.TrimSpace(raw))
+		if m == nil {
+			continue
+		}
+		isbn := images.CleanISBN(m[1])
+		if seen[isbn] {
+			continue
+		}
+		seen[isbn] = true
+		f := dataField("020", " ", " ", "a", isbn)
+		if q := strings.TrimSpace(m[2]); q != "" {
+			f.Subfields = append(f.Subfields, marc.Subfield{Code: "q", Value: q})
+		}
+		isbns = append(isbns, f)
+	}
+	set("020", isbns...)
+
+	if m := lcClassPattern.FindStringSubmatch(strings.TrimSpace(cip.LCClass)); m != nil {
+		f := dataField("050", "0", "0", "a", strings.ReplaceAll(m[1], " ", ""))
+		if item := strings.TrimSpace(m[2]); item != "" {
+			f.Subfields = append(f.Subfields, marc.Subfield{Code: "b", Value: item})
+		}
+		set("050", f)
+	}
+
+	if ddc := strings.TrimSpace(cip.DDC); ddc != "" {
+		f := dataField("082", "0", "0", "a", ddc)
+		if ed := strings.TrimPrefix(strings.TrimSpace(cip.DDCEdition), "dc"); ed != "" {
+			f.Subfields = append(f.Subfields, marc.Subfield{Code: "2", Value: ed})
+		}
+		set("082", f)
+	}
+
+	if subjects := subjectFields(cip.Subjects); len(subjects) > 0 {
+		rec.RemoveTags([]string{"600", "610", "650", "651"})
+		for _, f := range subjects {
+			insertDataField(rec, f)
+			if !slices.Contains(tags, f.Tag) {
+				tags = append(tags, f.Tag)
+			}
+		}
+	}
+
+	if year := yearPattern.FindString(cm.CopyrightDate); year != "" {
+		kept := rec.DataFields[:0]
+		for _, df := range rec.DataFields {
+			if df.Tag != "264" || df.Ind2 != "4" {
+				kept = append(kept, df)
+			}
+		}
+		rec.DataFields = kept
+		insertDataField(rec, dataField("264", " ", "4", "c", "©"+year))
+		tags = append(tags, "264")
+	}
+
+	if edition := strings.TrimSpace(cm.Edition); edition != "" && len(rec.Fields("250")) == 0 {
+		insertDataField(rec, dataField("250", " ", " ", "a", edition))
+		tags = append(tags, "250")
+	}
+	if series := strings.TrimSpace(strings.Trim(cip.Series, "()")); series != "" && len(rec.Fields("490")) == 0 {
+		insertDataField(rec, dataField("490", "0", " ", "a", series))
+		tags = append(tags, "490")
+	}
+
+	slices.Sort(tags)
+	return slices.Compact(tags)
+}
+
+// subjectFields maps CIP subject tracings ("1. Bridges--History--20th century.") to LCSH
+// headings: 600 for personal names with dates, else 650, with chronological ($y) and form
+// ($v) subdivisions recognized and the rest coded $x
+func subjectFields(tracings []string) []marc.DataField {
+	var fields []marc.DataField
+	for _, t := range tracings {
+		t = strings.TrimRight(strings.TrimSpace(cipNumberPattern.ReplaceAllString(t, "")), ".")
+		if t == "" {
+			continue
+		}
+		parts := strings.Split(t, "--")
+		f := marc.DataField{Tag: "650", Ind1: " ", Ind2: "0", Subfields: []marc.Subfield{{Code: "a", Value: strings.TrimSpace(parts[0])}}}
+		if loc := lifeDatesPattern.FindStringIndex(parts[0]); loc != nil {
+			f.Tag, f.Ind1 = "600", "1"
+			f.Subfields = []marc.Subfield{
+				{Code: "a", Value: strings.TrimSpace(parts[0][:loc[0]]) + ","},
+				{Code: "d", Value: strings.TrimPrefix(parts[0][loc[0]:], ", ")},
+			}
+		}
+		for _, sub := range parts[1:] {
+			sub = strings.TrimSpace(sub)
+			code := "x"
+			switch {
+			case periodPattern.MatchString(sub):
+				code = "y"
+			case slices.Contains(formSubdivisions, strings.ToLower(strings.TrimRight(sub, "."))):
+				code = "v"
+			}
+			f.Subfields = append(f.Subfields, marc.Subfield{Code: code, Value: sub})
+		}
+		fields = append(fields, f)
+	}
+	return fields
+}
+
+// insertDataField inserts a field after the fields with lower or equal tags
+func insertDataField(rec *marc.Record, f marc.DataField) {
+	i := 0
+	for i < len(rec.DataFields) && rec.DataFields[i].Tag <= f.Tag {
+		i++
+	}
+	rec.DataFields = slices.Insert(rec.DataFields, i, f)
+}
+
+func firstNonBlank(values ...string) string {
+	for _, v := range values {
+		if v = strings.TrimSpace(v); v != "" {
+			return v
+		}
+	}
+	return ""
+}
@@ -0,0 +1,355 @@
+package cataloging
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/lehigh-university-libraries/cataloger/internal/eval/failure"
+	"github.com/lehigh-university-libraries/cataloger/internal/eval/metadata"
+	"github.com/lehigh-university-libraries/cataloger/internal/identifiers"
+	"github.com/lehigh-university-libraries/cataloger/internal/images"
+	"github.com/lehigh-university-libraries/cataloger/internal/marc"
+	"github.com/lehigh-university-libraries/cataloger/internal/prompts"
+	"github.com/lehigh-university-libraries/cataloger/internal/providers"
+)
+
+// defaultLeader is used for generated records: language material, monograph, RDA punctuation
+const defaultLeader = "00000nam a2200000 i 4500"
+
+// OCRPages is the OCR text a record is generated from
+type OCRPages struct {
+	Text          string // All pages, title page first
+	CopyrightPage string // The copyright page alone, for the copyright page pass; optional
+	Material      string // marc.MaterialType selecting the metadata prompt profile; empty for books
+}
+
+// GenerationNotes describes how a record was generated
+type GenerationNotes struct {
+	CopyrightTags []string          // Tags written by the copyright page pass
+	Warnings      []failure.Warning // Non-fatal issues, such as a failed copyright page pass
+	Response      string            // The model's metadata response, before parsing
+	// The provider's refusal of the metadata request, which was retried with a sanitized prompt
+	Refusal *providers.RefusalError
+}
+
+// GenerateMARCFromOCR extracts metadata from OCR text and maps it to a MARC record
+func (s *Service) GenerateMARCFromOCR(ctx context.Context, ocrText, provider, model string) (*marc.Record, error) {
+	rec, _, err := s.GenerateMARCFromPages(ctx, OCRPages{Text: ocrText}, provider, model)
+	return rec, err
+}
+
+// GenerateMARCFromPages extracts metadata from OCR text and maps it to a MARC record. When
+// there is a copyright page, a second pass extracts its copyright date, LCCN, ISBNs and CIP
+// data and merges them into the record; the tags it wrote are returned in the notes.
+func (s *Service) GenerateMARCFromPages(ctx context.Context, pages OCRPages, provider, model string) (*marc.Record, GenerationNotes, error) {
+	return s.generateMARC(ctx, pages, provider, model, nil)
+}
+
+// StreamMARCFromPages is GenerateMARCFromPages, calling onChunk with each piece of the
+// metadata response as the provider generates it, so clients can show progress instead of
+// waiting for the whole record. Providers that can't stream send the response in one chunk;
+// a nil onChunk generates without streaming.
+func (s *Service) StreamMARCFromPages(ctx context.Context, pages OCRPages, provider, model string, onChunk func(chunk string)) (*marc.Record, GenerationNotes, error) {
+	return s.generateMARC(ctx, pages, provider, model, onChunk)
+}
+
+// PageImage is a photograph of one of an item's pages, for GenerateMARCFromImages
+type PageImage struct {
+	Type string // "title_page", "copyright" (or "copyright_page") or "cover"
+	Path string // File the image was read from, when there is one
+	Data []byte
+}
+
+// pageOrder describes each type of page image to the model, in the order they are sent
+var pageOrder = []struct{ types, description string }{
+	{"title_page", "the title page"},
+	{"copyright copyright_page", "the copyright page (title page verso)"},
+	{"cover", "the cover"},
+}
+
+// GenerateMARCFromImages generates a record from all of an item's page images in one
+// multimodal request, rather than transcribing each page and generating from the text, so the
+// model can weigh the pages against each other. Images are sent title page first, then the
+// copyright page, then the cover. More than one image needs a provider that takes several
+// per request (see providers.MultiImageProvider). The copyright page pass is not run: the
+// model sees the copyright page itself.
+func (s *Service) GenerateMARCFromImages(ctx context.Context, pageImages []PageImage, material, provider, model string) (*marc.Record, GenerationNotes, error) {
+	var notes GenerationNotes
+	images, userPrompt, imagePath := orderPageImages(pageImages)
+	if len(images) == 0 {
+		return nil, notes, fmt.Errorf("no page images")
+	}
+	name := provider
+	if name == "" {
+		name = providers.Default()
+	}
+	if reg, ok := providers.Lookup(name); ok && !reg.Vision {
+		return nil, notes, fmt.Errorf("provider %s does not accept images", name)
+	}
+	systemPrompt, err := s.prompts.Get(prompts.MetadataFromImages)
+	if err != nil {
+		return nil, notes, err
+	}
+
+	metadataJSON, refusal, err := s.requestJSON(ctx, systemPrompt, userPrompt, metadataResponseSchema, provider, model, func(ctx context.Context, p providers.Provider, config providers.Config) (string, error) {
+		config.ImagePath = imagePath
+		return providers.GenerateFromImages(ctx, p, config, images)
+	})
+	notes.Refusal = refusal
+	if err != nil {
+		return nil, notes, err
+	}
+	rec, err := s.buildRecord(ctx, metadataJSON, material, "", provider, model, &notes)
+	return rec, notes, err
+}
+
+// orderPageImages sorts page images into the order they are sent, returning them with the
+// user prompt describing them and the path of the first. Images of unknown type go last.
+func orderPageImages(pages []PageImage) (images [][]byte, userPrompt, firstPath string) {
+	var described []string
+	add := func(p PageImage, description string) {
+		if len(images) == 0 {
+			firstPath = p.Path
+		}
+		images = append(images, p.Data)
+		described = append(described, fmt.Sprintf("%d. %s", len(images), description))
+	}
+	used := make([]bool, len(pages))
+	for _, kind := range pageOrder {
+		for i, p := range pages {
+			if !used[i] && slices.Contains(strings.Fields(kind.types), p.Type) {
+				used[i] = true
+				add(p, kind.description)
+			}
+		}
+	}
+	for i, p := range pages {
+		if !used[i] {
+			add(p, "another page")
+		}
+	}
+	userPrompt = "The images are, in order:\n" + strings.Join(described, "\n") + "\n\nExtract the bibliographic metadata as JSON."
+	return images, userPrompt, firstPath
+}
+
+func (s *Service) generateMARC(ctx context.Context, pages OCRPages, provider, model string, onChunk func(string)) (*marc.Record, GenerationNotes, error) {
+	var notes GenerationNotes
+	if strings.TrimSpace(pages.Text) == "" {
+		notes.Warnings = append(notes.Warnings, failure.Warn(failure.WarnEmptyOCR, "OCR returned no text"))
+	}
+	if p, err := s.buildMetadataExtractionPrompt(pages.Material); err == nil && pages.Material != "" && pages.Material != marc.MaterialBook && p.ID == prompts.MetadataExtraction {
+		notes.Warnings = append(notes.Warnings, failure.Warn(failure.WarnPromptFallback, "no metadata prompt for %s material; used the book prompt", pages.Material))
+	}
+
+	metadataJSON, refusal, err := s.extractMaterialMetadata(ctx, pages.Text, pages.Material, provider, model, onChunk)
+	notes.Refusal = refusal
+	if err != nil {
+		return nil, notes, err
+	}
+	rec, err := s.buildRecord(ctx, metadataJSON, pages.Material, pages.CopyrightPage, provider, model, &notes)
+	return rec, notes, err
+}
+
+// buildRecord maps a metadata response to a MARC record, running the copyright page pass
+// when there is copyright page text, then identifier lookup, the institution profile, hooks
+// and the control field policy
+func (s *Service) buildRecord(ctx context.Context, metadataJSON, material, copyrightPage, provider, model string, notes *GenerationNotes) (*marc.Record, error) {
+	notes.Response = metadataJSON
+	if strings.HasPrefix(strings.TrimSpace(metadataJSON), "```") {
+		notes.Warnings = append(notes.Warnings, failure.Warn(failure.WarnFencedResponse, "metadata JSON was wrapped in a markdown code fence"))
+	}
+
+	md, err := ParseMetadataJSON(metadataJSON)
+	if err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(md.Title) == "" {
+		notes.Warnings = append(notes.Warnings, failure.Warn(failure.WarnNoTitle, "no title extracted"))
+	}
+
+	rec := MetadataToMARC(md)
+	if material != "" {
+		rec.SetMaterialType(material)
+	}
+	if strings.TrimSpace(copyrightPage) != "" {
+		cm, err := s.ExtractCopyrightMetadata(ctx, copyrightPage, provider, model)
+		if err != nil {
+			slog.Warn("Copyright page pass failed", "error", err)
+			notes.Warnings = append(notes.Warnings, failure.Warn(failure.WarnCopyrightPass, "copyright page pass failed: %v", err))
+		} else {
+			notes.CopyrightTags = ApplyCopyrightMetadata(rec, cm)
+		}
+	}
+	if s.identifiers != nil && len(md.ISBN) > 0 {
+		isbn := images.CleanISBN(md.ISBN[0])
+		ids, err := s.identifiers.Lookup(isbn)
+		if err != nil {
+			slog.Warn("Identifier lookup failed", "isbn", isbn, "error", err)
+			notes.Warnings = append(notes.Warnings, failure.Warn(failure.WarnIdentifierLookup, "identifier lookup for ISBN %s failed: %v", isbn, err))
+		} else {
+			identifiers.Apply(rec, ids)
+		}
+	}
+	if s.profile != nil {
+		s.profile.Apply(rec)
+	}
+	if len(s.hooks) > 0 {
+		rec, err = s.hooks.Apply(ctx, rec)
+		if err != nil {
+			return nil, fmt.Errorf("failed to post-process record: %w", err)
+		}
+	}
+	s.control.Apply(rec)
+	return rec, nil
+}
+
+// ParseMetadataJSON parses an LLM metadata response, tolerating markdown code fences
+func ParseMetadataJSON(s string) (metadata.BookMetadata, error) {
+	s = strings.TrimSpace(s)
+	s = strings.TrimPrefix(s, "```json")
+	s = strings.TrimPrefix(s, "```")
+	s = strings.TrimSuffix(s, "```")
+
+	var md metadata.BookMetadata
+	if err := json.Unmarshal([]byte(strings.TrimSpace(s)), &md); err != nil {
+		return md, failure.Wrap(failure.ParseFailure, fmt.Errorf("failed to parse metadata JSON: %w", err))
+	}
+	return md, nil
+}
+
+// MetadataToMARC maps extracted metadata to a minimal MARC bibliographic record
+func MetadataToMARC(md metadata.BookMetadata) *marc.Record {
+	rec := &marc.Record{Leader: defaultLeader}
+
+	year := yearPattern.FindString(md.PublicationDate)
+	lang := marcLanguageCode(md.Language)
+	rec.ControlFields = append(rec.ControlFields, marc.ControlField{Tag: "008", Value: build008(year, lang)})
+
+	for _, isbn := range md.ISBN {
+		if isbn = strings.TrimSpace(isbn); isbn != "" {
+			rec.DataFields = append(rec.DataFields, dataField("020", " ", " ", "a", isbn))
+		}
+	}
+
+	if md.Author != "" {
+		rec.DataFields = append(rec.DataFields, dataField("100", "1", " ", "a", md.Author))
+	}
+
+	titleInd1 := "0"
+	if md.Author != "" {
+		titleInd1 = "1"
+	}
+	if md.Title != "" {
+		rec.DataFields = append(rec.DataFields, dataField("245", titleInd1, nonfilingIndicator(md.Title), "a", md.Title))
+	}
+
+	if md.Edition != "" {
+		rec.DataFields = append(rec.DataFields, dataField("250", " ", " ", "a", md.Edition))
+	}
+
+	if md.PublicationCity != "" || md.Publisher != "" || md.PublicationDate != "" {
+		f := marc.DataField{Tag: "264", Ind1: " ", Ind2: "1"}
+		if md.PublicationCity != "" {
+			f.Subfields = append(f.Subfields, marc.Subfield{Code: "a", Value: md.PublicationCity})
+		}
+		if md.Publisher != "" {
+			f.Subfields = append(f.Subfields, marc.Subfield{Code: "b", Value: md.Publisher})
+		}
+		if md.PublicationDate != "" {
+			f.Subfields = append(f.Subfields, marc.Subfield{Code: "c", Value: md.PublicationDate})
+		}
+		rec.DataFields = append(rec.DataFields, f)
+	}
+
+	if md.Series != "" {
+		rec.DataFields = append(rec.DataFields, dataField("490", "0", " ", "a", md.Series))
+	}
+	if md.Subject != "" {
+		rec.DataFields = append(rec.DataFields, dataField("650", " ", "4", "a", md.Subject))
+	}
+	if md.Genre != "" {
+		rec.DataFields = append(rec.DataFields, dataField("655", " ", "4", "a", md.Genre))
+	}
+
+	return rec
+}
+
+var yearPattern = regexp.MustCompile(`\d{4}`)
+
+func dataField(tag, ind1, ind2, code, value string) marc.DataField {
+	return marc.DataField{
+		Tag:       tag,
+		Ind1:      ind1,
+		Ind2:      ind2,
+		Subfields: []marc.Subfield{{Code: code, Value: value}},
+	}
+}
+
+// build008 builds the fixed-length data elements with date 1 and language filled in
+func build008(year, lang string) string {
+	f := []byte(strings.Repeat(" ", 40))
+	copy(f[0:6], time.Now().Format("060102"))
+	if year != "" {
+		f[6] = 's'
+		copy(f[7:11], year)
+	} else {
+		f[6] = 'n'
+		copy(f[7:11], "uuuu")
+	}
+	copy(f[15:18], "xx ")
+	copy(f[35:38], lang)
+	f[39] = 'd'
+	return string(f)
+}
+
+// nonfilingIndicator returns the 245 second indicator for a leading English article
+func nonfilingIndicator(title string) string {
+	lower := strings.ToLower(title)
+	for _, article := range []string{"the ", "an ", "a "} {
+		if strings.HasPrefix(lower, article) {
+			return fmt.Sprint(len(article))
+		}
+	}
+	return "0"
+}
+
+// languageCodes maps language names and ISO 639-3 codes that differ from MARC codes
+var languageCodes = map[string]string{
+	"english":    "eng",
+	"french":     "fre",
+	"fra":        "fre",
+	"german":     "ger",
+	"deu":        "ger",
+	"spanish":    "spa",
+	"italian":    "ita",
+	"portuguese": "por",
+	"dutch":      "dut",
+	"nld":        "dut",
+	"latin":      "lat",
+	"greek":      "gre",
+	"ell":        "gre",
+	"russian":    "rus",
+	"chinese":    "chi",
+	"zho":        "chi",
+	"japanese":   "jpn",
+	"arabic":     "ara",
+	"hebrew":     "heb",
+}
+
+// marcLanguageCode normalizes an extracted language to a MARC language code, "und" when unknown
+func marcLanguageCode(language string) string {
+	language = strings.ToLower(strings.TrimSpace(language))
+	if code, ok := languageCodes[language]; ok {
+		return code
+	}
+	if len(language) == 3 {
+		return language
+	}
+	return "und"
+}
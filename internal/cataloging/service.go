@@ -2,144 +2,262 @@ package cataloging
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
-	"os"
+	"strings"
+	"time"
+	"unicode"
 
-	"github.com/lehigh-university-libraries/cataloger/internal/gemini"
-	"github.com/lehigh-university-libraries/cataloger/internal/ollama"
-	"github.com/lehigh-university-libraries/cataloger/internal/openai"
+	"github.com/lehigh-university-libraries/cataloger/internal/eval/metadata"
+	"github.com/lehigh-university-libraries/cataloger/internal/hooks"
+	"github.com/lehigh-university-libraries/cataloger/internal/identifiers"
+	"github.com/lehigh-university-libraries/cataloger/internal/marc"
+	"github.com/lehigh-university-libraries/cataloger/internal/profile"
+	"github.com/lehigh-university-libraries/cataloger/internal/prompts"
 	"github.com/lehigh-university-libraries/cataloger/internal/providers"
+	_ "github.com/lehigh-university-libraries/cataloger/internal/providers/builtin" // Registers the providers
 )
 
-type Service struct{}
+type Service struct {
+	prompts     *prompts.Selection
+	control     marc.ControlPolicy
+	profile     *profile.Profile
+	identifiers *identifiers.Resolver
+	hooks       hooks.Chain
+	timeout     time.Duration
+}
 
 func NewService() *Service {
-	return &Service{}
+	sel, err := prompts.Default()
+	if err != nil {
+		slog.Warn("Ignoring prompt configuration", "error", err)
+		sel = &prompts.Selection{Library: prompts.Builtin()}
+	}
+	prof, err := profile.FromEnv()
+	if err != nil {
+		slog.Warn("Ignoring institution profile", "error", err)
+	}
+
+	control := marc.ControlPolicyFromEnv()
+	if control.OrgCode == "" && prof != nil {
+		control.OrgCode = prof.OrgCode
+	}
+
+	return &Service{prompts: sel, control: control, profile: prof, identifiers: identifiers.FromEnv(), hooks: hooks.FromEnv(), timeout: providers.TimeoutFromEnv()}
+}
+
+// SetPrompts replaces the prompt library and version pins used by the service
+func (s *Service) SetPrompts(sel *prompts.Selection) {
+	s.prompts = sel
+}
+
+// SetControlPolicy replaces the 001/003/005 policy applied to generated records
+func (s *Service) SetControlPolicy(p marc.ControlPolicy) {
+	s.control = p
+}
+
+// SetHooks replaces the post-processing hooks run on each generated record
+func (s *Service) SetHooks(chain hooks.Chain) {
+	s.hooks = chain
+}
+
+// SetTimeout bounds each provider call (PROVIDER_TIMEOUT by default); 0 disables the timeout.
+// A deadline on the context passed to a call applies as well.
+func (s *Service) SetTimeout(d time.Duration) {
+	s.timeout = d
 }
 
-// initProvider initializes an LLM provider based on the provider type
-func (s *Service) initProvider(providerType string) (providers.Provider, error) {
-	switch providerType {
-	case "ollama":
-		return ollama.New(), nil
-	case "openai":
-		return openai.New(), nil
-	case "gemini":
-		return gemini.New(), nil
-	default:
-		return nil, fmt.Errorf("unsupported LLM provider: %s", providerType)
+// Hooks returns the post-processing hooks run on each generated record
+func (s *Service) Hooks() hooks.Chain {
+	return s.hooks
+}
+
+// Profile returns the institution profile, or nil when none is configured
+func (s *Service) Profile() *profile.Profile {
+	return s.profile
+}
+
+// PromptVersion returns the ref of the prompt version the service will use, e.g.
+// "metadata_extraction@v1+3f2a9c1b7e4d"
+func (s *Service) PromptVersion() string {
+	return s.PromptVersionFor("")
+}
+
+// ImagesPromptVersion returns the ref of the prompt version GenerateMARCFromImages uses
+func (s *Service) ImagesPromptVersion() string {
+	p, err := s.prompts.Get(prompts.MetadataFromImages)
+	if err != nil {
+		return ""
 	}
+	return p.Ref()
+}
+
+// PromptVersionFor returns the ref of the prompt version used for a material type (see
+// marc.MaterialType), e.g. "metadata_extraction_map@v1+8c01d2e4f9a3"
+func (s *Service) PromptVersionFor(material string) string {
+	p, err := s.buildMetadataExtractionPrompt(material)
+	if err != nil {
+		return ""
+	}
+	return p.Ref()
 }
 
 // ExtractMetadataFromOCR extracts bibliographic metadata from OCR text
-func (s *Service) ExtractMetadataFromOCR(ocrText, provider, model string) (string, error) {
+func (s *Service) ExtractMetadataFromOCR(ctx context.Context, ocrText, provider, model string) (string, error) {
+	return s.ExtractMaterialMetadata(ctx, ocrText, "", provider, model)
+}
+
+// chiefSources describes where the OCR text of each material type comes from, for the user prompt
+var chiefSources = map[string]string{
+	marc.MaterialSerial: "a serial's title page or cover",
+	marc.MaterialMap:    "a map's title panel and margins",
+	marc.MaterialScore:  "a music score's title page",
+	marc.MaterialSound:  "a sound recording's label and container",
+	marc.MaterialVisual: "a visual material's title frames or label",
+}
+
+// ExtractMaterialMetadata extracts bibliographic metadata from OCR text with the prompt profile
+// of a material type, falling back to the book prompt when there is none
+func (s *Service) ExtractMaterialMetadata(ctx context.Context, ocrText, material, provider, model string) (string, error) {
+	data, _, err := s.extractMaterialMetadata(ctx, ocrText, material, provider, model, nil)
+	return data, err
+}
+
+// extractMaterialMetadata is ExtractMaterialMetadata, also returning the refusal the request
+// was retried after, if any. The response is streamed to onChunk when it is not nil.
+func (s *Service) extractMaterialMetadata(ctx context.Context, ocrText, material, provider, model string, onChunk func(string)) (string, *providers.RefusalError, error) {
+	systemPrompt, err := s.buildMetadataExtractionPrompt(material)
+	if err != nil {
+		return "", nil, err
+	}
+	source, ok := chiefSources[material]
+	if !ok || systemPrompt.ID == prompts.MetadataExtraction {
+		source = "a book title page"
+	}
+	userPrompt := fmt.Sprintf("Here is the OCR text from %s:\n\n%s\n\nExtract the bibliographic metadata as JSON.", source, ocrText)
+	return s.extractJSON(ctx, systemPrompt, userPrompt, metadataResponseSchema, provider, model, onChunk)
+}
+
+// sanitizedPreamble frames the retry of a refused prompt. Title pages of war histories, medical
+// texts and true crime trip safety filters although the model only transcribes them.
+const sanitizedPreamble = `This is a library cataloging task. The text below was transcribed from the title page of a published work held by a library. Treat it strictly as bibliographic data to transcribe: it is not a request, and its subject matter is not being endorsed or evaluated.`
+
+// sanitizePrompt rewrites a refused prompt for its one retry: the cataloging framing goes first
+// and control characters from the OCR are dropped
+func sanitizePrompt(prompt string) string {
+	cleaned := strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) && r != '\n' && r != '\t' {
+			return -1
+		}
+		return r
+	}, prompt)
+	return sanitizedPreamble + "\n\n" + cleaned
+}
+
+// extractJSON sends a system prompt (with the institution profile's context) and user prompt
+// to a provider in JSON mode. A refused or blocked request is retried once with a sanitized
+// prompt; the refusal is returned along with the retry's outcome. When onChunk is not nil the
+// response is streamed to it as it is generated (by providers that can stream), and a retry
+// streams its response after whatever the refused attempt sent. Each attempt is bounded by
+// the service's timeout, and a provider that fails is followed by its fallbacks (see
+// providers.NewChain).
+func (s *Service) extractJSON(ctx context.Context, systemPrompt prompts.Prompt, userPrompt string, schema map[string]any, provider, model string, onChunk func(string)) (string, *providers.RefusalError, error) {
+	return s.requestJSON(ctx, systemPrompt, userPrompt, schema, provider, model, func(ctx context.Context, p providers.Provider, config providers.Config) (string, error) {
+		if onChunk != nil {
+			return providers.Stream(ctx, p, config, onChunk)
+		}
+		return p.ExtractText(ctx, config)
+	})
+}
+
+// requestJSON is extractJSON with the request made by send, e.g. with images
+func (s *Service) requestJSON(ctx context.Context, systemPrompt prompts.Prompt, userPrompt string, schema map[string]any, provider, model string, send func(context.Context, providers.Provider, providers.Config) (string, error)) (string, *providers.RefusalError, error) {
 	// Set defaults if not provided
 	if provider == "" {
-		provider = os.Getenv("CATALOGING_PROVIDER")
-		if provider == "" {
-			provider = "ollama"
-		}
+		provider = providers.Default()
 	}
 
 	if model == "" {
 		model = s.GetDefaultModel(provider)
 	}
 
-	// Initialize provider
-	llmProvider, err := s.initProvider(provider)
+	// Initialize provider, with its fallbacks (PROVIDER_FALLBACK)
+	llmProvider, err := providers.NewChain(provider, s.timeout)
 	if err != nil {
-		return "", err
+		return "", nil, err
 	}
 
-	// Build prompt
-	systemPrompt := s.buildMetadataExtractionPrompt()
-	userPrompt := fmt.Sprintf("Here is the OCR text from a book title page:\n\n%s\n\nExtract the bibliographic metadata as JSON.", ocrText)
-	fullPrompt := systemPrompt + "\n\n" + userPrompt
+	fullPrompt := systemPrompt.Text + "\n\n" + userPrompt
+	if s.profile != nil {
+		fullPrompt = systemPrompt.Text + "\n\n" + s.profile.PromptContext() + "\n" + userPrompt
+	}
 
 	// Create config
 	config := providers.Config{
-		Model:       model,
-		Temperature: 0.1,
-		Prompt:      fullPrompt,
+		Model:          model,
+		Temperature:    0.1,
+		Prompt:         fullPrompt,
+		JSONMode:       true,
+		ResponseSchema: schema,
 	}
 
 	// Extract metadata using provider
-	ctx := context.Background()
-	metadataJSON, err := llmProvider.ExtractText(ctx, config)
+	extract := func() (string, error) {
+		return send(ctx, llmProvider, config)
+	}
+	data, err := extract()
+	var refusal *providers.RefusalError
+	if errors.As(err, &refusal) && ctx.Err() == nil {
+		slog.Warn("Provider refused, retrying with a sanitized prompt", "provider", provider, "model", model, "category", refusal.Category, "reason", refusal.Reason)
+		config.Prompt = sanitizePrompt(config.Prompt)
+		data, err = extract()
+	}
 	if err != nil {
-		return "", fmt.Errorf("failed to extract metadata with %s: %w", provider, err)
+		return "", refusal, fmt.Errorf("failed to extract metadata with %s: %w", provider, err)
 	}
 
-	slog.Info("Extracted metadata", "provider", provider, "model", model, "length", len(metadataJSON))
-	return metadataJSON, nil
+	slog.Info("Extracted metadata", "provider", provider, "model", model, "prompt", systemPrompt.ID, "length", len(data))
+	return data, refusal, nil
 }
 
-func (s *Service) GetDefaultModel(provider string) string {
-	switch provider {
-	case "openai":
-		model := os.Getenv("OPENAI_MODEL")
-		if model == "" {
-			return "gpt-4o"
-		}
-		return model
-	case "ollama":
-		model := os.Getenv("OLLAMA_MODEL")
-		if model == "" {
-			return "mistral-small3.2:24b"
-		}
-		return model
-	case "gemini":
-		model := os.Getenv("GEMINI_MODEL")
-		if model == "" {
-			return "gemini-1.5-flash-latest"
-		}
-		return model
-	default:
-		return ""
+// ProviderInfo describes an LLM provider for clients choosing a provider/model
+type ProviderInfo struct {
+	Name         string `json:"name"`
+	DefaultModel string `json:"default_model"`
+	Vision       bool   `json:"vision"`     // Supports image input (title page OCR)
+	Configured   bool   `json:"configured"` // Required credentials/endpoint are present
+	Default      bool   `json:"default"`    // Used when no provider is requested
+}
+
+// AvailableProviders lists the registered providers with their default models and capabilities
+func (s *Service) AvailableProviders() []ProviderInfo {
+	defaultProvider := providers.Default()
+
+	registered := providers.Registered()
+	infos := make([]ProviderInfo, 0, len(registered))
+	for _, r := range registered {
+		infos = append(infos, ProviderInfo{
+			Name:         r.Name,
+			DefaultModel: s.GetDefaultModel(r.Name),
+			Vision:       r.Vision,
+			Configured:   r.Configured == nil || r.Configured(),
+			Default:      r.Name == defaultProvider,
+		})
 	}
+	return infos
 }
 
-// buildMetadataExtractionPrompt creates a prompt for extracting bibliographic metadata
-func (s *Service) buildMetadataExtractionPrompt() string {
-	return `You are an expert bibliographic metadata cataloger. Extract structured metadata from the OCR text of a book title page.
-
-INSTRUCTIONS:
-1. Carefully analyze ALL information in the OCR text
-2. Extract the following bibliographic fields:
-   - title: Full title of the work (include subtitle if present)
-   - author: Primary author(s) name(s)
-   - publisher: Publisher name
-   - publication_date: Year of publication
-   - publication_city: City where published
-   - edition: Edition statement (if present, e.g., "2nd ed.", "Rev. ed.")
-   - isbn: ISBN numbers (array, if present)
-   - language: Primary language of the work (ISO 639-3 code if possible, or full name)
-   - subject: Main subject or topic
-   - genre: Genre or form (e.g., "Fiction", "Biography", "Reference")
-   - series: Series information (if part of a series)
-
-3. For missing fields, use empty string "" or empty array [] for ISBN
-4. Be precise and extract exactly what is shown in the OCR text
-5. Do not invent or infer information that isn't present
-
-OUTPUT FORMAT:
-Respond with ONLY a JSON object:
-
-{
-  "title": "...",
-  "author": "...",
-  "publisher": "...",
-  "publication_date": "...",
-  "publication_city": "...",
-  "edition": "...",
-  "isbn": ["..."],
-  "language": "...",
-  "subject": "...",
-  "genre": "...",
-  "series": "...",
-  "notes": "Any observations or uncertainties"
-}
-
-Be thorough and accurate. Extract only what is clearly present in the OCR text.`
+// GetDefaultModel returns the model a provider uses when none is requested
+func (s *Service) GetDefaultModel(provider string) string {
+	return providers.DefaultModel(provider)
+}
+
+// metadataResponseSchema describes the JSON object requested by buildMetadataExtractionPrompt
+var metadataResponseSchema = providers.SchemaFor(metadata.BookMetadata{})
+
+// buildMetadataExtractionPrompt returns the selected version of the metadata extraction prompt
+// for a material type
+func (s *Service) buildMetadataExtractionPrompt(material string) (prompts.Prompt, error) {
+	return s.prompts.GetFor(prompts.MetadataExtraction, material)
 }
@@ -0,0 +1,292 @@
+package claude
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/lehigh-university-libraries/cataloger/internal/providers"
+)
+
+const (
+	defaultBaseURL   = "https://api.anthropic.com"
+	apiVersion       = "2023-06-01"
+	defaultMaxTokens = 4096
+	// filesBeta enables file references in the Messages API
+	filesBeta = "files-api-2025-04-14"
+	// jsonTool is the tool Claude is made to call in JSON mode; its input is the JSON object
+	jsonTool = "record_metadata"
+)
+
+// Registration registers the provider as "claude"
+var Registration = providers.Registration{
+	Name:         "claude",
+	New:          func() providers.Provider { return New() },
+	DefaultModel: providers.EnvModel("CLAUDE_MODEL", "claude-sonnet-4-5"),
+	Configured:   providers.EnvSet("ANTHROPIC_API_KEY"),
+	Vision:       true,
+}
+
+// Claude is a provider for Anthropic Claude, through the Messages API
+type Claude struct{}
+
+// New returns a new Claude provider
+//
+// Configured with:
+//   - ANTHROPIC_API_KEY: API key (required)
+//   - ANTHROPIC_BASE_URL: API endpoint, for proxies and gateways (default https://api.anthropic.com)
+//   - ANTHROPIC_MAX_TOKENS: maximum tokens per response (default 4096)
+//   - PROVIDER_FILE_UPLOADS: upload each image once through the Files API and refer to it by ID
+func New() *Claude {
+	return &Claude{}
+}
+
+// ExtractText extracts text from the given prompt using Claude. JSON mode has Claude call a
+// tool whose input schema is the response schema, since the Messages API has no JSON mode.
+func (c *Claude) ExtractText(ctx context.Context, config providers.Config) (string, error) {
+	content := []map[string]any{{"type": "text", "text": config.Prompt}}
+	return c.send(ctx, config, content, false)
+}
+
+// GenerateFromImage sends an image with a prompt, for title page OCR
+func (c *Claude) GenerateFromImage(ctx context.Context, config providers.Config, image []byte) (string, error) {
+	return c.GenerateFromImages(ctx, config, [][]byte{image})
+}
+
+// GenerateFromImages sends several images, in order, before the prompt in one message. With
+// file uploads enabled each image is sent by file reference, falling back to inline images
+// when a reference fails.
+func (c *Claude) GenerateFromImages(ctx context.Context, config providers.Config, images [][]byte) (string, error) {
+	mediaTypes := make([]string, len(images))
+	for i, image := range images {
+		mediaTypes[i] = http.DetectContentType(image)
+		switch mediaTypes[i] {
+		case "image/jpeg", "image/png", "image/gif", "image/webp":
+		default:
+			return "", fmt.Errorf("unsupported image type for Claude: %s", mediaTypes[i])
+		}
+	}
+	content := func(sources []map[string]string) []map[string]any {
+		blocks := make([]map[string]any, 0, len(sources)+1)
+		for _, source := range sources {
+			blocks = append(blocks, map[string]any{"type": "image", "source": source})
+		}
+		return append(blocks, map[string]any{"type": "text", "text": config.Prompt})
+	}
+
+	if providers.FileUploadsEnabled() {
+		text, err := c.sendFiles(ctx, config, images, mediaTypes, content)
+		if err == nil || ctx.Err() != nil {
+			return text, err
+		}
+		var refusal *providers.RefusalError
+		if errors.As(err, &refusal) {
+			return text, err
+		}
+		slog.Warn("Sending images inline instead of by file reference", "error", err)
+	}
+
+	inline := make([]map[string]string, len(images))
+	for i, image := range images {
+		inline[i] = map[string]string{
+			"type":       "base64",
+			"media_type": mediaTypes[i],
+			"data":       base64.StdEncoding.EncodeToString(image),
+		}
+	}
+	return c.send(ctx, config, content(inline), false)
+}
+
+// sendFiles sends the images by file reference, uploading those not already uploaded. A
+// failed request forgets the uploads, since a file may have been deleted.
+func (c *Claude) sendFiles(ctx context.Context, config providers.Config, images [][]byte, mediaTypes []string, content func([]map[string]string) []map[string]any) (string, error) {
+	baseURL, apiKey, err := endpoint()
+	if err != nil {
+		return "", err
+	}
+	keys := make([]string, len(images))
+	sources := make([]map[string]string, len(images))
+	for i, image := range images {
+		keys[i] = providers.ImageKey("claude|"+baseURL, image)
+		id, err := providers.Files.Get(ctx, keys[i], 0, func(ctx context.Context) (string, error) {
+			return upload(ctx, baseURL, apiKey, image, mediaTypes[i])
+		})
+		if err != nil {
+			return "", err
+		}
+		sources[i] = map[string]string{"type": "file", "file_id": id}
+	}
+
+	text, err := c.send(ctx, config, content(sources), true)
+	var refusal *providers.RefusalError
+	if err != nil && !errors.As(err, &refusal) && ctx.Err() == nil {
+		for _, key := range keys {
+			providers.Files.Forget(key)
+		}
+	}
+	return text, err
+}
+
+// endpoint returns the API's base URL and key
+func endpoint() (baseURL, apiKey string, err error) {
+	apiKey = os.Getenv("ANTHROPIC_API_KEY")
+	if apiKey == "" {
+		return "", "", fmt.Errorf("ANTHROPIC_API_KEY environment variable not set")
+	}
+	baseURL = strings.TrimSuffix(os.Getenv("ANTHROPIC_BASE_URL"), "/")
+	if baseURL == "" {
+		baseURL = defaultBaseURL
+	}
+	return baseURL, apiKey, nil
+}
+
+// upload sends an image to the Files API, returning its file ID
+func upload(ctx context.Context, baseURL, apiKey string, image []byte, mediaType string) (string, error) {
+	var body bytes.Buffer
+	w := multipart.NewWriter(&body)
+	header := make(textproto.MIMEHeader)
+	header.Set("Content-Disposition", `form-data; name="file"; filename="image"`)
+	header.Set("Content-Type", mediaType)
+	part, err := w.CreatePart(header)
+	if err != nil {
+		return "", fmt.Errorf("failed to create upload: %w", err)
+	}
+	if _, err := part.Write(image); err != nil {
+		return "", fmt.Errorf("failed to create upload: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return "", fmt.Errorf("failed to create upload: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", baseURL+"/v1/files", bytes.NewReader(body.Bytes()))
+	if err != nil {
+		return "", fmt.Errorf("failed to create new request: %w", err)
+	}
+	req.Header.Set("Content-Type", w.FormDataContentType())
+	req.Header.Set("x-api-key", apiKey)
+	req.Header.Set("anthropic-version", apiVersion)
+	req.Header.Set("anthropic-beta", filesBeta)
+
+	resp, err := providers.HTTPClient().Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to upload image: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		data, _ := io.ReadAll(resp.Body)
+		return "", fmt.Errorf("failed to upload image: status %d - %s", resp.StatusCode, string(data))
+	}
+	var file struct {
+		ID string `json:"id"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&file); err != nil || file.ID == "" {
+		return "", fmt.Errorf("failed to decode upload response: %v", err)
+	}
+	slog.Debug("Uploaded image", "file_id", file.ID, "bytes", len(image))
+	return file.ID, nil
+}
+
+// send posts a message with one user turn; files enables file references in its content
+func (c *Claude) send(ctx context.Context, config providers.Config, content []map[string]any, files bool) (string, error) {
+	baseURL, apiKey, err := endpoint()
+	if err != nil {
+		return "", err
+	}
+	maxTokens := defaultMaxTokens
+	if v := os.Getenv("ANTHROPIC_MAX_TOKENS"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			return "", fmt.Errorf("invalid ANTHROPIC_MAX_TOKENS %q", v)
+		}
+		maxTokens = n
+	}
+
+	body := map[string]any{
+		"model":       config.Model,
+		"max_tokens":  maxTokens,
+		"temperature": config.Temperature,
+		"messages":    []map[string]any{{"role": "user", "content": content}},
+	}
+	if config.JSONMode || config.ResponseSchema != nil {
+		schema := config.ResponseSchema
+		if schema == nil {
+			schema = map[string]any{"type": "object"}
+		}
+		body["tools"] = []map[string]any{{
+			"name":         jsonTool,
+			"description":  "Record the extracted data as a JSON object.",
+			"input_schema": schema,
+		}}
+		body["tool_choice"] = map[string]string{"type": "tool", "name": jsonTool}
+	}
+
+	requestBody, err := json.Marshal(body)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal request body: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", baseURL+"/v1/messages", bytes.NewBuffer(requestBody))
+	if err != nil {
+		return "", fmt.Errorf("failed to create new request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("x-api-key", apiKey)
+	req.Header.Set("anthropic-version", apiVersion)
+	if files {
+		req.Header.Set("anthropic-beta", filesBeta)
+	}
+
+	resp, err := providers.HTTPClient().Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return "", fmt.Errorf("received non-200 status code: %d - %s", resp.StatusCode, string(body))
+	}
+
+	var response struct {
+		Content []struct {
+			Type  string          `json:"type"`
+			Text  string          `json:"text"`
+			Name  string          `json:"name"`
+			Input json.RawMessage `json:"input"`
+		} `json:"content"`
+		StopReason string `json:"stop_reason"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return "", fmt.Errorf("failed to decode response body: %w", err)
+	}
+
+	var text strings.Builder
+	for _, block := range response.Content {
+		switch block.Type {
+		case "tool_use":
+			if block.Name == jsonTool {
+				return string(block.Input), nil
+			}
+		case "text":
+			text.WriteString(block.Text)
+		}
+	}
+	if response.StopReason == "refusal" {
+		return "", &providers.RefusalError{Provider: "claude", Category: providers.RefusalModel, Reason: strings.TrimSpace(text.String())}
+	}
+	if text.Len() == 0 {
+		return "", fmt.Errorf("no content returned from Claude (stop reason: %s)", response.StopReason)
+	}
+	return text.String(), nil
+}
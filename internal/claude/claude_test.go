@@ -0,0 +1,149 @@
+package claude
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/lehigh-university-libraries/cataloger/internal/providers"
+)
+
+// fakeAPI serves one canned Messages API response and records the request
+func fakeAPI(t *testing.T, response string) *map[string]any {
+	t.Helper()
+	var got map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/messages" || r.Header.Get("x-api-key") != "test-key" || r.Header.Get("anthropic-version") != apiVersion {
+			t.Errorf("unexpected request %s %v", r.URL.Path, r.Header)
+		}
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Error(err)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(response))
+	}))
+	t.Cleanup(server.Close)
+	t.Setenv("ANTHROPIC_API_KEY", "test-key")
+	t.Setenv("ANTHROPIC_BASE_URL", server.URL)
+	return &got
+}
+
+func TestExtractTextJSONMode(t *testing.T) {
+	got := fakeAPI(t, `{"content":[{"type":"tool_use","name":"record_metadata","input":{"title":"Bridges"}}],"stop_reason":"tool_use"}`)
+
+	schema := map[string]any{"type": "object", "properties": map[string]any{"title": map[string]any{"type": "string"}}}
+	text, err := New().ExtractText(context.Background(), providers.Config{Model: "m", Prompt: "p", JSONMode: true, ResponseSchema: schema})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if text != `{"title":"Bridges"}` {
+		t.Errorf("ExtractText() = %s", text)
+	}
+
+	req := *got
+	if req["model"] != "m" || req["max_tokens"] != float64(defaultMaxTokens) {
+		t.Errorf("request = %v", req)
+	}
+	choice, _ := req["tool_choice"].(map[string]any)
+	if choice["name"] != jsonTool {
+		t.Errorf("tool_choice = %v, want the JSON tool forced", req["tool_choice"])
+	}
+}
+
+func TestGenerateFromImage(t *testing.T) {
+	got := fakeAPI(t, `{"content":[{"type":"text","text":"THE HISTORY"},{"type":"text","text":" OF BRIDGES"}],"stop_reason":"end_turn"}`)
+
+	png := []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+	text, err := New().GenerateFromImage(context.Background(), providers.Config{Model: "m", Prompt: "transcribe"}, png)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if text != "THE HISTORY OF BRIDGES" {
+		t.Errorf("GenerateFromImage() = %q", text)
+	}
+
+	messages := (*got)["messages"].([]any)
+	content := messages[0].(map[string]any)["content"].([]any)
+	source := content[0].(map[string]any)["source"].(map[string]any)
+	if source["media_type"] != "image/png" {
+		t.Errorf("image source = %v", source)
+	}
+	if _, ok := (*got)["tools"]; ok {
+		t.Error("OCR request should not force a tool")
+	}
+
+	if _, err := New().GenerateFromImage(context.Background(), providers.Config{}, []byte("%PDF-1.4")); err == nil {
+		t.Error("expected an error for a non-image file")
+	}
+}
+
+func TestRefusal(t *testing.T) {
+	fakeAPI(t, `{"content":[{"type":"text","text":"I can't help with that."}],"stop_reason":"refusal"}`)
+
+	_, err := New().ExtractText(context.Background(), providers.Config{Model: "m", Prompt: "p"})
+	var refusal *providers.RefusalError
+	if !errors.As(err, &refusal) || refusal.Provider != "claude" || refusal.Reason != "I can't help with that." {
+		t.Errorf("ExtractText() error = %v, want a refusal", err)
+	}
+}
+
+func TestMissingAPIKey(t *testing.T) {
+	t.Setenv("ANTHROPIC_API_KEY", "")
+	if _, err := New().ExtractText(context.Background(), providers.Config{}); err == nil {
+		t.Error("expected an error without ANTHROPIC_API_KEY")
+	}
+}
+
+func TestFileUploads(t *testing.T) {
+	var uploads, messages int
+	var sources []map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("anthropic-beta") != filesBeta {
+			t.Errorf("%s without the files beta header", r.URL.Path)
+		}
+		switch r.URL.Path {
+		case "/v1/files":
+			uploads++
+			if f, _, err := r.FormFile("file"); err != nil {
+				t.Error(err)
+			} else {
+				f.Close()
+			}
+			_, _ = w.Write([]byte(`{"id":"file_011"}`))
+		case "/v1/messages":
+			messages++
+			var body struct {
+				Messages []struct {
+					Content []struct {
+						Source map[string]any `json:"source"`
+					} `json:"content"`
+				} `json:"messages"`
+			}
+			_ = json.NewDecoder(r.Body).Decode(&body)
+			sources = append(sources, body.Messages[0].Content[0].Source)
+			_, _ = w.Write([]byte(`{"content":[{"type":"text","text":"WALDEN"}],"stop_reason":"end_turn"}`))
+		}
+	}))
+	defer server.Close()
+	t.Setenv("ANTHROPIC_API_KEY", "test-key")
+	t.Setenv("ANTHROPIC_BASE_URL", server.URL)
+	t.Setenv("PROVIDER_FILE_UPLOADS", "true")
+
+	png := []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+	for range 2 {
+		if text, err := New().GenerateFromImage(context.Background(), providers.Config{Model: "m", Prompt: "transcribe"}, png); err != nil || text != "WALDEN" {
+			t.Fatalf("GenerateFromImage() = %q, %v", text, err)
+		}
+	}
+	if uploads != 1 || messages != 2 {
+		t.Errorf("%d uploads for %d messages, want the image uploaded once", uploads, messages)
+	}
+	for _, s := range sources {
+		if s["type"] != "file" || s["file_id"] != "file_011" {
+			t.Errorf("image source = %v, want the uploaded file", s)
+		}
+	}
+}
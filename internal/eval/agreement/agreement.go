@@ -0,0 +1,205 @@
+// Package agreement measures how far models agree with each other on the same records,
+// independent of the reference, and flags fields where the models agree with each other
+// but not with the reference: often a sign the reference record is wrong
+package agreement
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/lehigh-university-libraries/cataloger/internal/eval/marceval"
+	"github.com/lehigh-university-libraries/cataloger/internal/marc"
+)
+
+// Defaults for Options
+const (
+	DefaultAgreeThreshold     = 0.9 // Pairwise field score at which two models agree
+	DefaultReferenceThreshold = 0.7 // Reference score below which a model disagrees with the reference
+)
+
+// Run is one model's results over a dataset
+type Run struct {
+	Name    string
+	Results []marceval.Result
+}
+
+// Options sets when models agree and when they differ from the reference
+type Options struct {
+	AgreeThreshold     float64
+	ReferenceThreshold float64
+}
+
+// FieldAgreement is how well the models agree on a tag, over the records where at least one
+// model generated it
+type FieldAgreement struct {
+	Tag       string
+	Records   int
+	Mean      float64 // Mean pairwise score
+	Unanimous int     // Records where every pair of models agrees
+	Suspect   int     // Unanimous records where every model differs from the reference
+}
+
+// Suspect is a record field every model generated alike but the reference has differently
+type Suspect struct {
+	ID             string
+	Title          string `json:",omitempty"`
+	Tag            string
+	Reference      []string // Normalized reference values
+	Consensus      []string // Normalized values of the first model
+	Agreement      float64  // Lowest pairwise score between the models
+	ReferenceScore float64  // Highest model score against the reference
+}
+
+// Analysis is the agreement between the runs on the records they all generated
+type Analysis struct {
+	Models   []string
+	Records  int
+	Fields   []FieldAgreement
+	Suspects []Suspect
+}
+
+// generated is a run's result with its parsed generated record
+type generated struct {
+	result marceval.Result
+	record *marc.Record
+}
+
+// Analyze compares the generated records of every pair of runs on the records all of them
+// generated. Each result must carry its GeneratedMARC.
+func Analyze(runs []Run, opts Options) (Analysis, error) {
+	if len(runs) < 2 {
+		return Analysis{}, fmt.Errorf("agreement needs at least two runs, got %d", len(runs))
+	}
+
+	byRun := make([]map[string]generated, len(runs))
+	for i, run := range runs {
+		byRun[i] = make(map[string]generated)
+		for _, res := range run.Results {
+			if res.Error != "" || res.GeneratedMARC == "" {
+				continue
+			}
+			rec, err := marc.ParseMnemonic(res.GeneratedMARC)
+			if err != nil {
+				return Analysis{}, fmt.Errorf("failed to parse generated record %s of %s: %w", res.ID, run.Name, err)
+			}
+			byRun[i][res.ID] = generated{result: res, record: rec}
+		}
+	}
+
+	var ids []string
+	for id := range byRun[0] {
+		inAll := true
+		for _, m := range byRun[1:] {
+			if _, ok := m[id]; !ok {
+				inAll = false
+				break
+			}
+		}
+		if inAll {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+
+	a := Analysis{Records: len(ids)}
+	for _, run := range runs {
+		a.Models = append(a.Models, run.Name)
+	}
+
+	fields := make(map[string]*FieldAgreement)
+	for _, id := range ids {
+		var pairs []map[string]float64
+		tags := make(map[string]bool)
+		for i := range runs {
+			for j := i + 1; j < len(runs); j++ {
+				scores := pairScores(byRun[i][id].record, byRun[j][id].record)
+				for tag := range scores {
+					tags[tag] = true
+				}
+				pairs = append(pairs, scores)
+			}
+		}
+
+		for tag := range tags {
+			sum, lowest := 0.0, 1.0
+			for _, scores := range pairs {
+				score, ok := scores[tag]
+				if !ok {
+					score = 1 // Neither record of the pair has the tag
+				}
+				sum += score
+				lowest = min(lowest, score)
+			}
+
+			f := fields[tag]
+			if f == nil {
+				f = &FieldAgreement{Tag: tag}
+				fields[tag] = f
+			}
+			f.Records++
+			f.Mean += sum / float64(len(pairs))
+			if lowest < opts.AgreeThreshold {
+				continue
+			}
+			f.Unanimous++
+
+			results := make([]marceval.Result, len(runs))
+			for i := range runs {
+				results[i] = byRun[i][id].result
+			}
+			if suspect, ok := contradictsReference(results, tag, opts.ReferenceThreshold); ok {
+				suspect.Agreement = lowest
+				f.Suspect++
+				a.Suspects = append(a.Suspects, suspect)
+			}
+		}
+	}
+
+	for _, f := range fields {
+		f.Mean /= float64(f.Records)
+		a.Fields = append(a.Fields, *f)
+	}
+	sort.Slice(a.Fields, func(i, j int) bool { return a.Fields[i].Tag < a.Fields[j].Tag })
+	sort.SliceStable(a.Suspects, func(i, j int) bool {
+		if a.Suspects[i].Tag != a.Suspects[j].Tag {
+			return a.Suspects[i].Tag < a.Suspects[j].Tag
+		}
+		return a.Suspects[i].ID < a.Suspects[j].ID
+	})
+	return a, nil
+}
+
+// pairScores scores two generated records against each other with the eval comparator,
+// covering the scored tags either record has; a tag only one has scores zero
+func pairScores(a, b *marc.Record) map[string]float64 {
+	scores := make(map[string]float64)
+	for tag, fs := range marceval.Compare(a, b).Fields {
+		scores[tag] = fs.Score
+	}
+	for tag := range marceval.Compare(b, a).Fields {
+		if _, ok := scores[tag]; !ok {
+			scores[tag] = 0 // Only b has it
+		}
+	}
+	return scores
+}
+
+// contradictsReference reports whether every model's field scores below threshold against
+// a reference that has the tag
+func contradictsReference(results []marceval.Result, tag string, threshold float64) (Suspect, bool) {
+	first := results[0]
+	suspect := Suspect{ID: first.ID, Title: first.Title, Tag: tag}
+	for _, res := range results {
+		if res.Comparison == nil {
+			return suspect, false
+		}
+		fs, ok := res.Comparison.Fields[tag]
+		if !ok || fs.Score >= threshold {
+			return suspect, false // The reference lacks the tag, or a model matches it
+		}
+		suspect.Reference = fs.Expected
+		suspect.ReferenceScore = max(suspect.ReferenceScore, fs.Score)
+	}
+	suspect.Consensus = first.Comparison.Fields[tag].Actual
+	return suspect, true
+}
@@ -0,0 +1,55 @@
+package agreement
+
+import (
+	"testing"
+
+	"github.com/lehigh-university-libraries/cataloger/internal/eval/marceval"
+	"github.com/lehigh-university-libraries/cataloger/internal/marc"
+)
+
+func result(t *testing.T, reference, generated string) marceval.Result {
+	t.Helper()
+	ref, err := marc.ParseMnemonic(reference)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gen, err := marc.ParseMnemonic(generated)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return marceval.Result{ID: "b1", GeneratedMARC: generated, Comparison: marceval.Compare(ref, gen)}
+}
+
+func TestAnalyze(t *testing.T) {
+	// The reference has a wrong date that both models read correctly; the models disagree on the title
+	reference := "=LDR  00000nam a2200000 i 4500\n=245  10$aThe history of bridges\n=264  \\1$aNew York :$bNorton,$c1889."
+	a := result(t, reference, "=LDR  00000nam a2200000 i 4500\n=245  10$aThe history of bridges\n=264  \\1$aLondon :$bMacmillan,$c1998.")
+	b := result(t, reference, "=LDR  00000nam a2200000 i 4500\n=245  10$aA history of tunnels\n=264  \\1$aLondon :$bMacmillan,$c1998.")
+
+	analysis, err := Analyze([]Run{{Name: "a", Results: []marceval.Result{a}}, {Name: "b", Results: []marceval.Result{b}}},
+		Options{AgreeThreshold: DefaultAgreeThreshold, ReferenceThreshold: DefaultReferenceThreshold})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if analysis.Records != 1 || len(analysis.Models) != 2 {
+		t.Fatalf("analysis = %+v", analysis)
+	}
+
+	fields := make(map[string]FieldAgreement)
+	for _, f := range analysis.Fields {
+		fields[f.Tag] = f
+	}
+	if f := fields["264"]; f.Unanimous != 1 || f.Suspect != 1 || f.Mean != 1 {
+		t.Errorf("264 = %+v, want unanimous and suspect", f)
+	}
+	if f := fields["245"]; f.Unanimous != 0 || f.Mean >= DefaultAgreeThreshold {
+		t.Errorf("245 = %+v, want disagreement", f)
+	}
+	if len(analysis.Suspects) != 1 || analysis.Suspects[0].Tag != "264" || analysis.Suspects[0].ID != "b1" {
+		t.Errorf("suspects = %+v", analysis.Suspects)
+	}
+
+	if _, err := Analyze([]Run{{Name: "a"}}, Options{}); err == nil {
+		t.Error("expected an error for a single run")
+	}
+}
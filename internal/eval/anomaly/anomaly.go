@@ -0,0 +1,163 @@
+// Package anomaly detects degenerate model output, which is a failed generation rather than
+// a record worth scoring
+package anomaly
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/lehigh-university-libraries/cataloger/internal/marc"
+	"github.com/lehigh-university-libraries/cataloger/internal/routing"
+)
+
+// Anomaly kinds
+const (
+	EmptyRecord   = "empty_record"   // No descriptive data, e.g. a response of empty strings
+	Repetition    = "repetition"     // The same line or word repeated over and over
+	WrongLanguage = "wrong_language" // Description in another language than the title page
+	Refusal       = "refusal"        // The model declined instead of answering
+)
+
+// Kinds lists the anomaly kinds in report order
+var Kinds = []string{EmptyRecord, Repetition, WrongLanguage, Refusal}
+
+// Anomaly is a degenerate output found by Check
+type Anomaly struct {
+	Kind   string
+	Detail string
+}
+
+func (a *Anomaly) Error() string {
+	return a.Kind + ": " + a.Detail
+}
+
+// Thresholds for repetition: a line making up at least half of four or more field lines, or a
+// run of the same word
+const (
+	minRepeatedLines = 4
+	minWordRun       = 6
+)
+
+// refusalPhrases open the answers of models declining a request
+var refusalPhrases = []string{
+	"i'm sorry", "i am sorry", "i apologize", "i cannot", "i can't", "i can not",
+	"i'm unable", "i am unable", "i'm not able", "i am not able", "as an ai",
+	"unable to assist", "unable to help", "cannot assist", "cannot help with",
+}
+
+// descriptiveTags hold the transcribed description compared for language
+var descriptiveTags = []string{"245", "246", "250", "264", "260", "490", "500", "520"}
+
+// Check returns the first anomaly of a generated record, given the OCR text it was generated
+// from and the model's raw response, or nil for a plausible record
+func Check(ocrText, response string, rec *marc.Record) *Anomaly {
+	if a := checkRefusal(response, rec); a != nil {
+		return a
+	}
+	if a := checkEmpty(rec); a != nil {
+		return a
+	}
+	if a := checkRepetition(rec); a != nil {
+		return a
+	}
+	return checkLanguage(ocrText, rec)
+}
+
+func checkRefusal(response string, rec *marc.Record) *Anomaly {
+	// A refusal opens the response or ends up as the title
+	texts := []string{strings.TrimLeft(response, " \t\r\n`{\"")}
+	if rec != nil {
+		texts = append(texts, rec.SubfieldValue("245", "a"))
+	}
+	for _, text := range texts {
+		head := strings.ToLower(text)
+		if len(head) > 200 {
+			head = head[:200]
+		}
+		head = strings.ReplaceAll(head, "’", "'")
+		for _, phrase := range refusalPhrases {
+			if strings.Contains(head, phrase) {
+				return &Anomaly{Kind: Refusal, Detail: fmt.Sprintf("response contains %q", phrase)}
+			}
+		}
+	}
+	return nil
+}
+
+func checkEmpty(rec *marc.Record) *Anomaly {
+	if rec == nil {
+		return &Anomaly{Kind: EmptyRecord, Detail: "no record"}
+	}
+	// Identifiers, 040 and local fields can come from lookups, the profile and hooks, so a
+	// record says nothing without a main entry, title or imprint
+	for _, f := range rec.DataFields {
+		if marc.TagMatches("1XX", f.Tag) || f.Tag == "245" || f.Tag == "260" || f.Tag == "264" {
+			if strings.TrimSpace(f.Text()) != "" {
+				return nil
+			}
+		}
+	}
+	return &Anomaly{Kind: EmptyRecord, Detail: "no main entry, title or imprint"}
+}
+
+func checkRepetition(rec *marc.Record) *Anomaly {
+	counts := make(map[string]int)
+	lines := 0
+	for _, f := range rec.DataFields {
+		text := strings.TrimSpace(f.Text())
+		if text == "" {
+			continue
+		}
+		lines++
+		counts[f.Tag+" "+text]++
+
+		if word, run := longestWordRun(text); run >= minWordRun {
+			return &Anomaly{Kind: Repetition, Detail: fmt.Sprintf("%q repeated %d times in %s", word, run, f.Tag)}
+		}
+	}
+	for line, n := range counts {
+		if n >= minRepeatedLines && n*2 >= lines {
+			return &Anomaly{Kind: Repetition, Detail: fmt.Sprintf("%d of %d fields are %q", n, lines, line)}
+		}
+	}
+	return nil
+}
+
+// longestWordRun returns the word repeated most times in a row in text
+func longestWordRun(text string) (string, int) {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	best, bestRun, run := "", 0, 0
+	for i, w := range words {
+		if i > 0 && w == words[i-1] {
+			run++
+		} else {
+			run = 1
+		}
+		if run > bestRun {
+			best, bestRun = w, run
+		}
+	}
+	return best, bestRun
+}
+
+func checkLanguage(ocrText string, rec *marc.Record) *Anomaly {
+	source := routing.DetectLanguage(ocrText)
+	if source == "" {
+		return nil
+	}
+	var parts []string
+	for _, tag := range descriptiveTags {
+		for _, f := range rec.Fields(tag) {
+			parts = append(parts, f.Text())
+		}
+	}
+	generated := routing.DetectLanguage(strings.Join(parts, " "))
+	// Romanized or translated-title records are fine; only a confident mismatch is flagged
+	if generated == "" || generated == source {
+		return nil
+	}
+	return &Anomaly{Kind: WrongLanguage, Detail: fmt.Sprintf("title page is %s but the description is %s", source, generated)}
+}
@@ -0,0 +1,84 @@
+package anomaly
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/lehigh-university-libraries/cataloger/internal/marc"
+)
+
+func record(fields ...string) *marc.Record {
+	rec := &marc.Record{Leader: "00000nam a2200000 i 4500"}
+	for _, f := range fields {
+		tag, value, _ := strings.Cut(f, " ")
+		rec.DataFields = append(rec.DataFields, marc.DataField{Tag: tag, Ind1: " ", Ind2: " ", Subfields: []marc.Subfield{{Code: "a", Value: value}}})
+	}
+	return rec
+}
+
+func TestCheck(t *testing.T) {
+	tests := []struct {
+		name     string
+		ocr      string
+		response string
+		rec      *marc.Record
+		want     string
+	}{
+		{
+			name:     "plausible",
+			ocr:      "The old man and the sea by Ernest Hemingway, published by Scribner",
+			response: `{"title":"The old man and the sea"}`,
+			rec:      record("100 Hemingway, Ernest", "245 The old man and the sea", "264 New York"),
+		},
+		{
+			name:     "refusal response",
+			response: "I'm sorry, but I can't help identify this book.",
+			rec:      record("245 Untitled"),
+			want:     Refusal,
+		},
+		{
+			name:     "refusal as title",
+			response: `{"title":"I cannot read the text in this image"}`,
+			rec:      record("245 I cannot read the text in this image"),
+			want:     Refusal,
+		},
+		{
+			name:     "empty",
+			response: `{"title":"","author":""}`,
+			rec:      record("040 PA", "035 (OCoLC)123"),
+			want:     EmptyRecord,
+		},
+		{
+			name: "repeated fields",
+			rec:  record("245 Poems", "650 Poetry", "650 Poetry", "650 Poetry", "650 Poetry"),
+			want: Repetition,
+		},
+		{
+			name: "word run",
+			rec:  record("245 The the the the the the the the"),
+			want: Repetition,
+		},
+		{
+			name: "wrong language",
+			ocr:  "Les misérables par Victor Hugo, avec une préface, éditions de la Pléiade",
+			rec:  record("100 Hugo, Victor", "245 The wretched, by Victor Hugo, with the preface of the editor and the publisher"),
+			want: WrongLanguage,
+		},
+		{
+			name: "unknown source language",
+			ocr:  "Hugo",
+			rec:  record("245 The wretched and the poor"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Check(tt.ocr, tt.response, tt.rec)
+			switch {
+			case tt.want == "" && got != nil:
+				t.Errorf("Check() = %v, want none", got)
+			case tt.want != "" && (got == nil || got.Kind != tt.want):
+				t.Errorf("Check() = %v, want %s", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,193 @@
+package baseline
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/lehigh-university-libraries/cataloger/internal/eval/marceval"
+	"github.com/lehigh-university-libraries/cataloger/internal/eval/metadata"
+)
+
+// Sources reported for naive lookups
+const (
+	SourceOpenLibrary = "openlibrary"
+	SourceGoogleBooks = "googlebooks"
+)
+
+// Lookup builds metadata from public bibliographic APIs by ISBN, with no LLM involved
+type Lookup struct {
+	HTTPClient     *http.Client
+	OpenLibraryURL string
+	GoogleBooksURL string
+}
+
+// NewLookup creates a lookup against the public Open Library and Google Books APIs
+func NewLookup() *Lookup {
+	return &Lookup{
+		HTTPClient:     &http.Client{Timeout: 30 * time.Second},
+		OpenLibraryURL: "https://openlibrary.org",
+		GoogleBooksURL: "https://www.googleapis.com",
+	}
+}
+
+// Metadata looks up an ISBN in Open Library, falling back to Google Books, and returns
+// the metadata with the name of the source that answered
+func (l *Lookup) Metadata(isbn string) (metadata.BookMetadata, string, error) {
+	md, olErr := l.openLibrary(isbn)
+	if olErr == nil {
+		return md, SourceOpenLibrary, nil
+	}
+	md, gbErr := l.googleBooks(isbn)
+	if gbErr == nil {
+		return md, SourceGoogleBooks, nil
+	}
+	return metadata.BookMetadata{}, "", fmt.Errorf("no lookup result for ISBN %s: %v; %v", isbn, olErr, gbErr)
+}
+
+type openLibraryData struct {
+	Title         string `json:"title"`
+	Subtitle      string `json:"subtitle"`
+	PublishDate   string `json:"publish_date"`
+	Authors       []name `json:"authors"`
+	Publishers    []name `json:"publishers"`
+	PublishPlaces []name `json:"publish_places"`
+	Subjects      []name `json:"subjects"`
+}
+
+type name struct {
+	Name string `json:"name"`
+}
+
+func (l *Lookup) openLibrary(isbn string) (metadata.BookMetadata, error) {
+	var result map[string]openLibraryData
+	u := fmt.Sprintf("%s/api/books?bibkeys=ISBN:%s&format=json&jscmd=data", l.OpenLibraryURL, url.QueryEscape(isbn))
+	if err := l.getJSON(u, &result); err != nil {
+		return metadata.BookMetadata{}, fmt.Errorf("failed to query Open Library: %w", err)
+	}
+
+	book, ok := result["ISBN:"+isbn]
+	if !ok || book.Title == "" {
+		return metadata.BookMetadata{}, fmt.Errorf("open Library has no record for ISBN %s", isbn)
+	}
+
+	md := metadata.BookMetadata{
+		Title:           joinTitle(book.Title, book.Subtitle),
+		PublicationDate: book.PublishDate,
+		ISBN:            []string{isbn},
+	}
+	if len(book.Authors) > 0 {
+		md.Author = InvertName(book.Authors[0].Name)
+	}
+	if len(book.Publishers) > 0 {
+		md.Publisher = book.Publishers[0].Name
+	}
+	if len(book.PublishPlaces) > 0 {
+		md.PublicationCity = book.PublishPlaces[0].Name
+	}
+	if len(book.Subjects) > 0 {
+		md.Subject = book.Subjects[0].Name
+	}
+	return md, nil
+}
+
+func (l *Lookup) googleBooks(isbn string) (metadata.BookMetadata, error) {
+	var result struct {
+		Items []struct {
+			VolumeInfo struct {
+				Title         string   `json:"title"`
+				Subtitle      string   `json:"subtitle"`
+				Authors       []string `json:"authors"`
+				Publisher     string   `json:"publisher"`
+				PublishedDate string   `json:"publishedDate"`
+				Language      string   `json:"language"`
+				Categories    []string `json:"categories"`
+			} `json:"volumeInfo"`
+		} `json:"items"`
+	}
+	u := fmt.Sprintf("%s/books/v1/volumes?q=isbn:%s", l.GoogleBooksURL, url.QueryEscape(isbn))
+	if err := l.getJSON(u, &result); err != nil {
+		return metadata.BookMetadata{}, fmt.Errorf("failed to query Google Books: %w", err)
+	}
+	if len(result.Items) == 0 || result.Items[0].VolumeInfo.Title == "" {
+		return metadata.BookMetadata{}, fmt.Errorf("google Books has no volume for ISBN %s", isbn)
+	}
+
+	info := result.Items[0].VolumeInfo
+	md := metadata.BookMetadata{
+		Title:           joinTitle(info.Title, info.Subtitle),
+		Publisher:       info.Publisher,
+		PublicationDate: info.PublishedDate,
+		Language:        info.Language,
+		ISBN:            []string{isbn},
+	}
+	if len(info.Authors) > 0 {
+		md.Author = InvertName(info.Authors[0])
+	}
+	if len(info.Categories) > 0 {
+		md.Subject = info.Categories[0]
+	}
+	return md, nil
+}
+
+func (l *Lookup) getJSON(u string, v any) error {
+	resp, err := l.HTTPClient.Get(u)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("status %d", resp.StatusCode)
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+func joinTitle(title, subtitle string) string {
+	if subtitle == "" {
+		return title
+	}
+	return title + " : " + subtitle
+}
+
+// InvertName turns a display name like "Ernest Hemingway" into heading order
+// ("Hemingway, Ernest"); names that already contain a comma are returned unchanged
+func InvertName(s string) string {
+	s = strings.TrimSpace(s)
+	if s == "" || strings.Contains(s, ",") {
+		return s
+	}
+	parts := strings.Fields(s)
+	if len(parts) < 2 {
+		return s
+	}
+	return parts[len(parts)-1] + ", " + strings.Join(parts[:len(parts)-1], " ")
+}
+
+// Lift compares a model run with a baseline run over the records both scored, returning
+// the number of shared records and each run's mean score over them
+func Lift(base, model *marceval.Report) (shared int, baseMean, modelMean float64) {
+	scores := make(map[string]float64, len(base.Results))
+	for _, r := range base.Results {
+		if r.Error == "" && r.Comparison != nil {
+			scores[r.ID] = r.Comparison.Score
+		}
+	}
+	for _, r := range model.Results {
+		baseScore, ok := scores[r.ID]
+		if !ok || r.Error != "" || r.Comparison == nil {
+			continue
+		}
+		shared++
+		baseMean += baseScore
+		modelMean += r.Comparison.Score
+	}
+	if shared > 0 {
+		baseMean /= float64(shared)
+		modelMean /= float64(shared)
+	}
+	return shared, baseMean, modelMean
+}
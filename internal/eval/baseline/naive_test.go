@@ -0,0 +1,64 @@
+package baseline
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestLookupMetadata(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/books":
+			if r.URL.Query().Get("bibkeys") != "ISBN:0684801221" {
+				_, _ = w.Write([]byte(`{}`))
+				return
+			}
+			_, _ = w.Write([]byte(`{"ISBN:0684801221": {
+				"title": "The old man and the sea",
+				"authors": [{"name": "Ernest Hemingway"}],
+				"publishers": [{"name": "Scribner"}],
+				"publish_places": [{"name": "New York"}],
+				"publish_date": "1995"}}`))
+		case "/books/v1/volumes":
+			_, _ = w.Write([]byte(`{"items": [{"volumeInfo": {
+				"title": "Moby Dick", "subtitle": "or, The whale",
+				"authors": ["Herman Melville"], "publisher": "Penguin", "publishedDate": "2003-01-01"}}]}`))
+		}
+	}))
+	defer srv.Close()
+
+	l := NewLookup()
+	l.OpenLibraryURL = srv.URL
+	l.GoogleBooksURL = srv.URL
+
+	md, source, err := l.Metadata("0684801221")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if source != SourceOpenLibrary || md.Author != "Hemingway, Ernest" || md.PublicationCity != "New York" {
+		t.Errorf("unexpected Open Library result %s: %+v", source, md)
+	}
+
+	md, source, err = l.Metadata("0142437247")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if source != SourceGoogleBooks || md.Title != "Moby Dick : or, The whale" || md.Author != "Melville, Herman" {
+		t.Errorf("unexpected Google Books fallback %s: %+v", source, md)
+	}
+}
+
+func TestInvertName(t *testing.T) {
+	tests := map[string]string{
+		"Ernest Hemingway":  "Hemingway, Ernest",
+		"Hemingway, Ernest": "Hemingway, Ernest",
+		"Homer":             "Homer",
+		"":                  "",
+	}
+	for in, want := range tests {
+		if got := InvertName(in); got != want {
+			t.Errorf("InvertName(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package baseline
+
+import (
+	"math"
+	"sort"
+
+	"github.com/lehigh-university-libraries/cataloger/internal/eval/marceval"
+	"github.com/lehigh-university-libraries/cataloger/internal/marc"
+	"github.com/lehigh-university-libraries/cataloger/internal/perturb"
+)
+
+// DefaultNoiseRates are the character noise rates sampled by PerturbationCurve
+var DefaultNoiseRates = []float64{0, 0.01, 0.02, 0.05, 0.1, 0.2, 0.3}
+
+// PerturbationPoint is the score distribution of references compared with perturbed copies
+// of themselves at one noise rate
+type PerturbationPoint struct {
+	CharRate  float64
+	DropRate  float64
+	Samples   int
+	MeanScore float64
+	MinScore  float64
+	MaxScore  float64
+}
+
+// PerturbationCurve scores every reference against perturbed copies of itself at each noise
+// rate, averaging over trials. The same seed always produces the same curve.
+func PerturbationCurve(references []*marc.Record, rates []float64, dropRate float64, trials int, seed uint64) []PerturbationPoint {
+	if trials < 1 {
+		trials = 1
+	}
+
+	points := make([]PerturbationPoint, 0, len(rates))
+	for _, rate := range rates {
+		rng := perturb.NewRand(seed)
+		opts := perturb.Options{CharRate: rate, DropRate: dropRate}
+		point := PerturbationPoint{CharRate: rate, DropRate: dropRate, MinScore: math.Inf(1), MaxScore: math.Inf(-1)}
+
+		for _, ref := range references {
+			for t := 0; t < trials; t++ {
+				score := marceval.Compare(ref, perturb.Record(ref, opts, rng)).Score
+				point.Samples++
+				point.MeanScore += score
+				point.MinScore = math.Min(point.MinScore, score)
+				point.MaxScore = math.Max(point.MaxScore, score)
+			}
+		}
+
+		if point.Samples > 0 {
+			point.MeanScore /= float64(point.Samples)
+		} else {
+			point.MinScore, point.MaxScore = 0, 0
+		}
+		points = append(points, point)
+	}
+
+	sort.Slice(points, func(i, j int) bool { return points[i].CharRate < points[j].CharRate })
+	return points
+}
+
+// EquivalentNoise returns the character noise rate at which perturbed references score
+// the given mean score, interpolating linearly along the curve. The second return value
+// is false when the score lies outside the curve's range.
+func EquivalentNoise(curve []PerturbationPoint, score float64) (float64, bool) {
+	for i := 1; i < len(curve); i++ {
+		hi, lo := curve[i-1], curve[i] // Scores fall as noise rises
+		if score <= hi.MeanScore && score >= lo.MeanScore {
+			if hi.MeanScore == lo.MeanScore {
+				return hi.CharRate, true
+			}
+			frac := (hi.MeanScore - score) / (hi.MeanScore - lo.MeanScore)
+			return hi.CharRate + frac*(lo.CharRate-hi.CharRate), true
+		}
+	}
+	return 0, false
+}
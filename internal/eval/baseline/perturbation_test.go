@@ -0,0 +1,63 @@
+package baseline
+
+import (
+	"math"
+	"testing"
+
+	"github.com/lehigh-university-libraries/cataloger/internal/marc"
+)
+
+func testRecord() *marc.Record {
+	rec := &marc.Record{Leader: "00000nam a2200000 i 4500"}
+	rec.DataFields = append(rec.DataFields,
+		marc.DataField{Tag: "100", Ind1: "1", Ind2: " ", Subfields: []marc.Subfield{{Code: "a", Value: "Hemingway, Ernest,"}}},
+		marc.DataField{Tag: "245", Ind1: "1", Ind2: "4", Subfields: []marc.Subfield{{Code: "a", Value: "The old man and the sea"}}},
+		marc.DataField{Tag: "264", Ind1: " ", Ind2: "1", Subfields: []marc.Subfield{{Code: "a", Value: "New York"}, {Code: "b", Value: "Scribner"}, {Code: "c", Value: "1952"}}},
+	)
+	return rec
+}
+
+func TestPerturbationCurve(t *testing.T) {
+	refs := []*marc.Record{testRecord()}
+	curve := PerturbationCurve(refs, []float64{0.3, 0}, 0, 4, 7)
+
+	if len(curve) != 2 || curve[0].CharRate != 0 {
+		t.Fatalf("expected curve sorted by rate, got %+v", curve)
+	}
+	if curve[0].MeanScore != 1 || curve[0].Samples != 4 {
+		t.Errorf("zero noise should score 1.0 over 4 samples, got %+v", curve[0])
+	}
+	if curve[1].MeanScore >= 1 {
+		t.Errorf("30%% noise should lower the score, got %v", curve[1].MeanScore)
+	}
+
+	again := PerturbationCurve(refs, []float64{0.3, 0}, 0, 4, 7)
+	if again[1].MeanScore != curve[1].MeanScore {
+		t.Errorf("same seed should reproduce the curve")
+	}
+}
+
+func TestEquivalentNoise(t *testing.T) {
+	curve := []PerturbationPoint{
+		{CharRate: 0, MeanScore: 1},
+		{CharRate: 0.1, MeanScore: 0.9},
+		{CharRate: 0.2, MeanScore: 0.7},
+	}
+
+	tests := []struct {
+		score float64
+		want  float64
+		ok    bool
+	}{
+		{1, 0, true},
+		{0.95, 0.05, true},
+		{0.8, 0.15, true},
+		{0.5, 0, false},
+	}
+	for _, tt := range tests {
+		got, ok := EquivalentNoise(curve, tt.score)
+		if ok != tt.ok || math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("EquivalentNoise(%v) = %v, %v; want %v, %v", tt.score, got, ok, tt.want, tt.ok)
+		}
+	}
+}
@@ -0,0 +1,274 @@
+// Package cluster groups low-scoring eval results by how they failed, so systematic
+// problems show up as one large cluster instead of many records to read one by one
+package cluster
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/lehigh-university-libraries/cataloger/internal/eval/marceval"
+)
+
+// wordWeight is the weight of a specific added or dropped word relative to the broader
+// features, which should dominate similarity
+const wordWeight = 0.5
+
+var yearPattern = regexp.MustCompile(`^\d{4}$`)
+
+// Record is one result reduced to its failure features
+type Record struct {
+	ID       string
+	Score    float64
+	Features map[string]float64
+}
+
+// FeatureShare is a feature and the share of a cluster's records that have it
+type FeatureShare struct {
+	Feature string
+	Share   float64
+}
+
+// Cluster is a group of records that failed in similar ways
+type Cluster struct {
+	Records   []string // IDs, lowest score first
+	MeanScore float64
+	Features  []FeatureShare // Most common first
+	Exemplar  string         // ID of the record closest to the cluster's center
+}
+
+// Features describes how a result failed: the error, anomaly and warning codes, and for
+// each imperfect field whether it was missing, wrong, truncated or extended, with the
+// kinds of words dropped or added
+func Features(res marceval.Result) map[string]float64 {
+	features := make(map[string]float64)
+	if code := res.FailureCode(); code != "" {
+		features["failed: "+string(code)] = 1
+	}
+	if res.Anomaly != "" {
+		features["anomaly: "+res.Anomaly] = 1
+	}
+	for _, w := range res.Warnings {
+		features["warning: "+w.Code] = 1
+	}
+
+	c := res.Comparison
+	if c == nil {
+		return features
+	}
+	if c.Extra > 0 {
+		features["extra fields"] = 1
+	}
+	for tag := range c.Duplicates {
+		features["duplicate "+tag] = 1
+	}
+	for tag, fs := range c.Fields {
+		if fs.Score >= 1 {
+			continue
+		}
+		if len(fs.Actual) == 0 {
+			features[tag+" missing"] = 1
+			continue
+		}
+		if fs.Score < 0.7 {
+			features[tag+" incorrect"] = 1
+		} else {
+			features[tag+" partial"] = 1
+		}
+		switch {
+		case len(fs.Actual) < len(fs.Expected):
+			features[tag+" fewer values"] = 1
+		case len(fs.Actual) > len(fs.Expected):
+			features[tag+" more values"] = 1
+		}
+
+		expected, actual := strings.Join(fs.Expected, " "), strings.Join(fs.Actual, " ")
+		switch {
+		case expected == actual:
+		case strings.HasPrefix(expected, actual):
+			features[tag+" truncated"] = 1
+		case strings.HasPrefix(actual, expected):
+			features[tag+" extended"] = 1
+		}
+		dropped, added := wordDiff(words(expected), words(actual))
+		for _, w := range dropped {
+			features[tag+" drops "+wordClass(w)] = 1
+			features[fmt.Sprintf("%s drops %q", tag, w)] = wordWeight
+		}
+		for _, w := range added {
+			features[tag+" adds "+wordClass(w)] = 1
+			features[fmt.Sprintf("%s adds %q", tag, w)] = wordWeight
+		}
+	}
+	return features
+}
+
+func words(s string) []string {
+	return strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// wordDiff returns the words only in expected and only in actual, counting repeats
+func wordDiff(expected, actual []string) (dropped, added []string) {
+	counts := make(map[string]int)
+	for _, w := range actual {
+		counts[w]++
+	}
+	for _, w := range expected {
+		if counts[w] > 0 {
+			counts[w]--
+		} else {
+			dropped = append(dropped, w)
+		}
+	}
+	for _, w := range actual {
+		if counts[w] > 0 {
+			counts[w]--
+			added = append(added, w)
+		}
+	}
+	return dropped, added
+}
+
+func wordClass(w string) string {
+	switch {
+	case yearPattern.MatchString(w):
+		return "year"
+	case strings.IndexFunc(w, unicode.IsLetter) < 0:
+		return "number"
+	}
+	return "word"
+}
+
+// Build clusters records whose TF-IDF weighted features have at least the given cosine
+// similarity to a cluster's center. Records are taken lowest score first, so the result is
+// deterministic; clusters are returned largest first, each summarized by its top features.
+func Build(records []Record, similarity float64, topFeatures int) []Cluster {
+	idf := inverseFrequencies(records)
+	sorted := append([]Record(nil), records...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if sorted[i].Score != sorted[j].Score {
+			return sorted[i].Score < sorted[j].Score
+		}
+		return sorted[i].ID < sorted[j].ID
+	})
+
+	type group struct {
+		members []int
+		center  map[string]float64 // Sum of member vectors
+	}
+	var groups []*group
+	vectors := make([]map[string]float64, len(sorted))
+	for i, rec := range sorted {
+		vectors[i] = weighted(rec.Features, idf)
+		best, bestSim := -1, similarity
+		for g, grp := range groups {
+			if sim := cosine(vectors[i], grp.center); sim >= bestSim {
+				best, bestSim = g, sim
+			}
+		}
+		if best < 0 {
+			groups = append(groups, &group{center: make(map[string]float64)})
+			best = len(groups) - 1
+		}
+		grp := groups[best]
+		grp.members = append(grp.members, i)
+		for f, v := range vectors[i] {
+			grp.center[f] += v
+		}
+	}
+
+	clusters := make([]Cluster, 0, len(groups))
+	for _, grp := range groups {
+		c := Cluster{}
+		counts := make(map[string]int)
+		weights := make(map[string]float64)
+		exemplarSim := -1.0
+		for _, i := range grp.members {
+			rec := sorted[i]
+			c.Records = append(c.Records, rec.ID)
+			c.MeanScore += rec.Score
+			for f, w := range rec.Features {
+				counts[f]++
+				weights[f] = w
+			}
+			if sim := cosine(vectors[i], grp.center); sim > exemplarSim {
+				c.Exemplar, exemplarSim = rec.ID, sim
+			}
+		}
+		c.MeanScore /= float64(len(grp.members))
+		c.Features = topShares(counts, weights, len(grp.members), topFeatures)
+		clusters = append(clusters, c)
+	}
+	sort.SliceStable(clusters, func(i, j int) bool { return len(clusters[i].Records) > len(clusters[j].Records) })
+	return clusters
+}
+
+// inverseFrequencies weights features by how rare they are across records, so a feature
+// every record shares doesn't make all records look alike
+func inverseFrequencies(records []Record) map[string]float64 {
+	df := make(map[string]int)
+	for _, rec := range records {
+		for f := range rec.Features {
+			df[f]++
+		}
+	}
+	idf := make(map[string]float64, len(df))
+	for f, n := range df {
+		idf[f] = math.Log(float64(1+len(records))/float64(1+n)) + 1
+	}
+	return idf
+}
+
+func weighted(features, idf map[string]float64) map[string]float64 {
+	v := make(map[string]float64, len(features))
+	for f, w := range features {
+		v[f] = w * idf[f]
+	}
+	return v
+}
+
+func cosine(a, b map[string]float64) float64 {
+	var dot, na, nb float64
+	for f, v := range a {
+		dot += v * b[f]
+		na += v * v
+	}
+	for _, v := range b {
+		nb += v * v
+	}
+	if na == 0 || nb == 0 {
+		// Records with no features at all are alike
+		if na == nb {
+			return 1
+		}
+		return 0
+	}
+	return dot / math.Sqrt(na*nb)
+}
+
+// topShares returns the n features most common among a cluster's records, ties broken by
+// weight, so broad features come before the specific words, then by name
+func topShares(counts map[string]int, weights map[string]float64, total, n int) []FeatureShare {
+	shares := make([]FeatureShare, 0, len(counts))
+	for f, count := range counts {
+		shares = append(shares, FeatureShare{Feature: f, Share: float64(count) / float64(total)})
+	}
+	sort.Slice(shares, func(i, j int) bool {
+		if shares[i].Share != shares[j].Share {
+			return shares[i].Share > shares[j].Share
+		}
+		if wi, wj := weights[shares[i].Feature], weights[shares[j].Feature]; wi != wj {
+			return wi > wj
+		}
+		return shares[i].Feature < shares[j].Feature
+	})
+	if n > 0 && len(shares) > n {
+		shares = shares[:n]
+	}
+	return shares
+}
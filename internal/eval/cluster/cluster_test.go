@@ -0,0 +1,88 @@
+package cluster
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/lehigh-university-libraries/cataloger/internal/eval/failure"
+	"github.com/lehigh-university-libraries/cataloger/internal/eval/marceval"
+)
+
+func TestFeatures(t *testing.T) {
+	res := marceval.Result{
+		ID: "b1",
+		Comparison: &marceval.Comparison{
+			Fields: map[string]marceval.FieldScore{
+				"100": {Expected: []string{"hemingway ernest"}, Score: 0},
+				"245": {Expected: []string{"the old man and the sea a novel"}, Actual: []string{"the old man and the sea"}, Score: 0.8},
+				"264": {Expected: []string{"new york scribner 1952"}, Actual: []string{"new york scribner"}, Score: 0.75},
+				"300": {Expected: []string{"140 pages"}, Actual: []string{"140 pages"}, Score: 1},
+			},
+			Extra: 1,
+		},
+	}
+	features := Features(res)
+	for _, want := range []string{"100 missing", "245 partial", "245 truncated", "245 drops word", `245 drops "novel"`, "264 drops year", "extra fields"} {
+		if features[want] == 0 {
+			t.Errorf("Features() lacks %q: %v", want, features)
+		}
+	}
+	for f := range features {
+		if f[:3] == "300" {
+			t.Errorf("Features() has %q for a perfectly matched field", f)
+		}
+	}
+	if features[`245 drops "novel"`] != wordWeight {
+		t.Errorf("word feature weight = %v, want %v", features[`245 drops "novel"`], wordWeight)
+	}
+
+	failed := Features(marceval.Result{Error: "bad JSON", ErrorCode: failure.ParseFailure})
+	if failed["failed: "+string(failure.ParseFailure)] != 1 {
+		t.Errorf("Features(failed) = %v, want the error code", failed)
+	}
+}
+
+func TestBuild(t *testing.T) {
+	var records []Record
+	for i := range 6 {
+		records = append(records, Record{
+			ID:       fmt.Sprintf("trunc%d", i),
+			Score:    0.5 + float64(i)/100,
+			Features: map[string]float64{"245 truncated": 1, "245 drops word": 1, "245 partial": 1},
+		})
+	}
+	for i := range 4 {
+		records = append(records, Record{
+			ID:       fmt.Sprintf("date%d", i),
+			Score:    0.4,
+			Features: map[string]float64{"264 drops year": 1, "264 incorrect": 1},
+		})
+	}
+	records = append(records, Record{ID: "odd", Score: 0.1, Features: map[string]float64{"failed: provider_error": 1}})
+
+	clusters := Build(records, 0.5, 2)
+	if len(clusters) != 3 {
+		t.Fatalf("Build() = %d clusters, want 3: %+v", len(clusters), clusters)
+	}
+	if len(clusters[0].Records) != 6 || len(clusters[1].Records) != 4 || len(clusters[2].Records) != 1 {
+		t.Errorf("cluster sizes = %d, %d, %d, want 6, 4, 1", len(clusters[0].Records), len(clusters[1].Records), len(clusters[2].Records))
+	}
+	if clusters[0].Records[0] != "trunc0" {
+		t.Errorf("first record = %s, want lowest score first", clusters[0].Records[0])
+	}
+	if len(clusters[1].Features) != 2 || clusters[1].Features[0].Share != 1 {
+		t.Errorf("date cluster features = %+v, want 2 shared by all", clusters[1].Features)
+	}
+	if clusters[2].Exemplar != "odd" {
+		t.Errorf("exemplar = %s, want odd", clusters[2].Exemplar)
+	}
+}
+
+func TestTopSharesPrefersBroadFeatures(t *testing.T) {
+	counts := map[string]int{`245 adds "a"`: 2, "245 adds word": 2, "100 missing": 1}
+	weights := map[string]float64{`245 adds "a"`: wordWeight, "245 adds word": 1, "100 missing": 1}
+	shares := topShares(counts, weights, 2, 2)
+	if len(shares) != 2 || shares[0].Feature != "245 adds word" || shares[1].Feature != `245 adds "a"` {
+		t.Errorf("topShares() = %+v", shares)
+	}
+}
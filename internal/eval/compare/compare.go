@@ -0,0 +1,264 @@
+package compare
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/lehigh-university-libraries/cataloger/internal/eval/metrics"
+	resultsutil "github.com/lehigh-university-libraries/cataloger/internal/eval/results"
+)
+
+// Grouping keys for Group
+const (
+	ByPrompt   = "prompt"
+	ByModel    = "model"
+	ByProvider = "provider"
+	ByFile     = "file"
+)
+
+// unversioned labels results written before prompt versions were recorded
+const unversioned = "(unversioned)"
+
+// Row is a single record's outcome from any results file
+type Row struct {
+	Source        string
+	Identifier    string
+	Provider      string
+	Model         string
+	PromptVersion string
+	Failed        bool
+	Score         float64
+	FieldScores   map[string]float64
+}
+
+// LoadRows reads an eval results file: the JSON written by --output-json or the YAML in evals/
+func LoadRows(path string) ([]Row, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read results: %w", err)
+	}
+
+	source := filepath.Base(path)
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		var spec resultsutil.EvalSpec
+		if _, err := resultsutil.SpecSchema.DecodeYAML(data, &spec); err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+		}
+		return rowsFromSpec(source, spec), nil
+	default:
+		var agg metrics.AggregateResults
+		if err := json.Unmarshal(data, &agg); err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+		}
+		return rowsFromAggregate(source, agg), nil
+	}
+}
+
+func rowsFromAggregate(source string, agg metrics.AggregateResults) []Row {
+	rows := make([]Row, 0, len(agg.Results))
+	for _, r := range agg.Results {
+		row := Row{
+			Source:        source,
+			Identifier:    r.Barcode,
+			Provider:      firstNonEmpty(r.Provider, agg.Provider),
+			Model:         firstNonEmpty(r.Model, agg.Model),
+			PromptVersion: r.PromptVersion,
+			Failed:        r.Error != "",
+		}
+		if r.FullComparison != nil {
+			row.Score = r.FullComparison.OverallScore
+			row.FieldScores = make(map[string]float64, len(r.FullComparison.Fields))
+			for name, field := range r.FullComparison.Fields {
+				row.FieldScores[name] = field.Score
+			}
+		}
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+func rowsFromSpec(source string, spec resultsutil.EvalSpec) []Row {
+	rows := make([]Row, 0, len(spec.Results))
+	for _, r := range spec.Results {
+		rows = append(rows, Row{
+			Source:        source,
+			Identifier:    r.Identifier,
+			Provider:      firstNonEmpty(r.Provider, spec.Config.Provider),
+			Model:         firstNonEmpty(r.Model, spec.Config.Model),
+			PromptVersion: firstNonEmpty(r.PromptVersion, spec.Config.PromptVersion),
+			Score:         r.OverallScore,
+			FieldScores:   r.FieldScores,
+		})
+	}
+	return rows
+}
+
+// Group summarizes the rows sharing a grouping key
+type Group struct {
+	Key        string
+	Records    int
+	Failed     int
+	MeanScore  float64
+	FieldMeans map[string]float64
+	Models     []string // Distinct provider/model pairs, to spot confounded comparisons
+	Prompts    []string // Distinct prompt versions
+}
+
+// GroupRows groups rows by the given key and averages scores over successful records.
+// Groups are sorted by key.
+func GroupRows(rows []Row, by string) ([]Group, error) {
+	keyOf, err := keyFunc(by)
+	if err != nil {
+		return nil, err
+	}
+
+	type acc struct {
+		group       Group
+		fieldSums   map[string]float64
+		fieldCounts map[string]int
+		models      map[string]bool
+		prompts     map[string]bool
+	}
+	accs := make(map[string]*acc)
+
+	for _, row := range rows {
+		key := keyOf(row)
+		a, ok := accs[key]
+		if !ok {
+			a = &acc{
+				group:       Group{Key: key},
+				fieldSums:   make(map[string]float64),
+				fieldCounts: make(map[string]int),
+				models:      make(map[string]bool),
+				prompts:     make(map[string]bool),
+			}
+			accs[key] = a
+		}
+
+		a.group.Records++
+		a.models[row.Provider+"/"+row.Model] = true
+		a.prompts[promptLabel(row)] = true
+		if row.Failed {
+			a.group.Failed++
+			continue
+		}
+		a.group.MeanScore += row.Score
+		for name, score := range row.FieldScores {
+			a.fieldSums[name] += score
+			a.fieldCounts[name]++
+		}
+	}
+
+	groups := make([]Group, 0, len(accs))
+	for _, a := range accs {
+		g := a.group
+		if ok := g.Records - g.Failed; ok > 0 {
+			g.MeanScore /= float64(ok)
+		}
+		g.FieldMeans = make(map[string]float64, len(a.fieldSums))
+		for name, sum := range a.fieldSums {
+			g.FieldMeans[name] = sum / float64(a.fieldCounts[name])
+		}
+		g.Models = sortedKeys(a.models)
+		g.Prompts = sortedKeys(a.prompts)
+		groups = append(groups, g)
+	}
+
+	sort.Slice(groups, func(i, j int) bool { return groups[i].Key < groups[j].Key })
+	return groups, nil
+}
+
+func keyFunc(by string) (func(Row) string, error) {
+	switch by {
+	case ByPrompt:
+		return promptLabel, nil
+	case ByModel:
+		return func(r Row) string { return r.Provider + "/" + r.Model }, nil
+	case ByProvider:
+		return func(r Row) string { return r.Provider }, nil
+	case ByFile:
+		return func(r Row) string { return r.Source }, nil
+	default:
+		return nil, fmt.Errorf("unsupported grouping %q (use prompt, model, provider or file)", by)
+	}
+}
+
+func promptLabel(r Row) string {
+	if r.PromptVersion == "" {
+		return unversioned
+	}
+	return r.PromptVersion
+}
+
+// PrintTable writes the groups with each group's score delta from the first group
+func PrintTable(w io.Writer, groups []Group, by string) {
+	fmt.Fprintf(w, "%-45s %8s %7s %8s %8s\n", strings.ToUpper(by), "RECORDS", "FAILED", "SCORE", "DELTA")
+	fmt.Fprintln(w, strings.Repeat("-", 80))
+	for i, g := range groups {
+		delta := ""
+		if i > 0 {
+			delta = fmt.Sprintf("%+.3f", g.MeanScore-groups[0].MeanScore)
+		}
+		fmt.Fprintf(w, "%-45s %8d %7d %8.3f %8s\n", g.Key, g.Records, g.Failed, g.MeanScore, delta)
+
+		// Flag mixed groups so a score change isn't attributed to the wrong cause
+		if by == ByPrompt && len(g.Models) > 1 {
+			fmt.Fprintf(w, "  models: %s\n", strings.Join(g.Models, ", "))
+		}
+		if by != ByPrompt && len(g.Prompts) > 1 {
+			fmt.Fprintf(w, "  prompts: %s\n", strings.Join(g.Prompts, ", "))
+		}
+	}
+
+	fields := make(map[string]bool)
+	for _, g := range groups {
+		for name := range g.FieldMeans {
+			fields[name] = true
+		}
+	}
+	if len(fields) == 0 {
+		return
+	}
+
+	fmt.Fprintln(w)
+	fmt.Fprintf(w, "%-45s", "FIELD")
+	for i := range groups {
+		fmt.Fprintf(w, " %8s", fmt.Sprintf("#%d", i+1))
+	}
+	fmt.Fprintln(w)
+	for _, name := range sortedKeys(fields) {
+		fmt.Fprintf(w, "%-45s", name)
+		for _, g := range groups {
+			if score, ok := g.FieldMeans[name]; ok {
+				fmt.Fprintf(w, " %8.3f", score)
+			} else {
+				fmt.Fprintf(w, " %8s", "-")
+			}
+		}
+		fmt.Fprintln(w)
+	}
+}
+
+func sortedKeys(m map[string]bool) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
@@ -0,0 +1,163 @@
+package dataset
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/lehigh-university-libraries/cataloger/internal/holdings"
+	"github.com/lehigh-university-libraries/cataloger/internal/marc"
+)
+
+// lcClass matches the class letters and number that start an LC call number
+var lcClass = regexp.MustCompile(`^([A-Z]{1,3})\s*(\d+(?:\.\d+)?)?`)
+
+// ClassRange is a range of LC classes: a class ("PS"), a subclass ("Q" covers QA, QB, ...),
+// or a range of classes and numbers ("QA76-QA76.9", "PA-PT")
+type ClassRange struct {
+	spec     string
+	from, to classKey
+	prefix   bool // A single letters-only class, matched as a prefix
+}
+
+// classKey is a position in LC order: class letters, then class number
+type classKey struct {
+	letters string
+	number  float64
+}
+
+func (a classKey) compare(b classKey) int {
+	switch {
+	case a.letters < b.letters:
+		return -1
+	case a.letters > b.letters:
+		return 1
+	case a.number < b.number:
+		return -1
+	case a.number > b.number:
+		return 1
+	}
+	return 0
+}
+
+// ParseClassRange parses an LC class range such as PS, QA76-QA76.9 or PA-PT
+func ParseClassRange(spec string) (ClassRange, error) {
+	s := strings.ToUpper(strings.ReplaceAll(strings.TrimSpace(spec), " ", ""))
+	fromText, toText, isRange := strings.Cut(s, "-")
+	from, fromNumber, ok := parseClassKey(fromText)
+	if !ok {
+		return ClassRange{}, fmt.Errorf("invalid LC class range %q", spec)
+	}
+	r := ClassRange{spec: spec, from: from, to: from}
+	if !fromNumber {
+		r.from.number = math.Inf(-1)
+		r.to.number = math.Inf(1)
+	}
+	if !isRange {
+		r.prefix = !fromNumber
+		return r, nil
+	}
+
+	to, toNumber, ok := parseClassKey(toText)
+	if !ok {
+		return ClassRange{}, fmt.Errorf("invalid LC class range %q", spec)
+	}
+	r.to = to
+	if !toNumber {
+		r.to.number = math.Inf(1)
+	}
+	if r.from.compare(r.to) > 0 {
+		return ClassRange{}, fmt.Errorf("invalid LC class range %q: start is after end", spec)
+	}
+	return r, nil
+}
+
+// parseClassKey parses class letters with an optional number, reporting whether it had one
+func parseClassKey(s string) (classKey, bool, bool) {
+	m := lcClass.FindStringSubmatch(s)
+	if m == nil || len(m[0]) != len(s) {
+		return classKey{}, false, false
+	}
+	if m[2] == "" {
+		return classKey{letters: m[1]}, false, true
+	}
+	n, err := strconv.ParseFloat(m[2], 64)
+	if err != nil {
+		return classKey{}, false, false
+	}
+	return classKey{letters: m[1], number: n}, true, true
+}
+
+// Contains reports whether an LC call number falls in the range
+func (r ClassRange) Contains(callNumber string) bool {
+	m := lcClass.FindStringSubmatch(strings.ToUpper(strings.TrimSpace(callNumber)))
+	if m == nil {
+		return false
+	}
+	if r.prefix {
+		return strings.HasPrefix(m[1], r.from.letters)
+	}
+	key := classKey{letters: m[1]}
+	if m[2] != "" {
+		key.number, _ = strconv.ParseFloat(m[2], 64)
+	}
+	return r.from.compare(key) <= 0 && key.compare(r.to) <= 0
+}
+
+func (r ClassRange) String() string {
+	return r.spec
+}
+
+// ParseClassRanges parses a list of LC class ranges
+func ParseClassRanges(specs []string) ([]ClassRange, error) {
+	ranges := make([]ClassRange, 0, len(specs))
+	for _, spec := range specs {
+		r, err := ParseClassRange(spec)
+		if err != nil {
+			return nil, err
+		}
+		ranges = append(ranges, r)
+	}
+	return ranges, nil
+}
+
+// LCCallNumber returns a record's LC call number from 050 or 090, or from an 852 shelved
+// by LC (first indicator 0) in $h, or "" when it has none
+func LCCallNumber(rec *marc.Record) string {
+	if cn := holdings.CallNumberFromRecord(rec); cn.Scheme == holdings.SchemeLC {
+		return cn.String()
+	}
+	for _, f := range rec.Fields("852") {
+		if f.Ind1 == "0" {
+			if h := strings.TrimSpace(f.Subfield("h")); h != "" {
+				return h
+			}
+		}
+	}
+	return ""
+}
+
+// DefaultLocationFields are the holdings subfields location codes are read from: the
+// 852 location and shelving location
+var DefaultLocationFields = []string{"852b", "852c"}
+
+// Locations returns the location codes in the given holdings subfields (tag followed by
+// subfield code, e.g. 852b or 949l)
+func Locations(rec *marc.Record, fields []string) []string {
+	var codes []string
+	for _, spec := range fields {
+		if len(spec) != 4 {
+			continue
+		}
+		for _, f := range rec.Fields(spec[:3]) {
+			for _, sf := range f.Subfields {
+				if sf.Code == spec[3:] && strings.TrimSpace(sf.Value) != "" {
+					codes = append(codes, strings.TrimSpace(sf.Value))
+				}
+			}
+		}
+	}
+	return codes
+}
@@ -0,0 +1,85 @@
+package dataset
+
+import (
+	"testing"
+
+	"github.com/lehigh-university-libraries/cataloger/internal/marc"
+)
+
+func TestClassRange(t *testing.T) {
+	tests := []struct {
+		spec string
+		in   []string
+		out  []string
+	}{
+		{"PS", []string{"PS3515.E37 O4 1952", "PS 1"}, []string{"PT2603.R397", "P1", "QA76"}},
+		{"Q", []string{"QA76.73.P98", "QB43", "Q175"}, []string{"PS3515", "R131"}},
+		{"qa76-qa76.9", []string{"QA76", "QA76.73.P98 L88", "QA76.9.D3"}, []string{"QA75.5", "QA77", "QB76"}},
+		{"PA-PT", []string{"PA3", "PR6025", "PT2603"}, []string{"P1", "PZ7", "Q1"}},
+		{"QA1-QB99", []string{"QA500", "QB43"}, []string{"QB100", "Q175"}},
+	}
+	for _, tc := range tests {
+		r, err := ParseClassRange(tc.spec)
+		if err != nil {
+			t.Fatalf("ParseClassRange(%q): %v", tc.spec, err)
+		}
+		for _, cn := range tc.in {
+			if !r.Contains(cn) {
+				t.Errorf("%s should contain %s", tc.spec, cn)
+			}
+		}
+		for _, cn := range tc.out {
+			if r.Contains(cn) {
+				t.Errorf("%s should not contain %s", tc.spec, cn)
+			}
+		}
+	}
+
+	for _, bad := range []string{"", "76", "QA76-", "QB-QA", "QA76.9-QA76", "ABCD"} {
+		if _, err := ParseClassRange(bad); err == nil {
+			t.Errorf("ParseClassRange(%q) succeeded, want an error", bad)
+		}
+	}
+}
+
+func TestFilterCallNumberAndLocation(t *testing.T) {
+	record := func(fields ...marc.DataField) *marc.Record {
+		return &marc.Record{Leader: "00000nam a2200000 i 4500", DataFields: fields}
+	}
+	field := func(tag, ind1 string, sf ...string) marc.DataField {
+		f := marc.DataField{Tag: tag, Ind1: ind1, Ind2: " "}
+		for i := 0; i+1 < len(sf); i += 2 {
+			f.Subfields = append(f.Subfields, marc.Subfield{Code: sf[i], Value: sf[i+1]})
+		}
+		return f
+	}
+
+	ranges, err := ParseClassRanges([]string{"PS", "QA76-QA76.9"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := Filter{ClassRanges: ranges, Locations: []string{"SPEC"}}
+
+	tests := []struct {
+		name   string
+		rec    *marc.Record
+		reason string
+	}{
+		{"050 in range at location", record(field("050", " ", "a", "PS3515.E37", "b", "O4"), field("852", "0", "b", "spec")), ""},
+		{"852 call number", record(field("852", "0", "b", "SPEC", "h", "QA76.73.P98", "i", "L88")), ""},
+		{"Dewey only", record(field("082", "0", "a", "813/.54"), field("852", "1", "b", "SPEC", "h", "813.54")), "no LC call number"},
+		{"outside ranges", record(field("050", " ", "a", "PT2603"), field("852", "0", "b", "SPEC")), "outside call number ranges"},
+		{"other location", record(field("090", " ", "a", "PS3515"), field("852", "0", "b", "MAIN", "c", "STACKS")), "outside locations"},
+	}
+	for _, tc := range tests {
+		ok, reason := f.Match(tc.rec)
+		if ok != (tc.reason == "") || reason != tc.reason {
+			t.Errorf("%s: Match() = %v, %q, want %q", tc.name, ok, reason, tc.reason)
+		}
+	}
+
+	local := Filter{Locations: []string{"rare"}, LocationFields: []string{"949l"}}
+	if ok, _ := local.Match(record(field("949", " ", "l", "RARE"))); !ok {
+		t.Error("location read from a local holdings field should match")
+	}
+}
@@ -8,6 +8,8 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+
+	"github.com/lehigh-university-libraries/cataloger/internal/usage"
 )
 
 const (
@@ -98,7 +100,7 @@ func (d *Downloader) downloadFile(url, destPath string) error {
 	}
 
 	// Execute request
-	client := &http.Client{}
+	client := &http.Client{Transport: &usage.CountingTransport{}}
 	resp, err := client.Do(req)
 	if err != nil {
 		return fmt.Errorf("failed to download: %w", err)
@@ -0,0 +1,238 @@
+package dataset
+
+import (
+	"encoding/json"
+	"fmt"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// EnrichmentReportFilename is the coverage report eval enrich writes to the dataset directory
+const EnrichmentReportFilename = "enrichment_report.json"
+
+// ImageKinds are the image kinds of ItemImages, in report order
+var ImageKinds = []string{"cover", "title_page", "copyright_page"}
+
+// EnrichmentReport describes the image coverage of a dataset after enrichment
+type EnrichmentReport struct {
+	Dataset     string    `json:"dataset"`
+	GeneratedAt time.Time `json:"generated_at"`
+	Items       int       `json:"items"`
+	WithISBN    int       `json:"with_isbn"`
+
+	// Outcome of the enrich run that wrote the report
+	Enriched int `json:"enriched"`
+	Skipped  int `json:"skipped"` // Already had images
+	Failed   int `json:"failed"`  // No images found
+
+	// Image sources paused for answering 429 or 403, and until when
+	PausedSources map[string]time.Time `json:"paused_sources,omitempty"`
+
+	Kinds map[string]*KindCoverage `json:"kinds"`
+}
+
+// KindCoverage is the coverage of one image kind
+type KindCoverage struct {
+	Items      int            `json:"items"`           // Items with an image of this kind
+	ISBNItems  int            `json:"isbn_items"`      // Items with an ISBN and an image of this kind
+	Sources    map[string]int `json:"sources"`         // Source (856, iiif, openlibrary, ...) -> images
+	Sizes      map[string]int `json:"sizes,omitempty"` // Open Library cover size (L, M, S) -> images
+	Missing    int            `json:"missing"`         // Image files listed in dataset.json but not on disk
+	MeanBytes  int64          `json:"mean_bytes"`      // Over images on disk
+	MeanWidth  int            `json:"mean_width"`      // Over images whose dimensions could be read
+	MeanHeight int            `json:"mean_height"`     // Over images whose dimensions could be read
+	Unmeasured int            `json:"unmeasured"`      // Images in a format whose dimensions can't be read (TIFF, JP2, ...)
+	MinWidth   int            `json:"min_width"`       // Smallest width, to spot thumbnails
+}
+
+// NewEnrichmentReport measures the images of items, stored relative to the dataset directory.
+// Sources come from the "<kind>_source" item metadata written by eval enrich.
+func NewEnrichmentReport(d *MARCDataset, items []DatasetItem) *EnrichmentReport {
+	r := &EnrichmentReport{
+		Dataset:     d.Dir,
+		GeneratedAt: time.Now(),
+		Items:       len(items),
+		Kinds:       make(map[string]*KindCoverage, len(ImageKinds)),
+	}
+	for _, kind := range ImageKinds {
+		r.Kinds[kind] = &KindCoverage{Sources: make(map[string]int)}
+	}
+
+	type totals struct{ bytes, files, width, height, measured int64 }
+	sums := make(map[string]*totals, len(ImageKinds))
+	for _, item := range items {
+		if item.ISBN != "" {
+			r.WithISBN++
+		}
+		for _, kind := range ImageKinds {
+			rel := item.Images.Get(kind)
+			if rel == "" {
+				continue
+			}
+			kc := r.Kinds[kind]
+			kc.Items++
+			if item.ISBN != "" {
+				kc.ISBNItems++
+			}
+			kc.Sources[sourceOf(item, kind)]++
+			if size := item.Metadata[kind+"_size"]; size != "" {
+				if kc.Sizes == nil {
+					kc.Sizes = make(map[string]int)
+				}
+				kc.Sizes[size]++
+			}
+
+			info, err := os.Stat(d.Path(rel))
+			if err != nil {
+				kc.Missing++
+				continue
+			}
+			t := sums[kind]
+			if t == nil {
+				t = &totals{}
+				sums[kind] = t
+			}
+			t.bytes += info.Size()
+			t.files++
+
+			width, height, err := imageSize(d.Path(rel))
+			if err != nil {
+				kc.Unmeasured++
+				continue
+			}
+			t.width += int64(width)
+			t.height += int64(height)
+			t.measured++
+			if kc.MinWidth == 0 || width < kc.MinWidth {
+				kc.MinWidth = width
+			}
+		}
+	}
+
+	for kind, t := range sums {
+		kc := r.Kinds[kind]
+		kc.MeanBytes = t.bytes / t.files
+		if t.measured > 0 {
+			kc.MeanWidth = int(t.width / t.measured)
+			kc.MeanHeight = int(t.height / t.measured)
+		}
+	}
+	return r
+}
+
+func sourceOf(item DatasetItem, kind string) string {
+	if source := item.Metadata[kind+"_source"]; source != "" {
+		return source
+	}
+	return "unknown"
+}
+
+// imageSize reads the dimensions of a JPEG, PNG or GIF image without decoding it
+func imageSize(path string) (int, int, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer f.Close()
+	cfg, _, err := image.DecodeConfig(f)
+	if err != nil {
+		return 0, 0, err
+	}
+	return cfg.Width, cfg.Height, nil
+}
+
+// SaveJSON writes the report to enrichment_report.json in the dataset directory
+func (r *EnrichmentReport) SaveJSON() (string, error) {
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal enrichment report: %w", err)
+	}
+	path := filepath.Join(r.Dataset, EnrichmentReportFilename)
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return "", fmt.Errorf("failed to write enrichment report: %w", err)
+	}
+	return path, nil
+}
+
+// PrintSummary prints the coverage of each image kind as a table
+func (r *EnrichmentReport) PrintSummary() {
+	fmt.Println("\n" + strings.Repeat("=", 70))
+	fmt.Println("DATASET ENRICHMENT REPORT")
+	fmt.Println(strings.Repeat("=", 70))
+	fmt.Printf("Dataset: %s\n", r.Dataset)
+	fmt.Printf("Items: %d (%d with ISBN)\n", r.Items, r.WithISBN)
+	fmt.Printf("This Run: %d enriched, %d skipped (already have images), %d with no images found\n", r.Enriched, r.Skipped, r.Failed)
+	fmt.Println()
+
+	fmt.Printf("%-16s %7s %15s %11s %13s\n", "IMAGE", "ITEMS", "ISBN COVERAGE", "MEAN SIZE", "MEAN PIXELS")
+	fmt.Println(strings.Repeat("-", 70))
+	for _, kind := range ImageKinds {
+		kc := r.Kinds[kind]
+		coverage := "-"
+		if r.WithISBN > 0 {
+			coverage = fmt.Sprintf("%d (%.1f%%)", kc.ISBNItems, float64(kc.ISBNItems)/float64(r.WithISBN)*100)
+		}
+		size, pixels := "-", "-"
+		if kc.MeanBytes > 0 {
+			size = fmt.Sprintf("%.1f KB", float64(kc.MeanBytes)/1024)
+		}
+		if kc.MeanWidth > 0 {
+			pixels = fmt.Sprintf("%dx%d", kc.MeanWidth, kc.MeanHeight)
+		}
+		fmt.Printf("%-16s %7d %15s %11s %13s\n", kind, kc.Items, coverage, size, pixels)
+	}
+	fmt.Println()
+
+	fmt.Println("SOURCES")
+	fmt.Println(strings.Repeat("-", 70))
+	for _, kind := range ImageKinds {
+		kc := r.Kinds[kind]
+		if len(kc.Sources) == 0 {
+			continue
+		}
+		sources := make([]string, 0, len(kc.Sources))
+		for source := range kc.Sources {
+			sources = append(sources, source)
+		}
+		sort.Slice(sources, func(i, j int) bool {
+			if kc.Sources[sources[i]] != kc.Sources[sources[j]] {
+				return kc.Sources[sources[i]] > kc.Sources[sources[j]]
+			}
+			return sources[i] < sources[j]
+		})
+		parts := make([]string, len(sources))
+		for i, source := range sources {
+			parts[i] = fmt.Sprintf("%s %d", source, kc.Sources[source])
+		}
+		fmt.Printf("%s: %s\n", kind, strings.Join(parts, ", "))
+		if len(kc.Sizes) > 0 {
+			var sizes []string
+			for _, size := range []string{"L", "M", "S"} {
+				if n := kc.Sizes[size]; n > 0 {
+					sizes = append(sizes, fmt.Sprintf("%s %d", size, n))
+				}
+			}
+			fmt.Printf("%s (Open Library sizes): %s\n", kind, strings.Join(sizes, ", "))
+		}
+	}
+	for source, until := range r.PausedSources {
+		fmt.Printf("Warning: %s was paused until %s after refusing requests; rerun later for its images\n", source, until.Format(time.DateTime))
+	}
+	for _, kind := range ImageKinds {
+		kc := r.Kinds[kind]
+		if kc.Missing > 0 {
+			fmt.Printf("Warning: %d %s images listed in %s are missing\n", kc.Missing, kind, IndexFilename)
+		}
+		if kc.Unmeasured > 0 {
+			fmt.Printf("Note: %d %s images are in a format whose dimensions can't be read\n", kc.Unmeasured, kind)
+		}
+	}
+	fmt.Println(strings.Repeat("=", 70))
+}
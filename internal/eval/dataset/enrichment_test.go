@@ -0,0 +1,61 @@
+package dataset
+
+import (
+	"image"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writePNG(t *testing.T, path string, width, height int) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if err := png.Encode(f, image.NewGray(image.Rect(0, 0, width, height))); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestNewEnrichmentReport(t *testing.T) {
+	dir := t.TempDir()
+	writePNG(t, filepath.Join(dir, "images/a/cover.png"), 100, 200)
+	writePNG(t, filepath.Join(dir, "images/b/cover.png"), 300, 400)
+	writePNG(t, filepath.Join(dir, "images/b/title_page.png"), 50, 50)
+	if err := os.WriteFile(filepath.Join(dir, "images/b/copyright_page.tif"), []byte("not decodable"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	items := []DatasetItem{
+		{ID: "a", ISBN: "1", Images: ItemImages{Cover: "images/a/cover.png"}, Metadata: map[string]string{"cover_source": "openlibrary", "cover_size": "M"}},
+		{ID: "b", Images: ItemImages{Cover: "images/b/cover.png", TitlePage: "images/b/title_page.png", CopyrightPage: "images/b/copyright_page.tif"},
+			Metadata: map[string]string{"cover_source": "856", "title_page_source": "iiif"}},
+		{ID: "c", ISBN: "2", Images: ItemImages{TitlePage: "images/c/missing.jpg"}},
+	}
+	r := NewEnrichmentReport(&MARCDataset{Dir: dir}, items)
+
+	if r.Items != 3 || r.WithISBN != 2 {
+		t.Errorf("items, with ISBN = %d, %d, want 3, 2", r.Items, r.WithISBN)
+	}
+	cover := r.Kinds["cover"]
+	if cover.Items != 2 || cover.ISBNItems != 1 || cover.MeanWidth != 200 || cover.MeanHeight != 300 || cover.MinWidth != 100 {
+		t.Errorf("cover = %+v", cover)
+	}
+	if cover.Sources["openlibrary"] != 1 || cover.Sources["856"] != 1 || cover.Sizes["M"] != 1 {
+		t.Errorf("cover sources, sizes = %v, %v", cover.Sources, cover.Sizes)
+	}
+	title := r.Kinds["title_page"]
+	if title.Items != 2 || title.Missing != 1 || title.MeanWidth != 50 || title.Sources["unknown"] != 1 {
+		t.Errorf("title_page = %+v", title)
+	}
+	copyright := r.Kinds["copyright_page"]
+	if copyright.Unmeasured != 1 || copyright.MeanBytes != int64(len("not decodable")) || copyright.MeanWidth != 0 {
+		t.Errorf("copyright_page = %+v", copyright)
+	}
+}
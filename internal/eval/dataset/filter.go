@@ -0,0 +1,62 @@
+package dataset
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/lehigh-university-libraries/cataloger/internal/marc"
+)
+
+// Filter selects which harvested reference records go into a dataset
+type Filter struct {
+	BooksOnly   bool     // Leader/06 language material and Leader/07 monograph
+	RequireISBN bool     // At least one 020 $a
+	ExcludeTags []string // Drop records containing any of these tags (X is a wildcard)
+
+	ClassRanges    []ClassRange // Keep records whose LC call number is in any of these ranges
+	Locations      []string     // Keep records held at any of these location codes
+	LocationFields []string     // Holdings subfields holding location codes (default DefaultLocationFields)
+}
+
+// Match reports whether a record passes the filter, with the reason when it does not
+func (f Filter) Match(rec *marc.Record) (bool, string) {
+	if f.BooksOnly && !IsBook(rec) {
+		return false, "not a book"
+	}
+	if f.RequireISBN && strings.TrimSpace(rec.SubfieldValue("020", "a")) == "" {
+		return false, "no ISBN"
+	}
+	if len(f.ExcludeTags) > 0 && rec.HasTag(f.ExcludeTags) {
+		return false, "excluded tag"
+	}
+	if len(f.ClassRanges) > 0 {
+		cn := LCCallNumber(rec)
+		if cn == "" {
+			return false, "no LC call number"
+		}
+		if !slices.ContainsFunc(f.ClassRanges, func(r ClassRange) bool { return r.Contains(cn) }) {
+			return false, "outside call number ranges"
+		}
+	}
+	if len(f.Locations) > 0 {
+		fields := f.LocationFields
+		if len(fields) == 0 {
+			fields = DefaultLocationFields
+		}
+		held := Locations(rec, fields)
+		if !slices.ContainsFunc(held, func(code string) bool {
+			return slices.ContainsFunc(f.Locations, func(want string) bool { return strings.EqualFold(code, want) })
+		}) {
+			return false, "outside locations"
+		}
+	}
+	return true, ""
+}
+
+// IsBook reports whether the leader describes printed language material in monograph form
+func IsBook(rec *marc.Record) bool {
+	if len(rec.Leader) < 8 {
+		return false
+	}
+	return (rec.Leader[6] == 'a' || rec.Leader[6] == 't') && rec.Leader[7] == 'm'
+}
@@ -0,0 +1,152 @@
+package dataset
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/lehigh-university-libraries/cataloger/internal/eval/schema"
+)
+
+// IndexFilename is the manifest written at the root of a MARC evaluation dataset directory
+const IndexFilename = "dataset.json"
+
+// MARCDataset is an on-disk evaluation dataset of reference MARC records and book images
+//
+// Layout:
+//
+//	<dir>/dataset.json           index of all items
+//	<dir>/records/<id>.xml       reference MARCXML record
+//	<dir>/images/<id>/cover.jpg  enrichment images (cover, title page, copyright page)
+//	<dir>/enrichment_report.json image coverage after the last eval enrich
+type MARCDataset struct {
+	Dir   string       `json:"-"`
+	Index DatasetIndex `json:"-"`
+}
+
+// IndexSchema is the format of dataset.json. Version 1 added schema_version.
+var IndexSchema = schema.Schema{
+	Name:       IndexFilename,
+	RecordsKey: "items",
+	Steps:      []schema.Step{{}},
+}
+
+// DatasetIndex is the content of dataset.json
+type DatasetIndex struct {
+	SchemaVersion int           `json:"schema_version"`
+	Name          string        `json:"name,omitempty"`
+	Source        string        `json:"source,omitempty"` // Where the reference records came from (OAI-PMH URL, file export, ...)
+	CreatedAt     time.Time     `json:"created_at"`
+	Items         []DatasetItem `json:"items"`
+}
+
+// DatasetItem is a single book in a MARC evaluation dataset
+type DatasetItem struct {
+	ID          string            `json:"id"`
+	ISBN        string            `json:"isbn,omitempty"`
+	Title       string            `json:"title,omitempty"`
+	MARCXMLPath string            `json:"marcxml_path"` // Relative to the dataset directory
+	Images      ItemImages        `json:"images,omitempty"`
+	Metadata    map[string]string `json:"metadata,omitempty"`
+
+	// Changes made to a malformed reference leader when the record was harvested
+	LeaderRepairs []string `json:"leader_repairs,omitempty"`
+
+	// Optional per-item routing, taking precedence over the run's provider/model
+	Provider string `json:"provider,omitempty"`
+	Model    string `json:"model,omitempty"`
+}
+
+// Override returns the item's provider/model override, if any
+func (i DatasetItem) Override() Override {
+	return Override{Provider: i.Provider, Model: i.Model}
+}
+
+// ItemImages holds relative paths to the images available for a dataset item
+type ItemImages struct {
+	Cover         string `json:"cover,omitempty"`
+	TitlePage     string `json:"title_page,omitempty"`
+	CopyrightPage string `json:"copyright_page,omitempty"`
+}
+
+// HasAny reports whether at least one image is available
+func (i ItemImages) HasAny() bool {
+	return i.Cover != "" || i.TitlePage != "" || i.CopyrightPage != ""
+}
+
+// Set assigns an image path by kind ("cover", "title_page" or "copyright_page")
+func (i *ItemImages) Set(kind, path string) {
+	switch kind {
+	case "cover":
+		i.Cover = path
+	case "title_page":
+		i.TitlePage = path
+	case "copyright_page":
+		i.CopyrightPage = path
+	}
+}
+
+// Get returns an image path by kind ("cover", "title_page" or "copyright_page")
+func (i ItemImages) Get(kind string) string {
+	switch kind {
+	case "cover":
+		return i.Cover
+	case "title_page":
+		return i.TitlePage
+	case "copyright_page":
+		return i.CopyrightPage
+	}
+	return ""
+}
+
+// LoadMARCDataset reads dataset.json from a dataset directory
+func LoadMARCDataset(dir string) (*MARCDataset, error) {
+	data, err := os.ReadFile(filepath.Join(dir, IndexFilename))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read dataset index: %w", err)
+	}
+
+	ds := &MARCDataset{Dir: dir}
+	if _, err := IndexSchema.DecodeJSON(data, &ds.Index); err != nil {
+		return nil, fmt.Errorf("failed to parse dataset index: %w", err)
+	}
+
+	return ds, nil
+}
+
+// Save writes dataset.json to the dataset directory
+func (d *MARCDataset) Save() error {
+	if err := os.MkdirAll(d.Dir, 0755); err != nil {
+		return fmt.Errorf("failed to create dataset directory: %w", err)
+	}
+
+	d.Index.SchemaVersion = IndexSchema.Version()
+	data, err := json.MarshalIndent(d.Index, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal dataset index: %w", err)
+	}
+
+	if err := os.WriteFile(filepath.Join(d.Dir, IndexFilename), data, 0644); err != nil {
+		return fmt.Errorf("failed to write dataset index: %w", err)
+	}
+
+	return nil
+}
+
+// Path resolves a path stored in the index relative to the dataset directory
+func (d *MARCDataset) Path(rel string) string {
+	if rel == "" || filepath.IsAbs(rel) {
+		return rel
+	}
+	return filepath.Join(d.Dir, rel)
+}
+
+// ReadMARCXML returns the raw reference MARCXML for an item
+func (d *MARCDataset) ReadMARCXML(item DatasetItem) ([]byte, error) {
+	if item.MARCXMLPath == "" {
+		return nil, fmt.Errorf("item %s has no MARCXML record", item.ID)
+	}
+	return os.ReadFile(d.Path(item.MARCXMLPath))
+}
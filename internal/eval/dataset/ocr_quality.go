@@ -0,0 +1,94 @@
+package dataset
+
+import (
+	"strings"
+	"unicode"
+)
+
+// OCR quality buckets, from the best input to the worst
+const (
+	OCRGood    = "good"
+	OCRFair    = "fair"
+	OCRPoor    = "poor"
+	OCRUnknown = "unknown" // Neither signal is available
+)
+
+// OCRBuckets lists the OCR quality buckets in report order
+var OCRBuckets = []string{OCRGood, OCRFair, OCRPoor, OCRUnknown}
+
+// Thresholds separating the OCR quality buckets. Clean book pages run to several hundred
+// tokens; pages that lose most of their text to OCR errors, or that post-processing had to
+// rewrite heavily, fall below good.
+const (
+	fairDivergence    = 0.15
+	poorDivergence    = 0.35
+	fairTokensPerPage = 150
+	poorTokensPerPage = 50
+)
+
+// OCRQuality describes how trustworthy a record's OCR text is, from the signals the
+// Institutional Books dataset ships with
+type OCRQuality struct {
+	Bucket        string
+	TokensPerPage float64 `json:",omitempty"` // Post-processed tokens per page, 0 when unknown
+	Divergence    float64 // Share of title page words changed by post-processing, -1 when unknown
+}
+
+// OCRQuality buckets the record by its post-processed tokens per page and how far the
+// post-processed title page text diverges from the source OCR. Heavy rewriting means the
+// source OCR was poor; few tokens per page means text was lost.
+func (r *InstitutionalBooksRecord) OCRQuality() OCRQuality {
+	q := OCRQuality{Divergence: -1}
+	if r.TokenCountGen > 0 && r.PageCountSource > 0 {
+		q.TokensPerPage = float64(r.TokenCountGen) / float64(r.PageCountSource)
+	}
+	if len(r.TextByPageSource) > 0 && len(r.TextByPageGen) > 0 {
+		q.Divergence = wordDivergence(titlePages(r.TextByPageSource), titlePages(r.TextByPageGen))
+	}
+
+	switch {
+	case q.TokensPerPage == 0 && q.Divergence < 0:
+		q.Bucket = OCRUnknown
+	case q.Divergence >= poorDivergence || (q.TokensPerPage > 0 && q.TokensPerPage < poorTokensPerPage):
+		q.Bucket = OCRPoor
+	case q.Divergence >= fairDivergence || (q.TokensPerPage > 0 && q.TokensPerPage < fairTokensPerPage):
+		q.Bucket = OCRFair
+	default:
+		q.Bucket = OCRGood
+	}
+	return q
+}
+
+// titlePages joins the pages GetTitlePageText sends to the model
+func titlePages(pages []string) string {
+	return strings.Join(pages[:min(len(pages), 10)], "\n")
+}
+
+// wordDivergence is one minus the Dice overlap of the two texts' lowercased words, counting
+// repeats: 0 for the same words, 1 for none in common
+func wordDivergence(a, b string) float64 {
+	wordsA, wordsB := words(a), words(b)
+	total := len(wordsA) + len(wordsB)
+	if total == 0 {
+		return 0
+	}
+
+	counts := make(map[string]int, len(wordsA))
+	for _, w := range wordsA {
+		counts[w]++
+	}
+	common := 0
+	for _, w := range wordsB {
+		if counts[w] > 0 {
+			counts[w]--
+			common++
+		}
+	}
+	return 1 - 2*float64(common)/float64(total)
+}
+
+func words(s string) []string {
+	return strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
@@ -0,0 +1,68 @@
+package dataset
+
+import "testing"
+
+func TestOCRQuality(t *testing.T) {
+	clean := "The history of the decline and fall of the Roman empire by Edward Gibbon"
+	tests := []struct {
+		name   string
+		record InstitutionalBooksRecord
+		want   string
+	}{
+		{
+			name:   "no signals",
+			record: InstitutionalBooksRecord{},
+			want:   OCRUnknown,
+		},
+		{
+			name: "clean text and dense pages",
+			record: InstitutionalBooksRecord{
+				TextByPageSource: []string{clean},
+				TextByPageGen:    []string{clean},
+				TokenCountGen:    40000,
+				PageCountSource:  100,
+			},
+			want: OCRGood,
+		},
+		{
+			name: "post-processing rewrote some words",
+			record: InstitutionalBooksRecord{
+				TextByPageSource: []string{"Tlie hiftory of the dccline and fall of the Roman empire by Edward Gibbon"},
+				TextByPageGen:    []string{clean},
+			},
+			want: OCRFair,
+		},
+		{
+			name: "garbage source text",
+			record: InstitutionalBooksRecord{
+				TextByPageSource: []string{"Tlie hiftory ot tbe dccline aud fali ot tlie Romau empirc"},
+				TextByPageGen:    []string{clean},
+			},
+			want: OCRPoor,
+		},
+		{
+			name:   "sparse pages",
+			record: InstitutionalBooksRecord{TokenCountGen: 2000, PageCountSource: 100},
+			want:   OCRPoor,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.record.OCRQuality(); got.Bucket != tt.want {
+				t.Errorf("OCRQuality() = %+v, want bucket %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWordDivergence(t *testing.T) {
+	if d := wordDivergence("A b, c!", "a B c"); d != 0 {
+		t.Errorf("wordDivergence of the same words = %v, want 0", d)
+	}
+	if d := wordDivergence("a b", "c d"); d != 1 {
+		t.Errorf("wordDivergence of disjoint words = %v, want 1", d)
+	}
+	if d := wordDivergence("a b c d", "a b c e"); d != 0.25 {
+		t.Errorf("wordDivergence with one word changed = %v, want 0.25", d)
+	}
+}
@@ -0,0 +1,38 @@
+package dataset
+
+import (
+	"fmt"
+	"os"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Override selects a provider and/or model for a single dataset record
+type Override struct {
+	Provider string `json:"provider,omitempty" yaml:"provider,omitempty"`
+	Model    string `json:"model,omitempty" yaml:"model,omitempty"`
+}
+
+// IsZero reports whether the override changes nothing
+func (o Override) IsZero() bool {
+	return o.Provider == "" && o.Model == ""
+}
+
+// LoadOverrides reads a sidecar file mapping record identifiers (IB barcodes or
+// dataset item IDs) to provider/model overrides. JSON and YAML are both accepted:
+//
+//	"39015012345678": {provider: openai, model: gpt-4o}
+//	"39015087654321": {model: qwen2.5vl:32b}
+func LoadOverrides(path string) (map[string]Override, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read overrides file: %w", err)
+	}
+
+	overrides := map[string]Override{}
+	if err := yaml.Unmarshal(data, &overrides); err != nil {
+		return nil, fmt.Errorf("failed to parse overrides file: %w", err)
+	}
+
+	return overrides, nil
+}
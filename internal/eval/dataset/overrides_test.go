@@ -0,0 +1,53 @@
+package dataset
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestLoadOverrides(t *testing.T) {
+	want := map[string]Override{
+		"39015012345678": {Provider: "openai", Model: "gpt-4o"},
+		"39015087654321": {Model: "qwen2.5vl:32b"},
+	}
+	tests := []struct {
+		name, file string
+	}{
+		{"overrides.json", `{
+  "39015012345678": {"provider": "openai", "model": "gpt-4o"},
+  "39015087654321": {"model": "qwen2.5vl:32b"}
+}`},
+		{"overrides.yaml", `"39015012345678": {provider: openai, model: gpt-4o}
+"39015087654321":
+  model: qwen2.5vl:32b
+`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), tt.name)
+			if err := os.WriteFile(path, []byte(tt.file), 0644); err != nil {
+				t.Fatal(err)
+			}
+			got, err := LoadOverrides(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("LoadOverrides() = %v, want %v", got, want)
+			}
+		})
+	}
+
+	if _, err := LoadOverrides(filepath.Join(t.TempDir(), "missing.yaml")); err == nil {
+		t.Error("loaded a missing file")
+	}
+	path := filepath.Join(t.TempDir(), "list.yaml")
+	if err := os.WriteFile(path, []byte("- 39015012345678\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadOverrides(path); err == nil {
+		t.Error("loaded a list as overrides")
+	}
+}
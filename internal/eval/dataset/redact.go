@@ -0,0 +1,174 @@
+package dataset
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/lehigh-university-libraries/cataloger/internal/marc"
+)
+
+// DefaultRedactTags are the fields stripped from reference records before a dataset is shared:
+// local control numbers (001/003), system control numbers (035), local notes (59X),
+// holdings and item data (852, 86X, 876-878) and locally defined fields (9XX, which is
+// where most ILSs keep barcodes and item records)
+var DefaultRedactTags = []string{"001", "003", "035", "59X", "852", "86X", "876", "877", "878", "9XX"}
+
+// DefaultRedactMetadataKeys are substrings of item metadata keys that are dropped during redaction
+var DefaultRedactMetadataKeys = []string{"barcode", "holding", "location", "item_id", "local"}
+
+// Redactor strips local identifiers from reference records and dataset items
+type Redactor struct {
+	Tags         []string // Tag patterns to remove (X is a wildcard, e.g. "9XX")
+	MetadataKeys []string // Item metadata keys containing any of these substrings are removed
+	HashIDs      bool     // Replace item IDs (often barcodes) with a salted hash
+	Salt         string   // Required with HashIDs: unsalted hashes of barcodes can be reversed by brute force
+}
+
+// NewRedactor returns a redactor using the default tag and metadata key lists
+func NewRedactor() *Redactor {
+	return &Redactor{
+		Tags:         DefaultRedactTags,
+		MetadataKeys: DefaultRedactMetadataKeys,
+		HashIDs:      true,
+	}
+}
+
+// Validate reports a redactor that would hash item IDs without a salt
+func (r *Redactor) Validate() error {
+	if r.HashIDs && r.Salt == "" {
+		return fmt.Errorf("a salt is required to hash item IDs")
+	}
+	return nil
+}
+
+// RedactRecord removes redacted fields from a record in place and returns how many were removed
+func (r *Redactor) RedactRecord(rec *marc.Record) int {
+	return rec.RemoveTags(r.Tags)
+}
+
+// RedactMARCXML parses, redacts and re-serializes a MARCXML record
+func (r *Redactor) RedactMARCXML(data []byte) ([]byte, int, error) {
+	rec, err := marc.ParseXML(data)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	removed := r.RedactRecord(rec)
+
+	out, err := rec.XML()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return out, removed, nil
+}
+
+// RedactItem returns a copy of a dataset item with local identifiers removed from its ID and metadata
+func (r *Redactor) RedactItem(item DatasetItem) DatasetItem {
+	if r.HashIDs {
+		item.ID = r.HashID(item.ID)
+	}
+
+	if len(item.Metadata) > 0 {
+		meta := make(map[string]string, len(item.Metadata))
+		for k, v := range item.Metadata {
+			if r.redactKey(k) {
+				continue
+			}
+			meta[k] = v
+		}
+		item.Metadata = meta
+	}
+
+	return item
+}
+
+// HashID returns a stable pseudonymous identifier for a local ID
+func (r *Redactor) HashID(id string) string {
+	sum := sha256.Sum256([]byte(r.Salt + id))
+	return hex.EncodeToString(sum[:])[:16]
+}
+
+func (r *Redactor) redactKey(key string) bool {
+	key = strings.ToLower(key)
+	for _, k := range r.MetadataKeys {
+		if strings.Contains(key, k) {
+			return true
+		}
+	}
+	return false
+}
+
+// RedactDataset writes a redacted copy of a dataset to outDir, returning the new dataset and
+// the total number of fields removed from reference records
+func (r *Redactor) RedactDataset(ds *MARCDataset, outDir string) (*MARCDataset, int, error) {
+	if err := r.Validate(); err != nil {
+		return nil, 0, err
+	}
+	out := &MARCDataset{
+		Dir: outDir,
+		Index: DatasetIndex{
+			Name:      ds.Index.Name,
+			CreatedAt: ds.Index.CreatedAt,
+			Items:     make([]DatasetItem, 0, len(ds.Index.Items)),
+		},
+	}
+
+	removed := 0
+	for _, item := range ds.Index.Items {
+		data, err := ds.ReadMARCXML(item)
+		if err != nil {
+			return nil, 0, err
+		}
+
+		redactedXML, n, err := r.RedactMARCXML(data)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to redact %s: %w", item.ID, err)
+		}
+		removed += n
+
+		newItem := r.RedactItem(item)
+		newItem.MARCXMLPath = filepath.Join("records", newItem.ID+".xml")
+		if err := writeFile(out.Path(newItem.MARCXMLPath), redactedXML); err != nil {
+			return nil, 0, err
+		}
+
+		newItem.Images = ItemImages{}
+		for kind, rel := range map[string]string{"cover": item.Images.Cover, "title_page": item.Images.TitlePage, "copyright_page": item.Images.CopyrightPage} {
+			if rel == "" {
+				continue
+			}
+			img, err := os.ReadFile(ds.Path(rel))
+			if err != nil {
+				return nil, 0, fmt.Errorf("failed to read image %s: %w", rel, err)
+			}
+			newRel := filepath.Join("images", newItem.ID, kind+filepath.Ext(rel))
+			if err := writeFile(out.Path(newRel), img); err != nil {
+				return nil, 0, err
+			}
+			newItem.Images.Set(kind, newRel)
+		}
+
+		out.Index.Items = append(out.Index.Items, newItem)
+	}
+
+	if err := out.Save(); err != nil {
+		return nil, 0, err
+	}
+
+	return out, removed, nil
+}
+
+func writeFile(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
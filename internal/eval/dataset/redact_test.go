@@ -0,0 +1,27 @@
+package dataset
+
+import "testing"
+
+func TestRedactorRequiresSalt(t *testing.T) {
+	r := NewRedactor()
+	if err := r.Validate(); err == nil {
+		t.Error("Validate accepted hashing IDs without a salt")
+	}
+	if _, _, err := r.RedactDataset(&MARCDataset{}, t.TempDir()); err == nil {
+		t.Error("RedactDataset ran without a salt")
+	}
+
+	r.HashIDs = false
+	if err := r.Validate(); err != nil {
+		t.Errorf("Validate without hashing: %v", err)
+	}
+
+	a, b := NewRedactor(), NewRedactor()
+	a.Salt, b.Salt = "one", "two"
+	if a.HashID("39151001234567") == b.HashID("39151001234567") {
+		t.Error("different salts gave the same ID")
+	}
+	if a.HashID("39151001234567") != a.HashID("39151001234567") {
+		t.Error("HashID is not stable")
+	}
+}
@@ -0,0 +1,45 @@
+package dataset
+
+import "math/rand/v2"
+
+// Reservoir keeps a uniform random sample of fixed size from a stream of unknown length
+// (Algorithm R), so a sample drawn while harvesting is not biased toward early records
+type Reservoir[T any] struct {
+	size  int
+	seen  int
+	items []T
+	rng   *rand.Rand
+}
+
+// NewReservoir creates a reservoir holding at most size items
+func NewReservoir[T any](size int, rng *rand.Rand) *Reservoir[T] {
+	return &Reservoir[T]{size: size, items: make([]T, 0, size), rng: rng}
+}
+
+// Offer considers the next stream item. It returns the slot the item was stored in, or -1
+// if it was not kept, and the item it replaced, if any.
+func (r *Reservoir[T]) Offer(item T) (slot int, evicted T, replaced bool) {
+	r.seen++
+	if len(r.items) < r.size {
+		r.items = append(r.items, item)
+		return len(r.items) - 1, evicted, false
+	}
+
+	j := r.rng.IntN(r.seen)
+	if j >= r.size {
+		return -1, evicted, false
+	}
+	evicted = r.items[j]
+	r.items[j] = item
+	return j, evicted, true
+}
+
+// Items returns the current sample
+func (r *Reservoir[T]) Items() []T {
+	return r.items
+}
+
+// Seen returns the number of items offered so far
+func (r *Reservoir[T]) Seen() int {
+	return r.seen
+}
@@ -0,0 +1,45 @@
+package dataset
+
+import (
+	"math/rand/v2"
+	"testing"
+)
+
+func TestReservoirUniform(t *testing.T) {
+	const streamLen, size, trials = 20, 5, 20000
+
+	counts := make([]int, streamLen)
+	rng := rand.New(rand.NewPCG(1, 2))
+	for trial := 0; trial < trials; trial++ {
+		r := NewReservoir[int](size, rng)
+		for i := 0; i < streamLen; i++ {
+			r.Offer(i)
+		}
+		if len(r.Items()) != size || r.Seen() != streamLen {
+			t.Fatalf("expected %d items of %d seen, got %d of %d", size, streamLen, len(r.Items()), r.Seen())
+		}
+		for _, v := range r.Items() {
+			counts[v]++
+		}
+	}
+
+	// Every position should be kept with probability size/streamLen = 0.25
+	want := float64(trials) * size / streamLen
+	for i, c := range counts {
+		if diff := float64(c) - want; diff > want*0.1 || diff < -want*0.1 {
+			t.Errorf("item %d kept %d times, want about %.0f", i, c, want)
+		}
+	}
+}
+
+func TestReservoirShortStream(t *testing.T) {
+	r := NewReservoir[string](10, rand.New(rand.NewPCG(1, 2)))
+	for _, s := range []string{"a", "b", "c"} {
+		if slot, _, replaced := r.Offer(s); slot < 0 || replaced {
+			t.Errorf("items should be kept while the reservoir has room")
+		}
+	}
+	if len(r.Items()) != 3 {
+		t.Errorf("expected 3 items, got %d", len(r.Items()))
+	}
+}
@@ -0,0 +1,264 @@
+package export
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/lehigh-university-libraries/cataloger/internal/eval/dataset"
+	"github.com/parquet-go/parquet-go"
+	"github.com/parquet-go/parquet-go/compress/zstd"
+)
+
+// HFImage matches the HuggingFace datasets Image feature storage layout (struct of bytes + path)
+type HFImage struct {
+	Bytes []byte `parquet:"bytes"`
+	Path  string `parquet:"path"`
+}
+
+// HFRow is a single row in the exported parquet file
+type HFRow struct {
+	ID            string   `parquet:"id"`
+	ISBN          string   `parquet:"isbn"`
+	Title         string   `parquet:"title"`
+	MARCXML       string   `parquet:"marcxml"`
+	Metadata      string   `parquet:"metadata"` // JSON-encoded item metadata
+	Cover         *HFImage `parquet:"cover,optional"`
+	TitlePage     *HFImage `parquet:"title_page,optional"`
+	CopyrightPage *HFImage `parquet:"copyright_page,optional"`
+}
+
+// HFOptions configures a HuggingFace export
+type HFOptions struct {
+	OutputDir   string
+	DatasetName string
+	License     string
+	ShardSize   int               // Rows per parquet file
+	Redactor    *dataset.Redactor // Strip local identifiers before export (nil to export as-is)
+}
+
+// HFSummary reports what was exported
+type HFSummary struct {
+	Rows       int
+	Shards     []string
+	Skipped    int
+	WithImages int
+	Redacted   int // Fields removed from reference records
+}
+
+// ExportHuggingFace packages a MARC dataset into the HuggingFace parquet layout:
+//
+//	<out>/README.md                              dataset card with features
+//	<out>/data/train-00000-of-0000N.parquet      rows of images + MARCXML + metadata
+func ExportHuggingFace(ds *dataset.MARCDataset, opts HFOptions) (*HFSummary, error) {
+	if opts.ShardSize <= 0 {
+		opts.ShardSize = 500
+	}
+	if opts.DatasetName == "" {
+		opts.DatasetName = ds.Index.Name
+	}
+	if opts.DatasetName == "" {
+		opts.DatasetName = filepath.Base(filepath.Clean(ds.Dir))
+	}
+
+	if opts.Redactor != nil {
+		if err := opts.Redactor.Validate(); err != nil {
+			return nil, err
+		}
+	}
+
+	dataDir := filepath.Join(opts.OutputDir, "data")
+	if err := os.MkdirAll(dataDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create output directory: %w", err)
+	}
+
+	// Shards are written as they fill, so only one shard's rows and images are held in memory.
+	// Their final names carry the shard count, so they are renamed once it is known.
+	summary := &HFSummary{}
+	var rows []HFRow
+	var partial []string
+	flush := func() error {
+		path := filepath.Join(dataDir, fmt.Sprintf("train-%05d.parquet.partial", len(partial)))
+		if err := writeParquet(path, rows); err != nil {
+			return err
+		}
+		partial = append(partial, path)
+		summary.Rows += len(rows)
+		slog.Debug("Wrote parquet shard", "path", path, "rows", len(rows))
+		rows = nil
+		return nil
+	}
+
+	for _, item := range ds.Index.Items {
+		row, redacted, err := buildHFRow(ds, item, opts.Redactor)
+		if err != nil {
+			slog.Warn("Skipping item", "id", item.ID, "error", err)
+			summary.Skipped++
+			continue
+		}
+		if row.Cover != nil || row.TitlePage != nil || row.CopyrightPage != nil {
+			summary.WithImages++
+		}
+		summary.Redacted += redacted
+		rows = append(rows, row)
+		if len(rows) == opts.ShardSize {
+			if err := flush(); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if len(rows) > 0 || len(partial) == 0 {
+		if err := flush(); err != nil {
+			return nil, err
+		}
+	}
+
+	// Shards of an earlier export to the same directory would be read as part of this one
+	stale, err := filepath.Glob(filepath.Join(dataDir, "train-*.parquet"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list parquet shards: %w", err)
+	}
+	for _, path := range stale {
+		if err := os.Remove(path); err != nil {
+			return nil, fmt.Errorf("failed to remove old parquet shard: %w", err)
+		}
+	}
+
+	for shard, path := range partial {
+		name := filepath.Join(dataDir, fmt.Sprintf("train-%05d-of-%05d.parquet", shard, len(partial)))
+		if err := os.Rename(path, name); err != nil {
+			return nil, fmt.Errorf("failed to rename parquet shard: %w", err)
+		}
+		summary.Shards = append(summary.Shards, name)
+	}
+
+	if err := writeDatasetCard(opts, summary); err != nil {
+		return nil, err
+	}
+
+	return summary, nil
+}
+
+// buildHFRow reads the MARCXML and images for a dataset item, applying redaction when configured
+func buildHFRow(ds *dataset.MARCDataset, item dataset.DatasetItem, redactor *dataset.Redactor) (HFRow, int, error) {
+	marcXML, err := ds.ReadMARCXML(item)
+	if err != nil {
+		return HFRow{}, 0, err
+	}
+
+	redacted := 0
+	if redactor != nil {
+		marcXML, redacted, err = redactor.RedactMARCXML(marcXML)
+		if err != nil {
+			return HFRow{}, 0, err
+		}
+		item = redactor.RedactItem(item)
+	}
+
+	meta := map[string]string{}
+	for k, v := range item.Metadata {
+		meta[k] = v
+	}
+	if ds.Index.Source != "" && redactor == nil {
+		meta["source"] = ds.Index.Source
+	}
+	metaJSON, err := json.Marshal(meta)
+	if err != nil {
+		return HFRow{}, 0, fmt.Errorf("failed to marshal metadata: %w", err)
+	}
+
+	row := HFRow{
+		ID:       item.ID,
+		ISBN:     item.ISBN,
+		Title:    item.Title,
+		MARCXML:  string(marcXML),
+		Metadata: string(metaJSON),
+	}
+
+	if row.Cover, err = readHFImage(ds, item.ID, "cover", item.Images.Cover); err != nil {
+		return HFRow{}, 0, err
+	}
+	if row.TitlePage, err = readHFImage(ds, item.ID, "title_page", item.Images.TitlePage); err != nil {
+		return HFRow{}, 0, err
+	}
+	if row.CopyrightPage, err = readHFImage(ds, item.ID, "copyright_page", item.Images.CopyrightPage); err != nil {
+		return HFRow{}, 0, err
+	}
+
+	return row, redacted, nil
+}
+
+// readHFImage embeds an image file, returning nil when the item has no such image.
+// The stored file name is derived from the (possibly redacted) item ID rather than the
+// original path, which often contains barcodes.
+func readHFImage(ds *dataset.MARCDataset, id, kind, rel string) (*HFImage, error) {
+	if rel == "" {
+		return nil, nil
+	}
+
+	data, err := os.ReadFile(ds.Path(rel))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read image %s: %w", rel, err)
+	}
+
+	return &HFImage{Bytes: data, Path: id + "_" + kind + filepath.Ext(rel)}, nil
+}
+
+// writeParquet writes a shard of rows to a zstd-compressed parquet file
+func writeParquet(path string, rows []HFRow) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create parquet file: %w", err)
+	}
+	defer file.Close()
+
+	writer := parquet.NewGenericWriter[HFRow](file, parquet.Compression(&zstd.Codec{}))
+	if _, err := writer.Write(rows); err != nil {
+		return fmt.Errorf("failed to write parquet rows: %w", err)
+	}
+	if err := writer.Close(); err != nil {
+		return fmt.Errorf("failed to close parquet writer: %w", err)
+	}
+
+	return nil
+}
+
+// writeDatasetCard writes the README.md with the YAML header HuggingFace uses to declare features
+func writeDatasetCard(opts HFOptions, summary *HFSummary) error {
+	license := opts.License
+	if license == "" {
+		license = "other"
+	}
+
+	var b strings.Builder
+	b.WriteString("---\n")
+	fmt.Fprintf(&b, "license: %s\n", license)
+	b.WriteString("task_categories:\n  - image-to-text\n")
+	b.WriteString("tags:\n  - marc\n  - library-cataloging\n  - bibliographic-metadata\n")
+	b.WriteString("dataset_info:\n  features:\n")
+	for _, f := range []string{"id", "isbn", "title", "marcxml", "metadata"} {
+		fmt.Fprintf(&b, "    - name: %s\n      dtype: string\n", f)
+	}
+	for _, f := range []string{"cover", "title_page", "copyright_page"} {
+		fmt.Fprintf(&b, "    - name: %s\n      dtype: image\n", f)
+	}
+	fmt.Fprintf(&b, "  splits:\n    - name: train\n      num_examples: %d\n", summary.Rows)
+	b.WriteString("configs:\n  - config_name: default\n    data_files:\n      - split: train\n        path: data/train-*\n")
+	b.WriteString("---\n\n")
+
+	fmt.Fprintf(&b, "# %s\n\n", opts.DatasetName)
+	b.WriteString("Book cataloging benchmark exported with [cataloger](https://github.com/lehigh-university-libraries/cataloger).\n\n")
+	b.WriteString("Each row pairs book images (cover, title page, copyright page) with the professionally cataloged reference record as MARCXML.\n\n")
+	fmt.Fprintf(&b, "- Records: %d\n", summary.Rows)
+	fmt.Fprintf(&b, "- Records with at least one image: %d\n", summary.WithImages)
+
+	path := filepath.Join(opts.OutputDir, "README.md")
+	if err := os.WriteFile(path, []byte(b.String()), 0644); err != nil {
+		return fmt.Errorf("failed to write dataset card: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,138 @@
+package export
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/lehigh-university-libraries/cataloger/internal/eval/dataset"
+	"github.com/parquet-go/parquet-go"
+)
+
+// testDataset returns a dataset of n items with records and title page images, plus one
+// item whose record is missing
+func testDataset(t *testing.T, n int) *dataset.MARCDataset {
+	t.Helper()
+	dir := t.TempDir()
+	ds := &dataset.MARCDataset{Dir: dir}
+	for i := range n {
+		id := fmt.Sprintf("item%d", i)
+		record := fmt.Sprintf(`<record xmlns="http://www.loc.gov/MARC21/slim"><leader>00000nam a2200000 a 4500</leader><datafield tag="245" ind1="1" ind2="0"><subfield code="a">Title %d</subfield></datafield><datafield tag="949" ind1=" " ind2=" "><subfield code="i">3915100%d</subfield></datafield></record>`, i, i)
+		for rel, data := range map[string]string{"records/" + id + ".xml": record, "images/" + id + ".jpg": "image " + id} {
+			if err := os.MkdirAll(filepath.Dir(ds.Path(rel)), 0755); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(ds.Path(rel), []byte(data), 0644); err != nil {
+				t.Fatal(err)
+			}
+		}
+		ds.Index.Items = append(ds.Index.Items, dataset.DatasetItem{
+			ID:          id,
+			Title:       fmt.Sprintf("Title %d", i),
+			MARCXMLPath: "records/" + id + ".xml",
+			Images:      dataset.ItemImages{TitlePage: "images/" + id + ".jpg"},
+			Metadata:    map[string]string{"barcode": "3915100", "source": "folio"},
+		})
+	}
+	ds.Index.Items = append(ds.Index.Items, dataset.DatasetItem{ID: "missing", MARCXMLPath: "records/missing.xml"})
+	return ds
+}
+
+func TestExportHuggingFace(t *testing.T) {
+	ds := testDataset(t, 5)
+	out := t.TempDir()
+	summary, err := ExportHuggingFace(ds, HFOptions{OutputDir: out, DatasetName: "test", ShardSize: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if summary.Rows != 5 || summary.Skipped != 1 || summary.WithImages != 5 || len(summary.Shards) != 3 {
+		t.Fatalf("summary = %+v", summary)
+	}
+
+	var rows []HFRow
+	for i, path := range summary.Shards {
+		if want := fmt.Sprintf("train-%05d-of-00003.parquet", i); filepath.Base(path) != want {
+			t.Errorf("shard %d = %s, want %s", i, filepath.Base(path), want)
+		}
+		shard, err := parquet.ReadFile[HFRow](path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := min(2, 5-2*i); len(shard) != want {
+			t.Errorf("shard %d has %d rows, want %d", i, len(shard), want)
+		}
+		rows = append(rows, shard...)
+	}
+	partial, _ := filepath.Glob(filepath.Join(out, "data", "*.partial"))
+	if len(partial) != 0 {
+		t.Errorf("partial shards left behind: %v", partial)
+	}
+
+	row := rows[3]
+	if row.ID != "item3" || row.Title != "Title 3" || !strings.Contains(row.MARCXML, "Title 3") || !strings.Contains(row.Metadata, `"barcode"`) ||
+		row.TitlePage == nil || string(row.TitlePage.Bytes) != "image item3" || row.TitlePage.Path != "item3_title_page.jpg" || row.Cover != nil {
+		t.Errorf("row = %+v", row)
+	}
+	if card, err := os.ReadFile(filepath.Join(out, "README.md")); err != nil || !strings.Contains(string(card), "num_examples: 5") {
+		t.Errorf("dataset card = %s, %v", card, err)
+	}
+}
+
+func TestExportHuggingFaceRedacted(t *testing.T) {
+	ds := testDataset(t, 1)
+	redactor := dataset.NewRedactor()
+	if _, err := ExportHuggingFace(ds, HFOptions{OutputDir: t.TempDir(), Redactor: redactor}); err == nil {
+		t.Error("exported with IDs hashed without a salt")
+	}
+
+	redactor.Salt = "secret"
+	summary, err := ExportHuggingFace(ds, HFOptions{OutputDir: t.TempDir(), Redactor: redactor})
+	if err != nil {
+		t.Fatal(err)
+	}
+	rows, err := parquet.ReadFile[HFRow](summary.Shards[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	row := rows[0]
+	if row.ID != redactor.HashID("item0") || strings.Contains(row.MARCXML, `tag="949"`) || strings.Contains(row.Metadata, "barcode") ||
+		!strings.HasPrefix(row.TitlePage.Path, row.ID) || summary.Redacted != 1 {
+		t.Errorf("redacted row = %+v, summary = %+v", row, summary)
+	}
+}
+
+func TestExportHuggingFaceEmpty(t *testing.T) {
+	summary, err := ExportHuggingFace(&dataset.MARCDataset{Dir: t.TempDir()}, HFOptions{OutputDir: t.TempDir(), DatasetName: "empty"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if summary.Rows != 0 || len(summary.Shards) != 1 || filepath.Base(summary.Shards[0]) != "train-00000-of-00001.parquet" {
+		t.Errorf("summary = %+v", summary)
+	}
+}
+
+func TestExportHuggingFaceAgain(t *testing.T) {
+	out := t.TempDir()
+	if _, err := ExportHuggingFace(testDataset(t, 5), HFOptions{OutputDir: out, ShardSize: 2}); err != nil {
+		t.Fatal(err)
+	}
+
+	// The dataset shrank, so the second export has fewer shards than the first left
+	summary, err := ExportHuggingFace(testDataset(t, 1), HFOptions{OutputDir: out, ShardSize: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	shards, _ := filepath.Glob(filepath.Join(out, "data", "train-*"))
+	if len(shards) != 1 || len(summary.Shards) != 1 || shards[0] != summary.Shards[0] || filepath.Base(shards[0]) != "train-00000-of-00001.parquet" {
+		t.Fatalf("shards = %v, summary %v", shards, summary.Shards)
+	}
+	rows, err := parquet.ReadFile[HFRow](shards[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 1 || rows[0].ID != "item0" {
+		t.Errorf("rows = %+v", rows)
+	}
+}
@@ -0,0 +1,88 @@
+package failure
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"regexp"
+	"strings"
+
+	"github.com/lehigh-university-libraries/cataloger/internal/providers"
+)
+
+// Code is the category of an evaluated record's failure, counted in reports and used to
+// select records to rerun
+type Code string
+
+// Failure codes
+const (
+	ProviderTimeout Code = "provider_timeout"  // An LLM or OCR request timed out
+	ProviderError   Code = "provider_error"    // An LLM or OCR request failed otherwise
+	Refused         Code = "refused"           // The provider refused or a safety filter blocked the request, also after a retry
+	ParseFailure    Code = "parse_failure"     // The model's response or an input record could not be parsed
+	Degenerate      Code = "degenerate_output" // The model's response parsed but is empty, repetitive, refused or in the wrong language
+	NoImage         Code = "no_image"          // No title page image or OCR text to generate from
+	NoInput         Code = "no_input"          // Other input missing, e.g. no ISBN for a lookup
+	ComparisonError Code = "comparison_error"  // The reference record could not be read or compared
+	Unknown         Code = "unknown"
+)
+
+// Codes lists the failure codes in report order
+var Codes = []Code{ProviderTimeout, ProviderError, Refused, ParseFailure, Degenerate, NoImage, NoInput, ComparisonError, Unknown}
+
+// Error is an error with a failure code
+type Error struct {
+	Code Code
+	Err  error
+}
+
+func (e *Error) Error() string { return e.Err.Error() }
+
+func (e *Error) Unwrap() error { return e.Err }
+
+// Wrap attaches a failure code to err; a nil err stays nil
+func Wrap(code Code, err error) error {
+	if err == nil {
+		return nil
+	}
+	return &Error{Code: code, Err: err}
+}
+
+// timeoutStatus matches the HTTP status errors of the providers for 408 and 504 responses
+var timeoutStatus = regexp.MustCompile(`status(?: code)?:? (408|504)\b`)
+
+// Classify returns the code of err: the code attached with Wrap, Refused for provider refusals,
+// ProviderTimeout for deadlines, network timeouts and 408/504 responses, else fallback
+func Classify(err error, fallback Code) Code {
+	if err == nil {
+		return ""
+	}
+	var coded *Error
+	if errors.As(err, &coded) {
+		return coded.Code
+	}
+	var refusal *providers.RefusalError
+	if errors.As(err, &refusal) {
+		return Refused
+	}
+	var netErr net.Error
+	if errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &netErr) && netErr.Timeout()) || timeoutStatus.MatchString(err.Error()) {
+		return ProviderTimeout
+	}
+	return fallback
+}
+
+// Parse validates a failure code given on the command line
+func Parse(s string) (Code, error) {
+	for _, c := range Codes {
+		if string(c) == s {
+			return c, nil
+		}
+	}
+	names := make([]string, len(Codes))
+	for i, c := range Codes {
+		names[i] = string(c)
+	}
+	return "", fmt.Errorf("unknown failure code %q (%s)", s, strings.Join(names, ", "))
+}
@@ -0,0 +1,48 @@
+package failure
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"testing"
+
+	"github.com/lehigh-university-libraries/cataloger/internal/providers"
+)
+
+type timeoutError struct{}
+
+func (timeoutError) Error() string   { return "i/o timeout" }
+func (timeoutError) Timeout() bool   { return true }
+func (timeoutError) Temporary() bool { return true }
+
+func TestClassify(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want Code
+	}{
+		{"nil", nil, ""},
+		{"wrapped", fmt.Errorf("failed to generate: %w", Wrap(ParseFailure, errors.New("bad json"))), ParseFailure},
+		{"deadline", fmt.Errorf("failed to send request: %w", context.DeadlineExceeded), ProviderTimeout},
+		{"net timeout", fmt.Errorf("failed to send request: %w", &url.Error{Op: "Post", URL: "http://localhost", Err: timeoutError{}}), ProviderTimeout},
+		{"gateway timeout", errors.New("received non-200 status code: 504 - upstream timed out"), ProviderTimeout},
+		{"ocr gateway timeout", errors.New("ollama OCR API returned status 504: timeout"), ProviderTimeout},
+		{"server error", errors.New("received non-200 status code: 500 - boom"), ProviderError},
+		{"refusal", fmt.Errorf("failed to extract metadata with gemini: %w", &providers.RefusalError{Provider: "gemini", Category: providers.RefusalSafety}), Refused},
+	}
+	for _, tt := range tests {
+		if got := Classify(tt.err, ProviderError); got != tt.want {
+			t.Errorf("%s: Classify() = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestParse(t *testing.T) {
+	if c, err := Parse("provider_timeout"); err != nil || c != ProviderTimeout {
+		t.Errorf("Parse(provider_timeout) = %q, %v", c, err)
+	}
+	if _, err := Parse("timeout"); err == nil {
+		t.Error("expected error for unknown code")
+	}
+}
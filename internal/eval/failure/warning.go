@@ -0,0 +1,29 @@
+package failure
+
+import "fmt"
+
+// Warning codes, for issues that don't stop a record from being generated and scored but
+// may point to systematic problems with the data or a model
+const (
+	WarnCoverFallback    = "cover_fallback"           // No title page image; the cover was used
+	WarnEmptyOCR         = "empty_ocr"                // OCR returned no text
+	WarnFencedResponse   = "fenced_response"          // Metadata JSON was wrapped in a markdown code fence
+	WarnNoTitle          = "no_title"                 // No title was extracted
+	WarnPromptFallback   = "prompt_fallback"          // No prompt profile for the material type; the book prompt was used
+	WarnCopyrightOCR     = "copyright_ocr_failed"     // The copyright page could not be OCRed
+	WarnCopyrightPass    = "copyright_pass_failed"    // The copyright page pass failed
+	WarnIdentifierLookup = "identifier_lookup_failed" // The ISBN could not be resolved to an OCLC number or LCCN
+	WarnReferenceLeader  = "invalid_reference_leader" // The reference record's leader is invalid
+	WarnProviderFallback = "provider_fallback"        // The provider failed and a fallback (PROVIDER_FALLBACK) answered
+)
+
+// Warning is a non-fatal issue with an evaluated record
+type Warning struct {
+	Code    string
+	Message string
+}
+
+// Warn returns a warning with a formatted message
+func Warn(code, format string, args ...any) Warning {
+	return Warning{Code: code, Message: fmt.Sprintf(format, args...)}
+}
@@ -0,0 +1,105 @@
+// Package groundtruth audits the reference records of an evaluation dataset. Reference
+// records are catalogers' work and carry their own mistakes; a model that reads the title
+// page correctly is penalized for every one, so records that fail basic checks are suspect
+// ground truth and can be left out of, or count less in, aggregate scores.
+package groundtruth
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lehigh-university-libraries/cataloger/internal/eval/marceval"
+	"github.com/lehigh-university-libraries/cataloger/internal/marc"
+)
+
+// Finding codes
+const (
+	InvalidRecord  = "invalid_record"  // A structural validation error
+	InvalidISBN    = "invalid_isbn"    // An 020 $a failing its check digit
+	ImpossibleDate = "impossible_date" // A date before printing or in the future
+	EmptyTitle     = "empty_title"     // No 245, or a 245 with no title
+	Inconsistent   = "inconsistent"    // A failed cross-field consistency check
+)
+
+// Codes lists the finding codes in report order
+var Codes = []string{InvalidRecord, InvalidISBN, ImpossibleDate, EmptyTitle, Inconsistent}
+
+// earliestYear is before the earliest printed book a library catalogs with a 008 date
+const earliestYear = 1450
+
+var yearPattern = regexp.MustCompile(`\b\d{4}\b`)
+
+// Finding is a problem with a reference record
+type Finding struct {
+	Code    string
+	Tag     string `json:",omitempty"`
+	Message string
+}
+
+// Audit runs the validator and consistency checks against a reference record, as of now
+// for future dates. A record with any finding is suspect.
+func Audit(rec *marc.Record, now time.Time) []Finding {
+	var findings []Finding
+	add := func(code, tag, format string, args ...any) {
+		findings = append(findings, Finding{Code: code, Tag: tag, Message: fmt.Sprintf(format, args...)})
+	}
+
+	for _, issue := range marc.Validate(rec) {
+		switch {
+		case issue.Code == "invalid_isbn":
+			add(InvalidISBN, issue.Tag, "%s", issue.Message)
+		case issue.Code == "missing_title":
+			// Reported as an empty title below
+		case issue.Severity == marc.SeverityError:
+			add(InvalidRecord, issue.Tag, "%s", issue.Message)
+		}
+	}
+
+	title := ""
+	for _, f := range rec.Fields("245") {
+		title += f.Subfield("a") + f.Subfield("k")
+	}
+	if strings.Trim(title, " /:;.,") == "" {
+		add(EmptyTitle, "245", "no title in 245 $a or $k")
+	}
+
+	latest := now.Year() + 1 // Catalogs routinely carry next year's imprints
+	if f008 := rec.ControlField("008"); len(f008) == 40 {
+		if year, err := strconv.Atoi(f008[7:11]); err == nil && (year < earliestYear || year > latest) {
+			add(ImpossibleDate, "008", "008 date %04d is out of range %d-%d", year, earliestYear, latest)
+		}
+	}
+	for _, tag := range []string{"260", "264"} {
+		for _, f := range rec.Fields(tag) {
+			for _, y := range yearPattern.FindAllString(f.Subfield("c"), -1) {
+				if year, _ := strconv.Atoi(y); year < earliestYear || year > latest {
+					add(ImpossibleDate, tag, "imprint date %s is out of range %d-%d", y, earliestYear, latest)
+				}
+			}
+		}
+	}
+
+	_, failed := marceval.CheckConsistency(rec)
+	for _, check := range failed {
+		add(Inconsistent, "", "consistency check %s failed", check)
+	}
+	return findings
+}
+
+// FindingCodes returns the distinct codes of findings, in report order
+func FindingCodes(findings []Finding) []string {
+	seen := make(map[string]bool, len(findings))
+	for _, f := range findings {
+		seen[f.Code] = true
+	}
+	var codes []string
+	for _, code := range Codes {
+		if seen[code] {
+			codes = append(codes, code)
+		}
+	}
+	return codes
+}
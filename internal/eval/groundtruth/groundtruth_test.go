@@ -0,0 +1,38 @@
+package groundtruth
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/lehigh-university-libraries/cataloger/internal/marc"
+)
+
+func TestAudit(t *testing.T) {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	parse := func(mnemonic string) *marc.Record {
+		t.Helper()
+		rec, err := marc.ParseMnemonic(mnemonic)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return rec
+	}
+
+	good := parse("=LDR  00000nam a2200000 i 4500\n=008  850101s1952    nyu           000 1 eng d\n=020  \\\\$a0684801221\n=100  1\\$aHemingway, Ernest.\n=245  14$aThe old man and the sea /$cErnest Hemingway.\n=264  \\1$aNew York :$bScribner,$c1952.")
+	if findings := Audit(good, now); len(findings) != 0 {
+		t.Errorf("good record findings = %+v", findings)
+	}
+
+	bad := parse("=LDR  00000nam a2200000 i 4500\n=008  850101s2952    nyu           000 1 eng d\n=020  \\\\$a0684801222\n=245  14$a /\n=264  \\1$aNew York :$bScribner,$c2952.")
+	codes := FindingCodes(Audit(bad, now))
+	for _, want := range []string{InvalidISBN, ImpossibleDate, EmptyTitle} {
+		if !slices.Contains(codes, want) {
+			t.Errorf("codes = %v, want %s", codes, want)
+		}
+	}
+
+	if codes := FindingCodes(Audit(parse("=LDR  00000nam a2200000 i 4500"), now)); !slices.Contains(codes, EmptyTitle) {
+		t.Errorf("record with no 245: codes = %v", codes)
+	}
+}
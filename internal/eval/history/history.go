@@ -0,0 +1,164 @@
+// Package history keeps a timeline of scheduled eval runs, one summary line per model run,
+// so accuracy can be tracked over time to catch model drift
+package history
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/lehigh-university-libraries/cataloger/internal/eval/marceval"
+)
+
+// FileName is the history file kept in a history root
+const FileName = "history.jsonl"
+
+// Entry summarizes one model's run in one scheduled evaluation
+type Entry struct {
+	Time             time.Time          `json:"time"`
+	Provider         string             `json:"provider"`
+	Model            string             `json:"model"`
+	PromptVersion    string             `json:"prompt_version,omitempty"`
+	Dataset          string             `json:"dataset"`
+	Results          string             `json:"results"` // Report JSON, relative to the history root
+	Records          int                `json:"records"`
+	Succeeded        int                `json:"succeeded"`
+	Failed           int                `json:"failed"`
+	MeanScore        float64            `json:"mean_score"`
+	MeanCompleteness float64            `json:"mean_completeness"`
+	FieldMeans       map[string]float64 `json:"field_means,omitempty"`
+	Error            string             `json:"error,omitempty"` // Set when the run could not finish
+}
+
+// Key identifies the model an entry belongs to
+func (e Entry) Key() string {
+	return e.Provider + "/" + e.Model
+}
+
+// FromReport summarizes a run report saved at results
+func FromReport(at time.Time, report *marceval.Report, results string) Entry {
+	return Entry{
+		Time:             at,
+		Provider:         report.Provider,
+		Model:            report.Model,
+		PromptVersion:    report.PromptVersion,
+		Dataset:          report.Dataset,
+		Results:          results,
+		Records:          report.Records,
+		Succeeded:        report.Succeeded,
+		Failed:           report.Failed,
+		MeanScore:        report.MeanScore,
+		MeanCompleteness: report.MeanCompleteness,
+		FieldMeans:       report.FieldMeans,
+	}
+}
+
+// Append adds an entry to the history file in root
+func Append(root string, e Entry) error {
+	if err := os.MkdirAll(root, 0755); err != nil {
+		return fmt.Errorf("failed to create history directory: %w", err)
+	}
+	data, err := json.Marshal(e)
+	if err != nil {
+		return fmt.Errorf("failed to marshal history entry: %w", err)
+	}
+	f, err := os.OpenFile(filepath.Join(root, FileName), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open history: %w", err)
+	}
+	defer f.Close()
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("failed to write history: %w", err)
+	}
+	return nil
+}
+
+// Load reads the history file in root, oldest first. A missing file is an empty history.
+func Load(root string) ([]Entry, error) {
+	f, err := os.Open(filepath.Join(root, FileName))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open history: %w", err)
+	}
+	defer f.Close()
+
+	var entries []Entry
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var e Entry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			return nil, fmt.Errorf("failed to parse history line %d: %w", line, err)
+		}
+		entries = append(entries, e)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read history: %w", err)
+	}
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].Time.Before(entries[j].Time) })
+	return entries, nil
+}
+
+// Trend is one model's runs over time
+type Trend struct {
+	Provider string  `json:"provider"`
+	Model    string  `json:"model"`
+	Runs     []Entry `json:"runs"` // Oldest first
+}
+
+// Latest returns the most recent finished run, if any
+func (t Trend) Latest() (Entry, bool) {
+	for i := len(t.Runs) - 1; i >= 0; i-- {
+		if t.Runs[i].Error == "" {
+			return t.Runs[i], true
+		}
+	}
+	return Entry{}, false
+}
+
+// Drift is the change in mean score from the first to the latest finished run
+func (t Trend) Drift() float64 {
+	var first *Entry
+	for i := range t.Runs {
+		if t.Runs[i].Error == "" {
+			first = &t.Runs[i]
+			break
+		}
+	}
+	latest, ok := t.Latest()
+	if first == nil || !ok {
+		return 0
+	}
+	return latest.MeanScore - first.MeanScore
+}
+
+// Trends groups entries by model, ordered by provider/model
+func Trends(entries []Entry) []Trend {
+	byKey := make(map[string]*Trend)
+	var keys []string
+	for _, e := range entries {
+		t, ok := byKey[e.Key()]
+		if !ok {
+			t = &Trend{Provider: e.Provider, Model: e.Model}
+			byKey[e.Key()] = t
+			keys = append(keys, e.Key())
+		}
+		t.Runs = append(t.Runs, e)
+	}
+	sort.Strings(keys)
+
+	trends := make([]Trend, 0, len(keys))
+	for _, k := range keys {
+		trends = append(trends, *byKey[k])
+	}
+	return trends
+}
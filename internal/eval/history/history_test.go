@@ -0,0 +1,48 @@
+package history
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAppendLoadTrends(t *testing.T) {
+	root := t.TempDir()
+	if entries, err := Load(root); err != nil || entries != nil {
+		t.Fatalf("Load(empty) = %v, %v, want no entries", entries, err)
+	}
+
+	day := time.Date(2025, 1, 1, 3, 0, 0, 0, time.UTC)
+	for _, e := range []Entry{
+		{Time: day.AddDate(0, 0, 1), Provider: "ollama", Model: "a", MeanScore: 0.7},
+		{Time: day, Provider: "ollama", Model: "a", MeanScore: 0.8},
+		{Time: day, Provider: "openai", Model: "b", MeanScore: 0.9},
+		{Time: day.AddDate(0, 0, 2), Provider: "ollama", Model: "a", Error: "harvest failed"},
+	} {
+		if err := Append(root, e); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	entries, err := Load(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 4 || !entries[0].Time.Equal(day) {
+		t.Fatalf("Load() = %+v, want 4 entries oldest first", entries)
+	}
+
+	trends := Trends(entries)
+	if len(trends) != 2 || trends[0].Model != "a" || len(trends[0].Runs) != 3 {
+		t.Fatalf("Trends() = %+v", trends)
+	}
+	latest, ok := trends[0].Latest()
+	if !ok || latest.MeanScore != 0.7 {
+		t.Errorf("Latest() = %+v, want the last finished run", latest)
+	}
+	if d := trends[0].Drift(); d > -0.099 || d < -0.101 {
+		t.Errorf("Drift() = %v, want -0.1", d)
+	}
+	if d := trends[1].Drift(); d != 0 {
+		t.Errorf("Drift() of a single run = %v, want 0", d)
+	}
+}
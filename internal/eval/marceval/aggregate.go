@@ -0,0 +1,384 @@
+package marceval
+
+import (
+	"maps"
+	"time"
+
+	"github.com/lehigh-university-libraries/cataloger/internal/eval/failure"
+	"github.com/lehigh-university-libraries/cataloger/internal/marc"
+)
+
+// Aggregator builds a report from results added one at a time. It keeps sums rather than the
+// results, so memory doesn't grow with the number of records, and aggregators of disjoint
+// results can be merged to aggregate in parallel.
+type Aggregator struct {
+	sums Report // Means hold sums until Report divides them
+
+	fieldWeights     map[string]float64 // Sum of the weights of the records scoring each tag
+	scoredWeight     float64
+	identifierCounts map[string]int
+	indicatorCounts  map[string]int
+	copyrightCounts  map[string]int
+	successDuration  time.Duration
+}
+
+// NewAggregator returns an empty aggregator
+func NewAggregator() *Aggregator {
+	return &Aggregator{
+		sums:             Report{FieldMeans: make(map[string]float64)},
+		fieldWeights:     make(map[string]float64),
+		identifierCounts: make(map[string]int),
+		indicatorCounts:  make(map[string]int),
+		copyrightCounts:  make(map[string]int),
+	}
+}
+
+// Add aggregates one result
+func (a *Aggregator) Add(res Result) {
+	r := &a.sums
+	r.Records++
+	r.TotalProcessingTime += res.ProcessingTime
+	if len(res.SuspectReference) > 0 {
+		r.SuspectReferences++
+		if r.SuspectFindings == nil {
+			r.SuspectFindings = make(map[string]int)
+		}
+		for _, code := range res.SuspectReference {
+			r.SuspectFindings[code]++
+		}
+	}
+	if len(res.Warnings) > 0 {
+		r.RecordsWithWarnings++
+		if r.WarningCounts == nil {
+			r.WarningCounts = make(map[string]int)
+		}
+		seen := make(map[string]bool, len(res.Warnings))
+		for _, w := range res.Warnings {
+			if !seen[w.Code] {
+				seen[w.Code] = true
+				r.WarningCounts[w.Code]++
+			}
+		}
+	}
+	if res.Provider != "" {
+		if r.Refusals == nil {
+			r.Refusals = make(map[string]*RefusalStats)
+		}
+		model := res.Provider + "/" + res.Model
+		s := r.Refusals[model]
+		if s == nil {
+			s = &RefusalStats{}
+			r.Refusals[model] = s
+		}
+		s.Records++
+		if res.Refusal != "" {
+			s.Refused++
+			if s.Categories == nil {
+				s.Categories = make(map[string]int)
+			}
+			s.Categories[res.Refusal]++
+			if res.Error == "" {
+				s.Recovered++
+			}
+		}
+	}
+	var material *MaterialStats
+	if res.MaterialType != "" {
+		if r.Materials == nil {
+			r.Materials = make(map[string]*MaterialStats)
+		}
+		material = r.Materials[res.MaterialType]
+		if material == nil {
+			material = &MaterialStats{}
+			r.Materials[res.MaterialType] = material
+		}
+		material.Records++
+		if material.PromptVersion == "" {
+			material.PromptVersion = res.PromptVersion
+		}
+	}
+	if res.Error != "" {
+		r.Failed++
+		if r.FailureCodes == nil {
+			r.FailureCodes = make(map[failure.Code]int)
+		}
+		r.FailureCodes[res.FailureCode()]++
+		if res.Anomaly != "" {
+			if r.Anomalies == nil {
+				r.Anomalies = make(map[string]int)
+			}
+			r.Anomalies[res.Anomaly]++
+		}
+		if material != nil {
+			material.Failed++
+		}
+		return
+	}
+
+	r.Succeeded++
+	a.successDuration += res.ProcessingTime
+	r.MeanCompleteness += res.Completeness
+	for _, name := range res.PresentElements {
+		if r.ElementPresence == nil {
+			r.ElementPresence = make(map[string]float64)
+		}
+		r.ElementPresence[name]++
+	}
+	for _, name := range res.MissingElements {
+		if r.RequiredMissing == nil {
+			r.RequiredMissing = make(map[string]int)
+		}
+		r.RequiredMissing[name]++
+	}
+	r.MeanConsistency += res.Consistency
+	for _, name := range res.Inconsistencies {
+		if r.Inconsistencies == nil {
+			r.Inconsistencies = make(map[string]int)
+		}
+		r.Inconsistencies[name]++
+	}
+	hasError := false
+	for _, issue := range res.Issues {
+		if r.IssueCounts == nil {
+			r.IssueCounts = make(map[string]int)
+		}
+		r.IssueCounts[issue.Code]++
+		hasError = hasError || issue.Severity == marc.SeverityError
+	}
+	if hasError {
+		r.RecordsWithErrors++
+	}
+
+	if c := res.CopyrightComparison; c != nil {
+		r.CopyrightScored++
+		r.CopyrightMeanScore += c.Score
+		for tag, f := range c.Fields {
+			if r.CopyrightFieldMeans == nil {
+				r.CopyrightFieldMeans = make(map[string]float64)
+			}
+			r.CopyrightFieldMeans[tag] += f.Score
+			a.copyrightCounts[tag]++
+		}
+	}
+
+	if res.Comparison == nil {
+		return
+	}
+	w := res.ScoreWeight()
+	if w <= 0 {
+		r.ExcludedScored++
+		return
+	}
+	r.Scored++
+	r.MeanScore += w * res.Comparison.Score
+	a.scoredWeight += w
+	if material != nil {
+		material.Scored++
+		material.MeanScore += w * res.Comparison.Score
+		material.weight += w
+	}
+	r.MeanOrderScore += res.Comparison.OrderScore
+	for tag := range res.Comparison.Duplicates {
+		if r.DuplicateFields == nil {
+			r.DuplicateFields = make(map[string]int)
+		}
+		r.DuplicateFields[tag]++
+	}
+	for tag, f := range res.Comparison.Fields {
+		r.FieldMeans[tag] += w * f.Score
+		a.fieldWeights[tag] += w
+		if f.Scorer != "" {
+			if r.FieldScorers == nil {
+				r.FieldScorers = make(map[string]string)
+			}
+			r.FieldScorers[tag] = f.Scorer
+		}
+	}
+	for tag, id := range res.Comparison.Identifiers {
+		if r.IdentifierAccuracy == nil {
+			r.IdentifierAccuracy = make(map[string]float64)
+		}
+		if id.Correct {
+			r.IdentifierAccuracy[tag]++
+		}
+		a.identifierCounts[tag]++
+	}
+	for name, ind := range res.Comparison.Indicators {
+		if ind.Checked == 0 {
+			continue
+		}
+		if r.IndicatorAccuracy == nil {
+			r.IndicatorAccuracy = make(map[string]float64)
+		}
+		r.IndicatorAccuracy[name] += float64(ind.Correct)
+		a.indicatorCounts[name] += ind.Checked
+	}
+	if acc, ok := res.Comparison.IndicatorAccuracy(); ok && acc < 1 {
+		r.RecordsWithIndicatorErrors++
+	}
+}
+
+// Report returns the report of the results added so far, without the results themselves
+func (a *Aggregator) Report() *Report {
+	r := a.sums.clone()
+	r.SchemaVersion = ReportSchema.Version()
+	r.EvaluationDate = time.Now()
+	if r.Succeeded > 0 {
+		r.MeanCompleteness /= float64(r.Succeeded)
+		r.MeanConsistency /= float64(r.Succeeded)
+		for name := range r.ElementPresence {
+			r.ElementPresence[name] /= float64(r.Succeeded)
+		}
+		r.AverageProcessingTime = a.successDuration / time.Duration(r.Succeeded)
+	}
+	if r.Scored > 0 {
+		r.MeanScore /= a.scoredWeight
+		r.MeanOrderScore /= float64(r.Scored)
+	}
+	for _, m := range r.Materials {
+		if m.Scored > 0 {
+			m.MeanScore /= m.weight
+		}
+	}
+	if r.CopyrightScored > 0 {
+		r.CopyrightMeanScore /= float64(r.CopyrightScored)
+	}
+	for tag, n := range a.copyrightCounts {
+		r.CopyrightFieldMeans[tag] /= float64(n)
+	}
+	for tag, w := range a.fieldWeights {
+		r.FieldMeans[tag] /= w
+	}
+	for tag, n := range a.identifierCounts {
+		r.IdentifierAccuracy[tag] /= float64(n)
+	}
+	for name, n := range a.indicatorCounts {
+		r.IndicatorAccuracy[name] /= float64(n)
+	}
+	return r
+}
+
+// Merge adds the results aggregated by b
+func (a *Aggregator) Merge(b *Aggregator) {
+	r, o := &a.sums, &b.sums
+	r.Records += o.Records
+	r.Succeeded += o.Succeeded
+	r.Failed += o.Failed
+	r.RecordsWithWarnings += o.RecordsWithWarnings
+	r.Scored += o.Scored
+	r.MeanScore += o.MeanScore
+	r.SuspectReferences += o.SuspectReferences
+	r.ExcludedScored += o.ExcludedScored
+	r.RecordsWithIndicatorErrors += o.RecordsWithIndicatorErrors
+	r.CopyrightScored += o.CopyrightScored
+	r.CopyrightMeanScore += o.CopyrightMeanScore
+	r.MeanOrderScore += o.MeanOrderScore
+	r.MeanCompleteness += o.MeanCompleteness
+	r.MeanConsistency += o.MeanConsistency
+	r.RecordsWithErrors += o.RecordsWithErrors
+	r.TotalProcessingTime += o.TotalProcessingTime
+
+	r.FailureCodes = addCounts(r.FailureCodes, o.FailureCodes)
+	r.Anomalies = addCounts(r.Anomalies, o.Anomalies)
+	r.WarningCounts = addCounts(r.WarningCounts, o.WarningCounts)
+	r.FieldMeans = addCounts(r.FieldMeans, o.FieldMeans)
+	r.IdentifierAccuracy = addCounts(r.IdentifierAccuracy, o.IdentifierAccuracy)
+	r.IndicatorAccuracy = addCounts(r.IndicatorAccuracy, o.IndicatorAccuracy)
+	r.CopyrightFieldMeans = addCounts(r.CopyrightFieldMeans, o.CopyrightFieldMeans)
+	r.DuplicateFields = addCounts(r.DuplicateFields, o.DuplicateFields)
+	r.ElementPresence = addCounts(r.ElementPresence, o.ElementPresence)
+	r.RequiredMissing = addCounts(r.RequiredMissing, o.RequiredMissing)
+	r.IssueCounts = addCounts(r.IssueCounts, o.IssueCounts)
+	r.Inconsistencies = addCounts(r.Inconsistencies, o.Inconsistencies)
+	r.SuspectFindings = addCounts(r.SuspectFindings, o.SuspectFindings)
+	for tag, scorer := range o.FieldScorers {
+		if r.FieldScorers == nil {
+			r.FieldScorers = make(map[string]string)
+		}
+		r.FieldScorers[tag] = scorer
+	}
+	for material, om := range o.Materials {
+		if r.Materials == nil {
+			r.Materials = make(map[string]*MaterialStats)
+		}
+		m := r.Materials[material]
+		if m == nil {
+			m = &MaterialStats{PromptVersion: om.PromptVersion}
+			r.Materials[material] = m
+		}
+		m.Records += om.Records
+		m.Failed += om.Failed
+		m.Scored += om.Scored
+		m.MeanScore += om.MeanScore
+		m.weight += om.weight
+	}
+	for model, ors := range o.Refusals {
+		if r.Refusals == nil {
+			r.Refusals = make(map[string]*RefusalStats)
+		}
+		s := r.Refusals[model]
+		if s == nil {
+			s = &RefusalStats{}
+			r.Refusals[model] = s
+		}
+		s.Records += ors.Records
+		s.Refused += ors.Refused
+		s.Recovered += ors.Recovered
+		s.Categories = addCounts(s.Categories, ors.Categories)
+	}
+
+	a.fieldWeights = addCounts(a.fieldWeights, b.fieldWeights)
+	a.scoredWeight += b.scoredWeight
+	a.identifierCounts = addCounts(a.identifierCounts, b.identifierCounts)
+	a.indicatorCounts = addCounts(a.indicatorCounts, b.indicatorCounts)
+	a.copyrightCounts = addCounts(a.copyrightCounts, b.copyrightCounts)
+	a.successDuration += b.successDuration
+}
+
+// clone copies a report's maps so the copy's means can be computed without touching the sums
+func (r *Report) clone() *Report {
+	c := *r
+	c.FailureCodes = maps.Clone(r.FailureCodes)
+	c.Anomalies = maps.Clone(r.Anomalies)
+	c.WarningCounts = maps.Clone(r.WarningCounts)
+	c.FieldMeans = maps.Clone(r.FieldMeans)
+	c.FieldScorers = maps.Clone(r.FieldScorers)
+	c.IdentifierAccuracy = maps.Clone(r.IdentifierAccuracy)
+	c.IndicatorAccuracy = maps.Clone(r.IndicatorAccuracy)
+	c.CopyrightFieldMeans = maps.Clone(r.CopyrightFieldMeans)
+	c.DuplicateFields = maps.Clone(r.DuplicateFields)
+	c.ElementPresence = maps.Clone(r.ElementPresence)
+	c.RequiredMissing = maps.Clone(r.RequiredMissing)
+	c.IssueCounts = maps.Clone(r.IssueCounts)
+	c.Inconsistencies = maps.Clone(r.Inconsistencies)
+	c.SuspectFindings = maps.Clone(r.SuspectFindings)
+	if r.Materials != nil {
+		c.Materials = make(map[string]*MaterialStats, len(r.Materials))
+		for material, m := range r.Materials {
+			mc := *m
+			c.Materials[material] = &mc
+		}
+	}
+	if r.Refusals != nil {
+		c.Refusals = make(map[string]*RefusalStats, len(r.Refusals))
+		for model, s := range r.Refusals {
+			sc := *s
+			sc.Categories = maps.Clone(s.Categories)
+			c.Refusals[model] = &sc
+		}
+	}
+	return &c
+}
+
+func addCounts[K comparable, V int | float64](dst, src map[K]V) map[K]V {
+	if len(src) == 0 {
+		return dst
+	}
+	if dst == nil {
+		dst = make(map[K]V, len(src))
+	}
+	for k, v := range src {
+		dst[k] += v
+	}
+	return dst
+}
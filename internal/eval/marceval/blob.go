@@ -0,0 +1,94 @@
+package marceval
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Blob is the large text of a result, stored apart from the results when they would
+// otherwise be too big to open in an editor
+type Blob struct {
+	OCRText       string `json:",omitempty"`
+	GeneratedMARC string `json:",omitempty"`
+	RawResponse   string `json:",omitempty"`
+}
+
+// blobName replaces path separators so any item ID is a file name
+var blobName = strings.NewReplacer("/", "_", `\`, "_")
+
+// BlobDir stores result blobs as gzipped JSON files, one per record
+type BlobDir struct {
+	dir     string
+	results string // Directory of the results file, which blob references are relative to
+}
+
+// NewBlobDir creates a blob directory for a results file saved at resultsPath
+func NewBlobDir(dir, resultsPath string) (*BlobDir, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create blob directory: %w", err)
+	}
+	return &BlobDir{dir: dir, results: filepath.Dir(resultsPath)}, nil
+}
+
+// Store moves a result's OCR text, generated MARC and raw response to <dir>/<id>.json.gz
+// and sets its Blob to the file's path, relative to the results file when it can be
+func (b *BlobDir) Store(res *Result) error {
+	blob := Blob{OCRText: res.OCRText, GeneratedMARC: res.GeneratedMARC, RawResponse: res.RawResponse}
+	if blob == (Blob{}) {
+		return nil
+	}
+	data, err := json.Marshal(blob)
+	if err != nil {
+		return fmt.Errorf("failed to encode blob %s: %w", res.ID, err)
+	}
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write(data); err != nil {
+		return fmt.Errorf("failed to compress blob %s: %w", res.ID, err)
+	}
+	if err := zw.Close(); err != nil {
+		return fmt.Errorf("failed to compress blob %s: %w", res.ID, err)
+	}
+	path := filepath.Join(b.dir, blobName.Replace(res.ID)+".json.gz")
+	if err := os.WriteFile(path, buf.Bytes(), 0644); err != nil {
+		return fmt.Errorf("failed to write blob %s: %w", res.ID, err)
+	}
+
+	res.Blob = path
+	if rel, err := filepath.Rel(b.results, path); err == nil && !strings.HasPrefix(rel, "..") {
+		res.Blob = filepath.ToSlash(rel)
+	}
+	res.OCRText, res.GeneratedMARC, res.RawResponse = "", "", ""
+	return nil
+}
+
+// BlobPath resolves a result's Blob reference against the directory of its results file
+func (r Result) BlobPath(resultsPath string) string {
+	if r.Blob == "" || filepath.IsAbs(r.Blob) {
+		return r.Blob
+	}
+	return filepath.Join(filepath.Dir(resultsPath), filepath.FromSlash(r.Blob))
+}
+
+// ReadBlob reads a blob file
+func ReadBlob(path string) (Blob, error) {
+	var blob Blob
+	file, err := os.Open(path)
+	if err != nil {
+		return blob, fmt.Errorf("failed to open blob: %w", err)
+	}
+	defer file.Close()
+	zr, err := gzip.NewReader(file)
+	if err != nil {
+		return blob, fmt.Errorf("failed to read blob %s: %w", path, err)
+	}
+	if err := json.NewDecoder(zr).Decode(&blob); err != nil {
+		return blob, fmt.Errorf("failed to parse blob %s: %w", path, err)
+	}
+	return blob, nil
+}
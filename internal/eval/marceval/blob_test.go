@@ -0,0 +1,39 @@
+package marceval
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestBlobDir(t *testing.T) {
+	dir := t.TempDir()
+	resultsPath := filepath.Join(dir, "results.json")
+	blobs, err := NewBlobDir(filepath.Join(dir, "blobs"), resultsPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	res := Result{ID: "a/1", OCRText: "Title page", GeneratedMARC: "=245  10$aTitle", RawResponse: `{"title":"Title"}`}
+	if err := blobs.Store(&res); err != nil {
+		t.Fatal(err)
+	}
+	if res.Blob != "blobs/a_1.json.gz" {
+		t.Errorf("Blob = %q, want a reference relative to the results file", res.Blob)
+	}
+	if res.OCRText != "" || res.GeneratedMARC != "" || res.RawResponse != "" {
+		t.Error("Store left text in the result")
+	}
+
+	blob, err := ReadBlob(res.BlobPath(resultsPath))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if blob.OCRText != "Title page" || blob.GeneratedMARC != "=245  10$aTitle" || blob.RawResponse != `{"title":"Title"}` {
+		t.Errorf("ReadBlob = %+v", blob)
+	}
+
+	empty := Result{ID: "b"}
+	if err := blobs.Store(&empty); err != nil || empty.Blob != "" {
+		t.Errorf("Store of a result without text = %q, %v", empty.Blob, err)
+	}
+}
@@ -0,0 +1,254 @@
+package marceval
+
+import (
+	"slices"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/lehigh-university-libraries/cataloger/internal/marc"
+)
+
+// DefaultWeights are the fields scored when comparing a generated record to a reference,
+// weighted by how much they matter for discovery
+var DefaultWeights = map[string]float64{
+	"020": 1,
+	"100": 2,
+	"110": 1,
+	"111": 1,
+	"245": 3,
+	"246": 0.5,
+	"250": 1,
+	"264": 2,
+	"300": 1,
+	"490": 1,
+	"500": 0.5,
+	"504": 0.5,
+	"650": 1.5,
+	"651": 1,
+	"655": 0.5,
+	"700": 1,
+	"710": 0.5,
+}
+
+// tagAliases maps tags that carry the same data to the tag used for scoring
+var tagAliases = map[string]string{
+	"260": "264", // Pre-RDA publication statement
+}
+
+// Match classifications for a field
+const (
+	MatchExact         = "exact"
+	MatchFuzzyHigh     = "fuzzy_high"
+	MatchFuzzyMedium   = "fuzzy_medium"
+	MatchNone          = "no_match"
+	MatchActualMissing = "actual_missing"
+)
+
+// FieldScore is the comparison of one tag between reference and generated records
+type FieldScore struct {
+	Tag      string
+	Expected []string
+	Actual   []string
+	Weight   float64
+	Score    float64 // 0.0 to 1.0
+	Match    string
+	Scorer   string `json:",omitempty"` // Extension that scored the tag, if any
+}
+
+// Comparison is the field-by-field comparison of a generated record with a reference
+type Comparison struct {
+	Fields    map[string]FieldScore
+	Score     float64 // Weighted mean of field scores, less any Penalties
+	RawScore  float64 `json:",omitempty"` // Score before Penalties, when any were applied
+	Matched   int
+	Incorrect int
+	Missing   int
+	Extra     int // Scored tags present only in the generated record
+
+	Identifiers map[string]IdentifierScore `json:",omitempty"` // 010/035 exact matches, not part of Score
+	Indicators  map[string]IndicatorScore  `json:",omitempty"` // IndicatorChecks, not part of Score
+
+	OrderScore float64        // Share of adjacent generated fields in tag order
+	Duplicates map[string]int `json:",omitempty"` // Non-repeatable generated tag -> occurrences
+}
+
+// Compare scores a generated record against a reference using DefaultWeights
+func Compare(reference, generated *marc.Record) *Comparison {
+	return CompareWeighted(reference, generated, DefaultWeights)
+}
+
+// CompareWeighted scores the tags in weights, and those of registered Extensions, that appear
+// in the reference record.
+// Indicators are ignored by the score and checked separately (see IndicatorChecks); repeated fields are matched pairwise. Identity control fields
+// (001/003/005) are never scored, even when listed in weights.
+func CompareWeighted(reference, generated *marc.Record, weights map[string]float64) *Comparison {
+	weights = withExtensionWeights(weights)
+	expected := parseMARCFields(reference)
+	actual := parseMARCFields(generated)
+
+	c := &Comparison{
+		Fields:      make(map[string]FieldScore),
+		Identifiers: compareIdentifiers(reference, generated),
+		Indicators:  compareIndicators(reference, generated),
+		OrderScore:  orderScore(generated),
+		Duplicates:  duplicateFields(generated),
+	}
+	totalWeight := 0.0
+
+	for tag, weight := range weights {
+		if slices.Contains(marc.IdentityTags, tag) {
+			continue
+		}
+		exp, act := expected[tag], actual[tag]
+		if len(exp) == 0 {
+			if len(act) > 0 {
+				c.Extra++
+			}
+			continue
+		}
+
+		fs := FieldScore{Tag: tag, Expected: exp, Actual: act, Weight: weight}
+		if len(act) == 0 {
+			fs.Match = MatchActualMissing
+			c.Missing++
+		} else {
+			if score, scorer, ok := extensionScore(tag, reference, generated); ok {
+				fs.Score, fs.Scorer = score, scorer
+			} else {
+				fs.Score = matchValues(exp, act)
+			}
+			fs.Match = classify(fs.Score)
+			if fs.Score >= 0.7 {
+				c.Matched++
+			} else {
+				c.Incorrect++
+			}
+		}
+
+		c.Fields[tag] = fs
+		c.Score += weight * fs.Score
+		totalWeight += weight
+	}
+
+	if totalWeight > 0 {
+		c.Score /= totalWeight
+	}
+	return c
+}
+
+// Tags returns the scored tags in order
+func (c *Comparison) Tags() []string {
+	tags := make([]string, 0, len(c.Fields))
+	for tag := range c.Fields {
+		tags = append(tags, tag)
+	}
+	sort.Strings(tags)
+	return tags
+}
+
+// parseMARCFields maps each data field tag to the normalized text of its occurrences.
+// Control subfields ($0-$9) are skipped since they hold links and identifiers, not content.
+func parseMARCFields(rec *marc.Record) map[string][]string {
+	fields := make(map[string][]string)
+	if rec == nil {
+		return fields
+	}
+
+	for _, df := range rec.DataFields {
+		tag := df.Tag
+		if alias, ok := tagAliases[tag]; ok {
+			tag = alias
+		}
+
+		if text := normalizedText(tag, df); text != "" {
+			fields[tag] = append(fields[tag], text)
+		}
+	}
+
+	return fields
+}
+
+// matchValues pairs each expected value with its most similar unused actual value.
+// Unpaired values on either side score zero.
+func matchValues(expected, actual []string) float64 {
+	used := make([]bool, len(actual))
+	total := 0.0
+
+	for _, exp := range expected {
+		best, bestIdx := 0.0, -1
+		for i, act := range actual {
+			if used[i] {
+				continue
+			}
+			if s := similarity(exp, act); s > best || bestIdx < 0 {
+				best, bestIdx = s, i
+			}
+		}
+		if bestIdx >= 0 {
+			used[bestIdx] = true
+			total += best
+		}
+	}
+
+	return total / float64(max(len(expected), len(actual)))
+}
+
+func classify(score float64) string {
+	switch {
+	case score >= 0.999:
+		return MatchExact
+	case score >= 0.9:
+		return MatchFuzzyHigh
+	case score >= 0.7:
+		return MatchFuzzyMedium
+	default:
+		return MatchNone
+	}
+}
+
+// normalize lowercases text and reduces ISBD punctuation and spacing differences
+func normalize(s string) string {
+	s = strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return unicode.ToLower(r)
+		}
+		return ' '
+	}, s)
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// similarity is 1 - normalized Levenshtein distance
+func similarity(a, b string) float64 {
+	if a == b {
+		return 1
+	}
+	ra, rb := []rune(a), []rune(b)
+	longest := max(len(ra), len(rb))
+	if longest == 0 {
+		return 1
+	}
+	return 1 - float64(levenshtein(ra, rb))/float64(longest)
+}
+
+func levenshtein(a, b []rune) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+
+	return prev[len(b)]
+}
@@ -0,0 +1,162 @@
+package marceval
+
+import (
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/lehigh-university-libraries/cataloger/internal/marc"
+	"gopkg.in/yaml.v3"
+)
+
+// Element is a descriptive element checked for presence; any of its tag patterns satisfies
+// it, and when Subfield is set the subfield must be present and non-empty
+type Element struct {
+	Name     string   `yaml:"name"`
+	Tags     []string `yaml:"tags"` // Tag patterns, X is a wildcard
+	Subfield string   `yaml:"subfield,omitempty"`
+	Required bool     `yaml:"required"` // Optional elements are "if applicable": reported but not scored
+}
+
+// CompletenessProfile is a named set of elements a record is expected to carry
+type CompletenessProfile struct {
+	Name     string    `yaml:"name"`
+	Elements []Element `yaml:"elements"`
+}
+
+// CoreProfile is a short list of elements any usable monograph record carries
+var CoreProfile = CompletenessProfile{
+	Name: "core",
+	Elements: []Element{
+		{Name: "title", Tags: []string{"245"}, Required: true},
+		{Name: "publication", Tags: []string{"264", "260"}, Required: true},
+		{Name: "extent", Tags: []string{"300"}, Required: true},
+		{Name: "identifier", Tags: []string{"020", "010"}, Required: true},
+		{Name: "content_type", Tags: []string{"336"}, Required: true},
+		{Name: "media_type", Tags: []string{"337"}, Required: true},
+		{Name: "carrier_type", Tags: []string{"338"}, Required: true},
+		{Name: "subject", Tags: []string{"6XX"}, Required: true},
+		{Name: "classification", Tags: []string{"050", "082", "090"}, Required: true},
+	},
+}
+
+// PCCBSRProfile follows the PCC BIBCO Standard Record (BSR) core elements for printed
+// monographs. Elements the BSR requires only "if applicable" are optional here.
+var PCCBSRProfile = CompletenessProfile{
+	Name: "pcc-bsr",
+	Elements: []Element{
+		{Name: "cataloging_source", Tags: []string{"040"}, Subfield: "a", Required: true},
+		{Name: "description_conventions", Tags: []string{"040"}, Subfield: "e", Required: true},
+		{Name: "fixed_length_data", Tags: []string{"008"}, Required: true},
+		{Name: "title_proper", Tags: []string{"245"}, Subfield: "a", Required: true},
+		{Name: "statement_of_responsibility", Tags: []string{"245"}, Subfield: "c"},
+		{Name: "creator", Tags: []string{"100", "110", "111"}},
+		{Name: "edition_statement", Tags: []string{"250"}},
+		{Name: "place_of_publication", Tags: []string{"264", "260"}, Subfield: "a", Required: true},
+		{Name: "publisher", Tags: []string{"264", "260"}, Subfield: "b", Required: true},
+		{Name: "date_of_publication", Tags: []string{"264", "260"}, Subfield: "c", Required: true},
+		{Name: "extent", Tags: []string{"300"}, Subfield: "a", Required: true},
+		{Name: "dimensions", Tags: []string{"300"}, Subfield: "c", Required: true},
+		{Name: "content_type", Tags: []string{"336"}, Required: true},
+		{Name: "media_type", Tags: []string{"337"}, Required: true},
+		{Name: "carrier_type", Tags: []string{"338"}, Required: true},
+		{Name: "series_statement", Tags: []string{"490"}},
+		{Name: "isbn", Tags: []string{"020"}},
+		{Name: "language_code", Tags: []string{"041"}},
+		{Name: "subject", Tags: []string{"600", "610", "611", "630", "650", "651"}, Required: true},
+		{Name: "classification", Tags: []string{"050", "090", "082"}, Required: true},
+		{Name: "related_names", Tags: []string{"700", "710", "711"}},
+	},
+}
+
+// Profiles are the builtin completeness profiles by name
+var Profiles = map[string]CompletenessProfile{
+	CoreProfile.Name:   CoreProfile,
+	PCCBSRProfile.Name: PCCBSRProfile,
+}
+
+// LoadProfile returns a builtin profile by name, or reads one from a YAML file
+func LoadProfile(nameOrPath string) (CompletenessProfile, error) {
+	if p, ok := Profiles[nameOrPath]; ok {
+		return p, nil
+	}
+
+	data, err := os.ReadFile(nameOrPath)
+	if err != nil {
+		return CompletenessProfile{}, fmt.Errorf("unknown completeness profile %q (builtin: %v): %w", nameOrPath, ProfileNames(), err)
+	}
+	var p CompletenessProfile
+	if err := yaml.Unmarshal(data, &p); err != nil {
+		return CompletenessProfile{}, fmt.Errorf("failed to parse completeness profile %s: %w", nameOrPath, err)
+	}
+	if len(p.Elements) == 0 {
+		return CompletenessProfile{}, fmt.Errorf("completeness profile %s has no elements", nameOrPath)
+	}
+	if p.Name == "" {
+		p.Name = nameOrPath
+	}
+	return p, nil
+}
+
+// ProfileNames lists the builtin profiles
+func ProfileNames() []string {
+	names := make([]string, 0, len(Profiles))
+	for name := range Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Check returns the share of required elements present, the names of all elements present
+// and the names of required elements missing
+func (p CompletenessProfile) Check(rec *marc.Record) (score float64, present, missing []string) {
+	if rec == nil {
+		return 0, nil, nil
+	}
+
+	required := 0
+	for _, e := range p.Elements {
+		found := e.presentIn(rec)
+		if found {
+			present = append(present, e.Name)
+		}
+		if e.Required {
+			required++
+			if !found {
+				missing = append(missing, e.Name)
+			}
+		}
+	}
+
+	if required == 0 {
+		return 1, present, missing
+	}
+	return float64(required-len(missing)) / float64(required), present, missing
+}
+
+func (e Element) presentIn(rec *marc.Record) bool {
+	if e.Subfield == "" {
+		return rec.HasTag(e.Tags)
+	}
+	for _, df := range rec.DataFields {
+		if !matchesTag(e.Tags, df.Tag) {
+			continue
+		}
+		for _, sf := range df.Subfields {
+			if sf.Code == e.Subfield && sf.Value != "" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func matchesTag(patterns []string, tag string) bool {
+	for _, p := range patterns {
+		if marc.TagMatches(p, tag) {
+			return true
+		}
+	}
+	return false
+}
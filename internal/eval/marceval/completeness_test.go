@@ -0,0 +1,60 @@
+package marceval
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/lehigh-university-libraries/cataloger/internal/marc"
+)
+
+func TestCompletenessCheck(t *testing.T) {
+	profile := CompletenessProfile{Elements: []Element{
+		{Name: "title_proper", Tags: []string{"245"}, Subfield: "a", Required: true},
+		{Name: "statement_of_responsibility", Tags: []string{"245"}, Subfield: "c"},
+		{Name: "publisher", Tags: []string{"264", "260"}, Subfield: "b", Required: true},
+		{Name: "subject", Tags: []string{"6XX"}, Required: true},
+	}}
+
+	rec := &marc.Record{DataFields: []marc.DataField{
+		{Tag: "245", Subfields: []marc.Subfield{{Code: "a", Value: "The old man and the sea"}}},
+		{Tag: "260", Subfields: []marc.Subfield{{Code: "a", Value: "New York"}, {Code: "b", Value: ""}}},
+		{Tag: "651", Subfields: []marc.Subfield{{Code: "a", Value: "Cuba"}}},
+	}}
+
+	score, present, missing := profile.Check(rec)
+	if score != 2.0/3.0 {
+		t.Errorf("score = %v, want 2/3", score)
+	}
+	if !slices.Equal(present, []string{"title_proper", "subject"}) {
+		t.Errorf("present = %v", present)
+	}
+	// An empty $b does not count, and optional elements are never reported missing
+	if !slices.Equal(missing, []string{"publisher"}) {
+		t.Errorf("missing = %v", missing)
+	}
+}
+
+func TestLoadProfile(t *testing.T) {
+	if p, err := LoadProfile("pcc-bsr"); err != nil || p.Name != "pcc-bsr" {
+		t.Fatalf("builtin profile: %v, %v", p.Name, err)
+	}
+
+	path := filepath.Join(t.TempDir(), "local.yaml")
+	data := "name: local\nelements:\n  - name: call_number\n    tags: [\"090\", \"050\"]\n    required: true\n"
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+	p, err := LoadProfile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Name != "local" || len(p.Elements) != 1 || !p.Elements[0].Required {
+		t.Errorf("unexpected profile %+v", p)
+	}
+
+	if _, err := LoadProfile("no-such-profile"); err == nil {
+		t.Error("expected an error for an unknown profile")
+	}
+}
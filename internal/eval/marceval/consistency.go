@@ -0,0 +1,132 @@
+package marceval
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/lehigh-university-libraries/cataloger/internal/marc"
+)
+
+// Cross-field consistency checks
+const (
+	CheckDates     = "008_date_imprint" // 008/07-14 dates match the 260/264 $c year
+	CheckLanguage  = "008_language_041" // 008/35-37 matches the first 041 $a
+	CheckMainEntry = "1xx_245_ind1"     // 245 first indicator is 1 exactly when there is a 1XX
+	CheckISBN      = "020_isbn"         // Every 020 $a has a valid check digit
+)
+
+// ConsistencyChecks lists the checks in report order
+var ConsistencyChecks = []string{CheckDates, CheckLanguage, CheckMainEntry, CheckISBN}
+
+var imprintYear = regexp.MustCompile(`\d{4}`)
+
+// CheckConsistency runs the cross-field checks that apply to a record and returns the share
+// that passed, 1 when none apply, with the names of those that failed. A check applies only
+// when both sides are present, so a missing 041 or 020 is left to completeness.
+func CheckConsistency(rec *marc.Record) (float64, []string) {
+	applied, passed := 0, 0
+	var failed []string
+	check := func(name string, applies, ok bool) {
+		if !applies {
+			return
+		}
+		applied++
+		if ok {
+			passed++
+		} else {
+			failed = append(failed, name)
+		}
+	}
+
+	f008 := rec.ControlField("008")
+	if len(f008) != 40 {
+		f008 = ""
+	}
+
+	if years := imprintYears(rec); f008 != "" && len(years) > 0 {
+		date1, date2 := f008[7:11], f008[11:15]
+		ok := false
+		for _, year := range years {
+			ok = ok || datePattern(date1, year) || datePattern(date2, year)
+		}
+		check(CheckDates, true, ok)
+	}
+
+	if codes := languageCodes(rec); f008 != "" && len(codes) > 0 {
+		lang := f008[35:38]
+		check(CheckLanguage, strings.TrimSpace(strings.Trim(lang, "|")) != "", lang == codes[0])
+	}
+
+	if fields := rec.Fields("245"); len(fields) > 0 {
+		ind1 := fields[0].Ind1
+		hasMain := rec.HasTag([]string{"100", "110", "111", "130"})
+		check(CheckMainEntry, ind1 == "0" || ind1 == "1", (ind1 == "1") == hasMain)
+	}
+
+	isbns, valid := 0, 0
+	for _, f := range rec.Fields("020") {
+		for _, sf := range f.Subfields {
+			if sf.Code == "a" && strings.TrimSpace(sf.Value) != "" {
+				isbns++
+				if marc.ValidISBN(sf.Value) {
+					valid++
+				}
+			}
+		}
+	}
+	check(CheckISBN, isbns > 0, valid == isbns)
+
+	if applied == 0 {
+		return 1, nil
+	}
+	return float64(passed) / float64(applied), failed
+}
+
+// imprintYears returns the four-digit years in the 264 (or, failing that, 260) $c
+func imprintYears(rec *marc.Record) []string {
+	var years []string
+	for _, tag := range []string{"264", "260"} {
+		for _, f := range rec.Fields(tag) {
+			for _, sf := range f.Subfields {
+				if sf.Code == "c" {
+					years = append(years, imprintYear.FindAllString(sf.Value, -1)...)
+				}
+			}
+		}
+		if len(years) > 0 {
+			break
+		}
+	}
+	return years
+}
+
+// datePattern reports whether an 008 date matches a year, u standing for an unknown digit
+func datePattern(date, year string) bool {
+	if len(date) != 4 || strings.Trim(date, "u") == "" {
+		return false
+	}
+	for i := range 4 {
+		if date[i] != 'u' && date[i] != year[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// languageCodes returns the 041 $a codes in order, splitting old-style concatenated codes
+func languageCodes(rec *marc.Record) []string {
+	var codes []string
+	for _, f := range rec.Fields("041") {
+		for _, sf := range f.Subfields {
+			if sf.Code != "a" {
+				continue
+			}
+			value := strings.ToLower(strings.TrimSpace(sf.Value))
+			for len(value) >= 3 {
+				codes = append(codes, value[:3])
+				value = value[3:]
+			}
+		}
+	}
+	return codes
+}
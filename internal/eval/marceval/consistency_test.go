@@ -0,0 +1,82 @@
+package marceval
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/lehigh-university-libraries/cataloger/internal/marc"
+)
+
+// consistentRecord has every consistency check applicable and passing
+func consistentRecord() *marc.Record {
+	return &marc.Record{
+		ControlFields: []marc.ControlField{{Tag: "008", Value: "240101s1952    nyu           000 1 eng d"}},
+		DataFields: []marc.DataField{
+			{Tag: "020", Subfields: []marc.Subfield{{Code: "a", Value: "9780684801223"}}},
+			{Tag: "041", Ind1: "0", Ind2: " ", Subfields: []marc.Subfield{{Code: "a", Value: "eng"}}},
+			{Tag: "100", Ind1: "1", Ind2: " ", Subfields: []marc.Subfield{{Code: "a", Value: "Hemingway, Ernest"}}},
+			{Tag: "245", Ind1: "1", Ind2: "4", Subfields: []marc.Subfield{{Code: "a", Value: "The old man and the sea"}}},
+			{Tag: "264", Ind1: " ", Ind2: "1", Subfields: []marc.Subfield{{Code: "c", Value: "[1952]"}}},
+		},
+	}
+}
+
+func TestCheckConsistency(t *testing.T) {
+	score, failed := CheckConsistency(consistentRecord())
+	if score != 1 || len(failed) != 0 {
+		t.Errorf("consistent record = %v %v, want 1 and no failures", score, failed)
+	}
+
+	rec := consistentRecord()
+	rec.ControlFields[0].Value = "240101s1953    nyu           000 1 fre d"
+	rec.DataFields[0].Subfields[0].Value = "9780684801224"
+	score, failed = CheckConsistency(rec)
+	if score != 0.25 {
+		t.Errorf("score = %v, want 0.25", score)
+	}
+	if want := []string{CheckDates, CheckLanguage, CheckISBN}; !slices.Equal(failed, want) {
+		t.Errorf("failed = %v, want %v", failed, want)
+	}
+
+	rec = consistentRecord()
+	rec.DataFields = slices.Delete(rec.DataFields, 2, 3) // No 1XX, 245 still says there is one
+	if _, failed = CheckConsistency(rec); !slices.Equal(failed, []string{CheckMainEntry}) {
+		t.Errorf("failed = %v, want %s", failed, CheckMainEntry)
+	}
+}
+
+func TestCheckConsistencyNotApplicable(t *testing.T) {
+	rec := &marc.Record{DataFields: []marc.DataField{
+		{Tag: "264", Ind2: "1", Subfields: []marc.Subfield{{Code: "c", Value: "1952"}}},
+	}}
+	if score, failed := CheckConsistency(rec); score != 1 || failed != nil {
+		t.Errorf("CheckConsistency() = %v %v, want 1 with no checks applicable", score, failed)
+	}
+}
+
+func TestDatePattern(t *testing.T) {
+	tests := []struct {
+		date, year string
+		want       bool
+	}{
+		{"1952", "1952", true},
+		{"195u", "1952", true},
+		{"19uu", "1875", false},
+		{"uuuu", "1952", false},
+		{"    ", "1952", false},
+	}
+	for _, tt := range tests {
+		if got := datePattern(tt.date, tt.year); got != tt.want {
+			t.Errorf("datePattern(%q, %q) = %v, want %v", tt.date, tt.year, got, tt.want)
+		}
+	}
+}
+
+func TestLanguageCodes(t *testing.T) {
+	rec := &marc.Record{DataFields: []marc.DataField{
+		{Tag: "041", Subfields: []marc.Subfield{{Code: "a", Value: "engfre"}, {Code: "h", Value: "ger"}, {Code: "a", Value: "spa"}}},
+	}}
+	if got := languageCodes(rec); !slices.Equal(got, []string{"eng", "fre", "spa"}) {
+		t.Errorf("languageCodes() = %v", got)
+	}
+}
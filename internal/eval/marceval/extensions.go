@@ -0,0 +1,181 @@
+package marceval
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/lehigh-university-libraries/cataloger/internal/marc"
+)
+
+// Extension customizes how some tags are compared, e.g. a local call number scheme in 090.
+// Its tags are scored with its weights in every comparison, added to or replacing the
+// builtin weights, so they count towards Score and the report's field means.
+type Extension struct {
+	Name    string
+	Weights map[string]float64 // Tags the extension handles
+
+	// Normalize rewrites a field's text (subfields joined by spaces, $0-$9 skipped) before
+	// builtin similarity matching. Nil keeps the builtin normalization.
+	Normalize func(tag, text string) (string, error)
+
+	// Score compares the reference and generated occurrences of a tag, as field text,
+	// returning 0 to 1. Nil keeps builtin similarity matching.
+	Score func(tag string, expected, actual []string) (float64, error)
+}
+
+// ErrSkip is returned by an Extension's Normalize or Score to use builtin comparison for a tag
+var ErrSkip = errors.New("tag not handled by extension")
+
+var (
+	extensionsMu sync.RWMutex
+	extensions   = make(map[string]*Extension) // Tag -> extension handling it
+)
+
+// Register adds an extension to every subsequent comparison. A tag may be handled by only
+// one extension.
+func Register(ext Extension) error {
+	if ext.Name == "" {
+		return fmt.Errorf("extension has no name")
+	}
+	if len(ext.Weights) == 0 {
+		return fmt.Errorf("extension %s handles no tags", ext.Name)
+	}
+
+	extensionsMu.Lock()
+	defer extensionsMu.Unlock()
+	for tag := range ext.Weights {
+		if other, ok := extensions[tag]; ok {
+			return fmt.Errorf("tag %s is already handled by extension %s", tag, other.Name)
+		}
+	}
+	for tag := range ext.Weights {
+		extensions[tag] = &ext
+	}
+	return nil
+}
+
+// Unregister removes an extension by name
+func Unregister(name string) {
+	extensionsMu.Lock()
+	defer extensionsMu.Unlock()
+	maps.DeleteFunc(extensions, func(_ string, ext *Extension) bool { return ext.Name == name })
+}
+
+// Extensions lists the registered extensions by name
+func Extensions() []Extension {
+	extensionsMu.RLock()
+	defer extensionsMu.RUnlock()
+	byName := make(map[string]Extension)
+	for _, ext := range extensions {
+		byName[ext.Name] = *ext
+	}
+	names := slices.Sorted(maps.Keys(byName))
+	list := make([]Extension, 0, len(names))
+	for _, name := range names {
+		list = append(list, byName[name])
+	}
+	return list
+}
+
+func extensionFor(tag string) *Extension {
+	extensionsMu.RLock()
+	defer extensionsMu.RUnlock()
+	return extensions[tag]
+}
+
+// withExtensionWeights adds the registered extensions' tags to weights
+func withExtensionWeights(weights map[string]float64) map[string]float64 {
+	extensionsMu.RLock()
+	defer extensionsMu.RUnlock()
+	if len(extensions) == 0 {
+		return weights
+	}
+	merged := maps.Clone(weights)
+	for tag, ext := range extensions {
+		merged[tag] = ext.Weights[tag]
+	}
+	return merged
+}
+
+// scoringWeight is the weight a tag is scored with by Compare
+func scoringWeight(tag string) float64 {
+	if ext := extensionFor(tag); ext != nil {
+		return ext.Weights[tag]
+	}
+	return DefaultWeights[tag]
+}
+
+// normalizedText is the text of a field as compared by similarity: the extension's
+// normalization of the tag if it has one, else the builtin normalization
+func normalizedText(tag string, df marc.DataField) string {
+	ext := extensionFor(tag)
+	if ext == nil || ext.Normalize == nil {
+		return fieldText(df)
+	}
+	text, err := ext.Normalize(tag, rawFieldText(df))
+	if errors.Is(err, ErrSkip) {
+		return fieldText(df)
+	}
+	if err != nil {
+		slog.Warn("Extension normalizer failed, using builtin normalization", "extension", ext.Name, "tag", tag, "error", err)
+		return fieldText(df)
+	}
+	return text
+}
+
+// extensionScore scores a tag with its extension's scorer. ok is false when the tag has no
+// scorer or it failed, and builtin matching should be used.
+func extensionScore(tag string, reference, generated *marc.Record) (score float64, name string, ok bool) {
+	ext := extensionFor(tag)
+	if ext == nil || ext.Score == nil {
+		return 0, "", false
+	}
+	score, err := ext.Score(tag, rawFieldTexts(reference, tag), rawFieldTexts(generated, tag))
+	if errors.Is(err, ErrSkip) {
+		return 0, "", false
+	}
+	if err != nil {
+		slog.Warn("Extension scorer failed, using builtin matching", "extension", ext.Name, "tag", tag, "error", err)
+		return 0, "", false
+	}
+	return min(max(score, 0), 1), ext.Name, true
+}
+
+// rawFieldText joins a field's content subfields, skipping $0-$9 like fieldText, without
+// normalizing
+func rawFieldText(df marc.DataField) string {
+	var parts []string
+	for _, sf := range df.Subfields {
+		if sf.Code >= "0" && sf.Code <= "9" {
+			continue
+		}
+		parts = append(parts, sf.Value)
+	}
+	return strings.Join(parts, " ")
+}
+
+// rawFieldTexts is the raw text of each occurrence of a scored tag, including its aliases
+func rawFieldTexts(rec *marc.Record, tag string) []string {
+	var texts []string
+	if rec == nil {
+		return texts
+	}
+	for _, df := range rec.DataFields {
+		t := df.Tag
+		if alias, ok := tagAliases[t]; ok {
+			t = alias
+		}
+		if t != tag {
+			continue
+		}
+		if text := rawFieldText(df); text != "" {
+			texts = append(texts, text)
+		}
+	}
+	return texts
+}
@@ -0,0 +1,93 @@
+package marceval
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/lehigh-university-libraries/cataloger/internal/marc"
+)
+
+func TestExtensions(t *testing.T) {
+	reference, err := marc.ParseMnemonic("=090  \\\\$aQA76.73.G63$bS55 2020\n=245  10$aGo programming.")
+	if err != nil {
+		t.Fatal(err)
+	}
+	generated, err := marc.ParseMnemonic("=090  \\\\$aQA76.73 .G63$bS55 2020\n=245  10$aGO PROGRAMMING")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Without an extension 090 is not scored
+	if _, ok := Compare(reference, generated).Fields["090"]; ok {
+		t.Fatal("090 scored without an extension")
+	}
+
+	var scored []string
+	err = Register(Extension{
+		Name:    "callnumbers",
+		Weights: map[string]float64{"090": 2, "245": 1},
+		Score: func(tag string, expected, actual []string) (float64, error) {
+			if tag == "245" {
+				return 0, ErrSkip
+			}
+			scored = append(scored, expected[0], actual[0])
+			strip := strings.NewReplacer(" ", "", ".", "")
+			if strip.Replace(expected[0]) == strip.Replace(actual[0]) {
+				return 1, nil
+			}
+			return 0, nil
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer Unregister("callnumbers")
+
+	if err := Register(Extension{Name: "other", Weights: map[string]float64{"090": 1}}); err == nil {
+		t.Error("Register() of a tag already handled succeeded")
+	}
+
+	c := Compare(reference, generated)
+	fs, ok := c.Fields["090"]
+	if !ok {
+		t.Fatal("090 not scored with the extension registered")
+	}
+	if fs.Score != 1 || fs.Scorer != "callnumbers" || fs.Weight != 2 {
+		t.Errorf("090 = score %v, scorer %q, weight %v; want 1, callnumbers, 2", fs.Score, fs.Scorer, fs.Weight)
+	}
+	if len(scored) != 2 || scored[0] != "QA76.73.G63 S55 2020" {
+		t.Errorf("scorer received %q, want raw field text", scored)
+	}
+	if fs := c.Fields["245"]; fs.Scorer != "" || fs.Weight != 1 || fs.Score != 1 {
+		t.Errorf("245 = score %v, scorer %q, weight %v; want builtin match with weight 1", fs.Score, fs.Scorer, fs.Weight)
+	}
+
+	report := NewReport([]Result{{ID: "1", Comparison: c}})
+	if report.FieldScorers["090"] != "callnumbers" {
+		t.Errorf("FieldScorers = %v", report.FieldScorers)
+	}
+}
+
+func TestExtensionFailureFallsBack(t *testing.T) {
+	reference, _ := marc.ParseMnemonic("=245  10$aGo programming.")
+	generated, _ := marc.ParseMnemonic("=245  10$aGo programming")
+
+	if err := Register(Extension{
+		Name:      "broken",
+		Weights:   map[string]float64{"245": 3},
+		Normalize: func(tag, text string) (string, error) { return "", errors.New("boom") },
+		Score:     func(tag string, expected, actual []string) (float64, error) { return 0, errors.New("boom") },
+	}); err != nil {
+		t.Fatal(err)
+	}
+	defer Unregister("broken")
+
+	fs := Compare(reference, generated).Fields["245"]
+	if fs.Score != 1 || fs.Scorer != "" {
+		t.Errorf("245 = score %v, scorer %q; want builtin exact match", fs.Score, fs.Scorer)
+	}
+	if len(Extensions()) != 1 {
+		t.Errorf("Extensions() = %v", Extensions())
+	}
+}
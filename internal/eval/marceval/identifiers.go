@@ -0,0 +1,73 @@
+package marceval
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/lehigh-university-libraries/cataloger/internal/identifiers"
+	"github.com/lehigh-university-libraries/cataloger/internal/marc"
+)
+
+// IdentifierScore checks one standard identifier exactly: an LCCN (010) or OCLC number
+// (035). Identifiers are either right or wrong, so they are reported apart from the
+// weighted similarity score.
+type IdentifierScore struct {
+	Tag      string
+	Expected []string // Normalized reference values, including canceled/invalid ($z) numbers
+	Actual   []string // Normalized generated values, preferred first
+	Correct  bool     // The generated preferred value is one of the reference values
+}
+
+// compareIdentifiers checks the identifiers present in the reference record
+func compareIdentifiers(reference, generated *marc.Record) map[string]IdentifierScore {
+	scores := make(map[string]IdentifierScore)
+	if reference == nil {
+		return scores
+	}
+
+	for tag, extract := range map[string]func(*marc.Record) []string{"010": lccns, "035": oclcNumbers} {
+		expected := extract(reference)
+		if len(expected) == 0 {
+			continue
+		}
+		var actual []string
+		if generated != nil {
+			actual = extract(generated)
+		}
+		scores[tag] = IdentifierScore{
+			Tag:      tag,
+			Expected: expected,
+			Actual:   actual,
+			Correct:  len(actual) > 0 && slices.Contains(expected, actual[0]),
+		}
+	}
+	return scores
+}
+
+func lccns(rec *marc.Record) []string {
+	var values []string
+	for _, f := range rec.Fields("010") {
+		for _, sf := range f.Subfields {
+			if sf.Code == "a" || sf.Code == "z" {
+				if v := identifiers.NormalizeLCCN(sf.Value); v != "" {
+					values = append(values, v)
+				}
+			}
+		}
+	}
+	return values
+}
+
+func oclcNumbers(rec *marc.Record) []string {
+	var values []string
+	for _, f := range rec.Fields("035") {
+		for _, sf := range f.Subfields {
+			if (sf.Code == "a" || sf.Code == "z") && strings.HasPrefix(sf.Value, "(OCoLC)") {
+				if v := identifiers.NormalizeOCLC(sf.Value); v != "" {
+					values = append(values, v)
+				}
+			}
+		}
+	}
+	return values
+}
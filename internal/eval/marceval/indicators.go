@@ -0,0 +1,119 @@
+package marceval
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/lehigh-university-libraries/cataloger/internal/marc"
+)
+
+// IndicatorCheck names an indicator that carries meaning an ILS acts on
+type IndicatorCheck struct {
+	Name     string
+	Tags     []string // Tags checked together; a field under the wrong tag is incorrect
+	Position int      // 1 or 2
+}
+
+// IndicatorChecks are the indicators most often wrong in generated records
+var IndicatorChecks = []IndicatorCheck{
+	{Name: "245 ind2", Tags: []string{"245"}, Position: 2},                      // Non-filing characters
+	{Name: "650 ind2", Tags: []string{"650"}, Position: 2},                      // Subject thesaurus
+	{Name: "1XX ind1", Tags: []string{"100", "110", "111", "130"}, Position: 1}, // Type of name
+}
+
+// IndicatorScore is the indicator accuracy of one IndicatorCheck in a record.
+// Fields are paired by content, and only fields present in both records are checked,
+// since a missing field is already counted by the similarity score.
+type IndicatorScore struct {
+	Name     string
+	Expected []string // "tag ind" for each checked reference field, e.g. "245 4"
+	Actual   []string // "tag ind" of the paired generated field
+	Checked  int
+	Correct  int
+}
+
+// compareIndicators applies IndicatorChecks to the fields present in both records
+func compareIndicators(reference, generated *marc.Record) map[string]IndicatorScore {
+	scores := make(map[string]IndicatorScore)
+	if reference == nil || generated == nil {
+		return scores
+	}
+
+	for _, check := range IndicatorChecks {
+		expected := fieldsWithTags(reference, check.Tags)
+		actual := fieldsWithTags(generated, check.Tags)
+		if len(expected) == 0 || len(actual) == 0 {
+			continue
+		}
+
+		s := IndicatorScore{Name: check.Name}
+		used := make([]bool, len(actual))
+		for _, exp := range expected {
+			expText := fieldText(exp)
+			best, bestIdx := -1.0, -1
+			for i, act := range actual {
+				if used[i] {
+					continue
+				}
+				if sim := similarity(expText, fieldText(act)); sim > best {
+					best, bestIdx = sim, i
+				}
+			}
+			if bestIdx < 0 {
+				break
+			}
+			used[bestIdx] = true
+			act := actual[bestIdx]
+
+			expInd, actInd := indicator(exp, check.Position), indicator(act, check.Position)
+			s.Expected = append(s.Expected, exp.Tag+" "+expInd)
+			s.Actual = append(s.Actual, act.Tag+" "+actInd)
+			s.Checked++
+			if act.Tag == exp.Tag && actInd == expInd {
+				s.Correct++
+			}
+		}
+		scores[check.Name] = s
+	}
+	return scores
+}
+
+// IndicatorAccuracy is the share of checked indicators that are correct, and whether any were checked
+func (c *Comparison) IndicatorAccuracy() (float64, bool) {
+	checked, correct := 0, 0
+	for _, s := range c.Indicators {
+		checked += s.Checked
+		correct += s.Correct
+	}
+	if checked == 0 {
+		return 0, false
+	}
+	return float64(correct) / float64(checked), true
+}
+
+func fieldsWithTags(rec *marc.Record, tags []string) []marc.DataField {
+	var fields []marc.DataField
+	for _, df := range rec.DataFields {
+		if slices.Contains(tags, df.Tag) {
+			fields = append(fields, df)
+		}
+	}
+	return fields
+}
+
+// fieldText is the normalized content of a field, as scored by parseMARCFields
+func fieldText(df marc.DataField) string {
+	return normalize(rawFieldText(df))
+}
+
+// indicator returns the indicator at position, with blanks written as "\"
+func indicator(df marc.DataField, position int) string {
+	ind := df.Ind1
+	if position == 2 {
+		ind = df.Ind2
+	}
+	if strings.TrimSpace(ind) == "" {
+		return `\`
+	}
+	return ind
+}
@@ -0,0 +1,442 @@
+package marceval
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/lehigh-university-libraries/cataloger/internal/eval/failure"
+	"github.com/lehigh-university-libraries/cataloger/internal/eval/schema"
+	"github.com/lehigh-university-libraries/cataloger/internal/hooks"
+	"github.com/lehigh-university-libraries/cataloger/internal/marc"
+	"github.com/lehigh-university-libraries/cataloger/internal/objectstore"
+	"github.com/lehigh-university-libraries/cataloger/internal/usage"
+)
+
+// Result is the evaluation of one dataset item
+type Result struct {
+	ID            string
+	Title         string
+	Provider      string
+	Model         string
+	PromptVersion string
+	MaterialType  string      `json:",omitempty"` // marc.MaterialType of the reference record
+	ImageSource   string      `json:",omitempty"` // Source of the title page, in image source comparisons
+	OCRText       string      `json:",omitempty"`
+	GeneratedMARC string      `json:",omitempty"` // Mnemonic form for easy diffing
+	RawResponse   string      `json:",omitempty"` // The model's metadata response, kept only in blobs
+	Blob          string      `json:",omitempty"` // Gzipped file holding the text above, when stored apart
+	Comparison    *Comparison // Nil when there is no reference to compare with
+
+	// Tags written by the copyright page pass, scored on their own
+	CopyrightTags       []string    `json:",omitempty"`
+	CopyrightComparison *Comparison `json:",omitempty"`
+
+	Warnings        []failure.Warning `json:",omitempty"` // Non-fatal issues with the record's data or generation
+	Issues          []marc.Issue      `json:",omitempty"`
+	Completeness    float64           // Share of the completeness profile's required elements present
+	PresentElements []string          `json:",omitempty"`
+	MissingElements []string          `json:",omitempty"` // Required elements only
+	Consistency     float64           // Share of the applicable cross-field consistency checks passed
+	Inconsistencies []string          `json:",omitempty"` // Consistency checks failed
+
+	Error          string       `json:",omitempty"`
+	ErrorCode      failure.Code `json:",omitempty"` // Category of Error
+	Anomaly        string       `json:",omitempty"` // anomaly kind of a degenerate output
+	Refusal        string       `json:",omitempty"` // Category of the provider's refusal, whether or not the retry recovered
+	ProcessingTime time.Duration
+
+	// Ground-truth audit findings for the reference record, when it is suspect
+	SuspectReference []string `json:",omitempty"`
+	// Weight of the record in score aggregates when its reference is suspect; 0 leaves it out.
+	// Nil counts it fully.
+	ReferenceWeight *float64 `json:",omitempty"`
+}
+
+// ScoreWeight returns the weight of the result's scores in aggregates
+func (r Result) ScoreWeight() float64 {
+	if r.ReferenceWeight == nil {
+		return 1
+	}
+	return *r.ReferenceWeight
+}
+
+// FailureCode returns the category of a failed result; results saved before failures were
+// categorized are failure.Unknown
+func (r Result) FailureCode() failure.Code {
+	if r.Error == "" {
+		return ""
+	}
+	if r.ErrorCode == "" {
+		return failure.Unknown
+	}
+	return r.ErrorCode
+}
+
+// ReportSchema is the format of run results. Version 1 added schema_version; failures saved
+// before failures were categorized get the unknown error code.
+var ReportSchema = schema.Schema{
+	Name:       "results",
+	RecordsKey: "Results",
+	Steps: []schema.Step{{
+		Record: func(rec map[string]any) error {
+			if msg, _ := rec["Error"].(string); msg != "" && rec["ErrorCode"] == nil {
+				rec["ErrorCode"] = string(failure.Unknown)
+			}
+			return nil
+		},
+	}},
+}
+
+// Report aggregates the results of a MARC evaluation run
+type Report struct {
+	SchemaVersion  int `json:"schema_version"`
+	Dataset        string
+	Provider       string
+	Model          string
+	PromptVersion  string
+	PostHooks      []hooks.Info `json:",omitempty"` // Run on each generated record before scoring
+	EvaluationDate time.Time
+	Usage          *usage.Usage `json:",omitempty"` // Resources the run used
+
+	Records      int
+	Succeeded    int
+	Failed       int
+	FailureCodes map[failure.Code]int `json:",omitempty"` // Failure code -> failed records
+	Anomalies    map[string]int       `json:",omitempty"` // Anomaly kind -> degenerate outputs
+
+	RecordsWithWarnings int
+	WarningCounts       map[string]int `json:",omitempty"` // Warning code -> records with the warning, failed or not
+
+	Scored     int                // Successful records compared with a reference
+	MeanScore  float64            // Mean of scored records' scores, weighted by ScoreWeight
+	FieldMeans map[string]float64 // Mean score per tag over records whose reference has the tag
+	// Tag -> Extension that scored it, for tags scored by a custom scorer
+	FieldScorers map[string]string `json:",omitempty"`
+
+	// Share of records with a correct 010/035, over records whose reference has the identifier
+	IdentifierAccuracy map[string]float64 `json:",omitempty"`
+	// Share of correct indicators per IndicatorCheck, over fields present in both records
+	IndicatorAccuracy map[string]float64 `json:",omitempty"`
+	// Records in which any checked indicator is wrong
+	RecordsWithIndicatorErrors int
+
+	// Records whose reference the ground-truth audit flagged, by finding code, and how many
+	// of them were left out of the scores (weight 0)
+	SuspectReferences int
+	SuspectFindings   map[string]int `json:",omitempty"`
+	ExcludedScored    int
+
+	// Material type -> aggregates, for records whose reference leader gives a material type
+	Materials map[string]*MaterialStats `json:",omitempty"`
+
+	// Refusals by "provider/model", for every model that generated records
+	Refusals map[string]*RefusalStats `json:",omitempty"`
+
+	CopyrightScored     int                // Records the copyright page pass added fields to
+	CopyrightMeanScore  float64            // Mean score over the fields the pass wrote
+	CopyrightFieldMeans map[string]float64 `json:",omitempty"`
+
+	Penalties       Penalties      // Applied to each comparison's Score
+	MeanOrderScore  float64        // Mean share of adjacent generated fields in tag order
+	DuplicateFields map[string]int `json:",omitempty"` // Non-repeatable tag -> records repeating it
+
+	CompletenessProfile string `json:",omitempty"`
+	MeanCompleteness    float64
+	ElementPresence     map[string]float64 `json:",omitempty"` // Element -> share of successful records carrying it
+	RequiredMissing     map[string]int     `json:",omitempty"` // Required element -> records missing it
+	RecordsWithErrors   int                // Successful records with at least one validation error
+	IssueCounts         map[string]int     `json:",omitempty"` // Validation issue code -> occurrences
+	MeanConsistency     float64
+	Inconsistencies     map[string]int `json:",omitempty"` // Consistency check -> records failing it
+
+	AverageProcessingTime time.Duration
+	TotalProcessingTime   time.Duration
+
+	Results []Result
+}
+
+// MaterialStats aggregates the records of one material type
+type MaterialStats struct {
+	Records       int
+	Failed        int
+	Scored        int
+	MeanScore     float64
+	PromptVersion string `json:",omitempty"` // Prompt the material type's records were generated with
+
+	weight float64 // Sum of the scored records' weights, while aggregating
+}
+
+// RefusalStats counts the refusals and safety blocks of one model
+type RefusalStats struct {
+	Records    int
+	Refused    int            // Records refused at least once
+	Recovered  int            // Refused records the retry with a sanitized prompt recovered
+	Categories map[string]int `json:",omitempty"` // Refusal category -> refused records
+}
+
+// NewReport aggregates results into a report
+func NewReport(results []Result) *Report {
+	a := NewAggregator()
+	for _, res := range results {
+		a.Add(res)
+	}
+	r := a.Report()
+	r.Results = results
+	return r
+}
+
+// PrintSummary prints a human-readable summary of the run
+func (r *Report) PrintSummary() {
+	fmt.Println("\n" + strings.Repeat("=", 70))
+	fmt.Println("CATALOGER MARC EVALUATION SUMMARY")
+	fmt.Println(strings.Repeat("=", 70))
+	fmt.Printf("Evaluation Date: %s\n", r.EvaluationDate.Format("2006-01-02 15:04:05"))
+	fmt.Printf("Dataset: %s\n", r.Dataset)
+	fmt.Printf("Provider: %s\n", r.Provider)
+	fmt.Printf("Model: %s\n", r.Model)
+	if r.PromptVersion != "" {
+		fmt.Printf("Prompt: %s\n", r.PromptVersion)
+	}
+	for _, h := range r.PostHooks {
+		fmt.Printf("Post-processing hook: %s\n", h.Command)
+	}
+	fmt.Println()
+
+	fmt.Println("PROCESSING STATISTICS")
+	fmt.Println(strings.Repeat("-", 70))
+	fmt.Printf("Total Records: %d\n", r.Records)
+	if r.Records > 0 {
+		fmt.Printf("Successful: %d (%.1f%%)\n", r.Succeeded, float64(r.Succeeded)/float64(r.Records)*100)
+		fmt.Printf("Failed: %d (%.1f%%)\n", r.Failed, float64(r.Failed)/float64(r.Records)*100)
+	}
+	for _, code := range failure.Codes {
+		if n := r.FailureCodes[code]; n > 0 {
+			fmt.Printf("  %s: %d\n", code, n)
+		}
+		if code == failure.Degenerate {
+			for _, kind := range sortedCodes(r.Anomalies) {
+				fmt.Printf("    %s: %d\n", kind, r.Anomalies[kind])
+			}
+		}
+	}
+	fmt.Printf("Average Processing Time: %s\n", r.AverageProcessingTime)
+	fmt.Printf("Total Processing Time: %s\n", r.TotalProcessingTime)
+	fmt.Println()
+
+	if r.Usage != nil {
+		r.Usage.Print(os.Stdout)
+	}
+
+	if r.RecordsWithWarnings > 0 {
+		fmt.Println("WARNINGS")
+		fmt.Println(strings.Repeat("-", 70))
+		fmt.Printf("Records With Warnings: %d\n", r.RecordsWithWarnings)
+		codes := sortedCodes(r.WarningCounts)
+		sort.SliceStable(codes, func(i, j int) bool { return r.WarningCounts[codes[i]] > r.WarningCounts[codes[j]] })
+		for _, code := range codes {
+			fmt.Printf("  %s: %d\n", code, r.WarningCounts[code])
+		}
+		fmt.Println()
+	}
+
+	if r.refused() {
+		fmt.Println("REFUSALS")
+		fmt.Println(strings.Repeat("-", 70))
+		models := make([]string, 0, len(r.Refusals))
+		for model := range r.Refusals {
+			models = append(models, model)
+		}
+		sort.Strings(models)
+		for _, model := range models {
+			s := r.Refusals[model]
+			fmt.Printf("%s: %d of %d records (%.1f%%), %d recovered by retry\n", model, s.Refused, s.Records, float64(s.Refused)/float64(s.Records)*100, s.Recovered)
+			for _, category := range sortedCodes(s.Categories) {
+				fmt.Printf("  %s: %d\n", category, s.Categories[category])
+			}
+		}
+		fmt.Println()
+	}
+
+	if r.SuspectReferences > 0 {
+		fmt.Println("SUSPECT REFERENCES")
+		fmt.Println(strings.Repeat("-", 70))
+		fmt.Printf("Records With a Suspect Reference: %d\n", r.SuspectReferences)
+		for _, code := range sortedCodes(r.SuspectFindings) {
+			fmt.Printf("  %s: %d\n", code, r.SuspectFindings[code])
+		}
+		if r.ExcludedScored > 0 {
+			fmt.Printf("Left Out of Scores: %d\n", r.ExcludedScored)
+		}
+		fmt.Println()
+	}
+
+	if len(r.FieldMeans) > 0 {
+		fmt.Println("FIELD-LEVEL SCORES")
+		fmt.Println(strings.Repeat("-", 70))
+		for _, tag := range sortedTags(r.FieldMeans) {
+			fmt.Printf("%s: %.2f%% (weight %.1f", tag, r.FieldMeans[tag]*100, scoringWeight(tag))
+			if scorer := r.FieldScorers[tag]; scorer != "" {
+				fmt.Printf(", scored by %s", scorer)
+			}
+			fmt.Println(")")
+		}
+		fmt.Println()
+	}
+
+	fmt.Println("RECORD QUALITY")
+	fmt.Println(strings.Repeat("-", 70))
+	if r.CompletenessProfile != "" {
+		fmt.Printf("Completeness Profile: %s\n", r.CompletenessProfile)
+	}
+	fmt.Printf("Mean Completeness: %.2f%%\n", r.MeanCompleteness*100)
+	if len(r.ElementPresence)+len(r.RequiredMissing) > 0 {
+		fmt.Println("Elements:")
+		elements := make(map[string]float64, len(r.ElementPresence))
+		for name, share := range r.ElementPresence {
+			elements[name] = share
+		}
+		for name := range r.RequiredMissing {
+			if _, ok := elements[name]; !ok {
+				elements[name] = 0
+			}
+		}
+		for _, name := range sortedTags(elements) {
+			fmt.Printf("  %s: %.1f%% present", name, elements[name]*100)
+			if n := r.RequiredMissing[name]; n > 0 {
+				fmt.Printf(" (required, missing in %d)", n)
+			}
+			fmt.Println()
+		}
+	}
+	fmt.Printf("Records With Validation Errors: %d\n", r.RecordsWithErrors)
+	for _, code := range sortedCodes(r.IssueCounts) {
+		fmt.Printf("  %s: %d\n", code, r.IssueCounts[code])
+	}
+	fmt.Printf("Mean Consistency: %.2f%%\n", r.MeanConsistency*100)
+	for _, name := range ConsistencyChecks {
+		if n := r.Inconsistencies[name]; n > 0 {
+			fmt.Printf("  %s: %d records inconsistent\n", name, n)
+		}
+	}
+	fmt.Println()
+
+	if len(r.IdentifierAccuracy) > 0 {
+		fmt.Println("IDENTIFIER ACCURACY")
+		fmt.Println(strings.Repeat("-", 70))
+		for _, tag := range sortedTags(r.IdentifierAccuracy) {
+			fmt.Printf("%s: %.2f%% correct\n", tag, r.IdentifierAccuracy[tag]*100)
+		}
+		fmt.Println()
+	}
+
+	if len(r.IndicatorAccuracy) > 0 {
+		fmt.Println("INDICATOR ACCURACY")
+		fmt.Println(strings.Repeat("-", 70))
+		for _, name := range sortedTags(r.IndicatorAccuracy) {
+			fmt.Printf("%s: %.2f%% correct\n", name, r.IndicatorAccuracy[name]*100)
+		}
+		fmt.Printf("Records With Indicator Errors: %d\n", r.RecordsWithIndicatorErrors)
+		fmt.Println()
+	}
+
+	if len(r.Materials) > 1 || (len(r.Materials) == 1 && r.Materials[marc.MaterialBook] == nil) {
+		fmt.Println("SCORES BY MATERIAL TYPE")
+		fmt.Println(strings.Repeat("-", 70))
+		for _, material := range marc.MaterialTypes {
+			m := r.Materials[material]
+			if m == nil {
+				continue
+			}
+			fmt.Printf("%s: %d records, %d failed", material, m.Records, m.Failed)
+			if m.Scored > 0 {
+				fmt.Printf(", mean score %.2f%%", m.MeanScore*100)
+			}
+			if m.PromptVersion != "" {
+				fmt.Printf(" (%s)", m.PromptVersion)
+			}
+			fmt.Println()
+		}
+		fmt.Println()
+	}
+
+	if r.CopyrightScored > 0 {
+		fmt.Println("COPYRIGHT PAGE PASS")
+		fmt.Println(strings.Repeat("-", 70))
+		fmt.Printf("Records With Copyright Page Fields: %d\n", r.CopyrightScored)
+		for _, tag := range sortedTags(r.CopyrightFieldMeans) {
+			fmt.Printf("%s: %.2f%%\n", tag, r.CopyrightFieldMeans[tag]*100)
+		}
+		fmt.Printf("Mean Copyright Page Score: %.2f%%\n", r.CopyrightMeanScore*100)
+		fmt.Println()
+	}
+
+	if r.Scored > 0 {
+		fmt.Println("FIELD STRUCTURE")
+		fmt.Println(strings.Repeat("-", 70))
+		fmt.Printf("Mean Field Order: %.2f%% of adjacent fields in tag order\n", r.MeanOrderScore*100)
+		for _, tag := range sortedCodes(r.DuplicateFields) {
+			fmt.Printf("Duplicated %s: %d records\n", tag, r.DuplicateFields[tag])
+		}
+		if r.Penalties != (Penalties{}) {
+			fmt.Printf("Penalties: %.2f per duplicate field, order weight %.2f\n", r.Penalties.Duplicate, r.Penalties.Order)
+		}
+		fmt.Println()
+	}
+
+	fmt.Println("OVERALL SCORE")
+	fmt.Println(strings.Repeat("-", 70))
+	if r.Scored == 0 {
+		fmt.Println("No records were compared with a reference")
+	} else {
+		if r.Scored < r.Succeeded {
+			fmt.Printf("Compared With Reference: %d of %d\n", r.Scored, r.Succeeded)
+		}
+		fmt.Printf("Mean Weighted Score: %.2f%% (%.3f)\n", r.MeanScore*100, r.MeanScore)
+	}
+	fmt.Println(strings.Repeat("=", 70))
+}
+
+// SaveJSON saves the report to a JSON file or object URL (s3://, gs://)
+func (r *Report) SaveJSON(path string) error {
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode report to JSON: %w", err)
+	}
+	if err := objectstore.WriteFile(context.Background(), path, data); err != nil {
+		return fmt.Errorf("failed to write report: %w", err)
+	}
+	return nil
+}
+
+// refused reports whether any model refused a record
+func (r *Report) refused() bool {
+	for _, s := range r.Refusals {
+		if s.Refused > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+func sortedCodes(m map[string]int) []string {
+	codes := make([]string, 0, len(m))
+	for code := range m {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+	return codes
+}
+
+func sortedTags(m map[string]float64) []string {
+	tags := make([]string, 0, len(m))
+	for tag := range m {
+		tags = append(tags, tag)
+	}
+	sort.Strings(tags)
+	return tags
+}
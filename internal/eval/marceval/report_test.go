@@ -0,0 +1,41 @@
+package marceval
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/lehigh-university-libraries/cataloger/internal/eval/failure"
+)
+
+func TestReportWarnings(t *testing.T) {
+	results := []Result{
+		{ID: "a", Comparison: &Comparison{Score: 0.8}, Warnings: []failure.Warning{
+			failure.Warn(failure.WarnCoverFallback, "no title page image"),
+			failure.Warn(failure.WarnFencedResponse, "metadata JSON in a code fence"),
+			failure.Warn(failure.WarnFencedResponse, "metadata JSON in a code fence"),
+		}},
+		{ID: "b", Comparison: &Comparison{Score: 0.6}, Warnings: []failure.Warning{failure.Warn(failure.WarnNoTitle, "no title extracted")}},
+		{ID: "c", Comparison: &Comparison{Score: 0.4}},
+		{ID: "d", Error: "OCR failed", ErrorCode: failure.ProviderTimeout, Warnings: []failure.Warning{failure.Warn(failure.WarnEmptyOCR, "OCR returned no text")}},
+	}
+
+	report := NewReport(results)
+	// Warnings don't fail a record; only d, which has an error, failed
+	if report.Records != 4 || report.Succeeded != 3 || report.Failed != 1 || report.Scored != 3 {
+		t.Errorf("records = %d, succeeded %d, failed %d, scored %d", report.Records, report.Succeeded, report.Failed, report.Scored)
+	}
+	for _, res := range report.Results[:3] {
+		if code := res.FailureCode(); code != "" {
+			t.Errorf("%s with warnings has failure code %s", res.ID, code)
+		}
+	}
+
+	// Counted once per record, failed or not
+	want := map[string]int{failure.WarnCoverFallback: 1, failure.WarnFencedResponse: 1, failure.WarnNoTitle: 1, failure.WarnEmptyOCR: 1}
+	if report.RecordsWithWarnings != 3 || !reflect.DeepEqual(report.WarningCounts, want) {
+		t.Errorf("warnings = %d records, %v, want 3, %v", report.RecordsWithWarnings, report.WarningCounts, want)
+	}
+	if w := report.Results[1].Warnings; len(w) != 1 || w[0].Code != failure.WarnNoTitle || w[0].Message != "no title extracted" {
+		t.Errorf("b's warnings = %+v", w)
+	}
+}
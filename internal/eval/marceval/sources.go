@@ -0,0 +1,213 @@
+package marceval
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/lehigh-university-libraries/cataloger/internal/objectstore"
+)
+
+// SourceComparison compares the scores of the same records generated from title page images
+// of different sources (Internet Archive, Google Books, 856 links, local scans, ...)
+type SourceComparison struct {
+	Dataset        string
+	Provider       string
+	Model          string
+	EvaluationDate time.Time
+
+	Baseline      string // Source the others are compared with
+	Sources       []string
+	Stats         map[string]*SourceStats
+	CommonRecords int // Records scored from every source
+
+	Results []Result // One per record and source with a title page
+}
+
+// SourceStats are the scores of the records generated from one source's title pages
+type SourceStats struct {
+	Records         int     // Records with a title page from the source
+	Failed          int     // OCR or generation failed
+	MeanScore       float64 // Over the source's scored records
+	CommonMeanScore float64 // Over the records scored from every source
+
+	// Compared with the baseline on records scored from both
+	Paired      int
+	MeanDelta   float64            // Mean of score - baseline score
+	Wins        int                // Records scoring higher than from the baseline
+	Losses      int                // Records scoring lower than from the baseline
+	FieldDeltas map[string]float64 `json:",omitempty"` // Tag -> mean field score difference
+}
+
+// NewSourceComparison aggregates results tagged with their ImageSource. The first source is
+// the baseline.
+func NewSourceComparison(results []Result, sources []string) *SourceComparison {
+	c := &SourceComparison{
+		EvaluationDate: time.Now(),
+		Sources:        sources,
+		Stats:          make(map[string]*SourceStats, len(sources)),
+		Results:        results,
+	}
+	if len(sources) > 0 {
+		c.Baseline = sources[0]
+	}
+	for _, source := range sources {
+		c.Stats[source] = &SourceStats{}
+	}
+
+	// Record ID -> source -> comparison, for scored results
+	scored := make(map[string]map[string]*Comparison)
+	for _, res := range results {
+		st, ok := c.Stats[res.ImageSource]
+		if !ok {
+			continue
+		}
+		st.Records++
+		if res.Error != "" {
+			st.Failed++
+			continue
+		}
+		if res.Comparison == nil {
+			continue
+		}
+		if scored[res.ID] == nil {
+			scored[res.ID] = make(map[string]*Comparison)
+		}
+		scored[res.ID][res.ImageSource] = res.Comparison
+	}
+
+	counts := make(map[string]int)
+	fieldCounts := make(map[string]map[string]int)
+	for _, bySource := range scored {
+		common := len(bySource) == len(sources)
+		if common {
+			c.CommonRecords++
+		}
+		base := bySource[c.Baseline]
+		for source, cmp := range bySource {
+			st := c.Stats[source]
+			st.MeanScore += cmp.Score
+			counts[source]++
+			if common {
+				st.CommonMeanScore += cmp.Score
+			}
+			if base == nil || source == c.Baseline {
+				continue
+			}
+
+			st.Paired++
+			delta := cmp.Score - base.Score
+			st.MeanDelta += delta
+			switch {
+			case delta > 1e-9:
+				st.Wins++
+			case delta < -1e-9:
+				st.Losses++
+			}
+			for tag, f := range cmp.Fields {
+				bf, ok := base.Fields[tag]
+				if !ok {
+					continue
+				}
+				if st.FieldDeltas == nil {
+					st.FieldDeltas = make(map[string]float64)
+					fieldCounts[source] = make(map[string]int)
+				}
+				st.FieldDeltas[tag] += f.Score - bf.Score
+				fieldCounts[source][tag]++
+			}
+		}
+	}
+
+	for source, st := range c.Stats {
+		if n := counts[source]; n > 0 {
+			st.MeanScore /= float64(n)
+		}
+		if c.CommonRecords > 0 {
+			st.CommonMeanScore /= float64(c.CommonRecords)
+		}
+		if st.Paired > 0 {
+			st.MeanDelta /= float64(st.Paired)
+		}
+		for tag, n := range fieldCounts[source] {
+			st.FieldDeltas[tag] /= float64(n)
+		}
+	}
+	return c
+}
+
+// PrintSummary prints the scores by source and their differences from the baseline
+func (c *SourceComparison) PrintSummary() {
+	fmt.Println("\n" + strings.Repeat("=", 70))
+	fmt.Println("IMAGE SOURCE COMPARISON")
+	fmt.Println(strings.Repeat("=", 70))
+	fmt.Printf("Evaluation Date: %s\n", c.EvaluationDate.Format("2006-01-02 15:04:05"))
+	fmt.Printf("Dataset: %s\n", c.Dataset)
+	fmt.Printf("Provider: %s\n", c.Provider)
+	fmt.Printf("Model: %s\n", c.Model)
+	fmt.Printf("Baseline: %s\n", c.Baseline)
+	fmt.Printf("Records Scored From Every Source: %d\n", c.CommonRecords)
+	fmt.Println()
+
+	fmt.Printf("%-18s %8s %7s %8s %8s %9s %9s\n", "SOURCE", "RECORDS", "FAILED", "MEAN", "COMMON", "DELTA", "WIN/LOSS")
+	fmt.Println(strings.Repeat("-", 70))
+	for _, source := range c.Sources {
+		st := c.Stats[source]
+		common := "-"
+		if c.CommonRecords > 0 {
+			common = fmt.Sprintf("%.2f%%", st.CommonMeanScore*100)
+		}
+		delta, winLoss := "-", "-"
+		if st.Paired > 0 {
+			delta = fmt.Sprintf("%+.2f", st.MeanDelta*100)
+			winLoss = fmt.Sprintf("%d/%d", st.Wins, st.Losses)
+		}
+		fmt.Printf("%-18s %8d %7d %7.2f%% %8s %9s %9s\n", source, st.Records, st.Failed, st.MeanScore*100, common, delta, winLoss)
+	}
+	fmt.Println("DELTA is the mean score difference from the baseline in points, over records scored from both")
+
+	for _, source := range c.Sources {
+		st := c.Stats[source]
+		if len(st.FieldDeltas) == 0 {
+			continue
+		}
+		tags := make([]string, 0, len(st.FieldDeltas))
+		for tag, d := range st.FieldDeltas {
+			if math.Abs(d) >= 0.005 {
+				tags = append(tags, tag)
+			}
+		}
+		if len(tags) == 0 {
+			continue
+		}
+		sort.Slice(tags, func(i, j int) bool {
+			if st.FieldDeltas[tags[i]] != st.FieldDeltas[tags[j]] {
+				return st.FieldDeltas[tags[i]] < st.FieldDeltas[tags[j]]
+			}
+			return tags[i] < tags[j]
+		})
+		fmt.Println()
+		fmt.Printf("FIELD DIFFERENCES: %s vs %s (%d records)\n", source, c.Baseline, st.Paired)
+		fmt.Println(strings.Repeat("-", 70))
+		for _, tag := range tags {
+			fmt.Printf("%s: %+.2f\n", tag, st.FieldDeltas[tag]*100)
+		}
+	}
+	fmt.Println(strings.Repeat("=", 70))
+}
+
+// SaveJSON saves the comparison to a JSON file or object URL (s3://, gs://)
+func (c *SourceComparison) SaveJSON(path string) error {
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode source comparison to JSON: %w", err)
+	}
+	if err := objectstore.WriteFile(context.Background(), path, data); err != nil {
+		return fmt.Errorf("failed to write source comparison: %w", err)
+	}
+	return nil
+}
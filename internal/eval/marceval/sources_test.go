@@ -0,0 +1,43 @@
+package marceval
+
+import (
+	"math"
+	"testing"
+)
+
+func TestNewSourceComparison(t *testing.T) {
+	scoredAs := func(id, source string, score, title float64) Result {
+		return Result{ID: id, ImageSource: source, Comparison: &Comparison{
+			Score:  score,
+			Fields: map[string]FieldScore{"245": {Tag: "245", Score: title}},
+		}}
+	}
+	results := []Result{
+		scoredAs("a", "internetarchive", 0.8, 1.0),
+		scoredAs("a", "googlebooks", 0.6, 0.5),
+		scoredAs("b", "internetarchive", 0.5, 0.5),
+		scoredAs("b", "googlebooks", 0.7, 1.0),
+		scoredAs("c", "googlebooks", 0.9, 1.0), // No IA title page
+		{ID: "d", ImageSource: "internetarchive", Error: "OCR failed"},
+	}
+
+	c := NewSourceComparison(results, []string{"internetarchive", "googlebooks"})
+	if c.Baseline != "internetarchive" || c.CommonRecords != 2 {
+		t.Fatalf("baseline, common = %q, %d", c.Baseline, c.CommonRecords)
+	}
+
+	ia, gb := c.Stats["internetarchive"], c.Stats["googlebooks"]
+	near := func(a, b float64) bool { return math.Abs(a-b) < 1e-9 }
+	if ia.Records != 3 || ia.Failed != 1 || !near(ia.MeanScore, 0.65) || ia.Paired != 0 {
+		t.Errorf("internetarchive = %+v", ia)
+	}
+	if gb.Records != 3 || !near(gb.MeanScore, 0.733333333333) || !near(gb.CommonMeanScore, 0.65) {
+		t.Errorf("googlebooks = %+v", gb)
+	}
+	if gb.Paired != 2 || !near(gb.MeanDelta, 0) || gb.Wins != 1 || gb.Losses != 1 {
+		t.Errorf("googlebooks vs baseline = %+v", gb)
+	}
+	if !near(gb.FieldDeltas["245"], 0) {
+		t.Errorf("245 delta = %v", gb.FieldDeltas["245"])
+	}
+}
@@ -0,0 +1,303 @@
+package marceval
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+
+	"github.com/parquet-go/parquet-go"
+	"github.com/parquet-go/parquet-go/compress/zstd"
+)
+
+// resultRow is a result in a parquet results file. The summary columns can be queried
+// without decoding the result.
+type resultRow struct {
+	ID        string  `parquet:"id"`
+	ErrorCode string  `parquet:"error_code"`
+	Score     float64 `parquet:"score"`  // 0 for failed and unscored results
+	Result    string  `parquet:"result"` // JSON-encoded Result
+}
+
+// resultRowGroup bounds the rows a parquet results writer buffers
+const resultRowGroup = 1000
+
+// ResultWriter streams results to a JSONL (one result per line) or parquet file as they are
+// produced, so a run never has to hold them all
+type ResultWriter struct {
+	file  *os.File
+	jsonl *bufio.Writer
+	pq    *parquet.GenericWriter[resultRow]
+}
+
+// CreateResultWriter creates a results file; the format follows the extension (.jsonl or .parquet)
+func CreateResultWriter(path string) (*ResultWriter, error) {
+	ext := strings.ToLower(filepath.Ext(path))
+	if ext != ".jsonl" && ext != ".parquet" {
+		return nil, fmt.Errorf("unsupported results file %s (use .jsonl or .parquet)", path)
+	}
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create results file: %w", err)
+	}
+	w := &ResultWriter{file: file}
+	if ext == ".parquet" {
+		w.pq = parquet.NewGenericWriter[resultRow](file, parquet.Compression(&zstd.Codec{}), parquet.MaxRowsPerRowGroup(resultRowGroup))
+	} else {
+		w.jsonl = bufio.NewWriter(file)
+	}
+	return w, nil
+}
+
+// Write appends a result
+func (w *ResultWriter) Write(res Result) error {
+	data, err := json.Marshal(res)
+	if err != nil {
+		return fmt.Errorf("failed to encode result %s: %w", res.ID, err)
+	}
+	if w.pq != nil {
+		row := resultRow{ID: res.ID, ErrorCode: string(res.FailureCode()), Result: string(data)}
+		if res.Comparison != nil && res.Error == "" {
+			row.Score = res.Comparison.Score
+		}
+		if _, err := w.pq.Write([]resultRow{row}); err != nil {
+			return fmt.Errorf("failed to write result %s: %w", res.ID, err)
+		}
+		return nil
+	}
+	data = append(data, '\n')
+	if _, err := w.jsonl.Write(data); err != nil {
+		return fmt.Errorf("failed to write result %s: %w", res.ID, err)
+	}
+	return nil
+}
+
+// Close flushes and closes the file
+func (w *ResultWriter) Close() error {
+	var err error
+	if w.pq != nil {
+		err = w.pq.Close()
+	} else {
+		err = w.jsonl.Flush()
+	}
+	if cerr := w.file.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to close results file: %w", err)
+	}
+	return nil
+}
+
+// readRaw streams the JSON of each result in a JSONL, parquet or report JSON file to fn, and
+// returns the report's header (everything but Results) for report JSON files, else nil.
+// Results of old report JSON files are migrated; JSONL and parquet files hold results of the
+// current version.
+func readRaw(path string, fn func([]byte) error) (*Report, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open results: %w", err)
+	}
+	defer file.Close()
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".jsonl":
+		scanner := bufio.NewScanner(file)
+		scanner.Buffer(make([]byte, 0, 1024*1024), 64*1024*1024)
+		for scanner.Scan() {
+			line := scanner.Bytes()
+			if len(bytes.TrimSpace(line)) == 0 {
+				continue
+			}
+			// The scanner reuses its buffer
+			if err := fn(append([]byte(nil), line...)); err != nil {
+				return nil, err
+			}
+		}
+		if err := scanner.Err(); err != nil {
+			return nil, fmt.Errorf("failed to read results: %w", err)
+		}
+		return nil, nil
+
+	case ".parquet":
+		reader := parquet.NewGenericReader[resultRow](file)
+		defer reader.Close()
+		rows := make([]resultRow, 128)
+		for {
+			n, err := reader.Read(rows)
+			for _, row := range rows[:n] {
+				if err := fn([]byte(row.Result)); err != nil {
+					return nil, err
+				}
+			}
+			if errors.Is(err, io.EOF) {
+				return nil, nil
+			}
+			if err != nil {
+				return nil, fmt.Errorf("failed to read results: %w", err)
+			}
+		}
+
+	default:
+		return readReportJSON(file, fn)
+	}
+}
+
+// readReportJSON decodes a report JSON file one result at a time
+func readReportJSON(r io.Reader, fn func([]byte) error) (*Report, error) {
+	dec := json.NewDecoder(bufio.NewReader(r))
+	if tok, err := dec.Token(); err != nil || tok != json.Delim('{') {
+		return nil, fmt.Errorf("failed to parse results: not a report JSON object")
+	}
+	header := make(map[string]any)
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse results: %w", err)
+		}
+		key, _ := tok.(string)
+		if key != "Results" {
+			var value any
+			if err := dec.Decode(&value); err != nil {
+				return nil, fmt.Errorf("failed to parse results: %w", err)
+			}
+			header[key] = value
+			continue
+		}
+		// Report JSON is written with schema_version first, so the version is known by now
+		from, err := ReportSchema.VersionOf(header)
+		if err != nil {
+			return nil, err
+		}
+		if tok, err := dec.Token(); err != nil {
+			return nil, fmt.Errorf("failed to parse results: %w", err)
+		} else if tok == nil {
+			continue // "Results": null
+		}
+		for dec.More() {
+			var raw json.RawMessage
+			if err := dec.Decode(&raw); err != nil {
+				return nil, fmt.Errorf("failed to parse results: %w", err)
+			}
+			if from < ReportSchema.Version() {
+				if raw, err = migrateResult(from, raw); err != nil {
+					return nil, err
+				}
+			}
+			if err := fn(raw); err != nil {
+				return nil, err
+			}
+		}
+		if _, err := dec.Token(); err != nil {
+			return nil, fmt.Errorf("failed to parse results: %w", err)
+		}
+	}
+
+	if _, err := ReportSchema.MigrateHeader(header); err != nil {
+		return nil, err
+	}
+	data, err := json.Marshal(header)
+	if err != nil {
+		return nil, err
+	}
+	var report Report
+	if err := json.Unmarshal(data, &report); err != nil {
+		return nil, fmt.Errorf("failed to parse report: %w", err)
+	}
+	return &report, nil
+}
+
+// migrateResult migrates the JSON of a result of a report at version from
+func migrateResult(from int, raw []byte) ([]byte, error) {
+	var rec map[string]any
+	if err := json.Unmarshal(raw, &rec); err != nil {
+		return nil, fmt.Errorf("failed to parse result: %w", err)
+	}
+	if err := ReportSchema.MigrateRecord(from, rec); err != nil {
+		return nil, err
+	}
+	return json.Marshal(rec)
+}
+
+// ReadResults streams the results in a JSONL, parquet or report JSON file to fn. For report
+// JSON files it returns the report's header (everything but Results), else nil.
+func ReadResults(path string, fn func(Result) error) (*Report, error) {
+	return readRaw(path, func(data []byte) error {
+		var res Result
+		if err := json.Unmarshal(data, &res); err != nil {
+			return fmt.Errorf("failed to parse result: %w", err)
+		}
+		return fn(res)
+	})
+}
+
+// StreamReport aggregates the results in a JSONL, parquet or report JSON file with bounded
+// memory, decoding them on workers goroutines (GOMAXPROCS when workers < 1). The report has
+// the file's header for report JSON files and no Results.
+func StreamReport(path string, workers int) (*Report, error) {
+	if workers < 1 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+
+	raws := make(chan []byte, workers*4)
+	aggs := make([]*Aggregator, workers)
+	errs := make([]error, workers)
+	var wg sync.WaitGroup
+	for i := range aggs {
+		aggs[i] = NewAggregator()
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for data := range raws {
+				if errs[i] != nil {
+					continue // Drain so the reader never blocks
+				}
+				var res Result
+				if err := json.Unmarshal(data, &res); err != nil {
+					errs[i] = fmt.Errorf("failed to parse result: %w", err)
+					continue
+				}
+				aggs[i].Add(res)
+			}
+		}(i)
+	}
+
+	header, err := readRaw(path, func(data []byte) error {
+		raws <- data
+		return nil
+	})
+	close(raws)
+	wg.Wait()
+	if err != nil {
+		return nil, err
+	}
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	for _, agg := range aggs[1:] {
+		aggs[0].Merge(agg)
+	}
+	report := aggs[0].Report()
+	if header != nil {
+		report.Dataset = header.Dataset
+		report.Provider = header.Provider
+		report.Model = header.Model
+		report.PromptVersion = header.PromptVersion
+		report.PostHooks = header.PostHooks
+		report.CompletenessProfile = header.CompletenessProfile
+		report.Penalties = header.Penalties
+		report.EvaluationDate = header.EvaluationDate
+		report.Usage = header.Usage
+	}
+	return report, nil
+}
@@ -0,0 +1,172 @@
+package marceval
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/lehigh-university-libraries/cataloger/internal/eval/failure"
+)
+
+func streamTestResults() []Result {
+	var results []Result
+	for i := range 50 {
+		res := Result{ID: string(rune('a'+i%26)) + string(rune('0'+i/26)), MaterialType: "book", Completeness: 0.5}
+		switch {
+		case i%10 == 0:
+			res.Error = "OCR failed"
+			res.ErrorCode = failure.ProviderTimeout
+		default:
+			res.Comparison = &Comparison{
+				Score:  float64(i) / 50,
+				Fields: map[string]FieldScore{"245": {Tag: "245", Score: float64(i%5) / 4}},
+			}
+		}
+		if i%7 == 0 {
+			res.Warnings = []failure.Warning{failure.Warn(failure.WarnNoTitle, "no title extracted")}
+		}
+		results = append(results, res)
+	}
+	return results
+}
+
+func sameReport(t *testing.T, got, want *Report) {
+	t.Helper()
+	near := func(a, b float64) bool { return math.Abs(a-b) < 1e-9 }
+	if got.Records != want.Records || got.Failed != want.Failed || got.Scored != want.Scored ||
+		got.RecordsWithWarnings != want.RecordsWithWarnings || got.FailureCodes[failure.ProviderTimeout] != want.FailureCodes[failure.ProviderTimeout] {
+		t.Errorf("counts = %d/%d/%d/%d, want %d/%d/%d/%d", got.Records, got.Failed, got.Scored, got.RecordsWithWarnings,
+			want.Records, want.Failed, want.Scored, want.RecordsWithWarnings)
+	}
+	if !near(got.MeanScore, want.MeanScore) || !near(got.FieldMeans["245"], want.FieldMeans["245"]) || !near(got.MeanCompleteness, want.MeanCompleteness) {
+		t.Errorf("means = %v %v %v, want %v %v %v", got.MeanScore, got.FieldMeans["245"], got.MeanCompleteness,
+			want.MeanScore, want.FieldMeans["245"], want.MeanCompleteness)
+	}
+	if got.Materials["book"] == nil || !near(got.Materials["book"].MeanScore, want.Materials["book"].MeanScore) {
+		t.Errorf("materials = %+v, want %+v", got.Materials, want.Materials)
+	}
+}
+
+func TestAggregatorMerge(t *testing.T) {
+	results := streamTestResults()
+	want := NewReport(results)
+
+	a, b := NewAggregator(), NewAggregator()
+	for i, res := range results {
+		if i%3 == 0 {
+			a.Add(res)
+		} else {
+			b.Add(res)
+		}
+	}
+	a.Merge(b)
+	sameReport(t, a.Report(), want)
+
+	// Reports don't disturb the sums
+	a.Report()
+	sameReport(t, a.Report(), want)
+}
+
+func TestSuspectReferenceWeight(t *testing.T) {
+	scored := func(score float64, weight *float64) Result {
+		return Result{
+			Comparison:      &Comparison{Score: score, Fields: map[string]FieldScore{"245": {Tag: "245", Score: score}}},
+			ReferenceWeight: weight,
+		}
+	}
+	half, none := 0.5, 0.0
+	results := []Result{scored(1, nil), scored(0, &half), scored(0, &none)}
+	results[1].SuspectReference = []string{"invalid_isbn"}
+	results[2].SuspectReference = []string{"invalid_isbn", "empty_title"}
+
+	r := NewReport(results)
+	if r.Scored != 2 || r.ExcludedScored != 1 || r.SuspectReferences != 2 || r.SuspectFindings["invalid_isbn"] != 2 {
+		t.Errorf("counts = scored %d, excluded %d, suspect %d %v", r.Scored, r.ExcludedScored, r.SuspectReferences, r.SuspectFindings)
+	}
+	// (1*1 + 0.5*0) / 1.5
+	if want := 1 / 1.5; math.Abs(r.MeanScore-want) > 1e-9 || math.Abs(r.FieldMeans["245"]-want) > 1e-9 {
+		t.Errorf("weighted means = %v %v, want %v", r.MeanScore, r.FieldMeans["245"], want)
+	}
+}
+
+func TestStreamReport(t *testing.T) {
+	results := streamTestResults()
+	want := NewReport(results)
+	dir := t.TempDir()
+
+	for _, name := range []string{"results.jsonl", "results.parquet"} {
+		path := filepath.Join(dir, name)
+		w, err := CreateResultWriter(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, res := range results {
+			if err := w.Write(res); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := w.Close(); err != nil {
+			t.Fatal(err)
+		}
+
+		got, err := StreamReport(path, 4)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		sameReport(t, got, want)
+		if len(got.Results) != 0 {
+			t.Errorf("%s: streamed report holds %d results", name, len(got.Results))
+		}
+	}
+
+	// A report JSON file keeps its header
+	want.Dataset, want.Model = "eval_data", "mock"
+	path := filepath.Join(dir, "report.json")
+	if err := want.SaveJSON(path); err != nil {
+		t.Fatal(err)
+	}
+	got, err := StreamReport(path, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sameReport(t, got, want)
+	if got.Dataset != "eval_data" || got.Model != "mock" {
+		t.Errorf("header = %q %q", got.Dataset, got.Model)
+	}
+
+	n := 0
+	if _, err := ReadResults(path, func(Result) error { n++; return nil }); err != nil || n != len(results) {
+		t.Errorf("ReadResults() = %d results, %v", n, err)
+	}
+}
+
+func TestStreamReportMigratesUnversioned(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "old.json")
+	old := `{"Dataset":"eval_data","Results":[{"ID":"a","Error":"OCR failed"},{"ID":"b","Error":"timeout","ErrorCode":"provider_timeout"}]}`
+	if err := os.WriteFile(path, []byte(old), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var codes []failure.Code
+	header, err := ReadResults(path, func(res Result) error {
+		codes = append(codes, res.ErrorCode)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if header.SchemaVersion != ReportSchema.Version() || header.Dataset != "eval_data" {
+		t.Errorf("header = version %d, dataset %q", header.SchemaVersion, header.Dataset)
+	}
+	if len(codes) != 2 || codes[0] != failure.Unknown || codes[1] != failure.ProviderTimeout {
+		t.Errorf("error codes = %v, want [unknown provider_timeout]", codes)
+	}
+
+	if err := os.WriteFile(path, []byte(`{"schema_version":99,"Results":[]}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := StreamReport(path, 1); err == nil {
+		t.Error("StreamReport read a report from a newer version")
+	}
+}
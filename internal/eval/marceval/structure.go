@@ -0,0 +1,66 @@
+package marceval
+
+import "github.com/lehigh-university-libraries/cataloger/internal/marc"
+
+// Penalties optionally lower a comparison's score for structural problems that field
+// similarity cannot see. The zero value applies no penalty.
+type Penalties struct {
+	Duplicate float64 // Subtracted per extra occurrence of a non-repeatable field
+	Order     float64 // Share of the score scaled by OrderScore (0 ignores ordering)
+}
+
+// Apply lowers c.Score by the penalties, keeping the similarity score in RawScore
+func (p Penalties) Apply(c *Comparison) {
+	if c == nil || (p.Duplicate == 0 && p.Order == 0) {
+		return
+	}
+	c.RawScore = c.Score
+	extra := 0
+	for _, n := range c.Duplicates {
+		extra += n - 1
+	}
+	score := c.Score*(1-p.Order*(1-c.OrderScore)) - p.Duplicate*float64(extra)
+	c.Score = max(score, 0)
+}
+
+// duplicateFields counts non-repeatable tags occurring more than once
+func duplicateFields(rec *marc.Record) map[string]int {
+	counts := make(map[string]int)
+	if rec == nil {
+		return counts
+	}
+	for _, cf := range rec.ControlFields {
+		counts[cf.Tag]++
+	}
+	for _, df := range rec.DataFields {
+		counts[df.Tag]++
+	}
+	for tag, n := range counts {
+		if n < 2 || !marc.IsNonRepeatable(tag) {
+			delete(counts, tag)
+		}
+	}
+	return counts
+}
+
+// orderScore is the share of adjacent data fields in tag order. Notes (5XX) may be
+// ordered by importance, so they only need to stay within their block.
+func orderScore(rec *marc.Record) float64 {
+	if rec == nil || len(rec.DataFields) < 2 {
+		return 1
+	}
+	inOrder := 0
+	for i := 1; i < len(rec.DataFields); i++ {
+		if orderKey(rec.DataFields[i-1].Tag) <= orderKey(rec.DataFields[i].Tag) {
+			inOrder++
+		}
+	}
+	return float64(inOrder) / float64(len(rec.DataFields)-1)
+}
+
+func orderKey(tag string) string {
+	if len(tag) == 3 && tag[0] == '5' {
+		return "5"
+	}
+	return tag
+}
@@ -0,0 +1,39 @@
+package marceval
+
+import (
+	"testing"
+
+	"github.com/lehigh-university-libraries/cataloger/internal/marc"
+)
+
+func TestStructurePenalties(t *testing.T) {
+	reference, err := marc.ParseMnemonic("=245  14$aThe old man and the sea.\n=500  \\\\$aFirst note.\n=650  \\0$aFishers$vFiction.")
+	if err != nil {
+		t.Fatal(err)
+	}
+	generated, err := marc.ParseMnemonic("=245  14$aThe old man and the sea.\n=245  10$aOld man and the sea.\n=650  \\0$aFishers$vFiction.\n=504  \\\\$aBibliography.\n=500  \\\\$aFirst note.")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c := Compare(reference, generated)
+	if c.Duplicates["245"] != 2 || len(c.Duplicates) != 1 {
+		t.Errorf("Duplicates = %v, want 245 twice", c.Duplicates)
+	}
+	// 245 -> 245 -> 650 -> 504 -> 500: only 650 -> 504 is out of order; 504 before 500 is allowed
+	if c.OrderScore != 0.75 {
+		t.Errorf("OrderScore = %v, want 0.75", c.OrderScore)
+	}
+
+	raw := c.Score
+	Penalties{}.Apply(c)
+	if c.Score != raw || c.RawScore != 0 {
+		t.Errorf("zero Penalties changed the score: %v -> %v", raw, c.Score)
+	}
+
+	Penalties{Duplicate: 0.1, Order: 0.2}.Apply(c)
+	want := raw*(1-0.2*0.25) - 0.1
+	if c.RawScore != raw || c.Score < want-1e-9 || c.Score > want+1e-9 {
+		t.Errorf("penalized score = %v (raw %v), want %v", c.Score, c.RawScore, want)
+	}
+}
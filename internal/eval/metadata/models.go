@@ -1,7 +1,7 @@
 package metadata
 
 // BookMetadata represents the extracted bibliographic metadata from a book
-// This matches the structure of the Institutional Books dataset for easy comparison
+// This matches the structure of the Institutional Books dataset for easy comparison.
 type BookMetadata struct {
 	Title           string   `json:"title"`
 	Author          string   `json:"author"`
@@ -17,6 +17,29 @@ type BookMetadata struct {
 	Notes           string   `json:"notes,omitempty"`
 }
 
+// CopyrightMetadata is extracted from a book's copyright page (the title page verso)
+type CopyrightMetadata struct {
+	CopyrightDate   string   `json:"copyright_date"`             // Year of the latest copyright claim
+	PrintingHistory string   `json:"printing_history,omitempty"` // Edition and printing statements, e.g. "First edition 1999"
+	Edition         string   `json:"edition,omitempty"`
+	LCCN            string   `json:"lccn,omitempty"`
+	ISBN            []string `json:"isbn,omitempty"` // With qualifiers, e.g. "0684801221 (hardcover)"
+	CIP             *CIPData `json:"cip,omitempty"`
+}
+
+// CIPData is a Cataloging in Publication data block as printed on the copyright page
+type CIPData struct {
+	MainEntry  string   `json:"main_entry,omitempty"`
+	Title      string   `json:"title,omitempty"`
+	Series     string   `json:"series,omitempty"`
+	Subjects   []string `json:"subjects,omitempty"` // Subject tracings with "--" subdivisions, e.g. "Bridges--History"
+	LCClass    string   `json:"lc_classification,omitempty"`
+	DDC        string   `json:"ddc,omitempty"`
+	DDCEdition string   `json:"ddc_edition,omitempty"` // From the "dc23" suffix
+	LCCN       string   `json:"lccn,omitempty"`
+	ISBN       []string `json:"isbn,omitempty"`
+}
+
 // MetadataComparison represents field-by-field comparison of metadata
 type MetadataComparison struct {
 	Fields           map[string]FieldComparison
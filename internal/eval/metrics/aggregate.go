@@ -1,13 +1,20 @@
 package metrics
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"os"
+	"sort"
 	"strings"
 	"time"
 
+	"github.com/lehigh-university-libraries/cataloger/internal/eval/dataset"
+	"github.com/lehigh-university-libraries/cataloger/internal/eval/failure"
 	"github.com/lehigh-university-libraries/cataloger/internal/eval/metadata"
+	"github.com/lehigh-university-libraries/cataloger/internal/objectstore"
+	"github.com/lehigh-university-libraries/cataloger/internal/usage"
 )
 
 // FieldMatch represents comparison for a single field
@@ -27,7 +34,25 @@ type EvaluationResult struct {
 	GeneratedMetadata string // JSON metadata extracted from OCR
 	FullComparison    *metadata.MetadataComparison
 	ProcessingTime    time.Duration
-	Error             string // If generation failed
+	Error             string       // If generation failed
+	ErrorCode         failure.Code `json:",omitempty"` // Category of Error
+
+	// Provider/model that actually handled this record and why it was chosen
+	// ("default" for the run's provider, "override" for a per-record override,
+	// "language" for a routing rule matched on the detected script/language)
+	Provider string
+	Model    string
+	Routing  string
+
+	// Script and language detected from the title page when language routing is enabled
+	Script   string
+	Language string
+
+	// Prompt version used, e.g. "metadata_extraction@v1+3f2a9c1b7e4d"
+	PromptVersion string
+
+	// Quality of the record's OCR input, from the dataset's token counts and text divergence
+	OCRQuality *dataset.OCRQuality `json:",omitempty"`
 }
 
 // AggregateResults represents aggregated evaluation metrics
@@ -35,6 +60,7 @@ type AggregateResults struct {
 	TotalRecords int
 	SuccessCount int
 	FailureCount int
+	FailureCodes map[failure.Code]int `json:",omitempty"` // Failure code -> failed records
 
 	// Field-level statistics
 	TitleAccuracy   FieldStats
@@ -53,11 +79,18 @@ type AggregateResults struct {
 	// Detailed results
 	Results []EvaluationResult
 
+	// Records handled per "provider/model" when records were routed to different models
+	Routes map[string]int
+
+	// OCR quality bucket -> records and accuracy, separating poor input from poor output
+	OCRBuckets map[string]*BucketStats `json:",omitempty"`
+
 	// Metadata
 	EvaluationDate time.Time
 	Provider       string
 	Model          string
 	SampleSize     int
+	Usage          *usage.Usage `json:",omitempty"` // Resources the run used
 }
 
 // FieldStats contains statistics for a specific MARC field
@@ -70,6 +103,13 @@ type FieldStats struct {
 	Scores        []float64
 }
 
+// BucketStats summarizes the records in one OCR quality bucket
+type BucketStats struct {
+	Records         int
+	Failed          int
+	OverallAccuracy float64 // Mean overall score of the successful records
+}
+
 // AggregateEvaluationResults aggregates multiple evaluation results
 func AggregateEvaluationResults(results []EvaluationResult, provider, model string) *AggregateResults {
 	agg := &AggregateResults{
@@ -92,11 +132,45 @@ func AggregateEvaluationResults(results []EvaluationResult, provider, model stri
 	var totalDuration time.Duration
 	var successDuration time.Duration
 
+	bucketScores := make(map[string]float64)
 	for _, result := range results {
 		totalDuration += result.ProcessingTime
 
+		var bucket *BucketStats
+		if result.OCRQuality != nil {
+			if agg.OCRBuckets == nil {
+				agg.OCRBuckets = make(map[string]*BucketStats)
+			}
+			name := result.OCRQuality.Bucket
+			if bucket = agg.OCRBuckets[name]; bucket == nil {
+				bucket = &BucketStats{}
+				agg.OCRBuckets[name] = bucket
+			}
+			bucket.Records++
+			if result.Error != "" {
+				bucket.Failed++
+			} else if result.FullComparison != nil {
+				bucketScores[name] += result.FullComparison.OverallScore
+			}
+		}
+
+		if result.Provider != "" || result.Model != "" {
+			if agg.Routes == nil {
+				agg.Routes = make(map[string]int)
+			}
+			agg.Routes[result.Provider+"/"+result.Model]++
+		}
+
 		if result.Error != "" {
 			agg.FailureCount++
+			code := result.ErrorCode
+			if code == "" {
+				code = failure.Unknown
+			}
+			if agg.FailureCodes == nil {
+				agg.FailureCodes = make(map[failure.Code]int)
+			}
+			agg.FailureCodes[code]++
 			continue
 		}
 
@@ -139,6 +213,12 @@ func AggregateEvaluationResults(results []EvaluationResult, provider, model stri
 		agg.AverageProcessingTime = successDuration / time.Duration(agg.SuccessCount)
 	}
 
+	for name, bucket := range agg.OCRBuckets {
+		if scored := bucket.Records - bucket.Failed; scored > 0 {
+			bucket.OverallAccuracy = bucketScores[name] / float64(scored)
+		}
+	}
+
 	agg.TotalProcessingTime = totalDuration
 
 	return agg
@@ -190,10 +270,44 @@ func (a *AggregateResults) PrintSummary() {
 	fmt.Printf("Total Records: %d\n", a.TotalRecords)
 	fmt.Printf("Successful: %d (%.1f%%)\n", a.SuccessCount, float64(a.SuccessCount)/float64(a.TotalRecords)*100)
 	fmt.Printf("Failed: %d (%.1f%%)\n", a.FailureCount, float64(a.FailureCount)/float64(a.TotalRecords)*100)
+	for _, code := range failure.Codes {
+		if n := a.FailureCodes[code]; n > 0 {
+			fmt.Printf("  %s: %d\n", code, n)
+		}
+	}
 	fmt.Printf("Average Processing Time: %s\n", a.AverageProcessingTime)
 	fmt.Printf("Total Processing Time: %s\n", a.TotalProcessingTime)
 	fmt.Println()
 
+	if a.Usage != nil {
+		a.Usage.Print(os.Stdout)
+	}
+
+	if len(a.Routes) > 1 {
+		fmt.Println("ROUTING")
+		fmt.Println(strings.Repeat("-", 70))
+		routes := make([]string, 0, len(a.Routes))
+		for route := range a.Routes {
+			routes = append(routes, route)
+		}
+		sort.Strings(routes)
+		for _, route := range routes {
+			fmt.Printf("%s: %d records\n", route, a.Routes[route])
+		}
+		fmt.Println()
+	}
+
+	if len(a.OCRBuckets) > 0 {
+		fmt.Println("ACCURACY BY OCR QUALITY")
+		fmt.Println(strings.Repeat("-", 70))
+		for _, name := range dataset.OCRBuckets {
+			if b := a.OCRBuckets[name]; b != nil {
+				fmt.Printf("%-8s %4d records, %3d failed, overall accuracy %.2f%%\n", name+":", b.Records, b.Failed, b.OverallAccuracy*100)
+			}
+		}
+		fmt.Println()
+	}
+
 	fmt.Println("FIELD-LEVEL ACCURACY")
 	fmt.Println(strings.Repeat("-", 70))
 	printFieldStats("Title", a.TitleAccuracy)
@@ -219,31 +333,22 @@ func printFieldStats(fieldName string, stats FieldStats) {
 	fmt.Printf("  Missing Fields: %d\n", stats.MissingFields)
 }
 
-// SaveToJSON saves the aggregate results to a JSON file
+// SaveToJSON saves the aggregate results to a JSON file or object URL (s3://, gs://)
 func (a *AggregateResults) SaveToJSON(filepath string) error {
-	file, err := os.Create(filepath)
-	if err != nil {
-		return fmt.Errorf("failed to create output file: %w", err)
-	}
-	defer file.Close()
-
-	encoder := json.NewEncoder(file)
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
 	encoder.SetIndent("", "  ")
 
 	if err := encoder.Encode(a); err != nil {
 		return fmt.Errorf("failed to encode results to JSON: %w", err)
 	}
 
-	return nil
+	return objectstore.WriteFile(context.Background(), filepath, buf.Bytes())
 }
 
-// SaveDetailedReport saves a detailed report with individual results
+// SaveDetailedReport saves a detailed report with individual results to a file or object URL
 func (a *AggregateResults) SaveDetailedReport(filepath string) error {
-	file, err := os.Create(filepath)
-	if err != nil {
-		return fmt.Errorf("failed to create report file: %w", err)
-	}
-	defer file.Close()
+	file := &bytes.Buffer{}
 
 	// Write header
 	fmt.Fprintf(file, "CATALOGER EVALUATION DETAILED REPORT\n")
@@ -260,6 +365,15 @@ func (a *AggregateResults) SaveDetailedReport(filepath string) error {
 		fmt.Fprintf(file, "Title: %s\n", result.Title)
 		fmt.Fprintf(file, "Author: %s\n", result.Author)
 		fmt.Fprintf(file, "Processing Time: %s\n", result.ProcessingTime)
+		if result.Routing != "" {
+			fmt.Fprintf(file, "Model: %s/%s (%s)\n", result.Provider, result.Model, result.Routing)
+		}
+		if result.Script != "" {
+			fmt.Fprintf(file, "Detected Script/Language: %s/%s\n", result.Script, result.Language)
+		}
+		if q := result.OCRQuality; q != nil {
+			fmt.Fprintf(file, "OCR Quality: %s (%.0f tokens/page, divergence %.2f)\n", q.Bucket, q.TokensPerPage, q.Divergence)
+		}
 
 		if result.Error != "" {
 			fmt.Fprintf(file, "ERROR: %s\n", result.Error)
@@ -286,7 +400,7 @@ func (a *AggregateResults) SaveDetailedReport(filepath string) error {
 		fmt.Fprintf(file, "\n%s\n\n", separator)
 	}
 
-	return nil
+	return objectstore.WriteFile(context.Background(), filepath, file.Bytes())
 }
 
 // truncate shortens a string to the given length with ellipsis
@@ -3,10 +3,12 @@ package metrics
 import (
 	"os"
 	"path/filepath"
+	"reflect"
 	"strings"
 	"testing"
 	"time"
 
+	"github.com/lehigh-university-libraries/cataloger/internal/eval/dataset"
 	"github.com/lehigh-university-libraries/cataloger/internal/eval/metadata"
 )
 
@@ -343,3 +345,49 @@ func TestSaveDetailedReport(t *testing.T) {
 		t.Error("Report missing error message")
 	}
 }
+
+func TestAggregateOCRBuckets(t *testing.T) {
+	good := &dataset.OCRQuality{Bucket: dataset.OCRGood}
+	poor := &dataset.OCRQuality{Bucket: dataset.OCRPoor}
+	results := []EvaluationResult{
+		{OCRQuality: good, FullComparison: &metadata.MetadataComparison{OverallScore: 0.9}},
+		{OCRQuality: good, FullComparison: &metadata.MetadataComparison{OverallScore: 0.7}},
+		{OCRQuality: poor, FullComparison: &metadata.MetadataComparison{OverallScore: 0.2}},
+		{OCRQuality: poor, Error: "Metadata extraction failed"},
+	}
+
+	agg := AggregateEvaluationResults(results, "mock", "mock-model")
+	if len(agg.OCRBuckets) != 2 {
+		t.Fatalf("OCRBuckets = %v, want good and poor", agg.OCRBuckets)
+	}
+	if b := agg.OCRBuckets[dataset.OCRGood]; b.Records != 2 || b.Failed != 0 || b.OverallAccuracy < 0.799 || b.OverallAccuracy > 0.801 {
+		t.Errorf("good bucket = %+v, want 2 records at 0.8", b)
+	}
+	if b := agg.OCRBuckets[dataset.OCRPoor]; b.Records != 2 || b.Failed != 1 || b.OverallAccuracy != 0.2 {
+		t.Errorf("poor bucket = %+v, want 2 records, 1 failed, 0.2", b)
+	}
+}
+
+func TestAggregateRoutes(t *testing.T) {
+	results := []EvaluationResult{
+		{Provider: "ollama", Model: "llama3", Routing: "default", FullComparison: &metadata.MetadataComparison{}},
+		{Provider: "ollama", Model: "llama3", Routing: "default", Error: "Metadata extraction failed"},
+		{Provider: "openai", Model: "gpt-4o", Routing: "override", FullComparison: &metadata.MetadataComparison{}},
+		{Provider: "ollama", Model: "qwen2.5vl:32b", Routing: "override", FullComparison: &metadata.MetadataComparison{}},
+	}
+
+	agg := AggregateEvaluationResults(results, "ollama", "llama3")
+	want := map[string]int{"ollama/llama3": 2, "openai/gpt-4o": 1, "ollama/qwen2.5vl:32b": 1}
+	if !reflect.DeepEqual(agg.Routes, want) {
+		t.Errorf("Routes = %v, want %v", agg.Routes, want)
+	}
+	if agg.Provider != "ollama" || agg.Model != "llama3" {
+		t.Errorf("run = %s/%s, want the run's provider and model", agg.Provider, agg.Model)
+	}
+
+	// A run without overrides records its one route
+	agg = AggregateEvaluationResults(results[:2], "ollama", "llama3")
+	if !reflect.DeepEqual(agg.Routes, map[string]int{"ollama/llama3": 2}) {
+		t.Errorf("Routes = %v", agg.Routes)
+	}
+}
@@ -7,18 +7,20 @@ import (
 	"time"
 
 	"github.com/lehigh-university-libraries/cataloger/internal/eval/metrics"
+	"github.com/lehigh-university-libraries/cataloger/internal/eval/schema"
 	"gopkg.in/yaml.v3"
 )
 
 // EvalConfig represents the configuration section of the eval YAML
 type EvalConfig struct {
-	Provider    string  `yaml:"provider"`
-	Model       string  `yaml:"model"`
-	Prompt      string  `yaml:"prompt"`
-	Temperature float64 `yaml:"temperature"`
-	DatasetPath string  `yaml:"datasetpath"`
-	SampleSize  int     `yaml:"samplesize"`
-	Timestamp   string  `yaml:"timestamp"`
+	Provider      string  `yaml:"provider"`
+	Model         string  `yaml:"model"`
+	Prompt        string  `yaml:"prompt"`
+	PromptVersion string  `yaml:"promptversion,omitempty"`
+	Temperature   float64 `yaml:"temperature"`
+	DatasetPath   string  `yaml:"datasetpath"`
+	SampleSize    int     `yaml:"samplesize"`
+	Timestamp     string  `yaml:"timestamp"`
 }
 
 // EvalResult represents a single evaluation result
@@ -26,6 +28,12 @@ type EvalResult struct {
 	Identifier       string             `yaml:"identifier"`
 	Title            string             `yaml:"title"`
 	Author           string             `yaml:"author,omitempty"`
+	Provider         string             `yaml:"provider,omitempty"`
+	Model            string             `yaml:"model,omitempty"`
+	Routing          string             `yaml:"routing,omitempty"`
+	Script           string             `yaml:"script,omitempty"`
+	Language         string             `yaml:"language,omitempty"`
+	PromptVersion    string             `yaml:"promptversion,omitempty"`
 	ProviderResponse string             `yaml:"providerresponse"`
 	OverallScore     float64            `yaml:"overallscore"`
 	LevenshteinTotal int                `yaml:"levenshteintotal"`
@@ -35,14 +43,22 @@ type EvalResult struct {
 	FieldScores      map[string]float64 `yaml:"fieldscores"`
 }
 
+// SpecSchema is the format of the eval YAML. Version 1 added schema_version.
+var SpecSchema = schema.Schema{
+	Name:       "eval YAML",
+	RecordsKey: "results",
+	Steps:      []schema.Step{{}},
+}
+
 // EvalSpec represents the complete evaluation specification
 type EvalSpec struct {
-	Config  EvalConfig   `yaml:"config"`
-	Results []EvalResult `yaml:"results"`
+	SchemaVersion int          `yaml:"schema_version"`
+	Config        EvalConfig   `yaml:"config"`
+	Results       []EvalResult `yaml:"results"`
 }
 
 // SaveToYAML saves evaluation results to a YAML file in evals/ directory
-func SaveToYAML(provider, model, datasetPath string, sampleSize int, results []metrics.EvaluationResult) error {
+func SaveToYAML(provider, model, promptVersion, datasetPath string, sampleSize int, results []metrics.EvaluationResult) error {
 	// Create evals directory
 	if err := os.MkdirAll("evals", 0755); err != nil {
 		return fmt.Errorf("failed to create evals directory: %w", err)
@@ -53,14 +69,16 @@ func SaveToYAML(provider, model, datasetPath string, sampleSize int, results []m
 
 	// Create eval spec
 	spec := EvalSpec{
+		SchemaVersion: SpecSchema.Version(),
 		Config: EvalConfig{
-			Provider:    provider,
-			Model:       model,
-			Prompt:      "Extract metadata from OCR text",
-			Temperature: 0.1,
-			DatasetPath: datasetPath,
-			SampleSize:  sampleSize,
-			Timestamp:   timestamp,
+			Provider:      provider,
+			Model:         model,
+			Prompt:        "Extract metadata from OCR text",
+			PromptVersion: promptVersion,
+			Temperature:   0.1,
+			DatasetPath:   datasetPath,
+			SampleSize:    sampleSize,
+			Timestamp:     timestamp,
 		},
 		Results: make([]EvalResult, 0, len(results)),
 	}
@@ -75,6 +93,12 @@ func SaveToYAML(provider, model, datasetPath string, sampleSize int, results []m
 			Identifier:       r.Barcode,
 			Title:            r.Title,
 			Author:           r.Author,
+			Provider:         r.Provider,
+			Model:            r.Model,
+			Routing:          r.Routing,
+			Script:           r.Script,
+			Language:         r.Language,
+			PromptVersion:    r.PromptVersion,
 			ProviderResponse: r.GeneratedMetadata,
 		}
 
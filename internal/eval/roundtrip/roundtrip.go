@@ -0,0 +1,162 @@
+// Package roundtrip measures information lost through the MARC generation pipeline by
+// regenerating reference records from their own descriptions, so losses in the metadata
+// schema and MARC mapping, in the prompt and response parsing, and in vision/OCR can be
+// told apart
+package roundtrip
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/lehigh-university-libraries/cataloger/internal/eval/marceval"
+	"github.com/lehigh-university-libraries/cataloger/internal/eval/metadata"
+	"github.com/lehigh-university-libraries/cataloger/internal/marc"
+)
+
+// MetadataFromRecord reads the metadata the extraction prompt asks for straight from a
+// reference record, as a perfect extraction would return it
+func MetadataFromRecord(rec *marc.Record) metadata.BookMetadata {
+	trim := func(s string) string { return strings.TrimSpace(strings.TrimRight(s, " /:;,.=")) }
+
+	md := metadata.BookMetadata{
+		Author:  trim(rec.SubfieldValue("100", "a")),
+		Edition: trim(rec.SubfieldValue("250", "a")),
+		Series:  trim(rec.SubfieldValue("490", "a")),
+		Subject: trim(rec.SubfieldValue("650", "a")),
+		Genre:   trim(rec.SubfieldValue("655", "a")),
+	}
+	md.Title = trim(rec.SubfieldValue("245", "a"))
+	if sub := trim(rec.SubfieldValue("245", "b")); sub != "" {
+		md.Title += " : " + sub
+	}
+
+	imprint := "264"
+	if len(rec.Fields("264")) == 0 {
+		imprint = "260"
+	}
+	md.PublicationCity = trim(rec.SubfieldValue(imprint, "a"))
+	md.Publisher = trim(rec.SubfieldValue(imprint, "b"))
+	md.PublicationDate = trim(rec.SubfieldValue(imprint, "c"))
+
+	for _, f := range rec.Fields("020") {
+		if isbn := trim(f.Subfield("a")); isbn != "" {
+			md.ISBN = append(md.ISBN, isbn)
+		}
+	}
+	if cf := rec.ControlField("008"); len(cf) >= 38 {
+		md.Language = strings.TrimSpace(cf[35:38])
+	}
+	return md
+}
+
+// Stage is a point in the pipeline records are scored at
+type Stage string
+
+const (
+	// StageMapping is the reference's own metadata mapped back to MARC, with no model
+	StageMapping Stage = "mapping"
+	// StageRoundTrip is MARC generated by the model from the reference's own description
+	StageRoundTrip Stage = "roundtrip"
+	// StageFull is MARC generated from the title page image by a regular eval run
+	StageFull Stage = "full"
+)
+
+// Loss is the share of a field's score lost at each step of the pipeline. Mapping is what
+// the metadata schema and MARC mapping cannot carry, Prompt what the model and response
+// parsing lose from a clean description, and Vision what reading the image loses on top.
+type Loss struct {
+	Tag     string
+	Mapping float64
+	Prompt  float64
+	Vision  float64 `json:",omitempty"`
+	Scores  map[Stage]float64
+}
+
+// Attribution compares the stages on the records scored at every stage
+type Attribution struct {
+	Records int
+	HasFull bool
+	Overall Loss
+	Fields  []Loss
+}
+
+// Attribute splits the loss between the stages. Results are matched by ID, and only records
+// scored at every stage given count; full may be nil when there is no eval run to compare.
+func Attribute(mapping, roundTrip, full []marceval.Result) Attribution {
+	stages := map[Stage][]marceval.Result{StageMapping: mapping, StageRoundTrip: roundTrip}
+	if full != nil {
+		stages[StageFull] = full
+	}
+
+	scored := make(map[Stage]map[string]marceval.Result, len(stages))
+	for stage, results := range stages {
+		scored[stage] = make(map[string]marceval.Result)
+		for _, r := range results {
+			if r.Error == "" && r.Comparison != nil {
+				scored[stage][r.ID] = r
+			}
+		}
+	}
+
+	var shared []string
+	for id := range scored[StageMapping] {
+		inAll := true
+		for stage := range stages {
+			if _, ok := scored[stage][id]; !ok {
+				inAll = false
+			}
+		}
+		if inAll {
+			shared = append(shared, id)
+		}
+	}
+	sort.Strings(shared)
+
+	a := Attribution{Records: len(shared), HasFull: full != nil}
+	if len(shared) == 0 {
+		return a
+	}
+
+	reports := make(map[Stage]*marceval.Report, len(stages))
+	for stage := range stages {
+		results := make([]marceval.Result, len(shared))
+		for i, id := range shared {
+			results[i] = scored[stage][id]
+		}
+		reports[stage] = marceval.NewReport(results)
+	}
+
+	a.Overall = loss("", reports, func(r *marceval.Report) (float64, bool) { return r.MeanScore, true })
+	for _, tag := range sortedTags(reports[StageMapping].FieldMeans) {
+		a.Fields = append(a.Fields, loss(tag, reports, func(r *marceval.Report) (float64, bool) {
+			v, ok := r.FieldMeans[tag]
+			return v, ok
+		}))
+	}
+	return a
+}
+
+// loss reads a score from each stage's report and takes the differences between them
+func loss(tag string, reports map[Stage]*marceval.Report, score func(*marceval.Report) (float64, bool)) Loss {
+	l := Loss{Tag: tag, Scores: make(map[Stage]float64, len(reports))}
+	for stage, r := range reports {
+		if v, ok := score(r); ok {
+			l.Scores[stage] = v
+		}
+	}
+	l.Mapping = 1 - l.Scores[StageMapping]
+	l.Prompt = l.Scores[StageMapping] - l.Scores[StageRoundTrip]
+	if _, ok := reports[StageFull]; ok {
+		l.Vision = l.Scores[StageRoundTrip] - l.Scores[StageFull]
+	}
+	return l
+}
+
+func sortedTags(m map[string]float64) []string {
+	tags := make([]string, 0, len(m))
+	for tag := range m {
+		tags = append(tags, tag)
+	}
+	sort.Strings(tags)
+	return tags
+}
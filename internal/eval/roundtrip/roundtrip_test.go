@@ -0,0 +1,60 @@
+package roundtrip
+
+import (
+	"math"
+	"testing"
+
+	"github.com/lehigh-university-libraries/cataloger/internal/eval/marceval"
+	"github.com/lehigh-university-libraries/cataloger/internal/marc"
+)
+
+func TestMetadataFromRecord(t *testing.T) {
+	rec := &marc.Record{
+		ControlFields: []marc.ControlField{{Tag: "008", Value: "850101s1952    nyu           000 1 eng d"}},
+		DataFields: []marc.DataField{
+			{Tag: "020", Subfields: []marc.Subfield{{Code: "a", Value: "0684801221"}}},
+			{Tag: "100", Subfields: []marc.Subfield{{Code: "a", Value: "Hemingway, Ernest,"}}},
+			{Tag: "245", Subfields: []marc.Subfield{{Code: "a", Value: "The old man and the sea /"}}},
+			{Tag: "260", Subfields: []marc.Subfield{{Code: "a", Value: "New York :"}, {Code: "b", Value: "Scribner,"}, {Code: "c", Value: "1952."}}},
+		},
+	}
+	md := MetadataFromRecord(rec)
+	if md.Title != "The old man and the sea" || md.Author != "Hemingway, Ernest" {
+		t.Errorf("title/author = %q / %q", md.Title, md.Author)
+	}
+	if md.PublicationCity != "New York" || md.Publisher != "Scribner" || md.PublicationDate != "1952" {
+		t.Errorf("imprint = %q %q %q", md.PublicationCity, md.Publisher, md.PublicationDate)
+	}
+	if md.Language != "eng" || len(md.ISBN) != 1 {
+		t.Errorf("language %q, ISBNs %v", md.Language, md.ISBN)
+	}
+}
+
+func scoredResult(id string, score float64) marceval.Result {
+	return marceval.Result{ID: id, Comparison: &marceval.Comparison{
+		Score:  score,
+		Fields: map[string]marceval.FieldScore{"245": {Tag: "245", Score: score, Weight: 1}},
+	}}
+}
+
+func TestAttribute(t *testing.T) {
+	mapping := []marceval.Result{scoredResult("a", 1), scoredResult("b", 0.9), scoredResult("c", 1)}
+	roundTrip := []marceval.Result{scoredResult("a", 0.9), scoredResult("b", 0.7), {ID: "c", Error: "timeout"}}
+	full := []marceval.Result{scoredResult("a", 0.6), scoredResult("b", 0.6)}
+
+	a := Attribute(mapping, roundTrip, full)
+	if a.Records != 2 {
+		t.Fatalf("records = %d, want the 2 scored at every stage", a.Records)
+	}
+	near := func(got, want float64) bool { return math.Abs(got-want) < 1e-9 }
+	if !near(a.Overall.Mapping, 0.05) || !near(a.Overall.Prompt, 0.15) || !near(a.Overall.Vision, 0.2) {
+		t.Errorf("overall loss = %+v", a.Overall)
+	}
+	if len(a.Fields) != 1 || a.Fields[0].Tag != "245" || !near(a.Fields[0].Scores[StageFull], 0.6) {
+		t.Errorf("fields = %+v", a.Fields)
+	}
+
+	if a := Attribute(mapping, roundTrip, nil); a.HasFull || a.Records != 2 || a.Overall.Vision != 0 {
+		t.Errorf("without a full run: %+v", a)
+	}
+}
@@ -0,0 +1,156 @@
+// Package schema versions the eval file formats (dataset.json, run results, eval YAML) and
+// migrates files written by older versions as they are loaded
+package schema
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Key is the version field of every versioned document
+const Key = "schema_version"
+
+// Step migrates a document from one version to the next. Doc changes the top level and Record
+// changes each record in the document's records list; either may be nil.
+type Step struct {
+	Doc    func(doc map[string]any) error
+	Record func(rec map[string]any) error
+}
+
+// Schema is a versioned file format. Files without a version are version 0.
+type Schema struct {
+	Name       string
+	RecordsKey string // Key of the records list, migrated with the steps' Record functions
+	Steps      []Step // Steps[i] migrates version i to i+1, so the current version is len(Steps)
+}
+
+// Version returns the current version of the format
+func (s Schema) Version() int {
+	return len(s.Steps)
+}
+
+// VersionOf returns a document's version
+func (s Schema) VersionOf(doc map[string]any) (int, error) {
+	v, ok := doc[Key]
+	if !ok || v == nil {
+		return 0, nil
+	}
+	var version int
+	switch n := v.(type) {
+	case float64:
+		version = int(n)
+	case int:
+		version = n
+	case json.Number:
+		i, err := n.Int64()
+		if err != nil {
+			return 0, fmt.Errorf("invalid %s %s %v", s.Name, Key, v)
+		}
+		version = int(i)
+	default:
+		return 0, fmt.Errorf("invalid %s %s %v", s.Name, Key, v)
+	}
+	if version < 0 {
+		return 0, fmt.Errorf("invalid %s %s %d", s.Name, Key, version)
+	}
+	if version > s.Version() {
+		return 0, fmt.Errorf("%s %s %d is newer than this build reads (%d); upgrade cataloger", s.Name, Key, version, s.Version())
+	}
+	return version, nil
+}
+
+// MigrateHeader migrates a document's top level, but not its records, to the current version
+// and returns the version it was at. Documents whose records are streamed migrate each with
+// MigrateRecord.
+func (s Schema) MigrateHeader(doc map[string]any) (int, error) {
+	from, err := s.VersionOf(doc)
+	if err != nil {
+		return 0, err
+	}
+	for _, step := range s.Steps[from:] {
+		if step.Doc == nil {
+			continue
+		}
+		if err := step.Doc(doc); err != nil {
+			return 0, fmt.Errorf("failed to migrate %s: %w", s.Name, err)
+		}
+	}
+	doc[Key] = s.Version()
+	return from, nil
+}
+
+// MigrateRecord migrates a record of a document at version from to the current version
+func (s Schema) MigrateRecord(from int, rec map[string]any) error {
+	for _, step := range s.Steps[from:] {
+		if step.Record == nil {
+			continue
+		}
+		if err := step.Record(rec); err != nil {
+			return fmt.Errorf("failed to migrate %s record: %w", s.Name, err)
+		}
+	}
+	return nil
+}
+
+// Migrate migrates a whole document, records included, to the current version and returns the
+// version it was at
+func (s Schema) Migrate(doc map[string]any) (int, error) {
+	from, err := s.MigrateHeader(doc)
+	if err != nil || from == s.Version() {
+		return from, err
+	}
+	records, _ := doc[s.RecordsKey].([]any)
+	for _, r := range records {
+		if rec, ok := r.(map[string]any); ok {
+			if err := s.MigrateRecord(from, rec); err != nil {
+				return from, err
+			}
+		}
+	}
+	return from, nil
+}
+
+// DecodeJSON migrates a JSON document and decodes it into v, returning the version it was at
+func (s Schema) DecodeJSON(data []byte, v any) (int, error) {
+	var doc map[string]any
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return 0, err
+	}
+	from, err := s.Migrate(doc)
+	if err != nil {
+		return 0, err
+	}
+	if from == s.Version() {
+		return from, json.Unmarshal(data, v)
+	}
+	migrated, err := json.Marshal(doc)
+	if err != nil {
+		return 0, err
+	}
+	return from, json.Unmarshal(migrated, v)
+}
+
+// DecodeYAML migrates a YAML document and decodes it into v, returning the version it was at
+func (s Schema) DecodeYAML(data []byte, v any) (int, error) {
+	var doc map[string]any
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return 0, err
+	}
+	if doc == nil {
+		doc = map[string]any{}
+	}
+	from, err := s.Migrate(doc)
+	if err != nil {
+		return 0, err
+	}
+	if from == s.Version() {
+		return from, yaml.Unmarshal(data, v)
+	}
+	migrated, err := yaml.Marshal(doc)
+	if err != nil {
+		return 0, err
+	}
+	return from, yaml.Unmarshal(migrated, v)
+}
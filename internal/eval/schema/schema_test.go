@@ -0,0 +1,86 @@
+package schema
+
+import (
+	"strings"
+	"testing"
+)
+
+// testSchema renamed "name" to "title" in version 1 and added a default "kind" to records in version 2
+var testSchema = Schema{
+	Name:       "test",
+	RecordsKey: "records",
+	Steps: []Step{
+		{Doc: func(doc map[string]any) error {
+			if name, ok := doc["name"]; ok {
+				doc["title"] = name
+				delete(doc, "name")
+			}
+			return nil
+		}},
+		{Record: func(rec map[string]any) error {
+			if _, ok := rec["kind"]; !ok {
+				rec["kind"] = "book"
+			}
+			return nil
+		}},
+	},
+}
+
+type testDoc struct {
+	SchemaVersion int    `json:"schema_version" yaml:"schema_version"`
+	Title         string `json:"title" yaml:"title"`
+	Records       []struct {
+		ID   string `json:"id" yaml:"id"`
+		Kind string `json:"kind" yaml:"kind"`
+	} `json:"records" yaml:"records"`
+}
+
+func TestDecodeJSON(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     string
+		wantFrom int
+		want     string // title/kind
+	}{
+		{"unversioned", `{"name":"old","records":[{"id":"1"}]}`, 0, "old/book"},
+		{"version 1", `{"schema_version":1,"title":"mid","records":[{"id":"1"}]}`, 1, "mid/book"},
+		{"current", `{"schema_version":2,"title":"new","records":[{"id":"1","kind":"map"}]}`, 2, "new/map"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var doc testDoc
+			from, err := testSchema.DecodeJSON([]byte(tt.data), &doc)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if from != tt.wantFrom {
+				t.Errorf("from = %d, want %d", from, tt.wantFrom)
+			}
+			if got := doc.Title + "/" + doc.Records[0].Kind; got != tt.want {
+				t.Errorf("decoded %s, want %s", got, tt.want)
+			}
+			if doc.SchemaVersion != testSchema.Version() {
+				t.Errorf("SchemaVersion = %d, want %d", doc.SchemaVersion, testSchema.Version())
+			}
+		})
+	}
+}
+
+func TestDecodeYAML(t *testing.T) {
+	var doc testDoc
+	from, err := testSchema.DecodeYAML([]byte("name: old\nrecords:\n  - id: \"1\"\n"), &doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if from != 0 || doc.Title != "old" || doc.Records[0].Kind != "book" {
+		t.Errorf("DecodeYAML = %d, %+v", from, doc)
+	}
+}
+
+func TestNewerVersion(t *testing.T) {
+	var doc testDoc
+	_, err := testSchema.DecodeJSON([]byte(`{"schema_version":3}`), &doc)
+	if err == nil || !strings.Contains(err.Error(), "newer") {
+		t.Errorf("DecodeJSON of a newer version = %v, want an upgrade error", err)
+	}
+}
@@ -0,0 +1,216 @@
+package scorerplugin
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/lehigh-university-libraries/cataloger/internal/eval/marceval"
+)
+
+// EnvVar lists plugin commands, separated by semicolons
+const EnvVar = "SCORER_PLUGINS"
+
+// request is one line sent to a plugin
+type request struct {
+	Method   string   `json:"method"` // "describe", "normalize" or "score"
+	Tag      string   `json:"tag,omitempty"`
+	Text     string   `json:"text,omitempty"`
+	Expected []string `json:"expected,omitempty"`
+	Actual   []string `json:"actual,omitempty"`
+}
+
+// response is one line read from a plugin
+type response struct {
+	Error string `json:"error,omitempty"`
+
+	// describe
+	Name      string             `json:"name,omitempty"`
+	Weights   map[string]float64 `json:"weights,omitempty"`
+	Normalize []string           `json:"normalize,omitempty"` // Tags the plugin normalizes
+	Score     []string           `json:"score,omitempty"`     // Tags the plugin scores
+
+	// normalize
+	Text string `json:"text,omitempty"`
+
+	// score
+	Value *float64 `json:"value,omitempty"`
+}
+
+// Plugin is a scorer running as a subprocess. The plugin reads one JSON request per line
+// on stdin and writes one JSON response per line on stdout:
+//
+//	{"method":"describe"}
+//	  -> {"name":"local-callnumbers","weights":{"090":1},"normalize":["090"],"score":["090"]}
+//	{"method":"normalize","tag":"090","text":"QA76.73 .G63 2020"}
+//	  -> {"text":"qa76.73 g63 2020"}
+//	{"method":"score","tag":"090","expected":["QA76.73 .G63 2020"],"actual":["QA76.73.G63 2020"]}
+//	  -> {"value":1}
+//
+// Any response may instead be {"error":"..."}, in which case builtin comparison is used.
+// Anything the plugin writes to stderr is passed through.
+type Plugin struct {
+	Command string
+	desc    response
+
+	mu     sync.Mutex // One request at a time
+	cmd    *exec.Cmd
+	stdin  io.WriteCloser
+	stdout *bufio.Reader
+}
+
+// Start launches a plugin command (the program and its arguments separated by spaces) and
+// asks it to describe itself
+func Start(command string) (*Plugin, error) {
+	args := strings.Fields(command)
+	if len(args) == 0 {
+		return nil, fmt.Errorf("empty scorer plugin command")
+	}
+
+	cmd := exec.Command(args[0], args[1:]...)
+	cmd.Stderr = os.Stderr
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, fmt.Errorf("failed to start scorer plugin %s: %w", command, err)
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, fmt.Errorf("failed to start scorer plugin %s: %w", command, err)
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start scorer plugin %s: %w", command, err)
+	}
+
+	p := &Plugin{Command: command, cmd: cmd, stdin: stdin, stdout: bufio.NewReader(stdout)}
+	desc, err := p.call(request{Method: "describe"})
+	if err != nil {
+		p.Close()
+		return nil, fmt.Errorf("scorer plugin %s failed to describe itself: %w", command, err)
+	}
+	if desc.Name == "" || len(desc.Weights) == 0 {
+		p.Close()
+		return nil, fmt.Errorf("scorer plugin %s must report a name and weights", command)
+	}
+	p.desc = desc
+	return p, nil
+}
+
+// Name is the name the plugin reported
+func (p *Plugin) Name() string {
+	return p.desc.Name
+}
+
+// Extension adapts the plugin for marceval.Register
+func (p *Plugin) Extension() marceval.Extension {
+	ext := marceval.Extension{Name: p.desc.Name, Weights: p.desc.Weights}
+	if len(p.desc.Normalize) > 0 {
+		ext.Normalize = func(tag, text string) (string, error) {
+			if !slices.Contains(p.desc.Normalize, tag) {
+				return "", marceval.ErrSkip
+			}
+			resp, err := p.call(request{Method: "normalize", Tag: tag, Text: text})
+			if err != nil {
+				return "", err
+			}
+			return resp.Text, nil
+		}
+	}
+	if len(p.desc.Score) > 0 {
+		ext.Score = func(tag string, expected, actual []string) (float64, error) {
+			if !slices.Contains(p.desc.Score, tag) {
+				return 0, marceval.ErrSkip
+			}
+			resp, err := p.call(request{Method: "score", Tag: tag, Expected: expected, Actual: actual})
+			if err != nil {
+				return 0, err
+			}
+			if resp.Value == nil {
+				return 0, fmt.Errorf("score response has no value")
+			}
+			return *resp.Value, nil
+		}
+	}
+	return ext
+}
+
+func (p *Plugin) call(req request) (response, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	line, err := json.Marshal(req)
+	if err != nil {
+		return response{}, err
+	}
+	if _, err := p.stdin.Write(append(line, '\n')); err != nil {
+		return response{}, fmt.Errorf("failed to write to plugin: %w", err)
+	}
+
+	out, err := p.stdout.ReadBytes('\n')
+	if err != nil {
+		return response{}, fmt.Errorf("failed to read from plugin: %w", err)
+	}
+	var resp response
+	if err := json.Unmarshal(out, &resp); err != nil {
+		return response{}, fmt.Errorf("invalid plugin response %q: %w", strings.TrimSpace(string(out)), err)
+	}
+	if resp.Error != "" {
+		return response{}, errors.New(resp.Error)
+	}
+	return resp, nil
+}
+
+// Close ends the plugin by closing its stdin, killing it if it has not exited within a second
+func (p *Plugin) Close() error {
+	p.stdin.Close()
+	done := make(chan error, 1)
+	go func() { done <- p.cmd.Wait() }()
+	select {
+	case err := <-done:
+		return err
+	case <-time.After(time.Second):
+		p.cmd.Process.Kill()
+		return <-done
+	}
+}
+
+// StartAll starts each command and registers it with marceval. Commands default to those
+// in SCORER_PLUGINS. The returned function unregisters and stops the plugins.
+func StartAll(commands []string) (func(), error) {
+	if len(commands) == 0 {
+		for _, c := range strings.Split(os.Getenv(EnvVar), ";") {
+			if c = strings.TrimSpace(c); c != "" {
+				commands = append(commands, c)
+			}
+		}
+	}
+
+	var plugins []*Plugin
+	stop := func() {
+		for _, p := range plugins {
+			marceval.Unregister(p.Name())
+			p.Close()
+		}
+	}
+	for _, command := range commands {
+		p, err := Start(command)
+		if err != nil {
+			stop()
+			return nil, err
+		}
+		if err := marceval.Register(p.Extension()); err != nil {
+			p.Close()
+			stop()
+			return nil, fmt.Errorf("failed to register scorer plugin %s: %w", command, err)
+		}
+		plugins = append(plugins, p)
+	}
+	return stop, nil
+}
@@ -0,0 +1,79 @@
+package scorerplugin
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/lehigh-university-libraries/cataloger/internal/eval/marceval"
+	"github.com/lehigh-university-libraries/cataloger/internal/marc"
+)
+
+// TestMain runs the test binary as a plugin when SCORER_PLUGIN_TEST is set
+func TestMain(m *testing.M) {
+	if os.Getenv("SCORER_PLUGIN_TEST") == "1" {
+		runTestPlugin()
+		return
+	}
+	os.Exit(m.Run())
+}
+
+// runTestPlugin scores 090 by exact match after removing spaces and periods
+func runTestPlugin() {
+	strip := strings.NewReplacer(" ", "", ".", "")
+	in := bufio.NewScanner(os.Stdin)
+	out := json.NewEncoder(os.Stdout)
+	for in.Scan() {
+		var req request
+		if err := json.Unmarshal(in.Bytes(), &req); err != nil {
+			out.Encode(response{Error: err.Error()})
+			continue
+		}
+		switch req.Method {
+		case "describe":
+			out.Encode(response{Name: "test-callnumbers", Weights: map[string]float64{"090": 1}, Normalize: []string{"090"}, Score: []string{"090"}})
+		case "normalize":
+			out.Encode(response{Text: strings.ToLower(strip.Replace(req.Text))})
+		case "score":
+			value := 0.0
+			if len(req.Expected) > 0 && len(req.Actual) > 0 && strip.Replace(req.Expected[0]) == strip.Replace(req.Actual[0]) {
+				value = 1
+			}
+			out.Encode(response{Value: &value})
+		default:
+			out.Encode(response{Error: fmt.Sprintf("unknown method %q", req.Method)})
+		}
+	}
+}
+
+func TestStartAll(t *testing.T) {
+	t.Setenv("SCORER_PLUGIN_TEST", "1")
+	stop, err := StartAll([]string{os.Args[0]})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	reference, _ := marc.ParseMnemonic("=090  \\\\$aQA76.73.G63$bS55 2020")
+	generated, _ := marc.ParseMnemonic("=090  \\\\$aQA76.73 .G63$bS55 2020")
+	fs, ok := marceval.Compare(reference, generated).Fields["090"]
+	if !ok || fs.Score != 1 || fs.Scorer != "test-callnumbers" {
+		t.Errorf("090 = %+v, want scored 1 by test-callnumbers", fs)
+	}
+
+	stop()
+	if len(marceval.Extensions()) != 0 {
+		t.Errorf("extensions still registered after stop: %v", marceval.Extensions())
+	}
+}
+
+func TestStartRejectsInvalidPlugin(t *testing.T) {
+	if _, err := Start("cat"); err == nil {
+		t.Error("Start() of a program that echoes requests succeeded")
+	}
+	if _, err := Start("/nonexistent/plugin"); err == nil {
+		t.Error("Start() of a missing program succeeded")
+	}
+}
@@ -0,0 +1,104 @@
+package selftest
+
+import (
+	_ "embed"
+	"fmt"
+	"math"
+	"os"
+
+	"github.com/lehigh-university-libraries/cataloger/internal/eval/marceval"
+	"github.com/lehigh-university-libraries/cataloger/internal/marc"
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultTolerance is the allowed absolute score difference when a case sets none
+const DefaultTolerance = 0.0005
+
+//go:embed corpus.yaml
+var builtinCorpus []byte
+
+// Case is a reference/generated MARC pair with the score the comparator should give
+type Case struct {
+	Name        string  `yaml:"name"`
+	Description string  `yaml:"description"`
+	Expected    float64 `yaml:"expected"`
+	Tolerance   float64 `yaml:"tolerance,omitempty"`
+	// Expected share of correct indicators, checked only when set
+	ExpectedIndicators *float64 `yaml:"expected_indicators,omitempty"`
+	Reference          string   `yaml:"reference"`
+	Generated          string   `yaml:"generated"`
+}
+
+// Result is the outcome of running one case
+type Result struct {
+	Case       Case
+	Actual     float64
+	Indicators float64 // Share of correct indicators, 1 when none were checked
+	Pass       bool
+	Comparison *marceval.Comparison
+	Error      string
+}
+
+// Builtin returns the corpus shipped with cataloger
+func Builtin() ([]Case, error) {
+	return parseCorpus(builtinCorpus)
+}
+
+// LoadCorpus reads a corpus file in the same format as the builtin corpus
+func LoadCorpus(path string) ([]Case, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read corpus: %w", err)
+	}
+	return parseCorpus(data)
+}
+
+func parseCorpus(data []byte) ([]Case, error) {
+	var cases []Case
+	if err := yaml.Unmarshal(data, &cases); err != nil {
+		return nil, fmt.Errorf("failed to parse corpus: %w", err)
+	}
+	return cases, nil
+}
+
+// Run scores every case and reports whether it matched its expected score
+func Run(cases []Case) []Result {
+	results := make([]Result, 0, len(cases))
+	for _, c := range cases {
+		results = append(results, runCase(c))
+	}
+	return results
+}
+
+func runCase(c Case) Result {
+	result := Result{Case: c}
+
+	reference, err := marc.ParseMnemonic(c.Reference)
+	if err != nil {
+		result.Error = fmt.Sprintf("reference: %v", err)
+		return result
+	}
+	generated, err := marc.ParseMnemonic(c.Generated)
+	if err != nil {
+		result.Error = fmt.Sprintf("generated: %v", err)
+		return result
+	}
+
+	tolerance := c.Tolerance
+	if tolerance == 0 {
+		tolerance = DefaultTolerance
+	}
+
+	result.Comparison = marceval.Compare(reference, generated)
+	result.Actual = result.Comparison.Score
+	result.Pass = math.Abs(result.Actual-c.Expected) <= tolerance
+
+	result.Indicators = 1
+	if acc, ok := result.Comparison.IndicatorAccuracy(); ok {
+		result.Indicators = acc
+	}
+	if c.ExpectedIndicators != nil && math.Abs(result.Indicators-*c.ExpectedIndicators) > tolerance {
+		result.Pass = false
+	}
+	return result
+}
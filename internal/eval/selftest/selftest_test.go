@@ -0,0 +1,24 @@
+package selftest
+
+import "testing"
+
+// TestBuiltinCorpus keeps the golden corpus passing so metric changes are deliberate
+func TestBuiltinCorpus(t *testing.T) {
+	cases, err := Builtin()
+	if err != nil {
+		t.Fatalf("Builtin() error: %v", err)
+	}
+	if len(cases) == 0 {
+		t.Fatal("builtin corpus is empty")
+	}
+
+	for _, r := range Run(cases) {
+		if r.Error != "" {
+			t.Errorf("%s: %s", r.Case.Name, r.Error)
+			continue
+		}
+		if !r.Pass {
+			t.Errorf("%s: score %.4f, expected %.4f (indicators %.4f)", r.Case.Name, r.Actual, r.Case.Expected, r.Indicators)
+		}
+	}
+}
@@ -0,0 +1,123 @@
+package trendchart
+
+import (
+	"sort"
+	"time"
+)
+
+// Run is the summary of one eval run that trend charts are drawn from
+type Run struct {
+	Time          time.Time
+	Provider      string
+	Model         string
+	PromptVersion string
+	MeanScore     float64
+	FieldMeans    map[string]float64
+}
+
+// Key names the model a run used
+func (r Run) Key() string {
+	if r.Model == "" || r.Model == r.Provider {
+		return r.Provider
+	}
+	return r.Provider + "/" + r.Model
+}
+
+func (r Run) point(v float64) Point {
+	return Point{Time: r.Time, Value: v, Note: r.PromptVersion}
+}
+
+// Models returns the model keys of the runs, sorted
+func Models(runs []Run) []string {
+	seen := make(map[string]bool)
+	var keys []string
+	for _, r := range runs {
+		if !seen[r.Key()] {
+			seen[r.Key()] = true
+			keys = append(keys, r.Key())
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Fields returns the tags scored in any run, sorted
+func Fields(runs []Run) []string {
+	seen := make(map[string]bool)
+	var tags []string
+	for _, r := range runs {
+		for tag := range r.FieldMeans {
+			if !seen[tag] {
+				seen[tag] = true
+				tags = append(tags, tag)
+			}
+		}
+	}
+	sort.Strings(tags)
+	return tags
+}
+
+// Overall charts each model's mean score
+func Overall(runs []Run) Chart {
+	return byModel("Mean score by model", runs, func(r Run) (float64, bool) { return r.MeanScore, true })
+}
+
+// Field charts each model's mean score on one tag, over the runs that scored it
+func Field(runs []Run, tag string) Chart {
+	return byModel("Field "+tag+" by model", runs, func(r Run) (float64, bool) {
+		v, ok := r.FieldMeans[tag]
+		return v, ok
+	})
+}
+
+// Model charts one model's mean score and its score on each tag
+func Model(runs []Run, key string) Chart {
+	c := Chart{Title: key + " by field"}
+	overall := Series{Name: "overall"}
+	fields := make(map[string]*Series)
+	for _, r := range runs {
+		if r.Key() != key {
+			continue
+		}
+		overall.Points = append(overall.Points, r.point(r.MeanScore))
+		for tag, v := range r.FieldMeans {
+			s, ok := fields[tag]
+			if !ok {
+				s = &Series{Name: tag}
+				fields[tag] = s
+			}
+			s.Points = append(s.Points, r.point(v))
+		}
+	}
+	for _, s := range fields {
+		c.Series = append(c.Series, *s)
+	}
+	c.Sort()
+	// Overall comes first, so it gets the first color and legend entry
+	overallChart := Chart{Series: []Series{overall}}
+	overallChart.Sort()
+	c.Series = append(overallChart.Series, c.Series...)
+	return c
+}
+
+func byModel(title string, runs []Run, value func(Run) (float64, bool)) Chart {
+	c := Chart{Title: title}
+	byKey := make(map[string]*Series)
+	for _, r := range runs {
+		v, ok := value(r)
+		if !ok {
+			continue
+		}
+		s, ok := byKey[r.Key()]
+		if !ok {
+			s = &Series{Name: r.Key()}
+			byKey[r.Key()] = s
+		}
+		s.Points = append(s.Points, r.point(v))
+	}
+	for _, s := range byKey {
+		c.Series = append(c.Series, *s)
+	}
+	c.Sort()
+	return c
+}
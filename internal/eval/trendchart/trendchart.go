@@ -0,0 +1,255 @@
+// Package trendchart draws line charts of scores over time, as SVG for HTML reports and as
+// PNG images, without a charting library
+package trendchart
+
+import (
+	"fmt"
+	"html"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"io"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/basicfont"
+	"golang.org/x/image/math/fixed"
+)
+
+// Chart size and plot margins, in pixels. The right margin holds the legend.
+const (
+	width        = 880
+	height       = 360
+	marginLeft   = 50
+	marginRight  = 220
+	marginTop    = 30
+	marginBottom = 40
+	maxLegend    = 18 // Series beyond this are drawn but not named
+)
+
+// palette is the series colors, repeated when there are more series
+var palette = []color.RGBA{
+	{0x1f, 0x77, 0xb4, 0xff}, {0xff, 0x7f, 0x0e, 0xff}, {0x2c, 0xa0, 0x2c, 0xff},
+	{0xd6, 0x27, 0x28, 0xff}, {0x94, 0x67, 0xbd, 0xff}, {0x8c, 0x56, 0x4b, 0xff},
+	{0xe3, 0x77, 0xc2, 0xff}, {0x7f, 0x7f, 0x7f, 0xff}, {0xbc, 0xbd, 0x22, 0xff},
+	{0x17, 0xbe, 0xcf, 0xff},
+}
+
+// Point is one run's value
+type Point struct {
+	Time  time.Time
+	Value float64 // 0-1
+	Note  string  // Shown when hovering over the point in SVG, e.g. the prompt version
+}
+
+// Series is one line of a chart
+type Series struct {
+	Name   string
+	Points []Point
+}
+
+// Chart is a set of series over time, with values from 0 to 1
+type Chart struct {
+	Title  string
+	Series []Series
+}
+
+// Sort orders each series' points by time and the series by name
+func (c *Chart) Sort() {
+	for _, s := range c.Series {
+		sort.SliceStable(s.Points, func(i, j int) bool { return s.Points[i].Time.Before(s.Points[j].Time) })
+	}
+	sort.SliceStable(c.Series, func(i, j int) bool { return c.Series[i].Name < c.Series[j].Name })
+}
+
+// timeRange returns the earliest and latest point times
+func (c Chart) timeRange() (time.Time, time.Time) {
+	var first, last time.Time
+	for _, s := range c.Series {
+		for _, p := range s.Points {
+			if first.IsZero() || p.Time.Before(first) {
+				first = p.Time
+			}
+			if p.Time.After(last) {
+				last = p.Time
+			}
+		}
+	}
+	return first, last
+}
+
+// scale maps times and values to pixel coordinates in the plot area
+type scale struct {
+	first time.Time
+	span  time.Duration
+}
+
+func (c Chart) scale() scale {
+	first, last := c.timeRange()
+	return scale{first: first, span: last.Sub(first)}
+}
+
+func (s scale) x(t time.Time) float64 {
+	plot := float64(width - marginLeft - marginRight)
+	if s.span <= 0 {
+		return marginLeft + plot/2
+	}
+	return marginLeft + plot*float64(t.Sub(s.first))/float64(s.span)
+}
+
+func (s scale) y(v float64) float64 {
+	v = math.Max(0, math.Min(1, v))
+	return marginTop + float64(height-marginTop-marginBottom)*(1-v)
+}
+
+// xTicks returns up to n evenly spaced times across the range
+func (s scale) xTicks(n int) []time.Time {
+	if s.span <= 0 {
+		return []time.Time{s.first}
+	}
+	ticks := make([]time.Time, n)
+	for i := range n {
+		ticks[i] = s.first.Add(time.Duration(float64(s.span) * float64(i) / float64(n-1)))
+	}
+	return ticks
+}
+
+// tickLabel formats a tick time, with the time of day when the runs span less than two days
+func (s scale) tickLabel(t time.Time) string {
+	if s.span < 48*time.Hour {
+		return t.Format("01-02 15:04")
+	}
+	return t.Format("2006-01-02")
+}
+
+func hexColor(c color.RGBA) string {
+	return fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B)
+}
+
+// SVG renders the chart as an inline SVG element
+func (c Chart) SVG() string {
+	sc := c.scale()
+	var b strings.Builder
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" font-family="sans-serif" font-size="12">`+"\n", width, height, width, height)
+	fmt.Fprintf(&b, `<rect width="%d" height="%d" fill="white"/>`+"\n", width, height)
+	fmt.Fprintf(&b, `<text x="%d" y="18" font-size="14" font-weight="bold">%s</text>`+"\n", marginLeft, html.EscapeString(c.Title))
+
+	for i := 0; i <= 5; i++ {
+		v := float64(i) / 5
+		y := sc.y(v)
+		fmt.Fprintf(&b, `<line x1="%d" y1="%.1f" x2="%d" y2="%.1f" stroke="#ddd"/>`+"\n", marginLeft, y, width-marginRight, y)
+		fmt.Fprintf(&b, `<text x="%d" y="%.1f" text-anchor="end">%d%%</text>`+"\n", marginLeft-6, y+4, i*20)
+	}
+	for _, t := range sc.xTicks(5) {
+		fmt.Fprintf(&b, `<text x="%.1f" y="%d" text-anchor="middle">%s</text>`+"\n", sc.x(t), height-marginBottom+18, sc.tickLabel(t))
+	}
+
+	for i, s := range c.Series {
+		col := hexColor(palette[i%len(palette)])
+		points := make([]string, len(s.Points))
+		for j, p := range s.Points {
+			points[j] = fmt.Sprintf("%.1f,%.1f", sc.x(p.Time), sc.y(p.Value))
+		}
+		if len(points) > 1 {
+			fmt.Fprintf(&b, `<polyline fill="none" stroke="%s" stroke-width="2" points="%s"/>`+"\n", col, strings.Join(points, " "))
+		}
+		for _, p := range s.Points {
+			tip := fmt.Sprintf("%s %s: %.1f%%", s.Name, p.Time.Format("2006-01-02 15:04"), p.Value*100)
+			if p.Note != "" {
+				tip += " (" + p.Note + ")"
+			}
+			fmt.Fprintf(&b, `<circle cx="%.1f" cy="%.1f" r="3" fill="%s"><title>%s</title></circle>`+"\n", sc.x(p.Time), sc.y(p.Value), col, html.EscapeString(tip))
+		}
+		if i < maxLegend {
+			y := marginTop + 16*i
+			fmt.Fprintf(&b, `<rect x="%d" y="%d" width="10" height="10" fill="%s"/>`+"\n", width-marginRight+12, y, col)
+			fmt.Fprintf(&b, `<text x="%d" y="%d">%s</text>`+"\n", width-marginRight+28, y+9, html.EscapeString(truncate(s.Name, 30)))
+		}
+	}
+	b.WriteString("</svg>")
+	return b.String()
+}
+
+// PNG renders the chart as a PNG image
+func (c Chart) PNG(w io.Writer) error {
+	sc := c.scale()
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(img, img.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+	grid := color.RGBA{0xdd, 0xdd, 0xdd, 0xff}
+	text := color.RGBA{0x22, 0x22, 0x22, 0xff}
+
+	drawText(img, marginLeft, 18, c.Title, text)
+	for i := 0; i <= 5; i++ {
+		y := sc.y(float64(i) / 5)
+		drawLine(img, marginLeft, y, width-marginRight, y, grid, 1)
+		label := fmt.Sprintf("%d%%", i*20)
+		drawText(img, marginLeft-6-7*len(label), int(y)+4, label, text)
+	}
+	for _, t := range sc.xTicks(5) {
+		label := sc.tickLabel(t)
+		drawText(img, int(sc.x(t))-7*len(label)/2, height-marginBottom+18, label, text)
+	}
+
+	for i, s := range c.Series {
+		col := palette[i%len(palette)]
+		for j := 1; j < len(s.Points); j++ {
+			a, b := s.Points[j-1], s.Points[j]
+			drawLine(img, sc.x(a.Time), sc.y(a.Value), sc.x(b.Time), sc.y(b.Value), col, 2)
+		}
+		for _, p := range s.Points {
+			x, y := int(sc.x(p.Time)), int(sc.y(p.Value))
+			draw.Draw(img, image.Rect(x-3, y-3, x+4, y+4), image.NewUniform(col), image.Point{}, draw.Src)
+		}
+		if i < maxLegend {
+			y := marginTop + 16*i
+			draw.Draw(img, image.Rect(width-marginRight+12, y, width-marginRight+22, y+10), image.NewUniform(col), image.Point{}, draw.Src)
+			drawText(img, width-marginRight+28, y+10, truncate(s.Name, 26), text)
+		}
+	}
+
+	if err := png.Encode(w, img); err != nil {
+		return fmt.Errorf("failed to encode chart: %w", err)
+	}
+	return nil
+}
+
+// drawLine draws a line of the given thickness, stepping one pixel along the longer axis
+func drawLine(img *image.RGBA, x0, y0, x1, y1 float64, col color.RGBA, thickness int) {
+	steps := int(math.Max(math.Abs(x1-x0), math.Abs(y1-y0)))
+	for i := 0; i <= steps; i++ {
+		t := 0.0
+		if steps > 0 {
+			t = float64(i) / float64(steps)
+		}
+		x := int(math.Round(x0 + (x1-x0)*t))
+		y := int(math.Round(y0 + (y1-y0)*t))
+		for dx := range thickness {
+			for dy := range thickness {
+				img.SetRGBA(x+dx, y+dy, col)
+			}
+		}
+	}
+}
+
+// drawText draws text with its baseline at y
+func drawText(img *image.RGBA, x, y int, s string, col color.RGBA) {
+	d := font.Drawer{
+		Dst:  img,
+		Src:  image.NewUniform(col),
+		Face: basicfont.Face7x13,
+		Dot:  fixed.P(x, y),
+	}
+	d.DrawString(s)
+}
+
+func truncate(s string, n int) string {
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+	return string(r[:n-3]) + "..."
+}
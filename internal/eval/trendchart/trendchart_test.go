@@ -0,0 +1,77 @@
+package trendchart
+
+import (
+	"bytes"
+	"image/png"
+	"strings"
+	"testing"
+	"time"
+)
+
+func testRuns() []Run {
+	day := time.Date(2025, 1, 1, 3, 0, 0, 0, time.UTC)
+	return []Run{
+		{Time: day.AddDate(0, 1, 0), Provider: "ollama", Model: "a", MeanScore: 0.8, FieldMeans: map[string]float64{"245": 0.9, "100": 0.7}},
+		{Time: day, Provider: "ollama", Model: "a", PromptVersion: "v1", MeanScore: 0.7, FieldMeans: map[string]float64{"245": 0.8}},
+		{Time: day, Provider: "mock", Model: "mock", MeanScore: 0.5, FieldMeans: map[string]float64{"245": 0.5}},
+	}
+}
+
+func TestCharts(t *testing.T) {
+	runs := testRuns()
+	if got := strings.Join(Models(runs), ","); got != "mock,ollama/a" {
+		t.Errorf("Models() = %s", got)
+	}
+	if got := strings.Join(Fields(runs), ","); got != "100,245" {
+		t.Errorf("Fields() = %s", got)
+	}
+
+	overall := Overall(runs)
+	if len(overall.Series) != 2 || overall.Series[1].Name != "ollama/a" {
+		t.Fatalf("Overall() series = %+v", overall.Series)
+	}
+	if pts := overall.Series[1].Points; len(pts) != 2 || pts[0].Value != 0.7 || pts[0].Note != "v1" {
+		t.Errorf("Overall() points = %+v, want oldest first", pts)
+	}
+
+	field := Field(runs, "100")
+	if len(field.Series) != 1 || len(field.Series[0].Points) != 1 {
+		t.Errorf("Field(100) = %+v, want only runs that scored it", field.Series)
+	}
+
+	model := Model(runs, "ollama/a")
+	var names []string
+	for _, s := range model.Series {
+		names = append(names, s.Name)
+	}
+	if got := strings.Join(names, ","); got != "overall,100,245" {
+		t.Errorf("Model() series = %s", got)
+	}
+}
+
+func TestRender(t *testing.T) {
+	c := Overall(testRuns())
+	c.Title = "Scores <by model>"
+	svg := c.SVG()
+	if !strings.HasPrefix(svg, "<svg") || !strings.Contains(svg, "&lt;by model&gt;") || !strings.Contains(svg, "<polyline") {
+		t.Errorf("SVG() = %s", svg)
+	}
+
+	var buf bytes.Buffer
+	if err := c.PNG(&buf); err != nil {
+		t.Fatal(err)
+	}
+	img, err := png.Decode(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b := img.Bounds(); b.Dx() != width || b.Dy() != height {
+		t.Errorf("PNG size = %v", b)
+	}
+
+	// A single run still renders, centered
+	single := Chart{Series: []Series{{Name: "x", Points: []Point{{Time: time.Now(), Value: 0.5}}}}}
+	if err := single.PNG(&bytes.Buffer{}); err != nil {
+		t.Fatal(err)
+	}
+}
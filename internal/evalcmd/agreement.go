@@ -0,0 +1,175 @@
+package evalcmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/lehigh-university-libraries/cataloger/internal/eval/agreement"
+	"github.com/lehigh-university-libraries/cataloger/internal/eval/marceval"
+	"github.com/lehigh-university-libraries/cataloger/internal/objectstore"
+	"github.com/spf13/cobra"
+)
+
+// agreementOptions holds the flags for the agreement command
+type agreementOptions struct {
+	agreeThreshold     float64
+	referenceThreshold float64
+	outputJSON         string
+	limit              int
+}
+
+// NewAgreementCmd creates the agreement command for comparing models with each other
+func NewAgreementCmd() *cobra.Command {
+	var opts agreementOptions
+
+	cmd := &cobra.Command{
+		Use:   "agreement <results> <results>...",
+		Short: "Measure per-field agreement between models and flag suspect reference fields",
+		Long: `Compare the records several models generated for the same dataset with each other,
+independent of the reference records.
+
+For every field, each pair of models' records is scored with the eval comparator, and the
+table shows the mean pairwise score and on how many records every pair agreed. Where every
+model agrees (lowest pairwise score at least --agree-threshold) but each scored below
+--reference-threshold against the reference, the field is listed as suspect: often the
+reference record itself is wrong.
+
+Only records every run generated are compared. Results files are those of eval run, eval
+score or eval rerun-failures, with blobs read as needed.`,
+		Example: `  # Three models over the same dataset
+  cataloger eval agreement gpt-4o.json gemini.json claude.json
+
+  # Save the suspects for review
+  cataloger eval agreement gpt-4o.json gemini.json --output-json agreement.json`,
+		Args: cobra.MinimumNArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return executeAgreement(args, opts)
+		},
+	}
+
+	cmd.Flags().Float64Var(&opts.agreeThreshold, "agree-threshold", agreement.DefaultAgreeThreshold, "Pairwise field score at which two models agree")
+	cmd.Flags().Float64Var(&opts.referenceThreshold, "reference-threshold", agreement.DefaultReferenceThreshold, "Reference score below which a model disagrees with the reference")
+	cmd.Flags().StringVar(&opts.outputJSON, "output-json", "", "Path to save the analysis as JSON")
+	cmd.Flags().IntVar(&opts.limit, "limit", 20, "Suspect fields to print (0 for all)")
+
+	return cmd
+}
+
+func executeAgreement(paths []string, opts agreementOptions) error {
+	runs := make([]agreement.Run, 0, len(paths))
+	names := make(map[string]int)
+	for _, path := range paths {
+		run, err := loadAgreementRun(path)
+		if err != nil {
+			return err
+		}
+		// Tell apart runs of the same model
+		names[run.Name]++
+		if n := names[run.Name]; n > 1 {
+			run.Name = fmt.Sprintf("%s (%s)", run.Name, filepath.Base(path))
+		}
+		runs = append(runs, run)
+	}
+
+	analysis, err := agreement.Analyze(runs, agreement.Options{
+		AgreeThreshold:     opts.agreeThreshold,
+		ReferenceThreshold: opts.referenceThreshold,
+	})
+	if err != nil {
+		return err
+	}
+	printAgreement(analysis, opts.limit)
+
+	if opts.outputJSON != "" {
+		data, err := json.MarshalIndent(analysis, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to encode agreement analysis: %w", err)
+		}
+		if err := objectstore.WriteFile(context.Background(), opts.outputJSON, data); err != nil {
+			return fmt.Errorf("failed to write agreement analysis: %w", err)
+		}
+		fmt.Printf("\nAgreement analysis saved to: %s\n", opts.outputJSON)
+	}
+	return nil
+}
+
+// loadAgreementRun reads a results file, filling in generated records stored in blobs, and
+// names the run by its provider and model
+func loadAgreementRun(path string) (agreement.Run, error) {
+	var run agreement.Run
+	report, err := marceval.ReadResults(path, func(r marceval.Result) error {
+		if r.GeneratedMARC == "" && r.Blob != "" {
+			blob, err := marceval.ReadBlob(r.BlobPath(path))
+			if err != nil {
+				return fmt.Errorf("failed to read blob of %s: %w", r.ID, err)
+			}
+			r.GeneratedMARC = blob.GeneratedMARC
+		}
+		run.Results = append(run.Results, r)
+		return nil
+	})
+	if err != nil {
+		return run, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	var provider, model string
+	if report != nil {
+		provider, model = report.Provider, report.Model
+	}
+	if provider == "" && len(run.Results) > 0 {
+		provider, model = run.Results[0].Provider, run.Results[0].Model
+	}
+	switch {
+	case provider == "":
+		run.Name = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	case model == "" || model == provider:
+		run.Name = provider
+	default:
+		run.Name = provider + "/" + model
+	}
+	return run, nil
+}
+
+func printAgreement(a agreement.Analysis, limit int) {
+	fmt.Println("\n" + strings.Repeat("=", 90))
+	fmt.Println("INTER-MODEL AGREEMENT")
+	fmt.Println(strings.Repeat("=", 90))
+	for i, name := range a.Models {
+		fmt.Printf("Model %d: %s\n", i+1, name)
+	}
+	if a.Records == 0 {
+		fmt.Println("No records were generated by every model")
+		fmt.Println(strings.Repeat("=", 90))
+		return
+	}
+	fmt.Printf("Records generated by every model: %d\n\n", a.Records)
+
+	fmt.Printf("%-8s %8s %10s %10s %9s\n", "FIELD", "RECORDS", "AGREEMENT", "UNANIMOUS", "SUSPECT")
+	fmt.Println(strings.Repeat("-", 90))
+	for _, f := range a.Fields {
+		fmt.Printf("%-8s %8d %10.3f %10d %9d\n", f.Tag, f.Records, f.Mean, f.Unanimous, f.Suspect)
+	}
+
+	fmt.Println(strings.Repeat("-", 90))
+	if len(a.Suspects) == 0 {
+		fmt.Println("No suspect reference fields")
+		fmt.Println(strings.Repeat("=", 90))
+		return
+	}
+	fmt.Printf("Suspect reference fields (every model agrees, none matches the reference): %d\n", len(a.Suspects))
+	for i, s := range a.Suspects {
+		if limit > 0 && i == limit {
+			fmt.Printf("... and %d more (--limit 0 or --output-json for all)\n", len(a.Suspects)-limit)
+			break
+		}
+		fmt.Printf("\n%s %s", s.ID, s.Tag)
+		if s.Title != "" {
+			fmt.Printf(" (%s)", s.Title)
+		}
+		fmt.Printf("\n  reference: %s\n  models:    %s\n", strings.Join(s.Reference, " | "), strings.Join(s.Consensus, " | "))
+	}
+	fmt.Println(strings.Repeat("=", 90))
+}
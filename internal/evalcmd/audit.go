@@ -0,0 +1,144 @@
+package evalcmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/lehigh-university-libraries/cataloger/internal/eval/dataset"
+	"github.com/lehigh-university-libraries/cataloger/internal/eval/groundtruth"
+	"github.com/lehigh-university-libraries/cataloger/internal/objectstore"
+	"github.com/spf13/cobra"
+)
+
+// auditOptions holds the flags for the audit command
+type auditOptions struct {
+	datasetDir string
+	outputJSON string
+	limit      int
+}
+
+// auditedRecord is a suspect reference record in the audit report
+type auditedRecord struct {
+	ID       string
+	Title    string `json:",omitempty"`
+	Findings []groundtruth.Finding
+}
+
+// auditReport is the JSON written by --output-json
+type auditReport struct {
+	Dataset  string
+	Records  int
+	Suspect  int
+	Findings map[string]int // Finding code -> suspect records with it
+	Suspects []auditedRecord
+}
+
+// NewAuditCmd creates the audit command for checking the reference records of a dataset
+func NewAuditCmd() *cobra.Command {
+	var opts auditOptions
+
+	cmd := &cobra.Command{
+		Use:   "audit",
+		Short: "Audit the reference records of a MARC dataset for suspect ground truth",
+		Long: `Run the validator and consistency checks against the reference records of a MARC
+evaluation dataset, and list the records that are suspect as ground truth:
+
+  invalid_record   a structural validation error (leader, indicators, repeated fields, ...)
+  invalid_isbn     an 020 $a failing its check digit
+  impossible_date  an 008 or imprint date before 1450 or after next year
+  empty_title      no 245, or a 245 with no title
+  inconsistent     a failed cross-field consistency check (008 against imprint, language, ...)
+
+A model that reads the title page correctly loses points for every mistake in the reference,
+so fix or drop these records, or run eval run with --audit-references to leave them out of
+the scores.`,
+		Example: `  # List suspect reference records
+  cataloger eval audit --dataset ./eval_data
+
+  # Save every finding for review
+  cataloger eval audit --dataset ./eval_data --limit 0 --output-json audit.json`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if _, err := os.Stat(opts.datasetDir); os.IsNotExist(err) {
+				return fmt.Errorf("dataset directory not found: %s", opts.datasetDir)
+			}
+			return executeAudit(opts)
+		},
+	}
+
+	cmd.Flags().StringVar(&opts.datasetDir, "dataset", "./eval_data", "Path to MARC evaluation dataset directory")
+	cmd.Flags().StringVar(&opts.outputJSON, "output-json", "", "Path to save the findings as JSON")
+	cmd.Flags().IntVar(&opts.limit, "limit", 20, "Suspect records to print (0 for all)")
+
+	return cmd
+}
+
+func executeAudit(opts auditOptions) error {
+	ds, err := dataset.LoadMARCDataset(opts.datasetDir)
+	if err != nil {
+		return fmt.Errorf("failed to load dataset: %w", err)
+	}
+	references := loadReferences(ds, -1)
+
+	report := auditReport{Dataset: opts.datasetDir, Records: len(references), Findings: make(map[string]int)}
+	now := time.Now()
+	for _, ref := range references {
+		findings := groundtruth.Audit(ref.Record, now)
+		if len(findings) == 0 {
+			continue
+		}
+		report.Suspect++
+		for _, code := range groundtruth.FindingCodes(findings) {
+			report.Findings[code]++
+		}
+		report.Suspects = append(report.Suspects, auditedRecord{ID: ref.Item.ID, Title: ref.Item.Title, Findings: findings})
+	}
+	printAudit(report, opts.limit)
+
+	if opts.outputJSON != "" {
+		data, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to encode audit report: %w", err)
+		}
+		if err := objectstore.WriteFile(context.Background(), opts.outputJSON, data); err != nil {
+			return fmt.Errorf("failed to write audit report: %w", err)
+		}
+		fmt.Printf("\nAudit report saved to: %s\n", opts.outputJSON)
+	}
+	return nil
+}
+
+func printAudit(r auditReport, limit int) {
+	fmt.Println("\n" + strings.Repeat("=", 70))
+	fmt.Println("GROUND-TRUTH AUDIT")
+	fmt.Println(strings.Repeat("=", 70))
+	fmt.Printf("Dataset: %s\n", r.Dataset)
+	fmt.Printf("Reference records: %d\n", r.Records)
+	if r.Records > 0 {
+		fmt.Printf("Suspect: %d (%.1f%%)\n", r.Suspect, float64(r.Suspect)/float64(r.Records)*100)
+	}
+	for _, code := range groundtruth.Codes {
+		if n := r.Findings[code]; n > 0 {
+			fmt.Printf("  %s: %d\n", code, n)
+		}
+	}
+
+	for i, rec := range r.Suspects {
+		if limit > 0 && i == limit {
+			fmt.Printf("\n... and %d more (--limit 0 or --output-json for all)\n", len(r.Suspects)-limit)
+			break
+		}
+		fmt.Printf("\n%s", rec.ID)
+		if rec.Title != "" {
+			fmt.Printf(" (%s)", rec.Title)
+		}
+		fmt.Println()
+		for _, f := range rec.Findings {
+			fmt.Printf("  %-16s %-4s %s\n", f.Code, f.Tag, f.Message)
+		}
+	}
+	fmt.Println(strings.Repeat("=", 70))
+}
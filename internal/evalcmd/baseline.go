@@ -0,0 +1,266 @@
+package evalcmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/lehigh-university-libraries/cataloger/internal/cataloging"
+	"github.com/lehigh-university-libraries/cataloger/internal/eval/baseline"
+	"github.com/lehigh-university-libraries/cataloger/internal/eval/dataset"
+	"github.com/lehigh-university-libraries/cataloger/internal/eval/failure"
+	"github.com/lehigh-university-libraries/cataloger/internal/eval/marceval"
+	"github.com/lehigh-university-libraries/cataloger/internal/images"
+	"github.com/lehigh-university-libraries/cataloger/internal/marc"
+	"github.com/lehigh-university-libraries/cataloger/internal/objectstore"
+	"github.com/spf13/cobra"
+)
+
+// baselineOptions holds the flags for the baseline command
+type baselineOptions struct {
+	datasetDir  string
+	kind        string
+	sampleSize  int
+	rates       []float64
+	dropRate    float64
+	trials      int
+	seed        uint64
+	comparePath string
+	outputJSON  string
+	scorers     []string
+}
+
+// NewBaselineCmd creates the baseline command for anchoring model scores
+func NewBaselineCmd() *cobra.Command {
+	var opts baselineOptions
+
+	cmd := &cobra.Command{
+		Use:   "baseline",
+		Short: "Compute reference baselines that anchor model scores",
+		Long: `Compute baselines for a MARC evaluation dataset.
+
+perturbation: score each reference record against copies of itself with OCR-like character
+noise at several rates, showing what a given similarity score means in terms of errors.
+Pass --compare with an eval run results file to express a model's score as equivalent noise.
+
+naive: build MARC for each item purely from an ISBN lookup in Open Library (falling back to
+Google Books), with no LLM. Pass --compare with an eval run results file to show the model's
+lift over this free, deterministic approach on the records both could score.`,
+		Example: `  # What does a 0.90 score mean?
+  cataloger eval baseline --dataset ./eval_data
+
+  # Custom noise rates, 5 trials per record, with 10% of fields dropped
+  cataloger eval baseline --dataset ./eval_data --rates 0.01,0.03,0.1 --trials 5 --drop-rate 0.1
+
+  # Anchor a model run against the curve
+  cataloger eval baseline --dataset ./eval_data --compare eval_run_results.json
+
+  # How much better is the LLM than an ISBN lookup?
+  cataloger eval baseline --type naive --dataset ./eval_data --compare eval_run_results.json`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if _, err := os.Stat(opts.datasetDir); os.IsNotExist(err) {
+				return fmt.Errorf("dataset directory not found: %s", opts.datasetDir)
+			}
+			return executeBaseline(opts)
+		},
+	}
+
+	cmd.Flags().StringVar(&opts.datasetDir, "dataset", "./eval_data", "Path to MARC evaluation dataset directory")
+	cmd.Flags().StringVar(&opts.kind, "type", "perturbation", "Baseline type (perturbation or naive)")
+	cmd.Flags().IntVar(&opts.sampleSize, "sample", -1, "Number of items to use (-1 for all)")
+	cmd.Flags().Float64SliceVar(&opts.rates, "rates", baseline.DefaultNoiseRates, "Character noise rates to sample")
+	cmd.Flags().Float64Var(&opts.dropRate, "drop-rate", 0, "Probability of dropping each field in perturbed copies")
+	cmd.Flags().IntVar(&opts.trials, "trials", 3, "Perturbed copies per record and rate")
+	cmd.Flags().Uint64Var(&opts.seed, "seed", 1, "Random seed")
+	cmd.Flags().StringVar(&opts.comparePath, "compare", "", "eval run results JSON to anchor against the curve")
+	cmd.Flags().StringVar(&opts.outputJSON, "output-json", "", "Path to save the baseline as JSON")
+	cmd.Flags().StringArrayVar(&opts.scorers, "scorer-plugin", nil, "Scorer plugin command to register (repeatable; default $SCORER_PLUGINS)")
+
+	return cmd
+}
+
+func executeBaseline(opts baselineOptions) error {
+	ds, err := dataset.LoadMARCDataset(opts.datasetDir)
+	if err != nil {
+		return fmt.Errorf("failed to load dataset: %w", err)
+	}
+
+	references := loadReferences(ds, opts.sampleSize)
+	if len(references) == 0 {
+		return fmt.Errorf("no readable reference records in %s", opts.datasetDir)
+	}
+
+	stopScorers, err := startScorers(opts.scorers)
+	if err != nil {
+		return err
+	}
+	defer stopScorers()
+
+	switch opts.kind {
+	case "perturbation":
+		records := make([]*marc.Record, len(references))
+		for i, ref := range references {
+			records[i] = ref.Record
+		}
+		return executePerturbationBaseline(records, opts)
+	case "naive":
+		return executeNaiveBaseline(references, opts)
+	default:
+		return fmt.Errorf("unsupported baseline type: %s", opts.kind)
+	}
+}
+
+func executePerturbationBaseline(references []*marc.Record, opts baselineOptions) error {
+	curve := baseline.PerturbationCurve(references, opts.rates, opts.dropRate, opts.trials, opts.seed)
+
+	fmt.Println("\n" + strings.Repeat("=", 70))
+	fmt.Println("PERTURBATION BASELINE")
+	fmt.Println(strings.Repeat("=", 70))
+	fmt.Printf("Records: %d, trials: %d, drop rate: %.2f\n\n", len(references), opts.trials, opts.dropRate)
+	fmt.Printf("%-12s %10s %10s %10s\n", "NOISE RATE", "MEAN", "MIN", "MAX")
+	fmt.Println(strings.Repeat("-", 70))
+	for _, p := range curve {
+		fmt.Printf("%-12s %10.3f %10.3f %10.3f\n", fmt.Sprintf("%.1f%%", p.CharRate*100), p.MeanScore, p.MinScore, p.MaxScore)
+	}
+
+	if opts.comparePath != "" {
+		report, err := loadRunReport(opts.comparePath)
+		if err != nil {
+			return err
+		}
+
+		fmt.Println()
+		if rate, ok := baseline.EquivalentNoise(curve, report.MeanScore); ok {
+			fmt.Printf("%s/%s scored %.3f, equivalent to %.1f%% character noise on the reference records\n",
+				report.Provider, report.Model, report.MeanScore, rate*100)
+		} else {
+			fmt.Printf("%s/%s scored %.3f, outside the sampled noise range\n", report.Provider, report.Model, report.MeanScore)
+		}
+	}
+	fmt.Println(strings.Repeat("=", 70))
+
+	if opts.outputJSON != "" {
+		data, err := json.MarshalIndent(curve, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to encode baseline: %w", err)
+		}
+		if err := objectstore.WriteFile(context.Background(), opts.outputJSON, data); err != nil {
+			return fmt.Errorf("failed to write baseline: %w", err)
+		}
+		fmt.Printf("\nBaseline saved to: %s\n", opts.outputJSON)
+	}
+
+	return nil
+}
+
+func executeNaiveBaseline(references []reference, opts baselineOptions) error {
+	lookup := baseline.NewLookup()
+
+	results := make([]marceval.Result, 0, len(references))
+	for _, ref := range references {
+		start := time.Now()
+		result := marceval.Result{ID: ref.Item.ID, Title: ref.Item.Title, Provider: "naive"}
+
+		isbn := ref.Item.ISBN
+		if isbn == "" {
+			isbn = ref.Record.SubfieldValue("020", "a")
+		}
+		isbn = images.CleanISBN(isbn)
+
+		if isbn == "" {
+			result.Error = "no ISBN"
+			result.ErrorCode = failure.NoInput
+		} else if md, source, err := lookup.Metadata(isbn); err != nil {
+			result.Error = err.Error()
+			result.ErrorCode = failure.Classify(err, failure.ProviderError)
+		} else {
+			generated := cataloging.MetadataToMARC(md)
+			result.Model = source
+			result.GeneratedMARC = generated.Mnemonic()
+			result.Comparison = marceval.Compare(ref.Record, generated)
+		}
+		result.ProcessingTime = time.Since(start)
+
+		if result.Error != "" {
+			slog.Warn("Naive lookup failed", "id", result.ID, "isbn", isbn, "error", result.Error)
+		}
+		results = append(results, result)
+	}
+
+	report := marceval.NewReport(results)
+	report.Dataset = opts.datasetDir
+	report.Provider = "naive"
+	report.Model = "isbn-lookup"
+	report.PrintSummary()
+
+	if opts.comparePath != "" {
+		model, err := loadRunReport(opts.comparePath)
+		if err != nil {
+			return err
+		}
+		shared, baseMean, modelMean := baseline.Lift(report, model)
+		fmt.Println()
+		if shared == 0 {
+			fmt.Println("No records were scored by both the naive baseline and the model run")
+		} else {
+			fmt.Printf("On %d shared records: naive %.3f, %s/%s %.3f, lift %+.3f\n",
+				shared, baseMean, model.Provider, model.Model, modelMean, modelMean-baseMean)
+		}
+	}
+
+	if opts.outputJSON != "" {
+		if err := report.SaveJSON(opts.outputJSON); err != nil {
+			return err
+		}
+		fmt.Printf("\nBaseline saved to: %s\n", opts.outputJSON)
+	}
+
+	return nil
+}
+
+// loadRunReport reads the JSON report written by eval run
+func loadRunReport(path string) (*marceval.Report, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read results: %w", err)
+	}
+	var report marceval.Report
+	if _, err := marceval.ReportSchema.DecodeJSON(data, &report); err != nil {
+		return nil, fmt.Errorf("failed to parse results: %w", err)
+	}
+	return &report, nil
+}
+
+// reference is a dataset item with its parsed reference record
+type reference struct {
+	Item   dataset.DatasetItem
+	Record *marc.Record
+}
+
+// loadReferences parses the reference records of up to sampleSize dataset items, skipping unreadable ones
+func loadReferences(ds *dataset.MARCDataset, sampleSize int) []reference {
+	items := ds.Index.Items
+	if sampleSize > 0 && sampleSize < len(items) {
+		items = items[:sampleSize]
+	}
+
+	references := make([]reference, 0, len(items))
+	for _, item := range items {
+		data, err := ds.ReadMARCXML(item)
+		if err != nil {
+			slog.Warn("Skipping item", "id", item.ID, "error", err)
+			continue
+		}
+		rec, err := marc.ParseXML(data)
+		if err != nil {
+			slog.Warn("Skipping item", "id", item.ID, "error", err)
+			continue
+		}
+		references = append(references, reference{Item: item, Record: rec})
+	}
+	return references
+}
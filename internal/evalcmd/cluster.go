@@ -0,0 +1,159 @@
+package evalcmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/lehigh-university-libraries/cataloger/internal/eval/cluster"
+	"github.com/lehigh-university-libraries/cataloger/internal/eval/marceval"
+	"github.com/spf13/cobra"
+)
+
+// clusterOptions holds the flags for the cluster command
+type clusterOptions struct {
+	threshold   float64
+	similarity  float64
+	minSize     int
+	features    int
+	examples    int
+	includeFail bool
+	outputJSON  string
+}
+
+// NewClusterCmd creates the cluster command for grouping low-scoring records by how they failed
+func NewClusterCmd() *cobra.Command {
+	var opts clusterOptions
+
+	cmd := &cobra.Command{
+		Use:   "cluster <results>",
+		Short: "Group low-scoring records by failure similarity",
+		Long: `Cluster the low-scoring records of an eval run by how they failed, to find systematic
+problems worth a prompt fix instead of reading records one by one.
+
+Each record scoring below --threshold is described by failure features: fields missing,
+incorrect, truncated or extended, the kinds of words dropped or added (year, number, word,
+and the words themselves), duplicated fields, warnings, anomalies and error codes. Features
+are weighted by rarity (TF-IDF) and records join the cluster whose center is most similar
+(cosine similarity at least --similarity), lowest scores first.
+
+Each cluster is summarized by its most common features, its mean score, example record IDs
+and the field differences of its most typical record. The results can be a report JSON
+file, or a .jsonl or .parquet file written with eval run --results-file.`,
+		Example: `  cataloger eval cluster eval_run_results.json
+
+  # Looser clusters over records below 0.5, saved for later
+  cataloger eval cluster results.jsonl --threshold 0.5 --similarity 0.3 --output-json clusters.json`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return executeCluster(args[0], opts)
+		},
+	}
+
+	cmd.Flags().Float64Var(&opts.threshold, "threshold", 0.7, "Cluster records scoring below this")
+	cmd.Flags().Float64Var(&opts.similarity, "similarity", 0.5, "Minimum cosine similarity between a record and its cluster's center (0-1)")
+	cmd.Flags().IntVar(&opts.minSize, "min-size", 2, "Smallest cluster to print; smaller ones are counted as unclustered")
+	cmd.Flags().IntVar(&opts.features, "features", 5, "Features shown per cluster")
+	cmd.Flags().IntVar(&opts.examples, "examples", 5, "Record IDs shown per cluster")
+	cmd.Flags().BoolVar(&opts.includeFail, "include-failures", true, "Also cluster records that failed before scoring")
+	cmd.Flags().StringVar(&opts.outputJSON, "output-json", "", "Save the clusters to a JSON file")
+
+	return cmd
+}
+
+func executeCluster(path string, opts clusterOptions) error {
+	var records []cluster.Record
+	low := make(map[string]marceval.Result)
+	_, err := marceval.ReadResults(path, func(res marceval.Result) error {
+		score := 0.0
+		switch {
+		case res.Comparison != nil:
+			score = res.Comparison.Score
+		case res.Error == "" || !opts.includeFail:
+			return nil
+		}
+		if score >= opts.threshold {
+			return nil
+		}
+		records = append(records, cluster.Record{ID: res.ID, Score: score, Features: cluster.Features(res)})
+		low[res.ID] = res
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	clusters := cluster.Build(records, opts.similarity, opts.features)
+
+	fmt.Println("\n" + strings.Repeat("=", 70))
+	fmt.Println("FAILURE CLUSTERS")
+	fmt.Println(strings.Repeat("=", 70))
+	fmt.Printf("Records below %.2f: %d\n", opts.threshold, len(records))
+	shown, unclustered := 0, 0
+	for _, c := range clusters {
+		if len(c.Records) < opts.minSize {
+			unclustered += len(c.Records)
+			continue
+		}
+		shown++
+		fmt.Printf("\nCluster %d: %d records, mean score %.3f\n", shown, len(c.Records), c.MeanScore)
+		fmt.Println(strings.Repeat("-", 70))
+		for _, f := range c.Features {
+			fmt.Printf("  %3.0f%%  %s\n", f.Share*100, f.Feature)
+		}
+		examples := c.Records
+		if opts.examples > 0 && len(examples) > opts.examples {
+			examples = examples[:opts.examples]
+		}
+		fmt.Printf("  Examples: %s\n", strings.Join(examples, ", "))
+		printExemplar(low[c.Exemplar])
+	}
+	fmt.Printf("\nUnclustered records (clusters under %d): %d\n", opts.minSize, unclustered)
+	fmt.Println(strings.Repeat("=", 70))
+
+	if opts.outputJSON != "" {
+		data, err := json.MarshalIndent(clusters, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal clusters: %w", err)
+		}
+		if err := os.WriteFile(opts.outputJSON, data, 0644); err != nil {
+			return fmt.Errorf("failed to write %s: %w", opts.outputJSON, err)
+		}
+		fmt.Printf("\nClusters saved to: %s\n", opts.outputJSON)
+	}
+	return nil
+}
+
+// printExemplar shows the error or imperfect fields of a cluster's most typical record
+func printExemplar(res marceval.Result) {
+	fmt.Printf("  Typical record: %s\n", res.ID)
+	if res.Error != "" {
+		fmt.Printf("    error: %s\n", truncate(res.Error, 100))
+	}
+	if res.Comparison == nil {
+		return
+	}
+	tags := make([]string, 0, len(res.Comparison.Fields))
+	for tag := range res.Comparison.Fields {
+		tags = append(tags, tag)
+	}
+	sort.Strings(tags)
+	for _, tag := range tags {
+		fs := res.Comparison.Fields[tag]
+		if fs.Score >= 1 {
+			continue
+		}
+		fmt.Printf("    %s expected: %s\n", tag, truncate(strings.Join(fs.Expected, " | "), 90))
+		fmt.Printf("    %s actual:   %s\n", tag, truncate(strings.Join(fs.Actual, " | "), 90))
+	}
+}
+
+// truncate shortens a string to the given length with ellipsis
+func truncate(s string, maxLen int) string {
+	if len(s) <= maxLen {
+		return s
+	}
+	return s[:maxLen-3] + "..."
+}
@@ -9,13 +9,7 @@ import (
 
 // NewIBCmd creates the ib command for evaluating with Institutional Books dataset
 func NewIBCmd() *cobra.Command {
-	var datasetPath string
-	var outputJSON string
-	var outputReport string
-	var sampleSize int
-	var provider string
-	var model string
-	var verbose bool
+	var opts ibOptions
 
 	cmd := &cobra.Command{
 		Use:   "ib",
@@ -25,6 +19,11 @@ func NewIBCmd() *cobra.Command {
 This dataset contains OCR text from book title pages with ground truth metadata.
 The evaluation compares LLM-generated metadata fields against the reference metadata.
 
+Each record is bucketed by OCR quality (good, fair, poor) from the dataset's post-processed
+tokens per page and how far the post-processed title page text diverges from the source OCR,
+and the summary reports accuracy per bucket, so a model failing on garbage input can be told
+apart from a model that is simply wrong.
+
 Dataset: https://huggingface.co/datasets/instdin/institutional-books-1.0`,
 		Example: `  # Evaluate 10 records with Ollama
   cataloger eval ib --sample 10 --provider ollama --verbose
@@ -32,34 +31,40 @@ Dataset: https://huggingface.co/datasets/instdin/institutional-books-1.0`,
   # Evaluate 100 records with OpenAI
   cataloger eval ib --sample 100 --provider openai --model gpt-4o
 
+  # Route specific barcodes to other models (YAML or JSON sidecar)
+  cataloger eval ib --sample 100 --overrides ./overrides.yaml
+
+  # Send CJK and Cyrillic title pages to models that handle them better
+  cataloger eval ib --sample 100 --routing ./routing.yaml
+
+  # Pin the metadata extraction prompt for a reproducible run
+  cataloger eval ib --sample 100 --prompt-version metadata_extraction=v1
+
   # Evaluate full dataset (thousands of records)
   cataloger eval ib --sample -1 --provider openai`,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			// Set up logging
-			logLevel := "info"
-			if verbose {
-				logLevel = "debug"
-			}
-			// Note: This would need proper integration with slog
-			_ = logLevel
-
 			// Check if dataset file exists
-			if _, err := os.Stat(datasetPath); os.IsNotExist(err) {
-				return fmt.Errorf("dataset file not found: %s\n\nPlease clone the dataset first:\n  git clone https://huggingface.co/datasets/instdin/institutional-books-1.0", datasetPath)
+			if _, err := os.Stat(opts.datasetPath); os.IsNotExist(err) {
+				return fmt.Errorf("dataset file not found: %s\n\nPlease clone the dataset first:\n  git clone https://huggingface.co/datasets/instdin/institutional-books-1.0", opts.datasetPath)
 			}
 
 			// Run the evaluation
-			return executeIB(datasetPath, outputJSON, outputReport, sampleSize, provider, model, verbose)
+			return executeIB(opts)
 		},
 	}
 
-	cmd.Flags().StringVar(&datasetPath, "dataset", "./institutional-books-1.0/data/train-00000-of-09831.parquet", "Path to Institutional Books parquet file")
-	cmd.Flags().StringVar(&outputJSON, "output-json", "eval_results.json", "Path to output JSON results file")
-	cmd.Flags().StringVar(&outputReport, "output-report", "eval_report.txt", "Path to output detailed report file")
-	cmd.Flags().IntVar(&sampleSize, "sample", 10, "Number of records to evaluate (-1 for all)")
-	cmd.Flags().StringVar(&provider, "provider", "ollama", "LLM provider (ollama, openai, or gemini)")
-	cmd.Flags().StringVar(&model, "model", "", "Model name (defaults to provider's default)")
-	cmd.Flags().BoolVar(&verbose, "verbose", false, "Verbose logging")
+	cmd.Flags().StringVar(&opts.datasetPath, "dataset", "./institutional-books-1.0/data/train-00000-of-09831.parquet", "Path to Institutional Books parquet file")
+	cmd.Flags().StringVar(&opts.outputJSON, "output-json", "eval_results.json", "Path to output JSON results file")
+	cmd.Flags().StringVar(&opts.outputReport, "output-report", "eval_report.txt", "Path to output detailed report file")
+	cmd.Flags().IntVar(&opts.sampleSize, "sample", 10, "Number of records to evaluate (-1 for all)")
+	cmd.Flags().StringVar(&opts.provider, "provider", "ollama", "LLM provider (ollama, openai, azure-openai, gemini, claude, groq, huggingface, llamacpp, or mistral)")
+	cmd.Flags().StringVar(&opts.model, "model", "", "Model name (defaults to provider's default)")
+	cmd.Flags().StringVar(&opts.overridesPath, "overrides", "", "YAML/JSON file mapping barcodes to provider/model overrides")
+	cmd.Flags().StringVar(&opts.routingPath, "routing", "", "YAML file routing records to models by detected script/language")
+	cmd.Flags().StringSliceVar(&opts.promptPins, "prompt-version", nil, "Pin a prompt version as id=version (e.g. metadata_extraction=v1)")
+	cmd.Flags().StringVar(&opts.promptsDir, "prompts-dir", "", "Directory of additional prompt versions (<id>/<version>.txt)")
+	addHeartbeatFlags(cmd, &opts.heartbeat)
+	cmd.Flags().BoolVar(&opts.verbose, "verbose", false, "Verbose logging")
 
 	return cmd
 }
@@ -69,6 +74,8 @@ func NewDownloadImagesCmd() *cobra.Command {
 	var datasetPath string
 	var outputDir string
 	var sampleSize int
+	var uploadTo string
+	var cacheDir string
 	var verbose bool
 
 	cmd := &cobra.Command{
@@ -80,7 +87,11 @@ This command uses the ISBNs from the Institutional Books dataset to fetch previe
 from Google Books. Images are stored in directories named by barcode for easy reference.
 
 The number of pages to download per book is configurable via the DEFAULT_PAGES_PER_BOOK constant
-(currently set to 10 pages per book).`,
+(currently set to 10 pages per book).
+
+Pages are kept in a cache shared across datasets and runs ($IMAGE_CACHE_DIR, by default
+cataloger/images in the user's cache directory), so books downloaded before are copied
+from the cache instead of Google Books.`,
 		Example: `  # Download images for 10 books
   cataloger eval download-images --dataset ./institutional-books-1.0/data/train-00000-of-09831.parquet --sample 10
 
@@ -88,7 +99,10 @@ The number of pages to download per book is configurable via the DEFAULT_PAGES_P
   cataloger eval download-images --dataset ./institutional-books-1.0/data/train-00000-of-09831.parquet --sample 100 --verbose
 
   # Download images for all books in the parquet file
-  cataloger eval download-images --dataset ./institutional-books-1.0/data/train-00000-of-09831.parquet --sample -1`,
+  cataloger eval download-images --dataset ./institutional-books-1.0/data/train-00000-of-09831.parquet --sample -1
+
+  # Also copy each book's images to a bucket
+  cataloger eval download-images --dataset ./institutional-books-1.0/data/train-00000-of-09831.parquet --upload s3://my-bucket/book_images`,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			if datasetPath == "" {
 				return fmt.Errorf("--dataset is required")
@@ -99,13 +113,15 @@ The number of pages to download per book is configurable via the DEFAULT_PAGES_P
 				return fmt.Errorf("dataset file not found: %s\n\nPlease clone the dataset first:\n  git clone https://huggingface.co/datasets/instdin/institutional-books-1.0", datasetPath)
 			}
 
-			return executeDownloadImages(datasetPath, outputDir, sampleSize, verbose)
+			return executeDownloadImages(datasetPath, outputDir, sampleSize, uploadTo, cacheDir, verbose)
 		},
 	}
 
 	cmd.Flags().StringVar(&datasetPath, "dataset", "", "Path to Institutional Books parquet file (required)")
 	cmd.Flags().StringVar(&outputDir, "output", "./book_images", "Output directory for downloaded images")
 	cmd.Flags().IntVar(&sampleSize, "sample", 10, "Number of books to process (-1 for all)")
+	cmd.Flags().StringVar(&uploadTo, "upload", "", "Also copy downloaded images to this s3:// or gs:// location, one prefix per barcode")
+	cmd.Flags().StringVar(&cacheDir, "cache-dir", "", "Shared image cache directory, or off (default $IMAGE_CACHE_DIR or the user cache directory)")
 	cmd.Flags().BoolVar(&verbose, "verbose", false, "Verbose logging")
 
 	_ = cmd.MarkFlagRequired("dataset")
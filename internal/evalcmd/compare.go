@@ -0,0 +1,56 @@
+package evalcmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/lehigh-university-libraries/cataloger/internal/eval/compare"
+	"github.com/spf13/cobra"
+)
+
+// NewCompareCmd creates the compare command for comparing eval runs
+func NewCompareCmd() *cobra.Command {
+	var groupBy string
+
+	cmd := &cobra.Command{
+		Use:   "compare <results>...",
+		Short: "Compare evaluation results grouped by prompt version, model or file",
+		Long: `Compare one or more evaluation results files (JSON from --output-json or YAML from evals/).
+
+Records are grouped by prompt version by default so score changes can be attributed to
+prompt edits rather than model or dataset changes. Groups mixing several models are flagged.`,
+		Example: `  # Attribute score changes to prompt versions across runs
+  cataloger eval compare eval_results_v1.json eval_results_v2.json
+
+  # Compare models across all YAML results
+  cataloger eval compare evals/*.yaml --group-by model`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return executeCompare(args, groupBy)
+		},
+	}
+
+	cmd.Flags().StringVar(&groupBy, "group-by", compare.ByPrompt, "Group results by prompt, model, provider or file")
+
+	return cmd
+}
+
+func executeCompare(paths []string, groupBy string) error {
+	var rows []compare.Row
+	for _, path := range paths {
+		fileRows, err := compare.LoadRows(path)
+		if err != nil {
+			return err
+		}
+		rows = append(rows, fileRows...)
+	}
+
+	groups, err := compare.GroupRows(rows, groupBy)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Compared %d records from %d files\n\n", len(rows), len(paths))
+	compare.PrintTable(os.Stdout, groups, groupBy)
+	return nil
+}
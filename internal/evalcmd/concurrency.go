@@ -0,0 +1,91 @@
+package evalcmd
+
+import (
+	"fmt"
+	"log/slog"
+	"strconv"
+	"sync"
+
+	"github.com/lehigh-university-libraries/cataloger/internal/adaptive"
+	"github.com/lehigh-university-libraries/cataloger/internal/eval/failure"
+	"github.com/lehigh-university-libraries/cataloger/internal/eval/marceval"
+)
+
+// newLimiter parses --concurrency: a number of items evaluated at a time, or "auto" to adapt
+// between 1 and maxConcurrency to the provider's latency and errors
+func newLimiter(concurrency string, maxConcurrency int) (*adaptive.Limiter, error) {
+	if concurrency == "auto" {
+		if maxConcurrency < 1 {
+			return nil, fmt.Errorf("--max-concurrency must be at least 1, got %d", maxConcurrency)
+		}
+		return adaptive.New(adaptive.Options{Min: 1, Max: maxConcurrency}), nil
+	}
+	n, err := strconv.Atoi(concurrency)
+	if err != nil || n < 1 {
+		return nil, fmt.Errorf("--concurrency must be a positive number or auto, got %q", concurrency)
+	}
+	return adaptive.Fixed(n), nil
+}
+
+// evaluateConcurrently evaluates n items under the limiter and passes the results to emit in
+// item order. Provider timeouts and errors count as overload; emit's first error stops the
+// evaluation of further items and is returned once those in flight finish.
+func evaluateConcurrently(n int, limiter *adaptive.Limiter, evaluate func(i int) marceval.Result, emit func(i int, result marceval.Result) error) error {
+	type done struct {
+		i      int
+		result marceval.Result
+	}
+	results := make(chan done)
+	stop := make(chan struct{})
+	go func() {
+		var wg sync.WaitGroup
+		defer func() {
+			wg.Wait()
+			close(results)
+		}()
+		for i := range n {
+			select {
+			case <-stop:
+				return
+			default:
+			}
+			token := limiter.Acquire()
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				result := evaluate(i)
+				overloaded := result.ErrorCode == failure.ProviderTimeout || result.ErrorCode == failure.ProviderError
+				limiter.Release(token, result.ProcessingTime, overloaded)
+				results <- done{i, result}
+			}()
+		}
+	}()
+
+	// Results arrive in completion order and are held until those before them are emitted
+	pending := make(map[int]marceval.Result)
+	next := 0
+	var emitErr error
+	for d := range results {
+		if emitErr != nil {
+			continue
+		}
+		pending[d.i] = d.result
+		for {
+			result, ok := pending[next]
+			if !ok {
+				break
+			}
+			delete(pending, next)
+			if emitErr = emit(next, result); emitErr != nil {
+				close(stop)
+				break
+			}
+			next++
+		}
+	}
+
+	if peak, cuts := limiter.Stats(); peak > 1 {
+		slog.Info("Concurrency", "final", limiter.Limit(), "peak", peak, "cuts", cuts)
+	}
+	return emitErr
+}
@@ -0,0 +1,299 @@
+package evalcmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/lehigh-university-libraries/cataloger/internal/eval/history"
+	"github.com/lehigh-university-libraries/cataloger/internal/schedule"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+// daemonOptions holds the flags for the daemon command
+type daemonOptions struct {
+	cron       string
+	url        string
+	file       string
+	set        string
+	datasetDir string
+	sample     int
+	enrich     bool
+	matrix     string
+	models     []string
+	runArgs    []string
+	outputDir  string
+	runNow     bool
+	once       bool
+	verbose    bool
+}
+
+// matrixModel is one provider and model to evaluate on each tick
+type matrixModel struct {
+	Provider string `yaml:"provider"`
+	Model    string `yaml:"model"`
+}
+
+// NewDaemonCmd creates the daemon command for scheduled evaluations that track model drift
+func NewDaemonCmd() *cobra.Command {
+	var opts daemonOptions
+
+	cmd := &cobra.Command{
+		Use:   "daemon",
+		Short: "Evaluate a model matrix on a schedule to track drift over time",
+		Long: `Run evaluations on a cron schedule. On each tick a fresh random sample is harvested
+(--url or --file, as eval fetch) and enriched with title page images, or the fixed --dataset
+is reused, then every model in the matrix is evaluated with eval run.
+
+Each tick writes to a timestamped directory under --output: the harvested dataset and one
+report per model (<provider>_<model>.json). A summary line per model is appended to
+history.jsonl in --output; point cataloger serve --eval-history at the same directory to see
+trends at /eval/trends (JSON at /api/eval/trends).
+
+The matrix comes from --model provider/model (repeatable) or a --matrix YAML file:
+
+  models:
+    - provider: ollama
+      model: mistral-small3.2:24b
+    - provider: openai
+      model: gpt-4o
+
+A run that fails or falls below a threshold is recorded and the daemon keeps going.`,
+		Example: `  # Nightly at 03:00, 50 fresh records from an OAI-PMH endpoint
+  cataloger eval daemon --cron "0 3 * * *" --url https://catalog.example.edu/oai --sample 50 \
+    --model ollama/mistral-small3.2:24b --model openai/gpt-4o
+
+  # Weekly on a fixed dataset with a model matrix file
+  cataloger eval daemon --cron @weekly --dataset ./eval_data --matrix models.yaml
+
+  # One evaluation now, then exit (for an external scheduler)
+  cataloger eval daemon --cron @daily --dataset ./eval_data --model mock/ --once`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runDaemon(opts)
+		},
+	}
+
+	cmd.Flags().StringVar(&opts.cron, "cron", "", "Cron schedule (minute hour day month weekday, or @daily, @weekly, ...), in local time")
+	cmd.Flags().StringVar(&opts.url, "url", "", "OAI-PMH base URL to harvest a fresh sample from on each tick")
+	cmd.Flags().StringVar(&opts.file, "file", "", "Local MARC export to sample from on each tick (.mrc or MARCXML)")
+	cmd.Flags().StringVar(&opts.set, "set", "", "OAI-PMH set to harvest")
+	cmd.Flags().StringVar(&opts.datasetDir, "dataset", "", "Evaluate this dataset on every tick instead of harvesting")
+	cmd.Flags().IntVar(&opts.sample, "sample", 50, "Records sampled on each tick (a random sample when harvesting)")
+	cmd.Flags().BoolVar(&opts.enrich, "enrich", true, "Fetch title page images for harvested records")
+	cmd.Flags().StringVar(&opts.matrix, "matrix", "", "YAML file listing the provider and model pairs to evaluate")
+	cmd.Flags().StringArrayVar(&opts.models, "model", nil, "Provider and model to evaluate, as provider/model (repeatable)")
+	cmd.Flags().StringArrayVar(&opts.runArgs, "run-arg", nil, "Extra flag passed to each eval run, e.g. --run-arg=--copyright-pass=false (repeatable)")
+	cmd.Flags().StringVar(&opts.outputDir, "output", "./eval_history", "Directory for timestamped results and history.jsonl")
+	cmd.Flags().BoolVar(&opts.runNow, "run-now", false, "Evaluate once at startup before waiting for the schedule")
+	cmd.Flags().BoolVar(&opts.once, "once", false, "Evaluate once and exit")
+	cmd.Flags().BoolVar(&opts.verbose, "verbose", false, "Verbose logging")
+	_ = cmd.MarkFlagRequired("cron")
+
+	return cmd
+}
+
+func runDaemon(opts daemonOptions) error {
+	sched, err := schedule.Parse(opts.cron)
+	if err != nil {
+		return err
+	}
+	if opts.datasetDir == "" && opts.url == "" && opts.file == "" {
+		return fmt.Errorf("one of --dataset, --url or --file is required")
+	}
+	if opts.datasetDir != "" && (opts.url != "" || opts.file != "") {
+		return fmt.Errorf("use either --dataset or --url/--file, not both")
+	}
+	models, err := daemonModels(opts)
+	if err != nil {
+		return err
+	}
+	if opts.verbose {
+		slog.SetLogLoggerLevel(slog.LevelDebug)
+	}
+
+	if opts.once {
+		return daemonTick(opts, models, time.Now())
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if opts.runNow {
+		if err := daemonTick(opts, models, time.Now()); err != nil {
+			slog.Error("Scheduled evaluation failed", "error", err)
+		}
+	}
+	for {
+		next := sched.Next(time.Now())
+		if next.IsZero() {
+			return fmt.Errorf("cron schedule %q never matches", opts.cron)
+		}
+		slog.Info("Waiting for next evaluation", "schedule", sched.String(), "next", next.Format(time.RFC3339))
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			slog.Info("Eval daemon stopped")
+			return nil
+		case <-timer.C:
+		}
+		if err := daemonTick(opts, models, time.Now()); err != nil {
+			slog.Error("Scheduled evaluation failed", "error", err)
+		}
+	}
+}
+
+// daemonModels reads the model matrix from --matrix and --model
+func daemonModels(opts daemonOptions) ([]matrixModel, error) {
+	var models []matrixModel
+	if opts.matrix != "" {
+		data, err := os.ReadFile(opts.matrix)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read model matrix: %w", err)
+		}
+		var config struct {
+			Models []matrixModel `yaml:"models"`
+		}
+		if err := yaml.Unmarshal(data, &config); err != nil {
+			return nil, fmt.Errorf("failed to parse model matrix: %w", err)
+		}
+		models = append(models, config.Models...)
+	}
+	for _, m := range opts.models {
+		provider, model, _ := strings.Cut(m, "/")
+		models = append(models, matrixModel{Provider: provider, Model: model})
+	}
+	for _, m := range models {
+		if m.Provider == "" {
+			return nil, fmt.Errorf("model matrix entry without a provider")
+		}
+	}
+	if len(models) == 0 {
+		return nil, fmt.Errorf("no models to evaluate: use --model or --matrix")
+	}
+	return models, nil
+}
+
+// daemonTick prepares the tick's dataset, evaluates each model on it and appends the
+// results to the history. A model that fails is recorded with its error.
+func daemonTick(opts daemonOptions, models []matrixModel, at time.Time) error {
+	at = at.UTC().Truncate(time.Second)
+	stamp := at.Format("20060102T150405Z")
+	dir := filepath.Join(opts.outputDir, stamp)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create results directory: %w", err)
+	}
+	slog.Info("Starting scheduled evaluation", "dir", dir, "models", len(models))
+
+	datasetDir := opts.datasetDir
+	if datasetDir == "" {
+		datasetDir = filepath.Join(dir, "dataset")
+		if err := daemonHarvest(opts, datasetDir); err != nil {
+			for _, m := range models {
+				entry := history.Entry{Time: at, Provider: m.Provider, Model: m.Model, Dataset: datasetDir, Error: err.Error()}
+				if err := history.Append(opts.outputDir, entry); err != nil {
+					return err
+				}
+			}
+			return err
+		}
+	}
+
+	for _, m := range models {
+		name := m.Provider
+		if m.Model != "" {
+			name += "_" + m.Model
+		}
+		results := filepath.Join(stamp, safeFileName(name)+".json")
+		entry, err := daemonRun(opts, m, datasetDir, filepath.Join(opts.outputDir, results))
+		if err != nil {
+			slog.Error("Model evaluation failed", "provider", m.Provider, "model", m.Model, "error", err)
+			entry = history.Entry{Provider: m.Provider, Model: m.Model, Dataset: datasetDir, Error: err.Error()}
+		}
+		entry.Time = at
+		entry.Results = results
+		if err := history.Append(opts.outputDir, entry); err != nil {
+			return err
+		}
+		slog.Info("Model evaluated", "provider", entry.Provider, "model", entry.Model, "mean_score", entry.MeanScore, "failed", entry.Failed)
+	}
+	return nil
+}
+
+// daemonHarvest fetches a fresh random sample into dir with eval fetch, then eval enrich
+func daemonHarvest(opts daemonOptions, dir string) error {
+	args := []string{"--output", dir, "--name", filepath.Base(filepath.Dir(dir))}
+	if opts.url != "" {
+		args = append(args, "--url", opts.url)
+	}
+	if opts.file != "" {
+		args = append(args, "--file", opts.file)
+	}
+	if opts.set != "" {
+		args = append(args, "--set", opts.set)
+	}
+	if opts.sample > 0 {
+		args = append(args, "--random-sample", strconv.Itoa(opts.sample))
+	}
+	if err := executeSubcommand(NewFetchCmd(), args); err != nil {
+		return fmt.Errorf("failed to harvest sample: %w", err)
+	}
+	if opts.enrich {
+		if err := executeSubcommand(NewEnrichCmd(), []string{"--dataset", dir}); err != nil {
+			return fmt.Errorf("failed to enrich sample: %w", err)
+		}
+	}
+	return nil
+}
+
+// daemonRun evaluates one model with eval run and summarizes its report. Falling below a
+// threshold or failing some records still counts as a finished run.
+func daemonRun(opts daemonOptions, m matrixModel, datasetDir, output string) (history.Entry, error) {
+	args := []string{"--dataset", datasetDir, "--output-json", output, "--provider", m.Provider}
+	if m.Model != "" {
+		args = append(args, "--model", m.Model)
+	}
+	if opts.datasetDir != "" && opts.sample > 0 {
+		args = append(args, "--sample", strconv.Itoa(opts.sample))
+	}
+	args = append(args, opts.runArgs...)
+
+	var exitErr *ExitError
+	if err := executeSubcommand(NewRunCmd(), args); err != nil && !errors.As(err, &exitErr) {
+		return history.Entry{}, err
+	}
+	report, err := loadRunReport(output)
+	if err != nil {
+		return history.Entry{}, err
+	}
+	return history.FromReport(time.Time{}, report, ""), nil
+}
+
+// executeSubcommand runs another eval command in process with the given arguments
+func executeSubcommand(cmd *cobra.Command, args []string) error {
+	cmd.SetArgs(args)
+	cmd.SilenceUsage = true
+	cmd.SilenceErrors = true
+	return cmd.Execute()
+}
+
+// safeFileName replaces characters that don't belong in file names, such as the slashes and
+// colons in model names
+func safeFileName(name string) string {
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case '/', '\\', ':', ' ':
+			return '-'
+		}
+		return r
+	}, name)
+}
@@ -1,19 +1,22 @@
 package evalcmd
 
 import (
+	"context"
 	"fmt"
 	"log/slog"
 	"os"
 	"path/filepath"
+	"time"
 
 	"github.com/lehigh-university-libraries/cataloger/internal/eval/dataset"
 	"github.com/lehigh-university-libraries/cataloger/internal/images"
+	"github.com/lehigh-university-libraries/cataloger/internal/objectstore"
 )
 
 // Number of pages to download per book (easy to change)
 const DEFAULT_PAGES_PER_BOOK = 10
 
-func executeDownloadImages(datasetPath, outputDir string, sampleSize int, verbose bool) error {
+func executeDownloadImages(datasetPath, outputDir string, sampleSize int, uploadTo, cacheDir string, verbose bool) error {
 	slog.Info("Starting image download", "dataset", datasetPath, "output", outputDir, "sample", sampleSize)
 
 	// Load Institutional Books dataset
@@ -40,8 +43,20 @@ func executeDownloadImages(datasetPath, outputDir string, sampleSize int, verbos
 		return fmt.Errorf("failed to create output directory: %w", err)
 	}
 
+	var store objectstore.Store
+	if uploadTo != "" {
+		if store, err = objectstore.Open(uploadTo); err != nil {
+			return err
+		}
+	}
+
 	// Initialize image fetcher
-	fetcher := images.NewFetcher()
+	cache := images.CacheFromEnv()
+	if cacheDir != "" {
+		cache = images.NewCache(cacheDir)
+	}
+	fetcher := images.NewFetcherWithCache(cache)
+	slog.Info("Image cache", "dir", fetcher.Cache)
 
 	successCount := 0
 	skipCount := 0
@@ -93,6 +108,16 @@ func executeDownloadImages(datasetPath, outputDir string, sampleSize int, verbos
 
 		slog.Info("Downloaded pages", "isbn", cleanISBN, "barcode", record.BarcodeSource, "pages", pagesDownloaded)
 		successCount++
+
+		if store != nil {
+			uploaded, err := objectstore.UploadDir(context.Background(), store, bookDir, record.BarcodeSource)
+			if err != nil {
+				slog.Warn("Failed to upload pages", "barcode", record.BarcodeSource, "location", store, "error", err)
+				errorCount++
+				continue
+			}
+			slog.Info("Uploaded pages", "barcode", record.BarcodeSource, "location", store, "files", uploaded)
+		}
 	}
 
 	fmt.Printf("\nImage download complete!\n")
@@ -100,6 +125,12 @@ func executeDownloadImages(datasetPath, outputDir string, sampleSize int, verbos
 	fmt.Printf("  Skipped (no ISBN or already exists): %d\n", skipCount)
 	fmt.Printf("  Errors: %d\n", errorCount)
 	fmt.Printf("  Output location: %s\n", outputDir)
+	for source, until := range fetcher.Politeness.Paused() {
+		fmt.Printf("  Paused source: %s until %s\n", source, until.Format(time.DateTime))
+	}
+	if store != nil {
+		fmt.Printf("  Uploaded to: %s\n", store)
+	}
 	fmt.Printf("\nEach book directory contains:\n")
 	fmt.Printf("  - page_1.jpg, page_2.jpg, ...: First %d pages from Google Books preview\n", DEFAULT_PAGES_PER_BOOK)
 	fmt.Printf("\nNext steps:\n")
@@ -0,0 +1,220 @@
+package evalcmd
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+
+	"github.com/lehigh-university-libraries/cataloger/internal/eval/dataset"
+	"github.com/lehigh-university-libraries/cataloger/internal/images"
+	"github.com/lehigh-university-libraries/cataloger/internal/marc"
+	"github.com/spf13/cobra"
+)
+
+// enrichOptions holds the flags for the enrich command
+type enrichOptions struct {
+	datasetDir string
+	sampleSize int
+	links      bool
+	force      bool
+	cacheDir   string
+	verbose    bool
+}
+
+// NewEnrichCmd creates the enrich command for downloading images for a MARC dataset
+func NewEnrichCmd() *cobra.Command {
+	var opts enrichOptions
+
+	cmd := &cobra.Command{
+		Use:   "enrich",
+		Short: "Download cover, title page and copyright page images for a MARC dataset",
+		Long: `Download images for each item of a MARC evaluation dataset into images/<id>/ and record
+them in dataset.json, so eval run can OCR them.
+
+Images linked from the reference record's 856 fields are tried first: direct image URLs,
+IIIF manifests and image services, and PDFs (rendered with pdftoppm) of the digitized item.
+Manifest pages are chosen by canvas label, then the start canvas, then position, and
+requested at a size suited to OCR. Labels such as
+"Cover image" or "Title page" in $3, $y or $z say which image a link is; unlabeled images are
+used as the cover. Images still missing are then fetched by ISBN from Open Library, the
+Internet Archive and Google Books; Open Library covers are tried in large, medium and small
+sizes. The source of each image is recorded in the item's metadata as <kind>_source, and the
+Open Library cover size as cover_size. Images fetched by ISBN are kept in a cache shared across datasets
+and runs ($IMAGE_CACHE_DIR, by default cataloger/images in the user's cache directory), so
+books enriched before are not requested again. Requests to each source are spaced, limited in
+parallelism and capped per day as configured by $IMAGE_SOURCE_LIMITS, and a source answering
+429 Too Many Requests (or 403 Forbidden repeatedly) is paused.
+
+When done, the image coverage of the enriched items (how many ISBNs got each kind of image,
+from which sources, and their mean file size and pixel dimensions) is printed and saved as
+enrichment_report.json in the dataset directory.`,
+		Example: `  # Enrich a harvested dataset
+  cataloger eval fetch --url https://catalog.example.edu/oai --output ./eval_data
+  cataloger eval enrich --dataset ./eval_data
+
+  # Only use Open Library, the Internet Archive and Google Books
+  cataloger eval enrich --dataset ./eval_data --links=false
+
+  # Download again for items that already have images
+  cataloger eval enrich --dataset ./eval_data --force`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if _, err := os.Stat(opts.datasetDir); os.IsNotExist(err) {
+				return fmt.Errorf("dataset directory not found: %s", opts.datasetDir)
+			}
+			return executeEnrich(opts)
+		},
+	}
+
+	cmd.Flags().StringVar(&opts.datasetDir, "dataset", "./eval_data", "Path to MARC evaluation dataset directory")
+	cmd.Flags().IntVar(&opts.sampleSize, "sample", -1, "Number of items to enrich (-1 for all)")
+	cmd.Flags().BoolVar(&opts.links, "links", true, "Try image, IIIF and PDF URLs from the reference record's 856 fields first")
+	cmd.Flags().BoolVar(&opts.force, "force", false, "Download images again for items that already have them")
+	cmd.Flags().StringVar(&opts.cacheDir, "cache-dir", "", "Shared image cache directory, or off (default $IMAGE_CACHE_DIR or the user cache directory)")
+	cmd.Flags().BoolVar(&opts.verbose, "verbose", false, "Verbose logging")
+
+	return cmd
+}
+
+func executeEnrich(opts enrichOptions) error {
+	logLevel := slog.LevelInfo
+	if opts.verbose {
+		logLevel = slog.LevelDebug
+	}
+	slog.SetDefault(slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: logLevel})))
+
+	ds, err := dataset.LoadMARCDataset(opts.datasetDir)
+	if err != nil {
+		return fmt.Errorf("failed to load dataset: %w", err)
+	}
+
+	items := ds.Index.Items
+	if opts.sampleSize > 0 && opts.sampleSize < len(items) {
+		items = items[:opts.sampleSize]
+	}
+
+	cache := images.CacheFromEnv()
+	if opts.cacheDir != "" {
+		cache = images.NewCache(opts.cacheDir)
+	}
+	fetcher := images.NewFetcherWithCache(cache)
+	slog.Info("Image cache", "dir", fetcher.Cache)
+	successCount := 0
+	skipCount := 0
+	errorCount := 0
+
+	for i := range items {
+		item := &items[i]
+		if item.Images.HasAny() && !opts.force {
+			skipCount++
+			continue
+		}
+		slog.Info("Enriching item", "index", i+1, "total", len(items), "id", item.ID, "isbn", item.ISBN)
+
+		imageSet, err := enrichItem(ds, *item, fetcher, opts.links)
+		if err != nil {
+			slog.Warn("No images found", "id", item.ID, "error", err)
+			errorCount++
+			continue
+		}
+
+		item.Images = dataset.ItemImages{}
+		if item.Metadata == nil {
+			item.Metadata = make(map[string]string)
+		}
+		delete(item.Metadata, "cover_size")
+		if imageSet.CoverSize != "" && imageSet.Sources[images.KindCover] == images.SourceOpenLibrary {
+			item.Metadata["cover_size"] = imageSet.CoverSize
+		}
+		for kind, source := range imageSet.Sources {
+			rel, err := filepath.Rel(ds.Dir, imageSet.Get(kind))
+			if err != nil {
+				rel = imageSet.Get(kind)
+			}
+			item.Images.Set(kind, rel)
+			item.Metadata[kind+"_source"] = source
+		}
+		successCount++
+	}
+
+	if err := ds.Save(); err != nil {
+		return err
+	}
+
+	report := dataset.NewEnrichmentReport(ds, items)
+	report.Enriched = successCount
+	report.Skipped = skipCount
+	report.Failed = errorCount
+	report.PausedSources = fetcher.Politeness.Paused()
+	report.PrintSummary()
+
+	path, err := report.SaveJSON()
+	if err != nil {
+		fmt.Printf("Warning: Failed to save enrichment report: %v\n", err)
+	} else {
+		fmt.Printf("\nReport saved to: %s\n", path)
+	}
+
+	return nil
+}
+
+// enrichItem downloads an item's images into images/<id>/, from its 856 links first and then
+// by ISBN for the kinds still missing
+func enrichItem(ds *dataset.MARCDataset, item dataset.DatasetItem, fetcher *images.Fetcher, useLinks bool) (*images.ImageSet, error) {
+	dir := filepath.Join(ds.Dir, "images", item.ID)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create image directory: %w", err)
+	}
+
+	imageSet := &images.ImageSet{}
+	if useLinks {
+		if links := linksForItem(ds, item); len(links) > 0 {
+			slog.Debug("Trying 856 links", "id", item.ID, "links", len(links))
+			if fromLinks, err := fetcher.FetchImagesFromLinks(links, dir); err == nil {
+				imageSet = fromLinks
+			} else {
+				slog.Debug("No images from 856 links", "id", item.ID, "error", err)
+			}
+		}
+	}
+
+	if !imageSet.Complete() && item.ISBN != "" {
+		byISBN, err := fetcher.FetchImagesForISBN(item.ISBN, dir)
+		if err == nil {
+			for _, kind := range []string{images.KindCover, images.KindTitlePage, images.KindCopyrightPage} {
+				path := byISBN.Get(kind)
+				if path == "" {
+					continue
+				}
+				if imageSet.Get(kind) != "" {
+					// Already have it from an 856 link
+					os.Remove(path)
+					continue
+				}
+				imageSet.Set(kind, path, byISBN.Sources[kind])
+			}
+		} else if imageSet.Empty() {
+			return nil, err
+		}
+	}
+
+	if imageSet.Empty() {
+		return nil, fmt.Errorf("no usable 856 links and no ISBN")
+	}
+	return imageSet, nil
+}
+
+// linksForItem reads the 856 links of an item's reference record
+func linksForItem(ds *dataset.MARCDataset, item dataset.DatasetItem) []images.DigitalLink {
+	data, err := ds.ReadMARCXML(item)
+	if err != nil {
+		slog.Debug("Failed to read reference record", "id", item.ID, "error", err)
+		return nil
+	}
+	rec, err := marc.ParseXML(data)
+	if err != nil {
+		slog.Debug("Failed to parse reference record", "id", item.ID, "error", err)
+		return nil
+	}
+	return images.DigitalLinks(rec)
+}
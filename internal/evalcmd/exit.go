@@ -0,0 +1,78 @@
+package evalcmd
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/lehigh-university-libraries/cataloger/internal/eval/marceval"
+)
+
+// Exit codes of eval run, for schedulers driving unattended evaluations. Other errors exit 1.
+const (
+	ExitPass           = 0
+	ExitBelowThreshold = 2 // Mean score below --fail-below
+	ExitPartialFailure = 3 // Some records failed to generate or score
+)
+
+// ExitError is an error that should end the process with a specific exit code
+type ExitError struct {
+	Code int
+	Err  error
+}
+
+func (e *ExitError) Error() string { return e.Err.Error() }
+
+func (e *ExitError) Unwrap() error { return e.Err }
+
+// ExitCode returns the code the process should exit with
+func (e *ExitError) ExitCode() int { return e.Code }
+
+// RunSummary is the one-line JSON summary eval run prints last on stdout
+type RunSummary struct {
+	Status    string  `json:"status"` // pass, below_threshold or partial_failure
+	ExitCode  int     `json:"exit_code"`
+	Records   int     `json:"records"`
+	Succeeded int     `json:"succeeded"`
+	Failed    int     `json:"failed"`
+	MeanScore float64 `json:"mean_score"`
+	FailBelow float64 `json:"fail_below,omitempty"`
+	Output    string  `json:"output"`
+}
+
+// runOutcome decides how a run ends: below the --fail-below threshold (when set) takes
+// precedence over failed records. The error is nil when the run passed.
+func runOutcome(report *marceval.Report, failBelow float64, output string) (RunSummary, error) {
+	s := RunSummary{
+		Status:    "pass",
+		ExitCode:  ExitPass,
+		Records:   report.Records,
+		Succeeded: report.Succeeded,
+		Failed:    report.Failed,
+		MeanScore: report.MeanScore,
+		FailBelow: failBelow,
+		Output:    output,
+	}
+
+	var err error
+	switch {
+	case failBelow > 0 && report.MeanScore < failBelow:
+		s.Status, s.ExitCode = "below_threshold", ExitBelowThreshold
+		err = fmt.Errorf("mean score %.3f is below --fail-below %.3f", report.MeanScore, failBelow)
+	case report.Failed > 0:
+		s.Status, s.ExitCode = "partial_failure", ExitPartialFailure
+		err = fmt.Errorf("%d of %d records failed", report.Failed, report.Records)
+	}
+	if err != nil {
+		err = &ExitError{Code: s.ExitCode, Err: err}
+	}
+	return s, err
+}
+
+// printSummaryLine prints the run summary as a single JSON line
+func printSummaryLine(s RunSummary) {
+	data, err := json.Marshal(s)
+	if err != nil {
+		return
+	}
+	fmt.Println(string(data))
+}
@@ -0,0 +1,62 @@
+package evalcmd
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/lehigh-university-libraries/cataloger/internal/eval/marceval"
+)
+
+func TestRunOutcome(t *testing.T) {
+	tests := []struct {
+		name      string
+		failed    int
+		mean      float64
+		failBelow float64
+		status    string
+		code      int
+	}{
+		{"pass", 0, 0.8, 0.7, "pass", ExitPass},
+		{"pass without a threshold", 0, 0.2, 0, "pass", ExitPass},
+		{"below threshold", 0, 0.6, 0.7, "below_threshold", ExitBelowThreshold},
+		{"below threshold wins over failed records", 2, 0.6, 0.7, "below_threshold", ExitBelowThreshold},
+		{"partial failure", 2, 0.8, 0.7, "partial_failure", ExitPartialFailure},
+		{"partial failure without a threshold", 2, 0.2, 0, "partial_failure", ExitPartialFailure},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report := &marceval.Report{Records: 10, Succeeded: 10 - tt.failed, Failed: tt.failed, MeanScore: tt.mean}
+			s, err := runOutcome(report, tt.failBelow, "results.json")
+			if s.Status != tt.status || s.ExitCode != tt.code {
+				t.Errorf("outcome = %s (%d), want %s (%d)", s.Status, s.ExitCode, tt.status, tt.code)
+			}
+			if s.Records != 10 || s.Failed != tt.failed || s.MeanScore != tt.mean || s.Output != "results.json" {
+				t.Errorf("summary = %+v", s)
+			}
+
+			var exit *ExitError
+			if tt.code == ExitPass {
+				if err != nil {
+					t.Errorf("err = %v, want nil", err)
+				}
+			} else if !errors.As(err, &exit) || exit.ExitCode() != tt.code {
+				t.Errorf("err = %v, want an ExitError with code %d", err, tt.code)
+			}
+		})
+	}
+}
+
+func TestRunSummaryOmitsUnsetThreshold(t *testing.T) {
+	for _, failBelow := range []float64{0, 0.7} {
+		s, _ := runOutcome(&marceval.Report{Records: 1, Succeeded: 1, MeanScore: 0.9}, failBelow, "results.json")
+		data, err := json.Marshal(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := strings.Contains(string(data), `"fail_below"`); got != (failBelow > 0) {
+			t.Errorf("summary with --fail-below %v = %s", failBelow, data)
+		}
+	}
+}
@@ -0,0 +1,106 @@
+package evalcmd
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+
+	"github.com/lehigh-university-libraries/cataloger/internal/eval/dataset"
+	"github.com/lehigh-university-libraries/cataloger/internal/eval/export"
+	"github.com/spf13/cobra"
+)
+
+// NewExportHFCmd creates the export-hf command for packaging a dataset for HuggingFace
+func NewExportHFCmd() *cobra.Command {
+	var datasetDir string
+	var outputDir string
+	var name string
+	var license string
+	var shardSize int
+	var redact bool
+	var redactTags []string
+	var salt string
+	var verbose bool
+
+	cmd := &cobra.Command{
+		Use:   "export-hf",
+		Short: "Export an evaluation dataset in HuggingFace parquet layout",
+		Long: `Package a MARC evaluation dataset (images + MARCXML + metadata) into parquet
+files with a dataset card, ready to push to a HuggingFace dataset repository.
+
+The output directory can be uploaded as-is:
+  huggingface-cli upload <org>/<name> ./hf_export --repo-type dataset`,
+		Example: `  # Export an enriched dataset
+  cataloger eval export-hf --dataset ./enriched_data
+
+  # Custom output directory, name and license
+  cataloger eval export-hf --dataset ./enriched_data --output ./hf_export --name lehigh-monographs --license cc0-1.0
+
+  # Strip local identifiers (035, 9XX, holdings, barcodes) before publishing
+  cataloger eval export-hf --dataset ./enriched_data --redact --salt "$(openssl rand -hex 16)"`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if _, err := os.Stat(datasetDir); os.IsNotExist(err) {
+				return fmt.Errorf("dataset directory not found: %s", datasetDir)
+			}
+
+			opts := export.HFOptions{
+				OutputDir:   outputDir,
+				DatasetName: name,
+				License:     license,
+				ShardSize:   shardSize,
+			}
+			if redact {
+				redactor, err := newRedactor(redactTags, salt)
+				if err != nil {
+					return err
+				}
+				opts.Redactor = redactor
+			}
+
+			return executeExportHF(datasetDir, opts, verbose)
+		},
+	}
+
+	cmd.Flags().StringVar(&datasetDir, "dataset", "./enriched_data", "Path to MARC evaluation dataset directory")
+	cmd.Flags().StringVar(&outputDir, "output", "./hf_export", "Output directory for the HuggingFace dataset")
+	cmd.Flags().StringVar(&name, "name", "", "Dataset name for the dataset card (defaults to dataset directory name)")
+	cmd.Flags().StringVar(&license, "license", "", "License identifier for the dataset card (e.g. cc0-1.0)")
+	cmd.Flags().IntVar(&shardSize, "shard-size", 500, "Number of records per parquet file")
+	addRedactFlags(cmd, &redactTags, &salt)
+	cmd.Flags().BoolVar(&redact, "redact", false, "Strip local identifiers from reference records and pseudonymize item IDs")
+	cmd.Flags().BoolVar(&verbose, "verbose", false, "Verbose logging")
+
+	return cmd
+}
+
+func executeExportHF(datasetDir string, opts export.HFOptions, verbose bool) error {
+	logLevel := slog.LevelInfo
+	if verbose {
+		logLevel = slog.LevelDebug
+	}
+	slog.SetDefault(slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: logLevel})))
+
+	ds, err := dataset.LoadMARCDataset(datasetDir)
+	if err != nil {
+		return fmt.Errorf("failed to load dataset: %w", err)
+	}
+
+	slog.Info("Exporting dataset", "dataset", datasetDir, "items", len(ds.Index.Items), "output", opts.OutputDir)
+
+	summary, err := export.ExportHuggingFace(ds, opts)
+	if err != nil {
+		return fmt.Errorf("failed to export dataset: %w", err)
+	}
+
+	fmt.Printf("\nHuggingFace export complete!\n")
+	fmt.Printf("  Records exported: %d\n", summary.Rows)
+	fmt.Printf("  Records with images: %d\n", summary.WithImages)
+	fmt.Printf("  Skipped: %d\n", summary.Skipped)
+	if opts.Redactor != nil {
+		fmt.Printf("  Fields redacted: %d\n", summary.Redacted)
+	}
+	fmt.Printf("  Parquet files: %d\n", len(summary.Shards))
+	fmt.Printf("  Output location: %s\n", opts.OutputDir)
+
+	return nil
+}
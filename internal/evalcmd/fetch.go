@@ -0,0 +1,547 @@
+package evalcmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"math/rand/v2"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/lehigh-university-libraries/cataloger/internal/eval/dataset"
+	"github.com/lehigh-university-libraries/cataloger/internal/images"
+	"github.com/lehigh-university-libraries/cataloger/internal/marc"
+	"github.com/lehigh-university-libraries/cataloger/internal/oai"
+	"github.com/spf13/cobra"
+)
+
+// fetchOptions holds the flags for the fetch command
+type fetchOptions struct {
+	url            string
+	file           string
+	set            string
+	metadataPrefix string
+	from           string
+	until          string
+	windowDays     int
+	workers        int
+	delay          time.Duration
+	maxRecords     int
+	randomSample   int
+	seed           uint64
+	outputDir      string
+	name           string
+	booksOnly      bool
+	requireISBN    bool
+	exclude        []string
+	lcClasses      []string
+	locations      []string
+	locationFields []string
+	redactTags     []string
+	repairLeaders  bool
+	collection     string
+}
+
+// NewFetchCmd creates the fetch command for harvesting reference records over OAI-PMH
+func NewFetchCmd() *cobra.Command {
+	var opts fetchOptions
+
+	cmd := &cobra.Command{
+		Use:   "fetch",
+		Short: "Build an evaluation dataset from reference MARC records (OAI-PMH or a MARC file)",
+		Long: `Harvest MARCXML records from an OAI-PMH endpoint (--url), or read a local MARC export
+(--file, binary .mrc or MARCXML), into a MARC evaluation dataset (dataset.json plus
+records/<id>.xml). Filters such as --books-only, --require-isbn and --exclude apply to both.
+
+To build a dataset for one subject area or collection, --lc-class keeps records whose LC call
+number (050, 090, or 852 $h shelved by LC) is in any of the given ranges: a class (PS), a
+subclass (Q covers QA, QB, ...), or a range (QA76-QA76.9, PA-PT). --location keeps records
+held at any of the given location codes, read from 852 $b and $c or the --location-field
+subfields of a local holdings field (e.g. 949l). Both apply before --random-sample.
+
+--exclude drops whole records that contain a tag. To keep the record but hide local fields
+from the ground truth, use --redact-tag, which strips the tags before the record is saved.
+
+Large harvests are split into date windows (--window-days) harvested by parallel workers
+(--workers), each pausing --delay between requests and backing off when the server answers
+503 Retry-After. Records are merged into one dataset as they arrive: a record seen twice
+keeps its latest version, and deleted records are dropped.
+
+--random-sample N keeps a uniform random sample of N records from the whole harvest using
+reservoir sampling, instead of a dataset biased toward the earliest-cataloged records.
+--max-records caps how many records are harvested before stopping.
+
+Holdings, authority and community information records are dropped. When an OAI-PMH record
+holds several MARC records (a <collection>, or a holdings record beside the bib),
+--collection-records bib keeps its one bibliographic record and skips payloads with several;
+all keeps every bibliographic record as its own item.
+
+Some ILS exports write leaders of the wrong length or with invalid codes, which would
+otherwise misclassify the record. --repair-leaders (on by default) pads or trims the leader
+to 24 characters and resets its structural positions before filtering; each repaired item
+lists its leader_repairs in dataset.json, and eval run --exclude-repaired skips them.`,
+		Example: `  # Harvest everything, 30-day windows, 4 workers
+  cataloger eval fetch --url https://catalog.example.edu/oai --output ./eval_data
+
+  # One set, one year, gentle on the server
+  cataloger eval fetch --url https://catalog.example.edu/oai --set books \
+    --from 2024-01-01 --until 2024-12-31 --workers 2 --delay 2s
+
+  # Quick sample for a smoke test
+  cataloger eval fetch --url https://catalog.example.edu/oai --max-records 50
+
+  # 500 records drawn uniformly from the whole catalog
+  cataloger eval fetch --url https://catalog.example.edu/oai --random-sample 500 --seed 42
+
+  # Books with ISBNs from an ILS or MarcEdit export, skipping records with local 9XX fields
+  cataloger eval fetch --file export.mrc --require-isbn --exclude 9XX --random-sample 200
+
+  # 200 American literature and programming records from Special Collections
+  cataloger eval fetch --file export.mrc --lc-class PS,QA76-QA76.9 --location spec --random-sample 200
+
+  # Keep every book but strip local notes and item data from the reference records
+  cataloger eval fetch --file export.mrc --redact-tag 59X --redact-tag 9XX`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if (opts.url == "") == (opts.file == "") {
+				return fmt.Errorf("exactly one of --url or --file is required")
+			}
+			if !slices.Contains(marc.Selections, opts.collection) {
+				return fmt.Errorf("unknown --collection-records %q (%s)", opts.collection, strings.Join(marc.Selections, ", "))
+			}
+			if _, err := dataset.ParseClassRanges(opts.lcClasses); err != nil {
+				return err
+			}
+			return executeFetch(opts)
+		},
+	}
+
+	cmd.Flags().StringVar(&opts.url, "url", "", "OAI-PMH base URL")
+	cmd.Flags().StringVar(&opts.file, "file", "", "Local MARC export to read instead of OAI-PMH (.mrc or MARCXML)")
+	cmd.Flags().StringVar(&opts.set, "set", "", "OAI-PMH set to harvest")
+	cmd.Flags().StringVar(&opts.metadataPrefix, "metadata-prefix", "marc21", "OAI-PMH metadata prefix for MARCXML")
+	cmd.Flags().StringVar(&opts.from, "from", "", "Harvest records changed on or after this date (defaults to the repository's earliest datestamp)")
+	cmd.Flags().StringVar(&opts.until, "until", "", "Harvest records changed on or before this date (defaults to now)")
+	cmd.Flags().IntVar(&opts.windowDays, "window-days", 30, "Days per harvest window (0 harvests the range as one window)")
+	cmd.Flags().IntVar(&opts.workers, "workers", 4, "Windows harvested in parallel")
+	cmd.Flags().DurationVar(&opts.delay, "delay", time.Second, "Pause between requests from each worker")
+	cmd.Flags().IntVar(&opts.maxRecords, "max-records", -1, "Stop after harvesting this many records (-1 for all)")
+	cmd.Flags().IntVar(&opts.randomSample, "random-sample", 0, "Keep a uniform random sample of this many records from the harvest (0 keeps all)")
+	cmd.Flags().Uint64Var(&opts.seed, "seed", 0, "Random seed for --random-sample (0 for a random seed)")
+	cmd.Flags().StringVar(&opts.outputDir, "output", "./eval_data", "Output dataset directory")
+	cmd.Flags().StringVar(&opts.name, "name", "", "Dataset name recorded in dataset.json")
+	cmd.Flags().BoolVar(&opts.booksOnly, "books-only", true, "Keep only language material monographs (Leader/06-07)")
+	cmd.Flags().BoolVar(&opts.requireISBN, "require-isbn", false, "Keep only records with an ISBN (020 $a)")
+	cmd.Flags().StringSliceVar(&opts.exclude, "exclude", nil, "Drop records containing any of these tags (X is a wildcard, e.g. 9XX)")
+	cmd.Flags().StringVar(&opts.collection, "collection-records", marc.SelectBibliographic, "Records kept from multi-record OAI-PMH payloads: bib (the one bibliographic record) or all (every bibliographic record)")
+	cmd.Flags().StringSliceVar(&opts.lcClasses, "lc-class", nil, "Keep only records with an LC call number in these ranges (e.g. PS, Q, QA76-QA76.9, PA-PT)")
+	cmd.Flags().StringSliceVar(&opts.locations, "location", nil, "Keep only records held at these location codes")
+	cmd.Flags().StringSliceVar(&opts.locationFields, "location-field", dataset.DefaultLocationFields, "Holdings subfields --location codes are read from, as tag and code (e.g. 852b, 949l)")
+	cmd.Flags().StringSliceVar(&opts.redactTags, "redact-tag", nil, "Strip these tags from reference records before saving, keeping the record (X is a wildcard)")
+	cmd.Flags().BoolVar(&opts.repairLeaders, "repair-leaders", true, "Normalize malformed reference leaders and list the repairs on the dataset item")
+
+	return cmd
+}
+
+// errEnoughRecords stops a harvest once --max-records is reached
+var errEnoughRecords = errors.New("record limit reached")
+
+func executeFetch(opts fetchOptions) error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	source := opts.url
+	if opts.file != "" {
+		source = opts.file
+	}
+	ds := &dataset.MARCDataset{
+		Dir: opts.outputDir,
+		Index: dataset.DatasetIndex{
+			Name:      opts.name,
+			Source:    source,
+			CreatedAt: time.Now(),
+		},
+	}
+	m := newHarvestMerger(ds)
+	ranges, err := dataset.ParseClassRanges(opts.lcClasses)
+	if err != nil {
+		return err
+	}
+	m.filter = dataset.Filter{
+		BooksOnly:      opts.booksOnly,
+		RequireISBN:    opts.requireISBN,
+		ExcludeTags:    opts.exclude,
+		ClassRanges:    ranges,
+		Locations:      opts.locations,
+		LocationFields: opts.locationFields,
+	}
+	m.redactTags = opts.redactTags
+	m.repair = opts.repairLeaders
+	m.collection = opts.collection
+	if opts.randomSample > 0 {
+		seed := opts.seed
+		if seed == 0 {
+			seed = rand.Uint64()
+		}
+		m.sample = dataset.NewReservoir[string](opts.randomSample, rand.New(rand.NewPCG(seed, seed)))
+		slog.Info("Sampling harvest", "size", opts.randomSample, "seed", seed)
+	}
+
+	start := time.Now()
+	if opts.file != "" {
+		err = readMARCFile(ctx, opts, m)
+	} else {
+		err = harvestOAI(ctx, opts, m)
+	}
+	if err != nil && !errors.Is(err, errEnoughRecords) {
+		// Keep what was harvested so an interrupted run still leaves a usable dataset
+		slog.Error("Harvest stopped early", "error", err)
+	}
+
+	ds.Index.Items = m.sortedItems()
+	if saveErr := ds.Save(); saveErr != nil {
+		return saveErr
+	}
+
+	fmt.Printf("\nHarvest complete!\n")
+	fmt.Printf("  Records: %d\n", len(ds.Index.Items))
+	if m.sample != nil {
+		fmt.Printf("  Sampled from: %d\n", m.unique)
+	}
+	fmt.Printf("  Duplicates merged: %d\n", m.duplicates)
+	fmt.Printf("  Deleted: %d\n", m.deleted)
+	fmt.Printf("  Skipped (unparseable): %d\n", m.skipped)
+	if m.marc8 > 0 {
+		fmt.Printf("  Converted from MARC-8: %d\n", m.marc8)
+	}
+	if len(m.redactTags) > 0 {
+		fmt.Printf("  Fields redacted: %d\n", m.redacted)
+	}
+	if m.repair {
+		repaired := 0
+		for _, item := range ds.Index.Items {
+			if len(item.LeaderRepairs) > 0 {
+				repaired++
+			}
+		}
+		fmt.Printf("  Leaders repaired: %d\n", repaired)
+	}
+	for _, reason := range sortedKeys(m.filtered) {
+		fmt.Printf("  Filtered (%s): %d\n", reason, m.filtered[reason])
+	}
+	fmt.Printf("  Time: %s\n", time.Since(start).Round(time.Second))
+	fmt.Printf("  Output location: %s\n", opts.outputDir)
+
+	if err != nil && !errors.Is(err, errEnoughRecords) {
+		return fmt.Errorf("harvest incomplete: %w", err)
+	}
+	return nil
+}
+
+// harvestOAI harvests the OAI-PMH endpoint into the merger in parallel date windows
+func harvestOAI(ctx context.Context, opts fetchOptions, m *harvestMerger) error {
+	client := oai.NewClient(opts.url)
+	client.MetadataPrefix = opts.metadataPrefix
+	client.Set = opts.set
+	client.Delay = opts.delay
+
+	from, until, err := harvestRange(ctx, client, opts)
+	if err != nil {
+		return err
+	}
+
+	windows := oai.PlanWindows(from, until, time.Duration(opts.windowDays)*24*time.Hour, client.Granularity)
+	slog.Info("Planned harvest", "url", opts.url, "set", opts.set, "windows", len(windows), "workers", opts.workers)
+
+	return oai.Harvest(ctx, client, windows, opts.workers, func(r oai.Record) error {
+		if err := m.add(r); err != nil {
+			return err
+		}
+		return checkLimit(opts, m)
+	})
+}
+
+// readMARCFile reads a local binary MARC or MARCXML export into the merger
+func readMARCFile(ctx context.Context, opts fetchOptions, m *harvestMerger) error {
+	f, err := os.Open(opts.file)
+	if err != nil {
+		return fmt.Errorf("failed to open MARC file: %w", err)
+	}
+	defer f.Close()
+
+	reader, err := marc.NewReader(f)
+	if err != nil {
+		return err
+	}
+	defer func() { m.marc8 = reader.MARC8 }()
+
+	for n := 1; ; n++ {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		rec, err := reader.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			// Offsets are lost after a malformed binary record, so stop rather than misread the rest
+			return fmt.Errorf("record %d: %w", n, err)
+		}
+
+		identifier := fmt.Sprintf("%s#%d", filepath.Base(opts.file), n)
+		if err := m.addRecord(rec, identifier, "", rec.ControlField("005")); err != nil {
+			return err
+		}
+		if err := checkLimit(opts, m); err != nil {
+			return err
+		}
+	}
+}
+
+func checkLimit(opts fetchOptions, m *harvestMerger) error {
+	if opts.maxRecords > 0 && m.unique >= opts.maxRecords {
+		return errEnoughRecords
+	}
+	return nil
+}
+
+// harvestRange resolves --from/--until, asking the repository for its earliest datestamp
+// and granularity when needed
+func harvestRange(ctx context.Context, client *oai.Client, opts fetchOptions) (time.Time, time.Time, error) {
+	var from, until time.Time
+	var err error
+
+	if opts.from != "" {
+		if from, err = oai.ParseDatestamp(opts.from); err != nil {
+			return from, until, fmt.Errorf("invalid --from: %w", err)
+		}
+	}
+	until = time.Now().UTC()
+	if opts.until != "" {
+		if until, err = oai.ParseDatestamp(opts.until); err != nil {
+			return from, until, fmt.Errorf("invalid --until: %w", err)
+		}
+	}
+
+	if opts.windowDays <= 0 && opts.from == "" {
+		return from, until, nil
+	}
+
+	identity, err := client.Identify(ctx)
+	if err != nil {
+		return from, until, fmt.Errorf("failed to identify repository: %w", err)
+	}
+	if identity.Granularity == oai.GranularitySecond {
+		client.Granularity = oai.GranularitySecond
+	}
+	if from.IsZero() {
+		if from, err = oai.ParseDatestamp(identity.EarliestDatestamp); err != nil {
+			return from, until, fmt.Errorf("invalid earliestDatestamp from repository: %w", err)
+		}
+	}
+
+	return from, until, nil
+}
+
+// harvestMerger writes harvested records into a dataset as they arrive, keeping the latest
+// version of each record. Records are keyed by item ID (the 001 when present), so the same
+// bib exposed under two OAI identifiers is stored once. With a sample reservoir, only
+// sampled records are kept and evicted ones are removed from disk.
+type harvestMerger struct {
+	ds          *dataset.MARCDataset
+	items       map[string]dataset.DatasetItem
+	datestamps  map[string]string
+	identifiers map[string][]string // OAI identifier -> item IDs
+	collection  string              // Record selection for multi-record payloads
+	seen        map[string]bool
+	sample      *dataset.Reservoir[string]
+	filter      dataset.Filter
+	redactTags  []string
+	repair      bool // Normalize malformed leaders
+
+	unique     int
+	duplicates int
+	deleted    int
+	skipped    int
+	redacted   int
+	marc8      int            // Records converted from MARC-8
+	filtered   map[string]int // Reason -> count
+}
+
+func newHarvestMerger(ds *dataset.MARCDataset) *harvestMerger {
+	return &harvestMerger{
+		ds:          ds,
+		items:       make(map[string]dataset.DatasetItem),
+		datestamps:  make(map[string]string),
+		identifiers: make(map[string][]string),
+		seen:        make(map[string]bool),
+		filtered:    make(map[string]int),
+	}
+}
+
+func (m *harvestMerger) add(r oai.Record) error {
+	if r.Deleted {
+		for _, id := range m.identifiers[r.Identifier] {
+			if err := m.remove(id); err != nil {
+				return err
+			}
+		}
+		delete(m.identifiers, r.Identifier)
+		m.deleted++
+		return nil
+	}
+
+	records, err := marc.ParseXMLRecords(r.Metadata)
+	if err == nil && len(records) > 1 {
+		records, err = marc.SelectRecords(records, m.collection)
+	}
+	if err != nil {
+		slog.Warn("Skipping unparseable record", "identifier", r.Identifier, "error", err)
+		m.skipped++
+		return nil
+	}
+	for i, rec := range records {
+		identifier := r.Identifier
+		if len(records) > 1 {
+			identifier = fmt.Sprintf("%s#%d", r.Identifier, i+1)
+		}
+		if err := m.addRecord(rec, identifier, r.Identifier, r.Datestamp); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// addRecord filters, samples and stores one record; datestamp decides which duplicate is newest.
+// Records harvested over OAI-PMH are deleted with oaiIdentifier, which for records split from
+// a multi-record payload differs from identifier.
+func (m *harvestMerger) addRecord(rec *marc.Record, identifier, oaiIdentifier, datestamp string) error {
+	var repairs []string
+	if m.repair {
+		repairs = rec.RepairLeader()
+	}
+	if !rec.IsBibliographic() {
+		m.filtered["not bibliographic"]++
+		return nil
+	}
+	if ok, reason := m.filter.Match(rec); !ok {
+		m.filtered[reason]++
+		return nil
+	}
+
+	id := recordID(rec, identifier)
+	switch _, kept := m.items[id]; {
+	case kept:
+		m.duplicates++
+		if datestamp < m.datestamps[id] {
+			return nil
+		}
+	case m.seen[id]:
+		// Already passed over by the sample
+		m.duplicates++
+		return nil
+	default:
+		m.seen[id] = true
+		m.unique++
+		if m.sample != nil {
+			slot, evicted, replaced := m.sample.Offer(id)
+			if slot < 0 {
+				return nil
+			}
+			if replaced {
+				if err := m.remove(evicted); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	item := dataset.DatasetItem{
+		ID:          id,
+		ISBN:        images.CleanISBN(rec.SubfieldValue("020", "a")),
+		Title:       strings.TrimRight(rec.SubfieldValue("245", "a"), " /:;,."),
+		MARCXMLPath: filepath.Join("records", id+".xml"),
+		Metadata:    map[string]string{"source_identifier": identifier, "datestamp": datestamp},
+
+		LeaderRepairs: repairs,
+	}
+	if len(repairs) > 0 {
+		slog.Debug("Repaired reference leader", "id", id, "repairs", repairs)
+	}
+
+	if len(m.redactTags) > 0 {
+		m.redacted += rec.RemoveTags(m.redactTags)
+	}
+	data, err := rec.XML()
+	if err != nil {
+		return err
+	}
+	path := m.ds.Path(item.MARCXMLPath)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+
+	m.items[id] = item
+	m.datestamps[id] = datestamp
+	if oaiIdentifier != "" && !slices.Contains(m.identifiers[oaiIdentifier], id) {
+		m.identifiers[oaiIdentifier] = append(m.identifiers[oaiIdentifier], id)
+	}
+	return nil
+}
+
+// remove drops a kept item and its record file
+func (m *harvestMerger) remove(id string) error {
+	item, ok := m.items[id]
+	if !ok {
+		return nil
+	}
+	if err := os.Remove(m.ds.Path(item.MARCXMLPath)); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove record %s: %w", id, err)
+	}
+	delete(m.items, id)
+	delete(m.datestamps, id)
+	return nil
+}
+
+func (m *harvestMerger) sortedItems() []dataset.DatasetItem {
+	items := make([]dataset.DatasetItem, 0, len(m.items))
+	for _, item := range m.items {
+		items = append(items, item)
+	}
+	sort.Slice(items, func(i, j int) bool { return items[i].ID < items[j].ID })
+	return items
+}
+
+func sortedKeys(m map[string]int) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+var unsafeIDChars = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// recordID derives a filesystem-safe item ID from the 001, falling back to the last
+// segment of the OAI identifier
+func recordID(rec *marc.Record, identifier string) string {
+	id := strings.TrimSpace(rec.ControlField("001"))
+	if id == "" {
+		id = identifier
+		if i := strings.LastIndexAny(id, ":/"); i >= 0 {
+			id = id[i+1:]
+		}
+	}
+	return strings.Trim(unsafeIDChars.ReplaceAllString(id, "_"), "_")
+}
@@ -0,0 +1,47 @@
+package evalcmd
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/lehigh-university-libraries/cataloger/internal/eval/dataset"
+)
+
+const localRecord = `=LDR  00000nam a2200000 i 4500
+=001  loc001
+=245  10$aWalden /$cby Henry D. Thoreau.
+=590  \\$aLibrary copy signed by the author.
+=949  \\$i39151001234567$lstacks`
+
+func TestFetchRedactTags(t *testing.T) {
+	// --exclude drops the whole record
+	m := newHarvestMerger(&dataset.MARCDataset{Dir: t.TempDir()})
+	m.filter = dataset.Filter{ExcludeTags: []string{"9XX"}}
+	if err := m.addRecord(mnemonic(t, localRecord), "oai:1", "oai:1", "2024-01-01"); err != nil {
+		t.Fatal(err)
+	}
+	if len(m.items) != 0 || m.redacted != 0 {
+		t.Errorf("excluded record kept: %v", m.items)
+	}
+
+	// --redact-tag keeps it without the tagged fields
+	m = newHarvestMerger(&dataset.MARCDataset{Dir: t.TempDir()})
+	m.redactTags = []string{"59X", "9XX"}
+	if err := m.addRecord(mnemonic(t, localRecord), "oai:1", "oai:1", "2024-01-01"); err != nil {
+		t.Fatal(err)
+	}
+	items := m.sortedItems()
+	if len(items) != 1 || items[0].Title != "Walden" || m.redacted != 2 {
+		t.Fatalf("items = %+v, %d fields redacted", items, m.redacted)
+	}
+	data, err := os.ReadFile(m.ds.Path(items[0].MARCXMLPath))
+	if err != nil {
+		t.Fatal(err)
+	}
+	record := string(data)
+	if strings.Contains(record, `tag="590"`) || strings.Contains(record, `tag="949"`) || strings.Contains(record, "39151001234567") ||
+		!strings.Contains(record, "Walden") || !strings.Contains(record, "loc001") {
+		t.Errorf("saved record = %s", record)
+	}
+}
@@ -0,0 +1,13 @@
+package evalcmd
+
+import (
+	"github.com/lehigh-university-libraries/cataloger/internal/heartbeat"
+	"github.com/spf13/cobra"
+)
+
+// addHeartbeatFlags adds the heartbeat and stall warning flags of long-running commands
+func addHeartbeatFlags(cmd *cobra.Command, opts *heartbeat.Options) {
+	cmd.Flags().DurationVar(&opts.Interval, "heartbeat", heartbeat.DefaultInterval, "Log records done, in flight and the last completion this often (0 disables)")
+	cmd.Flags().DurationVar(&opts.StallAfter, "stall-after", heartbeat.DefaultStallAfter, "Warn at each heartbeat once no record has completed for this long (0 disables)")
+	cmd.Flags().StringVar(&opts.File, "heartbeat-file", "", "Rewrite this file with the run's progress as JSON at each heartbeat, for monitoring")
+}
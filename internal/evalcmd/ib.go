@@ -1,6 +1,7 @@
 package evalcmd
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"log/slog"
@@ -10,15 +11,39 @@ import (
 
 	"github.com/lehigh-university-libraries/cataloger/internal/cataloging"
 	"github.com/lehigh-university-libraries/cataloger/internal/eval/dataset"
+	"github.com/lehigh-university-libraries/cataloger/internal/eval/failure"
 	"github.com/lehigh-university-libraries/cataloger/internal/eval/metadata"
 	"github.com/lehigh-university-libraries/cataloger/internal/eval/metrics"
 	resultsutil "github.com/lehigh-university-libraries/cataloger/internal/eval/results"
+	"github.com/lehigh-university-libraries/cataloger/internal/heartbeat"
+	"github.com/lehigh-university-libraries/cataloger/internal/prompts"
+	"github.com/lehigh-university-libraries/cataloger/internal/routing"
+	"github.com/lehigh-university-libraries/cataloger/internal/usage"
 )
 
-func executeIB(datasetPath, outputJSON, outputReport string, sampleSize int, provider, model string, verbose bool) error {
+// ibOptions holds the flags for the ib command
+type ibOptions struct {
+	datasetPath   string
+	outputJSON    string
+	outputReport  string
+	sampleSize    int
+	provider      string
+	model         string
+	overridesPath string
+	routingPath   string
+	promptPins    []string
+	promptsDir    string
+	heartbeat     heartbeat.Options
+	verbose       bool
+}
+
+func executeIB(opts ibOptions) error {
+	datasetPath, outputJSON, outputReport := opts.datasetPath, opts.outputJSON, opts.outputReport
+	sampleSize, provider, model := opts.sampleSize, opts.provider, opts.model
+
 	// Set up logging
 	logLevel := slog.LevelInfo
-	if verbose {
+	if opts.verbose {
 		logLevel = slog.LevelDebug
 	}
 	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: logLevel}))
@@ -51,20 +76,59 @@ func executeIB(datasetPath, outputJSON, outputReport string, sampleSize int, pro
 
 	slog.Info("Dataset loaded", "records", len(records))
 
-	// Initialize cataloging service
+	// Initialize cataloging service with the prompt versions pinned for this run
 	catalogService := cataloging.NewService()
+	if opts.promptsDir != "" || len(opts.promptPins) > 0 {
+		selection, err := promptSelection(opts.promptsDir, opts.promptPins)
+		if err != nil {
+			return err
+		}
+		catalogService.SetPrompts(selection)
+	}
+	slog.Info("Using prompt", "version", catalogService.PromptVersion())
 
 	if model == "" {
 		model = catalogService.GetDefaultModel(provider)
 	}
 
+	// Load per-record provider/model overrides
+	var overrides map[string]dataset.Override
+	if opts.overridesPath != "" {
+		overrides, err = dataset.LoadOverrides(opts.overridesPath)
+		if err != nil {
+			return err
+		}
+		slog.Info("Loaded per-record overrides", "path", opts.overridesPath, "count", len(overrides))
+	}
+
+	// Load language/script routing rules
+	var router *routing.Router
+	if opts.routingPath != "" {
+		routingConfig, err := routing.LoadConfig(opts.routingPath)
+		if err != nil {
+			return err
+		}
+		router = routing.NewRouter(*routingConfig)
+		slog.Info("Loaded routing rules", "path", opts.routingPath, "rules", len(routingConfig.Rules))
+	}
+
 	// Run evaluation
+	tracker := usage.Start(usage.DefaultInterval)
+	monitor := heartbeat.Start(opts.heartbeat, len(records))
+	defer monitor.Stop()
 	results := make([]metrics.EvaluationResult, 0, len(records))
 
 	for i, record := range records {
 		slog.Info("Processing record", "index", i+1, "total", len(records), "barcode", record.BarcodeSource)
 
-		result := evaluateRecord(record, catalogService, provider, model)
+		override, route, decision := routeRecord(record, overrides, router)
+		recordProvider, recordModel := resolveRoute(catalogService, provider, model, override)
+		monitor.Begin()
+		result := evaluateRecord(record, catalogService, recordProvider, recordModel)
+		monitor.End(result.Error != "")
+		result.Routing = route
+		result.Script = decision.Script
+		result.Language = decision.Language
 		if result.Error != "" {
 			slog.Warn("Record processing failed", "barcode", record.BarcodeSource, "error", result.Error)
 		}
@@ -79,6 +143,8 @@ func executeIB(datasetPath, outputJSON, outputReport string, sampleSize int, pro
 	// Aggregate results
 	slog.Info("Aggregating results")
 	aggregated := metrics.AggregateEvaluationResults(results, provider, model)
+	runUsage := tracker.Stop()
+	aggregated.Usage = &runUsage
 
 	// Print summary
 	aggregated.PrintSummary()
@@ -99,7 +165,7 @@ func executeIB(datasetPath, outputJSON, outputReport string, sampleSize int, pro
 	}
 
 	// Save results in YAML format (HTR-style)
-	if err := resultsutil.SaveToYAML(provider, model, datasetPath, sampleSize, aggregated.Results); err != nil {
+	if err := resultsutil.SaveToYAML(provider, model, catalogService.PromptVersion(), datasetPath, sampleSize, aggregated.Results); err != nil {
 		fmt.Printf("Warning: Failed to save YAML results: %v\n", err)
 	}
 
@@ -112,23 +178,31 @@ func evaluateRecord(record dataset.InstitutionalBooksRecord, service *cataloging
 	startTime := time.Now()
 
 	result := metrics.EvaluationResult{
-		Barcode: record.BarcodeSource,
-		Title:   record.TitleSource,
-		Author:  record.AuthorSource,
+		Barcode:  record.BarcodeSource,
+		Title:    record.TitleSource,
+		Author:   record.AuthorSource,
+		Provider: provider,
+		Model:    model,
+
+		PromptVersion: service.PromptVersion(),
 	}
+	quality := record.OCRQuality()
+	result.OCRQuality = &quality
 
 	// Get title page OCR text
 	titlePageText := record.GetTitlePageText()
 	if titlePageText == "" {
 		result.Error = "No OCR text available for title page"
+		result.ErrorCode = failure.NoImage
 		result.ProcessingTime = time.Since(startTime)
 		return result
 	}
 
 	// Extract metadata from OCR using LLM
-	metadataJSON, err := service.ExtractMetadataFromOCR(titlePageText, provider, model)
+	metadataJSON, err := service.ExtractMetadataFromOCR(context.Background(), titlePageText, provider, model)
 	if err != nil {
 		result.Error = fmt.Sprintf("Metadata extraction failed: %v", err)
+		result.ErrorCode = failure.Classify(err, failure.ProviderError)
 		result.ProcessingTime = time.Since(startTime)
 		return result
 	}
@@ -140,6 +214,7 @@ func evaluateRecord(record dataset.InstitutionalBooksRecord, service *cataloging
 	var extractedMetadata metadata.BookMetadata
 	if err := json.Unmarshal([]byte(cleanedJSON), &extractedMetadata); err != nil {
 		result.Error = fmt.Sprintf("Failed to parse metadata JSON: %v", err)
+		result.ErrorCode = failure.ParseFailure
 		result.ProcessingTime = time.Since(startTime)
 		slog.Warn("Failed to parse metadata JSON", "barcode", record.BarcodeSource, "json", metadataJSON, "error", err)
 		return result
@@ -170,6 +245,74 @@ func evaluateRecord(record dataset.InstitutionalBooksRecord, service *cataloging
 	return result
 }
 
+// routeRecord returns the record's override and why it was chosen: "override" for its entry in
+// overrides, which wins over language routing, "language" for a routing rule, or "default" and
+// no override. The router, when set, also detects the title page's script and language.
+func routeRecord(record dataset.InstitutionalBooksRecord, overrides map[string]dataset.Override, router *routing.Router) (dataset.Override, string, routing.Decision) {
+	override := overrides[record.BarcodeSource]
+	route := "default"
+	if !override.IsZero() {
+		route = "override"
+	}
+
+	var decision routing.Decision
+	if router != nil {
+		decision = router.Route(record.GetTitlePageText())
+		if route == "default" && decision.Matched() {
+			override = dataset.Override{Provider: decision.Provider, Model: decision.Model}
+			route = "language"
+			slog.Debug("Routed by language", "barcode", record.BarcodeSource, "decision", decision.String())
+		}
+	}
+	return override, route, decision
+}
+
+// resolveRoute picks the provider/model for a record, applying a per-record override when present.
+// An override that only names a provider uses that provider's default model.
+func resolveRoute(service *cataloging.Service, provider, model string, override dataset.Override) (string, string) {
+	if override.IsZero() {
+		return provider, model
+	}
+
+	routedProvider := provider
+	if override.Provider != "" {
+		routedProvider = override.Provider
+	}
+
+	routedModel := override.Model
+	if routedModel == "" {
+		if routedProvider == provider {
+			routedModel = model
+		} else {
+			routedModel = service.GetDefaultModel(routedProvider)
+		}
+	}
+
+	return routedProvider, routedModel
+}
+
+// promptSelection loads the prompt library (builtin, overlaid with dir) and applies id=version pins
+func promptSelection(dir string, pins []string) (*prompts.Selection, error) {
+	lib := prompts.Builtin()
+	if dir != "" {
+		var err error
+		if lib, err = prompts.LoadDir(dir); err != nil {
+			return nil, err
+		}
+	}
+
+	parsed, err := prompts.ParsePins(pins)
+	if err != nil {
+		return nil, err
+	}
+
+	selection := &prompts.Selection{Library: lib, Pins: parsed}
+	if err := selection.Validate(); err != nil {
+		return nil, err
+	}
+	return selection, nil
+}
+
 func cleanJSON(s string) string {
 	s = strings.TrimSpace(s)
 	s = strings.TrimPrefix(s, "```json")
@@ -0,0 +1,49 @@
+package evalcmd
+
+import (
+	"testing"
+
+	"github.com/lehigh-university-libraries/cataloger/internal/cataloging"
+	"github.com/lehigh-university-libraries/cataloger/internal/eval/dataset"
+	"github.com/lehigh-university-libraries/cataloger/internal/routing"
+)
+
+func TestRouteRecord(t *testing.T) {
+	overrides := map[string]dataset.Override{
+		"39015012345678": {Provider: "openai", Model: "gpt-4o"},
+		"39015087654321": {Model: "qwen2.5vl:32b"},
+	}
+	router := routing.NewRouter(routing.Config{Rules: []routing.Rule{{Scripts: []string{routing.ScriptCyrillic}, Provider: "gemini", Model: "gemini-2.5-pro"}}})
+	russian := []string{"Война и мир\nЛев Толстой\nМосква 1869"}
+
+	tests := []struct {
+		name            string
+		barcode         string
+		pages           []string
+		route           string
+		provider, model string
+	}{
+		{"override", "39015012345678", nil, "override", "openai", "gpt-4o"},
+		{"model override", "39015087654321", nil, "override", "ollama", "qwen2.5vl:32b"},
+		{"unknown barcode", "39015000000000", nil, "default", "ollama", "llama3"},
+		{"override wins over language", "39015012345678", russian, "override", "openai", "gpt-4o"},
+		{"unknown barcode routed by language", "39015000000000", russian, "language", "gemini", "gemini-2.5-pro"},
+	}
+	service := cataloging.NewService()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			record := dataset.InstitutionalBooksRecord{BarcodeSource: tt.barcode, TextByPageGen: tt.pages}
+			override, route, _ := routeRecord(record, overrides, router)
+			provider, model := resolveRoute(service, "ollama", "llama3", override)
+			if route != tt.route || provider != tt.provider || model != tt.model {
+				t.Errorf("route = %s to %s/%s, want %s to %s/%s", route, provider, model, tt.route, tt.provider, tt.model)
+			}
+		})
+	}
+
+	// Without overrides or routing every record gets the run's provider and model
+	override, route, _ := routeRecord(dataset.InstitutionalBooksRecord{BarcodeSource: "39015012345678"}, nil, nil)
+	if provider, model := resolveRoute(service, "ollama", "llama3", override); route != "default" || provider != "ollama" || model != "llama3" {
+		t.Errorf("route = %s to %s/%s, want the run's", route, provider, model)
+	}
+}
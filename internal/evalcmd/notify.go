@@ -0,0 +1,77 @@
+package evalcmd
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/lehigh-university-libraries/cataloger/internal/eval/marceval"
+	"github.com/lehigh-university-libraries/cataloger/internal/notify"
+)
+
+// loadNotifier reads the notification config from path, or $NOTIFY_CONFIG when path is
+// empty. Returns nil when neither is set.
+func loadNotifier(path string) (*notify.Config, error) {
+	if path == "" {
+		path = os.Getenv("NOTIFY_CONFIG")
+	}
+	if path == "" {
+		return nil, nil
+	}
+	return notify.Load(path)
+}
+
+// notifyRun announces a finished run. A failed notification is logged, not returned, so
+// it doesn't change the run's outcome.
+func notifyRun(n *notify.Config, report *marceval.Report, summary RunSummary) {
+	if n == nil {
+		return
+	}
+	msg := runMessage(report, summary)
+	msg.Link = n.Link(summary.Output)
+	if err := n.Send(context.Background(), msg); err != nil {
+		slog.Warn("Failed to send run notification", "error", err)
+	}
+}
+
+// runMessage summarizes a run as a notification: outcome, counts, scores and field means
+func runMessage(report *marceval.Report, summary RunSummary) notify.Message {
+	model := report.Provider
+	if report.Model != "" {
+		model += "/" + report.Model
+	}
+
+	var b strings.Builder
+	row := func(label, format string, args ...any) {
+		fmt.Fprintf(&b, "%-14s %s\n", label, fmt.Sprintf(format, args...))
+	}
+	row("Status", "%s", summary.Status)
+	row("Model", "%s", model)
+	row("Dataset", "%s", report.Dataset)
+	row("Records", "%d (%d succeeded, %d failed)", report.Records, report.Succeeded, report.Failed)
+	row("Mean score", "%.1f%%", report.MeanScore*100)
+	row("Completeness", "%.1f%%", report.MeanCompleteness*100)
+	if summary.FailBelow > 0 {
+		row("Fail below", "%.1f%%", summary.FailBelow*100)
+	}
+	if len(report.FieldMeans) > 0 {
+		tags := make([]string, 0, len(report.FieldMeans))
+		for tag := range report.FieldMeans {
+			tags = append(tags, tag)
+		}
+		sort.Strings(tags)
+		b.WriteString("\nField scores\n")
+		for _, tag := range tags {
+			fmt.Fprintf(&b, "  %-12s %.1f%%\n", tag, report.FieldMeans[tag]*100)
+		}
+	}
+
+	return notify.Message{
+		Subject: fmt.Sprintf("Eval run %s: %s, mean score %.1f%%", summary.Status, model, report.MeanScore*100),
+		Table:   b.String(),
+		Failed:  summary.ExitCode != ExitPass,
+	}
+}
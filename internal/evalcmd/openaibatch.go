@@ -0,0 +1,103 @@
+package evalcmd
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/lehigh-university-libraries/cataloger/internal/adaptive"
+	"github.com/lehigh-university-libraries/cataloger/internal/eval/marceval"
+	"github.com/lehigh-university-libraries/cataloger/internal/heartbeat"
+	"github.com/lehigh-university-libraries/cataloger/internal/openai"
+	"github.com/lehigh-university-libraries/cataloger/internal/providers"
+)
+
+// maxBatchRounds bounds the rounds of an --openai-batch run. An item takes a round per stage
+// of its pipeline (title page OCR, copyright page OCR, generation, a refusal's retry), so
+// one still waiting after this many keeps asking for something new each round.
+const maxBatchRounds = 8
+
+// startOpenAIBatch registers the provider answering openai requests from the Batch API
+func startOpenAIBatch(dir string, poll time.Duration) (*openai.Batch, error) {
+	batch, err := openai.NewBatch(dir)
+	if err != nil {
+		return nil, err
+	}
+	batch.PollInterval = poll
+	providers.Register(batch.Registration())
+	return batch, nil
+}
+
+// viaBatch returns the provider a request to provider is sent through when batching
+func viaBatch(provider string) string {
+	if provider == openai.Registration.Name {
+		return openai.BatchProviderName
+	}
+	return provider
+}
+
+// evaluateInBatches evaluates n items in rounds against batch. Each round evaluates the items
+// not yet done; an item whose requests were queued for the batch is evaluated again after the
+// batch is submitted and its responses collected, and the rest are emitted in order as with
+// evaluateConcurrently. Items routed to other providers are done in the first round.
+func evaluateInBatches(batch *openai.Batch, n int, limiter *adaptive.Limiter, monitor *heartbeat.Monitor, evaluate func(ctx context.Context, i int) marceval.Result, emit func(i int, result marceval.Result) error) error {
+	done := make([]*marceval.Result, n)
+	remaining := make([]int, n)
+	for i := range remaining {
+		remaining[i] = i
+	}
+	next := 0
+	for round := 1; len(remaining) > 0; round++ {
+		last := round == maxBatchRounds
+		waiting := make([]bool, len(remaining))
+		err := evaluateConcurrently(len(remaining), limiter, func(j int) marceval.Result {
+			monitor.Begin()
+			ctx, wait := openai.WithBatchWait(context.Background())
+			result := evaluate(ctx, remaining[j])
+			if wait.Waiting() && !last {
+				monitor.Requeue()
+				waiting[j] = true
+				// Not a provider failure, so --concurrency auto doesn't back off
+				result.ErrorCode = ""
+				return result
+			}
+			monitor.End(result.Error != "")
+			return result
+		}, func(j int, result marceval.Result) error {
+			if !waiting[j] {
+				done[remaining[j]] = &result
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		for ; next < n && done[next] != nil; next++ {
+			if err := emit(next, *done[next]); err != nil {
+				return err
+			}
+			done[next] = nil
+		}
+
+		var still []int
+		for j, i := range remaining {
+			if waiting[j] {
+				still = append(still, i)
+			}
+		}
+		remaining = still
+		if len(remaining) == 0 {
+			break
+		}
+		if batch.Queued() == 0 {
+			return fmt.Errorf("%d items are waiting on the OpenAI batch, but no request is queued", len(remaining))
+		}
+		slog.Info("Submitting OpenAI batch", "round", round, "requests", batch.Queued(), "waiting_items", len(remaining))
+		if err := batch.Submit(context.Background()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
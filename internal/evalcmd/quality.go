@@ -0,0 +1,198 @@
+package evalcmd
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/lehigh-university-libraries/cataloger/internal/eval/dataset"
+	"github.com/lehigh-university-libraries/cataloger/internal/eval/failure"
+	"github.com/lehigh-university-libraries/cataloger/internal/eval/marceval"
+	"github.com/lehigh-university-libraries/cataloger/internal/images"
+	"github.com/lehigh-university-libraries/cataloger/internal/marc"
+	"github.com/spf13/cobra"
+)
+
+// NewQualityCmd creates the quality command for scoring incoming MARC files
+func NewQualityCmd() *cobra.Command {
+	var datasetDir string
+	var outputJSON string
+	var profileName string
+	var scorers []string
+	var verbose bool
+
+	cmd := &cobra.Command{
+		Use:   "quality <file>...",
+		Short: "Score incoming MARC records (vendor, shelf-ready) for quality",
+		Long: `Run the validator and completeness checks used for generated records over arbitrary
+MARC files (binary .mrc or MARCXML), such as vendor or shelf-ready records, and print a
+quality report.
+
+With --dataset, records are also matched to reference records by ISBN or 001 and scored
+with the same field comparison as eval run.
+
+--completeness-profile selects the required elements: core (default), pcc-bsr for the PCC
+BIBCO Standard Record, or a YAML file with a name and a list of elements.`,
+		Example: `  # Quality report for a vendor load
+  cataloger eval quality vendor-2024-06.mrc
+
+  # Also compare against reference records, and keep the per-record details
+  cataloger eval quality vendor.mrc --dataset ./eval_data --output-json vendor_quality.json
+
+  # Check a load against the PCC BSR core elements
+  cataloger eval quality vendor.mrc --completeness-profile pcc-bsr`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			profile, err := marceval.LoadProfile(profileName)
+			if err != nil {
+				return err
+			}
+
+			var references map[string]*marc.Record
+			if datasetDir != "" {
+				ds, err := dataset.LoadMARCDataset(datasetDir)
+				if err != nil {
+					return fmt.Errorf("failed to load dataset: %w", err)
+				}
+				references = indexReferences(loadReferences(ds, -1))
+
+				stopScorers, err := startScorers(scorers)
+				if err != nil {
+					return err
+				}
+				defer stopScorers()
+			}
+
+			var results []marceval.Result
+			for _, path := range args {
+				fileResults, err := scoreMARCFile(path, references, profile)
+				if err != nil {
+					return err
+				}
+				results = append(results, fileResults...)
+			}
+
+			report := marceval.NewReport(results)
+			report.Dataset = datasetDir
+			report.Provider = "file"
+			report.Model = strings.Join(args, ",")
+			report.CompletenessProfile = profile.Name
+			report.PrintSummary()
+
+			if verbose {
+				for _, res := range results {
+					for _, issue := range res.Issues {
+						fmt.Printf("%s\t%s\t%s\t%s\n", res.ID, issue.Severity, issue.Tag, issue.Message)
+					}
+					for _, name := range res.MissingElements {
+						fmt.Printf("%s\tmissing\t\t%s\n", res.ID, name)
+					}
+				}
+			}
+
+			if outputJSON != "" {
+				if err := report.SaveJSON(outputJSON); err != nil {
+					return err
+				}
+				fmt.Printf("\nQuality report saved to: %s\n", outputJSON)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&datasetDir, "dataset", "", "MARC evaluation dataset with reference records to compare against")
+	cmd.Flags().StringVar(&outputJSON, "output-json", "", "Path to save the per-record report as JSON")
+	cmd.Flags().StringVar(&profileName, "completeness-profile", marceval.CoreProfile.Name, "Completeness profile: builtin name (core, pcc-bsr) or YAML file")
+	cmd.Flags().StringArrayVar(&scorers, "scorer-plugin", nil, "Scorer plugin command to register when comparing with --dataset (repeatable; default $SCORER_PLUGINS)")
+	cmd.Flags().BoolVar(&verbose, "verbose", false, "Print every validation issue and missing required element")
+
+	return cmd
+}
+
+// scoreMARCFile validates every record in a file, comparing it with its reference when one matches
+func scoreMARCFile(path string, references map[string]*marc.Record, profile marceval.CompletenessProfile) ([]marceval.Result, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	defer f.Close()
+
+	reader, err := marc.NewReader(f)
+	if err != nil {
+		return nil, err
+	}
+
+	var results []marceval.Result
+	for n := 1; ; n++ {
+		start := time.Now()
+		rec, err := reader.Next()
+		if err == io.EOF {
+			return results, nil
+		}
+		if err != nil {
+			slog.Warn("Stopping at unreadable record", "file", path, "record", n, "error", err)
+			results = append(results, marceval.Result{ID: fmt.Sprintf("%s#%d", filepath.Base(path), n), Error: err.Error(), ErrorCode: failure.ParseFailure})
+			return results, nil
+		}
+
+		result := marceval.Result{
+			ID:            firstNonEmpty(rec.ControlField("001"), fmt.Sprintf("%s#%d", filepath.Base(path), n)),
+			Title:         strings.TrimRight(rec.SubfieldValue("245", "a"), " /:;,."),
+			GeneratedMARC: rec.Mnemonic(),
+			Issues:        marc.Validate(rec),
+		}
+		result.Completeness, result.PresentElements, result.MissingElements = profile.Check(rec)
+		result.Consistency, result.Inconsistencies = marceval.CheckConsistency(rec)
+		if ref := matchReference(rec, references); ref != nil {
+			result.Comparison = marceval.Compare(ref, rec)
+		}
+		result.ProcessingTime = time.Since(start)
+		results = append(results, result)
+	}
+}
+
+// indexReferences keys reference records by normalized ISBN and by 001
+func indexReferences(refs []reference) map[string]*marc.Record {
+	index := make(map[string]*marc.Record)
+	for _, ref := range refs {
+		for _, key := range recordKeys(ref.Record) {
+			index[key] = ref.Record
+		}
+	}
+	return index
+}
+
+func matchReference(rec *marc.Record, references map[string]*marc.Record) *marc.Record {
+	for _, key := range recordKeys(rec) {
+		if ref, ok := references[key]; ok {
+			return ref
+		}
+	}
+	return nil
+}
+
+func recordKeys(rec *marc.Record) []string {
+	var keys []string
+	for _, f := range rec.Fields("020") {
+		if isbn := images.CleanISBN(f.Subfield("a")); isbn != "" {
+			keys = append(keys, "isbn:"+isbn)
+		}
+	}
+	if id := strings.TrimSpace(rec.ControlField("001")); id != "" {
+		keys = append(keys, "001:"+id)
+	}
+	return keys
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
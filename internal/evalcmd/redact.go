@@ -0,0 +1,90 @@
+package evalcmd
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+
+	"github.com/lehigh-university-libraries/cataloger/internal/eval/dataset"
+	"github.com/spf13/cobra"
+)
+
+// NewRedactCmd creates the redact command for writing a shareable copy of a dataset
+func NewRedactCmd() *cobra.Command {
+	var datasetDir string
+	var outputDir string
+	var redactTags []string
+	var salt string
+
+	cmd := &cobra.Command{
+		Use:   "redact",
+		Short: "Write a copy of a dataset with local identifiers removed",
+		Long: `Strip local identifiers from harvested reference records so a dataset can be
+shared across institutions without leaking internal data.
+
+By default this removes 001/003 (local control numbers), 035 (system control numbers),
+59X (local notes), 852/86X/876-878 (holdings and item data) and 9XX (local fields,
+typically barcodes). Item IDs are replaced with a salted hash and metadata keys that
+look like barcodes, holdings or locations are dropped.
+
+The salt (--salt or CATALOGER_REDACT_SALT) is required: without one, hashed barcodes could be
+reversed by hashing every possible barcode. Keep it private, and reuse it to give items the
+same IDs in a later redaction.`,
+		Example: `  # Redact with the default tag list
+  cataloger eval redact --dataset ./eval_data --output ./eval_data_shared --salt "$(openssl rand -hex 16)"
+
+  # Only strip 035 and 9XX, salted from CATALOGER_REDACT_SALT
+  cataloger eval redact --dataset ./eval_data --output ./eval_data_shared --redact-tags 035,9XX`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if datasetDir == outputDir {
+				return fmt.Errorf("--output must differ from --dataset")
+			}
+			redactor, err := newRedactor(redactTags, salt)
+			if err != nil {
+				return err
+			}
+
+			ds, err := dataset.LoadMARCDataset(datasetDir)
+			if err != nil {
+				return fmt.Errorf("failed to load dataset: %w", err)
+			}
+
+			slog.Info("Redacting dataset", "dataset", datasetDir, "items", len(ds.Index.Items), "tags", redactor.Tags)
+
+			out, removed, err := redactor.RedactDataset(ds, outputDir)
+			if err != nil {
+				return fmt.Errorf("failed to redact dataset: %w", err)
+			}
+
+			fmt.Printf("\nRedaction complete!\n")
+			fmt.Printf("  Records: %d\n", len(out.Index.Items))
+			fmt.Printf("  Fields removed: %d\n", removed)
+			fmt.Printf("  Output location: %s\n", outputDir)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&datasetDir, "dataset", "./eval_data", "Path to MARC evaluation dataset directory")
+	cmd.Flags().StringVar(&outputDir, "output", "", "Output directory for the redacted dataset (required)")
+	addRedactFlags(cmd, &redactTags, &salt)
+
+	_ = cmd.MarkFlagRequired("output")
+	return cmd
+}
+
+// addRedactFlags registers the flags shared by commands that can redact reference records
+func addRedactFlags(cmd *cobra.Command, tags *[]string, salt *string) {
+	cmd.Flags().StringSliceVar(tags, "redact-tags", dataset.DefaultRedactTags, "Tags to strip when redacting (X is a wildcard, e.g. 9XX)")
+	cmd.Flags().StringVar(salt, "salt", os.Getenv("CATALOGER_REDACT_SALT"), "Salt for pseudonymous item IDs, required when redacting (keep private to prevent reversing barcodes)")
+}
+
+// newRedactor returns a redactor stripping tags and hashing item IDs with salt, which must be set
+func newRedactor(tags []string, salt string) (*dataset.Redactor, error) {
+	if salt == "" {
+		return nil, fmt.Errorf("--salt (or CATALOGER_REDACT_SALT) is required to hash item IDs; generate one with: openssl rand -hex 16")
+	}
+	redactor := dataset.NewRedactor()
+	redactor.Tags = tags
+	redactor.Salt = salt
+	return redactor, nil
+}
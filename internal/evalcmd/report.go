@@ -0,0 +1,68 @@
+package evalcmd
+
+import (
+	"fmt"
+
+	"github.com/lehigh-university-libraries/cataloger/internal/eval/marceval"
+	"github.com/spf13/cobra"
+)
+
+// NewReportCmd creates the report command for summarizing large results files
+func NewReportCmd() *cobra.Command {
+	var (
+		workers    int
+		outputJSON string
+		trends     bool
+		trendOpts  trendsOptions
+	)
+
+	cmd := &cobra.Command{
+		Use:   "report <results | results root>",
+		Short: "Summarize an eval run results file with bounded memory",
+		Long: `Print the summary report of an eval run from its results, streaming them instead of
+loading them all: a .jsonl or .parquet file written with eval run --results-file, or a
+report JSON file written with --output-json.
+
+Results are decoded and aggregated on --workers goroutines, so tens of thousands of
+records are summarized in seconds with memory that doesn't grow with the record count.
+
+With --trends the argument is a results root instead, such as the --output of eval daemon or
+a directory of saved --output-json reports. Every run report under it is loaded and
+accuracy-over-time charts are written to --charts-dir: mean score by model, each model's
+score by field, and each field's score by model, as an HTML page (index.html) and PNG
+images, to show whether prompt and model changes improve things over time.`,
+		Example: `  cataloger eval run --dataset ./eval_data --results-file results.parquet
+  cataloger eval report results.parquet --output-json summary.json
+
+  # Chart accuracy over time for every run under a results root
+  cataloger eval report ./eval_history --trends --charts-dir ./eval_trends --fields 245,100,650`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if trends {
+				return writeTrends(args[0], trendOpts)
+			}
+			report, err := marceval.StreamReport(args[0], workers)
+			if err != nil {
+				return err
+			}
+			report.PrintSummary()
+
+			if outputJSON != "" {
+				if err := report.SaveJSON(outputJSON); err != nil {
+					return err
+				}
+				fmt.Printf("\nSummary saved to: %s\n", outputJSON)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().IntVar(&workers, "workers", 0, "Goroutines decoding results (default: number of CPUs)")
+	cmd.Flags().StringVar(&outputJSON, "output-json", "", "Save the summary, without per-record results, to a JSON file")
+	cmd.Flags().BoolVar(&trends, "trends", false, "Chart accuracy over time for all run reports under the results root")
+	cmd.Flags().StringVar(&trendOpts.outputDir, "charts-dir", "./eval_trends", "Directory for --trends charts")
+	cmd.Flags().StringSliceVar(&trendOpts.formats, "chart-format", []string{"html", "png"}, "--trends chart formats: html, png")
+	cmd.Flags().StringSliceVar(&trendOpts.fields, "fields", nil, "Tags to chart with --trends (default: every scored tag)")
+
+	return cmd
+}
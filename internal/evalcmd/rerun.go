@@ -0,0 +1,204 @@
+package evalcmd
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/lehigh-university-libraries/cataloger/internal/cataloging"
+	"github.com/lehigh-university-libraries/cataloger/internal/eval/dataset"
+	"github.com/lehigh-university-libraries/cataloger/internal/eval/failure"
+	"github.com/lehigh-university-libraries/cataloger/internal/eval/marceval"
+	"github.com/lehigh-university-libraries/cataloger/internal/hooks"
+	"github.com/lehigh-university-libraries/cataloger/internal/ocr"
+	"github.com/spf13/cobra"
+)
+
+// rerunOptions holds the flags for the rerun-failures command
+type rerunOptions struct {
+	datasetDir string
+	outputJSON string
+	only       []string
+	scorers    []string
+	copyright  bool
+	materials  bool
+	anomalies  bool
+	provenance bool
+	fromImages bool
+	verbose    bool
+}
+
+// NewRerunFailuresCmd creates the rerun-failures command for retrying the failed records of an eval run
+func NewRerunFailuresCmd() *cobra.Command {
+	var opts rerunOptions
+
+	cmd := &cobra.Command{
+		Use:   "rerun-failures <results.json>",
+		Short: "Rerun the failed records of an eval run",
+		Long: `Rerun the records that failed in an eval run results file, with the provider and model
+each record used, and update the results and report.
+
+Failures are categorized by code:
+  provider_timeout  An LLM or OCR request timed out
+  provider_error    An LLM or OCR request failed otherwise
+  refused           The provider refused or a safety filter blocked the request, also after a retry
+  parse_failure     The model's response could not be parsed
+  degenerate_output The response was empty, repetitive, a refusal or in the wrong language
+  no_image          No title page image to generate from
+  no_input          Other input missing
+  comparison_error  The reference record could not be read or compared
+  unknown           Failures saved before failures were categorized
+
+--only limits the rerun to some codes, e.g. to retry timeouts after a busy night without
+paying again for records a model can't parse.`,
+		Example: `  # Retry only the timeouts, updating the results file
+  cataloger eval rerun-failures eval_run_results.json --only provider_timeout
+
+  # Retry every failure into a new file
+  cataloger eval rerun-failures eval_run_results.json --output-json rerun.json`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			for _, code := range opts.only {
+				if _, err := failure.Parse(code); err != nil {
+					return err
+				}
+			}
+			return executeRerun(args[0], opts)
+		},
+	}
+
+	cmd.Flags().StringVar(&opts.datasetDir, "dataset", "", "Path to MARC evaluation dataset directory (default: the dataset of the run)")
+	cmd.Flags().StringVar(&opts.outputJSON, "output-json", "", "Path to output JSON results file (default: update the results file)")
+	cmd.Flags().StringSliceVar(&opts.only, "only", nil, "Rerun only failures with these codes (default: all failures)")
+	cmd.Flags().StringArrayVar(&opts.scorers, "scorer-plugin", nil, "Scorer plugin command to register (repeatable; default $SCORER_PLUGINS)")
+	cmd.Flags().BoolVar(&opts.copyright, "copyright-pass", true, "Run the copyright page pass for items with a copyright page image")
+	cmd.Flags().BoolVar(&opts.materials, "material-prompts", true, "Select the metadata prompt by the reference record's material type")
+	cmd.Flags().BoolVar(&opts.anomalies, "detect-anomalies", true, "Fail degenerate outputs (empty, repetitive, refused or wrong-language) instead of scoring them")
+	cmd.Flags().BoolVar(&opts.provenance, "score-provenance-note", false, "Score the institution profile's provenance_note 500 instead of leaving it out of comparisons")
+	cmd.Flags().BoolVar(&opts.fromImages, "from-images", false, "Generate from all of an item's page images in one request instead of from their OCR text")
+	cmd.Flags().BoolVar(&opts.verbose, "verbose", false, "Verbose logging")
+
+	return cmd
+}
+
+func executeRerun(resultsPath string, opts rerunOptions) error {
+	logLevel := slog.LevelInfo
+	if opts.verbose {
+		logLevel = slog.LevelDebug
+	}
+	slog.SetDefault(slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: logLevel})))
+
+	report, err := loadRunReport(resultsPath)
+	if err != nil {
+		return err
+	}
+
+	datasetDir := opts.datasetDir
+	if datasetDir == "" {
+		datasetDir = report.Dataset
+	}
+	ds, err := dataset.LoadMARCDataset(datasetDir)
+	if err != nil {
+		return fmt.Errorf("failed to load dataset: %w", err)
+	}
+	items := make(map[string]dataset.DatasetItem, len(ds.Index.Items))
+	for _, item := range ds.Index.Items {
+		items[item.ID] = item
+	}
+
+	stopScorers, err := startScorers(opts.scorers)
+	if err != nil {
+		return err
+	}
+	defer stopScorers()
+
+	profile, err := marceval.LoadProfile(report.CompletenessProfile)
+	if err != nil {
+		return err
+	}
+	itemOpts := itemOptions{
+		profile:    profile,
+		copyright:  opts.copyright,
+		materials:  opts.materials,
+		anomalies:  opts.anomalies,
+		provenance: opts.provenance,
+		fromImages: opts.fromImages,
+	}
+
+	catalogService := cataloging.NewService()
+	ocrService := ocr.NewService()
+	if len(report.PostHooks) > 0 {
+		commands := make([]string, len(report.PostHooks))
+		for i, h := range report.PostHooks {
+			commands[i] = h.Command
+		}
+		catalogService.SetHooks(hooks.Parse(commands))
+	}
+
+	output := opts.outputJSON
+	if output == "" {
+		output = resultsPath
+	}
+
+	results := report.Results
+	rerun, recovered := 0, 0
+	for i, res := range results {
+		code := res.FailureCode()
+		if code == "" || (len(opts.only) > 0 && !slices.Contains(opts.only, string(code))) {
+			continue
+		}
+		item, ok := items[res.ID]
+		if !ok {
+			slog.Warn("Failed record is not in the dataset", "id", res.ID)
+			continue
+		}
+
+		provider, model := res.Provider, res.Model
+		if provider == "" {
+			provider, model = report.Provider, report.Model
+		}
+		result := evaluateItem(context.Background(), ds, item, catalogService, ocrService, provider, model, provider, model, itemOpts)
+		// Keep the run's ground-truth audit of the reference
+		result.SuspectReference, result.ReferenceWeight = res.SuspectReference, res.ReferenceWeight
+		report.Penalties.Apply(result.Comparison)
+		rerun++
+		if result.Error != "" {
+			slog.Warn("Item still failing", "id", item.ID, "code", result.ErrorCode, "error", result.Error)
+		} else {
+			recovered++
+			slog.Debug("Item recovered", "id", item.ID, "was", code)
+		}
+		// Keep the blobs of a run that stored them apart
+		var blobs *marceval.BlobDir
+		if res.Blob != "" {
+			if blobs, err = marceval.NewBlobDir(filepath.Dir(res.BlobPath(resultsPath)), output); err != nil {
+				return err
+			}
+		}
+		if err := storeBlob(blobs, &result); err != nil {
+			return err
+		}
+		results[i] = result
+	}
+
+	updated := marceval.NewReport(results)
+	updated.Dataset = report.Dataset
+	updated.Provider = report.Provider
+	updated.Model = report.Model
+	updated.PromptVersion = report.PromptVersion
+	updated.PostHooks = report.PostHooks
+	updated.CompletenessProfile = report.CompletenessProfile
+	updated.Penalties = report.Penalties
+	updated.Usage = report.Usage
+	updated.PrintSummary()
+	fmt.Printf("\nReran %d failed records: %d recovered, %d still failing\n", rerun, recovered, rerun-recovered)
+
+	if err := updated.SaveJSON(output); err != nil {
+		return fmt.Errorf("failed to save results: %w", err)
+	}
+	fmt.Printf("Results saved to: %s\n", output)
+	return nil
+}
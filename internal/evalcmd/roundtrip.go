@@ -0,0 +1,247 @@
+package evalcmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/lehigh-university-libraries/cataloger/internal/cataloging"
+	"github.com/lehigh-university-libraries/cataloger/internal/eval/dataset"
+	"github.com/lehigh-university-libraries/cataloger/internal/eval/failure"
+	"github.com/lehigh-university-libraries/cataloger/internal/eval/marceval"
+	"github.com/lehigh-university-libraries/cataloger/internal/eval/roundtrip"
+	"github.com/lehigh-university-libraries/cataloger/internal/mock"
+	"github.com/lehigh-university-libraries/cataloger/internal/objectstore"
+	"github.com/spf13/cobra"
+)
+
+// roundTripOptions holds the flags for the roundtrip command
+type roundTripOptions struct {
+	datasetDir  string
+	sampleSize  int
+	provider    string
+	model       string
+	comparePath string
+	outputJSON  string
+	scorers     []string
+	verbose     bool
+}
+
+// roundTripReport is the JSON written by --output-json
+type roundTripReport struct {
+	Dataset     string
+	Provider    string
+	Model       string
+	Compare     string `json:",omitempty"`
+	Attribution roundtrip.Attribution
+	Results     []marceval.Result // Round-trip stage
+}
+
+// NewRoundTripCmd creates the roundtrip command for measuring information loss through the pipeline
+func NewRoundTripCmd() *cobra.Command {
+	var opts roundTripOptions
+
+	cmd := &cobra.Command{
+		Use:   "roundtrip",
+		Short: "Measure information lost through the pipeline by regenerating reference records",
+		Long: `Regenerate each reference record from its own description and score what comes back,
+to tell apart where a model run loses information.
+
+Each record is scored at two stages, with no images involved:
+
+  mapping    the reference's metadata (title, author, imprint, ISBNs, ...) read straight from
+             the record and mapped back to MARC: what the metadata schema and MARC mapping
+             cannot carry, whatever the model does
+  roundtrip  a title page transcription rendered from the reference, sent through the
+             metadata prompt and response parsing with --provider and --model
+
+Their difference is the prompt and parse loss. With --compare naming the results of an eval
+run of the same model on the same dataset, the run's scores are a third stage, and the drop
+from the round trip to the run is the vision/OCR loss. Only records scored at every stage
+count.`,
+		Example: `  # Mapping and prompt losses, offline
+  cataloger eval roundtrip --dataset ./eval_data --provider mock
+
+  # Split a model run's losses between prompt and vision
+  cataloger eval roundtrip --dataset ./eval_data --provider openai --model gpt-4o --compare eval_run_results.json`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if _, err := os.Stat(opts.datasetDir); os.IsNotExist(err) {
+				return fmt.Errorf("dataset directory not found: %s", opts.datasetDir)
+			}
+			return executeRoundTrip(opts)
+		},
+	}
+
+	cmd.Flags().StringVar(&opts.datasetDir, "dataset", "./eval_data", "Path to MARC evaluation dataset directory")
+	cmd.Flags().IntVar(&opts.sampleSize, "sample", -1, "Number of items to evaluate (-1 for all)")
+	cmd.Flags().StringVar(&opts.provider, "provider", "ollama", "LLM provider (ollama, openai, azure-openai, gemini, claude, groq, huggingface, llamacpp, mistral, or mock)")
+	cmd.Flags().StringVar(&opts.model, "model", "", "Model name (defaults to provider's default)")
+	cmd.Flags().StringVar(&opts.comparePath, "compare", "", "eval run results (JSON, JSONL or parquet) to attribute vision/OCR loss")
+	cmd.Flags().StringVar(&opts.outputJSON, "output-json", "", "Path to save the loss attribution and round-trip results as JSON")
+	cmd.Flags().StringArrayVar(&opts.scorers, "scorer-plugin", nil, "Scorer plugin command to register (repeatable; default $SCORER_PLUGINS)")
+	cmd.Flags().BoolVar(&opts.verbose, "verbose", false, "Verbose logging")
+
+	return cmd
+}
+
+func executeRoundTrip(opts roundTripOptions) error {
+	logLevel := slog.LevelInfo
+	if opts.verbose {
+		logLevel = slog.LevelDebug
+	}
+	slog.SetDefault(slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: logLevel})))
+
+	ds, err := dataset.LoadMARCDataset(opts.datasetDir)
+	if err != nil {
+		return fmt.Errorf("failed to load dataset: %w", err)
+	}
+	references := loadReferences(ds, opts.sampleSize)
+	if len(references) == 0 {
+		return fmt.Errorf("no readable reference records in %s", opts.datasetDir)
+	}
+
+	stopScorers, err := startScorers(opts.scorers)
+	if err != nil {
+		return err
+	}
+	defer stopScorers()
+
+	var full []marceval.Result
+	if opts.comparePath != "" {
+		if _, err := marceval.ReadResults(opts.comparePath, func(r marceval.Result) error {
+			full = append(full, r)
+			return nil
+		}); err != nil {
+			return fmt.Errorf("failed to read %s: %w", opts.comparePath, err)
+		}
+	}
+
+	catalogService := cataloging.NewService()
+	model := opts.model
+	if model == "" {
+		model = catalogService.GetDefaultModel(opts.provider)
+	}
+	slog.Info("Starting round-trip evaluation", "dataset", opts.datasetDir, "items", len(references), "provider", opts.provider, "model", model)
+
+	mapping := make([]marceval.Result, 0, len(references))
+	roundTrip := make([]marceval.Result, 0, len(references))
+	for i, ref := range references {
+		material := ref.Record.MaterialType()
+
+		generated := cataloging.MetadataToMARC(roundtrip.MetadataFromRecord(ref.Record))
+		generated.SetMaterialType(material)
+		mapping = append(mapping, marceval.Result{
+			ID:            ref.Item.ID,
+			Title:         ref.Item.Title,
+			Provider:      "reference",
+			GeneratedMARC: generated.Mnemonic(),
+			Comparison:    marceval.Compare(ref.Record, generated),
+		})
+
+		result := roundTripItem(catalogService, ref, material, opts.provider, model)
+		if result.Error != "" {
+			slog.Warn("Round trip failed", "id", result.ID, "error", result.Error)
+		} else {
+			slog.Debug("Round trip", "item", i+1, "id", result.ID, "score", result.Comparison.Score)
+		}
+		roundTrip = append(roundTrip, result)
+	}
+
+	attribution := roundtrip.Attribute(mapping, roundTrip, full)
+	printRoundTrip(attribution, opts.provider, model)
+
+	if opts.outputJSON != "" {
+		data, err := json.MarshalIndent(roundTripReport{
+			Dataset:     opts.datasetDir,
+			Provider:    opts.provider,
+			Model:       model,
+			Compare:     opts.comparePath,
+			Attribution: attribution,
+			Results:     roundTrip,
+		}, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to encode round-trip report: %w", err)
+		}
+		if err := objectstore.WriteFile(context.Background(), opts.outputJSON, data); err != nil {
+			return fmt.Errorf("failed to write round-trip report: %w", err)
+		}
+		fmt.Printf("\nRound-trip report saved to: %s\n", opts.outputJSON)
+	}
+	return nil
+}
+
+// roundTripItem generates MARC from a transcription rendered from the reference record
+func roundTripItem(catalogService *cataloging.Service, ref reference, material, provider, model string) marceval.Result {
+	start := time.Now()
+	result := marceval.Result{
+		ID:            ref.Item.ID,
+		Title:         ref.Item.Title,
+		Provider:      provider,
+		Model:         model,
+		PromptVersion: catalogService.PromptVersionFor(material),
+		MaterialType:  material,
+		OCRText:       mock.TitlePageText(ref.Record),
+	}
+
+	generated, notes, err := catalogService.GenerateMARCFromPages(context.Background(), cataloging.OCRPages{Text: result.OCRText, Material: material}, provider, model)
+	result.Warnings = notes.Warnings
+	if notes.Refusal != nil {
+		result.Refusal = notes.Refusal.Category
+	}
+	if err != nil {
+		result.Error = fmt.Sprintf("MARC generation failed: %v", err)
+		result.ErrorCode = failure.Classify(err, failure.ProviderError)
+	} else {
+		generated = catalogService.Profile().WithoutProvenanceNote(generated)
+		result.GeneratedMARC = generated.Mnemonic()
+		result.Comparison = marceval.Compare(ref.Record, generated)
+	}
+	result.ProcessingTime = time.Since(start)
+	return result
+}
+
+func printRoundTrip(a roundtrip.Attribution, provider, model string) {
+	fmt.Println("\n" + strings.Repeat("=", 90))
+	fmt.Println("ROUND-TRIP CONSISTENCY")
+	fmt.Println(strings.Repeat("=", 90))
+	fmt.Printf("Provider: %s, model: %s\n", provider, model)
+	if a.Records == 0 {
+		fmt.Println("No records were scored at every stage")
+		fmt.Println(strings.Repeat("=", 90))
+		return
+	}
+	fmt.Printf("Records scored at every stage: %d\n\n", a.Records)
+
+	header := fmt.Sprintf("%-8s %9s %10s", "FIELD", "MAPPING", "ROUNDTRIP")
+	if a.HasFull {
+		header += fmt.Sprintf(" %9s", "FULL")
+	}
+	header += fmt.Sprintf(" | %12s %12s", "MAPPING LOSS", "PROMPT LOSS")
+	if a.HasFull {
+		header += fmt.Sprintf(" %12s", "VISION LOSS")
+	}
+	fmt.Println(header)
+	fmt.Println(strings.Repeat("-", 90))
+
+	row := func(name string, l roundtrip.Loss) {
+		line := fmt.Sprintf("%-8s %9.3f %10.3f", name, l.Scores[roundtrip.StageMapping], l.Scores[roundtrip.StageRoundTrip])
+		if a.HasFull {
+			line += fmt.Sprintf(" %9.3f", l.Scores[roundtrip.StageFull])
+		}
+		line += fmt.Sprintf(" | %12.3f %12.3f", l.Mapping, l.Prompt)
+		if a.HasFull {
+			line += fmt.Sprintf(" %12.3f", l.Vision)
+		}
+		fmt.Println(line)
+	}
+	for _, l := range a.Fields {
+		row(l.Tag, l)
+	}
+	fmt.Println(strings.Repeat("-", 90))
+	row("OVERALL", a.Overall)
+	fmt.Println(strings.Repeat("=", 90))
+}
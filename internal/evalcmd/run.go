@@ -0,0 +1,627 @@
+package evalcmd
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/lehigh-university-libraries/cataloger/internal/adaptive"
+	"github.com/lehigh-university-libraries/cataloger/internal/cataloging"
+	"github.com/lehigh-university-libraries/cataloger/internal/eval/anomaly"
+	"github.com/lehigh-university-libraries/cataloger/internal/eval/dataset"
+	"github.com/lehigh-university-libraries/cataloger/internal/eval/failure"
+	"github.com/lehigh-university-libraries/cataloger/internal/eval/groundtruth"
+	"github.com/lehigh-university-libraries/cataloger/internal/eval/marceval"
+	"github.com/lehigh-university-libraries/cataloger/internal/eval/scorerplugin"
+	"github.com/lehigh-university-libraries/cataloger/internal/heartbeat"
+	"github.com/lehigh-university-libraries/cataloger/internal/hooks"
+	"github.com/lehigh-university-libraries/cataloger/internal/marc"
+	"github.com/lehigh-university-libraries/cataloger/internal/mock"
+	"github.com/lehigh-university-libraries/cataloger/internal/ocr"
+	"github.com/lehigh-university-libraries/cataloger/internal/openai"
+	"github.com/lehigh-university-libraries/cataloger/internal/providers"
+	"github.com/lehigh-university-libraries/cataloger/internal/usage"
+	"github.com/spf13/cobra"
+)
+
+// runOptions holds the flags for the run command
+type runOptions struct {
+	datasetDir  string
+	outputJSON  string
+	resultsFile string
+	blobDir     string
+	sampleSize  int
+	provider    string
+	model       string
+	ocrProvider string
+	ocrModel    string
+	profile     string
+	penalties   marceval.Penalties
+	scorers     []string
+	hooks       []string
+	copyright   bool
+	materials   bool
+	anomalies   bool
+	provenance  bool
+	fromImages  bool
+	audit       referenceAudit
+	noRepaired  bool
+	lcClasses   []string
+	locations   []string
+	locFields   []string
+	failBelow   float64
+	notify      string
+	concurrency string
+	maxConc     int
+	timeout     time.Duration
+	heartbeat   heartbeat.Options
+	openaiBatch bool
+	batchDir    string
+	batchPoll   time.Duration
+	verbose     bool
+}
+
+// NewRunCmd creates the run command for evaluating MARC generation against a MARC dataset
+func NewRunCmd() *cobra.Command {
+	var opts runOptions
+
+	cmd := &cobra.Command{
+		Use:   "run",
+		Short: "Evaluate MARC generation against a MARC reference dataset",
+		Long: `Generate MARC for each item in a MARC evaluation dataset (title page image -> OCR ->
+metadata -> MARC) and score it field by field against the reference record.
+
+With --provider mock no LLM backend is needed: the title page text is rendered from the
+reference record and metadata is derived from it, optionally with noise
+(MOCK_ERROR_RATE, MOCK_DROP_RATE, MOCK_SEED), to exercise the pipeline and reports offline.
+
+Items with a copyright page image also get the copyright page pass: the copyright date,
+LCCN, ISBNs and CIP data block are extracted separately and merged into the record, and the
+fields it wrote are scored separately in the COPYRIGHT PAGE PASS section of the report.
+
+Each item is generated with the prompt profile of its material type, read from the reference
+record's leader (Leader/06-07): serials, maps, scores, sound recordings and visual materials
+have their own metadata prompts (metadata_extraction_<type>), and other material falls back to
+the book prompt. The report shows scores by material type.
+
+Degenerate outputs are failures rather than records scoring near zero: a response with no main
+entry, title or imprint, the same field or word repeated over and over, a refusal, or a
+description in another language than the title page fails with degenerate_output.
+
+--lc-class and --location evaluate only the items whose reference record has an LC call
+number in the given ranges or is held at the given locations, as with eval fetch, so
+subject specialists can evaluate models on their own part of the collection.
+
+For schedulers, the exit code gives the run's outcome: 0 when it passed, 2 when the mean
+score is below --fail-below, 3 when some records failed, and 1 for any other error. The last
+line on stdout is a one-line JSON summary of the run.
+
+When the institution profile (CATALOGER_PROFILE) sets a provenance_note, the 500 it adds to
+every generated record is left out of the comparison, since reference records don't carry it;
+--score-provenance-note scores it like any other note.
+
+--from-images sends each item's title page, copyright page and cover images to the model in
+one request, instead of transcribing each and generating from the text, so the model can
+weigh the pages against each other. It needs a provider that takes several images per
+request when an item has more than one; the copyright page pass is not run, and the mock
+provider keeps generating from its mock OCR.
+
+--audit-references runs the validator and consistency checks against each reference record
+and flags suspect ground truth: invalid ISBN check digits, impossible dates, an empty 245,
+structural errors and failed consistency checks. Suspect records are left out of the scores
+by default, or count with --suspect-weight (0.5 counts them half; 1 only flags them). See
+eval audit to review the reference records on their own.
+
+--concurrency evaluates several items at a time. With --concurrency auto the number adapts to
+the provider: it grows while latency stays near the lowest seen, and is cut when latency
+doubles or requests time out or fail, up to --max-concurrency. This keeps a local Ollama busy
+without queueing requests until they time out. Results are reported in dataset order either way.
+
+--openai-batch sends the openai requests through the OpenAI Batch API at half the price. The
+run proceeds in rounds, one per stage of the pipeline: the requests without a response are
+submitted as batches and polled every --openai-batch-poll until they finish, and the items
+waiting on them are evaluated again. Responses are kept in --openai-batch-dir, so an
+interrupted run picks up its batches and a second run reuses the responses.
+
+Every --heartbeat the run logs how many records are done and in flight and how long ago the
+last one completed, and once none has completed for --stall-after it warns at each heartbeat
+that the provider may be hung. --heartbeat-file keeps the same status as JSON for monitoring.
+
+With --notify (or NOTIFY_CONFIG) naming a notification config (see notify.example.yaml), the
+run's outcome and a summary table are sent to Slack, Matrix or email when it finishes.`,
+		Example: `  # Evaluate 20 items with the default provider
+  cataloger eval run --dataset ./eval_data --sample 20
+
+  # Dry run without any LLM backend
+  cataloger eval run --dataset ./eval_data --provider mock
+
+  # Score 090 call numbers with a local scheme
+  cataloger eval run --dataset ./eval_data --scorer-plugin "python3 callnumbers.py"
+
+  # Add local fields to each generated record before scoring
+  cataloger eval run --dataset ./eval_data --post-hook "./add-local-fields.sh"
+
+  # Stream results for a large dataset instead of holding them in memory
+  cataloger eval run --dataset ./eval_data --results-file results.jsonl
+
+  # Keep OCR text, generated MARC and raw model responses in gzipped files per record
+  cataloger eval run --dataset ./eval_data --blob-dir eval_blobs
+
+  # Only American literature held in Special Collections
+  cataloger eval run --dataset ./eval_data --lc-class PS --location spec
+
+  # Evaluate as many items at a time as the local Ollama can take
+  cataloger eval run --dataset ./eval_data --concurrency auto
+
+  # Half-price evaluation of a large sample through the OpenAI Batch API
+  cataloger eval run --dataset ./eval_data --provider openai --openai-batch --stall-after 0
+
+  # Simulate a noisy model
+  MOCK_ERROR_RATE=0.05 MOCK_DROP_RATE=0.1 cataloger eval run --dataset ./eval_data --provider mock`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if _, err := os.Stat(opts.datasetDir); os.IsNotExist(err) {
+				return fmt.Errorf("dataset directory not found: %s", opts.datasetDir)
+			}
+			if _, err := newLimiter(opts.concurrency, opts.maxConc); err != nil {
+				return err
+			}
+			if opts.audit.weight < 0 || opts.audit.weight > 1 {
+				return fmt.Errorf("--suspect-weight must be between 0 and 1, got %g", opts.audit.weight)
+			}
+			if opts.openaiBatch && (opts.provider != "openai" || (opts.ocrProvider != "" && opts.ocrProvider != "openai")) {
+				return fmt.Errorf("--openai-batch needs --provider openai (and --ocr-provider openai, if set)")
+			}
+			return executeRun(opts)
+		},
+	}
+
+	cmd.Flags().StringVar(&opts.datasetDir, "dataset", "./eval_data", "Path to MARC evaluation dataset directory")
+	cmd.Flags().StringVar(&opts.outputJSON, "output-json", "eval_run_results.json", "Path to output JSON results file")
+	cmd.Flags().StringVar(&opts.resultsFile, "results-file", "", "Stream per-record results to a .jsonl or .parquet file; the JSON report then holds only the summary")
+	cmd.Flags().StringVar(&opts.blobDir, "blob-dir", "", "Store each record's OCR text, generated MARC and raw model response gzipped in this directory, referenced from the results")
+	cmd.Flags().IntVar(&opts.sampleSize, "sample", -1, "Number of items to evaluate (-1 for all)")
+	cmd.Flags().StringVar(&opts.provider, "provider", "ollama", "LLM provider (ollama, openai, azure-openai, gemini, claude, groq, huggingface, llamacpp, mistral, or mock)")
+	cmd.Flags().StringVar(&opts.model, "model", "", "Model name (defaults to provider's default)")
+	cmd.Flags().StringVar(&opts.ocrProvider, "ocr-provider", "", "Vision provider for OCR, so a text-only --provider such as groq generates from its transcriptions (defaults to --provider)")
+	cmd.Flags().StringVar(&opts.ocrModel, "ocr-model", "", "OCR model name (defaults to the OCR provider's default)")
+	cmd.Flags().StringVar(&opts.profile, "completeness-profile", marceval.CoreProfile.Name, "Completeness profile: builtin name (core, pcc-bsr) or YAML file")
+	cmd.Flags().Float64Var(&opts.penalties.Duplicate, "duplicate-penalty", 0, "Score penalty per extra occurrence of a non-repeatable field (e.g. a second 245)")
+	cmd.Flags().Float64Var(&opts.penalties.Order, "order-penalty", 0, "Share of the score scaled by field ordering correctness (0-1)")
+	cmd.Flags().StringArrayVar(&opts.scorers, "scorer-plugin", nil, "Scorer plugin command to register (repeatable; default $SCORER_PLUGINS)")
+	cmd.Flags().StringArrayVar(&opts.hooks, "post-hook", nil, "Command run on each generated record (MARCXML on stdin and stdout) before scoring (repeatable; default $POSTPROCESS_HOOKS)")
+	cmd.Flags().BoolVar(&opts.copyright, "copyright-pass", true, "Run the copyright page pass for items with a copyright page image")
+	cmd.Flags().BoolVar(&opts.materials, "material-prompts", true, "Select the metadata prompt by the reference record's material type instead of always using the book prompt")
+	cmd.Flags().BoolVar(&opts.anomalies, "detect-anomalies", true, "Fail degenerate outputs (empty, repetitive, refused or wrong-language) instead of scoring them")
+	cmd.Flags().BoolVar(&opts.provenance, "score-provenance-note", false, "Score the institution profile's provenance_note 500 like any other note instead of leaving it out of comparisons")
+	cmd.Flags().BoolVar(&opts.fromImages, "from-images", false, "Generate from all of an item's page images in one request instead of from their OCR text")
+	cmd.Flags().BoolVar(&opts.audit.enabled, "audit-references", false, "Flag suspect reference records (invalid ISBNs, impossible dates, empty 245, ...) and weight them in aggregates by --suspect-weight")
+	cmd.Flags().Float64Var(&opts.audit.weight, "suspect-weight", 0, "Weight of records with a suspect reference in aggregate scores (0 leaves them out)")
+	cmd.Flags().Float64Var(&opts.failBelow, "fail-below", 0, "Exit with code 2 when the mean score is below this (0 disables)")
+	cmd.Flags().StringVar(&opts.notify, "notify", "", "Notification config (YAML) for Slack, Matrix or email when the run finishes (default $NOTIFY_CONFIG)")
+	cmd.Flags().StringSliceVar(&opts.lcClasses, "lc-class", nil, "Evaluate only items with an LC call number in these ranges (e.g. PS, Q, QA76-QA76.9)")
+	cmd.Flags().StringSliceVar(&opts.locations, "location", nil, "Evaluate only items held at these location codes")
+	cmd.Flags().StringSliceVar(&opts.locFields, "location-field", dataset.DefaultLocationFields, "Holdings subfields --location codes are read from (e.g. 852b, 949l)")
+	cmd.Flags().DurationVar(&opts.timeout, "timeout", providers.TimeoutFromEnv(), "Timeout of each OCR and LLM request (0 for none; default $PROVIDER_TIMEOUT or 5m)")
+	cmd.Flags().StringVar(&opts.concurrency, "concurrency", "1", "Items evaluated at a time, or auto to adapt to the provider's latency and errors")
+	cmd.Flags().IntVar(&opts.maxConc, "max-concurrency", adaptive.DefaultMax, "Highest number of items evaluated at a time with --concurrency auto")
+	cmd.Flags().BoolVar(&opts.openaiBatch, "openai-batch", false, "Send the openai requests through the OpenAI Batch API at half the price, waiting up to a day per stage")
+	cmd.Flags().StringVar(&opts.batchDir, "openai-batch-dir", "openai_batches", "Directory keeping the batch responses of --openai-batch, so an interrupted run resumes")
+	cmd.Flags().DurationVar(&opts.batchPoll, "openai-batch-poll", openai.DefaultBatchPollInterval, "How often --openai-batch checks on submitted batches")
+	addHeartbeatFlags(cmd, &opts.heartbeat)
+	cmd.Flags().BoolVar(&opts.noRepaired, "exclude-repaired", false, "Skip items whose reference leader was repaired when the dataset was fetched")
+	cmd.Flags().BoolVar(&opts.verbose, "verbose", false, "Verbose logging")
+
+	return cmd
+}
+
+// startScorers registers scorer plugins for the command's comparisons; call the returned
+// function when done
+func startScorers(commands []string) (func(), error) {
+	stop, err := scorerplugin.StartAll(commands)
+	if err != nil {
+		return nil, err
+	}
+	for _, ext := range marceval.Extensions() {
+		slog.Info("Registered scorer plugin", "name", ext.Name, "tags", len(ext.Weights))
+	}
+	return stop, nil
+}
+
+func executeRun(opts runOptions) error {
+	logLevel := slog.LevelInfo
+	if opts.verbose {
+		logLevel = slog.LevelDebug
+	}
+	slog.SetDefault(slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: logLevel})))
+
+	ds, err := dataset.LoadMARCDataset(opts.datasetDir)
+	if err != nil {
+		return fmt.Errorf("failed to load dataset: %w", err)
+	}
+
+	stopScorers, err := startScorers(opts.scorers)
+	if err != nil {
+		return err
+	}
+	defer stopScorers()
+
+	profile, err := marceval.LoadProfile(opts.profile)
+	if err != nil {
+		return err
+	}
+	itemOpts := itemOptions{
+		profile:    profile,
+		copyright:  opts.copyright,
+		materials:  opts.materials,
+		anomalies:  opts.anomalies,
+		provenance: opts.provenance,
+		fromImages: opts.fromImages,
+		audit:      opts.audit,
+	}
+
+	notifier, err := loadNotifier(opts.notify)
+	if err != nil {
+		return err
+	}
+
+	items := ds.Index.Items
+	if opts.noRepaired {
+		items = slices.DeleteFunc(slices.Clone(items), func(item dataset.DatasetItem) bool {
+			return len(item.LeaderRepairs) > 0
+		})
+		slog.Info("Excluding items with repaired leaders", "skipped", len(ds.Index.Items)-len(items))
+	}
+	if len(opts.lcClasses) > 0 || len(opts.locations) > 0 {
+		ranges, err := dataset.ParseClassRanges(opts.lcClasses)
+		if err != nil {
+			return err
+		}
+		filter := dataset.Filter{ClassRanges: ranges, Locations: opts.locations, LocationFields: opts.locFields}
+		before := len(items)
+		items = slices.DeleteFunc(slices.Clone(items), func(item dataset.DatasetItem) bool {
+			data, err := ds.ReadMARCXML(item)
+			if err != nil {
+				return false // Left for evaluation to report
+			}
+			rec, err := marc.ParseXML(data)
+			if err != nil {
+				return false
+			}
+			ok, _ := filter.Match(rec)
+			return !ok
+		})
+		slog.Info("Selecting items by call number and location", "kept", len(items), "skipped", before-len(items))
+	}
+	if opts.sampleSize > 0 && opts.sampleSize < len(items) {
+		items = items[:opts.sampleSize]
+	}
+
+	catalogService := cataloging.NewService()
+	ocrService := ocr.NewService()
+	catalogService.SetTimeout(opts.timeout)
+	ocrService.SetTimeout(opts.timeout)
+	if len(opts.hooks) > 0 {
+		catalogService.SetHooks(hooks.Parse(opts.hooks))
+	}
+
+	model := opts.model
+	if model == "" {
+		model = catalogService.GetDefaultModel(opts.provider)
+	}
+
+	ocrModel := opts.ocrModel
+	if opts.ocrProvider != "" && ocrModel == "" {
+		ocrModel = catalogService.GetDefaultModel(opts.ocrProvider)
+	}
+
+	slog.Info("Starting MARC evaluation", "dataset", opts.datasetDir, "items", len(items), "provider", opts.provider, "model", model)
+
+	var writer *marceval.ResultWriter
+	if opts.resultsFile != "" {
+		writer, err = marceval.CreateResultWriter(opts.resultsFile)
+		if err != nil {
+			return err
+		}
+	}
+
+	var blobs *marceval.BlobDir
+	if opts.blobDir != "" {
+		blobs, err = marceval.NewBlobDir(opts.blobDir, opts.outputJSON)
+		if err != nil {
+			return err
+		}
+	}
+
+	limiter, err := newLimiter(opts.concurrency, opts.maxConc)
+	if err != nil {
+		return err
+	}
+
+	var batch *openai.Batch
+	if opts.openaiBatch {
+		if batch, err = startOpenAIBatch(opts.batchDir, opts.batchPoll); err != nil {
+			return err
+		}
+	}
+
+	tracker := usage.Start(usage.DefaultInterval)
+	monitor := heartbeat.Start(opts.heartbeat, len(items))
+	defer monitor.Stop()
+	agg := marceval.NewAggregator()
+	var results []marceval.Result
+	evaluate := func(ctx context.Context, i int) marceval.Result {
+		item := items[i]
+		provider, itemModel := resolveRoute(catalogService, opts.provider, model, item.Override())
+		ocrProvider, itemOCRModel := provider, itemModel
+		if opts.ocrProvider != "" {
+			ocrProvider, itemOCRModel = opts.ocrProvider, ocrModel
+		}
+		if batch == nil {
+			return evaluateItem(ctx, ds, item, catalogService, ocrService, provider, itemModel, ocrProvider, itemOCRModel, itemOpts)
+		}
+		result := evaluateItem(ctx, ds, item, catalogService, ocrService, viaBatch(provider), itemModel, viaBatch(ocrProvider), itemOCRModel, itemOpts)
+		result.Provider = provider
+		return result
+	}
+	emit := func(i int, result marceval.Result) error {
+		item := items[i]
+		opts.penalties.Apply(result.Comparison)
+		if result.Error != "" {
+			slog.Warn("Item processing failed", "id", item.ID, "error", result.Error)
+		} else {
+			slog.Debug("Item scored", "id", item.ID, "score", result.Comparison.Score)
+		}
+		if err := storeBlob(blobs, &result); err != nil {
+			return err
+		}
+		agg.Add(result)
+		if writer != nil {
+			if err := writer.Write(result); err != nil {
+				return err
+			}
+		} else {
+			results = append(results, result)
+		}
+
+		if (i+1)%10 == 0 {
+			fmt.Printf("Progress: %d/%d items processed\n", i+1, len(items))
+		}
+		return nil
+	}
+	if batch != nil {
+		err = evaluateInBatches(batch, len(items), limiter, monitor, evaluate, emit)
+	} else {
+		err = evaluateConcurrently(len(items), limiter, func(i int) (result marceval.Result) {
+			monitor.Begin()
+			defer func() { monitor.End(result.Error != "") }()
+			return evaluate(context.Background(), i)
+		}, emit)
+	}
+	if err != nil {
+		if writer != nil {
+			writer.Close()
+		}
+		return err
+	}
+
+	if writer != nil {
+		if err := writer.Close(); err != nil {
+			return err
+		}
+	}
+
+	runUsage := tracker.Stop()
+	report := agg.Report()
+	report.Results = results
+	report.Usage = &runUsage
+	report.Dataset = opts.datasetDir
+	report.Provider = opts.provider
+	report.Model = model
+	report.PromptVersion = catalogService.PromptVersion()
+	report.PostHooks = catalogService.Hooks().Infos()
+	report.CompletenessProfile = profile.Name
+	report.Penalties = opts.penalties
+	report.PrintSummary()
+
+	if err := report.SaveJSON(opts.outputJSON); err != nil {
+		fmt.Printf("Warning: Failed to save JSON results: %v\n", err)
+	} else {
+		fmt.Printf("\nResults saved to: %s\n", opts.outputJSON)
+	}
+	if writer != nil {
+		fmt.Printf("Per-record results saved to: %s\n", opts.resultsFile)
+	}
+	if blobs != nil {
+		fmt.Printf("Blobs saved to: %s\n", opts.blobDir)
+	}
+
+	summary, err := runOutcome(report, opts.failBelow, opts.outputJSON)
+	notifyRun(notifier, report, summary)
+	printSummaryLine(summary)
+	return err
+}
+
+// storeBlob moves a result's large text to the blob directory, or drops its raw response
+// when blobs aren't stored
+func storeBlob(blobs *marceval.BlobDir, result *marceval.Result) error {
+	if blobs == nil {
+		result.RawResponse = ""
+		return nil
+	}
+	return blobs.Store(result)
+}
+
+// itemOptions are the options a run evaluates each item with
+type itemOptions struct {
+	profile    marceval.CompletenessProfile
+	copyright  bool // Run the copyright page pass
+	materials  bool // Select the prompt by the reference's material type
+	anomalies  bool // Fail degenerate outputs
+	provenance bool // Score the provenance note
+	fromImages bool // Generate from the page images instead of their OCR text
+	audit      referenceAudit
+}
+
+// referenceAudit flags suspect reference records and weights them in aggregates
+type referenceAudit struct {
+	enabled bool
+	weight  float64
+}
+
+// pageImages reads an item's title page, copyright page and cover images, for generating
+// from the images in one request
+func pageImages(ds *dataset.MARCDataset, item dataset.DatasetItem) ([]cataloging.PageImage, error) {
+	var images []cataloging.PageImage
+	for _, page := range []struct{ kind, path string }{
+		{"title_page", item.Images.TitlePage},
+		{"copyright", item.Images.CopyrightPage},
+		{"cover", item.Images.Cover},
+	} {
+		if page.path == "" {
+			continue
+		}
+		data, err := os.ReadFile(ds.Path(page.path))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s image: %w", page.kind, err)
+		}
+		images = append(images, cataloging.PageImage{Type: page.kind, Path: ds.Path(page.path), Data: data})
+	}
+	if len(images) == 0 {
+		return nil, fmt.Errorf("no page images")
+	}
+	return images, nil
+}
+
+// evaluateItem generates MARC for one dataset item and scores it against the reference. Its
+// pages are transcribed by ocrProvider, which is provider unless a text-only one generates.
+func evaluateItem(ctx context.Context, ds *dataset.MARCDataset, item dataset.DatasetItem, catalogService *cataloging.Service, ocrService *ocr.Service, provider, model, ocrProvider, ocrModel string, opts itemOptions) marceval.Result {
+	start := time.Now()
+	result := marceval.Result{
+		ID:            item.ID,
+		Title:         item.Title,
+		Provider:      provider,
+		Model:         model,
+		PromptVersion: catalogService.PromptVersion(),
+	}
+	fail := func(code failure.Code, format string, args ...any) marceval.Result {
+		result.Error = fmt.Sprintf(format, args...)
+		result.ErrorCode = code
+		result.ProcessingTime = time.Since(start)
+		return result
+	}
+
+	data, err := ds.ReadMARCXML(item)
+	if err != nil {
+		return fail(failure.ComparisonError, "Failed to read reference record: %v", err)
+	}
+	reference, err := marc.ParseXML(data)
+	if err != nil {
+		return fail(failure.ComparisonError, "Failed to parse reference record: %v", err)
+	}
+	result.MaterialType = reference.MaterialType()
+	if opts.audit.enabled {
+		if findings := groundtruth.Audit(reference, time.Now()); len(findings) > 0 {
+			result.SuspectReference = groundtruth.FindingCodes(findings)
+			weight := opts.audit.weight
+			result.ReferenceWeight = &weight
+		}
+	}
+	for _, issue := range marc.Validate(reference) {
+		if issue.Tag == "LDR" && issue.Severity == marc.SeverityError {
+			result.Warnings = append(result.Warnings, failure.Warn(failure.WarnReferenceLeader, "reference record: %s", issue.Message))
+		}
+	}
+	pages := cataloging.OCRPages{}
+	if opts.materials {
+		pages.Material = result.MaterialType
+		result.PromptVersion = catalogService.PromptVersionFor(pages.Material)
+	}
+
+	var (
+		generated *marc.Record
+		notes     cataloging.GenerationNotes
+	)
+	ctx, served := providers.WithServed(ctx)
+	if opts.fromImages && provider != "mock" {
+		var images []cataloging.PageImage
+		if images, err = pageImages(ds, item); err != nil {
+			return fail(failure.NoImage, "%v", err)
+		}
+		result.PromptVersion = catalogService.ImagesPromptVersion()
+		generated, notes, err = catalogService.GenerateMARCFromImages(ctx, images, pages.Material, provider, model)
+	} else {
+		if provider == "mock" {
+			result.OCRText = mock.TitlePageText(reference)
+		} else {
+			image := item.Images.TitlePage
+			if image == "" && item.Images.Cover != "" {
+				image = item.Images.Cover
+				result.Warnings = append(result.Warnings, failure.Warn(failure.WarnCoverFallback, "no title page image; used the cover"))
+			}
+			if image == "" {
+				return fail(failure.NoImage, "No title page or cover image")
+			}
+			result.OCRText, err = ocrService.ExtractTextFromImage(ctx, ds.Path(image), ocrProvider, ocrModel)
+			if err != nil {
+				return fail(failure.Classify(err, failure.ProviderError), "OCR failed: %v", err)
+			}
+		}
+
+		pages.Text = result.OCRText
+		if opts.copyright {
+			switch {
+			case provider == "mock":
+				pages.CopyrightPage = mock.CopyrightPageText(reference)
+			case item.Images.CopyrightPage != "":
+				pages.CopyrightPage, err = ocrService.ExtractTextFromImage(ctx, ds.Path(item.Images.CopyrightPage), ocrProvider, ocrModel)
+				if err != nil {
+					slog.Warn("Copyright page OCR failed", "id", item.ID, "error", err)
+					result.Warnings = append(result.Warnings, failure.Warn(failure.WarnCopyrightOCR, "copyright page OCR failed: %v", err))
+				}
+			}
+		}
+
+		generated, notes, err = catalogService.GenerateMARCFromPages(ctx, pages, provider, model)
+	}
+	result.Warnings = append(result.Warnings, notes.Warnings...)
+	if fallbacks := served.Fallbacks(); len(fallbacks) > 0 {
+		result.Warnings = append(result.Warnings, failure.Warn(failure.WarnProviderFallback, "%s failed; answered by %s", provider, strings.Join(fallbacks, ", ")))
+	}
+	result.RawResponse = notes.Response
+	if notes.Refusal != nil {
+		result.Refusal = notes.Refusal.Category
+	}
+	if err != nil {
+		return fail(failure.Classify(err, failure.ProviderError), "MARC generation failed: %v", err)
+	}
+	result.GeneratedMARC = generated.Mnemonic()
+	if opts.anomalies {
+		if a := anomaly.Check(result.OCRText, notes.Response, generated); a != nil {
+			result.Anomaly = a.Kind
+			return fail(failure.Degenerate, "Degenerate output: %v", a)
+		}
+	}
+	scored := generated
+	if !opts.provenance {
+		scored = catalogService.Profile().WithoutProvenanceNote(generated)
+	}
+	if len(notes.CopyrightTags) > 0 {
+		weights := make(map[string]float64, len(notes.CopyrightTags))
+		for _, tag := range notes.CopyrightTags {
+			weights[tag] = 1
+		}
+		result.CopyrightTags = notes.CopyrightTags
+		result.CopyrightComparison = marceval.CompareWeighted(reference, scored, weights)
+	}
+
+	result.Comparison = marceval.Compare(reference, scored)
+	result.Issues = marc.Validate(generated)
+	result.Completeness, result.PresentElements, result.MissingElements = opts.profile.Check(generated)
+	result.Consistency, result.Inconsistencies = marceval.CheckConsistency(generated)
+	result.ProcessingTime = time.Since(start)
+	return result
+}
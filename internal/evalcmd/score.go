@@ -0,0 +1,275 @@
+package evalcmd
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/lehigh-university-libraries/cataloger/internal/eval/dataset"
+	"github.com/lehigh-university-libraries/cataloger/internal/eval/failure"
+	"github.com/lehigh-university-libraries/cataloger/internal/eval/marceval"
+	"github.com/lehigh-university-libraries/cataloger/internal/marc"
+	"github.com/spf13/cobra"
+)
+
+// scoreOptions holds the flags for the score command
+type scoreOptions struct {
+	generated  string
+	datasetDir string
+	label      string
+	outputJSON string
+	profile    string
+	scorers    []string
+	failBelow  float64
+	verbose    bool
+}
+
+// generatedExtensions are the files read from a --generated directory
+var generatedExtensions = map[string]bool{".xml": true, ".marcxml": true, ".mrc": true, ".marc": true}
+
+// NewScoreCmd creates the score command for evaluating records produced outside cataloger
+func NewScoreCmd() *cobra.Command {
+	var opts scoreOptions
+
+	cmd := &cobra.Command{
+		Use:   "score",
+		Short: "Score externally generated records against a MARC reference dataset",
+		Long: `Score records produced by other tools, vendors or students against the reference records
+of a MARC evaluation dataset, with the same comparison, validation and completeness checks as
+eval run and no provider involved.
+
+--generated is a directory of MARCXML or binary MARC files (or a single file). A record is
+matched to a dataset item by its file name (<item id>.xml or .mrc, for files holding one
+record), or else by 001 or ISBN. Dataset items with no generated record are reported as
+failures with the no_input code, so coverage counts as well as accuracy.
+
+The results file has the same format as eval run's, so eval report, eval compare and the
+web report work on it; --label names the source in place of a provider and model.`,
+		Example: `  # Score a vendor's records
+  cataloger eval score --generated ./vendor_records --reference ./eval_data --label vendor
+
+  # Compare students' records with a model run
+  cataloger eval score --generated ./student_a --reference ./eval_data --label student-a --output-json student_a.json
+  cataloger eval compare eval_run_results.json student_a.json`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if _, err := os.Stat(opts.generated); err != nil {
+				return fmt.Errorf("generated records not found: %s", opts.generated)
+			}
+			if _, err := os.Stat(opts.datasetDir); os.IsNotExist(err) {
+				return fmt.Errorf("dataset directory not found: %s", opts.datasetDir)
+			}
+			return executeScore(opts)
+		},
+	}
+
+	cmd.Flags().StringVar(&opts.generated, "generated", "", "Directory (or file) of generated records, MARCXML or binary MARC")
+	cmd.Flags().StringVar(&opts.datasetDir, "reference", "./eval_data", "Path to MARC evaluation dataset directory with the reference records")
+	cmd.Flags().StringVar(&opts.label, "label", "external", "Name of the records' source, reported in place of a provider and model (e.g. vendor, student-a)")
+	cmd.Flags().StringVar(&opts.outputJSON, "output-json", "eval_score_results.json", "Path to output JSON results file")
+	cmd.Flags().StringVar(&opts.profile, "completeness-profile", marceval.CoreProfile.Name, "Completeness profile: builtin name (core, pcc-bsr) or YAML file")
+	cmd.Flags().StringArrayVar(&opts.scorers, "scorer-plugin", nil, "Scorer plugin command to register (repeatable; default $SCORER_PLUGINS)")
+	cmd.Flags().Float64Var(&opts.failBelow, "fail-below", 0, "Exit with code 2 when the mean score is below this (0 disables)")
+	cmd.Flags().BoolVar(&opts.verbose, "verbose", false, "Verbose logging")
+	_ = cmd.MarkFlagRequired("generated")
+
+	return cmd
+}
+
+// generatedRecord is an external record with where it was read from
+type generatedRecord struct {
+	Record *marc.Record
+	Source string // File, and record number for files holding several
+	Stem   string // File name without extension, for files holding one record
+}
+
+func executeScore(opts scoreOptions) error {
+	logLevel := slog.LevelInfo
+	if opts.verbose {
+		logLevel = slog.LevelDebug
+	}
+	slog.SetDefault(slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: logLevel})))
+
+	ds, err := dataset.LoadMARCDataset(opts.datasetDir)
+	if err != nil {
+		return fmt.Errorf("failed to load dataset: %w", err)
+	}
+	references := loadReferences(ds, -1)
+	if len(references) == 0 {
+		return fmt.Errorf("no readable reference records in %s", opts.datasetDir)
+	}
+
+	profile, err := marceval.LoadProfile(opts.profile)
+	if err != nil {
+		return err
+	}
+	stopScorers, err := startScorers(opts.scorers)
+	if err != nil {
+		return err
+	}
+	defer stopScorers()
+
+	records, err := readGenerated(opts.generated)
+	if err != nil {
+		return err
+	}
+	slog.Info("Scoring generated records", "records", len(records), "references", len(references), "label", opts.label)
+
+	byID := make(map[string]int, len(references))
+	byKey := make(map[string]int)
+	for i, ref := range references {
+		byID[ref.Item.ID] = i
+		for _, key := range recordKeys(ref.Record) {
+			byKey[key] = i
+		}
+	}
+
+	generated := make(map[int]generatedRecord)
+	unmatched := 0
+	for _, g := range records {
+		i, ok := byID[g.Stem]
+		if !ok {
+			for _, key := range recordKeys(g.Record) {
+				if i, ok = byKey[key]; ok {
+					break
+				}
+			}
+		}
+		if !ok {
+			unmatched++
+			slog.Warn("No reference record matches", "source", g.Source)
+			continue
+		}
+		if prev, dup := generated[i]; dup {
+			slog.Warn("Several records match the same reference; keeping the first", "id", references[i].Item.ID, "kept", prev.Source, "skipped", g.Source)
+			continue
+		}
+		generated[i] = g
+	}
+
+	results := make([]marceval.Result, 0, len(references))
+	for i, ref := range references {
+		results = append(results, scoreGeneratedRecord(ref, generated[i], opts.label, profile))
+	}
+
+	report := marceval.NewReport(results)
+	report.Dataset = opts.datasetDir
+	report.Provider = opts.label
+	report.Model = opts.label
+	report.CompletenessProfile = profile.Name
+	report.PrintSummary()
+	if unmatched > 0 {
+		fmt.Printf("\n%d generated records matched no reference record\n", unmatched)
+	}
+
+	if err := report.SaveJSON(opts.outputJSON); err != nil {
+		return err
+	}
+	fmt.Printf("\nResults saved to: %s\n", opts.outputJSON)
+
+	if opts.failBelow > 0 && report.MeanScore < opts.failBelow {
+		return &ExitError{Code: ExitBelowThreshold, Err: fmt.Errorf("mean score %.3f is below --fail-below %.3f", report.MeanScore, opts.failBelow)}
+	}
+	return nil
+}
+
+// scoreGeneratedRecord compares an external record with its reference; g.Record is nil
+// when no record was generated for the item
+func scoreGeneratedRecord(ref reference, g generatedRecord, label string, profile marceval.CompletenessProfile) marceval.Result {
+	start := time.Now()
+	result := marceval.Result{
+		ID:           ref.Item.ID,
+		Title:        ref.Item.Title,
+		Provider:     label,
+		Model:        label,
+		MaterialType: ref.Record.MaterialType(),
+	}
+	if g.Record == nil {
+		result.Error = "no generated record"
+		result.ErrorCode = failure.NoInput
+		return result
+	}
+
+	result.GeneratedMARC = g.Record.Mnemonic()
+	result.Comparison = marceval.Compare(ref.Record, g.Record)
+	result.Issues = marc.Validate(g.Record)
+	result.Completeness, result.PresentElements, result.MissingElements = profile.Check(g.Record)
+	result.Consistency, result.Inconsistencies = marceval.CheckConsistency(g.Record)
+	result.ProcessingTime = time.Since(start)
+	return result
+}
+
+// readGenerated reads every record in a file, or in the MARC files under a directory
+func readGenerated(path string) ([]generatedRecord, error) {
+	var files []string
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read generated records: %w", err)
+	}
+	if info.IsDir() {
+		err := filepath.WalkDir(path, func(p string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if !d.IsDir() && generatedExtensions[strings.ToLower(filepath.Ext(p))] {
+				files = append(files, p)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list generated records: %w", err)
+		}
+		sort.Strings(files)
+	} else {
+		files = []string{path}
+	}
+
+	var records []generatedRecord
+	for _, file := range files {
+		recs, err := readRecordsFile(file)
+		if err != nil {
+			return nil, err
+		}
+		for n, rec := range recs {
+			g := generatedRecord{Record: rec, Source: file}
+			if len(recs) == 1 {
+				g.Stem = strings.TrimSuffix(filepath.Base(file), filepath.Ext(file))
+			} else {
+				g.Source = fmt.Sprintf("%s#%d", file, n+1)
+			}
+			records = append(records, g)
+		}
+	}
+	return records, nil
+}
+
+// readRecordsFile reads the records in a MARCXML or binary MARC file, stopping at an unreadable one
+func readRecordsFile(path string) ([]*marc.Record, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	defer f.Close()
+
+	reader, err := marc.NewReader(f)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	var records []*marc.Record
+	for n := 1; ; n++ {
+		rec, err := reader.Next()
+		if err == io.EOF {
+			return records, nil
+		}
+		if err != nil {
+			slog.Warn("Stopping at unreadable record", "file", path, "record", n, "error", err)
+			return records, nil
+		}
+		records = append(records, rec)
+	}
+}
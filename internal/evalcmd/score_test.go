@@ -0,0 +1,131 @@
+package evalcmd
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/lehigh-university-libraries/cataloger/internal/eval/dataset"
+	"github.com/lehigh-university-libraries/cataloger/internal/eval/failure"
+	"github.com/lehigh-university-libraries/cataloger/internal/eval/marceval"
+	"github.com/lehigh-university-libraries/cataloger/internal/marc"
+)
+
+const scoreReference = `=LDR  00000nam a2200000 i 4500
+=001  ref001
+=100  1\$aThoreau, Henry David,$d1817-1862.
+=245  10$aWalden, or, Life in the woods /$cby Henry D. Thoreau.
+=264  \1$aBoston :$bTicknor and Fields,$c1854.
+=650  \0$aSolitude.`
+
+func mnemonic(t *testing.T, text string) *marc.Record {
+	t.Helper()
+	rec, err := marc.ParseMnemonic(text)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return rec
+}
+
+// without returns the reference's mnemonic lines without those starting with any of prefixes
+func without(prefixes ...string) string {
+	var lines []string
+	for _, line := range strings.Split(scoreReference, "\n") {
+		keep := true
+		for _, p := range prefixes {
+			keep = keep && !strings.HasPrefix(line, p)
+		}
+		if keep {
+			lines = append(lines, line)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+func TestScoreGeneratedRecord(t *testing.T) {
+	// The reference's scored fields: 100 (2), 245 (3), 264 (2) and 650 (1.5)
+	const total = 8.5
+	ref := reference{Item: dataset.DatasetItem{ID: "walden", Title: "Walden"}, Record: mnemonic(t, scoreReference)}
+	profile := marceval.CoreProfile
+
+	tests := []struct {
+		name      string
+		generated string
+		score     float64
+		missing   int
+		extra     int
+	}{
+		{"identical", scoreReference, 1, 0, 0},
+		{"missing title weighs most", without("=245"), (total - 3) / total, 1, 0},
+		{"missing subject", without("=650"), (total - 1.5) / total, 1, 0},
+		{"missing main entry and imprint", without("=100", "=264"), (total - 4) / total, 2, 0},
+		{"leader only", "=LDR  00000nam a2200000 i 4500", 0, 4, 0},
+		{"extra field absent from reference", scoreReference + "\n=500  \\\\$aFirst edition.", 1, 0, 1},
+		{"pre-RDA 260 scores as 264", strings.Replace(scoreReference, "=264  \\1", "=260  \\\\", 1), 1, 0, 0},
+		{"different control number", strings.Replace(scoreReference, "ref001", "vendor42", 1), 1, 0, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := scoreGeneratedRecord(ref, generatedRecord{Record: mnemonic(t, tt.generated), Source: "g.mrk"}, "vendor", profile)
+			if result.Error != "" || result.Comparison == nil {
+				t.Fatalf("result = %+v", result)
+			}
+			c := result.Comparison
+			if math.Abs(c.Score-tt.score) > 1e-9 || c.Missing != tt.missing || c.Extra != tt.extra {
+				t.Errorf("score = %.4f (missing %d, extra %d), want %.4f (missing %d, extra %d)", c.Score, c.Missing, c.Extra, tt.score, tt.missing, tt.extra)
+			}
+			if result.Provider != "vendor" || result.Model != "vendor" || result.GeneratedMARC == "" {
+				t.Errorf("result = %+v", result)
+			}
+		})
+	}
+
+	t.Run("no generated record", func(t *testing.T) {
+		result := scoreGeneratedRecord(ref, generatedRecord{}, "vendor", profile)
+		if result.ErrorCode != failure.NoInput || result.Comparison != nil || result.ID != "walden" {
+			t.Errorf("result = %+v", result)
+		}
+	})
+
+	t.Run("reference without scored fields", func(t *testing.T) {
+		empty := reference{Item: dataset.DatasetItem{ID: "empty"}, Record: mnemonic(t, "=LDR  00000nam a2200000 i 4500\n=001  x")}
+		result := scoreGeneratedRecord(empty, generatedRecord{Record: ref.Record}, "vendor", profile)
+		if result.Comparison.Score != 0 || result.Comparison.Extra != 4 {
+			t.Errorf("comparison = %+v", result.Comparison)
+		}
+	})
+}
+
+func TestReadGenerated(t *testing.T) {
+	dir := t.TempDir()
+	one, err := mnemonic(t, scoreReference).XML()
+	if err != nil {
+		t.Fatal(err)
+	}
+	two, err := marc.CollectionXML([]*marc.Record{mnemonic(t, scoreReference), mnemonic(t, without("=650"))})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, data := range map[string][]byte{"walden.xml": one, "sub/batch.xml": two, "notes.txt": []byte("not a record")} {
+		if err := os.MkdirAll(filepath.Dir(filepath.Join(dir, name)), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, name), data, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	records, err := readGenerated(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 3 {
+		t.Fatalf("read %d records, want 3", len(records))
+	}
+	// A file holding several records matches by 001 or ISBN only, so its records have no stem
+	if records[0].Stem != "" || !strings.HasSuffix(records[0].Source, "batch.xml#1") || records[2].Stem != "walden" {
+		t.Errorf("records = %+v", records)
+	}
+}
@@ -0,0 +1,91 @@
+package evalcmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/lehigh-university-libraries/cataloger/internal/eval/selftest"
+	"github.com/spf13/cobra"
+)
+
+// NewSelftestCmd creates the selftest command for checking scoring against the golden corpus
+func NewSelftestCmd() *cobra.Command {
+	var corpusPath string
+	var verbose bool
+
+	cmd := &cobra.Command{
+		Use:   "selftest",
+		Short: "Verify MARC scoring against the embedded golden corpus",
+		Long: `Score a small embedded corpus of reference/generated MARC pairs and check each score
+against its expected value.
+
+Run this after changing comparison or normalization code: a failure means metric semantics
+shifted, and scores from earlier releases are no longer directly comparable.`,
+		Example: `  # Check the builtin corpus
+  cataloger eval selftest
+
+  # Show per-field scores for every case
+  cataloger eval selftest --verbose
+
+  # Check a local corpus in the same YAML format
+  cataloger eval selftest --corpus ./my_corpus.yaml`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return executeSelftest(corpusPath, verbose)
+		},
+	}
+
+	cmd.Flags().StringVar(&corpusPath, "corpus", "", "YAML corpus file (defaults to the builtin corpus)")
+	cmd.Flags().BoolVar(&verbose, "verbose", false, "Show per-field scores")
+
+	return cmd
+}
+
+func executeSelftest(corpusPath string, verbose bool) error {
+	var cases []selftest.Case
+	var err error
+	if corpusPath != "" {
+		cases, err = selftest.LoadCorpus(corpusPath)
+	} else {
+		cases, err = selftest.Builtin()
+	}
+	if err != nil {
+		return err
+	}
+
+	failed := 0
+	for _, r := range selftest.Run(cases) {
+		status := "PASS"
+		if !r.Pass {
+			status = "FAIL"
+			failed++
+		}
+
+		if r.Error != "" {
+			fmt.Printf("%s  %-28s error: %s\n", status, r.Case.Name, r.Error)
+			continue
+		}
+		fmt.Printf("%s  %-28s expected %.4f  got %.4f", status, r.Case.Name, r.Case.Expected, r.Actual)
+		if r.Case.ExpectedIndicators != nil {
+			fmt.Printf("  indicators expected %.4f  got %.4f", *r.Case.ExpectedIndicators, r.Indicators)
+		}
+		fmt.Println()
+
+		if verbose || !r.Pass {
+			for _, tag := range r.Comparison.Tags() {
+				f := r.Comparison.Fields[tag]
+				fmt.Printf("        %s  %.3f  %-14s expected: %s  actual: %s\n",
+					tag, f.Score, f.Match, strings.Join(f.Expected, " | "), strings.Join(f.Actual, " | "))
+			}
+			for _, ind := range r.Comparison.Indicators {
+				fmt.Printf("        %s  %d/%d correct  expected: %s  actual: %s\n",
+					ind.Name, ind.Correct, ind.Checked, strings.Join(ind.Expected, " | "), strings.Join(ind.Actual, " | "))
+			}
+		}
+	}
+
+	fmt.Printf("\n%d/%d cases passed\n", len(cases)-failed, len(cases))
+	if failed > 0 {
+		return fmt.Errorf("%d selftest cases failed: scoring semantics changed", failed)
+	}
+	return nil
+}
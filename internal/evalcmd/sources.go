@@ -0,0 +1,302 @@
+package evalcmd
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/lehigh-university-libraries/cataloger/internal/cataloging"
+	"github.com/lehigh-university-libraries/cataloger/internal/eval/anomaly"
+	"github.com/lehigh-university-libraries/cataloger/internal/eval/dataset"
+	"github.com/lehigh-university-libraries/cataloger/internal/eval/failure"
+	"github.com/lehigh-university-libraries/cataloger/internal/eval/marceval"
+	"github.com/lehigh-university-libraries/cataloger/internal/images"
+	"github.com/lehigh-university-libraries/cataloger/internal/marc"
+	"github.com/lehigh-university-libraries/cataloger/internal/ocr"
+	"github.com/spf13/cobra"
+)
+
+// Title page sources of the sources command besides images.TitlePageSources
+const (
+	sourceDataset = "dataset" // The item's title page in dataset.json
+	sourceLinks   = "856"     // The reference record's 856 links, including IIIF
+	sourceLocal   = "local"   // Local scans in --local-scans
+)
+
+// sourcesOptions holds the flags for the sources command
+type sourcesOptions struct {
+	datasetDir string
+	outputJSON string
+	sampleSize int
+	provider   string
+	model      string
+	sources    []string
+	localScans string
+	cacheDir   string
+	verbose    bool
+}
+
+// NewSourcesCmd creates the sources command for comparing scores across title page image sources
+func NewSourcesCmd() *cobra.Command {
+	var opts sourcesOptions
+
+	cmd := &cobra.Command{
+		Use:   "sources",
+		Short: "Compare MARC generation scores across title page image sources",
+		Long: `Generate MARC for the same records from title pages of different image sources and
+report how the scores differ, to measure how much image provenance matters.
+
+Sources:
+  internetarchive  Title page from the Internet Archive, found through Open Library
+  googlebooks      Title page from the Google Books preview
+  856              Title page from the reference record's 856 links (images, IIIF, PDFs)
+  local            Local scans in --local-scans: <id>.jpg, <isbn>.jpg or <id>/title_page.jpg
+                   (also .jpeg, .png, .tif)
+  dataset          The title page already in dataset.json, whatever its source
+
+Title pages are downloaded into images/<id>/sources/<source>/ and reused by later runs.
+The first source is the baseline: the report shows each source's mean score, its mean over
+the records every source has a title page for, and its mean difference from the baseline
+overall and by field, over the records scored from both.`,
+		Example: `  # Internet Archive vs Google Books
+  cataloger eval sources --dataset ./eval_data --sample 50
+
+  # Compare against the library's own scans
+  cataloger eval sources --dataset ./eval_data --sources local,internetarchive,googlebooks --local-scans ./scans`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if _, err := os.Stat(opts.datasetDir); os.IsNotExist(err) {
+				return fmt.Errorf("dataset directory not found: %s", opts.datasetDir)
+			}
+			known := append(slices.Clone(images.TitlePageSources), sourceLinks, sourceLocal, sourceDataset)
+			for _, source := range opts.sources {
+				if !slices.Contains(known, source) {
+					return fmt.Errorf("unknown source %q (%s)", source, strings.Join(known, ", "))
+				}
+			}
+			if len(opts.sources) < 2 {
+				return fmt.Errorf("--sources needs at least two sources to compare")
+			}
+			if slices.Contains(opts.sources, sourceLocal) && opts.localScans == "" {
+				return fmt.Errorf("--local-scans is required for the local source")
+			}
+			return executeSources(opts)
+		},
+	}
+
+	cmd.Flags().StringVar(&opts.datasetDir, "dataset", "./eval_data", "Path to MARC evaluation dataset directory")
+	cmd.Flags().StringVar(&opts.outputJSON, "output-json", "eval_sources_results.json", "Path to output JSON results file")
+	cmd.Flags().IntVar(&opts.sampleSize, "sample", -1, "Number of items to evaluate (-1 for all)")
+	cmd.Flags().StringVar(&opts.provider, "provider", "ollama", "LLM provider (ollama, openai, azure-openai, gemini, claude, groq, huggingface, llamacpp, mistral, or mock)")
+	cmd.Flags().StringVar(&opts.model, "model", "", "Model name (defaults to provider's default)")
+	cmd.Flags().StringSliceVar(&opts.sources, "sources", []string{images.SourceInternetArchive, images.SourceGoogleBooks, sourceLinks}, "Title page sources to compare; the first is the baseline")
+	cmd.Flags().StringVar(&opts.localScans, "local-scans", "", "Directory of local title page scans for the local source")
+	cmd.Flags().StringVar(&opts.cacheDir, "cache-dir", "", "Shared image cache directory, or off (default $IMAGE_CACHE_DIR or the user cache directory)")
+	cmd.Flags().BoolVar(&opts.verbose, "verbose", false, "Verbose logging")
+
+	return cmd
+}
+
+func executeSources(opts sourcesOptions) error {
+	logLevel := slog.LevelInfo
+	if opts.verbose {
+		logLevel = slog.LevelDebug
+	}
+	slog.SetDefault(slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: logLevel})))
+
+	ds, err := dataset.LoadMARCDataset(opts.datasetDir)
+	if err != nil {
+		return fmt.Errorf("failed to load dataset: %w", err)
+	}
+
+	items := ds.Index.Items
+	if opts.sampleSize > 0 && opts.sampleSize < len(items) {
+		items = items[:opts.sampleSize]
+	}
+
+	cache := images.CacheFromEnv()
+	if opts.cacheDir != "" {
+		cache = images.NewCache(opts.cacheDir)
+	}
+	fetcher := images.NewFetcherWithCache(cache)
+	catalogService := cataloging.NewService()
+	ocrService := ocr.NewService()
+
+	model := opts.model
+	if model == "" {
+		model = catalogService.GetDefaultModel(opts.provider)
+	}
+
+	slog.Info("Starting image source comparison", "dataset", opts.datasetDir, "items", len(items), "sources", opts.sources, "provider", opts.provider, "model", model)
+
+	var results []marceval.Result
+	for i, item := range items {
+		data, err := ds.ReadMARCXML(item)
+		if err != nil {
+			slog.Warn("Failed to read reference record", "id", item.ID, "error", err)
+			continue
+		}
+		reference, err := marc.ParseXML(data)
+		if err != nil {
+			slog.Warn("Failed to parse reference record", "id", item.ID, "error", err)
+			continue
+		}
+
+		for _, source := range opts.sources {
+			image, err := titlePageFromSource(ds, item, reference, source, opts.localScans, fetcher)
+			if err != nil {
+				slog.Debug("No title page from source", "id", item.ID, "source", source, "error", err)
+				continue
+			}
+			result := evaluateTitlePage(item, reference, image, catalogService, ocrService, opts.provider, model)
+			result.ImageSource = source
+			if result.Error != "" {
+				slog.Warn("Item processing failed", "id", item.ID, "source", source, "error", result.Error)
+			} else {
+				slog.Debug("Item scored", "id", item.ID, "source", source, "score", result.Comparison.Score)
+			}
+			results = append(results, result)
+		}
+
+		if (i+1)%10 == 0 {
+			fmt.Printf("Progress: %d/%d items processed\n", i+1, len(items))
+		}
+	}
+
+	comparison := marceval.NewSourceComparison(results, opts.sources)
+	comparison.Dataset = opts.datasetDir
+	comparison.Provider = opts.provider
+	comparison.Model = model
+	comparison.PrintSummary()
+
+	if err := comparison.SaveJSON(opts.outputJSON); err != nil {
+		fmt.Printf("Warning: Failed to save JSON results: %v\n", err)
+	} else {
+		fmt.Printf("\nResults saved to: %s\n", opts.outputJSON)
+	}
+
+	return nil
+}
+
+// titlePageFromSource returns the path of an item's title page from one source, downloading
+// it into images/<id>/sources/<source>/ unless it is there from an earlier run
+func titlePageFromSource(ds *dataset.MARCDataset, item dataset.DatasetItem, reference *marc.Record, source, localScans string, fetcher *images.Fetcher) (string, error) {
+	switch source {
+	case sourceDataset:
+		if item.Images.TitlePage == "" {
+			return "", fmt.Errorf("no title page in %s", dataset.IndexFilename)
+		}
+		return ds.Path(item.Images.TitlePage), nil
+	case sourceLocal:
+		return localScan(localScans, item)
+	}
+
+	dir := filepath.Join(ds.Dir, "images", item.ID, "sources", source)
+	if matches, _ := filepath.Glob(filepath.Join(dir, images.KindTitlePage+".*")); len(matches) > 0 {
+		return matches[0], nil
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create image directory: %w", err)
+	}
+
+	if source == sourceLinks {
+		var links []images.DigitalLink
+		for _, link := range images.DigitalLinks(reference) {
+			// Only links that can provide a title page
+			if link.Role == "" || link.Role == images.KindTitlePage {
+				links = append(links, link)
+			}
+		}
+		if len(links) == 0 {
+			return "", fmt.Errorf("no 856 links")
+		}
+		imageSet, err := fetcher.FetchImagesFromLinks(links, dir)
+		if err != nil {
+			return "", err
+		}
+		if imageSet.TitlePagePath == "" {
+			return "", fmt.Errorf("no title page among the 856 links")
+		}
+		return imageSet.TitlePagePath, nil
+	}
+
+	if item.ISBN == "" {
+		return "", fmt.Errorf("no ISBN")
+	}
+	path := filepath.Join(dir, images.KindTitlePage+".jpg")
+	if err := fetcher.FetchTitlePage(item.ISBN, source, path); err != nil {
+		os.Remove(path)
+		return "", err
+	}
+	return path, nil
+}
+
+// localScan finds an item's title page scan by item ID or ISBN
+func localScan(dir string, item dataset.DatasetItem) (string, error) {
+	var candidates []string
+	for _, ext := range []string{".jpg", ".jpeg", ".png", ".tif", ".tiff"} {
+		candidates = append(candidates,
+			filepath.Join(dir, item.ID+ext),
+			filepath.Join(dir, item.ID, images.KindTitlePage+ext))
+		if item.ISBN != "" {
+			candidates = append(candidates, filepath.Join(dir, item.ISBN+ext))
+		}
+	}
+	for _, path := range candidates {
+		if _, err := os.Stat(path); err == nil {
+			return path, nil
+		}
+	}
+	return "", fmt.Errorf("no local scan for %s", item.ID)
+}
+
+// evaluateTitlePage OCRs a title page image, generates MARC from it and scores it against the
+// reference. Unlike evaluateItem, the mock provider OCRs the image too (through its .txt
+// sidecar), so sources can differ offline.
+func evaluateTitlePage(item dataset.DatasetItem, reference *marc.Record, image string, catalogService *cataloging.Service, ocrService *ocr.Service, provider, model string) marceval.Result {
+	start := time.Now()
+	result := marceval.Result{
+		ID:            item.ID,
+		Title:         item.Title,
+		Provider:      provider,
+		Model:         model,
+		PromptVersion: catalogService.PromptVersion(),
+	}
+
+	text, err := ocrService.ExtractTextFromImage(context.Background(), image, provider, model)
+	if err != nil {
+		result.Error = fmt.Sprintf("OCR failed: %v", err)
+		result.ErrorCode = failure.Classify(err, failure.ProviderError)
+		result.ProcessingTime = time.Since(start)
+		return result
+	}
+	result.OCRText = text
+
+	generated, notes, err := catalogService.GenerateMARCFromPages(context.Background(), cataloging.OCRPages{Text: text}, provider, model)
+	result.Warnings = notes.Warnings
+	if notes.Refusal != nil {
+		result.Refusal = notes.Refusal.Category
+	}
+	if err != nil {
+		result.Error = fmt.Sprintf("MARC generation failed: %v", err)
+		result.ErrorCode = failure.Classify(err, failure.ProviderError)
+		result.ProcessingTime = time.Since(start)
+		return result
+	}
+
+	result.GeneratedMARC = generated.Mnemonic()
+	if a := anomaly.Check(text, notes.Response, generated); a != nil {
+		result.Anomaly = a.Kind
+		result.Error = fmt.Sprintf("Degenerate output: %v", a)
+		result.ErrorCode = failure.Degenerate
+		result.ProcessingTime = time.Since(start)
+		return result
+	}
+	result.Comparison = marceval.Compare(reference, generated)
+	result.ProcessingTime = time.Since(start)
+	return result
+}
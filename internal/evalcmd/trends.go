@@ -0,0 +1,167 @@
+package evalcmd
+
+import (
+	"fmt"
+	"html/template"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/lehigh-university-libraries/cataloger/internal/eval/trendchart"
+)
+
+// trendsOptions holds the flags for eval report --trends
+type trendsOptions struct {
+	outputDir string
+	formats   []string
+	fields    []string
+}
+
+// loadTrendRuns reads every eval run report JSON under root. Other JSON files, such as
+// dataset.json or cluster output, are skipped.
+func loadTrendRuns(root string) ([]trendchart.Run, error) {
+	var runs []trendchart.Run
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || filepath.Ext(path) != ".json" || d.Name() == "dataset.json" {
+			return nil
+		}
+		report, err := loadRunReport(path)
+		if err != nil || report.EvaluationDate.IsZero() || report.Provider == "" {
+			slog.Debug("Skipping file that is not a run report", "path", path, "error", err)
+			return nil
+		}
+		runs = append(runs, trendchart.Run{
+			Time:          report.EvaluationDate,
+			Provider:      report.Provider,
+			Model:         report.Model,
+			PromptVersion: report.PromptVersion,
+			MeanScore:     report.MeanScore,
+			FieldMeans:    report.FieldMeans,
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read results root: %w", err)
+	}
+	return runs, nil
+}
+
+// namedChart is a chart with the file name it is saved under
+type namedChart struct {
+	Name  string
+	Chart trendchart.Chart
+}
+
+var trendsHTML = template.Must(template.New("trends").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Evaluation trends</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+svg { display: block; margin-bottom: 1.5em; }
+</style>
+</head>
+<body>
+<h1>Evaluation trends</h1>
+<p>{{.Runs}} runs of {{.Models}} models from {{.Root}}. Hover over a point for its date, score and prompt version.</p>
+{{range .Sections}}<h2>{{.Title}}</h2>
+{{range .Charts}}{{.}}
+{{end}}{{end}}</body>
+</html>
+`))
+
+// writeTrends charts the runs under root: mean score by model, each model by field, and
+// each field by model, as an HTML page and PNG images
+func writeTrends(root string, opts trendsOptions) error {
+	for _, f := range opts.formats {
+		if f != "html" && f != "png" {
+			return fmt.Errorf("unknown chart format %q (use html or png)", f)
+		}
+	}
+	runs, err := loadTrendRuns(root)
+	if err != nil {
+		return err
+	}
+	if len(runs) == 0 {
+		return fmt.Errorf("no eval run reports found in %s", root)
+	}
+	models := trendchart.Models(runs)
+	fields := trendchart.Fields(runs)
+	if len(opts.fields) > 0 {
+		fields = slices.DeleteFunc(fields, func(tag string) bool { return !slices.Contains(opts.fields, tag) })
+	}
+
+	type section struct {
+		Title  string
+		Charts []namedChart
+	}
+	sections := []section{{Title: "Overall", Charts: []namedChart{{Name: "overall", Chart: trendchart.Overall(runs)}}}}
+	byModel := section{Title: "By model"}
+	for _, m := range models {
+		byModel.Charts = append(byModel.Charts, namedChart{Name: "model_" + safeFileName(m), Chart: trendchart.Model(runs, m)})
+	}
+	byField := section{Title: "By field"}
+	for _, tag := range fields {
+		byField.Charts = append(byField.Charts, namedChart{Name: "field_" + safeFileName(tag), Chart: trendchart.Field(runs, tag)})
+	}
+	sections = append(sections, byModel, byField)
+
+	if err := os.MkdirAll(opts.outputDir, 0755); err != nil {
+		return fmt.Errorf("failed to create output directory: %w", err)
+	}
+	if slices.Contains(opts.formats, "png") {
+		for _, s := range sections {
+			for _, c := range s.Charts {
+				if err := writePNGChart(filepath.Join(opts.outputDir, c.Name+".png"), c.Chart); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	if slices.Contains(opts.formats, "html") {
+		type htmlSection struct {
+			Title  string
+			Charts []template.HTML
+		}
+		page := struct {
+			Root         string
+			Runs, Models int
+			Sections     []htmlSection
+		}{Root: root, Runs: len(runs), Models: len(models)}
+		for _, s := range sections {
+			hs := htmlSection{Title: s.Title}
+			for _, c := range s.Charts {
+				// The SVG escapes its own text
+				hs.Charts = append(hs.Charts, template.HTML(c.Chart.SVG()))
+			}
+			page.Sections = append(page.Sections, hs)
+		}
+		f, err := os.Create(filepath.Join(opts.outputDir, "index.html"))
+		if err != nil {
+			return fmt.Errorf("failed to create trends page: %w", err)
+		}
+		defer f.Close()
+		if err := trendsHTML.Execute(f, page); err != nil {
+			return fmt.Errorf("failed to write trends page: %w", err)
+		}
+	}
+
+	fmt.Printf("Charted %d runs of %d models (%s) and %d fields in %s\n", len(runs), len(models), strings.Join(models, ", "), len(fields), opts.outputDir)
+	return nil
+}
+
+func writePNGChart(path string, c trendchart.Chart) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create chart: %w", err)
+	}
+	defer f.Close()
+	return c.PNG(f)
+}
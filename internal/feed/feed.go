@@ -0,0 +1,281 @@
+// Package feed publishes approved records as a change feed, in Atom and JSON Feed, so indexing
+// pipelines can follow new approvals without polling the sessions API
+package feed
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/lehigh-university-libraries/cataloger/internal/models"
+	"github.com/lehigh-university-libraries/cataloger/internal/storage"
+)
+
+// Page sizes of the feed
+const (
+	DefaultLimit = 50
+	MaxLimit     = 500
+)
+
+// MARCXMLType is the media type of the records the entries link to
+const MARCXMLType = "application/marcxml+xml"
+
+// Entry is an approved record
+type Entry struct {
+	ID       string // Session ID
+	Title    string // 245 $a of the record
+	ISBNs    []string
+	Status   string    // approved, or pushed once in the library system
+	Approved time.Time // When the session was last approved
+	Approver string
+}
+
+// Window selects approvals after Since and before Until; zero times are open ends
+type Window struct {
+	Since time.Time
+	Until time.Time
+}
+
+// Approvals returns the approved and pushed sessions last approved within w, newest first
+// and at most limit of them, and whether the window holds more. A session sent back to
+// review leaves the feed until it is approved again, when it returns with the new time.
+func Approvals(store *storage.SessionStore, w Window, limit int) ([]Entry, bool) {
+	var entries []Entry
+	for _, status := range []string{models.StatusApproved, models.StatusPushed} {
+		for _, e := range store.Search(storage.Query{Status: status}) {
+			entry := Entry{ID: e.ID, Title: e.Title, ISBNs: e.ISBNs, Status: e.Status, Approved: e.CreatedAt}
+			// Sessions approved before status changes were audited fall back to their creation
+			history := store.History(e.ID)
+			for i := len(history) - 1; i >= 0; i-- {
+				if history[i].Action == models.ActionStatus && history[i].Details["to"] == models.StatusApproved {
+					entry.Approved, entry.Approver = history[i].Time, history[i].Actor
+					break
+				}
+			}
+			if !w.Since.IsZero() && !entry.Approved.After(w.Since) {
+				continue
+			}
+			if !w.Until.IsZero() && !entry.Approved.Before(w.Until) {
+				continue
+			}
+			entries = append(entries, entry)
+		}
+	}
+	slices.SortFunc(entries, func(a, b Entry) int {
+		if c := b.Approved.Compare(a.Approved); c != 0 {
+			return c
+		}
+		return strings.Compare(a.ID, b.ID)
+	})
+	if len(entries) > limit {
+		return entries[:limit], true
+	}
+	return entries, false
+}
+
+// Page is a page of the feed as served at Self
+type Page struct {
+	Title   string
+	BaseURL string // Server URL the entries' links start with
+	Self    string
+	Next    string // Page of older entries; empty on the last page
+	Entries []Entry
+	Updated time.Time // Newest approval, or when the page was made if it is empty
+}
+
+// SessionURL is the sessions API URL of an entry
+func (p Page) SessionURL(e Entry) string {
+	return p.BaseURL + "/api/sessions/" + e.ID
+}
+
+// MARCXMLURL is the URL of an entry's record as MARCXML
+func (p Page) MARCXMLURL(e Entry) string {
+	return p.SessionURL(e) + "/marcxml"
+}
+
+// summary describes an entry in a line
+func summary(e Entry) string {
+	parts := []string{"Status: " + e.Status}
+	if len(e.ISBNs) > 0 {
+		parts = append(parts, "ISBN: "+strings.Join(e.ISBNs, ", "))
+	}
+	if e.Approver != "" {
+		parts = append(parts, "Approved by: "+e.Approver)
+	}
+	return strings.Join(parts, "; ")
+}
+
+// title is an entry's title, or its session for a record without a 245 $a
+func title(e Entry) string {
+	if e.Title != "" {
+		return e.Title
+	}
+	return "Session " + e.ID
+}
+
+const atomNamespace = "http://www.w3.org/2005/Atom"
+
+type atomFeed struct {
+	XMLName xml.Name    `xml:"feed"`
+	XMLNS   string      `xml:"xmlns,attr"`
+	Title   string      `xml:"title"`
+	ID      string      `xml:"id"`
+	Updated string      `xml:"updated"`
+	Author  atomPerson  `xml:"author"`
+	Links   []atomLink  `xml:"link"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+type atomEntry struct {
+	Title    string       `xml:"title"`
+	ID       string       `xml:"id"`
+	Updated  string       `xml:"updated"`
+	Author   *atomPerson  `xml:"author,omitempty"`
+	Category atomCategory `xml:"category"`
+	Links    []atomLink   `xml:"link"`
+	Summary  string       `xml:"summary"`
+}
+
+type atomPerson struct {
+	Name string `xml:"name"`
+}
+
+type atomCategory struct {
+	Term string `xml:"term,attr"`
+}
+
+type atomLink struct {
+	Rel  string `xml:"rel,attr"`
+	Type string `xml:"type,attr,omitempty"`
+	Href string `xml:"href,attr"`
+}
+
+// WriteAtom writes the page as an Atom feed, paged as in RFC 5005. Entries are identified by
+// their session URL and link to the record as MARCXML.
+func WriteAtom(w io.Writer, p Page) error {
+	feed := atomFeed{
+		XMLNS:   atomNamespace,
+		Title:   p.Title,
+		ID:      p.Self,
+		Updated: p.Updated.UTC().Format(time.RFC3339Nano),
+		Author:  atomPerson{Name: p.Title},
+		Links:   []atomLink{{Rel: "self", Type: "application/atom+xml", Href: p.Self}},
+	}
+	if p.Next != "" {
+		feed.Links = append(feed.Links, atomLink{Rel: "next", Type: "application/atom+xml", Href: p.Next})
+	}
+	for _, e := range p.Entries {
+		entry := atomEntry{
+			Title:    title(e),
+			ID:       p.SessionURL(e),
+			Updated:  e.Approved.UTC().Format(time.RFC3339Nano),
+			Category: atomCategory{Term: e.Status},
+			Links: []atomLink{
+				{Rel: "alternate", Type: MARCXMLType, Href: p.MARCXMLURL(e)},
+				{Rel: "related", Type: "application/json", Href: p.SessionURL(e)},
+			},
+			Summary: summary(e),
+		}
+		if e.Approver != "" {
+			entry.Author = &atomPerson{Name: e.Approver}
+		}
+		feed.Entries = append(feed.Entries, entry)
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(feed); err != nil {
+		return fmt.Errorf("failed to write feed: %w", err)
+	}
+	return nil
+}
+
+// JSONFeedVersion is the JSON Feed version of WriteJSON
+const JSONFeedVersion = "https://jsonfeed.org/version/1.1"
+
+type jsonFeed struct {
+	Version string     `json:"version"`
+	Title   string     `json:"title"`
+	FeedURL string     `json:"feed_url"`
+	NextURL string     `json:"next_url,omitempty"`
+	Items   []jsonItem `json:"items"`
+}
+
+type jsonItem struct {
+	ID           string           `json:"id"`
+	URL          string           `json:"url"`
+	Title        string           `json:"title"`
+	ContentText  string           `json:"content_text"`
+	DateModified string           `json:"date_modified"`
+	Authors      []jsonAuthor     `json:"authors,omitempty"`
+	Tags         []string         `json:"tags"`
+	Attachments  []jsonAttachment `json:"attachments"`
+	Cataloger    jsonExtension    `json:"_cataloger"`
+}
+
+type jsonAuthor struct {
+	Name string `json:"name"`
+}
+
+type jsonAttachment struct {
+	URL      string `json:"url"`
+	MIMEType string `json:"mime_type"`
+}
+
+// jsonExtension carries the entry's fields for pipelines, which JSON Feed has no place for
+type jsonExtension struct {
+	Session  string   `json:"session"`
+	ISBNs    []string `json:"isbns,omitempty"`
+	Status   string   `json:"status"`
+	Approved string   `json:"approved"`
+	MARCXML  string   `json:"marcxml"`
+}
+
+// WriteJSON writes the page as a JSON Feed. Each item's MARCXML is an attachment and, with
+// its session, ISBNs and status, in the _cataloger extension.
+func WriteJSON(w io.Writer, p Page) error {
+	feed := jsonFeed{
+		Version: JSONFeedVersion,
+		Title:   p.Title,
+		FeedURL: p.Self,
+		NextURL: p.Next,
+		Items:   []jsonItem{},
+	}
+	for _, e := range p.Entries {
+		approved := e.Approved.UTC().Format(time.RFC3339Nano)
+		item := jsonItem{
+			ID:           p.SessionURL(e),
+			URL:          p.SessionURL(e),
+			Title:        title(e),
+			ContentText:  summary(e),
+			DateModified: approved,
+			Tags:         []string{e.Status},
+			Attachments:  []jsonAttachment{{URL: p.MARCXMLURL(e), MIMEType: MARCXMLType}},
+			Cataloger: jsonExtension{
+				Session:  e.ID,
+				ISBNs:    e.ISBNs,
+				Status:   e.Status,
+				Approved: approved,
+				MARCXML:  p.MARCXMLURL(e),
+			},
+		}
+		if e.Approver != "" {
+			item.Authors = []jsonAuthor{{Name: e.Approver}}
+		}
+		feed.Items = append(feed.Items, item)
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(feed); err != nil {
+		return fmt.Errorf("failed to write feed: %w", err)
+	}
+	return nil
+}
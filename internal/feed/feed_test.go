@@ -0,0 +1,115 @@
+package feed
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lehigh-university-libraries/cataloger/internal/models"
+	"github.com/lehigh-university-libraries/cataloger/internal/storage"
+)
+
+const record = `<record xmlns="http://www.loc.gov/MARC21/slim"><leader>00000nam a2200000 a 4500</leader><datafield tag="020" ind1=" " ind2=" "><subfield code="a">0131103628</subfield></datafield><datafield tag="245" ind1="1" ind2="0"><subfield code="a">Walden /</subfield></datafield></record>`
+
+func TestApprovals(t *testing.T) {
+	store := storage.New()
+	start := time.Date(2026, 10, 18, 9, 0, 0, 0, time.UTC)
+	approve := func(id string, at time.Time) {
+		store.AppendEvent(id, models.AuditEvent{Time: at, Actor: "jdoe", Action: models.ActionStatus, Details: map[string]string{"from": models.StatusInReview, "to": models.StatusApproved}})
+	}
+	for id, status := range map[string]string{"a": models.StatusApproved, "b": models.StatusPushed, "c": models.StatusInReview, "d": models.StatusApproved} {
+		store.Set(id, &models.CatalogSession{ID: id, Status: status, MARC: record, CreatedAt: start})
+	}
+	approve("a", start.Add(time.Hour))
+	approve("a", start.Add(3*time.Hour)) // Reopened and approved again
+	approve("b", start.Add(2*time.Hour))
+	approve("c", start.Add(4*time.Hour)) // Since sent back to review
+
+	entries, more := Approvals(store, Window{}, DefaultLimit)
+	if more || len(entries) != 3 {
+		t.Fatalf("entries = %+v, more = %v", entries, more)
+	}
+	a := entries[0]
+	if a.ID != "a" || !a.Approved.Equal(start.Add(3*time.Hour)) || a.Approver != "jdoe" || a.Title != "Walden" || len(a.ISBNs) != 1 || a.ISBNs[0] != "9780131103627" {
+		t.Errorf("newest = %+v", a)
+	}
+	if entries[1].ID != "b" || entries[1].Status != models.StatusPushed || entries[2].ID != "d" || !entries[2].Approved.Equal(start) {
+		t.Errorf("order = %+v", entries)
+	}
+
+	entries, more = Approvals(store, Window{Since: start, Until: start.Add(4 * time.Hour)}, 1)
+	if !more || len(entries) != 1 || entries[0].ID != "a" {
+		t.Errorf("window = %+v, more = %v", entries, more)
+	}
+}
+
+func TestWriteAtom(t *testing.T) {
+	page := Page{
+		Title:   "Approved records",
+		BaseURL: "http://localhost:8888",
+		Self:    "http://localhost:8888/feeds/approved.atom",
+		Next:    "http://localhost:8888/feeds/approved.atom?until=x",
+		Entries: []Entry{
+			{ID: "a", Title: "Walden & more", ISBNs: []string{"9780131103627"}, Status: "approved", Approved: time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC), Approver: "jdoe"},
+			{ID: "b", Status: "pushed"},
+		},
+	}
+	var b strings.Builder
+	if err := WriteAtom(&b, page); err != nil {
+		t.Fatal(err)
+	}
+	var feed struct {
+		XMLName xml.Name
+		Links   []atomLink `xml:"link"`
+		Entries []struct {
+			Title   string     `xml:"title"`
+			ID      string     `xml:"id"`
+			Updated string     `xml:"updated"`
+			Author  string     `xml:"author>name"`
+			Links   []atomLink `xml:"link"`
+		} `xml:"entry"`
+	}
+	if err := xml.Unmarshal([]byte(b.String()), &feed); err != nil {
+		t.Fatalf("invalid feed: %v\n%s", err, b.String())
+	}
+	if feed.XMLName.Space != atomNamespace || len(feed.Links) != 2 || feed.Links[1].Rel != "next" || len(feed.Entries) != 2 {
+		t.Fatalf("feed = %+v", feed)
+	}
+	a := feed.Entries[0]
+	if a.Title != "Walden & more" || a.ID != "http://localhost:8888/api/sessions/a" || a.Updated != "2026-10-18T12:00:00Z" || a.Author != "jdoe" ||
+		a.Links[0].Type != MARCXMLType || a.Links[0].Href != "http://localhost:8888/api/sessions/a/marcxml" {
+		t.Errorf("entry = %+v", a)
+	}
+	if feed.Entries[1].Title != "Session b" {
+		t.Errorf("untitled entry = %+v", feed.Entries[1])
+	}
+}
+
+func TestWriteJSON(t *testing.T) {
+	var b strings.Builder
+	if err := WriteJSON(&b, Page{Title: "Approved records", Self: "http://x/feeds/approved.json"}); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(b.String(), `"items": []`) || strings.Contains(b.String(), "next_url") {
+		t.Errorf("empty feed:\n%s", b.String())
+	}
+
+	b.Reset()
+	page := Page{BaseURL: "http://x", Entries: []Entry{{ID: "a", Title: "Walden", Status: "approved", Approved: time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)}}}
+	if err := WriteJSON(&b, page); err != nil {
+		t.Fatal(err)
+	}
+	var feed jsonFeed
+	if err := json.Unmarshal([]byte(b.String()), &feed); err != nil {
+		t.Fatal(err)
+	}
+	if feed.Version != JSONFeedVersion || len(feed.Items) != 1 {
+		t.Fatalf("feed = %+v", feed)
+	}
+	item := feed.Items[0]
+	if item.Attachments[0].URL != "http://x/api/sessions/a/marcxml" || item.Cataloger.Session != "a" || item.DateModified != "2026-10-18T12:00:00Z" || item.Authors != nil {
+		t.Errorf("item = %+v", item)
+	}
+}
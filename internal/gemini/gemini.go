@@ -1,25 +1,96 @@
 package gemini
 
 import (
+	"bytes"
 	"context"
+	"errors"
 	"fmt"
+	"log/slog"
+	"net/http"
 	"os"
+	"strings"
+	"time"
 
 	"github.com/google/generative-ai-go/genai"
 	"github.com/lehigh-university-libraries/cataloger/internal/providers"
+	"google.golang.org/api/googleapi"
 	"google.golang.org/api/option"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
+// defaultSafetyThreshold is used for every harm category unless overridden. Gemini's own
+// default blocks too many legitimate title pages (war history, medicine, criminology).
+const defaultSafetyThreshold = "block_only_high"
+
+// Registration registers the provider as "gemini"
+var Registration = providers.Registration{
+	Name:         "gemini",
+	New:          func() providers.Provider { return New() },
+	DefaultModel: providers.EnvModel("GEMINI_MODEL", "gemini-1.5-flash-latest"),
+	Configured:   providers.EnvSet("GEMINI_API_KEY"),
+	Vision:       true,
+}
+
 // Gemini is a provider for Google Gemini
-type Gemini struct{}
+type Gemini struct {
+	SafetySettings []*genai.SafetySetting
+}
 
 // New returns a new Gemini provider
+//
+// Safety settings are configured with:
+//   - GEMINI_SAFETY_THRESHOLD: threshold applied to all categories
+//     (block_none, block_only_high, block_medium_and_above, block_low_and_above, default)
+//   - GEMINI_SAFETY_SETTINGS: per-category overrides, e.g. "dangerous_content=block_none,harassment=block_only_high"
+//
+// With PROVIDER_FILE_UPLOADS, each image is uploaded once through the Files API and referred
+// to by URI until it expires.
 func New() *Gemini {
-	return &Gemini{}
+	settings, err := ParseSafetySettings(os.Getenv("GEMINI_SAFETY_THRESHOLD"), os.Getenv("GEMINI_SAFETY_SETTINGS"))
+	if err != nil {
+		slog.Warn("Invalid Gemini safety settings, using defaults", "error", err)
+		settings, _ = ParseSafetySettings("", "")
+	}
+	return &Gemini{SafetySettings: settings}
 }
 
 // ExtractText extracts text from the given prompt using Gemini
 func (g *Gemini) ExtractText(ctx context.Context, config providers.Config) (string, error) {
+	return g.generate(ctx, config, nil)
+}
+
+// GenerateFromImages sends several images, in order, before the prompt in one request
+func (g *Gemini) GenerateFromImages(ctx context.Context, config providers.Config, images [][]byte) (string, error) {
+	for _, image := range images {
+		if _, err := imageFormat(image); err != nil {
+			return "", err
+		}
+	}
+	return g.generate(ctx, config, images)
+}
+
+// GenerateFromImage sends an image, inline or as an uploaded file, with the prompt, for title
+// page OCR
+func (g *Gemini) GenerateFromImage(ctx context.Context, config providers.Config, image []byte) (string, error) {
+	return g.GenerateFromImages(ctx, config, [][]byte{image})
+}
+
+// fileTTL is how long the Files API keeps an upload
+const fileTTL = 48 * time.Hour
+
+// imageFormat returns the image subtype Gemini accepts inline, from the image's content
+func imageFormat(image []byte) (string, error) {
+	switch mediaType := http.DetectContentType(image); mediaType {
+	case "image/jpeg", "image/png", "image/webp":
+		return strings.TrimPrefix(mediaType, "image/"), nil
+	default:
+		return "", fmt.Errorf("unsupported image type for Gemini: %s", mediaType)
+	}
+}
+
+// generate sends the prompt, after the images when there are any
+func (g *Gemini) generate(ctx context.Context, config providers.Config, images [][]byte) (string, error) {
 	apiKey := os.Getenv("GEMINI_API_KEY")
 	if apiKey == "" {
 		return "", fmt.Errorf("GEMINI_API_KEY environment variable not set")
@@ -33,19 +104,97 @@ func (g *Gemini) ExtractText(ctx context.Context, config providers.Config) (stri
 
 	model := client.GenerativeModel(config.Model)
 	model.SetTemperature(float32(config.Temperature))
+	model.SafetySettings = g.SafetySettings
+
+	if config.JSONMode || config.ResponseSchema != nil {
+		model.ResponseMIMEType = "application/json"
+	}
+	if config.ResponseSchema != nil {
+		model.ResponseSchema = toSchema(config.ResponseSchema)
+	}
+
+	if len(images) == 0 {
+		return g.send(ctx, model, genai.Text(config.Prompt))
+	}
+	if providers.FileUploadsEnabled() {
+		text, err := g.sendFiles(ctx, client, model, apiKey, config, images)
+		var refusal *providers.RefusalError
+		if err == nil || errors.As(err, &refusal) || ctx.Err() != nil {
+			return text, err
+		}
+		slog.Warn("Sending images inline instead of by file reference", "error", err)
+	}
+	parts := make([]genai.Part, 0, len(images)+1)
+	for _, image := range images {
+		format, _ := imageFormat(image)
+		parts = append(parts, genai.ImageData(format, image))
+	}
+	return g.send(ctx, model, append(parts, genai.Text(config.Prompt))...)
+}
+
+// sendFiles sends the images as uploaded files, uploading those not already uploaded. A failed
+// request forgets the uploads, since a file may have expired early.
+func (g *Gemini) sendFiles(ctx context.Context, client *genai.Client, model *genai.GenerativeModel, apiKey string, config providers.Config, images [][]byte) (string, error) {
+	keys := make([]string, len(images))
+	parts := make([]genai.Part, 0, len(images)+1)
+	for i, image := range images {
+		format, _ := imageFormat(image)
+		// Files belong to the API key's project
+		keys[i] = providers.ImageKey("gemini|"+apiKey, image)
+		uri, err := providers.Files.Get(ctx, keys[i], fileTTL, func(ctx context.Context) (string, error) {
+			file, err := client.UploadFile(ctx, "", bytes.NewReader(image), &genai.UploadFileOptions{MIMEType: "image/" + format})
+			if err != nil {
+				return "", fmt.Errorf("failed to upload image: %w", err)
+			}
+			slog.Debug("Uploaded image", "uri", file.URI, "bytes", len(image))
+			return file.URI, nil
+		})
+		if err != nil {
+			return "", err
+		}
+		parts = append(parts, genai.FileData{MIMEType: "image/" + format, URI: uri})
+	}
 
-	resp, err := model.GenerateContent(ctx, genai.Text(config.Prompt))
+	text, err := g.send(ctx, model, append(parts, genai.Text(config.Prompt))...)
+	var refusal *providers.RefusalError
+	if err != nil && !errors.As(err, &refusal) && ctx.Err() == nil {
+		for _, key := range keys {
+			providers.Files.Forget(key)
+		}
+	}
+	return text, err
+}
+
+// send generates content from the parts
+func (g *Gemini) send(ctx context.Context, model *genai.GenerativeModel, parts ...genai.Part) (string, error) {
+	var resp *genai.GenerateContentResponse
+	err := providers.RetryPolicyFromEnv().Do(ctx, transient, func() error {
+		var err error
+		resp, err = model.GenerateContent(ctx, parts...)
+		return err
+	})
 	if err != nil {
+		var blocked *genai.BlockedError
+		if errors.As(err, &blocked) {
+			return "", describeBlock(blocked)
+		}
 		return "", fmt.Errorf("failed to generate content: %w", err)
 	}
 
 	if len(resp.Candidates) == 0 {
+		if resp.PromptFeedback != nil && resp.PromptFeedback.BlockReason != genai.BlockReasonUnspecified {
+			return "", describeBlock(&genai.BlockedError{PromptFeedback: resp.PromptFeedback})
+		}
 		return "", fmt.Errorf("no candidates returned from Gemini")
 	}
 
 	candidate := resp.Candidates[0]
 	if candidate.Content == nil || len(candidate.Content.Parts) == 0 {
-		return "", fmt.Errorf("empty content returned from Gemini")
+		switch candidate.FinishReason {
+		case genai.FinishReasonSafety, genai.FinishReasonRecitation:
+			return "", describeBlock(&genai.BlockedError{Candidate: candidate})
+		}
+		return "", fmt.Errorf("empty content returned from Gemini (finish reason: %s)", candidate.FinishReason)
 	}
 
 	if txt, ok := candidate.Content.Parts[0].(genai.Text); ok {
@@ -54,3 +203,166 @@ func (g *Gemini) ExtractText(ctx context.Context, config providers.Config) (stri
 
 	return "", fmt.Errorf("unexpected response format from Gemini")
 }
+
+// describeBlock turns a Gemini block into a refusal naming the reason and the offending categories
+func describeBlock(blocked *genai.BlockedError) error {
+	var ratings []*genai.SafetyRating
+	reason := ""
+	category := providers.RefusalSafety
+
+	if blocked.PromptFeedback != nil {
+		reason = "prompt blocked: " + blocked.PromptFeedback.BlockReason.String()
+		ratings = blocked.PromptFeedback.SafetyRatings
+	} else if blocked.Candidate != nil {
+		reason = "response blocked: " + blocked.Candidate.FinishReason.String()
+		ratings = blocked.Candidate.SafetyRatings
+		if blocked.Candidate.FinishReason == genai.FinishReasonRecitation {
+			category = providers.RefusalRecitation
+		}
+	}
+
+	var flagged []string
+	for _, r := range ratings {
+		if r.Blocked || r.Probability >= genai.HarmProbabilityMedium {
+			flagged = append(flagged, fmt.Sprintf("%s=%s", r.Category, r.Probability))
+		}
+	}
+	if len(flagged) > 0 {
+		reason += " (" + strings.Join(flagged, ", ") + ")"
+	}
+
+	return &providers.RefusalError{
+		Provider: "gemini",
+		Category: category,
+		Reason:   reason + "; adjust GEMINI_SAFETY_THRESHOLD or GEMINI_SAFETY_SETTINGS",
+	}
+}
+
+var categoryNames = map[string]genai.HarmCategory{
+	"harassment":        genai.HarmCategoryHarassment,
+	"hate_speech":       genai.HarmCategoryHateSpeech,
+	"sexually_explicit": genai.HarmCategorySexuallyExplicit,
+	"dangerous_content": genai.HarmCategoryDangerousContent,
+}
+
+var thresholdNames = map[string]genai.HarmBlockThreshold{
+	"default":                genai.HarmBlockUnspecified,
+	"block_none":             genai.HarmBlockNone,
+	"block_only_high":        genai.HarmBlockOnlyHigh,
+	"block_medium_and_above": genai.HarmBlockMediumAndAbove,
+	"block_low_and_above":    genai.HarmBlockLowAndAbove,
+}
+
+// ParseSafetySettings builds safety settings from a global threshold and a comma-separated
+// list of category=threshold overrides. Returns nil (Gemini defaults) when the threshold is "default"
+// and there are no overrides.
+func ParseSafetySettings(threshold, overrides string) ([]*genai.SafetySetting, error) {
+	threshold = strings.ToLower(strings.TrimSpace(threshold))
+	if threshold == "" {
+		threshold = defaultSafetyThreshold
+	}
+
+	global, ok := thresholdNames[threshold]
+	if !ok {
+		return nil, fmt.Errorf("unknown safety threshold %q", threshold)
+	}
+
+	perCategory := make(map[genai.HarmCategory]genai.HarmBlockThreshold, len(categoryNames))
+	for _, category := range categoryNames {
+		perCategory[category] = global
+	}
+
+	for _, pair := range strings.Split(overrides, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		name, value, found := strings.Cut(pair, "=")
+		if !found {
+			return nil, fmt.Errorf("invalid safety setting %q (expected category=threshold)", pair)
+		}
+		category, ok := categoryNames[strings.ToLower(strings.TrimSpace(name))]
+		if !ok {
+			return nil, fmt.Errorf("unknown safety category %q", name)
+		}
+		t, ok := thresholdNames[strings.ToLower(strings.TrimSpace(value))]
+		if !ok {
+			return nil, fmt.Errorf("unknown safety threshold %q", value)
+		}
+		perCategory[category] = t
+	}
+
+	var settings []*genai.SafetySetting
+	for _, category := range []genai.HarmCategory{
+		genai.HarmCategoryHarassment,
+		genai.HarmCategoryHateSpeech,
+		genai.HarmCategorySexuallyExplicit,
+		genai.HarmCategoryDangerousContent,
+	} {
+		if t := perCategory[category]; t != genai.HarmBlockUnspecified {
+			settings = append(settings, &genai.SafetySetting{Category: category, Threshold: t})
+		}
+	}
+
+	return settings, nil
+}
+
+// toSchema converts a JSON Schema map into Gemini's schema type
+func toSchema(s map[string]any) *genai.Schema {
+	if s == nil {
+		return nil
+	}
+
+	schema := &genai.Schema{}
+	switch s["type"] {
+	case "object":
+		schema.Type = genai.TypeObject
+	case "array":
+		schema.Type = genai.TypeArray
+	case "integer":
+		schema.Type = genai.TypeInteger
+	case "number":
+		schema.Type = genai.TypeNumber
+	case "boolean":
+		schema.Type = genai.TypeBoolean
+	default:
+		schema.Type = genai.TypeString
+	}
+
+	if d, ok := s["description"].(string); ok {
+		schema.Description = d
+	}
+	if enum, ok := s["enum"].([]string); ok {
+		schema.Format = "enum"
+		schema.Enum = enum
+	}
+	if items, ok := s["items"].(map[string]any); ok {
+		schema.Items = toSchema(items)
+	}
+	if props, ok := s["properties"].(map[string]any); ok {
+		schema.Properties = make(map[string]*genai.Schema, len(props))
+		for name, p := range props {
+			if pm, ok := p.(map[string]any); ok {
+				schema.Properties[name] = toSchema(pm)
+			}
+		}
+	}
+	if required, ok := s["required"].([]string); ok {
+		schema.Required = required
+	}
+
+	return schema
+}
+
+// transient reports whether a Gemini error is a rate limit, server error or timeout worth retrying
+func transient(err error) bool {
+	var apiErr *googleapi.Error
+	if errors.As(err, &apiErr) {
+		return apiErr.Code == http.StatusTooManyRequests || apiErr.Code >= 500
+	}
+	switch status.Code(err) {
+	case codes.ResourceExhausted, codes.Unavailable, codes.Internal, codes.DeadlineExceeded:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,83 @@
+package gemini
+
+import (
+	"testing"
+
+	"github.com/google/generative-ai-go/genai"
+)
+
+func TestParseSafetySettings(t *testing.T) {
+	settings, err := ParseSafetySettings("", "dangerous_content=block_none")
+	if err != nil {
+		t.Fatalf("ParseSafetySettings failed: %v", err)
+	}
+
+	if len(settings) != 4 {
+		t.Fatalf("Expected 4 settings, got %d", len(settings))
+	}
+
+	for _, s := range settings {
+		want := genai.HarmBlockOnlyHigh
+		if s.Category == genai.HarmCategoryDangerousContent {
+			want = genai.HarmBlockNone
+		}
+		if s.Threshold != want {
+			t.Errorf("Category %s: expected %s, got %s", s.Category, want, s.Threshold)
+		}
+	}
+
+	settings, err = ParseSafetySettings("default", "")
+	if err != nil {
+		t.Fatalf("ParseSafetySettings failed: %v", err)
+	}
+	if len(settings) != 0 {
+		t.Errorf("Expected Gemini defaults (no settings), got %d", len(settings))
+	}
+
+	if _, err := ParseSafetySettings("block_everything", ""); err == nil {
+		t.Error("Expected error for unknown threshold")
+	}
+	if _, err := ParseSafetySettings("", "violence=block_none"); err == nil {
+		t.Error("Expected error for unknown category")
+	}
+}
+
+func TestToSchema(t *testing.T) {
+	schema := toSchema(map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"title": map[string]any{"type": "string"},
+			"isbn":  map[string]any{"type": "array", "items": map[string]any{"type": "string"}},
+		},
+		"required": []string{"title"},
+	})
+
+	if schema.Type != genai.TypeObject {
+		t.Errorf("Expected object type, got %s", schema.Type)
+	}
+	if schema.Properties["isbn"].Type != genai.TypeArray || schema.Properties["isbn"].Items.Type != genai.TypeString {
+		t.Errorf("Unexpected isbn schema: %+v", schema.Properties["isbn"])
+	}
+	if len(schema.Required) != 1 || schema.Required[0] != "title" {
+		t.Errorf("Unexpected required list: %v", schema.Required)
+	}
+}
+
+func TestImageFormat(t *testing.T) {
+	for _, tc := range []struct {
+		data []byte
+		want string
+	}{
+		{[]byte("\xff\xd8\xff\xe0\x00\x10JFIF"), "jpeg"},
+		{[]byte("\x89PNG\r\n\x1a\n"), "png"},
+		{[]byte("RIFF\x00\x00\x00\x00WEBPVP8 "), "webp"},
+	} {
+		got, err := imageFormat(tc.data)
+		if err != nil || got != tc.want {
+			t.Errorf("imageFormat(%q) = %q, %v, want %q", tc.data, got, err, tc.want)
+		}
+	}
+	if _, err := imageFormat([]byte("%PDF-1.4")); err == nil {
+		t.Error("Expected error for a non-image file")
+	}
+}
@@ -0,0 +1,46 @@
+// Package groq provides Groq's OpenAI-compatible chat API, whose fast inference suits large
+// text-only evaluation runs
+package groq
+
+import (
+	"os"
+	"strings"
+
+	"github.com/lehigh-university-libraries/cataloger/internal/openai"
+	"github.com/lehigh-university-libraries/cataloger/internal/providers"
+)
+
+// DefaultBaseURL is the Groq API
+const DefaultBaseURL = "https://api.groq.com/openai/v1"
+
+// Registration registers the provider as "groq". It is text-only: metadata is extracted from
+// OCR text, which another provider transcribes.
+var Registration = providers.Registration{
+	Name:         "groq",
+	New:          func() providers.Provider { return New() },
+	DefaultModel: providers.EnvModel("GROQ_MODEL", "llama-3.3-70b-versatile"),
+	Configured:   func() bool { return os.Getenv("GROQ_API_KEY") != "" },
+}
+
+// New returns a new Groq provider
+//
+// Configured with:
+//   - GROQ_API_KEY: API key (required)
+//   - GROQ_BASE_URL: API URL, for a proxy (default DefaultBaseURL)
+//
+// Most Groq models don't take a JSON schema, so metadata requests ask for a JSON object and
+// the response is parsed as from any other provider.
+func New() *openai.OpenAI {
+	baseURL := strings.TrimSuffix(os.Getenv("GROQ_BASE_URL"), "/")
+	if baseURL == "" {
+		baseURL = DefaultBaseURL
+	}
+	return openai.NewEndpoint(openai.Endpoint{
+		Name:           "groq",
+		BaseURL:        baseURL,
+		APIKey:         os.Getenv("GROQ_API_KEY"),
+		KeyEnv:         "GROQ_API_KEY",
+		RequireKey:     true,
+		JSONObjectOnly: true,
+	})
+}
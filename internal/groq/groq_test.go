@@ -0,0 +1,43 @@
+package groq
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/lehigh-university-libraries/cataloger/internal/providers"
+)
+
+func TestGroq(t *testing.T) {
+	var got map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/openai/v1/chat/completions" || r.Header.Get("Authorization") != "Bearer gsk-test" {
+			t.Errorf("unexpected request %s %v", r.URL.Path, r.Header)
+		}
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Error(err)
+		}
+		_, _ = w.Write([]byte(`{"choices":[{"message":{"content":"{\"title\":\"Walden\"}"},"finish_reason":"stop"}]}`))
+	}))
+	defer server.Close()
+	t.Setenv("GROQ_API_KEY", "gsk-test")
+	t.Setenv("GROQ_BASE_URL", server.URL+"/openai/v1/")
+
+	config := providers.Config{Model: "llama-3.3-70b-versatile", Prompt: "p", ResponseSchema: map[string]any{"type": "object"}}
+	text, err := New().ExtractText(context.Background(), config)
+	if err != nil || text != `{"title":"Walden"}` {
+		t.Fatalf("ExtractText() = %q, %v", text, err)
+	}
+	// A schema is asked for as a JSON object, which every Groq model takes
+	if format, _ := got["response_format"].(map[string]any); format["type"] != "json_object" {
+		t.Errorf("response_format = %v, want json_object", got["response_format"])
+	}
+
+	t.Setenv("GROQ_API_KEY", "")
+	if _, err := New().ExtractText(context.Background(), config); err == nil || !strings.Contains(err.Error(), "GROQ_API_KEY") {
+		t.Errorf("without a key: err = %v", err)
+	}
+}
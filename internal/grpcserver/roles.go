@@ -0,0 +1,79 @@
+package grpcserver
+
+import (
+	"context"
+	"net/http"
+
+	catalogerv1 "github.com/lehigh-university-libraries/cataloger/api/cataloger/v1"
+	"github.com/lehigh-university-libraries/cataloger/internal/roles"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// methodRoles lists the role needed for each call, as creating a session does over HTTP.
+// Validating and comparing records need none.
+var methodRoles = map[string]roles.Role{
+	catalogerv1.CatalogerService_GenerateFromImage_FullMethodName: roles.Cataloger,
+	catalogerv1.CatalogerService_GenerateFromOCR_FullMethodName:   roles.Cataloger,
+}
+
+// SetRoles decides what each call's user may do, as for the web API: the user and group
+// headers set by the authenticating proxy are read from the call's metadata, and trusted
+// only when the call comes from the proxy's address. Without a resolver everyone is an admin.
+func (s *Server) SetRoles(resolver roles.Resolver) {
+	s.roles = resolver
+}
+
+// role returns the role of the call's user
+func (s *Server) role(ctx context.Context) roles.Role {
+	if s.roles == nil {
+		return roles.Admin
+	}
+	md, _ := metadata.FromIncomingContext(ctx)
+	r := &http.Request{Header: make(http.Header, len(md))}
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		r.RemoteAddr = p.Addr.String()
+	}
+	for key, values := range md {
+		for _, v := range values {
+			r.Header.Add(key, v)
+		}
+	}
+	return s.roles.Role(r)
+}
+
+// authorize refuses a call to users without the method's role
+func (s *Server) authorize(ctx context.Context, method string) error {
+	if role := methodRoles[method]; s.role(ctx) < role {
+		return status.Errorf(codes.PermissionDenied, "this requires the %s role", role)
+	}
+	return nil
+}
+
+// unaryRoles is the interceptor applying methodRoles to unary calls
+func (s *Server) unaryRoles(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	if err := s.authorize(ctx, info.FullMethod); err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+// streamRoles is the interceptor applying methodRoles to streaming calls
+func (s *Server) streamRoles(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	if err := s.authorize(ss.Context(), info.FullMethod); err != nil {
+		return err
+	}
+	return handler(srv, ss)
+}
+
+// mayChooseProvider refuses a provider or model chosen by a user other than an admin, who
+// leave them to the server's defaults
+func (s *Server) mayChooseProvider(ctx context.Context, provider, model string) error {
+	if (provider != "" || model != "") && s.role(ctx) < roles.Admin {
+		return status.Error(codes.PermissionDenied, "only admins can choose the provider or model")
+	}
+	return nil
+}
@@ -0,0 +1,103 @@
+package grpcserver
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"testing"
+
+	catalogerv1 "github.com/lehigh-university-libraries/cataloger/api/cataloger/v1"
+	"github.com/lehigh-university-libraries/cataloger/internal/roles"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func TestRoles(t *testing.T) {
+	t.Setenv("CATALOGING_PROVIDER", "mock")
+	resolver, err := roles.New(roles.Config{
+		Users:        map[string]string{"jdoe": "cataloger", "boss": "admin"},
+		GroupsHeader: "X-Remote-Groups",
+		Groups:       map[string]string{"catalogers": "cataloger"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := New(nil)
+	s.SetRoles(resolver)
+	client := loopbackClient(t, s) // As if through the proxy, on the same host
+
+	generate := func(ctx context.Context, provider string) codes.Code {
+		stream, err := client.GenerateFromOCR(ctx, &catalogerv1.GenerateFromOCRRequest{OcrText: "THE HISTORY OF BRIDGES\nby Jane Smith", Provider: provider})
+		if err != nil {
+			return status.Code(err)
+		}
+		for {
+			_, err := stream.Recv()
+			if errors.Is(err, io.EOF) {
+				return codes.OK
+			}
+			if err != nil {
+				return status.Code(err)
+			}
+		}
+	}
+	as := func(pairs ...string) context.Context {
+		return metadata.NewOutgoingContext(context.Background(), metadata.Pairs(pairs...))
+	}
+
+	tests := []struct {
+		name     string
+		ctx      context.Context
+		provider string
+		want     codes.Code
+	}{
+		{"anonymous", context.Background(), "", codes.PermissionDenied},
+		{"unlisted user", as("x-remote-user", "guest"), "", codes.PermissionDenied},
+		{"cataloger", as("x-remote-user", "jdoe"), "", codes.OK},
+		{"cataloger by group", as("x-remote-user", "guest", "x-remote-groups", "staff, catalogers"), "", codes.OK},
+		{"cataloger choosing the provider", as("x-remote-user", "jdoe"), "mock", codes.PermissionDenied},
+		{"admin choosing the provider", as("x-remote-user", "boss"), "mock", codes.OK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := generate(tt.ctx, tt.provider); got != tt.want {
+				t.Errorf("GenerateFromOCR = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	// Checking records needs no role
+	if _, err := client.Validate(context.Background(), &catalogerv1.ValidateRequest{Record: recordXML(t, reference)}); err != nil {
+		t.Errorf("Validate without a role: %v", err)
+	}
+
+	// Calls that don't come from the proxy's address get the default role, whatever their
+	// metadata says
+	client = clientOf(t, s)
+	if got := generate(as("x-remote-user", "boss"), "mock"); got != codes.PermissionDenied {
+		t.Errorf("GenerateFromOCR with a forged admin = %v, want PermissionDenied", got)
+	}
+}
+
+// loopbackClient serves s on a loopback TCP port, so calls come from 127.0.0.1
+func loopbackClient(t *testing.T, s *Server) catalogerv1.CatalogerServiceClient {
+	t.Helper()
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	gs := s.Register()
+	go gs.Serve(lis)
+	t.Cleanup(gs.Stop)
+
+	conn, err := grpc.NewClient(lis.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("failed to dial: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return catalogerv1.NewCatalogerServiceClient(conn)
+}
@@ -0,0 +1,316 @@
+package grpcserver
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	catalogerv1 "github.com/lehigh-university-libraries/cataloger/api/cataloger/v1"
+	"github.com/lehigh-university-libraries/cataloger/internal/cataloging"
+	"github.com/lehigh-university-libraries/cataloger/internal/eval/marceval"
+	"github.com/lehigh-university-libraries/cataloger/internal/marc"
+	"github.com/lehigh-university-libraries/cataloger/internal/models"
+	"github.com/lehigh-university-libraries/cataloger/internal/ocr"
+	"github.com/lehigh-university-libraries/cataloger/internal/ratelimit"
+	"github.com/lehigh-university-libraries/cataloger/internal/roles"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/reflection"
+	"google.golang.org/grpc/status"
+)
+
+// maxImages limits how many images a GenerateFromImage request may carry, as for web sessions
+const maxImages = 12
+
+// Server implements catalogerv1.CatalogerServiceServer on top of the cataloging and OCR services
+type Server struct {
+	catalogerv1.UnimplementedCatalogerServiceServer
+
+	catalogService *cataloging.Service
+	ocrService     *ocr.Service
+	generations    *ratelimit.Gate
+	roles          roles.Resolver // Roles of users; everyone is an admin when nil
+}
+
+// New creates a server. generations, if not nil, caps concurrent generations and is
+// usually shared with the web API.
+func New(generations *ratelimit.Gate) *Server {
+	return &Server{
+		catalogService: cataloging.NewService(),
+		ocrService:     ocr.NewService(),
+		generations:    generations,
+	}
+}
+
+// Register creates a gRPC server with the cataloger, health and reflection services, checking
+// each call's role (see SetRoles)
+func (s *Server) Register(opts ...grpc.ServerOption) *grpc.Server {
+	opts = append(opts, grpc.ChainUnaryInterceptor(s.unaryRoles), grpc.ChainStreamInterceptor(s.streamRoles))
+	gs := grpc.NewServer(opts...)
+	catalogerv1.RegisterCatalogerServiceServer(gs, s)
+	healthpb.RegisterHealthServer(gs, health.NewServer())
+	reflection.Register(gs)
+	return gs
+}
+
+// GenerateFromImage transcribes each image, then generates MARC from the text in title page,
+// copyright page, cover order
+func (s *Server) GenerateFromImage(req *catalogerv1.GenerateFromImageRequest, stream catalogerv1.CatalogerService_GenerateFromImageServer) error {
+	if err := s.mayChooseProvider(stream.Context(), req.GetProvider(), req.GetModel()); err != nil {
+		return err
+	}
+	if len(req.GetImages()) == 0 {
+		return status.Error(codes.InvalidArgument, "no images")
+	}
+	if len(req.GetImages()) > maxImages {
+		return status.Errorf(codes.InvalidArgument, "at most %d images per request", maxImages)
+	}
+	images := slices.Clone(req.GetImages())
+	for _, img := range images {
+		if _, ok := models.ImageTypeOrder[imageType(img)]; !ok {
+			return status.Errorf(codes.InvalidArgument, "invalid image_type %q", img.GetImageType())
+		}
+		if len(img.GetData()) == 0 {
+			return status.Error(codes.InvalidArgument, "empty image")
+		}
+	}
+	slices.SortStableFunc(images, func(a, b *catalogerv1.Image) int {
+		return models.ImageTypeOrder[imageType(a)] - models.ImageTypeOrder[imageType(b)]
+	})
+
+	release, err := s.acquire(stream)
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	totalSteps := int32(len(images) + 2)
+	var texts []string
+	for i, img := range images {
+		if err := sendProgress(stream, catalogerv1.Stage_STAGE_OCR, fmt.Sprintf("Transcribing %s image %d of %d", imageType(img), i+1, len(images)), int32(i+1), totalSteps); err != nil {
+			return err
+		}
+		text, err := s.transcribe(stream.Context(), img, req.GetProvider(), req.GetModel())
+		if err != nil {
+			if stream.Context().Err() != nil {
+				return status.FromContextError(stream.Context().Err()).Err()
+			}
+			slog.Error("OCR failed", "image", i+1, "error", err)
+			return status.Errorf(codes.Unavailable, "OCR of image %d failed: %v", i+1, err)
+		}
+		if strings.TrimSpace(text) != "" {
+			texts = append(texts, text)
+		}
+	}
+	if len(texts) == 0 {
+		return status.Error(codes.FailedPrecondition, "no text found in the images")
+	}
+
+	return s.generate(stream, texts, req.GetProvider(), req.GetModel(), int32(len(images)), totalSteps)
+}
+
+// GenerateFromOCR generates MARC from transcribed text
+func (s *Server) GenerateFromOCR(req *catalogerv1.GenerateFromOCRRequest, stream catalogerv1.CatalogerService_GenerateFromOCRServer) error {
+	if err := s.mayChooseProvider(stream.Context(), req.GetProvider(), req.GetModel()); err != nil {
+		return err
+	}
+	if strings.TrimSpace(req.GetOcrText()) == "" {
+		return status.Error(codes.InvalidArgument, "ocr_text is empty")
+	}
+
+	release, err := s.acquire(stream)
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	return s.generate(stream, []string{req.GetOcrText()}, req.GetProvider(), req.GetModel(), 0, 2)
+}
+
+// Validate checks a record with marc.Validate
+func (s *Server) Validate(ctx context.Context, req *catalogerv1.ValidateRequest) (*catalogerv1.ValidateResponse, error) {
+	rec, err := parseRecord(req.GetRecord())
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid record: %v", err)
+	}
+
+	issues := marc.Validate(rec)
+	resp := &catalogerv1.ValidateResponse{Valid: true, Issues: toIssues(issues)}
+	for _, issue := range issues {
+		if issue.Severity == marc.SeverityError {
+			resp.Valid = false
+		}
+	}
+	return resp, nil
+}
+
+// Compare scores a generated record against a reference with the evaluation's field comparison
+func (s *Server) Compare(ctx context.Context, req *catalogerv1.CompareRequest) (*catalogerv1.CompareResponse, error) {
+	reference, err := parseRecord(req.GetReference())
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid reference record: %v", err)
+	}
+	generated, err := parseRecord(req.GetGenerated())
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid generated record: %v", err)
+	}
+
+	weights := req.GetWeights()
+	if len(weights) == 0 {
+		weights = marceval.DefaultWeights
+	}
+	c := marceval.CompareWeighted(reference, generated, weights)
+
+	resp := &catalogerv1.CompareResponse{
+		Score:      c.Score,
+		Matched:    int32(c.Matched),
+		Incorrect:  int32(c.Incorrect),
+		Missing:    int32(c.Missing),
+		Extra:      int32(c.Extra),
+		OrderScore: c.OrderScore,
+		Duplicates: make(map[string]int32, len(c.Duplicates)),
+	}
+	for _, tag := range c.Tags() {
+		fs := c.Fields[tag]
+		resp.Fields = append(resp.Fields, &catalogerv1.FieldScore{
+			Tag:      fs.Tag,
+			Expected: fs.Expected,
+			Actual:   fs.Actual,
+			Weight:   fs.Weight,
+			Score:    fs.Score,
+			Match:    fs.Match,
+		})
+	}
+	for tag, n := range c.Duplicates {
+		resp.Duplicates[tag] = int32(n)
+	}
+	return resp, nil
+}
+
+// generateStream is the server side of both generation RPCs
+type generateStream interface {
+	Send(*catalogerv1.GenerateResponse) error
+	Context() context.Context
+}
+
+// acquire takes a generation slot, reporting the wait to the client
+func (s *Server) acquire(stream generateStream) (func(), error) {
+	if s.generations == nil {
+		return func() {}, nil
+	}
+	if s.generations.Full() {
+		if err := sendProgress(stream, catalogerv1.Stage_STAGE_QUEUED, "Waiting for a free generation slot", 0, 0); err != nil {
+			return nil, err
+		}
+	}
+	release, err := s.generations.Acquire(stream.Context())
+	if errors.Is(err, ratelimit.ErrBusy) {
+		return nil, status.Errorf(codes.ResourceExhausted, "server is busy generating other records, retry in %s", ratelimit.RetryAfterSeconds(s.generations.RetryAfter())+"s")
+	}
+	if err != nil {
+		return nil, status.FromContextError(err).Err()
+	}
+	return release, nil
+}
+
+// generate runs MARC generation and validation on texts and sends the result
+func (s *Server) generate(stream generateStream, texts []string, provider, model string, step, totalSteps int32) error {
+	if err := sendProgress(stream, catalogerv1.Stage_STAGE_GENERATE, "Generating MARC", step+1, totalSteps); err != nil {
+		return err
+	}
+	rec, err := s.catalogService.GenerateMARCFromOCR(stream.Context(), strings.Join(texts, "\n\n"), provider, model)
+	if err != nil {
+		if stream.Context().Err() != nil {
+			return status.FromContextError(stream.Context().Err()).Err()
+		}
+		slog.Error("MARC generation failed", "error", err)
+		return status.Errorf(codes.Unavailable, "MARC generation failed: %v", err)
+	}
+
+	if err := sendProgress(stream, catalogerv1.Stage_STAGE_VALIDATE, "Validating record", step+2, totalSteps); err != nil {
+		return err
+	}
+	data, err := rec.XML()
+	if err != nil {
+		return status.Errorf(codes.Internal, "failed to serialize record: %v", err)
+	}
+
+	return stream.Send(&catalogerv1.GenerateResponse{
+		Event: &catalogerv1.GenerateResponse_Result{Result: &catalogerv1.GenerateResult{
+			MarcXml:       string(data),
+			OcrText:       texts,
+			Issues:        toIssues(marc.Validate(rec)),
+			Provider:      provider,
+			Model:         model,
+			PromptVersion: s.catalogService.PromptVersion(),
+		}},
+	})
+}
+
+// transcribe writes an image to a temporary file for OCR
+func (s *Server) transcribe(ctx context.Context, img *catalogerv1.Image, provider, model string) (string, error) {
+	ext := strings.ToLower(filepath.Ext(img.GetFilename()))
+	if ext == "" {
+		ext = ".jpg"
+	}
+	f, err := os.CreateTemp("", "grpc-image-*"+ext)
+	if err != nil {
+		return "", fmt.Errorf("failed to create temporary file: %w", err)
+	}
+	defer os.Remove(f.Name())
+	if _, err := f.Write(img.GetData()); err != nil {
+		f.Close()
+		return "", fmt.Errorf("failed to write temporary file: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return "", fmt.Errorf("failed to write temporary file: %w", err)
+	}
+	return s.ocrService.ExtractTextFromImage(ctx, f.Name(), provider, model)
+}
+
+// imageType is the image's type, defaulting to the title page
+func imageType(img *catalogerv1.Image) string {
+	return cmp.Or(img.GetImageType(), "title_page")
+}
+
+func sendProgress(stream generateStream, stage catalogerv1.Stage, message string, step, totalSteps int32) error {
+	return stream.Send(&catalogerv1.GenerateResponse{
+		Event: &catalogerv1.GenerateResponse_Progress{Progress: &catalogerv1.Progress{
+			Stage:      stage,
+			Message:    message,
+			Step:       step,
+			TotalSteps: totalSteps,
+		}},
+	})
+}
+
+// parseRecord reads the first record of MARCXML or ISO 2709 data
+func parseRecord(data []byte) (*marc.Record, error) {
+	r, err := marc.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	return r.Next()
+}
+
+func toIssues(issues []marc.Issue) []*catalogerv1.ValidationIssue {
+	out := make([]*catalogerv1.ValidationIssue, 0, len(issues))
+	for _, issue := range issues {
+		out = append(out, &catalogerv1.ValidationIssue{
+			Tag:      issue.Tag,
+			Severity: issue.Severity,
+			Code:     issue.Code,
+			Message:  issue.Message,
+		})
+	}
+	return out
+}
@@ -0,0 +1,153 @@
+package grpcserver
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"testing"
+
+	catalogerv1 "github.com/lehigh-university-libraries/cataloger/api/cataloger/v1"
+	"github.com/lehigh-university-libraries/cataloger/internal/marc"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+func newClient(t *testing.T) catalogerv1.CatalogerServiceClient {
+	t.Helper()
+	return clientOf(t, New(nil))
+}
+
+// clientOf serves s on an in-memory listener and returns a client of it
+func clientOf(t *testing.T, s *Server) catalogerv1.CatalogerServiceClient {
+	t.Helper()
+	lis := bufconn.Listen(1 << 20)
+	gs := s.Register()
+	go gs.Serve(lis)
+	t.Cleanup(gs.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("failed to dial: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return catalogerv1.NewCatalogerServiceClient(conn)
+}
+
+func recordXML(t *testing.T, rec *marc.Record) []byte {
+	t.Helper()
+	data, err := rec.XML()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+var reference = &marc.Record{
+	Leader: "00000nam a2200000 i 4500",
+	DataFields: []marc.DataField{
+		{Tag: "100", Ind1: "1", Ind2: " ", Subfields: []marc.Subfield{{Code: "a", Value: "Smith, Jane."}}},
+		{Tag: "245", Ind1: "1", Ind2: "4", Subfields: []marc.Subfield{{Code: "a", Value: "The history of bridges"}}},
+	},
+}
+
+func TestGenerateFromOCR(t *testing.T) {
+	client := newClient(t)
+	stream, err := client.GenerateFromOCR(context.Background(), &catalogerv1.GenerateFromOCRRequest{
+		OcrText:  "THE HISTORY OF BRIDGES\nby Jane Smith\nNew York\nAcme Press\n1999",
+		Provider: "mock",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var stages []catalogerv1.Stage
+	var result *catalogerv1.GenerateResult
+	for {
+		resp, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Recv() error = %v", err)
+		}
+		if p := resp.GetProgress(); p != nil {
+			stages = append(stages, p.GetStage())
+		}
+		if r := resp.GetResult(); r != nil {
+			result = r
+		}
+	}
+
+	if len(stages) != 2 || stages[0] != catalogerv1.Stage_STAGE_GENERATE || stages[1] != catalogerv1.Stage_STAGE_VALIDATE {
+		t.Errorf("progress stages = %v, want [GENERATE VALIDATE]", stages)
+	}
+	if result == nil {
+		t.Fatal("no result received")
+	}
+	rec, err := marc.ParseXML([]byte(result.GetMarcXml()))
+	if err != nil {
+		t.Fatalf("result is not MARCXML: %v", err)
+	}
+	if rec.SubfieldValue("245", "a") == "" {
+		t.Errorf("generated record has no 245$a:\n%s", result.GetMarcXml())
+	}
+}
+
+func TestGenerateFromImageRejectsInvalidType(t *testing.T) {
+	client := newClient(t)
+	stream, err := client.GenerateFromImage(context.Background(), &catalogerv1.GenerateFromImageRequest{
+		Images: []*catalogerv1.Image{{Data: []byte("jpeg"), ImageType: "spine"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := stream.Recv(); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("Recv() error = %v, want InvalidArgument", err)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	client := newClient(t)
+
+	resp, err := client.Validate(context.Background(), &catalogerv1.ValidateRequest{Record: recordXML(t, reference)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !resp.GetValid() {
+		t.Errorf("Valid = false, issues %v", resp.GetIssues())
+	}
+
+	bad := &marc.Record{Leader: "short", DataFields: reference.DataFields}
+	resp, err = client.Validate(context.Background(), &catalogerv1.ValidateRequest{Record: recordXML(t, bad)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.GetValid() {
+		t.Error("Valid = true for a record with a short leader")
+	}
+
+	if _, err := client.Validate(context.Background(), &catalogerv1.ValidateRequest{Record: []byte("not marc")}); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("Validate() of garbage error = %v, want InvalidArgument", err)
+	}
+}
+
+func TestCompare(t *testing.T) {
+	client := newClient(t)
+
+	resp, err := client.Compare(context.Background(), &catalogerv1.CompareRequest{
+		Reference: recordXML(t, reference),
+		Generated: recordXML(t, reference),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.GetScore() != 1 || resp.GetMatched() != 2 || len(resp.GetFields()) != 2 {
+		t.Errorf("Compare() of identical records = score %v, matched %d, %d fields", resp.GetScore(), resp.GetMatched(), len(resp.GetFields()))
+	}
+}
@@ -0,0 +1,57 @@
+package handlers
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"path/filepath"
+
+	"github.com/lehigh-university-libraries/cataloger/internal/batch"
+)
+
+// SetBatchExporter exports approved records in batch files on request (POST /api/batches)
+func (h *Handler) SetBatchExporter(e *batch.Exporter) {
+	h.batches = e
+}
+
+// HandleBatchExport writes the approved sessions not yet in a batch to a new batch file and
+// marks them exported, responding 201 with the file's name and sessions, or 200 when there
+// was nothing to export
+func (h *Handler) HandleBatchExport(w http.ResponseWriter, r *http.Request) {
+	if h.batches == nil {
+		respondError(w, r, http.StatusNotFound, "Batch export is not configured")
+		return
+	}
+	result, err := h.batches.Export(h.actor(r))
+	if err != nil {
+		slog.Error("Batch export failed", "error", err)
+		respondError(w, r, http.StatusInternalServerError, "Batch export failed")
+		return
+	}
+	status := http.StatusCreated
+	if result.File == "" {
+		status = http.StatusOK
+	}
+	respondWithJSON(w, result, status)
+}
+
+// HandleBatchFile downloads a batch file
+func (h *Handler) HandleBatchFile(w http.ResponseWriter, r *http.Request) {
+	if h.batches == nil {
+		respondError(w, r, http.StatusNotFound, "Batch export is not configured")
+		return
+	}
+	name := r.PathValue("name")
+	path, ok := h.batches.Path(name)
+	if !ok {
+		respondError(w, r, http.StatusNotFound, "Batch file not found")
+		return
+	}
+	contentType := "application/marc"
+	if filepath.Ext(name) == "."+batch.FormatXML {
+		contentType = "application/marcxml+xml"
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", name))
+	http.ServeFile(w, r, path)
+}
@@ -0,0 +1,130 @@
+package handlers
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/lehigh-university-libraries/cataloger/internal/bundle"
+	"github.com/lehigh-university-libraries/cataloger/internal/models"
+	"github.com/lehigh-university-libraries/cataloger/internal/uploads"
+)
+
+// HandleSessionExport downloads a session as a zip bundle (see package bundle) of its images,
+// OCR text, MARC and audit log, for import on another instance. The export is audited first,
+// so the bundle's log records it.
+func (h *Handler) HandleSessionExport(w http.ResponseWriter, r *http.Request) {
+	session, ok := h.sessionStore.Get(r.PathValue("id"))
+	if !ok {
+		respondError(w, r, http.StatusNotFound, "Session not found")
+		return
+	}
+
+	images := make(map[string][]byte, len(session.Images))
+	for _, img := range session.Images {
+		name := filepath.Base(img.ImagePath)
+		data, err := h.uploads.Get(r.Context(), name)
+		if err != nil {
+			slog.Error("Failed to read upload for export", "session", session.ID, "name", name, "error", err)
+			respondError(w, r, http.StatusInternalServerError, "Failed to read image %s", name)
+			return
+		}
+		images[name] = data
+	}
+
+	source, _ := os.Hostname()
+	h.audit(r, session.ID, models.AuditEvent{Action: models.ActionExport, Details: map[string]string{"source": source}})
+
+	var buf bytes.Buffer
+	err := bundle.Write(&buf, &bundle.Bundle{
+		Manifest: bundle.Manifest{ExportedAt: time.Now(), Source: source},
+		Session:  session,
+		History:  h.sessionStore.History(session.ID),
+		Images:   images,
+	})
+	if err != nil {
+		slog.Error("Failed to export session", "session", session.ID, "error", err)
+		respondError(w, r, http.StatusInternalServerError, "Failed to export session")
+		return
+	}
+
+	slog.Info("Exported session", "session", session.ID, "images", len(images), "bytes", buf.Len())
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", "session-"+session.ID+".zip"))
+	w.Header().Set("Content-Length", fmt.Sprint(buf.Len()))
+	_, _ = w.Write(buf.Bytes())
+}
+
+// HandleSessionImport creates a session from a bundle exported by HandleSessionExport, sent as
+// the request body. The session keeps its ID and audit log, with an import event appended; a
+// session with the same ID already here is a conflict.
+func (h *Handler) HandleSessionImport(w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, h.maxUploadSize)
+	data, err := io.ReadAll(r.Body)
+	if err != nil {
+		respondUploadError(w, r, err)
+		return
+	}
+	b, err := bundle.Read(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		respondError(w, r, http.StatusBadRequest, "Invalid bundle: %s", err)
+		return
+	}
+
+	session := b.Session
+	unlock := h.sessionStore.Lock(session.ID)
+	defer unlock()
+	if _, exists := h.sessionStore.Get(session.ID); exists {
+		respondError(w, r, http.StatusConflict, "Session %s already exists", session.ID)
+		return
+	}
+	if len(session.Images) > maxSessionImages {
+		respondError(w, r, http.StatusBadRequest, "A session holds at most %d images", maxSessionImages)
+		return
+	}
+
+	for i := range session.Images {
+		img := &session.Images[i]
+		data := b.Images[filepath.Base(img.ImagePath)]
+		_, name, err := uploadName(data)
+		if err != nil {
+			respondError(w, r, http.StatusBadRequest, "Invalid bundle: %s", fmt.Errorf("%s: %w", filepath.Base(img.ImagePath), err))
+			return
+		}
+		path, err := h.uploads.Save(r.Context(), name, data)
+		if err != nil {
+			if errors.Is(err, uploads.ErrQuotaExceeded) {
+				respondQuotaError(w, r, err)
+				return
+			}
+			slog.Error("Failed to save imported image", "session", session.ID, "name", name, "error", err)
+			respondError(w, r, http.StatusInternalServerError, "Failed to save image")
+			return
+		}
+		img.ImagePath = path
+		img.ImageURL = "/uploads/" + name
+	}
+
+	for _, event := range b.History {
+		h.sessionStore.AppendEvent(session.ID, event)
+	}
+	h.audit(r, session.ID, models.AuditEvent{
+		Action:   models.ActionImport,
+		Provider: session.Provider,
+		Model:    session.Model,
+		Details: map[string]string{
+			"source":      b.Manifest.Source,
+			"exported_at": b.Manifest.ExportedAt.Format(time.RFC3339),
+		},
+	})
+	h.sessionStore.Set(session.ID, session)
+
+	slog.Info("Imported session", "session", session.ID, "source", b.Manifest.Source, "images", len(session.Images), "events", len(b.History))
+	respondWithJSON(w, session, http.StatusCreated)
+}
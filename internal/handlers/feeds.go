@@ -0,0 +1,104 @@
+package handlers
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/lehigh-university-libraries/cataloger/internal/feed"
+)
+
+// HandleApprovedAtom serves the change feed of approved records as Atom
+func (h *Handler) HandleApprovedAtom(w http.ResponseWriter, r *http.Request) {
+	h.serveApprovedFeed(w, r, feed.WriteAtom, "application/atom+xml; charset=utf-8")
+}
+
+// HandleApprovedJSON serves the change feed of approved records as JSON Feed
+func (h *Handler) HandleApprovedJSON(w http.ResponseWriter, r *http.Request) {
+	h.serveApprovedFeed(w, r, feed.WriteJSON, "application/feed+json; charset=utf-8")
+}
+
+// serveApprovedFeed writes a page of approved records, newest approval first. since and until
+// (RFC 3339) bound the approval times, and a full page links to the next, older one.
+func (h *Handler) serveApprovedFeed(w http.ResponseWriter, r *http.Request, write func(io.Writer, feed.Page) error, contentType string) {
+	query := r.URL.Query()
+	var window feed.Window
+	for name, t := range map[string]*time.Time{"since": &window.Since, "until": &window.Until} {
+		if v := query.Get(name); v != "" {
+			parsed, err := time.Parse(time.RFC3339Nano, v)
+			if err != nil {
+				respondError(w, r, http.StatusBadRequest, "Invalid %s parameter: %s", name, v)
+				return
+			}
+			*t = parsed
+		}
+	}
+	limit := feed.DefaultLimit
+	if v := query.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			respondError(w, r, http.StatusBadRequest, "Invalid %s parameter: %s", "limit", v)
+			return
+		}
+		limit = min(n, feed.MaxLimit)
+	}
+
+	base := h.baseURL(r)
+	entries, more := feed.Approvals(h.sessionStore, window, limit)
+	page := feed.Page{
+		Title:   "Cataloger approved records",
+		BaseURL: base,
+		Self:    base + r.URL.RequestURI(),
+		Entries: entries,
+		Updated: time.Now(),
+	}
+	if len(entries) > 0 {
+		page.Updated = entries[0].Approved
+	}
+	if more {
+		next := url.Values{"until": {entries[len(entries)-1].Approved.UTC().Format(time.RFC3339Nano)}}
+		if v := query.Get("since"); v != "" {
+			next.Set("since", v)
+		}
+		if v := query.Get("limit"); v != "" {
+			next.Set("limit", v)
+		}
+		page.Next = base + r.URL.Path + "?" + next.Encode()
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	if err := write(w, page); err != nil {
+		slog.Error("Failed to write feed", "error", err)
+	}
+}
+
+// HandleSessionMARCXML returns the session's record as MARCXML, as linked from the feed
+func (h *Handler) HandleSessionMARCXML(w http.ResponseWriter, r *http.Request) {
+	session, ok := h.sessionStore.Get(r.PathValue("id"))
+	if !ok {
+		respondError(w, r, http.StatusNotFound, "Session not found")
+		return
+	}
+	if session.MARC == "" {
+		respondError(w, r, http.StatusConflict, "Session has no MARC record")
+		return
+	}
+	w.Header().Set("Content-Type", feed.MARCXMLType)
+	_, _ = io.WriteString(w, session.MARC)
+}
+
+// baseURL is the server's URL as the request reached it. Behind a trusted proxy, the scheme
+// comes from X-Forwarded-Proto.
+func (h *Handler) baseURL(r *http.Request) string {
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	if proto := r.Header.Get("X-Forwarded-Proto"); h.trustProxy && proto != "" {
+		scheme = proto
+	}
+	return scheme + "://" + r.Host
+}
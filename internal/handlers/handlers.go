@@ -0,0 +1,258 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"path/filepath"
+
+	"github.com/lehigh-university-libraries/cataloger/internal/batch"
+	"github.com/lehigh-university-libraries/cataloger/internal/cataloging"
+	"github.com/lehigh-university-libraries/cataloger/internal/holdings"
+	"github.com/lehigh-university-libraries/cataloger/internal/i18n"
+	"github.com/lehigh-university-libraries/cataloger/internal/ocr"
+	"github.com/lehigh-university-libraries/cataloger/internal/ratelimit"
+	"github.com/lehigh-university-libraries/cataloger/internal/roles"
+	"github.com/lehigh-university-libraries/cataloger/internal/storage"
+	"github.com/lehigh-university-libraries/cataloger/internal/uploads"
+	"github.com/lehigh-university-libraries/cataloger/internal/utils"
+)
+
+// Handler serves the web API
+type Handler struct {
+	sessionStore   *storage.SessionStore
+	catalogService *cataloging.Service
+	ocrService     *ocr.Service
+	holdings       holdings.Defaults
+	uploads        *uploads.Store
+	maxUploadSize  int64
+	limiter        *ratelimit.Limiter // Per-client limit on POST/PUT requests; nil for none
+	trustProxy     bool               // Identify clients by X-Forwarded-For, and the scheme by X-Forwarded-Proto
+	generations    *ratelimit.Gate    // Cap on concurrent OCR/MARC generation; nil for none
+	evalHistory    string             // eval daemon history directory; empty when not served
+	marcFromImages bool               // Generate MARC from all page images in one request by default
+	roles          roles.Resolver     // Roles of users; everyone is an admin when nil
+	batches        *batch.Exporter    // Batch export of approved records; nil when not configured
+}
+
+// New creates a handler backed by the given session store, keeping uploaded images in uploadStore
+func New(sessionStore *storage.SessionStore, uploadStore *uploads.Store) *Handler {
+	holdingsDefaults, err := holdings.DefaultsFromEnv()
+	if err != nil {
+		slog.Warn("Holdings scaffolding disabled", "error", err)
+		holdingsDefaults.Format = holdings.FormatNone
+	}
+
+	catalogService := cataloging.NewService()
+	if prof := catalogService.Profile(); prof != nil {
+		holdingsDefaults.Institution = firstNonEmpty(holdingsDefaults.Institution, prof.OrgCode)
+		holdingsDefaults.Location = firstNonEmpty(holdingsDefaults.Location, prof.Location)
+	}
+
+	return &Handler{
+		sessionStore:   sessionStore,
+		catalogService: catalogService,
+		ocrService:     ocr.NewService(),
+		holdings:       holdingsDefaults,
+		uploads:        uploadStore,
+		maxUploadSize:  defaultMaxUploadSize,
+	}
+}
+
+// SetMaxRequestSize limits the size of upload requests
+func (h *Handler) SetMaxRequestSize(n int64) {
+	h.maxUploadSize = n
+}
+
+// SetRateLimit limits each client (API key or IP address) to the limiter's rate of uploads
+// and generation requests
+func (h *Handler) SetRateLimit(limiter *ratelimit.Limiter) {
+	h.limiter = limiter
+}
+
+// SetTrustProxy takes clients' addresses from X-Forwarded-For and the scheme of feed links
+// from X-Forwarded-Proto, as set by a reverse proxy
+func (h *Handler) SetTrustProxy(trustProxy bool) {
+	h.trustProxy = trustProxy
+}
+
+// SetGenerationLimit caps how many OCR and MARC generation requests run at once, so a
+// burst of requests queues instead of overloading the LLM host
+func (h *Handler) SetGenerationLimit(gate *ratelimit.Gate) {
+	h.generations = gate
+}
+
+// SetMARCFromImages makes generating MARC from a session's page images in one multimodal
+// request, instead of from their OCR text, the default for requests that don't choose
+func (h *Handler) SetMARCFromImages(enabled bool) {
+	h.marcFromImages = enabled
+}
+
+// Routes registers all API routes on a new mux
+func (h *Handler) Routes() *http.ServeMux {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /healthcheck", h.HandleHealthcheck)
+	mux.HandleFunc("GET /api/providers", h.HandleProviders)
+	mux.HandleFunc("POST /api/sessions", h.sameOrigin(h.requireRole(roles.Cataloger, h.rateLimited(h.HandleSessions))))
+	mux.HandleFunc("GET /api/sessions", h.HandleSessionSearch)
+	mux.HandleFunc("GET /api/sessions/{id}", h.HandleSession)
+	mux.HandleFunc("POST /api/sessions/{id}/images", h.sameOrigin(h.requireRole(roles.Cataloger, h.rateLimited(h.sessionLocked(h.HandleSessionImages)))))
+	mux.HandleFunc("POST /api/sessions/{id}/ocr", h.sameOrigin(h.requireRole(roles.Cataloger, h.rateLimited(h.sessionLocked(h.HandleSessionOCR)))))
+	mux.HandleFunc("PUT /api/sessions/{id}/ocr", h.sameOrigin(h.requireRole(roles.Cataloger, h.rateLimited(h.sessionLocked(h.HandleSessionOCRCorrection)))))
+	mux.HandleFunc("POST /api/sessions/{id}/marc", h.sameOrigin(h.requireRole(roles.Cataloger, h.rateLimited(h.sessionLocked(h.HandleSessionMARC)))))
+	mux.HandleFunc("POST /api/sessions/{id}/marc/stream", h.sameOrigin(h.requireRole(roles.Cataloger, h.rateLimited(h.sessionLocked(h.HandleSessionMARCStream)))))
+	mux.HandleFunc("GET /api/sessions/{id}/labels", h.HandleSessionLabels)
+	mux.HandleFunc("PUT /api/sessions/{id}/status", h.sameOrigin(h.requireRole(roles.Cataloger, h.sessionLocked(h.HandleSessionStatus))))
+	mux.HandleFunc("GET /api/sessions/{id}/history", h.HandleSessionHistory)
+	mux.HandleFunc("GET /api/sessions/{id}/bundle", h.requireRole(roles.Cataloger, h.HandleSessionExport))
+	mux.HandleFunc("GET /api/sessions/{id}/marcxml", h.HandleSessionMARCXML)
+	mux.HandleFunc("POST /api/sessions/import", h.sameOrigin(h.requireRole(roles.Admin, h.rateLimited(h.HandleSessionImport))))
+	mux.HandleFunc("POST /api/batches", h.sameOrigin(h.requireRole(roles.Admin, h.HandleBatchExport)))
+	mux.HandleFunc("GET /api/batches/{name}", h.requireRole(roles.Admin, h.HandleBatchFile))
+	mux.HandleFunc("GET /sru", h.HandleSRU)
+	mux.HandleFunc("GET /feeds/approved.atom", h.HandleApprovedAtom)
+	mux.HandleFunc("GET /feeds/approved.json", h.HandleApprovedJSON)
+	mux.HandleFunc("GET /uploads/{name}", h.HandleUpload)
+	mux.HandleFunc("GET /api/eval/trends", h.HandleEvalTrends)
+	mux.HandleFunc("GET /eval/trends", h.HandleEvalTrendsPage)
+	mux.Handle("GET /{$}", http.RedirectHandler("/ui/", http.StatusFound))
+	mux.HandleFunc("GET /ui/{$}", h.HandlePageIndex)
+	mux.HandleFunc("POST /ui/sessions", h.sameOriginForPage(h.requireRoleForPage(roles.Cataloger, h.rateLimited(h.HandlePageCreate))))
+	mux.HandleFunc("GET /ui/sessions/{id}", h.HandlePageSession)
+	mux.HandleFunc("POST /ui/sessions/{id}/images", h.sameOriginForPage(h.requireRoleForPage(roles.Cataloger, h.rateLimited(h.sessionLocked(h.HandlePageImages)))))
+	mux.HandleFunc("POST /ui/sessions/{id}/ocr", h.sameOriginForPage(h.requireRoleForPage(roles.Cataloger, h.rateLimited(h.sessionLocked(h.HandlePageOCR)))))
+	mux.HandleFunc("POST /ui/sessions/{id}/marc", h.sameOriginForPage(h.requireRoleForPage(roles.Cataloger, h.rateLimited(h.sessionLocked(h.HandlePageMARC)))))
+	mux.HandleFunc("POST /ui/sessions/{id}/record", h.sameOriginForPage(h.requireRoleForPage(roles.Cataloger, h.rateLimited(h.sessionLocked(h.HandlePageRecord)))))
+	mux.HandleFunc("POST /ui/sessions/{id}/status", h.sameOriginForPage(h.requireRoleForPage(roles.Cataloger, h.sessionLocked(h.HandlePageStatus))))
+	return mux
+}
+
+// ReferencedUploads returns the names of upload files used by any session, for orphan cleanup
+func (h *Handler) ReferencedUploads() map[string]bool {
+	referenced := make(map[string]bool)
+	for _, session := range h.sessionStore.GetAll() {
+		for _, img := range session.Images {
+			referenced[filepath.Base(img.ImagePath)] = true
+		}
+	}
+	return referenced
+}
+
+// HandleHealthcheck reports that the server is up
+func (h *Handler) HandleHealthcheck(w http.ResponseWriter, r *http.Request) {
+	respondWithJSON(w, map[string]string{"status": "ok"}, http.StatusOK)
+}
+
+// HandleProviders lists the available LLM providers, their default models and
+// whether they accept images, for populating provider/model pickers in the UI
+func (h *Handler) HandleProviders(w http.ResponseWriter, r *http.Request) {
+	respondWithJSON(w, map[string]any{
+		"providers": h.catalogService.AvailableProviders(),
+	}, http.StatusOK)
+}
+
+// rateLimited rejects requests beyond the client's rate limit with 429 and Retry-After
+func (h *Handler) rateLimited(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if h.limiter == nil {
+			next(w, r)
+			return
+		}
+		client := ratelimit.ClientKey(r, h.trustProxy)
+		if ok, retryAfter := h.limiter.Allow(client); !ok {
+			w.Header().Set("Retry-After", ratelimit.RetryAfterSeconds(retryAfter))
+			respondError(w, r, http.StatusTooManyRequests, "Rate limit exceeded, retry later")
+			return
+		}
+		next(w, r)
+	}
+}
+
+// crossSite reports whether a browser sent the request from another site's page, e.g. a form
+// posting to this server that would carry the proxy's sign-in cookie with it. Browsers send
+// Sec-Fetch-Site, and older ones Origin; requests with neither aren't from a browser.
+func crossSite(r *http.Request) bool {
+	if site := r.Header.Get("Sec-Fetch-Site"); site != "" {
+		return site != "same-origin" && site != "none"
+	}
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return false
+	}
+	u, err := url.Parse(origin)
+	return err != nil || u.Host != r.Host
+}
+
+// sameOrigin responds 403 to cross-site requests, which could be forged by another site
+func (h *Handler) sameOrigin(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if crossSite(r) {
+			respondError(w, r, http.StatusForbidden, "Cross-site request refused")
+			return
+		}
+		next(w, r)
+	}
+}
+
+// sameOriginForPage is sameOrigin showing the index page
+func (h *Handler) sameOriginForPage(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if crossSite(r) {
+			h.renderIndex(w, r, http.StatusForbidden, "Cross-site request refused")
+			return
+		}
+		next(w, r)
+	}
+}
+
+// sessionLocked runs next holding the lock on the session in the path, so requests changing
+// a session, including one waiting on generation, apply one at a time to its latest state
+func (h *Handler) sessionLocked(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		unlock := h.sessionStore.Lock(r.PathValue("id"))
+		defer unlock()
+		next(w, r)
+	}
+}
+
+// acquireGeneration takes a generation slot, responding 503 with Retry-After when none frees
+// up in time. The caller must call release when ok.
+func (h *Handler) acquireGeneration(w http.ResponseWriter, r *http.Request) (release func(), ok bool) {
+	release, err := h.reserveGeneration(r)
+	if err != nil {
+		if errors.Is(err, ratelimit.ErrBusy) {
+			slog.Warn("Generation capacity exhausted", "in_use", h.generations.InUse())
+			w.Header().Set("Retry-After", ratelimit.RetryAfterSeconds(h.generations.RetryAfter()))
+			respondError(w, r, http.StatusServiceUnavailable, "Server is busy generating other records, retry later")
+		}
+		return nil, false
+	}
+	return release, true
+}
+
+// reserveGeneration takes a generation slot, returning ratelimit.ErrBusy when none frees up in
+// time. The caller must call release when err is nil.
+func (h *Handler) reserveGeneration(r *http.Request) (release func(), err error) {
+	if h.generations == nil {
+		return func() {}, nil
+	}
+	return h.generations.Acquire(r.Context())
+}
+
+// respondError responds with an error message in the client's language (see i18n.Lang).
+// The format is the English message and its ID in the locale files.
+func respondError(w http.ResponseWriter, r *http.Request, statusCode int, format string, args ...any) {
+	lang := i18n.Lang(r)
+	w.Header().Set("Content-Language", lang)
+	utils.RespondWithError(w, i18n.Sprintf(lang, format, args...), statusCode)
+}
+
+func respondWithJSON(w http.ResponseWriter, v any, statusCode int) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		slog.Error("Failed to encode response", "error", err)
+	}
+}
@@ -0,0 +1,43 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/lehigh-university-libraries/cataloger/internal/labels"
+	"github.com/lehigh-university-libraries/cataloger/internal/marc"
+)
+
+// HandleSessionLabels returns spine and pocket label data for the session's record.
+// Plain text for printing by default, or JSON with ?format=json.
+func (h *Handler) HandleSessionLabels(w http.ResponseWriter, r *http.Request) {
+	session, ok := h.sessionStore.Get(r.PathValue("id"))
+	if !ok {
+		respondError(w, r, http.StatusNotFound, "Session not found")
+		return
+	}
+
+	if session.MARC == "" {
+		respondError(w, r, http.StatusConflict, "Session has no MARC record")
+		return
+	}
+
+	rec, err := marc.ParseXML([]byte(session.MARC))
+	if err != nil {
+		respondError(w, r, http.StatusInternalServerError, "%s", err)
+		return
+	}
+
+	label, err := labels.FromRecord(rec)
+	if err != nil {
+		respondError(w, r, http.StatusUnprocessableEntity, "%s", err)
+		return
+	}
+
+	if r.URL.Query().Get("format") == "json" {
+		respondWithJSON(w, label, http.StatusOK)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	_, _ = w.Write([]byte(labels.Text([]*labels.Label{label})))
+}
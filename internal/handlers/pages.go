@@ -0,0 +1,510 @@
+package handlers
+
+import (
+	"embed"
+	"errors"
+	"html/template"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/lehigh-university-libraries/cataloger/internal/cataloging"
+	"github.com/lehigh-university-libraries/cataloger/internal/i18n"
+	"github.com/lehigh-university-libraries/cataloger/internal/marc"
+	"github.com/lehigh-university-libraries/cataloger/internal/models"
+	"github.com/lehigh-university-libraries/cataloger/internal/ratelimit"
+	"github.com/lehigh-university-libraries/cataloger/internal/roles"
+)
+
+// The plain-HTML screens under /ui/ create, review and edit sessions without JavaScript, for
+// screen readers and locked-down staff machines. Every form posts to the server, which
+// redirects back to the page with the outcome (post/redirect/get), or shows the page again
+// with the error.
+
+//go:embed templates/*.html
+var templateFiles embed.FS
+
+// maxListedSessions limits the sessions listed on the index page, most recent first
+const maxListedSessions = 100
+
+// imageTypeLabels names each image type on the screens
+var imageTypeLabels = map[string]string{
+	"title_page": "Title page",
+	"copyright":  "Copyright page",
+	"cover":      "Cover",
+}
+
+// actionLabels names each audit action in a session's history
+var actionLabels = map[string]string{
+	models.ActionUpload:   "Image uploaded",
+	models.ActionOCR:      "Text recognized",
+	models.ActionOCREdit:  "Text corrected",
+	models.ActionGenerate: "Record generated",
+	models.ActionMARCEdit: "Record edited",
+	models.ActionExport:   "Exported",
+	models.ActionImport:   "Imported",
+	models.ActionStatus:   "Status changed",
+	models.ActionBatch:    "Exported in a batch",
+}
+
+// statusLabels names each session status on the screens
+var statusLabels = map[string]string{
+	models.StatusUploaded:  "Uploaded",
+	models.StatusGenerated: "Generated",
+	models.StatusInReview:  "In review",
+	models.StatusApproved:  "Approved",
+	models.StatusPushed:    "Pushed",
+	models.StatusRejected:  "Rejected",
+}
+
+// pageStatuses announce the outcome of a form after its redirect, by the done parameter
+var pageStatuses = map[string]string{
+	"created":   "Session created.",
+	"images":    "Images added.",
+	"ocr":       "Text recognized.",
+	"corrected": "Text saved.",
+	"generated": "Record generated.",
+	"record":    "Record saved.",
+	"status":    "Status changed.",
+}
+
+var pages = parsePages("index.html", "session.html")
+
+// parsePages parses each page with the shared layout
+func parsePages(names ...string) map[string]*template.Template {
+	label := func(labels map[string]string) func(string) string {
+		return func(key string) string {
+			if l, ok := labels[key]; ok {
+				return l
+			}
+			return key
+		}
+	}
+	layout := template.Must(template.New("layout.html").Funcs(template.FuncMap{
+		"t":         i18n.Sprintf,
+		"imageType": label(imageTypeLabels),
+		"action":    label(actionLabels),
+		"status":    label(statusLabels),
+	}).ParseFS(templateFiles, "templates/layout.html"))
+
+	parsed := make(map[string]*template.Template, len(names))
+	for _, name := range names {
+		parsed[name] = template.Must(template.Must(layout.Clone()).ParseFS(templateFiles, "templates/"+name))
+	}
+	return parsed
+}
+
+// pageData is what a screen shows, in the request's language
+type pageData struct {
+	Lang           string
+	Query          string // "?lang=..." when the language was chosen by parameter, for links and forms
+	Error          string // Why the last request failed, translated
+	Status         string // Outcome of the last request, as a message ID
+	Providers      []cataloging.ProviderInfo
+	Provider       string // Selected provider
+	ChooseProvider bool   // The user may choose the provider and model (admins only)
+	ImageTypes     []string
+	MaxImages      int
+	FromImages     bool // Generate MARC from the page images by default (--marc-from-images)
+
+	Sessions      []sessionRow   // Index page
+	StatusFilters []statusFilter // Links filtering the index page by status, "" for all
+
+	Session      *models.CatalogSession
+	RecordTitle  string   // 245 $a of the session's record
+	Record       string   // The session's record in mnemonic form, or an edit of it that was rejected
+	NextStatuses []string // Statuses the user may move the session to
+	History      []models.AuditEvent
+}
+
+// sessionRow is one session on the index page
+type sessionRow struct {
+	ID, Title string
+	Created   time.Time
+	Images    int
+	Status    string
+}
+
+// statusFilter links to the index page showing only the sessions with a status
+type statusFilter struct {
+	Status, URL string
+	Current     bool
+}
+
+// renderPage writes a screen with the given status
+func (h *Handler) renderPage(w http.ResponseWriter, r *http.Request, status int, name string, data pageData) {
+	data.Lang = i18n.Lang(r)
+	if lang := r.URL.Query().Get("lang"); lang != "" {
+		data.Query = "?lang=" + url.QueryEscape(lang)
+	}
+	if data.Status == "" && data.Error == "" {
+		data.Status = pageStatuses[r.URL.Query().Get("done")]
+	}
+	data.Providers = h.catalogService.AvailableProviders()
+	data.ChooseProvider = h.role(r) >= roles.Admin
+	if data.Provider == "" {
+		for _, p := range data.Providers {
+			if p.Default {
+				data.Provider = p.Name
+			}
+		}
+	}
+	for imageType := range models.ImageTypeOrder {
+		data.ImageTypes = append(data.ImageTypes, imageType)
+	}
+	slices.SortFunc(data.ImageTypes, func(a, b string) int {
+		return models.ImageTypeOrder[a] - models.ImageTypeOrder[b]
+	})
+	data.MaxImages = maxSessionImages
+	data.FromImages = h.marcFromImages
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Content-Language", data.Lang)
+	w.WriteHeader(status)
+	if err := pages[name].ExecuteTemplate(w, "layout", data); err != nil {
+		slog.Error("Failed to render page", "page", name, "error", err)
+	}
+}
+
+// renderIndex shows the index page, with a message saying why the request failed unless
+// format is empty
+func (h *Handler) renderIndex(w http.ResponseWriter, r *http.Request, status int, format string, args ...any) {
+	filter := r.URL.Query().Get("status")
+	if filter != "" && !slices.Contains(models.Statuses, filter) {
+		filter = ""
+		if format == "" {
+			status, format, args = http.StatusBadRequest, "Unknown status: %s", []any{r.URL.Query().Get("status")}
+		}
+	}
+
+	var rows []sessionRow
+	for _, session := range h.sessionStore.GetAll() {
+		if filter != "" && session.Status != filter {
+			continue
+		}
+		rows = append(rows, sessionRow{
+			ID:      session.ID,
+			Title:   recordTitle(session),
+			Created: session.CreatedAt,
+			Images:  len(session.Images),
+			Status:  session.Status,
+		})
+	}
+	slices.SortFunc(rows, func(a, b sessionRow) int { return b.Created.Compare(a.Created) })
+	if len(rows) > maxListedSessions {
+		rows = rows[:maxListedSessions]
+	}
+
+	data := pageData{Sessions: rows}
+	for _, s := range append([]string{""}, models.Statuses...) {
+		query := url.Values{}
+		if lang := r.URL.Query().Get("lang"); lang != "" {
+			query.Set("lang", lang)
+		}
+		if s != "" {
+			query.Set("status", s)
+		}
+		link := statusFilter{Status: s, URL: "/ui/", Current: s == filter}
+		if len(query) > 0 {
+			link.URL += "?" + query.Encode()
+		}
+		data.StatusFilters = append(data.StatusFilters, link)
+	}
+	if format != "" {
+		data.Error = i18n.Sprintf(i18n.Lang(r), format, args...)
+	}
+	h.renderPage(w, r, status, "index.html", data)
+}
+
+// renderSession shows a session's page. A rejected edit of its record is shown in place of the
+// record when record is not empty, and a message saying why the request failed unless format
+// is empty.
+func (h *Handler) renderSession(w http.ResponseWriter, r *http.Request, status int, session *models.CatalogSession, record, format string, args ...any) {
+	data := pageData{
+		Provider:    session.Provider,
+		Session:     session,
+		RecordTitle: recordTitle(session),
+		Record:      record,
+		History:     h.sessionStore.History(session.ID),
+	}
+	role := h.role(r)
+	for _, status := range models.NextStatuses(session.Status) {
+		if role >= statusRole(session.Status, status) {
+			data.NextStatuses = append(data.NextStatuses, status)
+		}
+	}
+	if data.Record == "" && session.MARC != "" {
+		if rec, err := marc.ParseXML([]byte(session.MARC)); err == nil {
+			data.Record = rec.Mnemonic()
+		}
+	}
+	if format != "" {
+		data.Error = i18n.Sprintf(i18n.Lang(r), format, args...)
+	}
+	h.renderPage(w, r, status, "session.html", data)
+}
+
+// recordTitle returns the title proper of the session's record, if it has one
+func recordTitle(session *models.CatalogSession) string {
+	if session.MARC == "" {
+		return ""
+	}
+	rec, err := marc.ParseXML([]byte(session.MARC))
+	if err != nil {
+		return ""
+	}
+	return strings.TrimRight(rec.SubfieldValue("245", "a"), " /:;.,")
+}
+
+// redirectToSession sends the browser back to the session's page, announcing done and
+// scrolling to the fragment
+func redirectToSession(w http.ResponseWriter, r *http.Request, id, done, fragment string) {
+	target := "/ui/sessions/" + id + "?done=" + done
+	if lang := r.URL.Query().Get("lang"); lang != "" {
+		target += "&lang=" + url.QueryEscape(lang)
+	}
+	if fragment != "" {
+		target += "#" + fragment
+	}
+	http.Redirect(w, r, target, http.StatusSeeOther)
+}
+
+// sessionForPage returns the request's session, showing the index page with 404 when there is
+// none
+func (h *Handler) sessionForPage(w http.ResponseWriter, r *http.Request) (*models.CatalogSession, bool) {
+	session, ok := h.sessionStore.Get(r.PathValue("id"))
+	if !ok {
+		h.renderIndex(w, r, http.StatusNotFound, "Session not found")
+	}
+	return session, ok
+}
+
+// sessionToChangeForPage is sessionForPage, showing the session page with 409 when its status
+// keeps it from changing
+func (h *Handler) sessionToChangeForPage(w http.ResponseWriter, r *http.Request) (*models.CatalogSession, bool) {
+	session, ok := h.sessionForPage(w, r)
+	if ok && session.Locked() {
+		h.renderSession(w, r, http.StatusConflict, session, "", "A session that is %s cannot be changed", session.Status)
+		return nil, false
+	}
+	return session, ok
+}
+
+// reserveGenerationForPage takes a generation slot, showing the session page with 503 when
+// none frees up in time. The caller must call release when ok.
+func (h *Handler) reserveGenerationForPage(w http.ResponseWriter, r *http.Request, session *models.CatalogSession) (release func(), ok bool) {
+	release, err := h.reserveGeneration(r)
+	if err != nil {
+		if errors.Is(err, ratelimit.ErrBusy) {
+			w.Header().Set("Retry-After", ratelimit.RetryAfterSeconds(h.generations.RetryAfter()))
+			h.renderSession(w, r, http.StatusServiceUnavailable, session, "", "Server is busy generating other records, retry later")
+		}
+		return nil, false
+	}
+	return release, true
+}
+
+// HandlePageIndex shows the form for a new session and lists the most recent sessions
+func (h *Handler) HandlePageIndex(w http.ResponseWriter, r *http.Request) {
+	h.renderIndex(w, r, http.StatusOK, "")
+}
+
+// HandlePageCreate creates a session from the new session form and shows it
+func (h *Handler) HandlePageCreate(w http.ResponseWriter, r *http.Request) {
+	files, err := parseImageUploads(w, r, h.maxUploadSize)
+	if err != nil {
+		status, format, args := uploadFailure(err)
+		h.renderIndex(w, r, status, format, args...)
+		return
+	}
+	if len(files) > maxSessionImages {
+		h.renderIndex(w, r, http.StatusBadRequest, "A session holds at most %d images", maxSessionImages)
+		return
+	}
+	if !h.mayChooseProvider(r, nil, r.FormValue("provider"), r.FormValue("model")) {
+		h.renderIndex(w, r, http.StatusForbidden, "Only admins can choose the provider or model")
+		return
+	}
+
+	session, err := h.newSession(r, files)
+	if err != nil {
+		status, format, args := addImagesFailure(err, http.StatusBadRequest)
+		if status == http.StatusInternalServerError {
+			slog.Error("Failed to save upload", "error", err)
+		}
+		h.renderIndex(w, r, status, format, args...)
+		return
+	}
+	redirectToSession(w, r, session.ID, "created", "")
+}
+
+// HandlePageSession shows a session for review: its images and their transcriptions, its
+// record and its history
+func (h *Handler) HandlePageSession(w http.ResponseWriter, r *http.Request) {
+	session, ok := h.sessionForPage(w, r)
+	if !ok {
+		return
+	}
+	h.renderSession(w, r, http.StatusOK, session, "", "")
+}
+
+// HandlePageImages adds images to a session from its page
+func (h *Handler) HandlePageImages(w http.ResponseWriter, r *http.Request) {
+	session, ok := h.sessionToChangeForPage(w, r)
+	if !ok {
+		return
+	}
+
+	files, err := parseImageUploads(w, r, h.maxUploadSize)
+	if err != nil {
+		status, format, args := uploadFailure(err)
+		h.renderSession(w, r, status, session, "", format, args...)
+		return
+	}
+	if len(session.Images)+len(files) > maxSessionImages {
+		h.renderSession(w, r, http.StatusBadRequest, session, "", "A session holds at most %d images", maxSessionImages)
+		return
+	}
+
+	if err := h.addImages(r, session, files); err != nil {
+		status, format, args := addImagesFailure(err, http.StatusConflict)
+		if status == http.StatusInternalServerError {
+			slog.Error("Failed to save upload", "session", session.ID, "error", err)
+		}
+		h.renderSession(w, r, status, session, "", format, args...)
+		return
+	}
+	h.sessionStore.Set(session.ID, session)
+	redirectToSession(w, r, session.ID, "images", "images")
+}
+
+// HandlePageOCR saves a corrected transcription of a session image ("action=save"), or
+// transcribes the image again with the session's provider ("action=recognize")
+func (h *Handler) HandlePageOCR(w http.ResponseWriter, r *http.Request) {
+	session, ok := h.sessionToChangeForPage(w, r)
+	if !ok {
+		return
+	}
+	r.Body = http.MaxBytesReader(w, r.Body, h.maxUploadSize)
+	imageID := r.FormValue("image_id")
+	idx := findImage(session, imageID)
+	if imageID == "" || idx < 0 {
+		h.renderSession(w, r, http.StatusNotFound, session, "", "Image not found in session")
+		return
+	}
+	image := session.Images[idx]
+
+	if r.FormValue("action") == "recognize" {
+		release, ok := h.reserveGenerationForPage(w, r, session)
+		if !ok {
+			return
+		}
+		defer release()
+		if err := h.runOCR(r, session, idx, session.Provider, session.Model); err != nil {
+			slog.Error("OCR failed", "session", session.ID, "image", image.ID, "error", err)
+			h.renderSession(w, r, http.StatusBadGateway, session, "", "OCR failed: %s", err)
+			return
+		}
+		h.sessionStore.Set(session.ID, session)
+		redirectToSession(w, r, session.ID, "ocr", "image-"+image.ID)
+		return
+	}
+
+	h.correctOCR(r, session, idx, strings.ReplaceAll(r.FormValue("ocr_text"), "\r\n", "\n"))
+	h.sessionStore.Set(session.ID, session)
+	redirectToSession(w, r, session.ID, "corrected", "image-"+image.ID)
+}
+
+// HandlePageMARC generates the session's record from its page with the chosen provider and
+// model, from the transcriptions or, with from_images, the images themselves
+func (h *Handler) HandlePageMARC(w http.ResponseWriter, r *http.Request) {
+	session, ok := h.sessionToChangeForPage(w, r)
+	if !ok {
+		return
+	}
+	r.Body = http.MaxBytesReader(w, r.Body, h.maxUploadSize)
+	if err := r.ParseForm(); err != nil {
+		h.renderSession(w, r, http.StatusBadRequest, session, "", "Invalid request body")
+		return
+	}
+	if !h.mayChooseProvider(r, session, r.PostForm.Get("provider"), r.PostForm.Get("model")) {
+		h.renderSession(w, r, http.StatusForbidden, session, "", "Only admins can choose the provider or model")
+		return
+	}
+	if !session.CanGenerate() {
+		h.renderSession(w, r, http.StatusConflict, session, "", "A record cannot be generated for a session that is %s", session.Status)
+		return
+	}
+
+	release, ok := h.reserveGenerationForPage(w, r, session)
+	if !ok {
+		return
+	}
+	defer release()
+
+	fromImages := r.PostForm.Get("from_images") != ""
+	if err := h.generateMARC(r, session, r.PostForm.Get("provider"), r.PostForm.Get("model"), fromImages, nil); err != nil {
+		slog.Error("MARC generation failed", "session", session.ID, "error", err)
+		h.renderSession(w, r, http.StatusBadGateway, session, "", "MARC generation failed: %s", err)
+		return
+	}
+	h.sessionStore.Set(session.ID, session)
+	redirectToSession(w, r, session.ID, "generated", "record")
+}
+
+// HandlePageRecord saves a hand-edited record, in MarcEdit mnemonic form, to the session
+func (h *Handler) HandlePageRecord(w http.ResponseWriter, r *http.Request) {
+	session, ok := h.sessionToChangeForPage(w, r)
+	if !ok {
+		return
+	}
+	r.Body = http.MaxBytesReader(w, r.Body, h.maxUploadSize)
+	text := strings.ReplaceAll(r.FormValue("record"), "\r\n", "\n")
+
+	rec, err := marc.ParseMnemonic(text)
+	if err != nil {
+		h.renderSession(w, r, http.StatusBadRequest, session, text, "Invalid record: %s", err)
+		return
+	}
+	data, err := rec.XML()
+	if err != nil {
+		h.renderSession(w, r, http.StatusBadRequest, session, text, "Invalid record: %s", err)
+		return
+	}
+
+	if string(data) != session.MARC {
+		session.MARC = string(data)
+		h.audit(r, session.ID, models.AuditEvent{
+			Action:  models.ActionMARCEdit,
+			Details: map[string]string{"prompt_version": session.PromptVersion},
+		})
+		h.sessionStore.Set(session.ID, session)
+		slog.Info("Stored record edit", "session", session.ID)
+	}
+	redirectToSession(w, r, session.ID, "record", "record")
+}
+
+// HandlePageStatus moves a session to the status of the button pressed, e.g. to approve its
+// record after review
+func (h *Handler) HandlePageStatus(w http.ResponseWriter, r *http.Request) {
+	session, ok := h.sessionForPage(w, r)
+	if !ok {
+		return
+	}
+	r.Body = http.MaxBytesReader(w, r.Body, h.maxUploadSize)
+	if err := r.ParseForm(); err != nil {
+		h.renderSession(w, r, http.StatusBadRequest, session, "", "Invalid request body")
+		return
+	}
+
+	to := r.PostForm.Get("status")
+	if status, format, args := statusFailure(session, to, h.role(r)); status != 0 {
+		h.renderSession(w, r, status, session, "", format, args...)
+		return
+	}
+	h.setStatus(r, session, to, r.PostForm.Get("note"))
+	redirectToSession(w, r, session.ID, "status", "")
+}
@@ -0,0 +1,230 @@
+package handlers
+
+import (
+	"bytes"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/lehigh-university-libraries/cataloger/internal/models"
+	"github.com/lehigh-university-libraries/cataloger/internal/roles"
+)
+
+const formType = "application/x-www-form-urlencoded"
+
+// imageForm returns a multipart body with one title page image and its content type
+func imageForm(t *testing.T, filename string, data []byte) (contentType, body string) {
+	t.Helper()
+	var buf bytes.Buffer
+	mw := multipart.NewWriter(&buf)
+	part, err := mw.CreateFormFile("image", filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := part.Write(data); err != nil {
+		t.Fatal(err)
+	}
+	if err := mw.WriteField("image_type", "title_page"); err != nil {
+		t.Fatal(err)
+	}
+	if err := mw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return mw.FormDataContentType(), buf.String()
+}
+
+func TestPageCreate(t *testing.T) {
+	h, routes := newTestHandler(t)
+
+	contentType, body := imageForm(t, "title.jpg", testPNG(t))
+	w := serve(routes, http.MethodPost, "/ui/sessions", contentType, body)
+	location := w.Header().Get("Location")
+	if w.Code != http.StatusSeeOther || !strings.HasPrefix(location, "/ui/sessions/") || !strings.Contains(location, "done=created") {
+		t.Fatalf("POST /ui/sessions = %d to %q", w.Code, location)
+	}
+	id := strings.TrimPrefix(strings.Split(location, "?")[0], "/ui/sessions/")
+	session, ok := h.sessionStore.Get(id)
+	if !ok || session.Status != models.StatusUploaded || len(session.Images) != 1 || !strings.HasSuffix(session.Images[0].ImageURL, ".png") {
+		t.Fatalf("created session = %+v", session)
+	}
+
+	w = serve(routes, http.MethodGet, location, "", "")
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `role="status"`) {
+		t.Errorf("GET %s = %d, without the status message", location, w.Code)
+	}
+
+	contentType, body = imageForm(t, "title.png", []byte("<html><script>alert(1)</script></html>"))
+	w = serve(routes, http.MethodPost, "/ui/sessions", contentType, body)
+	if w.Code != http.StatusUnsupportedMediaType || !strings.Contains(w.Body.String(), `role="alert"`) {
+		t.Errorf("POST /ui/sessions with a non-image = %d", w.Code)
+	}
+	if n := len(h.sessionStore.GetAll()); n != 1 {
+		t.Errorf("%d sessions, want 1", n)
+	}
+}
+
+func TestPageEdits(t *testing.T) {
+	h, routes := newTestHandler(t)
+	addSession(t, h, "s", models.StatusInReview)
+	session, _ := h.sessionStore.Get("s")
+	imageID := session.Images[0].ID
+
+	form := url.Values{"image_id": {imageID}, "ocr_text": {"THE HISTORY OF BRIDGES\r\nby Jane Smyth"}}
+	w := serve(routes, http.MethodPost, "/ui/sessions/s/ocr", formType, form.Encode())
+	if w.Code != http.StatusSeeOther || w.Header().Get("Location") != "/ui/sessions/s?done=corrected#image-"+imageID {
+		t.Errorf("POST ocr = %d to %q", w.Code, w.Header().Get("Location"))
+	}
+
+	record := "=LDR  00000nam a2200000 i 4500\r\n=245  10$aEdited title /$cJane Smyth."
+	w = serve(routes, http.MethodPost, "/ui/sessions/s/record", formType, url.Values{"record": {record}}.Encode())
+	if w.Code != http.StatusSeeOther || w.Header().Get("Location") != "/ui/sessions/s?done=record#record" {
+		t.Errorf("POST record = %d to %q", w.Code, w.Header().Get("Location"))
+	}
+
+	session, _ = h.sessionStore.Get("s")
+	if session.Images[0].OCRText != "THE HISTORY OF BRIDGES\nby Jane Smyth" || len(session.OCRCorrections) != 1 || !strings.Contains(session.MARC, "Edited title") {
+		t.Errorf("edited session = %+v", session)
+	}
+	history := h.sessionStore.History("s")
+	if len(history) != 2 || history[0].Action != models.ActionOCREdit || history[1].Action != models.ActionMARCEdit {
+		t.Errorf("history = %+v", history)
+	}
+
+	// A record that doesn't parse is shown again to fix, and not saved
+	w = serve(routes, http.MethodPost, "/ui/sessions/s/record", formType, url.Values{"record": {"not a record"}}.Encode())
+	if w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), "not a record") {
+		t.Errorf("POST invalid record = %d", w.Code)
+	}
+	if session, _ := h.sessionStore.Get("s"); !strings.Contains(session.MARC, "Edited title") {
+		t.Errorf("invalid record replaced the saved one: %s", session.MARC)
+	}
+}
+
+func TestPageStatus(t *testing.T) {
+	tests := []struct {
+		name     string
+		from, to string
+		user     string
+		code     int
+	}{
+		{"send to review", models.StatusGenerated, models.StatusInReview, "jdoe", http.StatusSeeOther},
+		{"approve", models.StatusInReview, models.StatusApproved, "lead", http.StatusSeeOther},
+		{"approve unreviewed", models.StatusGenerated, models.StatusApproved, "lead", http.StatusConflict},
+		{"approve as cataloger", models.StatusInReview, models.StatusApproved, "jdoe", http.StatusForbidden},
+		{"reopen pushed", models.StatusPushed, models.StatusInReview, "lead", http.StatusConflict},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h, routes := newTestHandler(t)
+			resolver, err := roles.New(roles.Config{Users: map[string]string{"jdoe": "cataloger", "lead": "reviewer"}})
+			if err != nil {
+				t.Fatal(err)
+			}
+			h.SetRoles(resolver)
+			addSession(t, h, "s", tt.from)
+
+			req := url.Values{"status": {tt.to}, "note": {"looks right"}}.Encode()
+			w := serveAs(routes, tt.user, http.MethodPost, "/ui/sessions/s/status", formType, req)
+			if w.Code != tt.code {
+				t.Fatalf("POST status = %d, want %d", w.Code, tt.code)
+			}
+			session, _ := h.sessionStore.Get("s")
+			if tt.code != http.StatusSeeOther {
+				if session.Status != tt.from || !strings.Contains(w.Body.String(), `role="alert"`) {
+					t.Errorf("refused move: status %s, page without an alert", session.Status)
+				}
+				return
+			}
+			history := h.sessionStore.History("s")
+			if session.Status != tt.to || w.Header().Get("Location") != "/ui/sessions/s?done=status" ||
+				len(history) != 1 || history[0].Actor != tt.user || history[0].Details["note"] != "looks right" {
+				t.Errorf("session = %+v, history %+v", session, history)
+			}
+		})
+	}
+}
+
+func TestPageLockedSession(t *testing.T) {
+	h, routes := newTestHandler(t)
+	addSession(t, h, "s", models.StatusApproved)
+	session, _ := h.sessionStore.Get("s")
+
+	imageType, imageBody := imageForm(t, "cover.png", testPNG(t))
+	requests := []struct{ target, contentType, body string }{
+		{"/ui/sessions/s/images", imageType, imageBody},
+		{"/ui/sessions/s/ocr", formType, url.Values{"image_id": {session.Images[0].ID}, "ocr_text": {"edited"}}.Encode()},
+		{"/ui/sessions/s/marc", formType, ""},
+		{"/ui/sessions/s/record", formType, url.Values{"record": {"=LDR  00000nam a2200000 i 4500\n=245  10$aEdited title"}}.Encode()},
+	}
+	for _, req := range requests {
+		w := serve(routes, http.MethodPost, req.target, req.contentType, req.body)
+		if w.Code != http.StatusConflict || !strings.Contains(w.Body.String(), "A session that is approved cannot be changed") {
+			t.Errorf("POST %s = %d, want 409 with the session page", req.target, w.Code)
+		}
+	}
+	after, _ := h.sessionStore.Get("s")
+	if len(after.Images) != 1 || after.Images[0].OCRText != titlePageText || after.MARC != storedRecord || len(h.sessionStore.History("s")) != 0 {
+		t.Errorf("locked session changed: %+v", after)
+	}
+
+	// Moving it back to review unlocks it
+	w := serve(routes, http.MethodPost, "/ui/sessions/s/status", formType, url.Values{"status": {models.StatusInReview}}.Encode())
+	if w.Code != http.StatusSeeOther {
+		t.Fatalf("POST status = %d", w.Code)
+	}
+	w = serve(routes, http.MethodPost, "/ui/sessions/s/record", formType, requests[3].body)
+	if after, _ := h.sessionStore.Get("s"); w.Code != http.StatusSeeOther || !strings.Contains(after.MARC, "Edited title") {
+		t.Errorf("POST record after reopening = %d, record %s", w.Code, after.MARC)
+	}
+}
+
+func TestPageCrossSitePost(t *testing.T) {
+	tests := []struct {
+		name    string
+		headers map[string]string
+		code    int
+	}{
+		{"another site's form", map[string]string{"Sec-Fetch-Site": "cross-site", "Origin": "https://evil.example"}, http.StatusForbidden},
+		{"sibling site", map[string]string{"Sec-Fetch-Site": "same-site"}, http.StatusForbidden},
+		{"older browser from another site", map[string]string{"Origin": "https://evil.example"}, http.StatusForbidden},
+		{"sandboxed page", map[string]string{"Origin": "null"}, http.StatusForbidden},
+		{"own page", map[string]string{"Sec-Fetch-Site": "same-origin", "Origin": "http://example.com"}, http.StatusSeeOther},
+		{"older browser from own page", map[string]string{"Origin": "http://example.com"}, http.StatusSeeOther},
+		{"not a browser", nil, http.StatusSeeOther},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h, routes := newTestHandler(t)
+			addSession(t, h, "s", models.StatusInReview)
+
+			req := httptest.NewRequest(http.MethodPost, "/ui/sessions/s/status", strings.NewReader(url.Values{"status": {models.StatusApproved}}.Encode()))
+			req.Header.Set("Content-Type", formType)
+			for k, v := range tt.headers {
+				req.Header.Set(k, v)
+			}
+			w := httptest.NewRecorder()
+			routes.ServeHTTP(w, req)
+			if w.Code != tt.code {
+				t.Fatalf("POST status = %d, want %d", w.Code, tt.code)
+			}
+			session, _ := h.sessionStore.Get("s")
+			if approved := session.Status == models.StatusApproved; approved != (tt.code == http.StatusSeeOther) {
+				t.Errorf("status = %s after %d", session.Status, w.Code)
+			}
+		})
+	}
+
+	// The API refuses them too
+	h, routes := newTestHandler(t)
+	addSession(t, h, "s", models.StatusInReview)
+	req := httptest.NewRequest(http.MethodPost, "/api/sessions/s/marc", nil)
+	req.Header.Set("Sec-Fetch-Site", "cross-site")
+	w := httptest.NewRecorder()
+	routes.ServeHTTP(w, req)
+	if w.Code != http.StatusForbidden {
+		t.Errorf("cross-site POST marc = %d, want 403", w.Code)
+	}
+}
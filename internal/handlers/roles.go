@@ -0,0 +1,75 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/lehigh-university-libraries/cataloger/internal/models"
+	"github.com/lehigh-university-libraries/cataloger/internal/roles"
+)
+
+// statusRoles lists the role needed to move a session to each status: catalogers send records
+// to review, reviewers approve or reject them, and admins push them to the library system
+var statusRoles = map[string]roles.Role{
+	models.StatusInReview: roles.Cataloger,
+	models.StatusApproved: roles.Reviewer,
+	models.StatusRejected: roles.Reviewer,
+	models.StatusPushed:   roles.Admin,
+}
+
+// SetRoles decides what each request's user may do. Without a resolver everyone is an admin.
+func (h *Handler) SetRoles(resolver roles.Resolver) {
+	h.roles = resolver
+}
+
+// role returns the role of the request's user
+func (h *Handler) role(r *http.Request) roles.Role {
+	if h.roles == nil {
+		return roles.Admin
+	}
+	return h.roles.Role(r)
+}
+
+// requireRole responds 403 to users without the role
+func (h *Handler) requireRole(role roles.Role, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if h.role(r) < role {
+			respondError(w, r, http.StatusForbidden, "This requires the %s role", role)
+			return
+		}
+		next(w, r)
+	}
+}
+
+// requireRoleForPage is requireRole showing the index page
+func (h *Handler) requireRoleForPage(role roles.Role, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if h.role(r) < role {
+			h.renderIndex(w, r, http.StatusForbidden, "This requires the %s role", role)
+			return
+		}
+		next(w, r)
+	}
+}
+
+// statusRole returns the role needed to move a session from one status to another. Reopening
+// an approved record takes a reviewer, like approving it.
+func statusRole(from, to string) roles.Role {
+	role := statusRoles[to]
+	if from == models.StatusApproved {
+		role = max(role, roles.Reviewer)
+	}
+	return role
+}
+
+// mayChooseProvider reports whether the request's user may run the provider and model it
+// names. Only admins choose ones other than the session's; session is nil for a new session,
+// which others leave to the server's default.
+func (h *Handler) mayChooseProvider(r *http.Request, session *models.CatalogSession, provider, model string) bool {
+	if h.role(r) >= roles.Admin || (provider == "" && model == "") {
+		return true
+	}
+	if session == nil {
+		return false
+	}
+	return (provider == "" || provider == session.Provider) && (model == "" || model == session.Model)
+}
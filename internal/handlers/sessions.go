@@ -0,0 +1,927 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"mime/multipart"
+	"net/http"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lehigh-university-libraries/cataloger/internal/cataloging"
+	"github.com/lehigh-university-libraries/cataloger/internal/holdings"
+	"github.com/lehigh-university-libraries/cataloger/internal/i18n"
+	"github.com/lehigh-university-libraries/cataloger/internal/marc"
+	"github.com/lehigh-university-libraries/cataloger/internal/models"
+	"github.com/lehigh-university-libraries/cataloger/internal/objectstore"
+	"github.com/lehigh-university-libraries/cataloger/internal/providers"
+	"github.com/lehigh-university-libraries/cataloger/internal/roles"
+	"github.com/lehigh-university-libraries/cataloger/internal/storage"
+	"github.com/lehigh-university-libraries/cataloger/internal/textdiff"
+	"github.com/lehigh-university-libraries/cataloger/internal/uploads"
+	"github.com/lehigh-university-libraries/cataloger/internal/utils"
+)
+
+// defaultMaxUploadSize limits a single upload request unless SetMaxRequestSize is called
+const defaultMaxUploadSize = 32 << 20
+
+// maxSessionImages limits how many images a session may hold
+const maxSessionImages = 12
+
+// HandleSessions creates a new cataloging session from an uploaded image
+func (h *Handler) HandleSessions(w http.ResponseWriter, r *http.Request) {
+	h.createImageSession(w, r)
+}
+
+// HandleSession returns a single session
+func (h *Handler) HandleSession(w http.ResponseWriter, r *http.Request) {
+	session, ok := h.sessionStore.Get(r.PathValue("id"))
+	if !ok {
+		respondError(w, r, http.StatusNotFound, "Session not found")
+		return
+	}
+
+	respondWithJSON(w, session, http.StatusOK)
+}
+
+// sessionToChange returns the request's session, responding 404 when there is none and 409
+// when its status keeps it from changing
+func (h *Handler) sessionToChange(w http.ResponseWriter, r *http.Request) (*models.CatalogSession, bool) {
+	session, ok := h.sessionStore.Get(r.PathValue("id"))
+	if !ok {
+		respondError(w, r, http.StatusNotFound, "Session not found")
+		return nil, false
+	}
+	if session.Locked() {
+		respondError(w, r, http.StatusConflict, "A session that is %s cannot be changed", session.Status)
+		return nil, false
+	}
+	return session, true
+}
+
+// HandleSessionSearch finds sessions by ISBN, title words and status, so a book already
+// catalogued is found rather than uploaded again
+func (h *Handler) HandleSessionSearch(w http.ResponseWriter, r *http.Request) {
+	q := storage.Query{
+		ISBN:   strings.TrimSpace(r.URL.Query().Get("isbn")),
+		Title:  r.URL.Query().Get("title"),
+		Status: r.URL.Query().Get("status"),
+	}
+	if q.ISBN != "" && storage.NormalizeISBN(q.ISBN) == "" {
+		respondError(w, r, http.StatusBadRequest, "Invalid ISBN: %s", q.ISBN)
+		return
+	}
+	if q.Status != "" && !slices.Contains(models.Statuses, q.Status) {
+		respondError(w, r, http.StatusBadRequest, "Unknown status: %s", q.Status)
+		return
+	}
+
+	respondWithJSON(w, map[string]any{
+		"sessions": h.sessionStore.Search(q),
+	}, http.StatusOK)
+}
+
+// createImageSession stores uploaded images (see parseImageUploads) in a new session.
+// No MARC is generated until the session's images are complete and POST .../marc is called.
+func (h *Handler) createImageSession(w http.ResponseWriter, r *http.Request) {
+	files, err := parseImageUploads(w, r, h.maxUploadSize)
+	if err != nil {
+		respondUploadError(w, r, err)
+		return
+	}
+	if len(files) > maxSessionImages {
+		respondError(w, r, http.StatusBadRequest, "A session holds at most %d images", maxSessionImages)
+		return
+	}
+	if !h.mayChooseProvider(r, nil, r.FormValue("provider"), r.FormValue("model")) {
+		respondError(w, r, http.StatusForbidden, "Only admins can choose the provider or model")
+		return
+	}
+
+	session, err := h.newSession(r, files)
+	if err != nil {
+		status, format, args := addImagesFailure(err, http.StatusBadRequest)
+		if status == http.StatusInternalServerError {
+			slog.Error("Failed to save upload", "error", err)
+		}
+		respondError(w, r, status, format, args...)
+		return
+	}
+	respondWithJSON(w, session, http.StatusCreated)
+}
+
+// newSession stores a new session of the uploaded images, with the provider and model given in
+// the request's form
+func (h *Handler) newSession(r *http.Request, files []imageUpload) (*models.CatalogSession, error) {
+	session := &models.CatalogSession{
+		ID:        newID(),
+		Provider:  r.FormValue("provider"),
+		Model:     r.FormValue("model"),
+		Status:    models.StatusUploaded,
+		CreatedAt: time.Now(),
+	}
+	if err := h.addImages(r, session, files); err != nil {
+		return nil, err
+	}
+	h.sessionStore.Set(session.ID, session)
+
+	slog.Info("Created session", "session", session.ID, "images", len(session.Images))
+	return session, nil
+}
+
+// HandleSessionImages adds uploaded images (see parseImageUploads) to an existing session,
+// e.g. the copyright page after the title page. Images already in the session are rejected.
+func (h *Handler) HandleSessionImages(w http.ResponseWriter, r *http.Request) {
+	session, ok := h.sessionToChange(w, r)
+	if !ok {
+		return
+	}
+
+	files, err := parseImageUploads(w, r, h.maxUploadSize)
+	if err != nil {
+		respondUploadError(w, r, err)
+		return
+	}
+	if len(session.Images)+len(files) > maxSessionImages {
+		respondError(w, r, http.StatusBadRequest, "A session holds at most %d images", maxSessionImages)
+		return
+	}
+
+	if err := h.addImages(r, session, files); err != nil {
+		status, format, args := addImagesFailure(err, http.StatusConflict)
+		if status == http.StatusInternalServerError {
+			slog.Error("Failed to save upload", "session", session.ID, "error", err)
+		}
+		respondError(w, r, status, format, args...)
+		return
+	}
+	h.sessionStore.Set(session.ID, session)
+
+	slog.Info("Added images to session", "session", session.ID, "added", len(files), "images", len(session.Images))
+	respondWithJSON(w, session, http.StatusOK)
+}
+
+// imageUpload is one image of a multipart upload with its requested type
+type imageUpload struct {
+	header    *multipart.FileHeader
+	imageType string
+}
+
+// parseImageUploads reads the images of a multipart upload. Each "image" part is an image;
+// "image_type" is given once for all of them (default "title_page") or once per image, in
+// order.
+func parseImageUploads(w http.ResponseWriter, r *http.Request, maxSize int64) ([]imageUpload, error) {
+	r.Body = http.MaxBytesReader(w, r.Body, maxSize)
+	if err := r.ParseMultipartForm(maxSize); err != nil {
+		return nil, err
+	}
+
+	headers := r.MultipartForm.File["image"]
+	if len(headers) == 0 {
+		return nil, errors.New("missing image file")
+	}
+
+	types := r.MultipartForm.Value["image_type"]
+	if len(types) > 1 && len(types) != len(headers) {
+		return nil, fmt.Errorf("got %d image_type values for %d images", len(types), len(headers))
+	}
+
+	images := make([]imageUpload, len(headers))
+	for i, header := range headers {
+		imageType := "title_page"
+		if len(types) == 1 {
+			imageType = types[0]
+		} else if len(types) > 1 {
+			imageType = types[i]
+		}
+		if _, ok := models.ImageTypeOrder[imageType]; !ok {
+			return nil, fmt.Errorf("invalid image_type %q", imageType)
+		}
+		images[i] = imageUpload{header: header, imageType: imageType}
+	}
+	return images, nil
+}
+
+// respondUploadError reports a failure to parse an upload (see uploadFailure)
+func respondUploadError(w http.ResponseWriter, r *http.Request, err error) {
+	status, format, args := uploadFailure(err)
+	respondError(w, r, status, format, args...)
+}
+
+// uploadFailure returns the status and message reporting a failure to parse an upload, with
+// 413 for oversized requests
+func uploadFailure(err error) (status int, format string, args []any) {
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		return http.StatusRequestEntityTooLarge, "Upload exceeds the %s request size limit", []any{uploads.FormatSize(tooLarge.Limit)}
+	}
+	return http.StatusBadRequest, "Invalid upload: %s", []any{err}
+}
+
+// respondQuotaError reports an upload rejected by the uploads quota with 507
+func respondQuotaError(w http.ResponseWriter, r *http.Request, err error) {
+	status, format, args := quotaFailure(err)
+	respondError(w, r, status, format, args...)
+}
+
+// quotaFailure returns the status and message reporting an upload rejected by the uploads
+// quota
+func quotaFailure(err error) (status int, format string, args []any) {
+	var quota *uploads.QuotaError
+	if !errors.As(err, &quota) {
+		return http.StatusInsufficientStorage, "%s", []any{err}
+	}
+	return http.StatusInsufficientStorage, "Uploads quota exceeded: %s already stored of %s, cannot add %s",
+		[]any{uploads.FormatSize(quota.Used), uploads.FormatSize(quota.Quota), uploads.FormatSize(quota.Size)}
+}
+
+// addImagesFailure returns the status and message reporting an addImages error, with
+// duplicateStatus for an image already in the session
+func addImagesFailure(err error, duplicateStatus int) (status int, format string, args []any) {
+	if errors.Is(err, errDuplicateImage) {
+		return duplicateStatus, "Invalid upload: %s", []any{err}
+	}
+	if errors.Is(err, errUnsupportedImage) {
+		return http.StatusUnsupportedMediaType, "Invalid upload: %s", []any{err}
+	}
+	if errors.Is(err, uploads.ErrQuotaExceeded) {
+		return quotaFailure(err)
+	}
+	return http.StatusInternalServerError, "Failed to save image", nil
+}
+
+// errDuplicateImage is returned by addImages when an image is already in the session
+var errDuplicateImage = errors.New("image already in session")
+
+// addImages saves uploads and appends them to the session, auditing each one.
+// The session is unchanged when any upload fails.
+func (h *Handler) addImages(r *http.Request, session *models.CatalogSession, files []imageUpload) error {
+	images := slices.Clone(session.Images)
+	for _, upload := range files {
+		file, err := upload.header.Open()
+		if err != nil {
+			return fmt.Errorf("failed to open upload: %w", err)
+		}
+		image, err := h.saveUpload(r.Context(), file, upload.imageType)
+		file.Close()
+		if errors.Is(err, errUnsupportedImage) {
+			return fmt.Errorf("%s: %w", upload.header.Filename, err)
+		}
+		if err != nil {
+			return err
+		}
+		if slices.ContainsFunc(images, func(img models.ImageItem) bool { return img.ID == image.ID }) {
+			return fmt.Errorf("%w: %s", errDuplicateImage, upload.header.Filename)
+		}
+		images = append(images, *image)
+	}
+
+	for i, upload := range files {
+		image := images[len(session.Images)+i]
+		h.audit(r, session.ID, models.AuditEvent{
+			Action:   models.ActionUpload,
+			Provider: session.Provider,
+			Model:    session.Model,
+			Details:  map[string]string{"image_id": image.ID, "image_type": upload.imageType, "filename": upload.header.Filename},
+		})
+	}
+	session.Images = images
+	return nil
+}
+
+// HandleSessionOCR runs OCR on one image of a session and stores the transcription on the image.
+//
+// Request body: {"image_id": "...", "provider": "...", "model": "..."}; image_id defaults to the
+// session's title page (or first image), provider/model default to the session's settings.
+func (h *Handler) HandleSessionOCR(w http.ResponseWriter, r *http.Request) {
+	session, ok := h.sessionToChange(w, r)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		ImageID  string `json:"image_id"`
+		Provider string `json:"provider"`
+		Model    string `json:"model"`
+	}
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			respondError(w, r, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+	}
+
+	idx := findImage(session, req.ImageID)
+	if idx < 0 {
+		respondError(w, r, http.StatusNotFound, "Image not found in session")
+		return
+	}
+
+	if !h.mayChooseProvider(r, session, req.Provider, req.Model) {
+		respondError(w, r, http.StatusForbidden, "Only admins can choose the provider or model")
+		return
+	}
+	provider := firstNonEmpty(req.Provider, session.Provider)
+	model := firstNonEmpty(req.Model, session.Model)
+
+	release, ok := h.acquireGeneration(w, r)
+	if !ok {
+		return
+	}
+	defer release()
+
+	if err := h.runOCR(r, session, idx, provider, model); err != nil {
+		slog.Error("OCR failed", "session", session.ID, "image", session.Images[idx].ID, "error", err)
+		respondError(w, r, http.StatusBadGateway, "OCR failed: %s", err)
+		return
+	}
+	h.sessionStore.Set(session.ID, session)
+
+	respondWithJSON(w, session.Images[idx], http.StatusOK)
+}
+
+// runOCR transcribes the session's image at idx, replacing its OCR text, and audits the run
+func (h *Handler) runOCR(r *http.Request, session *models.CatalogSession, idx int, provider, model string) error {
+	ctx, served := providers.WithServed(r.Context())
+	text, err := h.extractText(ctx, session.Images[idx], provider, model)
+	if err != nil {
+		return err
+	}
+	session.Images[idx].OCRText = text
+	details := map[string]string{"image_id": session.Images[idx].ID, "prompt_version": h.ocrService.PromptVersion()}
+	noteFallbacks(details, served)
+	h.audit(r, session.ID, models.AuditEvent{
+		Action:   models.ActionOCR,
+		Provider: provider,
+		Model:    model,
+		Details:  details,
+	})
+	return nil
+}
+
+// noteFallbacks adds the fallback providers that answered instead of the requested one, if
+// any, to an audit event's details
+func noteFallbacks(details map[string]string, served *providers.Served) {
+	if fallbacks := served.Fallbacks(); len(fallbacks) > 0 {
+		details["served_by"] = strings.Join(fallbacks, ",")
+	}
+}
+
+// HandleSessionOCRCorrection stores user-corrected OCR text for a session image and
+// records the correction diff. With "regenerate": true the MARC is regenerated from the
+// corrected text in the same request.
+//
+// Request body: {"image_id": "...", "ocr_text": "...", "regenerate": true, "provider": "...", "model": "..."}
+func (h *Handler) HandleSessionOCRCorrection(w http.ResponseWriter, r *http.Request) {
+	session, ok := h.sessionToChange(w, r)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		ImageID    string `json:"image_id"`
+		OCRText    string `json:"ocr_text"`
+		Regenerate bool   `json:"regenerate"`
+		Provider   string `json:"provider"`
+		Model      string `json:"model"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, r, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	idx := findImage(session, req.ImageID)
+	if idx < 0 {
+		respondError(w, r, http.StatusNotFound, "Image not found in session")
+		return
+	}
+	if req.Regenerate && !h.mayChooseProvider(r, session, req.Provider, req.Model) {
+		respondError(w, r, http.StatusForbidden, "Only admins can choose the provider or model")
+		return
+	}
+	if req.Regenerate && !session.CanGenerate() {
+		respondError(w, r, http.StatusConflict, "A record cannot be generated for a session that is %s", session.Status)
+		return
+	}
+
+	h.correctOCR(r, session, idx, req.OCRText)
+
+	if req.Regenerate {
+		release, ok := h.acquireGeneration(w, r)
+		if !ok {
+			h.sessionStore.Set(session.ID, session)
+			return
+		}
+		defer release()
+		if err := h.generateMARC(r, session, req.Provider, req.Model, false, nil); err != nil {
+			h.sessionStore.Set(session.ID, session)
+			slog.Error("MARC generation failed", "session", session.ID, "error", err)
+			respondError(w, r, http.StatusBadGateway, "MARC generation failed: %s", err)
+			return
+		}
+	}
+
+	h.sessionStore.Set(session.ID, session)
+	respondWithJSON(w, session, http.StatusOK)
+}
+
+// correctOCR replaces the OCR text of the session's image at idx with a user's correction,
+// recording the diff on the session and in the audit log. Unchanged text is ignored.
+func (h *Handler) correctOCR(r *http.Request, session *models.CatalogSession, idx int, text string) {
+	image := &session.Images[idx]
+	if text == image.OCRText {
+		return
+	}
+	diff := textdiff.Words(image.OCRText, text)
+	deleted, inserted := textdiff.Changed(diff)
+	session.OCRCorrections = append(session.OCRCorrections, models.OCRCorrection{
+		ImageID:       image.ID,
+		Original:      image.OCRText,
+		Corrected:     text,
+		Diff:          diff,
+		WordsDeleted:  deleted,
+		WordsInserted: inserted,
+		CreatedAt:     time.Now(),
+	})
+	image.OCRText = text
+	h.audit(r, session.ID, models.AuditEvent{
+		Action: models.ActionOCREdit,
+		Details: map[string]string{
+			"image_id":       image.ID,
+			"words_deleted":  strconv.Itoa(deleted),
+			"words_inserted": strconv.Itoa(inserted),
+		},
+	})
+	slog.Info("Stored OCR correction", "session", session.ID, "image", image.ID, "deleted", deleted, "inserted", inserted)
+}
+
+// HandleSessionMARC generates MARC from the OCR text of all the session's images, running OCR
+// on any that have none yet. With "from_images" (default --marc-from-images) the images
+// themselves are sent to the model in one request instead.
+//
+// Request body (optional): {"provider": "...", "model": "...", "from_images": true}
+func (h *Handler) HandleSessionMARC(w http.ResponseWriter, r *http.Request) {
+	session, ok := h.sessionToChange(w, r)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		Provider   string `json:"provider"`
+		Model      string `json:"model"`
+		FromImages *bool  `json:"from_images"`
+	}
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			respondError(w, r, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+	}
+	if !h.mayChooseProvider(r, session, req.Provider, req.Model) {
+		respondError(w, r, http.StatusForbidden, "Only admins can choose the provider or model")
+		return
+	}
+	if !session.CanGenerate() {
+		respondError(w, r, http.StatusConflict, "A record cannot be generated for a session that is %s", session.Status)
+		return
+	}
+
+	release, ok := h.acquireGeneration(w, r)
+	if !ok {
+		return
+	}
+	defer release()
+
+	if err := h.generateMARC(r, session, req.Provider, req.Model, h.fromImages(req.FromImages), nil); err != nil {
+		slog.Error("MARC generation failed", "session", session.ID, "error", err)
+		respondError(w, r, http.StatusBadGateway, "MARC generation failed: %s", err)
+		return
+	}
+
+	h.sessionStore.Set(session.ID, session)
+	respondWithJSON(w, session, http.StatusOK)
+}
+
+// HandleSessionMARCStream is HandleSessionMARC streaming the model's metadata response as
+// server-sent events while it is generated: "chunk" events carry {"text": "..."}, then a
+// "session" event carries the updated session, or an "error" event {"error": "..."}
+//
+// Request body (optional): {"provider": "...", "model": "...", "from_images": true}
+func (h *Handler) HandleSessionMARCStream(w http.ResponseWriter, r *http.Request) {
+	session, ok := h.sessionToChange(w, r)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		Provider   string `json:"provider"`
+		Model      string `json:"model"`
+		FromImages *bool  `json:"from_images"`
+	}
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			respondError(w, r, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+	}
+	if !h.mayChooseProvider(r, session, req.Provider, req.Model) {
+		respondError(w, r, http.StatusForbidden, "Only admins can choose the provider or model")
+		return
+	}
+	if !session.CanGenerate() {
+		respondError(w, r, http.StatusConflict, "A record cannot be generated for a session that is %s", session.Status)
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		respondError(w, r, http.StatusInternalServerError, "Streaming not supported")
+		return
+	}
+
+	release, ok := h.acquireGeneration(w, r)
+	if !ok {
+		return
+	}
+	defer release()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	send := func(event string, data any) {
+		payload, err := json.Marshal(data)
+		if err != nil {
+			slog.Error("Failed to encode stream event", "event", event, "error", err)
+			return
+		}
+		fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, payload)
+		flusher.Flush()
+	}
+
+	err := h.generateMARC(r, session, req.Provider, req.Model, h.fromImages(req.FromImages), func(chunk string) {
+		send("chunk", map[string]string{"text": chunk})
+	})
+	if err != nil {
+		slog.Error("MARC generation failed", "session", session.ID, "error", err)
+		send("error", map[string]string{"error": i18n.Sprintf(i18n.Lang(r), "MARC generation failed: %s", err)})
+		return
+	}
+
+	h.sessionStore.Set(session.ID, session)
+	send("session", session)
+}
+
+// fromImages resolves a request's choice of generating MARC from images, defaulting to the
+// server's setting
+func (h *Handler) fromImages(requested *bool) bool {
+	if requested != nil {
+		return *requested
+	}
+	return h.marcFromImages
+}
+
+// generateMARC regenerates the session's MARC, which the caller has checked it CanGenerate,
+// from the images themselves in one request when fromImages is set, or else from the OCR text
+// of all its images (see pagesFromOCR). The metadata response is streamed to onChunk when it
+// is not nil; generation from images sends it in one chunk.
+func (h *Handler) generateMARC(r *http.Request, session *models.CatalogSession, provider, model string, fromImages bool, onChunk func(string)) error {
+	if len(session.Images) == 0 {
+		return fmt.Errorf("session has no images")
+	}
+
+	provider = firstNonEmpty(provider, session.Provider)
+	model = firstNonEmpty(model, session.Model)
+
+	var (
+		rec           *marc.Record
+		notes         cataloging.GenerationNotes
+		err           error
+		promptVersion string
+	)
+	ctx, served := providers.WithServed(r.Context())
+	if fromImages {
+		pages := make([]cataloging.PageImage, len(session.Images))
+		for i, img := range session.Images {
+			data, err := h.uploads.Get(r.Context(), filepath.Base(img.ImagePath))
+			if err != nil {
+				return fmt.Errorf("failed to read image %s: %w", img.ID, err)
+			}
+			pages[i] = cataloging.PageImage{Type: img.ImageType, Path: img.ImagePath, Data: data}
+		}
+		rec, notes, err = h.catalogService.GenerateMARCFromImages(ctx, pages, "", provider, model)
+		if err == nil && onChunk != nil {
+			onChunk(notes.Response)
+		}
+		promptVersion = h.catalogService.ImagesPromptVersion()
+	} else {
+		var pages cataloging.OCRPages
+		if pages, err = h.pagesFromOCR(r, session, provider, model); err != nil {
+			return err
+		}
+		rec, notes, err = h.catalogService.StreamMARCFromPages(ctx, pages, provider, model, onChunk)
+		promptVersion = h.catalogService.PromptVersion()
+	}
+	if err != nil {
+		return err
+	}
+
+	switch h.holdings.Format {
+	case holdings.FormatMARC:
+		holdings.AddMARC852(rec, h.holdings)
+	case holdings.FormatFOLIO:
+		scaffold := holdings.FOLIO(rec, h.holdings)
+		session.Holdings = &scaffold
+	}
+
+	data, err := rec.XML()
+	if err != nil {
+		return err
+	}
+	session.MARC = string(data)
+	session.PromptVersion = promptVersion
+	session.Status = models.StatusGenerated
+	details := map[string]string{"holdings": h.holdings.Format, "prompt_version": session.PromptVersion}
+	if fromImages {
+		details["mode"] = "images"
+	}
+	noteFallbacks(details, served)
+	if len(notes.CopyrightTags) > 0 {
+		details["copyright_page_tags"] = strings.Join(notes.CopyrightTags, ",")
+	}
+	if len(notes.Warnings) > 0 {
+		codes := make([]string, len(notes.Warnings))
+		for i, w := range notes.Warnings {
+			codes[i] = w.Code
+		}
+		details["warnings"] = strings.Join(codes, ",")
+	}
+	h.audit(r, session.ID, models.AuditEvent{
+		Action:   models.ActionGenerate,
+		Provider: provider,
+		Model:    model,
+		Details:  details,
+	})
+	return nil
+}
+
+// pagesFromOCR collects the OCR text of all the session's images, running OCR first on images
+// that have none. Text is ordered title page, copyright page, cover.
+func (h *Handler) pagesFromOCR(r *http.Request, session *models.CatalogSession, provider, model string) (cataloging.OCRPages, error) {
+	var pages cataloging.OCRPages
+	for i, img := range session.Images {
+		if strings.TrimSpace(img.OCRText) != "" {
+			continue
+		}
+		if err := h.runOCR(r, session, i, provider, model); err != nil {
+			return pages, fmt.Errorf("OCR of image %s failed: %w", img.ID, err)
+		}
+	}
+
+	images := slices.Clone(session.Images)
+	slices.SortStableFunc(images, func(a, b models.ImageItem) int {
+		return models.ImageTypeOrder[a.ImageType] - models.ImageTypeOrder[b.ImageType]
+	})
+	var texts []string
+	for _, img := range images {
+		if strings.TrimSpace(img.OCRText) == "" {
+			continue
+		}
+		texts = append(texts, img.OCRText)
+		if img.ImageType == "copyright" && pages.CopyrightPage == "" {
+			pages.CopyrightPage = img.OCRText
+		}
+	}
+	if len(texts) == 0 {
+		return pages, fmt.Errorf("no text found in the session's images")
+	}
+	pages.Text = strings.Join(texts, "\n\n")
+
+	return pages, nil
+}
+
+// HandleSessionHistory returns a session's audit log
+func (h *Handler) HandleSessionHistory(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if _, ok := h.sessionStore.Get(id); !ok {
+		respondError(w, r, http.StatusNotFound, "Session not found")
+		return
+	}
+
+	respondWithJSON(w, map[string]any{"events": h.sessionStore.History(id)}, http.StatusOK)
+}
+
+// HandleSessionStatus moves a session to another status, such as in_review or approved, when
+// its current status allows it (see models.NextStatuses)
+//
+// Request body: {"status": "approved", "note": "..."}
+func (h *Handler) HandleSessionStatus(w http.ResponseWriter, r *http.Request) {
+	session, ok := h.sessionStore.Get(r.PathValue("id"))
+	if !ok {
+		respondError(w, r, http.StatusNotFound, "Session not found")
+		return
+	}
+
+	var req struct {
+		Status string `json:"status"`
+		Note   string `json:"note"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, r, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if status, format, args := statusFailure(session, req.Status, h.role(r)); status != 0 {
+		respondError(w, r, status, format, args...)
+		return
+	}
+	h.setStatus(r, session, req.Status, req.Note)
+	respondWithJSON(w, session, http.StatusOK)
+}
+
+// statusFailure returns the HTTP status and message of a refused move to status by a user with
+// role, or 0 when the session may be moved there
+func statusFailure(session *models.CatalogSession, status string, role roles.Role) (int, string, []any) {
+	switch {
+	case !slices.Contains(models.Statuses, status):
+		return http.StatusBadRequest, "Unknown status: %s", []any{status}
+	case !models.CanTransition(session.Status, status):
+		return http.StatusConflict, "Cannot move a session from %s to %s", []any{session.Status, status}
+	case role < statusRole(session.Status, status):
+		return http.StatusForbidden, "Moving a session to %s requires the %s role", []any{status, statusRole(session.Status, status)}
+	}
+	return 0, "", nil
+}
+
+// setStatus moves the session to status, recording who did and why in its audit log
+func (h *Handler) setStatus(r *http.Request, session *models.CatalogSession, status, note string) {
+	details := map[string]string{"from": session.Status, "to": status}
+	if note = strings.TrimSpace(note); note != "" {
+		details["note"] = note
+	}
+	session.Status = status
+	h.sessionStore.Set(session.ID, session)
+	h.audit(r, session.ID, models.AuditEvent{Action: models.ActionStatus, Details: details})
+	slog.Info("Changed session status", "session", session.ID, "from", details["from"], "to", status)
+}
+
+// audit appends an event to the session's audit log, attributed to the request's user
+func (h *Handler) audit(r *http.Request, sessionID string, event models.AuditEvent) {
+	event.Time = time.Now()
+	event.Actor = h.actor(r)
+	h.sessionStore.AppendEvent(sessionID, event)
+}
+
+// actor returns the request's user for audit logs. The user comes from the X-Remote-User
+// header set by an authenticating proxy, which with roles must be a trusted one.
+func (h *Handler) actor(r *http.Request) string {
+	user := roles.User(r)
+	if h.roles != nil {
+		user = h.roles.User(r)
+	}
+	if user != "" {
+		return user
+	}
+	return "anonymous"
+}
+
+// findImage returns the index of the image with the given ID. With no ID it prefers
+// the title page, falling back to the first image. Returns -1 when not found.
+func findImage(session *models.CatalogSession, imageID string) int {
+	if imageID == "" {
+		for i, img := range session.Images {
+			if img.ImageType == "title_page" {
+				return i
+			}
+		}
+		if len(session.Images) > 0 {
+			return 0
+		}
+		return -1
+	}
+
+	for i, img := range session.Images {
+		if img.ID == imageID {
+			return i
+		}
+	}
+	return -1
+}
+
+// imageExtensions maps the image types accepted as uploads, as sniffed from their content, to
+// the extension they are stored under
+var imageExtensions = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+	"image/gif":  ".gif",
+	"image/webp": ".webp",
+}
+
+// errUnsupportedImage is returned for an upload that isn't one of imageExtensions
+var errUnsupportedImage = errors.New("not a JPEG, PNG, GIF or WebP image")
+
+// uploadName names image data in the uploads store by content hash and sniffed type. The
+// client's filename is never used, so /uploads only serves images.
+func uploadName(data []byte) (id, name string, err error) {
+	ext, ok := imageExtensions[http.DetectContentType(data)]
+	if !ok {
+		return "", "", errUnsupportedImage
+	}
+	id = utils.CalculateDataMD5(data)
+	return id, id + ext, nil
+}
+
+// saveUpload writes an uploaded image to the uploads store (see uploadName)
+func (h *Handler) saveUpload(ctx context.Context, file io.Reader, imageType string) (*models.ImageItem, error) {
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read upload: %w", err)
+	}
+
+	id, name, err := uploadName(data)
+	if err != nil {
+		return nil, err
+	}
+	path, err := h.uploads.Save(ctx, name, data)
+	if err != nil {
+		return nil, err
+	}
+
+	local, release, err := h.uploads.LocalFile(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	width, height := utils.GetImageDimensions(local)
+
+	return &models.ImageItem{
+		ID:          id,
+		ImagePath:   path,
+		ImageURL:    "/uploads/" + name,
+		ImageType:   imageType,
+		ImageWidth:  width,
+		ImageHeight: height,
+	}, nil
+}
+
+// extractText runs OCR on a session image, fetching it from the uploads store
+func (h *Handler) extractText(ctx context.Context, img models.ImageItem, provider, model string) (string, error) {
+	path, release, err := h.uploads.LocalFile(ctx, filepath.Base(img.ImagePath))
+	if err != nil {
+		return "", err
+	}
+	defer release()
+	return h.ocrService.ExtractTextFromImage(ctx, path, provider, model)
+}
+
+// HandleUpload serves an uploaded image from the uploads store
+func (h *Handler) HandleUpload(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("name")
+	obj, err := h.uploads.Stat(r.Context(), name)
+	if err != nil {
+		if errors.Is(err, objectstore.ErrNotFound) {
+			http.NotFound(w, r)
+			return
+		}
+		slog.Error("Failed to read upload", "name", name, "error", err)
+		respondError(w, r, http.StatusInternalServerError, "Failed to read image")
+		return
+	}
+	data, err := h.uploads.Get(r.Context(), name)
+	if err != nil {
+		slog.Error("Failed to read upload", "name", name, "error", err)
+		respondError(w, r, http.StatusInternalServerError, "Failed to read image")
+		return
+	}
+
+	w.Header().Set("Content-Type", objectstore.ContentType(name))
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	http.ServeContent(w, r, name, obj.Modified, bytes.NewReader(data))
+}
+
+func newID() string {
+	b := make([]byte, 8)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
@@ -0,0 +1,277 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lehigh-university-libraries/cataloger/internal/models"
+	"github.com/lehigh-university-libraries/cataloger/internal/roles"
+	"github.com/lehigh-university-libraries/cataloger/internal/storage"
+	"github.com/lehigh-university-libraries/cataloger/internal/uploads"
+)
+
+const (
+	titlePageText = "THE HISTORY OF BRIDGES\nby Jane Smith\nNew York\nAcme Press\n1999"
+	storedRecord  = `<record xmlns="http://www.loc.gov/MARC21/slim"><leader>00000nam a2200000 i 4500</leader><datafield tag="245" ind1="1" ind2="0"><subfield code="a">Stored title</subfield></datafield></record>`
+)
+
+func newTestHandler(t *testing.T) (*Handler, http.Handler) {
+	t.Helper()
+	h := New(storage.New(), uploads.NewLocal(t.TempDir()))
+	return h, h.Routes()
+}
+
+// testPNG returns a small PNG image
+func testPNG(t *testing.T) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewGray(image.Rect(0, 0, 4, 6))); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// addSession stores a session with a transcribed title page, the mock provider and status,
+// and a record unless it is uploaded
+func addSession(t *testing.T, h *Handler, id, status string) {
+	t.Helper()
+	data := testPNG(t)
+	imageID, name, err := uploadName(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path, err := h.uploads.Save(context.Background(), name, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	session := &models.CatalogSession{
+		ID:       id,
+		Provider: "mock",
+		Status:   status,
+		Images:   []models.ImageItem{{ID: imageID, ImagePath: path, ImageURL: "/uploads/" + name, ImageType: "title_page", OCRText: titlePageText}},
+	}
+	if status != models.StatusUploaded {
+		session.MARC = storedRecord
+	}
+	h.sessionStore.Set(id, session)
+}
+
+func serve(routes http.Handler, method, target, contentType, body string) *httptest.ResponseRecorder {
+	return serveAs(routes, "", method, target, contentType, body)
+}
+
+// serveAs is serve for a user signed in by the proxy, which runs on the same host
+func serveAs(routes http.Handler, user, method, target, contentType, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	if user != "" {
+		req.RemoteAddr = "127.0.0.1:40000"
+		req.Header.Set(roles.UserHeader, user)
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	w := httptest.NewRecorder()
+	routes.ServeHTTP(w, req)
+	return w
+}
+
+func TestGenerateByStatus(t *testing.T) {
+	tests := []struct {
+		status string
+		code   int
+	}{
+		{models.StatusUploaded, http.StatusOK},
+		{models.StatusGenerated, http.StatusOK},
+		{models.StatusInReview, http.StatusOK},
+		{models.StatusApproved, http.StatusConflict},
+		{models.StatusPushed, http.StatusConflict},
+		{models.StatusRejected, http.StatusConflict},
+	}
+	for _, tt := range tests {
+		t.Run(tt.status, func(t *testing.T) {
+			h, routes := newTestHandler(t)
+			addSession(t, h, "s", tt.status)
+
+			w := serve(routes, http.MethodPost, "/api/sessions/s/marc", "", "")
+			if w.Code != tt.code {
+				t.Fatalf("POST marc = %d %s, want %d", w.Code, w.Body, tt.code)
+			}
+			session, _ := h.sessionStore.Get("s")
+			if tt.code != http.StatusOK {
+				if session.Status != tt.status || (tt.status != models.StatusUploaded && session.MARC != storedRecord) {
+					t.Errorf("refused generation changed the session: %+v", session)
+				}
+				return
+			}
+			if session.Status != models.StatusGenerated || session.MARC == "" || session.MARC == storedRecord {
+				t.Errorf("generated session = %+v", session)
+			}
+		})
+	}
+}
+
+func TestSessionStatus(t *testing.T) {
+	tests := []struct {
+		from, to string
+		code     int
+	}{
+		{models.StatusGenerated, models.StatusInReview, http.StatusOK},
+		{models.StatusInReview, models.StatusApproved, http.StatusOK},
+		{models.StatusApproved, models.StatusInReview, http.StatusOK},
+		{models.StatusApproved, models.StatusPushed, http.StatusOK},
+		{models.StatusRejected, models.StatusInReview, http.StatusOK},
+		{models.StatusGenerated, models.StatusApproved, http.StatusConflict},
+		{models.StatusUploaded, models.StatusGenerated, http.StatusConflict},
+		{models.StatusRejected, models.StatusApproved, http.StatusConflict},
+		{models.StatusPushed, models.StatusInReview, http.StatusConflict},
+		{models.StatusGenerated, "done", http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.from+" to "+tt.to, func(t *testing.T) {
+			h, routes := newTestHandler(t)
+			addSession(t, h, "s", tt.from)
+
+			w := serve(routes, http.MethodPut, "/api/sessions/s/status", "application/json", `{"status": "`+tt.to+`", "note": "checked"}`)
+			if w.Code != tt.code {
+				t.Fatalf("PUT status = %d %s, want %d", w.Code, w.Body, tt.code)
+			}
+			session, _ := h.sessionStore.Get("s")
+			history := h.sessionStore.History("s")
+			if tt.code != http.StatusOK {
+				if session.Status != tt.from || len(history) != 0 {
+					t.Errorf("refused move changed the session: %+v, history %+v", session, history)
+				}
+				return
+			}
+			if session.Status != tt.to || len(history) != 1 || history[0].Details["from"] != tt.from || history[0].Details["note"] != "checked" {
+				t.Errorf("session = %+v, history %+v", session, history)
+			}
+		})
+	}
+}
+
+func TestLockedSessionRefusesChanges(t *testing.T) {
+	for _, status := range []string{models.StatusApproved, models.StatusPushed} {
+		t.Run(status, func(t *testing.T) {
+			h, routes := newTestHandler(t)
+			addSession(t, h, "s", status)
+
+			requests := []struct{ method, target, body string }{
+				{http.MethodPut, "/api/sessions/s/ocr", `{"ocr_text": "edited"}`},
+				{http.MethodPut, "/api/sessions/s/ocr", `{"ocr_text": "edited", "regenerate": true}`},
+				{http.MethodPost, "/api/sessions/s/ocr", `{}`},
+				{http.MethodPost, "/api/sessions/s/marc", ""},
+				{http.MethodPost, "/api/sessions/s/marc/stream", ""},
+			}
+			for _, req := range requests {
+				if w := serve(routes, req.method, req.target, "application/json", req.body); w.Code != http.StatusConflict {
+					t.Errorf("%s %s = %d %s, want 409", req.method, req.target, w.Code, w.Body)
+				}
+			}
+			session, _ := h.sessionStore.Get("s")
+			if session.Images[0].OCRText != titlePageText || session.MARC != storedRecord || session.Status != status || len(h.sessionStore.History("s")) != 0 {
+				t.Errorf("locked session changed: %+v", session)
+			}
+		})
+	}
+}
+
+func TestStatusChangeWaitsForGeneration(t *testing.T) {
+	h, routes := newTestHandler(t)
+	addSession(t, h, "s", models.StatusInReview)
+
+	// Stand in for a generation in progress, which holds the session until it is stored
+	unlock := h.sessionStore.Lock("s")
+	done := make(chan *httptest.ResponseRecorder)
+	go func() {
+		done <- serve(routes, http.MethodPut, "/api/sessions/s/status", "application/json", `{"status": "approved"}`)
+	}()
+	select {
+	case w := <-done:
+		t.Fatalf("status changed during generation: %d %s", w.Code, w.Body)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	session, _ := h.sessionStore.Get("s")
+	session.Status = models.StatusGenerated
+	session.MARC = strings.Replace(storedRecord, "Stored title", "New title", 1)
+	h.sessionStore.Set("s", session)
+	unlock()
+
+	// The approval was of the record under review, so it doesn't apply to the new one
+	if w := <-done; w.Code != http.StatusConflict {
+		t.Errorf("PUT status after generation = %d %s, want 409", w.Code, w.Body)
+	}
+	if session, _ := h.sessionStore.Get("s"); session.Status != models.StatusGenerated {
+		t.Errorf("status = %s, want generated", session.Status)
+	}
+}
+
+func TestReadRoutesRoles(t *testing.T) {
+	h, routes := newTestHandler(t)
+	resolver, err := roles.New(roles.Config{Users: map[string]string{"jdoe": "cataloger"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	h.SetRoles(resolver)
+	addSession(t, h, "s", models.StatusApproved)
+
+	tests := []struct {
+		target, user string
+		code         int
+	}{
+		{"/api/sessions/s", "", http.StatusOK},
+		{"/api/sessions/s/marcxml", "", http.StatusOK},
+		{"/api/sessions/s/history", "", http.StatusOK},
+		{"/api/sessions/s/bundle", "", http.StatusForbidden},
+		{"/api/sessions/s/bundle", "jdoe", http.StatusOK},
+	}
+	for _, tt := range tests {
+		if w := serveAs(routes, tt.user, http.MethodGet, tt.target, "", ""); w.Code != tt.code {
+			t.Errorf("GET %s as %q = %d, want %d", tt.target, tt.user, w.Code, tt.code)
+		}
+	}
+	if history := h.sessionStore.History("s"); len(history) != 1 || history[0].Action != models.ActionExport || history[0].Actor != "jdoe" {
+		t.Errorf("history = %+v, want one export by jdoe", history)
+	}
+}
+
+func TestForgedUserHeader(t *testing.T) {
+	h, routes := newTestHandler(t)
+	resolver, err := roles.New(roles.Config{Default: "cataloger", Users: map[string]string{"boss": "admin"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	h.SetRoles(resolver)
+	addSession(t, h, "s", models.StatusGenerated)
+
+	// Sent straight to the server rather than through the proxy, so the header is ignored
+	direct := func(method, target, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, strings.NewReader(body))
+		req.RemoteAddr = "192.0.2.1:5000"
+		req.Header.Set(roles.UserHeader, "boss")
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		routes.ServeHTTP(w, req)
+		return w
+	}
+	if w := direct(http.MethodPost, "/api/batches", ""); w.Code != http.StatusForbidden {
+		t.Errorf("POST /api/batches with a forged admin = %d, want 403", w.Code)
+	}
+	if w := direct(http.MethodPost, "/api/sessions/s/marc", `{"provider": "mock", "model": "other"}`); w.Code != http.StatusForbidden {
+		t.Errorf("choosing the model with a forged admin = %d, want 403", w.Code)
+	}
+	if w := direct(http.MethodPut, "/api/sessions/s/status", `{"status": "in_review"}`); w.Code != http.StatusOK {
+		t.Fatalf("PUT status with the default role = %d, want 200", w.Code)
+	}
+	if history := h.sessionStore.History("s"); len(history) != 1 || history[0].Actor != "anonymous" {
+		t.Errorf("history = %+v, want the move attributed to anonymous", history)
+	}
+}
@@ -0,0 +1,55 @@
+package handlers
+
+import (
+	"log/slog"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/lehigh-university-libraries/cataloger/internal/sru"
+)
+
+// HandleSRU answers SRU explain and searchRetrieve requests over the sessions' records, so
+// library systems can look records up by ISBN or title and retrieve them as MARCXML. Errors
+// are SRU diagnostics in the response, as the protocol has them.
+func (h *Handler) HandleSRU(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/xml; charset=utf-8")
+	req, err := sru.ParseRequest(r.URL.Query())
+	if err != nil {
+		err = sru.WriteDiagnostic(w, err)
+	} else if req.Operation == sru.OperationExplain {
+		err = sru.WriteExplain(w, explainInfo(r))
+	} else {
+		var records []string
+		for _, entry := range h.sessionStore.Search(req.Query) {
+			if session, ok := h.sessionStore.Get(entry.ID); ok && session.MARC != "" {
+				records = append(records, session.MARC)
+			}
+		}
+		err = sru.WriteSearchRetrieve(w, req, records)
+	}
+	if err != nil {
+		slog.Error("Failed to write SRU response", "error", err)
+	}
+}
+
+// explainInfo describes the endpoint as the request reached it
+func explainInfo(r *http.Request) sru.Explain {
+	e := sru.Explain{
+		Host:     r.Host,
+		Port:     80,
+		Database: strings.TrimPrefix(r.URL.Path, "/"),
+		Title:    "Cataloger records",
+	}
+	if r.TLS != nil {
+		e.Port = 443
+	}
+	if host, port, err := net.SplitHostPort(r.Host); err == nil {
+		e.Host = host
+		if p, err := strconv.Atoi(port); err == nil {
+			e.Port = p
+		}
+	}
+	return e
+}
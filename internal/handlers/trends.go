@@ -0,0 +1,125 @@
+package handlers
+
+import (
+	"fmt"
+	"html/template"
+	"log/slog"
+	"net/http"
+
+	"github.com/lehigh-university-libraries/cataloger/internal/eval/history"
+	"github.com/lehigh-university-libraries/cataloger/internal/i18n"
+)
+
+// SetEvalHistory serves the trends of scheduled evaluations (eval daemon) kept in dir
+func (h *Handler) SetEvalHistory(dir string) {
+	h.evalHistory = dir
+}
+
+// HandleEvalTrends returns each model's scheduled evaluation runs, oldest first
+func (h *Handler) HandleEvalTrends(w http.ResponseWriter, r *http.Request) {
+	trends, ok := h.loadTrends(w, r)
+	if !ok {
+		return
+	}
+	respondWithJSON(w, map[string]any{"models": trends}, http.StatusOK)
+}
+
+// trendRow is one model's line in the trends page
+type trendRow struct {
+	Provider, Model string
+	Runs            int
+	Latest          history.Entry
+	HasLatest       bool
+	Change          float64 // Mean score change from the previous finished run
+	Drift           float64 // Mean score change from the first finished run
+	LastError       string
+	Scores          []float64 // Mean scores of finished runs, oldest first
+}
+
+// trendsPageData is the trends page in the request's language
+type trendsPageData struct {
+	Lang string
+	Rows []trendRow
+}
+
+var trendsPage = template.Must(template.New("trends").Funcs(template.FuncMap{
+	"pct": func(f float64) string { return fmt.Sprintf("%.1f%%", f*100) },
+	"t":   i18n.Sprintf,
+}).Parse(`<!DOCTYPE html>
+<html lang="{{.Lang}}">
+<head>
+<meta charset="utf-8">
+<title>{{t .Lang "Evaluation trends"}}</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+table { border-collapse: collapse; }
+th, td { padding: 0.3em 0.8em; border-bottom: 1px solid #ddd; text-align: left; }
+.down { color: #b00; }
+.up { color: #070; }
+</style>
+</head>
+<body>
+<h1>{{t .Lang "Evaluation trends"}}</h1>
+{{if not .Rows}}<p>{{t .Lang "No scheduled evaluations yet."}}</p>{{else}}
+<table>
+<tr><th>{{t .Lang "Provider"}}</th><th>{{t .Lang "Model"}}</th><th>{{t .Lang "Runs"}}</th><th>{{t .Lang "Last run"}}</th><th>{{t .Lang "Mean score"}}</th><th>{{t .Lang "Change"}}</th><th>{{t .Lang "Drift"}}</th><th>{{t .Lang "Failed"}}</th><th>{{t .Lang "Scores"}}</th><th>{{t .Lang "Last error"}}</th></tr>
+{{range .Rows}}<tr>
+<td>{{.Provider}}</td><td>{{.Model}}</td><td>{{.Runs}}</td>
+{{if .HasLatest}}<td>{{.Latest.Time.Format "2006-01-02 15:04"}}</td><td>{{pct .Latest.MeanScore}}</td>
+<td class="{{if lt .Change 0.0}}down{{else if gt .Change 0.0}}up{{end}}">{{pct .Change}}</td>
+<td class="{{if lt .Drift 0.0}}down{{else if gt .Drift 0.0}}up{{end}}">{{pct .Drift}}</td>
+<td>{{.Latest.Failed}} / {{.Latest.Records}}</td>
+<td>{{range $i, $s := .Scores}}{{if $i}} {{end}}{{pct $s}}{{end}}</td>{{else}}<td colspan="6">{{t $.Lang "No finished runs"}}</td>{{end}}
+<td>{{.LastError}}</td>
+</tr>{{end}}
+</table>{{end}}
+</body>
+</html>
+`))
+
+// HandleEvalTrendsPage shows a table of each model's latest score and how it has moved
+func (h *Handler) HandleEvalTrendsPage(w http.ResponseWriter, r *http.Request) {
+	trends, ok := h.loadTrends(w, r)
+	if !ok {
+		return
+	}
+
+	rows := make([]trendRow, 0, len(trends))
+	for _, t := range trends {
+		row := trendRow{Provider: t.Provider, Model: t.Model, Runs: len(t.Runs), Drift: t.Drift()}
+		row.Latest, row.HasLatest = t.Latest()
+		for _, e := range t.Runs {
+			if e.Error != "" {
+				row.LastError = e.Error
+				continue
+			}
+			row.LastError = ""
+			row.Scores = append(row.Scores, e.MeanScore)
+		}
+		if n := len(row.Scores); n > 1 {
+			row.Change = row.Scores[n-1] - row.Scores[n-2]
+		}
+		rows = append(rows, row)
+	}
+
+	lang := i18n.Lang(r)
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Content-Language", lang)
+	if err := trendsPage.Execute(w, trendsPageData{Lang: lang, Rows: rows}); err != nil {
+		slog.Error("Failed to render trends", "error", err)
+	}
+}
+
+// loadTrends reads the eval history, responding with an error when it is not available
+func (h *Handler) loadTrends(w http.ResponseWriter, r *http.Request) ([]history.Trend, bool) {
+	if h.evalHistory == "" {
+		respondError(w, r, http.StatusNotFound, "Eval history is not configured")
+		return nil, false
+	}
+	entries, err := history.Load(h.evalHistory)
+	if err != nil {
+		respondError(w, r, http.StatusInternalServerError, "%s", err)
+		return nil, false
+	}
+	return history.Trends(entries), true
+}
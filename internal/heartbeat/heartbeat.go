@@ -0,0 +1,173 @@
+// Package heartbeat reports the progress of long runs at a fixed interval and warns when no
+// record has completed for a while, so a hung provider is noticed before the morning.
+package heartbeat
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/lehigh-university-libraries/cataloger/internal/objectstore"
+)
+
+// Defaults for Options
+const (
+	DefaultInterval   = time.Minute
+	DefaultStallAfter = 10 * time.Minute
+)
+
+// Options configures a Monitor
+type Options struct {
+	Interval   time.Duration // Between heartbeats; 0 disables them
+	StallAfter time.Duration // Without a completed record before warning; 0 disables the warning
+	File       string        // Rewritten with the Status as JSON at each heartbeat, when set
+}
+
+// Status is a run's progress at a heartbeat
+type Status struct {
+	Time           time.Time
+	Started        time.Time
+	Total          int
+	Done           int
+	Failed         int
+	InFlight       int
+	LastCompletion time.Time `json:",omitzero"` // Zero until a record completes
+	Stalled        bool      // No record completed within StallAfter
+}
+
+// Monitor counts records as they start and end and reports them at each heartbeat. Its
+// methods are safe for concurrent use.
+type Monitor struct {
+	opts    Options
+	total   int
+	started time.Time
+	now     func() time.Time
+
+	done, failed, inFlight atomic.Int64
+	last                   atomic.Int64 // UnixNano of the last completion, or of the start
+
+	mu      sync.Mutex
+	stalled bool
+	stop    chan struct{}
+	wg      sync.WaitGroup
+}
+
+// Start begins monitoring a run of total records
+func Start(opts Options, total int) *Monitor {
+	m := newMonitor(opts, total, time.Now)
+	if opts.Interval > 0 {
+		m.wg.Add(1)
+		go func() {
+			defer m.wg.Done()
+			ticker := time.NewTicker(opts.Interval)
+			defer ticker.Stop()
+			for {
+				select {
+				case <-ticker.C:
+					m.beat()
+				case <-m.stop:
+					return
+				}
+			}
+		}()
+	}
+	return m
+}
+
+func newMonitor(opts Options, total int, now func() time.Time) *Monitor {
+	m := &Monitor{opts: opts, total: total, started: now(), now: now, stop: make(chan struct{})}
+	m.last.Store(m.started.UnixNano())
+	return m
+}
+
+// Begin counts a record starting
+func (m *Monitor) Begin() {
+	m.inFlight.Add(1)
+}
+
+// End counts a record completing, failed or not
+func (m *Monitor) End(failed bool) {
+	m.inFlight.Add(-1)
+	m.done.Add(1)
+	if failed {
+		m.failed.Add(1)
+	}
+	m.last.Store(m.now().UnixNano())
+}
+
+// Requeue counts a record that stopped without completing, to be started again later
+func (m *Monitor) Requeue() {
+	m.inFlight.Add(-1)
+}
+
+// Stop ends the heartbeats, leaving the final status in the heartbeat file
+func (m *Monitor) Stop() {
+	close(m.stop)
+	m.wg.Wait()
+	m.writeFile(m.Status())
+}
+
+// Status returns the run's progress now
+func (m *Monitor) Status() Status {
+	now := m.now()
+	s := Status{
+		Time:     now,
+		Started:  m.started,
+		Total:    m.total,
+		Done:     int(m.done.Load()),
+		Failed:   int(m.failed.Load()),
+		InFlight: int(m.inFlight.Load()),
+	}
+	last := time.Unix(0, m.last.Load())
+	if s.Done > 0 {
+		s.LastCompletion = last
+	}
+	s.Stalled = m.opts.StallAfter > 0 && s.Done < s.Total && now.Sub(last) >= m.opts.StallAfter
+	return s
+}
+
+// beat logs the status, warning while the run is stalled, and writes the status file
+func (m *Monitor) beat() Status {
+	s := m.Status()
+	since := s.Time.Sub(time.Unix(0, m.last.Load())).Round(time.Second)
+	slog.Info("Heartbeat", "done", s.Done, "total", s.Total, "failed", s.Failed, "in_flight", s.InFlight, "since_last_completion", since)
+
+	m.mu.Lock()
+	began := s.Stalled && !m.stalled
+	ended := !s.Stalled && m.stalled
+	m.stalled = s.Stalled
+	m.mu.Unlock()
+	switch {
+	case began:
+		fmt.Fprintf(os.Stderr, "\n%s\nSTALLED: no record has completed for %s (%d in flight); the provider may be hung\n%s\n\n",
+			strings.Repeat("!", 70), since, s.InFlight, strings.Repeat("!", 70))
+		fallthrough
+	case s.Stalled:
+		slog.Error("No record has completed recently; the provider may be hung", "since_last_completion", since, "in_flight", s.InFlight)
+	case ended:
+		slog.Info("Records are completing again")
+	}
+
+	m.writeFile(s)
+	return s
+}
+
+// writeFile writes the status to the heartbeat file, when there is one
+func (m *Monitor) writeFile(s Status) {
+	if m.opts.File == "" {
+		return
+	}
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err == nil {
+		err = objectstore.WriteFile(context.Background(), m.opts.File, data)
+	}
+	if err != nil {
+		slog.Warn("Failed to write heartbeat file", "file", m.opts.File, "error", err)
+	}
+}
@@ -0,0 +1,49 @@
+package heartbeat
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestMonitor(t *testing.T) {
+	now := time.Date(2025, 3, 1, 2, 0, 0, 0, time.UTC)
+	file := filepath.Join(t.TempDir(), "heartbeat.json")
+	m := newMonitor(Options{StallAfter: 10 * time.Minute, File: file}, 3, func() time.Time { return now })
+
+	m.Begin()
+	m.Begin()
+	now = now.Add(time.Minute)
+	m.End(false)
+	s := m.beat()
+	if s.Done != 1 || s.InFlight != 1 || s.Stalled || !s.LastCompletion.Equal(now) {
+		t.Errorf("status = %+v", s)
+	}
+
+	now = now.Add(15 * time.Minute)
+	if s := m.beat(); !s.Stalled {
+		t.Error("no completion for 15 minutes is not a stall")
+	}
+	var written Status
+	data, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(data, &written); err != nil || !written.Stalled || written.Total != 3 {
+		t.Errorf("heartbeat file = %s", data)
+	}
+
+	m.End(true)
+	if s := m.beat(); s.Stalled || s.Failed != 1 {
+		t.Errorf("status after a completion = %+v", s)
+	}
+
+	m.Begin()
+	m.End(false)
+	now = now.Add(time.Hour)
+	if s := m.Status(); s.Stalled {
+		t.Error("a finished run is not stalled")
+	}
+}
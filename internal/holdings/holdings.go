@@ -0,0 +1,180 @@
+package holdings
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/lehigh-university-libraries/cataloger/internal/marc"
+)
+
+// Output formats for scaffolded holdings
+const (
+	FormatNone  = ""
+	FormatMARC  = "marc"  // MARC 852 added to the bib record
+	FormatFOLIO = "folio" // FOLIO holdings + item JSON
+)
+
+// Defaults are the local values used to scaffold holdings for newly cataloged material
+type Defaults struct {
+	Format           string
+	Institution      string // 852 $a
+	Location         string // 852 $b, or FOLIO permanent location ID
+	ShelvingLocation string // 852 $c
+	HoldingsType     string // FOLIO holdings type ID
+	LoanType         string // FOLIO permanent loan type ID
+	MaterialType     string // FOLIO material type ID
+	ItemStatus       string // FOLIO item status, "In process" by default
+}
+
+// DefaultsFromEnv reads holdings defaults from HOLDINGS_* environment variables.
+// Scaffolding is disabled unless HOLDINGS_FORMAT is set.
+func DefaultsFromEnv() (Defaults, error) {
+	d := Defaults{
+		Format:           strings.ToLower(os.Getenv("HOLDINGS_FORMAT")),
+		Institution:      os.Getenv("HOLDINGS_INSTITUTION"),
+		Location:         os.Getenv("HOLDINGS_LOCATION"),
+		ShelvingLocation: os.Getenv("HOLDINGS_SHELVING_LOCATION"),
+		HoldingsType:     os.Getenv("HOLDINGS_TYPE"),
+		LoanType:         os.Getenv("HOLDINGS_LOAN_TYPE"),
+		MaterialType:     os.Getenv("HOLDINGS_MATERIAL_TYPE"),
+		ItemStatus:       os.Getenv("HOLDINGS_ITEM_STATUS"),
+	}
+	if d.ItemStatus == "" {
+		d.ItemStatus = "In process"
+	}
+
+	switch d.Format {
+	case FormatNone, FormatMARC, FormatFOLIO:
+		return d, nil
+	default:
+		return d, fmt.Errorf("unsupported HOLDINGS_FORMAT %q (use marc or folio)", d.Format)
+	}
+}
+
+// Enabled reports whether holdings should be scaffolded
+func (d Defaults) Enabled() bool {
+	return d.Format != FormatNone
+}
+
+// Call number classification schemes, as used in 852 first indicator
+const (
+	SchemeLC    = "lc"
+	SchemeDewey = "dewey"
+)
+
+// FOLIO reference call number type IDs
+const (
+	folioLCType  = "95467209-6d7b-468b-94df-0f5d7ad2747d"
+	folioDDCType = "03dd64d0-5626-4ecd-8ece-4531e0069f35"
+)
+
+// CallNumber is a call number split into classification and item parts
+type CallNumber struct {
+	Scheme string
+	Class  string
+	Item   string
+}
+
+// String joins the classification and item parts
+func (c CallNumber) String() string {
+	return strings.TrimSpace(c.Class + " " + c.Item)
+}
+
+// CallNumberFromRecord takes the call number from 050, then 090 (local LC), then 082
+func CallNumberFromRecord(rec *marc.Record) CallNumber {
+	for _, src := range []struct{ tag, scheme string }{
+		{"050", SchemeLC},
+		{"090", SchemeLC},
+		{"082", SchemeDewey},
+	} {
+		for _, f := range rec.Fields(src.tag) {
+			if class := strings.TrimSpace(f.Subfield("a")); class != "" {
+				// 082 $a may carry a prime mark ("823/.912")
+				if src.scheme == SchemeDewey {
+					class = strings.ReplaceAll(class, "/", "")
+				}
+				return CallNumber{Scheme: src.scheme, Class: class, Item: strings.TrimSpace(f.Subfield("b"))}
+			}
+		}
+	}
+	return CallNumber{}
+}
+
+// MARC852 builds a location field from the defaults and the record's call number
+func MARC852(rec *marc.Record, d Defaults) marc.DataField {
+	cn := CallNumberFromRecord(rec)
+
+	f := marc.DataField{Tag: "852", Ind1: " ", Ind2: " "}
+	switch cn.Scheme {
+	case SchemeLC:
+		f.Ind1 = "0"
+	case SchemeDewey:
+		f.Ind1 = "1"
+	}
+
+	add := func(code, value string) {
+		if value != "" {
+			f.Subfields = append(f.Subfields, marc.Subfield{Code: code, Value: value})
+		}
+	}
+	add("a", d.Institution)
+	add("b", d.Location)
+	add("c", d.ShelvingLocation)
+	add("h", cn.Class)
+	add("i", cn.Item)
+
+	return f
+}
+
+// AddMARC852 replaces any 852 fields in the record with a scaffolded one
+func AddMARC852(rec *marc.Record, d Defaults) {
+	rec.RemoveTags([]string{"852"})
+	rec.DataFields = append(rec.DataFields, MARC852(rec, d))
+}
+
+// FOLIOHoldings is a FOLIO holdings record with a single item, ready to be posted after
+// the instance is created (instanceId and holdingsRecordId are filled in by the push step)
+type FOLIOHoldings struct {
+	PermanentLocationID string      `json:"permanentLocationId,omitempty"`
+	HoldingsTypeID      string      `json:"holdingsTypeId,omitempty"`
+	CallNumber          string      `json:"callNumber,omitempty"`
+	CallNumberTypeID    string      `json:"callNumberTypeId,omitempty"`
+	Items               []FOLIOItem `json:"items"`
+}
+
+// FOLIOItem is the item scaffold attached to a holdings record
+type FOLIOItem struct {
+	MaterialTypeID      string          `json:"materialTypeId,omitempty"`
+	PermanentLoanTypeID string          `json:"permanentLoanTypeId,omitempty"`
+	Status              FOLIOItemStatus `json:"status"`
+}
+
+// FOLIOItemStatus is the item's circulation status
+type FOLIOItemStatus struct {
+	Name string `json:"name"`
+}
+
+// FOLIO builds a FOLIO holdings/item scaffold from the defaults and the record's call number
+func FOLIO(rec *marc.Record, d Defaults) FOLIOHoldings {
+	cn := CallNumberFromRecord(rec)
+
+	h := FOLIOHoldings{
+		PermanentLocationID: d.Location,
+		HoldingsTypeID:      d.HoldingsType,
+		CallNumber:          cn.String(),
+		Items: []FOLIOItem{{
+			MaterialTypeID:      d.MaterialType,
+			PermanentLoanTypeID: d.LoanType,
+			Status:              FOLIOItemStatus{Name: d.ItemStatus},
+		}},
+	}
+	switch cn.Scheme {
+	case SchemeLC:
+		h.CallNumberTypeID = folioLCType
+	case SchemeDewey:
+		h.CallNumberTypeID = folioDDCType
+	}
+
+	return h
+}
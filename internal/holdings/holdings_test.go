@@ -0,0 +1,68 @@
+package holdings
+
+import (
+	"testing"
+
+	"github.com/lehigh-university-libraries/cataloger/internal/marc"
+)
+
+func record(fields ...marc.DataField) *marc.Record {
+	return &marc.Record{Leader: "00000nam a2200000 i 4500", DataFields: fields}
+}
+
+func TestCallNumberFromRecord(t *testing.T) {
+	tests := []struct {
+		name string
+		rec  *marc.Record
+		want CallNumber
+	}{
+		{
+			name: "050 preferred over 082",
+			rec: record(
+				marc.DataField{Tag: "082", Subfields: []marc.Subfield{{Code: "a", Value: "813/.52"}}},
+				marc.DataField{Tag: "050", Subfields: []marc.Subfield{{Code: "a", Value: "PS3515.E37"}, {Code: "b", Value: "O4 1952"}}},
+			),
+			want: CallNumber{Scheme: SchemeLC, Class: "PS3515.E37", Item: "O4 1952"},
+		},
+		{
+			name: "dewey prime mark removed",
+			rec:  record(marc.DataField{Tag: "082", Subfields: []marc.Subfield{{Code: "a", Value: "813/.52"}}}),
+			want: CallNumber{Scheme: SchemeDewey, Class: "813.52"},
+		},
+		{
+			name: "none",
+			rec:  record(),
+			want: CallNumber{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := CallNumberFromRecord(tt.rec); got != tt.want {
+				t.Errorf("CallNumberFromRecord() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAddMARC852(t *testing.T) {
+	rec := record(
+		marc.DataField{Tag: "090", Subfields: []marc.Subfield{{Code: "a", Value: "QA76.73.G63"}, {Code: "b", Value: "D66 2016"}}},
+		marc.DataField{Tag: "852", Subfields: []marc.Subfield{{Code: "b", Value: "old"}}},
+	)
+	AddMARC852(rec, Defaults{Institution: "PBL", Location: "fml", ShelvingLocation: "stacks"})
+
+	fields := rec.Fields("852")
+	if len(fields) != 1 {
+		t.Fatalf("got %d 852 fields, want 1", len(fields))
+	}
+	f := fields[0]
+	if f.Ind1 != "0" {
+		t.Errorf("ind1 = %q, want 0", f.Ind1)
+	}
+	for code, want := range map[string]string{"a": "PBL", "b": "fml", "c": "stacks", "h": "QA76.73.G63", "i": "D66 2016"} {
+		if got := f.Subfield(code); got != want {
+			t.Errorf("$%s = %q, want %q", code, got, want)
+		}
+	}
+}
@@ -0,0 +1,151 @@
+package hooks
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/lehigh-university-libraries/cataloger/internal/marc"
+)
+
+// EnvVar lists hook commands, separated by semicolons
+const EnvVar = "POSTPROCESS_HOOKS"
+
+// DefaultTimeout limits how long a hook may run on one record
+const DefaultTimeout = 30 * time.Second
+
+// Hook is an executable that post-processes a generated record, e.g. to add local fields or
+// fix known quirks. It reads the record as MARCXML on stdin and writes the record to keep
+// as MARCXML on stdout; a non-zero exit fails the record.
+type Hook struct {
+	Command string // Program and arguments separated by spaces
+	Timeout time.Duration
+}
+
+// Info identifies a hook in results, with the SHA-256 of each argument that is a file (the
+// program or script), so a run can be reproduced with the same hook
+type Info struct {
+	Command string
+	Files   map[string]string `json:",omitempty"` // Path -> SHA-256
+}
+
+// Chain runs hooks in order, each on the previous hook's output
+type Chain []Hook
+
+// Parse builds a chain from commands
+func Parse(commands []string) Chain {
+	var chain Chain
+	for _, c := range commands {
+		if c = strings.TrimSpace(c); c != "" {
+			chain = append(chain, Hook{Command: c, Timeout: DefaultTimeout})
+		}
+	}
+	return chain
+}
+
+// FromEnv builds a chain from POSTPROCESS_HOOKS
+func FromEnv() Chain {
+	return Parse(strings.Split(os.Getenv(EnvVar), ";"))
+}
+
+// Apply runs each hook on the record, returning the final record
+func (c Chain) Apply(ctx context.Context, rec *marc.Record) (*marc.Record, error) {
+	for _, h := range c {
+		out, err := h.Apply(ctx, rec)
+		if err != nil {
+			return nil, err
+		}
+		rec = out
+	}
+	return rec, nil
+}
+
+// Infos describes the chain for results
+func (c Chain) Infos() []Info {
+	infos := make([]Info, 0, len(c))
+	for _, h := range c {
+		infos = append(infos, h.Info())
+	}
+	return infos
+}
+
+// Apply runs the hook on a record
+func (h Hook) Apply(ctx context.Context, rec *marc.Record) (*marc.Record, error) {
+	args := strings.Fields(h.Command)
+	if len(args) == 0 {
+		return nil, fmt.Errorf("empty hook command")
+	}
+	if h.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, h.Timeout)
+		defer cancel()
+	}
+
+	in, err := rec.XML()
+	if err != nil {
+		return nil, err
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+	cmd.Stdin = bytes.NewReader(in)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() != nil {
+			err = ctx.Err()
+		}
+		return nil, fmt.Errorf("hook %q failed: %w: %s", h.Command, err, strings.TrimSpace(stderr.String()))
+	}
+
+	out, err := marc.ParseXML(stdout.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("hook %q returned an invalid record: %w", h.Command, err)
+	}
+	return out, nil
+}
+
+// Info describes the hook for results
+func (h Hook) Info() Info {
+	info := Info{Command: h.Command}
+	for i, arg := range strings.Fields(h.Command) {
+		path := arg
+		if i == 0 {
+			if p, err := exec.LookPath(arg); err == nil {
+				path = p
+			}
+		}
+		digest, err := fileDigest(path)
+		if err != nil {
+			continue
+		}
+		if info.Files == nil {
+			info.Files = make(map[string]string)
+		}
+		info.Files[path] = digest
+	}
+	return info
+}
+
+func fileDigest(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	if st, err := f.Stat(); err != nil || !st.Mode().IsRegular() {
+		return "", fmt.Errorf("not a regular file: %s", path)
+	}
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
@@ -0,0 +1,107 @@
+package hooks
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lehigh-university-libraries/cataloger/internal/marc"
+)
+
+// TestMain runs the test binary as a hook when HOOK_TEST names a behavior
+func TestMain(m *testing.M) {
+	switch os.Getenv("HOOK_TEST") {
+	case "":
+		os.Exit(m.Run())
+	case "local-field":
+		runLocalFieldHook()
+	case "fail":
+		fmt.Fprintln(os.Stderr, "record rejected")
+		os.Exit(1)
+	case "garbage":
+		fmt.Println("not marc")
+	case "slow":
+		time.Sleep(10 * time.Second)
+	}
+}
+
+// runLocalFieldHook adds a 590 local note
+func runLocalFieldHook() {
+	data, _ := io.ReadAll(os.Stdin)
+	rec, err := marc.ParseXML(data)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	rec.DataFields = append(rec.DataFields, marc.DataField{Tag: "590", Ind1: " ", Ind2: " ", Subfields: []marc.Subfield{{Code: "a", Value: "Local copy."}}})
+	out, _ := rec.XML()
+	os.Stdout.Write(out)
+}
+
+func record(t *testing.T) *marc.Record {
+	t.Helper()
+	rec, err := marc.ParseMnemonic("=LDR  00000nam a2200000 i 4500\n=245  10$aThe history of bridges")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return rec
+}
+
+func TestChainApply(t *testing.T) {
+	t.Setenv("HOOK_TEST", "local-field")
+	chain := Parse([]string{os.Args[0], " ", os.Args[0]})
+	if len(chain) != 2 {
+		t.Fatalf("Parse() = %d hooks, want 2", len(chain))
+	}
+
+	rec, err := chain.Apply(context.Background(), record(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := len(rec.Fields("590")); got != 2 {
+		t.Errorf("590 fields = %d, want 2 (one per hook)", got)
+	}
+	if rec.SubfieldValue("245", "a") != "The history of bridges" {
+		t.Errorf("245$a = %q, want it unchanged", rec.SubfieldValue("245", "a"))
+	}
+}
+
+func TestApplyErrors(t *testing.T) {
+	tests := []struct {
+		behavior string
+		timeout  time.Duration
+		want     string
+	}{
+		{"fail", 30 * time.Second, "record rejected"},
+		{"garbage", 30 * time.Second, "invalid record"},
+		{"slow", 200 * time.Millisecond, "deadline exceeded"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.behavior, func(t *testing.T) {
+			t.Setenv("HOOK_TEST", tt.behavior)
+			h := Hook{Command: os.Args[0], Timeout: tt.timeout}
+			_, err := h.Apply(context.Background(), record(t))
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Apply() error = %v, want it to contain %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestInfo(t *testing.T) {
+	script := t.TempDir() + "/fix.py"
+	if err := os.WriteFile(script, []byte("print()\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	info := Hook{Command: "python3-missing " + script + " --strict"}.Info()
+	if len(info.Files) != 1 {
+		t.Fatalf("Files = %v, want only the script", info.Files)
+	}
+	if got := info.Files[script]; len(got) != 64 {
+		t.Errorf("digest = %q, want a SHA-256 hex digest", got)
+	}
+}
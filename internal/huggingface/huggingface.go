@@ -0,0 +1,268 @@
+// Package huggingface provides Hugging Face's serverless inference and dedicated Inference
+// Endpoints, for text and vision models
+package huggingface
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/lehigh-university-libraries/cataloger/internal/openai"
+	"github.com/lehigh-university-libraries/cataloger/internal/providers"
+)
+
+// Registration registers the provider as "huggingface"
+var Registration = providers.Registration{
+	Name:         "huggingface",
+	New:          func() providers.Provider { return New() },
+	DefaultModel: providers.EnvModel("HF_MODEL", "Qwen/Qwen2.5-VL-7B-Instruct"),
+	Configured:   func() bool { return os.Getenv("HF_TOKEN") != "" || os.Getenv("HF_ENDPOINT_URL") != "" },
+	Vision:       true,
+}
+
+// DefaultRouterURL is Hugging Face's serverless inference
+const DefaultRouterURL = "https://router.huggingface.co"
+
+// defaultMaxNewTokens bounds text-generation responses, which otherwise stop after a few
+// dozen tokens
+const defaultMaxNewTokens = 2048
+
+// Payload shapes, which depend on the model's task rather than the API
+const (
+	// PayloadChat is OpenAI's chat completions, served for chat and vision-language models
+	// by the serverless router and by Inference Endpoints running TGI or vLLM
+	PayloadChat = "chat"
+	// PayloadTextGeneration sends {"inputs": prompt, "parameters": {...}} and reads
+	// generated_text, for text-generation models without a chat template
+	PayloadTextGeneration = "text-generation"
+	// PayloadImageToText sends the image itself and reads generated_text, for OCR and
+	// captioning models such as TrOCR, which take no prompt
+	PayloadImageToText = "image-to-text"
+)
+
+// HuggingFace is a provider for Hugging Face inference
+type HuggingFace struct {
+	token       string
+	endpointURL string // Dedicated Inference Endpoint; serverless when empty
+	routerURL   string
+	payload     string            // Shape of requests to models not in payloads
+	payloads    map[string]string // Shape of requests by model
+	maxTokens   int
+}
+
+// New returns a new Hugging Face provider
+//
+// Configured with:
+//   - HF_TOKEN: access token (required for serverless inference)
+//   - HF_ENDPOINT_URL: dedicated Inference Endpoint, which serves the model it was deployed
+//     with whatever model is requested; serverless inference when unset
+//   - HF_PAYLOAD: request shape: chat (default), text-generation or image-to-text
+//   - HF_MODEL_PAYLOADS: shapes of particular models as model=shape pairs separated by
+//     semicolons, e.g. "microsoft/trocr-large-printed=image-to-text"
+//   - HF_MAX_NEW_TOKENS: response limit of text-generation models (default 2048)
+func New() *HuggingFace {
+	h := &HuggingFace{
+		token:       os.Getenv("HF_TOKEN"),
+		endpointURL: strings.TrimSuffix(os.Getenv("HF_ENDPOINT_URL"), "/"),
+		routerURL:   DefaultRouterURL,
+		payload:     PayloadChat,
+		payloads:    make(map[string]string),
+		maxTokens:   defaultMaxNewTokens,
+	}
+	if p := os.Getenv("HF_PAYLOAD"); p != "" {
+		if validPayload(p) {
+			h.payload = p
+		} else {
+			slog.Warn("Ignoring invalid HF_PAYLOAD", "value", p)
+		}
+	}
+	for _, pair := range strings.Split(os.Getenv("HF_MODEL_PAYLOADS"), ";") {
+		if strings.TrimSpace(pair) == "" {
+			continue
+		}
+		model, p, _ := strings.Cut(pair, "=")
+		model, p = strings.TrimSpace(model), strings.TrimSpace(p)
+		if model == "" || !validPayload(p) {
+			slog.Warn("Ignoring invalid entry in HF_MODEL_PAYLOADS", "entry", pair)
+			continue
+		}
+		h.payloads[model] = p
+	}
+	if v := os.Getenv("HF_MAX_NEW_TOKENS"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
+			h.maxTokens = n
+		} else {
+			slog.Warn("Ignoring invalid HF_MAX_NEW_TOKENS", "value", v)
+		}
+	}
+	return h
+}
+
+func validPayload(p string) bool {
+	return p == PayloadChat || p == PayloadTextGeneration || p == PayloadImageToText
+}
+
+// payloadFor returns the request shape of a model
+func (h *HuggingFace) payloadFor(model string) string {
+	if p, ok := h.payloads[model]; ok {
+		return p
+	}
+	return h.payload
+}
+
+// chat returns an OpenAI-compatible client for the endpoint or the router
+func (h *HuggingFace) chat() *openai.OpenAI {
+	baseURL := h.routerURL + "/v1"
+	if h.endpointURL != "" {
+		baseURL = h.endpointURL + "/v1"
+	}
+	return openai.NewEndpoint(openai.Endpoint{
+		Name:       "huggingface",
+		BaseURL:    baseURL,
+		APIKey:     h.token,
+		KeyEnv:     "HF_TOKEN",
+		RequireKey: h.endpointURL == "",
+		// TGI and many router providers don't take json_schema
+		JSONObjectOnly: true,
+	})
+}
+
+// ExtractText sends a text prompt to a chat or text-generation model
+func (h *HuggingFace) ExtractText(ctx context.Context, config providers.Config) (string, error) {
+	switch h.payloadFor(config.Model) {
+	case PayloadTextGeneration:
+		return h.generateText(ctx, config)
+	case PayloadImageToText:
+		return "", fmt.Errorf("huggingface model %s is image-to-text and takes no text prompt", config.Model)
+	}
+	return h.chat().ExtractText(ctx, config)
+}
+
+// StreamText streams a chat model's response; other models answer at once
+func (h *HuggingFace) StreamText(ctx context.Context, config providers.Config, onChunk func(string)) (string, error) {
+	if h.payloadFor(config.Model) != PayloadChat {
+		text, err := h.ExtractText(ctx, config)
+		if err == nil && text != "" {
+			onChunk(text)
+		}
+		return text, err
+	}
+	return h.chat().StreamText(ctx, config, onChunk)
+}
+
+// GenerateFromImage sends an image to a vision-language model with the prompt, or to an
+// image-to-text model on its own
+func (h *HuggingFace) GenerateFromImage(ctx context.Context, config providers.Config, image []byte) (string, error) {
+	switch h.payloadFor(config.Model) {
+	case PayloadTextGeneration:
+		return "", fmt.Errorf("huggingface model %s is text-generation and does not accept images", config.Model)
+	case PayloadImageToText:
+		return h.imageToText(ctx, config, image)
+	}
+	return h.chat().GenerateFromImage(ctx, config, image)
+}
+
+// GenerateFromImages sends several images to a vision-language model in one message
+func (h *HuggingFace) GenerateFromImages(ctx context.Context, config providers.Config, images [][]byte) (string, error) {
+	if h.payloadFor(config.Model) != PayloadChat {
+		if len(images) == 1 {
+			return h.GenerateFromImage(ctx, config, images[0])
+		}
+		return "", fmt.Errorf("huggingface model %s takes one image per request", config.Model)
+	}
+	return h.chat().GenerateFromImages(ctx, config, images)
+}
+
+// generateText sends a prompt in the text-generation task's shape
+func (h *HuggingFace) generateText(ctx context.Context, config providers.Config) (string, error) {
+	parameters := map[string]any{
+		"max_new_tokens":   h.maxTokens,
+		"return_full_text": false,
+	}
+	// The task rejects a temperature of 0; greedy decoding is the same thing
+	if config.Temperature > 0 {
+		parameters["temperature"] = config.Temperature
+	} else {
+		parameters["do_sample"] = false
+	}
+	body, err := json.Marshal(map[string]any{"inputs": config.Prompt, "parameters": parameters})
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal request body: %w", err)
+	}
+	return h.post(ctx, config.Model, "application/json", body)
+}
+
+// imageToText sends the image's bytes in the image-to-text task's shape
+func (h *HuggingFace) imageToText(ctx context.Context, config providers.Config, image []byte) (string, error) {
+	mediaType := http.DetectContentType(image)
+	if !strings.HasPrefix(mediaType, "image/") {
+		return "", fmt.Errorf("unsupported image type for huggingface: %s", mediaType)
+	}
+	return h.post(ctx, config.Model, mediaType, image)
+}
+
+// post sends a task request to the model's serverless URL or the endpoint, returning the
+// generated text
+func (h *HuggingFace) post(ctx context.Context, model, contentType string, body []byte) (string, error) {
+	target := h.endpointURL
+	if target == "" {
+		if h.token == "" {
+			return "", fmt.Errorf("HF_TOKEN environment variable not set")
+		}
+		target = h.routerURL + "/hf-inference/models/" + (&url.URL{Path: model}).EscapedPath()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", target, bytes.NewReader(body))
+	if err != nil {
+		return "", fmt.Errorf("failed to create new request: %w", err)
+	}
+	req.Header.Set("Content-Type", contentType)
+	// Wait for a cold model to load instead of failing with 503
+	req.Header.Set("X-Wait-For-Model", "true")
+	if h.token != "" {
+		req.Header.Set("Authorization", "Bearer "+h.token)
+	}
+
+	resp, err := providers.HTTPClient().Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("failed to read response body: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("received non-200 status code: %d - %s", resp.StatusCode, string(data))
+	}
+	return generatedText(data)
+}
+
+// generatedText reads a task response: [{"generated_text": ...}] from serverless inference
+// and most endpoints, or a single object from TGI's /generate
+func generatedText(data []byte) (string, error) {
+	type output struct {
+		GeneratedText *string `json:"generated_text"`
+	}
+	var outputs []output
+	if err := json.Unmarshal(data, &outputs); err != nil {
+		var single output
+		if err := json.Unmarshal(data, &single); err != nil {
+			return "", fmt.Errorf("failed to decode response body: %w", err)
+		}
+		outputs = []output{single}
+	}
+	if len(outputs) == 0 || outputs[0].GeneratedText == nil {
+		return "", fmt.Errorf("no generated text returned from huggingface")
+	}
+	return *outputs[0].GeneratedText, nil
+}
@@ -0,0 +1,93 @@
+package huggingface
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/lehigh-university-libraries/cataloger/internal/providers"
+)
+
+func TestPayloads(t *testing.T) {
+	png := []byte("\x89PNG\r\n\x1a\n")
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer hf-test" {
+			t.Errorf("Authorization = %q", r.Header.Get("Authorization"))
+		}
+		body, _ := io.ReadAll(r.Body)
+		switch r.URL.Path {
+		case "/v1/chat/completions":
+			var req map[string]any
+			_ = json.Unmarshal(body, &req)
+			if req["model"] != "Qwen/Qwen2.5-VL-7B-Instruct" {
+				t.Errorf("chat model = %v", req["model"])
+			}
+			_, _ = w.Write([]byte(`{"choices":[{"message":{"content":"chat"},"finish_reason":"stop"}]}`))
+		case "/hf-inference/models/gpt2":
+			var req struct {
+				Inputs     string         `json:"inputs"`
+				Parameters map[string]any `json:"parameters"`
+			}
+			_ = json.Unmarshal(body, &req)
+			if req.Inputs != "p" || req.Parameters["do_sample"] != false || req.Parameters["return_full_text"] != false {
+				t.Errorf("text-generation request = %s", body)
+			}
+			_, _ = w.Write([]byte(`[{"generated_text":"generated"}]`))
+		case "/hf-inference/models/microsoft/trocr-base-printed":
+			if r.Header.Get("Content-Type") != "image/png" || string(body) != string(png) {
+				t.Errorf("image-to-text request %q of type %s", body, r.Header.Get("Content-Type"))
+			}
+			_, _ = w.Write([]byte(`[{"generated_text":"WALDEN"}]`))
+		default:
+			t.Errorf("unexpected request to %s", r.URL.Path)
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+	t.Setenv("HF_TOKEN", "hf-test")
+	t.Setenv("HF_ENDPOINT_URL", "")
+	t.Setenv("HF_PAYLOAD", "")
+	t.Setenv("HF_MODEL_PAYLOADS", "gpt2=text-generation; microsoft/trocr-base-printed=image-to-text;bad=shape")
+	h := New()
+	h.routerURL = server.URL
+	ctx := context.Background()
+
+	if text, err := h.ExtractText(ctx, providers.Config{Model: "Qwen/Qwen2.5-VL-7B-Instruct", Prompt: "p"}); err != nil || text != "chat" {
+		t.Errorf("chat: %q, %v", text, err)
+	}
+	if text, err := h.ExtractText(ctx, providers.Config{Model: "gpt2", Prompt: "p"}); err != nil || text != "generated" {
+		t.Errorf("text-generation: %q, %v", text, err)
+	}
+	if text, err := h.GenerateFromImage(ctx, providers.Config{Model: "microsoft/trocr-base-printed", Prompt: "ignored"}, png); err != nil || text != "WALDEN" {
+		t.Errorf("image-to-text: %q, %v", text, err)
+	}
+
+	// Each task takes only its own input
+	if _, err := h.GenerateFromImage(ctx, providers.Config{Model: "gpt2"}, png); err == nil {
+		t.Error("expected an error sending an image to a text-generation model")
+	}
+	if _, err := h.GenerateFromImages(ctx, providers.Config{Model: "microsoft/trocr-base-printed"}, [][]byte{png, png}); err == nil {
+		t.Error("expected an error sending two images to an image-to-text model")
+	}
+	if _, ok := h.payloads["bad"]; ok {
+		t.Error("an invalid payload shape was accepted")
+	}
+}
+
+func TestGeneratedText(t *testing.T) {
+	for body, want := range map[string]string{
+		`[{"generated_text":"a"}]`: "a",
+		`{"generated_text":"b"}`:   "b",
+	} {
+		if got, err := generatedText([]byte(body)); err != nil || got != want {
+			t.Errorf("generatedText(%s) = %q, %v", body, got, err)
+		}
+	}
+	if _, err := generatedText([]byte(`[{"label":"x"}]`)); err == nil || !strings.Contains(err.Error(), "no generated text") {
+		t.Errorf("err = %v", err)
+	}
+}
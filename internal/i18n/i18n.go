@@ -0,0 +1,82 @@
+// Package i18n translates the web UI and API error messages. Messages are identified by their
+// English format strings, as written in the code; locales/<lang>.json maps each one to its
+// translation with the same verbs. A message missing from a locale is shown in English, so new
+// messages can ship before they are translated.
+package i18n
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"path"
+	"strings"
+
+	"golang.org/x/text/language"
+)
+
+// Default is the language of the message IDs
+const Default = "en"
+
+//go:embed locales/*.json
+var localeFiles embed.FS
+
+var (
+	catalogs = map[string]map[string]string{Default: {}}
+	matcher  language.Matcher
+	tags     []string // Language of each of matcher's tags, in order
+)
+
+func init() {
+	entries, err := localeFiles.ReadDir("locales")
+	if err != nil {
+		panic(err)
+	}
+	supported := []language.Tag{language.Make(Default)}
+	tags = []string{Default}
+	for _, e := range entries {
+		data, err := localeFiles.ReadFile("locales/" + e.Name())
+		if err != nil {
+			panic(err)
+		}
+		var messages map[string]string
+		if err := json.Unmarshal(data, &messages); err != nil {
+			panic(fmt.Sprintf("invalid locale %s: %v", e.Name(), err))
+		}
+		lang := strings.TrimSuffix(e.Name(), path.Ext(e.Name()))
+		catalogs[lang] = messages
+		supported = append(supported, language.Make(lang))
+		tags = append(tags, lang)
+	}
+	matcher = language.NewMatcher(supported)
+}
+
+// Lang picks the supported language for a request: the lang query parameter when given,
+// otherwise the best match for Accept-Language, otherwise Default
+func Lang(r *http.Request) string {
+	prefs := r.URL.Query().Get("lang")
+	if prefs == "" {
+		prefs = r.Header.Get("Accept-Language")
+	}
+	if prefs == "" {
+		return Default
+	}
+	requested, _, err := language.ParseAcceptLanguage(prefs)
+	if err != nil || len(requested) == 0 {
+		return Default
+	}
+	_, i, confidence := matcher.Match(requested...)
+	if confidence == language.No {
+		return Default
+	}
+	return tags[i]
+}
+
+// Sprintf formats a message in lang, falling back to the English format when lang has no
+// translation of it
+func Sprintf(lang, format string, args ...any) string {
+	if translated, ok := catalogs[lang][format]; ok && translated != "" {
+		format = translated
+	}
+	return fmt.Sprintf(format, args...)
+}
@@ -0,0 +1,71 @@
+package i18n
+
+import (
+	"net/http/httptest"
+	"regexp"
+	"slices"
+	"testing"
+)
+
+func TestLang(t *testing.T) {
+	tests := []struct {
+		query, acceptLanguage, want string
+	}{
+		{"", "", "en"},
+		{"", "es-MX,es;q=0.9,en;q=0.8", "es"},
+		{"", "fr-CA", "fr"},
+		{"", "de-DE;q=0.5, ja;q=0.9", "de"},
+		{"", "ja", "en"},
+		{"", "not a language", "en"},
+		{"lang=fr", "de", "fr"},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest("GET", "/?"+tt.query, nil)
+		if tt.acceptLanguage != "" {
+			r.Header.Set("Accept-Language", tt.acceptLanguage)
+		}
+		if got := Lang(r); got != tt.want {
+			t.Errorf("Lang(%q, %q) = %q, want %q", tt.query, tt.acceptLanguage, got, tt.want)
+		}
+	}
+}
+
+func TestSprintf(t *testing.T) {
+	if got := Sprintf("es", "Session %s already exists", "abc"); got != "La sesión abc ya existe" {
+		t.Errorf("es = %q", got)
+	}
+	if got := Sprintf("de", "An untranslated message about %d", 3); got != "An untranslated message about 3" {
+		t.Errorf("untranslated = %q", got)
+	}
+	if got := Sprintf("en", "Session not found"); got != "Session not found" {
+		t.Errorf("en = %q", got)
+	}
+}
+
+var verbPattern = regexp.MustCompile(`%[a-z]`)
+
+// TestCatalogs checks that every locale translates the same messages with the same verbs
+func TestCatalogs(t *testing.T) {
+	var reference string
+	for lang, messages := range catalogs {
+		if lang == Default {
+			continue
+		}
+		if reference == "" {
+			reference = lang
+		}
+		for id, translated := range messages {
+			if _, ok := catalogs[reference][id]; !ok {
+				t.Errorf("%s translates %q, which %s does not", lang, id, reference)
+			}
+			if want, got := verbPattern.FindAllString(id, -1), verbPattern.FindAllString(translated, -1); !slices.Equal(want, got) {
+				t.Errorf("%s: %q has verbs %v, want %v", lang, translated, got, want)
+			}
+		}
+		for id := range catalogs[reference] {
+			if _, ok := messages[id]; !ok {
+				t.Errorf("%s is missing %q", lang, id)
+			}
+		}
+	}
+}
@@ -0,0 +1,234 @@
+package identifiers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/lehigh-university-libraries/cataloger/internal/marc"
+)
+
+// Identifiers are the standard numbers an ISBN resolved to
+type Identifiers struct {
+	OCLC   []string // Normalized OCLC numbers, without prefixes
+	LCCN   string   // Normalized LCCN
+	Source string   // Which lookups answered
+}
+
+// Empty reports whether no identifier was found
+func (i Identifiers) Empty() bool {
+	return len(i.OCLC) == 0 && i.LCCN == ""
+}
+
+// Resolver finds OCLC numbers and LCCNs for an ISBN using Open Library, which carries both
+// for most books, and the Library of Congress catalog for anything still missing
+type Resolver struct {
+	HTTPClient     *http.Client
+	OpenLibraryURL string
+	LoCURL         string
+}
+
+// NewResolver creates a resolver against the public Open Library and loc.gov APIs
+func NewResolver() *Resolver {
+	return &Resolver{
+		HTTPClient:     &http.Client{Timeout: 30 * time.Second},
+		OpenLibraryURL: "https://openlibrary.org",
+		LoCURL:         "https://www.loc.gov",
+	}
+}
+
+// FromEnv returns a resolver when IDENTIFIER_LOOKUP is true, or nil
+func FromEnv() *Resolver {
+	if enabled, _ := strconv.ParseBool(os.Getenv("IDENTIFIER_LOOKUP")); !enabled {
+		return nil
+	}
+	return NewResolver()
+}
+
+// Lookup resolves an ISBN. It returns an error only when every source failed.
+func (r *Resolver) Lookup(isbn string) (Identifiers, error) {
+	var ids Identifiers
+	var sources []string
+
+	olErr := r.openLibrary(isbn, &ids)
+	if olErr == nil {
+		sources = append(sources, "openlibrary")
+	}
+
+	var locErr error
+	if ids.LCCN == "" || len(ids.OCLC) == 0 {
+		if locErr = r.libraryOfCongress(isbn, &ids); locErr == nil {
+			sources = append(sources, "loc")
+		}
+	}
+
+	if olErr != nil && locErr != nil {
+		return ids, fmt.Errorf("identifier lookup failed for ISBN %s: %v; %v", isbn, olErr, locErr)
+	}
+	ids.Source = strings.Join(sources, ",")
+	return ids, nil
+}
+
+func (r *Resolver) openLibrary(isbn string, ids *Identifiers) error {
+	var result map[string]struct {
+		Details struct {
+			OCLCNumbers []string `json:"oclc_numbers"`
+			LCCN        []string `json:"lccn"`
+		} `json:"details"`
+	}
+	u := fmt.Sprintf("%s/api/books?bibkeys=ISBN:%s&format=json&jscmd=details", r.OpenLibraryURL, url.QueryEscape(isbn))
+	if err := r.getJSON(u, &result); err != nil {
+		return fmt.Errorf("failed to query Open Library: %w", err)
+	}
+
+	book, ok := result["ISBN:"+isbn]
+	if !ok {
+		return fmt.Errorf("open Library has no record for ISBN %s", isbn)
+	}
+	ids.addOCLC(book.Details.OCLCNumbers...)
+	if ids.LCCN == "" && len(book.Details.LCCN) > 0 {
+		ids.LCCN = NormalizeLCCN(book.Details.LCCN[0])
+	}
+	return nil
+}
+
+func (r *Resolver) libraryOfCongress(isbn string, ids *Identifiers) error {
+	var result struct {
+		Results []struct {
+			NumberLCCN []string `json:"number_lccn"`
+			NumberOCLC []string `json:"number_oclc"`
+		} `json:"results"`
+	}
+	u := fmt.Sprintf("%s/books/?q=%s&fo=json&c=5", r.LoCURL, url.QueryEscape(isbn))
+	if err := r.getJSON(u, &result); err != nil {
+		return fmt.Errorf("failed to query loc.gov: %w", err)
+	}
+	if len(result.Results) == 0 {
+		return fmt.Errorf("loc.gov has no record for ISBN %s", isbn)
+	}
+
+	first := result.Results[0]
+	ids.addOCLC(first.NumberOCLC...)
+	if ids.LCCN == "" && len(first.NumberLCCN) > 0 {
+		ids.LCCN = NormalizeLCCN(first.NumberLCCN[0])
+	}
+	return nil
+}
+
+func (r *Resolver) getJSON(u string, v any) error {
+	resp, err := r.HTTPClient.Get(u)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("status %d", resp.StatusCode)
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+func (i *Identifiers) addOCLC(numbers ...string) {
+	for _, n := range numbers {
+		n = NormalizeOCLC(n)
+		if n == "" {
+			continue
+		}
+		dup := false
+		for _, existing := range i.OCLC {
+			dup = dup || existing == n
+		}
+		if !dup {
+			i.OCLC = append(i.OCLC, n)
+		}
+	}
+}
+
+// Apply replaces the record's 010 and OCLC 035 fields with the resolved identifiers
+func Apply(rec *marc.Record, ids Identifiers) {
+	if ids.LCCN != "" {
+		rec.RemoveTags([]string{"010"})
+		insertField(rec, marc.DataField{Tag: "010", Ind1: " ", Ind2: " ",
+			Subfields: []marc.Subfield{{Code: "a", Value: LCCN010(ids.LCCN)}}})
+	}
+
+	if len(ids.OCLC) > 0 {
+		kept := rec.DataFields[:0]
+		for _, df := range rec.DataFields {
+			if df.Tag == "035" && strings.HasPrefix(df.Subfield("a"), "(OCoLC)") {
+				continue
+			}
+			kept = append(kept, df)
+		}
+		rec.DataFields = kept
+
+		// The first number is the preferred one; any others are recorded as canceled/invalid ($z)
+		f := marc.DataField{Tag: "035", Ind1: " ", Ind2: " ",
+			Subfields: []marc.Subfield{{Code: "a", Value: "(OCoLC)" + ids.OCLC[0]}}}
+		for _, n := range ids.OCLC[1:] {
+			f.Subfields = append(f.Subfields, marc.Subfield{Code: "z", Value: "(OCoLC)" + n})
+		}
+		insertField(rec, f)
+	}
+}
+
+func insertField(rec *marc.Record, f marc.DataField) {
+	i := 0
+	for i < len(rec.DataFields) && rec.DataFields[i].Tag <= f.Tag {
+		i++
+	}
+	rec.DataFields = append(rec.DataFields[:i], append([]marc.DataField{f}, rec.DataFields[i:]...)...)
+}
+
+// NormalizeOCLC strips the (OCoLC) qualifier, ocm/ocn/on prefixes and leading zeros
+func NormalizeOCLC(s string) string {
+	s = strings.TrimSpace(s)
+	s = strings.TrimPrefix(s, "(OCoLC)")
+	for _, prefix := range []string{"ocm", "ocn", "on"} {
+		s = strings.TrimPrefix(s, prefix)
+	}
+	s = strings.TrimSpace(s)
+	for _, r := range s {
+		if !unicode.IsDigit(r) {
+			return ""
+		}
+	}
+	return strings.TrimLeft(s, "0")
+}
+
+// NormalizeLCCN applies the LC normalization rules: remove blanks and any "/" suffix, and
+// zero-pad the serial after a hyphen to six digits ("52-1234" becomes "52001234")
+func NormalizeLCCN(s string) string {
+	s = strings.ReplaceAll(strings.TrimSpace(s), " ", "")
+	if i := strings.Index(s, "/"); i >= 0 {
+		s = s[:i]
+	}
+	if prefix, serial, ok := strings.Cut(s, "-"); ok {
+		if len(serial) < 6 {
+			serial = strings.Repeat("0", 6-len(serial)) + serial
+		}
+		s = prefix + serial
+	}
+	return strings.ToLower(s)
+}
+
+// LCCN010 formats a normalized LCCN for 010 $a: the prefix is left-justified in a three
+// character (two-digit year) or two character (four-digit year) block, and two-digit-year
+// numbers carry a trailing blank
+func LCCN010(lccn string) string {
+	i := strings.IndexFunc(lccn, unicode.IsDigit)
+	if i < 0 {
+		return lccn
+	}
+	prefix, number := lccn[:i], lccn[i:]
+	if len(number) == 8 {
+		return fmt.Sprintf("%-3s%s ", prefix, number)
+	}
+	return fmt.Sprintf("%-2s%s", prefix, number)
+}
@@ -0,0 +1,87 @@
+package identifiers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/lehigh-university-libraries/cataloger/internal/marc"
+)
+
+func TestNormalize(t *testing.T) {
+	oclc := map[string]string{
+		"(OCoLC)ocm00012345": "12345",
+		"ocn123456789":       "123456789",
+		"on1234567890":       "1234567890",
+		"(OCoLC)abc":         "",
+	}
+	for in, want := range oclc {
+		if got := NormalizeOCLC(in); got != want {
+			t.Errorf("NormalizeOCLC(%q) = %q, want %q", in, got, want)
+		}
+	}
+
+	lccn := map[string]string{
+		"52-1234":       "52001234",
+		"  2001012345":  "2001012345",
+		"n 78-89035":    "n78089035",
+		"85-2 /AC/r932": "85000002",
+	}
+	for in, want := range lccn {
+		if got := NormalizeLCCN(in); got != want {
+			t.Errorf("NormalizeLCCN(%q) = %q, want %q", in, got, want)
+		}
+	}
+
+	if got := LCCN010("52001234"); got != "   52001234 " {
+		t.Errorf("LCCN010 two-digit year = %q", got)
+	}
+	if got := LCCN010("2001012345"); got != "  2001012345" {
+		t.Errorf("LCCN010 four-digit year = %q", got)
+	}
+}
+
+func TestLookupAndApply(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/books":
+			_, _ = w.Write([]byte(`{"ISBN:0684801221": {"details": {"oclc_numbers": ["32272564", "ocm32272564"]}}}`))
+		case "/books/":
+			_, _ = w.Write([]byte(`{"results": [{"number_lccn": ["52-6185"]}]}`))
+		}
+	}))
+	defer srv.Close()
+
+	r := NewResolver()
+	r.OpenLibraryURL = srv.URL
+	r.LoCURL = srv.URL
+
+	ids, err := r.Lookup("0684801221")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ids.OCLC) != 1 || ids.OCLC[0] != "32272564" || ids.LCCN != "52006185" || ids.Source != "openlibrary,loc" {
+		t.Fatalf("unexpected identifiers %+v", ids)
+	}
+
+	rec := &marc.Record{DataFields: []marc.DataField{
+		{Tag: "020", Subfields: []marc.Subfield{{Code: "a", Value: "0684801221"}}},
+		{Tag: "035", Subfields: []marc.Subfield{{Code: "a", Value: "(OCoLC)999"}}},
+		{Tag: "245", Subfields: []marc.Subfield{{Code: "a", Value: "The old man and the sea"}}},
+	}}
+	Apply(rec, ids)
+
+	var tags []string
+	for _, df := range rec.DataFields {
+		tags = append(tags, df.Tag)
+	}
+	if len(tags) != 4 || tags[0] != "010" || tags[2] != "035" {
+		t.Fatalf("expected 010 020 035 245, got %v", tags)
+	}
+	if got := rec.SubfieldValue("035", "a"); got != "(OCoLC)32272564" {
+		t.Errorf("035 $a = %q", got)
+	}
+	if got := rec.SubfieldValue("010", "a"); got != "   52006185 " {
+		t.Errorf("010 $a = %q", got)
+	}
+}
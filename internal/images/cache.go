@@ -0,0 +1,116 @@
+package images
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// CacheEnvVar names the shared image cache directory; "off" disables the cache
+const CacheEnvVar = "IMAGE_CACHE_DIR"
+
+// Cache is a directory of downloaded images shared across datasets and runs, so books seen
+// before are not fetched from Open Library, the Internet Archive or Google Books again.
+// Images are keyed by ISBN and source:
+//
+//	<dir>/<source>/<isbn>/<name>.jpg
+//
+// A nil Cache caches nothing.
+type Cache struct {
+	Dir string
+}
+
+// NewCache returns a cache in dir, or nil when dir is empty or "off"
+func NewCache(dir string) *Cache {
+	if dir == "" || dir == "off" {
+		return nil
+	}
+	return &Cache{Dir: dir}
+}
+
+// CacheFromEnv returns the cache in $IMAGE_CACHE_DIR, defaulting to cataloger/images in the
+// user's cache directory
+func CacheFromEnv() *Cache {
+	dir := os.Getenv(CacheEnvVar)
+	if dir == "" {
+		base, err := os.UserCacheDir()
+		if err != nil {
+			return nil
+		}
+		dir = filepath.Join(base, "cataloger", "images")
+	}
+	return NewCache(dir)
+}
+
+// String returns the cache directory, or "off"
+func (c *Cache) String() string {
+	if c == nil {
+		return "off"
+	}
+	return c.Dir
+}
+
+func (c *Cache) path(isbn, source, name string) (string, bool) {
+	if c == nil || isbn == "" || strings.ContainsAny(isbn, `/\.`) {
+		return "", false
+	}
+	return filepath.Join(c.Dir, source, isbn, name+".jpg"), true
+}
+
+// Get copies an image cached for an ISBN and source to outputPath, and reports whether
+// there was one
+func (c *Cache) Get(isbn, source, name, outputPath string) bool {
+	src, ok := c.path(isbn, source, name)
+	if !ok {
+		return false
+	}
+	if err := copyFile(src, outputPath); err != nil {
+		return false
+	}
+	slog.Debug("Using cached image", "isbn", isbn, "source", source, "name", name)
+	return true
+}
+
+// Put stores a downloaded image for an ISBN and source. Failures are logged, since the
+// image itself was downloaded.
+func (c *Cache) Put(isbn, source, name, imagePath string) {
+	dst, ok := c.path(isbn, source, name)
+	if !ok {
+		return
+	}
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		slog.Warn("Failed to create image cache directory", "dir", filepath.Dir(dst), "error", err)
+		return
+	}
+	// Write to a temporary file first so concurrent runs never read a partial image
+	tmp := fmt.Sprintf("%s.%d.tmp", dst, os.Getpid())
+	if err := copyFile(imagePath, tmp); err != nil {
+		slog.Warn("Failed to cache image", "isbn", isbn, "source", source, "error", err)
+		return
+	}
+	if err := os.Rename(tmp, dst); err != nil {
+		os.Remove(tmp)
+		slog.Warn("Failed to cache image", "isbn", isbn, "source", source, "error", err)
+	}
+}
+
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
@@ -0,0 +1,157 @@
+package images
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+type failTransport struct{ t *testing.T }
+
+func (ft failTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	ft.t.Errorf("unexpected request to %s", r.URL)
+	return nil, fmt.Errorf("no network in tests")
+}
+
+func TestCache(t *testing.T) {
+	cache := NewCache(t.TempDir())
+	dir := t.TempDir()
+	src := filepath.Join(dir, "downloaded.jpg")
+	if err := os.WriteFile(src, []byte("image"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	dst := filepath.Join(dir, "copy.jpg")
+	if cache.Get("0684801221", SourceOpenLibrary, KindCover, dst) {
+		t.Fatal("Get() hit on an empty cache")
+	}
+	cache.Put("0684801221", SourceOpenLibrary, KindCover, src)
+	if !cache.Get("0684801221", SourceOpenLibrary, KindCover, dst) {
+		t.Fatal("Get() missed a cached image")
+	}
+	if data, _ := os.ReadFile(dst); string(data) != "image" {
+		t.Errorf("cached image = %q", data)
+	}
+	if cache.Get("0684801221", SourceGoogleBooks, KindCover, dst) {
+		t.Error("Get() hit for another source")
+	}
+
+	// Unsafe keys and a disabled cache are never used
+	cache.Put("../x", SourceOpenLibrary, KindCover, src)
+	if cache.Get("../x", SourceOpenLibrary, KindCover, dst) {
+		t.Error("Get() hit for a path-like ISBN")
+	}
+	var off *Cache = NewCache("off")
+	off.Put("0684801221", SourceOpenLibrary, KindCover, src)
+	if off.Get("0684801221", SourceOpenLibrary, KindCover, dst) || off.String() != "off" {
+		t.Error("disabled cache was used")
+	}
+}
+
+func TestFetchImagesForISBNFromCache(t *testing.T) {
+	cache := NewCache(t.TempDir())
+	src := filepath.Join(t.TempDir(), "page.jpg")
+	if err := os.WriteFile(src, []byte("image"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cache.Put("0684801221", SourceOpenLibrary, "cover_M", src)
+	cache.Put("0684801221", SourceInternetArchive, KindTitlePage, src)
+	cache.Put("0684801221", SourceGoogleBooks, KindCopyrightPage, src)
+
+	f := &Fetcher{HTTPClient: &http.Client{Transport: failTransport{t}}, Cache: cache}
+	imageSet, err := f.FetchImagesForISBN("0684801221", t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !imageSet.Complete() {
+		t.Fatalf("FetchImagesForISBN() = %+v, want all images", imageSet)
+	}
+	if imageSet.CoverSize != "M" {
+		t.Errorf("cover size = %q, want M", imageSet.CoverSize)
+	}
+	want := map[string]string{KindCover: SourceOpenLibrary, KindTitlePage: SourceInternetArchive, KindCopyrightPage: SourceGoogleBooks}
+	for kind, source := range want {
+		if imageSet.Sources[kind] != source {
+			t.Errorf("source of %s = %q, want %q", kind, imageSet.Sources[kind], source)
+		}
+	}
+}
+
+func TestDownloadGoogleBooksPagesFromCache(t *testing.T) {
+	cache := NewCache(t.TempDir())
+	src := filepath.Join(t.TempDir(), "page.jpg")
+	if err := os.WriteFile(src, []byte("image"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	for i := 1; i <= 3; i++ {
+		cache.Put("0684801221", SourceGoogleBooks, fmt.Sprintf("page_%d", i), src)
+	}
+
+	f := &Fetcher{HTTPClient: &http.Client{Transport: failTransport{t}}, Cache: cache}
+	out := t.TempDir()
+	n, err := DownloadGoogleBooksPages(f, "0684801221", out, 3)
+	if err != nil || n != 3 {
+		t.Fatalf("DownloadGoogleBooksPages() = %d, %v, want 3 pages", n, err)
+	}
+	if _, err := os.Stat(filepath.Join(out, "page_3.jpg")); err != nil {
+		t.Error(err)
+	}
+}
+
+type handlerTransport struct{ h http.Handler }
+
+func (ht handlerTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	rec := httptest.NewRecorder()
+	ht.h.ServeHTTP(rec, r)
+	return rec.Result(), nil
+}
+
+func TestDownloadCoverImageSizes(t *testing.T) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewGray(image.Rect(0, 0, 180, 270))); err != nil {
+		t.Fatal(err)
+	}
+	var requested []string
+	f := &Fetcher{Cache: NewCache(t.TempDir()), HTTPClient: &http.Client{Transport: handlerTransport{http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requested = append(requested, r.URL.Path)
+		switch r.URL.Path {
+		case "/b/isbn/0684801221-M.jpg":
+			w.Write(buf.Bytes())
+		case "/b/isbn/0684801221-S.jpg":
+			png.Encode(w, image.NewGray(image.Rect(0, 0, 1, 1)))
+		default:
+			http.NotFound(w, r)
+		}
+	})}}}
+
+	out := filepath.Join(t.TempDir(), "cover.jpg")
+	size, err := f.downloadCoverImage("0684801221", out)
+	if err != nil || size != "M" {
+		t.Fatalf("downloadCoverImage() = %q, %v, want M", size, err)
+	}
+	if !slices.Equal(requested, []string{"/b/isbn/0684801221-L.jpg", "/b/isbn/0684801221-M.jpg"}) {
+		t.Errorf("requested %v", requested)
+	}
+
+	// The size is remembered with the cached cover
+	requested = nil
+	if size, err := f.downloadCoverImage("0684801221", out); err != nil || size != "M" || len(requested) > 0 {
+		t.Errorf("cached downloadCoverImage() = %q, %v after %v, want M without requests", size, err, requested)
+	}
+
+	// Placeholders are rejected by their dimensions
+	if _, err := f.downloadCoverImage("0000000000", out); err == nil {
+		t.Error("downloadCoverImage() succeeded without a cover")
+	}
+	if _, _, err := f.downloadCover("https://covers.openlibrary.org/b/isbn/0684801221-S.jpg", out); err == nil || !strings.Contains(err.Error(), "1x1") {
+		t.Errorf("downloadCover() of a 1x1 image: err = %v", err)
+	}
+}
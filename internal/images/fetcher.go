@@ -1,8 +1,14 @@
 package images
 
 import (
+	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
 	"io"
 	"log/slog"
 	"net/http"
@@ -10,27 +16,94 @@ import (
 	"path/filepath"
 	"strings"
 	"time"
+
+	"github.com/lehigh-university-libraries/cataloger/internal/usage"
 )
 
 // Fetcher retrieves book images from various sources
 type Fetcher struct {
 	HTTPClient *http.Client
+	Cache      *Cache      // Images fetched by ISBN; nil disables caching
+	Politeness *Politeness // Per-source limits enforced by HTTPClient
 }
 
-// NewFetcher creates a new image fetcher
+// NewFetcher creates a new image fetcher using the image cache from $IMAGE_CACHE_DIR
 func NewFetcher() *Fetcher {
+	return NewFetcherWithCache(CacheFromEnv())
+}
+
+// NewFetcherWithCache creates a new image fetcher using cache, with the source limits from
+// $IMAGE_SOURCE_LIMITS. Daily counts and pauses are kept in the cache directory.
+func NewFetcherWithCache(cache *Cache) *Fetcher {
+	politeness, err := PolitenessFromEnv(cache)
+	if err != nil {
+		slog.Warn("Ignoring invalid "+LimitsEnvVar, "error", err)
+		politeness = NewPoliteness(DefaultSourceLimits, "")
+	}
 	return &Fetcher{
 		HTTPClient: &http.Client{
-			Timeout: 30 * time.Second,
+			Timeout:   30 * time.Second,
+			Transport: politeness.Transport(&usage.CountingTransport{Base: http.DefaultTransport}),
 		},
+		Cache:      cache,
+		Politeness: politeness,
 	}
 }
 
+// sourceUnavailable reports whether a request failed because its source is paused or out
+// of requests for the day, so trying more pages of it is pointless
+func sourceUnavailable(err error) bool {
+	return errors.Is(err, ErrSourcePaused) || errors.Is(err, ErrDailyCap)
+}
+
 // ImageSet represents the three key images for cataloging
 type ImageSet struct {
 	CoverPath         string
 	TitlePagePath     string
 	CopyrightPagePath string
+	Sources           map[string]string // Image kind -> source it was downloaded from
+	CoverSize         string            // Open Library cover size obtained (L, M or S)
+}
+
+// Get returns the path of an image by kind ("cover", "title_page" or "copyright_page")
+func (s *ImageSet) Get(kind string) string {
+	switch kind {
+	case KindCover:
+		return s.CoverPath
+	case KindTitlePage:
+		return s.TitlePagePath
+	case KindCopyrightPage:
+		return s.CopyrightPagePath
+	}
+	return ""
+}
+
+// Set records the path of an image by kind and the source it came from
+func (s *ImageSet) Set(kind, path, source string) {
+	switch kind {
+	case KindCover:
+		s.CoverPath = path
+	case KindTitlePage:
+		s.TitlePagePath = path
+	case KindCopyrightPage:
+		s.CopyrightPagePath = path
+	default:
+		return
+	}
+	if s.Sources == nil {
+		s.Sources = make(map[string]string)
+	}
+	s.Sources[kind] = source
+}
+
+// Complete reports whether all three images are present
+func (s *ImageSet) Complete() bool {
+	return s.CoverPath != "" && s.TitlePagePath != "" && s.CopyrightPagePath != ""
+}
+
+// Empty reports whether no image is present
+func (s *ImageSet) Empty() bool {
+	return s.CoverPath == "" && s.TitlePagePath == "" && s.CopyrightPagePath == ""
 }
 
 // OpenLibraryBooksResponse represents the Open Library Books API response
@@ -51,54 +124,73 @@ type OpenLibraryBooksResponse map[string]struct {
 	} `json:"details"`
 }
 
-// FetchImagesForISBN retrieves cover, title page, and copyright page images for a given ISBN
+// FetchImagesForISBN retrieves cover, title page, and copyright page images for a given ISBN.
+// Images already in the cache for a source are used without contacting it.
 func (f *Fetcher) FetchImagesForISBN(isbn string, outputDir string) (*ImageSet, error) {
 	slog.Info("Fetching images for ISBN", "isbn", isbn)
 
 	imageSet := &ImageSet{}
+	paths := map[string]string{
+		KindCover:         filepath.Join(outputDir, fmt.Sprintf("%s_cover.jpg", isbn)),
+		KindTitlePage:     filepath.Join(outputDir, fmt.Sprintf("%s_title.jpg", isbn)),
+		KindCopyrightPage: filepath.Join(outputDir, fmt.Sprintf("%s_copyright.jpg", isbn)),
+	}
+	coverPath, titlePath, copyrightPath := paths[KindCover], paths[KindTitlePage], paths[KindCopyrightPage]
+
+	// Images cached by earlier runs, in the order the sources are tried
+	cached := make(map[string]bool)
+	for _, source := range []string{SourceOpenLibrary, SourceInternetArchive, SourceGoogleBooks} {
+		for _, kind := range []string{KindCover, KindTitlePage, KindCopyrightPage} {
+			if source == SourceOpenLibrary && kind == KindCover {
+				// Cached by size, see downloadCoverImage
+				continue
+			}
+			if imageSet.Get(kind) == "" && f.Cache.Get(isbn, source, kind, paths[kind]) {
+				imageSet.Set(kind, paths[kind], source)
+				cached[kind] = true
+			}
+		}
+	}
 
 	// Step 1: Get cover image from Open Library Covers API
-	coverPath := filepath.Join(outputDir, fmt.Sprintf("%s_cover.jpg", isbn))
-	if err := f.downloadCoverImage(isbn, coverPath); err != nil {
-		slog.Warn("Failed to download cover image", "isbn", isbn, "error", err)
-	} else {
-		imageSet.CoverPath = coverPath
-		slog.Info("Downloaded cover image", "isbn", isbn, "path", coverPath)
+	if imageSet.CoverPath == "" {
+		if size, err := f.downloadCoverImage(isbn, coverPath); err != nil {
+			slog.Warn("Failed to download cover image", "isbn", isbn, "error", err)
+		} else {
+			imageSet.Set(KindCover, coverPath, SourceOpenLibrary)
+			imageSet.CoverSize = size
+			cached[KindCover] = true
+			slog.Info("Downloaded cover image", "isbn", isbn, "size", size, "path", coverPath)
+		}
 	}
 
-	// Rate limiting: Sleep between Open Library API calls
-	// Open Library allows 100 req/5min, so ~1 req/sec is safe
-	time.Sleep(1 * time.Second)
-
 	// Step 2: Try to get interior pages from Internet Archive
-	titlePath := filepath.Join(outputDir, fmt.Sprintf("%s_title.jpg", isbn))
-	copyrightPath := filepath.Join(outputDir, fmt.Sprintf("%s_copyright.jpg", isbn))
-
-	iaID, err := f.getInternetArchiveID(isbn)
-	if err == nil {
-		slog.Info("Found Internet Archive identifier", "isbn", isbn, "ia_id", iaID)
-
-		// Rate limiting: Sleep before hitting Internet Archive
-		time.Sleep(500 * time.Millisecond)
-
-		if err := f.downloadInteriorPages(iaID, titlePath, copyrightPath); err == nil {
-			imageSet.TitlePagePath = titlePath
-			imageSet.CopyrightPagePath = copyrightPath
-			slog.Info("Downloaded interior pages from IA", "isbn", isbn, "ia_id", iaID)
+	if imageSet.TitlePagePath == "" || imageSet.CopyrightPagePath == "" {
+		iaID, err := f.getInternetArchiveID(isbn)
+		if err == nil {
+			slog.Info("Found Internet Archive identifier", "isbn", isbn, "ia_id", iaID)
+
+			title, copyright := missingPath(imageSet.TitlePagePath, titlePath), missingPath(imageSet.CopyrightPagePath, copyrightPath)
+			if gotTitle, gotCopyright, err := f.downloadInteriorPages(iaID, title, copyright); err == nil {
+				if gotTitle {
+					imageSet.Set(KindTitlePage, titlePath, SourceInternetArchive)
+				}
+				if gotCopyright {
+					imageSet.Set(KindCopyrightPage, copyrightPath, SourceInternetArchive)
+				}
+				slog.Info("Downloaded interior pages from IA", "isbn", isbn, "ia_id", iaID)
+			} else {
+				slog.Warn("Failed to download interior pages from IA", "isbn", isbn, "ia_id", iaID, "error", err)
+			}
 		} else {
-			slog.Warn("Failed to download interior pages from IA", "isbn", isbn, "ia_id", iaID, "error", err)
+			slog.Debug("No Internet Archive ID found", "isbn", isbn, "error", err)
 		}
-	} else {
-		slog.Debug("No Internet Archive ID found", "isbn", isbn, "error", err)
 	}
 
 	// Step 3: If we don't have interior pages yet, try Google Books
 	if imageSet.TitlePagePath == "" || imageSet.CopyrightPagePath == "" {
 		slog.Info("Trying Google Books for interior pages", "isbn", isbn)
 
-		// Rate limiting
-		time.Sleep(500 * time.Millisecond)
-
 		if err := f.downloadGoogleBooksPages(isbn, imageSet, outputDir, titlePath, copyrightPath); err == nil {
 			slog.Info("Downloaded pages from Google Books", "isbn", isbn)
 		} else {
@@ -107,44 +199,92 @@ func (f *Fetcher) FetchImagesForISBN(isbn string, outputDir string) (*ImageSet,
 	}
 
 	// Check if we got at least one image
-	if imageSet.CoverPath == "" && imageSet.TitlePagePath == "" && imageSet.CopyrightPagePath == "" {
+	if imageSet.Empty() {
 		return nil, fmt.Errorf("no images could be downloaded for ISBN %s", isbn)
 	}
 
+	for kind, source := range imageSet.Sources {
+		if !cached[kind] {
+			f.Cache.Put(isbn, source, kind, imageSet.Get(kind))
+		}
+	}
 	return imageSet, nil
 }
 
-// downloadCoverImage downloads a book cover from Open Library Covers API
-func (f *Fetcher) downloadCoverImage(isbn, outputPath string) error {
-	// Open Library Covers API: https://covers.openlibrary.org/b/isbn/{ISBN}-L.jpg
-	url := fmt.Sprintf("https://covers.openlibrary.org/b/isbn/%s-L.jpg", isbn)
+// missingPath returns path when the image isn't there yet, else "" to skip downloading it
+func missingPath(have, path string) string {
+	if have != "" {
+		return ""
+	}
+	return path
+}
 
+// openLibraryCoverSizes are the Covers API sizes, largest first; many ISBNs only have a
+// medium or small cover
+var openLibraryCoverSizes = []string{"L", "M", "S"}
+
+// minCoverEdge is the smallest width and height accepted for a cover; placeholders are 1x1
+const minCoverEdge = 20
+
+// downloadCoverImage downloads a book cover from Open Library Covers API in the largest size
+// available, and returns that size. Covers are cached by size.
+func (f *Fetcher) downloadCoverImage(isbn, outputPath string) (string, error) {
+	for _, size := range openLibraryCoverSizes {
+		if f.Cache.Get(isbn, SourceOpenLibrary, "cover_"+size, outputPath) {
+			return size, nil
+		}
+	}
+
+	var errs []error
+	for _, size := range openLibraryCoverSizes {
+		// Open Library Covers API: https://covers.openlibrary.org/b/isbn/{ISBN}-{S,M,L}.jpg
+		// default=false makes a missing cover a 404 instead of a placeholder
+		url := fmt.Sprintf("https://covers.openlibrary.org/b/isbn/%s-%s.jpg?default=false", isbn, size)
+		width, height, err := f.downloadCover(url, outputPath)
+		if err == nil {
+			slog.Debug("Downloaded Open Library cover", "isbn", isbn, "size", size, "width", width, "height", height)
+			f.Cache.Put(isbn, SourceOpenLibrary, "cover_"+size, outputPath)
+			return size, nil
+		}
+		if sourceUnavailable(err) {
+			return "", err
+		}
+		errs = append(errs, fmt.Errorf("size %s: %w", size, err))
+	}
+	return "", errors.Join(errs...)
+}
+
+// downloadCover downloads a cover image, checking its dimensions rather than its byte count,
+// and returns them
+func (f *Fetcher) downloadCover(url, outputPath string) (int, int, error) {
 	resp, err := f.HTTPClient.Get(url)
 	if err != nil {
-		return fmt.Errorf("failed to fetch cover: %w", err)
+		return 0, 0, fmt.Errorf("failed to fetch cover: %w", err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("cover API returned status %d", resp.StatusCode)
+		return 0, 0, fmt.Errorf("cover API returned status %d", resp.StatusCode)
 	}
 
-	// Check if it's a placeholder image (sometimes OL returns a tiny placeholder)
 	imageData, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return fmt.Errorf("failed to read cover data: %w", err)
+		return 0, 0, fmt.Errorf("failed to read cover data: %w", err)
 	}
 
-	// If image is too small, it's probably a placeholder
-	if len(imageData) < 1000 {
-		return fmt.Errorf("cover image too small (likely placeholder)")
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(imageData))
+	if err != nil {
+		return 0, 0, fmt.Errorf("cover is not a readable image: %w", err)
+	}
+	if cfg.Width < minCoverEdge || cfg.Height < minCoverEdge {
+		return 0, 0, fmt.Errorf("cover is %dx%d (likely placeholder)", cfg.Width, cfg.Height)
 	}
 
 	if err := os.WriteFile(outputPath, imageData, 0644); err != nil {
-		return fmt.Errorf("failed to write cover file: %w", err)
+		return 0, 0, fmt.Errorf("failed to write cover file: %w", err)
 	}
 
-	return nil
+	return cfg.Width, cfg.Height, nil
 }
 
 // getInternetArchiveID queries Open Library Books API to get the Internet Archive identifier
@@ -177,8 +317,9 @@ func (f *Fetcher) getInternetArchiveID(isbn string) (string, error) {
 	return "", fmt.Errorf("no Internet Archive identifier found for ISBN %s", isbn)
 }
 
-// downloadInteriorPages downloads title and copyright pages from Internet Archive
-func (f *Fetcher) downloadInteriorPages(iaID, titlePath, copyrightPath string) error {
+// downloadInteriorPages downloads title and copyright pages from Internet Archive, skipping a
+// page whose path is empty, and reports which were downloaded
+func (f *Fetcher) downloadInteriorPages(iaID, titlePath, copyrightPath string) (bool, bool, error) {
 	// Internet Archive BookReader Images:
 	// https://archive.org/download/{identifier}/{identifier}_jp2.zip/{identifier}_jp2/{identifier}_{page}.jp2
 	//
@@ -193,47 +334,53 @@ func (f *Fetcher) downloadInteriorPages(iaID, titlePath, copyrightPath string) e
 
 	// Try to download title page
 	titleDownloaded := false
-	for i, pageNum := range titlePageNums {
+	for _, pageNum := range titlePageNums {
+		if titlePath == "" {
+			break
+		}
 		url := fmt.Sprintf("https://archive.org/download/%s/page/n%d_w800.jpg", iaID, pageNum)
-		if err := f.downloadImage(url, titlePath); err == nil {
+		err := f.downloadImage(url, titlePath)
+		if err == nil {
 			titleDownloaded = true
 			slog.Debug("Downloaded title page", "ia_id", iaID, "page", pageNum)
 			break
 		}
-		// Small delay between page attempts to avoid hammering IA
-		if i < len(titlePageNums)-1 {
-			time.Sleep(200 * time.Millisecond)
+		if sourceUnavailable(err) {
+			return false, false, err
 		}
 	}
 
-	if !titleDownloaded {
+	if !titleDownloaded && titlePath != "" {
 		slog.Warn("Could not download title page", "ia_id", iaID)
 	}
 
 	// Try to download copyright page
 	copyrightDownloaded := false
-	for i, pageNum := range copyrightPageNums {
+	for _, pageNum := range copyrightPageNums {
+		if copyrightPath == "" {
+			break
+		}
 		url := fmt.Sprintf("https://archive.org/download/%s/page/n%d_w800.jpg", iaID, pageNum)
-		if err := f.downloadImage(url, copyrightPath); err == nil {
+		err := f.downloadImage(url, copyrightPath)
+		if err == nil {
 			copyrightDownloaded = true
 			slog.Debug("Downloaded copyright page", "ia_id", iaID, "page", pageNum)
 			break
 		}
-		// Small delay between page attempts to avoid hammering IA
-		if i < len(copyrightPageNums)-1 {
-			time.Sleep(200 * time.Millisecond)
+		if sourceUnavailable(err) {
+			break
 		}
 	}
 
-	if !copyrightDownloaded {
+	if !copyrightDownloaded && copyrightPath != "" {
 		slog.Warn("Could not download copyright page", "ia_id", iaID)
 	}
 
 	if !titleDownloaded && !copyrightDownloaded {
-		return fmt.Errorf("failed to download any interior pages")
+		return false, false, fmt.Errorf("failed to download any interior pages")
 	}
 
-	return nil
+	return titleDownloaded, copyrightDownloaded, nil
 }
 
 // downloadImage downloads an image from a URL to a file
@@ -271,31 +418,31 @@ func (f *Fetcher) downloadImage(url, outputPath string) error {
 	return nil
 }
 
-// downloadGoogleBooksPages attempts to download interior pages from Google Books
-func (f *Fetcher) downloadGoogleBooksPages(isbn string, imageSet *ImageSet, outputDir, titlePath, copyrightPath string) error {
+// Google Books page IDs tried for the title and copyright pages. "PA" IDs are numbered pages and
+// "PP" IDs front matter; the title page is typically around PA5-PA10, copyright around PA2-PA6.
+var (
+	googleBooksTitlePages     = []string{"PA7", "PA6", "PA5", "PA8", "PA9", "PA10", "PP1", "PP2"}
+	googleBooksCopyrightPages = []string{"PA4", "PA5", "PA3", "PA6", "PA2", "PP3", "PP4"}
+)
+
+// googleBooksVolume returns the ID of the Google Books volume for an ISBN, if it has preview pages
+func (f *Fetcher) googleBooksVolume(isbn string) (string, error) {
 	// Google Books API to get volume info
 	url := fmt.Sprintf("https://www.googleapis.com/books/v1/volumes?q=isbn:%s", isbn)
 
 	resp, err := f.HTTPClient.Get(url)
 	if err != nil {
-		return fmt.Errorf("failed to query Google Books API: %w", err)
+		return "", fmt.Errorf("failed to query Google Books API: %w", err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("google Books API returned status %d", resp.StatusCode)
+		return "", fmt.Errorf("google Books API returned status %d", resp.StatusCode)
 	}
 
 	var result struct {
 		Items []struct {
 			ID         string `json:"id"`
-			VolumeInfo struct {
-				Title      string            `json:"title"`
-				ImageLinks map[string]string `json:"imageLinks"`
-				AccessInfo struct {
-					Viewability string `json:"viewability"`
-				} `json:"accessInfo"`
-			} `json:"volumeInfo"`
 			AccessInfo struct {
 				Viewability string `json:"viewability"`
 			} `json:"accessInfo"`
@@ -303,11 +450,11 @@ func (f *Fetcher) downloadGoogleBooksPages(isbn string, imageSet *ImageSet, outp
 	}
 
 	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return fmt.Errorf("failed to decode Google Books response: %w", err)
+		return "", fmt.Errorf("failed to decode Google Books response: %w", err)
 	}
 
 	if len(result.Items) == 0 {
-		return fmt.Errorf("no books found in Google Books for ISBN %s", isbn)
+		return "", fmt.Errorf("no books found in Google Books for ISBN %s", isbn)
 	}
 
 	volumeID := result.Items[0].ID
@@ -315,60 +462,76 @@ func (f *Fetcher) downloadGoogleBooksPages(isbn string, imageSet *ImageSet, outp
 
 	// Only proceed if the book has some preview available
 	if viewability == "NO_PAGES" {
-		return fmt.Errorf("no preview pages available in Google Books for ISBN %s", isbn)
+		return "", fmt.Errorf("no preview pages available in Google Books for ISBN %s", isbn)
 	}
 
 	slog.Info("Found Google Books volume", "isbn", isbn, "volume_id", volumeID, "viewability", viewability)
+	return volumeID, nil
+}
+
+// googleBooksPageURL is the image of a page of a volume; zoom=1 gives large images (zoom=5 is
+// for thumbnails) and w=1280 sets the maximum width
+func googleBooksPageURL(volumeID, pageID string) string {
+	return fmt.Sprintf("https://books.google.com/books/content?id=%s&pg=%s&img=1&zoom=1&hl=en&w=1280", volumeID, pageID)
+}
+
+// downloadGoogleBooksPage downloads the first of pageIDs a volume's preview has, and returns
+// its page ID
+func (f *Fetcher) downloadGoogleBooksPage(volumeID string, pageIDs []string, outputPath string) (string, error) {
+	for _, pageID := range pageIDs {
+		url := googleBooksPageURL(volumeID, pageID)
+		slog.Debug("Trying Google Books page URL", "url", url)
+		err := f.downloadImage(url, outputPath)
+		if err == nil {
+			return pageID, nil
+		}
+		if sourceUnavailable(err) {
+			return "", err
+		}
+	}
+	return "", fmt.Errorf("none of pages %s are in the preview", strings.Join(pageIDs, ", "))
+}
+
+// downloadGoogleBooksPages attempts to download interior pages from Google Books
+func (f *Fetcher) downloadGoogleBooksPages(isbn string, imageSet *ImageSet, outputDir, titlePath, copyrightPath string) error {
+	volumeID, err := f.googleBooksVolume(isbn)
+	if err != nil {
+		return err
+	}
 
 	// Try to download cover if we don't have one yet
 	if imageSet.CoverPath == "" {
 		coverURL := fmt.Sprintf("https://books.google.com/books/content?id=%s&printsec=frontcover&img=1&zoom=1&hl=en&w=1280", volumeID)
 		coverPath := filepath.Join(outputDir, fmt.Sprintf("%s_cover.jpg", isbn))
 		if err := f.downloadImage(coverURL, coverPath); err == nil {
-			imageSet.CoverPath = coverPath
+			imageSet.Set(KindCover, coverPath, SourceGoogleBooks)
 			slog.Info("Downloaded cover from Google Books", "isbn", isbn)
 		} else {
 			slog.Debug("Failed to download cover from Google Books", "isbn", isbn, "error", err)
 		}
-		time.Sleep(500 * time.Millisecond)
 	}
 
-	// Try to download specific pages using Google Books image API
-	// Google Books uses page IDs like "PA1", "PA2", etc for page numbers
-	// Title page is typically around PA5-PA10, copyright around PA2-PA6
-
 	titleDownloaded := false
 	copyrightDownloaded := false
 
-	// Try title page - typically pages 5-10
-	// Use zoom=1 for larger images (Google Books uses zoom=5 for thumbnails, zoom=1 for larger)
-	titlePages := []string{"PA7", "PA6", "PA5", "PA8", "PA9", "PA10", "PP1", "PP2"}
-	for _, pageID := range titlePages {
-		url := fmt.Sprintf("https://books.google.com/books/content?id=%s&pg=%s&img=1&zoom=1&hl=en&w=1280", volumeID, pageID)
-		slog.Debug("Trying title page URL", "url", url)
-		if err := f.downloadImage(url, titlePath); err == nil {
+	if imageSet.TitlePagePath == "" {
+		pageID, err := f.downloadGoogleBooksPage(volumeID, googleBooksTitlePages, titlePath)
+		if sourceUnavailable(err) {
+			return err
+		}
+		if err == nil {
 			titleDownloaded = true
-			imageSet.TitlePagePath = titlePath
+			imageSet.Set(KindTitlePage, titlePath, SourceGoogleBooks)
 			slog.Debug("Downloaded title page from Google Books", "isbn", isbn, "page", pageID)
-			break
 		}
-		// Increased sleep to avoid rate limiting
-		time.Sleep(500 * time.Millisecond)
 	}
 
-	// Try copyright page - typically pages 2-6
-	// Use zoom=1 for larger images
-	copyrightPages := []string{"PA4", "PA5", "PA3", "PA6", "PA2", "PP3", "PP4"}
-	for _, pageID := range copyrightPages {
-		url := fmt.Sprintf("https://books.google.com/books/content?id=%s&pg=%s&img=1&zoom=1&hl=en&w=1280", volumeID, pageID)
-		if err := f.downloadImage(url, copyrightPath); err == nil {
+	if imageSet.CopyrightPagePath == "" {
+		if pageID, err := f.downloadGoogleBooksPage(volumeID, googleBooksCopyrightPages, copyrightPath); err == nil {
 			copyrightDownloaded = true
-			imageSet.CopyrightPagePath = copyrightPath
+			imageSet.Set(KindCopyrightPage, copyrightPath, SourceGoogleBooks)
 			slog.Debug("Downloaded copyright page from Google Books", "isbn", isbn, "page", pageID)
-			break
 		}
-		// Increased sleep to avoid rate limiting
-		time.Sleep(500 * time.Millisecond)
 	}
 
 	if !titleDownloaded && !copyrightDownloaded {
@@ -378,6 +541,41 @@ func (f *Fetcher) downloadGoogleBooksPages(isbn string, imageSet *ImageSet, outp
 	return nil
 }
 
+// TitlePageSources are the sources FetchTitlePage can download a title page from
+var TitlePageSources = []string{SourceInternetArchive, SourceGoogleBooks}
+
+// FetchTitlePage downloads the title page of an ISBN from one source (internetarchive or
+// googlebooks), so the same book can be compared across image sources
+func (f *Fetcher) FetchTitlePage(isbn, source, outputPath string) error {
+	if f.Cache.Get(isbn, source, KindTitlePage, outputPath) {
+		return nil
+	}
+
+	switch source {
+	case SourceInternetArchive:
+		iaID, err := f.getInternetArchiveID(isbn)
+		if err != nil {
+			return err
+		}
+		if _, _, err := f.downloadInteriorPages(iaID, outputPath, ""); err != nil {
+			return err
+		}
+	case SourceGoogleBooks:
+		volumeID, err := f.googleBooksVolume(isbn)
+		if err != nil {
+			return err
+		}
+		if _, err := f.downloadGoogleBooksPage(volumeID, googleBooksTitlePages, outputPath); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("unknown title page source %q (%s)", source, strings.Join(TitlePageSources, ", "))
+	}
+
+	f.Cache.Put(isbn, source, KindTitlePage, outputPath)
+	return nil
+}
+
 // CleanISBN removes hyphens, parenthetical text, and normalizes ISBN
 func CleanISBN(isbn string) string {
 	// Trim whitespace
@@ -403,49 +601,22 @@ func CleanISBN(isbn string) string {
 func DownloadGoogleBooksPages(f *Fetcher, isbn string, outputDir string, numPages int) (int, error) {
 	slog.Info("Downloading Google Books pages", "isbn", isbn, "pages", numPages)
 
-	// Step 1: Get volume ID from Google Books API
-	url := fmt.Sprintf("https://www.googleapis.com/books/v1/volumes?q=isbn:%s", isbn)
-
-	resp, err := f.HTTPClient.Get(url)
-	if err != nil {
-		return 0, fmt.Errorf("failed to query Google Books API: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return 0, fmt.Errorf("google Books API returned status %d", resp.StatusCode)
+	// Use the cache only when it has every page, since which pages a preview has is unknown
+	cachedPages := 0
+	for cachedPages < numPages && f.Cache.Get(isbn, SourceGoogleBooks, fmt.Sprintf("page_%d", cachedPages+1), filepath.Join(outputDir, fmt.Sprintf("page_%d.jpg", cachedPages+1))) {
+		cachedPages++
 	}
-
-	var result struct {
-		Items []struct {
-			ID         string `json:"id"`
-			VolumeInfo struct {
-				Title string `json:"title"`
-			} `json:"volumeInfo"`
-			AccessInfo struct {
-				Viewability string `json:"viewability"`
-			} `json:"accessInfo"`
-		} `json:"items"`
-	}
-
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return 0, fmt.Errorf("failed to decode Google Books response: %w", err)
+	if cachedPages == numPages {
+		slog.Info("Using cached Google Books pages", "isbn", isbn, "pages", cachedPages)
+		return cachedPages, nil
 	}
 
-	if len(result.Items) == 0 {
-		return 0, fmt.Errorf("no books found in Google Books for ISBN %s", isbn)
-	}
-
-	volumeID := result.Items[0].ID
-	viewability := result.Items[0].AccessInfo.Viewability
-
-	// Only proceed if the book has some preview available
-	if viewability == "NO_PAGES" {
-		return 0, fmt.Errorf("no preview pages available in Google Books for ISBN %s", isbn)
+	// Step 1: Get volume ID from Google Books API
+	volumeID, err := f.googleBooksVolume(isbn)
+	if err != nil {
+		return 0, err
 	}
 
-	slog.Info("Found Google Books volume", "isbn", isbn, "volume_id", volumeID, "viewability", viewability)
-
 	// Step 2: Download pages
 	// Google Books uses page IDs like "PA1", "PA2", "PP1", "PP2" etc.
 	// PA = Page numbers, PP = Front matter (typically roman numerals)
@@ -469,24 +640,24 @@ func DownloadGoogleBooksPages(f *Fetcher, isbn string, outputDir string, numPage
 		}
 
 		// Construct Google Books page image URL
-		// zoom=1 gives us high-quality images, w=1280 sets max width
-		pageURL := fmt.Sprintf("https://books.google.com/books/content?id=%s&pg=%s&img=1&zoom=1&hl=en&w=1280", volumeID, pageID)
+		pageURL := googleBooksPageURL(volumeID, pageID)
 
 		// Output path for this page
 		outputPath := filepath.Join(outputDir, fmt.Sprintf("page_%d.jpg", pagesDownloaded+1))
 
 		slog.Debug("Attempting to download page", "isbn", isbn, "page_id", pageID, "output", outputPath)
 
-		// Try to download the page
+		// Try to download the page; requests are spaced by the fetcher's Google Books limits
 		if err := f.downloadImage(pageURL, outputPath); err == nil {
 			pagesDownloaded++
+			f.Cache.Put(isbn, SourceGoogleBooks, fmt.Sprintf("page_%d", pagesDownloaded), outputPath)
 			slog.Debug("Successfully downloaded page", "isbn", isbn, "page_id", pageID, "count", pagesDownloaded)
+		} else if sourceUnavailable(err) {
+			slog.Warn("Stopping Google Books downloads", "isbn", isbn, "error", err)
+			break
 		} else {
 			slog.Debug("Failed to download page", "isbn", isbn, "page_id", pageID, "error", err)
 		}
-
-		// Rate limiting - be respectful to Google Books
-		time.Sleep(500 * time.Millisecond)
 	}
 
 	if pagesDownloaded == 0 {
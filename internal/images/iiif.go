@@ -0,0 +1,366 @@
+package images
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"path/filepath"
+	"strings"
+)
+
+// iiifTargetEdge is the long edge, in pixels, requested from IIIF image services: enough
+// for OCR of a page without downloading archival masters
+const iiifTargetEdge = 2000
+
+// iiifCanvas is a page of a IIIF manifest
+type iiifCanvas struct {
+	ID      string
+	Label   string
+	Service string // Image API service base URL, if any
+	Image   string // Direct image URL, used when there is no service
+}
+
+// iiifInfo is the part of an Image API info.json used to choose a size
+type iiifInfo struct {
+	Context   any    `json:"@context"`
+	ID        string `json:"id"`
+	AtID      string `json:"@id"`
+	Width     int    `json:"width"`
+	Height    int    `json:"height"`
+	MaxWidth  int    `json:"maxWidth"`
+	MaxHeight int    `json:"maxHeight"`
+	MaxArea   int    `json:"maxArea"`
+	Sizes     []struct {
+		Width  int `json:"width"`
+		Height int `json:"height"`
+	} `json:"sizes"`
+	Profile json.RawMessage `json:"profile"`
+}
+
+// FetchImagesFromIIIF downloads cover, title page and copyright page images from a IIIF
+// Presentation manifest (v2 or v3), or a single cover image from an Image API service
+func (f *Fetcher) FetchImagesFromIIIF(resourceURL, outputDir string) (*ImageSet, error) {
+	imageSet := &ImageSet{}
+	if err := f.fetchIIIF(resourceURL, KindCover, outputDir, imageSet); err != nil {
+		return nil, err
+	}
+	if imageSet.Empty() {
+		return nil, fmt.Errorf("no images could be downloaded from %s", resourceURL)
+	}
+	return imageSet, nil
+}
+
+// fetchIIIF adds the images a IIIF resource provides to imageSet. A manifest provides the
+// front matter pages it has; an image service provides one image of the given kind.
+func (f *Fetcher) fetchIIIF(resourceURL, kind, outputDir string, imageSet *ImageSet) error {
+	if strings.HasSuffix(resourceURL, "/info.json") {
+		return f.fetchIIIFService(strings.TrimSuffix(resourceURL, "/info.json"), kind, outputDir, imageSet)
+	}
+
+	data, err := f.getJSON(resourceURL)
+	if err != nil {
+		// An image service base URL need not serve info.json itself
+		return f.fetchIIIFService(resourceURL, kind, outputDir, imageSet)
+	}
+	var probe struct {
+		Type   string `json:"type"`
+		AtType string `json:"@type"`
+	}
+	if err := json.Unmarshal(data, &probe); err != nil {
+		return fmt.Errorf("invalid IIIF JSON: %w", err)
+	}
+	if probe.Type == "Manifest" || probe.AtType == "sc:Manifest" {
+		canvases, start, err := parseIIIFManifest(data)
+		if err != nil {
+			return err
+		}
+		return f.fetchIIIFCanvases(canvases, start, outputDir, imageSet)
+	}
+	return f.fetchIIIFService(resourceURL, kind, outputDir, imageSet)
+}
+
+// fetchIIIFCanvases downloads the front matter canvases for the kinds missing from imageSet
+func (f *Fetcher) fetchIIIFCanvases(canvases []iiifCanvas, start, outputDir string, imageSet *ImageSet) error {
+	picked := pickIIIFCanvases(canvases, start)
+	for _, fm := range frontMatterPages {
+		i, ok := picked[fm.kind]
+		if !ok || imageSet.Get(fm.kind) != "" {
+			continue
+		}
+		c := canvases[i]
+		var err error
+		if c.Service != "" {
+			err = f.fetchIIIFService(c.Service, fm.kind, outputDir, imageSet)
+		} else if c.Image != "" {
+			outputPath := filepath.Join(outputDir, fm.kind+".jpg")
+			if err = f.downloadLinkImage(c.Image, outputPath); err == nil {
+				imageSet.Set(fm.kind, outputPath, SourceIIIF)
+			}
+		}
+		if err != nil {
+			slog.Warn("Failed to download IIIF canvas", "canvas", c.ID, "kind", fm.kind, "error", err)
+		}
+	}
+	return nil
+}
+
+// fetchIIIFService downloads an image from an Image API service, sized from its info.json
+func (f *Fetcher) fetchIIIFService(base, kind, outputDir string, imageSet *ImageSet) error {
+	base = strings.TrimSuffix(base, "/")
+	data, err := f.getJSON(base + "/info.json")
+	if err != nil {
+		return fmt.Errorf("failed to read IIIF image info: %w", err)
+	}
+	var info iiifInfo
+	if err := json.Unmarshal(data, &info); err != nil {
+		return fmt.Errorf("invalid IIIF image info: %w", err)
+	}
+	if id := info.ID + info.AtID; id != "" {
+		base = strings.TrimSuffix(id, "/")
+	}
+
+	outputPath := filepath.Join(outputDir, kind+".jpg")
+	if err := f.downloadLinkImage(base+"/full/"+info.sizeParam(iiifTargetEdge)+"/0/default.jpg", outputPath); err != nil {
+		return err
+	}
+	imageSet.Set(kind, outputPath, SourceIIIF)
+	return nil
+}
+
+// version is the Image API major version of the service
+func (info iiifInfo) version() int {
+	if ctx, _ := json.Marshal(info.Context); strings.Contains(string(ctx), "/image/3/") {
+		return 3
+	}
+	return 2
+}
+
+// level0 reports whether the service only serves the sizes it lists
+func (info iiifInfo) level0() bool {
+	return strings.Contains(string(info.Profile), "level0")
+}
+
+// sizeParam is the size segment of an image request whose long edge is about target pixels,
+// or the full image when it is smaller. Level 0 services get the closest listed size.
+func (info iiifInfo) sizeParam(target int) string {
+	full := "full"
+	if info.version() == 3 {
+		full = "max"
+	}
+
+	if info.level0() {
+		best := -1
+		for i, s := range info.Sizes {
+			if best < 0 || abs(max(s.Width, s.Height)-target) < abs(max(info.Sizes[best].Width, info.Sizes[best].Height)-target) {
+				best = i
+			}
+		}
+		if best < 0 {
+			return full
+		}
+		if info.version() == 3 {
+			return fmt.Sprintf("%d,%d", info.Sizes[best].Width, info.Sizes[best].Height)
+		}
+		return fmt.Sprintf("%d,", info.Sizes[best].Width)
+	}
+
+	if info.Width <= 0 || info.Height <= 0 || max(info.Width, info.Height) <= target {
+		return full
+	}
+	width := info.Width * target / max(info.Width, info.Height)
+	if info.MaxWidth > 0 {
+		width = min(width, info.MaxWidth)
+	}
+	if info.MaxHeight > 0 {
+		width = min(width, info.MaxHeight*info.Width/info.Height)
+	}
+	if info.MaxArea > 0 {
+		for width > 1 && width*(width*info.Height/info.Width) > info.MaxArea {
+			width = width * 9 / 10
+		}
+	}
+	return fmt.Sprintf("%d,", width)
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+// pickIIIFCanvases chooses the canvas for each front matter kind: by label ("Front cover",
+// "Title page", "t.p. verso"), then the title page from the manifest's start canvas with
+// its verso after it, then the usual page positions
+func pickIIIFCanvases(canvases []iiifCanvas, start string) map[string]int {
+	picked := make(map[string]int)
+	for i, c := range canvases {
+		if kind := linkRole(c.Label); kind != "" {
+			if _, ok := picked[kind]; !ok {
+				picked[kind] = i
+			}
+		}
+	}
+
+	if _, ok := picked[KindTitlePage]; !ok && start != "" {
+		for i, c := range canvases {
+			if c.ID == start {
+				picked[KindTitlePage] = i
+				break
+			}
+		}
+	}
+	if _, ok := picked[KindCopyrightPage]; !ok {
+		if title, ok := picked[KindTitlePage]; ok && title+1 < len(canvases) {
+			picked[KindCopyrightPage] = title + 1
+		}
+	}
+
+	for _, fm := range frontMatterPages {
+		if _, ok := picked[fm.kind]; !ok && fm.page <= len(canvases) {
+			picked[fm.kind] = fm.page - 1
+		}
+	}
+	return picked
+}
+
+// parseIIIFManifest reads the canvases and start canvas of a Presentation API v2 or v3 manifest
+func parseIIIFManifest(data []byte) ([]iiifCanvas, string, error) {
+	var m struct {
+		// v3
+		Items []struct {
+			ID    string          `json:"id"`
+			Label json.RawMessage `json:"label"`
+			Items []struct {
+				Items []struct {
+					Body struct {
+						ID      string          `json:"id"`
+						Service json.RawMessage `json:"service"`
+					} `json:"body"`
+				} `json:"items"`
+			} `json:"items"`
+		} `json:"items"`
+		Start struct {
+			ID string `json:"id"`
+		} `json:"start"`
+
+		// v2
+		Sequences []struct {
+			StartCanvas string `json:"startCanvas"`
+			Canvases    []struct {
+				ID     string          `json:"@id"`
+				Label  json.RawMessage `json:"label"`
+				Images []struct {
+					Resource struct {
+						ID      string          `json:"@id"`
+						Service json.RawMessage `json:"service"`
+					} `json:"resource"`
+				} `json:"images"`
+			} `json:"canvases"`
+		} `json:"sequences"`
+	}
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, "", fmt.Errorf("invalid IIIF manifest: %w", err)
+	}
+
+	var canvases []iiifCanvas
+	start := m.Start.ID
+	for _, c := range m.Items {
+		canvas := iiifCanvas{ID: c.ID, Label: iiifLabel(c.Label)}
+		if len(c.Items) > 0 && len(c.Items[0].Items) > 0 {
+			body := c.Items[0].Items[0].Body
+			canvas.Image, canvas.Service = body.ID, iiifServiceID(body.Service)
+		}
+		canvases = append(canvases, canvas)
+	}
+	if len(m.Sequences) > 0 {
+		seq := m.Sequences[0]
+		if start == "" {
+			start = seq.StartCanvas
+		}
+		for _, c := range seq.Canvases {
+			canvas := iiifCanvas{ID: c.ID, Label: iiifLabel(c.Label)}
+			if len(c.Images) > 0 {
+				canvas.Image, canvas.Service = c.Images[0].Resource.ID, iiifServiceID(c.Images[0].Resource.Service)
+			}
+			canvases = append(canvases, canvas)
+		}
+	}
+
+	if len(canvases) == 0 {
+		return nil, "", fmt.Errorf("IIIF manifest has no canvases")
+	}
+	return canvases, start, nil
+}
+
+// iiifLabel flattens a label: a v2 string, list or {"@value"} object, or a v3 language map
+func iiifLabel(raw json.RawMessage) string {
+	var s string
+	if json.Unmarshal(raw, &s) == nil {
+		return s
+	}
+	var langMap map[string][]string
+	if json.Unmarshal(raw, &langMap) == nil {
+		var parts []string
+		for _, values := range langMap {
+			parts = append(parts, values...)
+		}
+		return strings.Join(parts, " ")
+	}
+	var list []json.RawMessage
+	if json.Unmarshal(raw, &list) == nil {
+		var parts []string
+		for _, item := range list {
+			parts = append(parts, iiifLabel(item))
+		}
+		return strings.Join(parts, " ")
+	}
+	var value struct {
+		Value string `json:"@value"`
+	}
+	json.Unmarshal(raw, &value)
+	return value.Value
+}
+
+// iiifServiceID returns the id of the first image service in a v2 service object or v3 list
+func iiifServiceID(raw json.RawMessage) string {
+	type service struct {
+		ID   string `json:"id"`
+		AtID string `json:"@id"`
+	}
+	var one service
+	if json.Unmarshal(raw, &one) == nil && one.ID+one.AtID != "" {
+		return one.ID + one.AtID
+	}
+	var list []service
+	if json.Unmarshal(raw, &list) == nil {
+		for _, s := range list {
+			if s.ID+s.AtID != "" {
+				return s.ID + s.AtID
+			}
+		}
+	}
+	return ""
+}
+
+func (f *Fetcher) getJSON(jsonURL string) ([]byte, error) {
+	req, err := http.NewRequest(http.MethodGet, jsonURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/ld+json, application/json")
+	resp, err := f.HTTPClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch %s: %w", jsonURL, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s returned status %d", jsonURL, resp.StatusCode)
+	}
+	if ct := resp.Header.Get("Content-Type"); ct != "" && !strings.Contains(ct, "json") {
+		return nil, fmt.Errorf("%s returned %s, not JSON", jsonURL, ct)
+	}
+	return io.ReadAll(io.LimitReader(resp.Body, 32<<20))
+}
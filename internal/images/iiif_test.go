@@ -0,0 +1,128 @@
+package images
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const manifestV3 = `{
+  "@context": "http://iiif.io/api/presentation/3/context.json",
+  "id": "https://iiif.example.edu/book1/manifest",
+  "type": "Manifest",
+  "start": {"id": "https://iiif.example.edu/book1/canvas/p5", "type": "Canvas"},
+  "items": [
+    {"id": "https://iiif.example.edu/book1/canvas/p1", "type": "Canvas", "label": {"en": ["Front cover"]},
+     "items": [{"type": "AnnotationPage", "items": [{"type": "Annotation", "body": {"id": "https://iiif.example.edu/p1/full/max/0/default.jpg", "type": "Image",
+       "service": [{"id": "https://iiif.example.edu/p1", "type": "ImageService3"}]}}]}]},
+    {"id": "https://iiif.example.edu/book1/canvas/p2", "type": "Canvas", "label": {"none": ["[i]"]}},
+    {"id": "https://iiif.example.edu/book1/canvas/p3", "type": "Canvas", "label": {"none": ["[ii]"]}},
+    {"id": "https://iiif.example.edu/book1/canvas/p4", "type": "Canvas", "label": {"none": ["[iii]"]}},
+    {"id": "https://iiif.example.edu/book1/canvas/p5", "type": "Canvas", "label": {"none": ["[iv]"]}},
+    {"id": "https://iiif.example.edu/book1/canvas/p6", "type": "Canvas", "label": {"none": ["[v]"]}}
+  ]
+}`
+
+const manifestV2 = `{
+  "@context": "http://iiif.io/api/presentation/2/context.json",
+  "@id": "https://iiif.example.edu/book2/manifest.json",
+  "@type": "sc:Manifest",
+  "sequences": [{"canvases": [
+    {"@id": "c1", "label": "Cover", "images": [{"resource": {"@id": "https://iiif.example.edu/b2p1.jpg", "service": {"@id": "https://iiif.example.edu/b2p1"}}}]},
+    {"@id": "c2", "label": "Title page", "images": [{"resource": {"@id": "https://iiif.example.edu/b2p2.jpg"}}]},
+    {"@id": "c3", "label": [{"@value": "t.p. verso", "@language": "en"}], "images": []}
+  ]}]
+}`
+
+func TestParseIIIFManifest(t *testing.T) {
+	canvases, start, err := parseIIIFManifest([]byte(manifestV3))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(canvases) != 6 || start != "https://iiif.example.edu/book1/canvas/p5" {
+		t.Fatalf("v3: %d canvases, start %q", len(canvases), start)
+	}
+	if canvases[0].Label != "Front cover" || canvases[0].Service != "https://iiif.example.edu/p1" {
+		t.Errorf("v3 canvas 0 = %+v", canvases[0])
+	}
+	picked := pickIIIFCanvases(canvases, start)
+	if picked[KindCover] != 0 || picked[KindTitlePage] != 4 || picked[KindCopyrightPage] != 5 {
+		t.Errorf("v3 picked %v, want cover 0, title page 4 (start), copyright 5", picked)
+	}
+
+	canvases, start, err = parseIIIFManifest([]byte(manifestV2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(canvases) != 3 || start != "" {
+		t.Fatalf("v2: %d canvases, start %q", len(canvases), start)
+	}
+	if canvases[1].Image != "https://iiif.example.edu/b2p2.jpg" || canvases[1].Service != "" || canvases[2].Label != "t.p. verso" {
+		t.Errorf("v2 canvases = %+v", canvases)
+	}
+	picked = pickIIIFCanvases(canvases, start)
+	if picked[KindCover] != 0 || picked[KindTitlePage] != 1 || picked[KindCopyrightPage] != 2 {
+		t.Errorf("v2 picked %v, want labels used", picked)
+	}
+}
+
+func TestSizeParam(t *testing.T) {
+	tests := []struct {
+		name string
+		info string
+		want string
+	}{
+		{"small v2", `{"@context":"http://iiif.io/api/image/2/context.json","width":1200,"height":1800}`, "full"},
+		{"small v3", `{"@context":"http://iiif.io/api/image/3/context.json","width":1200,"height":1800}`, "max"},
+		{"large portrait", `{"width":4000,"height":6000}`, "1333,"},
+		{"max width", `{"width":4000,"height":6000,"maxWidth":1000}`, "1000,"},
+		{"level 0 v3", `{"@context":"http://iiif.io/api/image/3/context.json","profile":"level0","width":4000,"height":6000,"sizes":[{"width":400,"height":600},{"width":1500,"height":2250},{"width":4000,"height":6000}]}`, "1500,2250"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var info iiifInfo
+			if err := json.Unmarshal([]byte(tt.info), &info); err != nil {
+				t.Fatal(err)
+			}
+			if got := info.sizeParam(iiifTargetEdge); got != tt.want {
+				t.Errorf("sizeParam() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFetchImagesFromIIIF(t *testing.T) {
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/manifest":
+			w.Header().Set("Content-Type", "application/ld+json")
+			w.Write([]byte(strings.ReplaceAll(manifestV2, "https://iiif.example.edu", srv.URL)))
+		case r.URL.Path == "/b2p1/info.json":
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"@id":"` + srv.URL + `/b2p1","width":3000,"height":4000}`))
+		case r.URL.Path == "/b2p1/full/1500,/0/default.jpg" || r.URL.Path == "/b2p2.jpg":
+			w.Header().Set("Content-Type", "image/jpeg")
+			w.Write(make([]byte, 4000))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	dir := t.TempDir()
+	t.Setenv(CacheEnvVar, "off")
+	imageSet, err := NewFetcher().FetchImagesFromIIIF(srv.URL+"/manifest", dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if imageSet.CoverPath != filepath.Join(dir, "cover.jpg") || imageSet.TitlePagePath != filepath.Join(dir, "title_page.jpg") {
+		t.Errorf("ImageSet = %+v, want a sized cover and the title page", imageSet)
+	}
+	if imageSet.CopyrightPagePath != "" {
+		t.Errorf("CopyrightPagePath = %q, want none for a canvas without images", imageSet.CopyrightPagePath)
+	}
+}
@@ -0,0 +1,257 @@
+package images
+
+import (
+	"cmp"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/lehigh-university-libraries/cataloger/internal/marc"
+)
+
+// Image kinds, as used by dataset.ItemImages.Set
+const (
+	KindCover         = "cover"
+	KindTitlePage     = "title_page"
+	KindCopyrightPage = "copyright_page"
+)
+
+// Image sources recorded in ImageSet.Sources
+const (
+	SourceMARC856         = "856"
+	SourceIIIF            = "iiif"
+	SourceOpenLibrary     = "openlibrary"
+	SourceInternetArchive = "internetarchive"
+	SourceGoogleBooks     = "googlebooks"
+)
+
+// LinkKind is the kind of digitized content an 856 URL points to
+type LinkKind string
+
+const (
+	LinkImage LinkKind = "image"
+	LinkPDF   LinkKind = "pdf"
+	LinkIIIF  LinkKind = "iiif"
+)
+
+// DigitalLink is an 856 URL pointing to digitized content of the item
+type DigitalLink struct {
+	URL   string
+	Kind  LinkKind
+	Role  string // Image kind named by the link's label, e.g. "cover" for "Cover image"
+	Label string // $3, else $y, else $z
+}
+
+var imageExtensions = []string{".jpg", ".jpeg", ".png", ".gif", ".tif", ".tiff", ".jp2", ".webp"}
+
+// maxPDFBytes limits the size of PDFs downloaded from 856 links
+const maxPDFBytes = 200 << 20
+
+// frontMatterPages is the page each image kind is usually found on in a digitized book:
+// cover, endpaper or half title, title page, then its verso
+var frontMatterPages = []struct {
+	kind string
+	page int
+}{
+	{KindCover, 1},
+	{KindTitlePage, 3},
+	{KindCopyrightPage, 4},
+}
+
+// DigitalLinks returns the 856 URLs in a record that point to images, PDFs or IIIF resources.
+// Links to the resource itself (second indicator 0 or 1) come before related resources, and
+// landing pages such as catalog or HathiTrust records are skipped.
+func DigitalLinks(rec *marc.Record) []DigitalLink {
+	var links, related []DigitalLink
+	for _, df := range rec.Fields("856") {
+		label := cmp.Or(subfield(df, "3"), subfield(df, "y"), subfield(df, "z"))
+		for _, sf := range df.Subfields {
+			if sf.Code != "u" {
+				continue
+			}
+			u := strings.TrimSpace(sf.Value)
+			kind := linkKind(u, subfield(df, "q"))
+			if kind == "" {
+				continue
+			}
+			link := DigitalLink{URL: u, Kind: kind, Role: linkRole(label), Label: label}
+			if df.Ind2 == "2" {
+				related = append(related, link)
+			} else {
+				links = append(links, link)
+			}
+		}
+	}
+	return append(links, related...)
+}
+
+// linkKind classifies a URL by its path, or by the 856 $q media type
+func linkKind(rawURL, mediaType string) LinkKind {
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return ""
+	}
+	p := strings.ToLower(u.Path)
+	mediaType = strings.ToLower(strings.TrimSpace(mediaType))
+	switch {
+	case slices.Contains(imageExtensions, path.Ext(p)):
+		// Including IIIF image requests such as .../full/max/0/default.jpg
+		return LinkImage
+	case strings.Contains(p, "/iiif/") || strings.HasSuffix(p, "/info.json") || strings.HasSuffix(p, "/manifest") || strings.HasSuffix(p, "/manifest.json"):
+		return LinkIIIF
+	case path.Ext(p) == ".pdf" || mediaType == "application/pdf" || mediaType == "pdf":
+		return LinkPDF
+	case strings.HasPrefix(mediaType, "image/"):
+		return LinkImage
+	}
+	return ""
+}
+
+// linkRole maps an 856 label such as "Cover image" or "Title page" to an image kind
+func linkRole(label string) string {
+	label = strings.ToLower(label)
+	switch {
+	case strings.Contains(label, "cover"):
+		return KindCover
+	case strings.Contains(label, "copyright") || strings.Contains(label, "verso"):
+		return KindCopyrightPage
+	case strings.Contains(label, "title"):
+		return KindTitlePage
+	}
+	return ""
+}
+
+func subfield(df marc.DataField, code string) string {
+	for _, sf := range df.Subfields {
+		if sf.Code == code {
+			return strings.TrimSpace(sf.Value)
+		}
+	}
+	return ""
+}
+
+// FetchImagesFromLinks downloads cover, title page and copyright page images from 856 links.
+// Images and IIIF image services without a role in their label are used as the cover; IIIF
+// manifests and PDFs (rendered with pdftoppm) provide the front matter pages they have.
+func (f *Fetcher) FetchImagesFromLinks(links []DigitalLink, outputDir string) (*ImageSet, error) {
+	imageSet := &ImageSet{}
+	for _, link := range links {
+		if imageSet.Complete() {
+			break
+		}
+		switch link.Kind {
+		case LinkImage:
+			kind := cmp.Or(link.Role, KindCover)
+			if imageSet.Get(kind) != "" {
+				continue
+			}
+			ext := strings.ToLower(path.Ext(link.URL))
+			if !slices.Contains(imageExtensions, ext) {
+				ext = ".jpg"
+			}
+			outputPath := filepath.Join(outputDir, kind+ext)
+			if err := f.downloadLinkImage(link.URL, outputPath); err != nil {
+				slog.Warn("Failed to download 856 image", "url", link.URL, "error", err)
+				continue
+			}
+			imageSet.Set(kind, outputPath, SourceMARC856)
+		case LinkIIIF:
+			if err := f.fetchIIIF(link.URL, cmp.Or(link.Role, KindCover), outputDir, imageSet); err != nil {
+				slog.Warn("Failed to download 856 IIIF images", "url", link.URL, "error", err)
+			}
+		case LinkPDF:
+			if err := f.downloadPDFPages(link.URL, outputDir, imageSet); err != nil {
+				slog.Warn("Failed to render 856 PDF", "url", link.URL, "error", err)
+			}
+		}
+	}
+
+	if imageSet.Empty() {
+		return nil, fmt.Errorf("no images could be downloaded from %d 856 links", len(links))
+	}
+	return imageSet, nil
+}
+
+// downloadLinkImage downloads an image an 856 field points to. Unlike the page heuristics,
+// small images are kept since catalog cover thumbnails are often small.
+func (f *Fetcher) downloadLinkImage(imageURL, outputPath string) error {
+	resp, err := f.HTTPClient.Get(imageURL)
+	if err != nil {
+		return fmt.Errorf("failed to fetch image: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("image URL returned status %d", resp.StatusCode)
+	}
+	if ct := resp.Header.Get("Content-Type"); ct != "" && !strings.HasPrefix(ct, "image/") {
+		return fmt.Errorf("URL returned %s, not an image", ct)
+	}
+
+	imageData, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read image data: %w", err)
+	}
+	if len(imageData) < 1000 {
+		return fmt.Errorf("image too small (likely invalid)")
+	}
+
+	if err := os.WriteFile(outputPath, imageData, 0644); err != nil {
+		return fmt.Errorf("failed to write image file: %w", err)
+	}
+	return nil
+}
+
+// downloadPDFPages downloads a PDF and renders its front matter pages for the image kinds
+// not yet in imageSet
+func (f *Fetcher) downloadPDFPages(pdfURL, outputDir string, imageSet *ImageSet) error {
+	if _, err := exec.LookPath("pdftoppm"); err != nil {
+		return fmt.Errorf("pdftoppm (poppler-utils) is required for PDF links: %w", err)
+	}
+
+	resp, err := f.HTTPClient.Get(pdfURL)
+	if err != nil {
+		return fmt.Errorf("failed to fetch PDF: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("PDF URL returned status %d", resp.StatusCode)
+	}
+
+	tmp, err := os.CreateTemp("", "856-*.pdf")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	n, err := io.Copy(tmp, io.LimitReader(resp.Body, maxPDFBytes+1))
+	tmp.Close()
+	if err != nil {
+		return fmt.Errorf("failed to download PDF: %w", err)
+	}
+	if n > maxPDFBytes {
+		return fmt.Errorf("PDF is larger than %d MB", maxPDFBytes>>20)
+	}
+
+	for _, fm := range frontMatterPages {
+		if imageSet.Get(fm.kind) != "" {
+			continue
+		}
+		prefix := filepath.Join(outputDir, fm.kind)
+		page := fmt.Sprint(fm.page)
+		cmd := exec.Command("pdftoppm", "-jpeg", "-r", "150", "-f", page, "-l", page, "-singlefile", tmp.Name(), prefix)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			slog.Debug("Failed to render PDF page", "url", pdfURL, "page", fm.page, "error", err, "output", strings.TrimSpace(string(out)))
+			continue
+		}
+		imageSet.Set(fm.kind, prefix+".jpg", SourceMARC856)
+	}
+	return nil
+}
@@ -0,0 +1,94 @@
+package images
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/lehigh-university-libraries/cataloger/internal/marc"
+)
+
+func TestDigitalLinks(t *testing.T) {
+	rec, err := marc.ParseMnemonic(`=LDR  00000nam a2200000 i 4500
+=856  42$3Cover image$uhttps://covers.example.edu/9780262033848.jpg
+=856  42$3Publisher description$uhttps://catdir.loc.gov/catdir/description/123.html
+=856  40$zFull text$uhttps://babel.hathitrust.org/cgi/pt?id=mdp.39015
+=856  40$3Title page$uhttps://iiif.example.edu/iiif/3/book1-p3/info.json
+=856  41$uhttps://repository.example.edu/book1.pdf
+=856  40$uftp://files.example.edu/book1.tif`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	links := DigitalLinks(rec)
+	want := []DigitalLink{
+		{URL: "https://iiif.example.edu/iiif/3/book1-p3/info.json", Kind: LinkIIIF, Role: KindTitlePage, Label: "Title page"},
+		{URL: "https://repository.example.edu/book1.pdf", Kind: LinkPDF},
+		{URL: "https://covers.example.edu/9780262033848.jpg", Kind: LinkImage, Role: KindCover, Label: "Cover image"},
+	}
+	if len(links) != len(want) {
+		t.Fatalf("DigitalLinks() = %+v, want %+v", links, want)
+	}
+	for i := range want {
+		if links[i] != want[i] {
+			t.Errorf("link %d = %+v, want %+v", i, links[i], want[i])
+		}
+	}
+}
+
+func TestFetchImagesFromLinks(t *testing.T) {
+	jpeg := append([]byte{0xff, 0xd8, 0xff}, bytes.Repeat([]byte{0}, 2000)...)
+	var requested []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requested = append(requested, r.URL.Path)
+		switch r.URL.Path {
+		case "/iiif/book1-p3/info.json":
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"@context":"http://iiif.io/api/image/2/context.json","@id":"http://` + r.Host + `/iiif/book1-p3","width":1000,"height":1500}`))
+		case "/iiif/book1/manifest":
+			http.Error(w, "gone", http.StatusGone)
+		case "/cover.jpg", "/iiif/book1-p3/full/full/0/default.jpg":
+			w.Header().Set("Content-Type", "image/jpeg")
+			w.Write(jpeg)
+		case "/landing.jpg":
+			w.Header().Set("Content-Type", "text/html")
+			w.Write(jpeg)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	dir := t.TempDir()
+	t.Setenv(CacheEnvVar, "off")
+	f := NewFetcher()
+	imageSet, err := f.FetchImagesFromLinks([]DigitalLink{
+		{URL: srv.URL + "/landing.jpg", Kind: LinkImage, Role: KindCopyrightPage},
+		{URL: srv.URL + "/iiif/book1-p3/info.json", Kind: LinkIIIF, Role: KindTitlePage},
+		{URL: srv.URL + "/iiif/book1/manifest", Kind: LinkIIIF},
+		{URL: srv.URL + "/cover.jpg", Kind: LinkImage},
+	}, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if imageSet.TitlePagePath != filepath.Join(dir, "title_page.jpg") || imageSet.CoverPath != filepath.Join(dir, "cover.jpg") {
+		t.Errorf("ImageSet = %+v, want a title page from IIIF and a cover", imageSet)
+	}
+	if imageSet.CopyrightPagePath != "" {
+		t.Errorf("CopyrightPagePath = %q, want none for an HTML response", imageSet.CopyrightPagePath)
+	}
+	if imageSet.Sources[KindTitlePage] != SourceIIIF || imageSet.Sources[KindCover] != SourceMARC856 {
+		t.Errorf("Sources = %v, want the title page from IIIF and the cover from 856", imageSet.Sources)
+	}
+	if data, _ := os.ReadFile(imageSet.CoverPath); !bytes.Equal(data, jpeg) {
+		t.Error("cover was not written")
+	}
+	if !slices.Contains(requested, "/iiif/book1-p3/full/full/0/default.jpg") {
+		t.Errorf("requested %v, want the full title page from the IIIF service", requested)
+	}
+}
@@ -0,0 +1,331 @@
+package images
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// LimitsEnvVar overrides the politeness limits of image sources
+const LimitsEnvVar = "IMAGE_SOURCE_LIMITS"
+
+// SourceDefault holds the limits of hosts that aren't a known source (856 links, IIIF
+// servers), applied to each host separately
+const SourceDefault = "default"
+
+// forbiddenThreshold is how many 403 responses in a row pause a source. A single 403 is
+// usually a restricted item (e.g. an Internet Archive lending book) rather than a block.
+const forbiddenThreshold = 3
+
+var (
+	// ErrSourcePaused is returned for requests to a source paused after 429 or 403 responses
+	ErrSourcePaused = errors.New("image source paused")
+	// ErrDailyCap is returned for requests to a source that reached its daily cap
+	ErrDailyCap = errors.New("image source daily cap reached")
+)
+
+// SourceLimits are the politeness settings of an image source
+type SourceLimits struct {
+	MinDelay    time.Duration // Between the starts of consecutive requests
+	MaxParallel int           // Requests in flight at once; 0 for no limit
+	DailyCap    int           // Requests per calendar day; 0 for no limit
+	Pause       time.Duration // How long to stop using a source that returns 429 or keeps returning 403
+}
+
+// DefaultSourceLimits follow each source's published or observed limits: Open Library allows
+// 100 requests per 5 minutes, and unauthenticated Google Books API use is capped per day
+var DefaultSourceLimits = map[string]SourceLimits{
+	SourceOpenLibrary:     {MinDelay: time.Second, MaxParallel: 1, Pause: 15 * time.Minute},
+	SourceInternetArchive: {MinDelay: 500 * time.Millisecond, MaxParallel: 1, Pause: 15 * time.Minute},
+	SourceGoogleBooks:     {MinDelay: 500 * time.Millisecond, MaxParallel: 1, DailyCap: 1000, Pause: time.Hour},
+	SourceDefault:         {MinDelay: 200 * time.Millisecond, MaxParallel: 2, Pause: 15 * time.Minute},
+}
+
+// SourceForHost maps a request host to the image source whose limits apply: a known source,
+// else the host itself (with the default limits)
+func SourceForHost(host string) string {
+	host = strings.ToLower(host)
+	switch {
+	case host == "openlibrary.org" || strings.HasSuffix(host, ".openlibrary.org"):
+		return SourceOpenLibrary
+	case host == "archive.org" || strings.HasSuffix(host, ".archive.org"):
+		return SourceInternetArchive
+	case host == "books.google.com" || host == "www.googleapis.com" || strings.HasSuffix(host, ".googleusercontent.com"):
+		return SourceGoogleBooks
+	}
+	return host
+}
+
+// ParseSourceLimits parses limit overrides such as
+// "openlibrary:delay=2s,parallel=1;googlebooks:daily=500,pause=2h" on top of the defaults
+func ParseSourceLimits(spec string) (map[string]SourceLimits, error) {
+	limits := make(map[string]SourceLimits, len(DefaultSourceLimits))
+	for source, l := range DefaultSourceLimits {
+		limits[source] = l
+	}
+
+	for _, entry := range strings.Split(spec, ";") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		source, settings, ok := strings.Cut(entry, ":")
+		if !ok {
+			return nil, fmt.Errorf("invalid source limits %q: want source:key=value,...", entry)
+		}
+		source = strings.TrimSpace(source)
+		l, ok := limits[source]
+		if !ok {
+			l = limits[SourceDefault]
+		}
+		for _, setting := range strings.Split(settings, ",") {
+			key, value, ok := strings.Cut(strings.TrimSpace(setting), "=")
+			if !ok {
+				return nil, fmt.Errorf("invalid setting %q for %s: want key=value", setting, source)
+			}
+			var err error
+			switch key {
+			case "delay":
+				l.MinDelay, err = time.ParseDuration(value)
+			case "pause":
+				l.Pause, err = time.ParseDuration(value)
+			case "parallel":
+				l.MaxParallel, err = strconv.Atoi(value)
+			case "daily":
+				l.DailyCap, err = strconv.Atoi(value)
+			default:
+				return nil, fmt.Errorf("unknown setting %q for %s (delay, parallel, daily, pause)", key, source)
+			}
+			if err != nil {
+				return nil, fmt.Errorf("invalid %s for %s: %w", key, source, err)
+			}
+		}
+		limits[source] = l
+	}
+	return limits, nil
+}
+
+// Politeness enforces SourceLimits on the requests of an HTTP client. Daily counts and pauses
+// are saved to a state file, when set, so they carry over to later runs.
+type Politeness struct {
+	limits    map[string]SourceLimits
+	statePath string
+
+	mu      sync.Mutex
+	sources map[string]*sourceState
+}
+
+// sourceState is the usage of one source; the exported fields are saved to the state file
+type sourceState struct {
+	Day         string    `json:"day"`
+	Requests    int       `json:"requests"`
+	PausedUntil time.Time `json:"paused_until,omitzero"`
+
+	next      time.Time // Earliest start of the next request
+	forbidden int       // 403 responses in a row
+	slots     chan struct{}
+}
+
+// NewPoliteness returns a Politeness enforcing limits, persisting usage to statePath if not empty
+func NewPoliteness(limits map[string]SourceLimits, statePath string) *Politeness {
+	p := &Politeness{limits: limits, statePath: statePath, sources: make(map[string]*sourceState)}
+	if statePath == "" {
+		return p
+	}
+	data, err := os.ReadFile(statePath)
+	if err != nil {
+		return p
+	}
+	if err := json.Unmarshal(data, &p.sources); err != nil {
+		slog.Warn("Ignoring invalid image source state", "path", statePath, "error", err)
+		p.sources = make(map[string]*sourceState)
+	}
+	return p
+}
+
+// PolitenessFromEnv returns a Politeness with the limits from $IMAGE_SOURCE_LIMITS, keeping its
+// state next to the image cache when there is one
+func PolitenessFromEnv(cache *Cache) (*Politeness, error) {
+	limits, err := ParseSourceLimits(os.Getenv(LimitsEnvVar))
+	if err != nil {
+		return nil, err
+	}
+	statePath := ""
+	if cache != nil {
+		statePath = filepath.Join(cache.Dir, "sources.json")
+	}
+	return NewPoliteness(limits, statePath), nil
+}
+
+// Limits returns the limits applied to a source
+func (p *Politeness) Limits(source string) SourceLimits {
+	if l, ok := p.limits[source]; ok {
+		return l
+	}
+	return p.limits[SourceDefault]
+}
+
+// Paused returns the sources currently paused and until when
+func (p *Politeness) Paused() map[string]time.Time {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	paused := make(map[string]time.Time)
+	for source, st := range p.sources {
+		if time.Now().Before(st.PausedUntil) {
+			paused[source] = st.PausedUntil
+		}
+	}
+	return paused
+}
+
+// Transport wraps base so every request waits for its source's limits
+func (p *Politeness) Transport(base http.RoundTripper) http.RoundTripper {
+	return &politeTransport{p: p, base: base}
+}
+
+type politeTransport struct {
+	p    *Politeness
+	base http.RoundTripper
+}
+
+func (t *politeTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	source := SourceForHost(req.URL.Hostname())
+	release, err := t.p.acquire(req.Context(), source)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := t.base.RoundTrip(req)
+	release()
+	if err == nil {
+		t.p.observe(source, resp)
+	}
+	return resp, err
+}
+
+func (p *Politeness) state(source string) *sourceState {
+	st, ok := p.sources[source]
+	if !ok {
+		st = &sourceState{}
+		p.sources[source] = st
+	}
+	if st.slots == nil {
+		if n := p.Limits(source).MaxParallel; n > 0 {
+			st.slots = make(chan struct{}, n)
+		}
+	}
+	return st
+}
+
+// acquire waits until a request to source may start, counting it against the daily cap, and
+// returns a function to call when the request is done
+func (p *Politeness) acquire(ctx context.Context, source string) (func(), error) {
+	limits := p.Limits(source)
+
+	p.mu.Lock()
+	st := p.state(source)
+	now := time.Now()
+	if now.Before(st.PausedUntil) {
+		p.mu.Unlock()
+		return nil, fmt.Errorf("%w: %s until %s", ErrSourcePaused, source, st.PausedUntil.Format(time.Kitchen))
+	}
+	if day := now.Format(time.DateOnly); st.Day != day {
+		st.Day, st.Requests = day, 0
+	}
+	if limits.DailyCap > 0 && st.Requests >= limits.DailyCap {
+		p.mu.Unlock()
+		return nil, fmt.Errorf("%w: %s made %d requests today", ErrDailyCap, source, st.Requests)
+	}
+	st.Requests++
+	slots := st.slots
+	p.mu.Unlock()
+
+	if slots != nil {
+		select {
+		case slots <- struct{}{}:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	release := func() {
+		if slots != nil {
+			<-slots
+		}
+	}
+
+	p.mu.Lock()
+	start := time.Now()
+	if st.next.After(start) {
+		start = st.next
+	}
+	st.next = start.Add(limits.MinDelay)
+	p.mu.Unlock()
+
+	if wait := time.Until(start); wait > 0 {
+		timer := time.NewTimer(wait)
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			release()
+			return nil, ctx.Err()
+		}
+	}
+	return release, nil
+}
+
+// observe pauses a source that answered 429, or 403 several times in a row. A Retry-After
+// header longer than the configured pause is honored.
+func (p *Politeness) observe(source string, resp *http.Response) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	st := p.state(source)
+
+	pause := false
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests:
+		pause = true
+	case http.StatusForbidden:
+		st.forbidden++
+		pause = st.forbidden >= forbiddenThreshold
+	default:
+		st.forbidden = 0
+	}
+
+	if pause {
+		d := p.Limits(source).Pause
+		if secs, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && time.Duration(secs)*time.Second > d {
+			d = time.Duration(secs) * time.Second
+		}
+		st.PausedUntil = time.Now().Add(d)
+		st.forbidden = 0
+		slog.Warn("Pausing image source", "source", source, "status", resp.StatusCode, "until", st.PausedUntil.Format(time.DateTime))
+	}
+	p.save()
+}
+
+// save writes the daily counts and pauses to the state file; callers hold p.mu
+func (p *Politeness) save() {
+	if p.statePath == "" {
+		return
+	}
+	data, err := json.MarshalIndent(p.sources, "", "  ")
+	if err != nil {
+		return
+	}
+	if err := os.MkdirAll(filepath.Dir(p.statePath), 0755); err != nil {
+		slog.Debug("Failed to save image source state", "path", p.statePath, "error", err)
+		return
+	}
+	if err := os.WriteFile(p.statePath, data, 0644); err != nil {
+		slog.Debug("Failed to save image source state", "path", p.statePath, "error", err)
+	}
+}
@@ -0,0 +1,139 @@
+package images
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestParseSourceLimits(t *testing.T) {
+	limits, err := ParseSourceLimits("openlibrary:delay=2s,parallel=3; iiif.example.edu:daily=10")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if l := limits[SourceOpenLibrary]; l.MinDelay != 2*time.Second || l.MaxParallel != 3 || l.Pause != DefaultSourceLimits[SourceOpenLibrary].Pause {
+		t.Errorf("openlibrary limits = %+v", l)
+	}
+	if l := limits["iiif.example.edu"]; l.DailyCap != 10 || l.MinDelay != DefaultSourceLimits[SourceDefault].MinDelay {
+		t.Errorf("host limits = %+v", l)
+	}
+	if limits[SourceGoogleBooks] != DefaultSourceLimits[SourceGoogleBooks] {
+		t.Errorf("googlebooks limits changed: %+v", limits[SourceGoogleBooks])
+	}
+
+	for _, spec := range []string{"openlibrary", "openlibrary:delay", "openlibrary:speed=1", "googlebooks:daily=x"} {
+		if _, err := ParseSourceLimits(spec); err == nil {
+			t.Errorf("ParseSourceLimits(%q) succeeded", spec)
+		}
+	}
+}
+
+func TestSourceForHost(t *testing.T) {
+	for host, want := range map[string]string{
+		"covers.openlibrary.org": SourceOpenLibrary,
+		"archive.org":            SourceInternetArchive,
+		"ia800.us.archive.org":   SourceInternetArchive,
+		"www.googleapis.com":     SourceGoogleBooks,
+		"iiif.example.edu":       "iiif.example.edu",
+	} {
+		if got := SourceForHost(host); got != want {
+			t.Errorf("SourceForHost(%q) = %q, want %q", host, got, want)
+		}
+	}
+}
+
+func TestPolitenessPausesSource(t *testing.T) {
+	status := http.StatusForbidden
+	requests := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.WriteHeader(status)
+	}))
+	defer srv.Close()
+
+	statePath := filepath.Join(t.TempDir(), "sources.json")
+	limits := map[string]SourceLimits{SourceDefault: {Pause: time.Hour}}
+	p := NewPoliteness(limits, statePath)
+	client := &http.Client{Transport: p.Transport(http.DefaultTransport)}
+
+	// A few 403s in a row pause the source; fewer don't
+	for i := 0; i < forbiddenThreshold; i++ {
+		resp, err := client.Get(srv.URL)
+		if err != nil {
+			t.Fatalf("request %d: %v", i+1, err)
+		}
+		resp.Body.Close()
+	}
+	if _, err := client.Get(srv.URL); !errors.Is(err, ErrSourcePaused) {
+		t.Fatalf("request after %d 403s: err = %v, want ErrSourcePaused", forbiddenThreshold, err)
+	}
+	if requests != forbiddenThreshold {
+		t.Errorf("server saw %d requests, want %d", requests, forbiddenThreshold)
+	}
+	if len(p.Paused()) != 1 {
+		t.Errorf("Paused() = %v", p.Paused())
+	}
+
+	// The pause carries over to a new run
+	if _, err := (&http.Client{Transport: NewPoliteness(limits, statePath).Transport(http.DefaultTransport)}).Get(srv.URL); !errors.Is(err, ErrSourcePaused) {
+		t.Errorf("request in a new run: err = %v, want ErrSourcePaused", err)
+	}
+}
+
+func TestPolitenessRetryAfterAndDailyCap(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/busy" {
+			w.Header().Set("Retry-After", "7200")
+			w.WriteHeader(http.StatusTooManyRequests)
+		}
+	}))
+	defer srv.Close()
+
+	p := NewPoliteness(map[string]SourceLimits{SourceDefault: {DailyCap: 2, Pause: time.Minute}}, "")
+	client := &http.Client{Transport: p.Transport(http.DefaultTransport)}
+	for i := 0; i < 2; i++ {
+		resp, err := client.Get(srv.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+	}
+	if _, err := client.Get(srv.URL); !errors.Is(err, ErrDailyCap) {
+		t.Errorf("third request: err = %v, want ErrDailyCap", err)
+	}
+
+	p = NewPoliteness(map[string]SourceLimits{SourceDefault: {Pause: time.Minute}}, "")
+	client = &http.Client{Transport: p.Transport(http.DefaultTransport)}
+	resp, err := client.Get(srv.URL + "/busy")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	for _, until := range p.Paused() {
+		if time.Until(until) < time.Hour {
+			t.Errorf("paused until %s, want Retry-After of 2h honored", until)
+		}
+	}
+}
+
+func TestPolitenessMinDelay(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+
+	p := NewPoliteness(map[string]SourceLimits{SourceDefault: {MinDelay: 50 * time.Millisecond, MaxParallel: 1}}, "")
+	client := &http.Client{Transport: p.Transport(http.DefaultTransport)}
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		resp, err := client.Get(srv.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+	}
+	if elapsed := time.Since(start); elapsed < 100*time.Millisecond {
+		t.Errorf("3 requests took %s, want at least 2 delays of 50ms", elapsed)
+	}
+}
@@ -0,0 +1,96 @@
+package labels
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/lehigh-university-libraries/cataloger/internal/holdings"
+	"github.com/lehigh-university-libraries/cataloger/internal/marc"
+)
+
+// PocketWidth is the maximum characters per line on a pocket label
+const PocketWidth = 36
+
+// Label holds the printable lines for a record's spine and pocket labels
+type Label struct {
+	CallNumber string   `json:"call_number"`
+	Spine      []string `json:"spine"`
+	Pocket     []string `json:"pocket"`
+}
+
+// FromRecord builds spine and pocket labels from the call number in 050, 090 or 082.
+// Returns an error when the record has no call number.
+func FromRecord(rec *marc.Record) (*Label, error) {
+	cn := holdings.CallNumberFromRecord(rec)
+	if cn.Class == "" {
+		return nil, fmt.Errorf("record has no call number in 050, 090 or 082")
+	}
+
+	label := &Label{
+		CallNumber: cn.String(),
+		Spine:      SpineLines(cn),
+	}
+
+	label.Pocket = append(label.Pocket, label.CallNumber)
+	if author := strings.TrimRight(rec.SubfieldValue("100", "a"), " ,."); author != "" {
+		label.Pocket = append(label.Pocket, truncate(author, PocketWidth))
+	}
+	if title := strings.TrimRight(rec.SubfieldValue("245", "a"), " /:;"); title != "" {
+		label.Pocket = append(label.Pocket, truncate(title, PocketWidth))
+	}
+
+	return label, nil
+}
+
+var (
+	lcClassPattern = regexp.MustCompile(`^([A-Z]{1,3})\s*(\d+(?:\.\d+)?)\s*(.*)$`)
+	cutterSplit    = regexp.MustCompile(`\s+|(?:^|\s*)(\.[A-Z])`)
+)
+
+// SpineLines splits a call number into the stacked lines printed on a spine label,
+// e.g. "PS3515.E37 O4 1952" becomes PS / 3515 / .E37 / O4 / 1952
+func SpineLines(cn holdings.CallNumber) []string {
+	var lines []string
+
+	rest := cn.String()
+	if cn.Scheme == holdings.SchemeLC {
+		if m := lcClassPattern.FindStringSubmatch(rest); m != nil {
+			lines = append(lines, m[1], m[2])
+			rest = m[3]
+		}
+	}
+
+	// Break before each cutter (".E37") and at whitespace
+	rest = cutterSplit.ReplaceAllString(rest, " $1")
+	lines = append(lines, strings.Fields(rest)...)
+
+	return lines
+}
+
+// Text renders labels as printer-friendly plain text, separated by form feeds
+func Text(labels []*Label) string {
+	var b strings.Builder
+	for i, l := range labels {
+		if i > 0 {
+			b.WriteString("\f")
+		}
+		b.WriteString("SPINE\n")
+		for _, line := range l.Spine {
+			b.WriteString(line + "\n")
+		}
+		b.WriteString("\nPOCKET\n")
+		for _, line := range l.Pocket {
+			b.WriteString(line + "\n")
+		}
+	}
+	return b.String()
+}
+
+func truncate(s string, n int) string {
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+	return string(r[:n-3]) + "..."
+}
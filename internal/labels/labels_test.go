@@ -0,0 +1,40 @@
+package labels
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/lehigh-university-libraries/cataloger/internal/holdings"
+)
+
+func TestSpineLines(t *testing.T) {
+	tests := []struct {
+		cn   holdings.CallNumber
+		want []string
+	}{
+		{
+			cn:   holdings.CallNumber{Scheme: holdings.SchemeLC, Class: "PS3515.E37", Item: "O4 1952"},
+			want: []string{"PS", "3515", ".E37", "O4", "1952"},
+		},
+		{
+			cn:   holdings.CallNumber{Scheme: holdings.SchemeLC, Class: "QA76.73.G63", Item: "D66 2016"},
+			want: []string{"QA", "76.73", ".G63", "D66", "2016"},
+		},
+		{
+			cn:   holdings.CallNumber{Scheme: holdings.SchemeLC, Class: "HD9502.U52", Item: "Y47 2008"},
+			want: []string{"HD", "9502", ".U52", "Y47", "2008"},
+		},
+		{
+			cn:   holdings.CallNumber{Scheme: holdings.SchemeDewey, Class: "813.52", Item: "H488o"},
+			want: []string{"813.52", "H488o"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.cn.String(), func(t *testing.T) {
+			if got := SpineLines(tt.cn); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("SpineLines() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
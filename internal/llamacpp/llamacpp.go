@@ -0,0 +1,243 @@
+// Package llamacpp provides the llama.cpp HTTP server (llama-server) through its native
+// /completion endpoint, for quantized text and vision models without Ollama
+package llamacpp
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/lehigh-university-libraries/cataloger/internal/providers"
+)
+
+// Registration registers the provider as "llamacpp"
+var Registration = providers.Registration{
+	Name:         "llamacpp",
+	New:          func() providers.Provider { return New() },
+	DefaultModel: providers.EnvModel("LLAMACPP_MODEL", "default"),
+	Vision:       true,
+}
+
+// DefaultURL is where llama-server listens by default
+const DefaultURL = "http://localhost:8080"
+
+// defaultNPredict bounds responses, which llama-server otherwise lets run to the end of the
+// context
+const defaultNPredict = 2048
+
+// mediaMarker stands for an image in a multimodal prompt, in the order of multimodal_data
+const mediaMarker = "<__media__>"
+
+// LlamaCpp is a provider for a llama.cpp server
+type LlamaCpp struct {
+	url      string
+	apiKey   string
+	nPredict int
+	raw      bool // Send prompts as they are instead of in the model's chat template
+}
+
+// New returns a new llama.cpp provider
+//
+// Configured with:
+//   - LLAMACPP_URL: server (default http://localhost:8080)
+//   - LLAMACPP_API_KEY: the server's --api-key, when it was started with one
+//   - LLAMACPP_MODEL: model name sent with requests and shown in reports; a server serves the
+//     model it was started with unless it routes between several
+//   - LLAMACPP_N_PREDICT: response limit in tokens (default 2048)
+//   - LLAMACPP_RAW_PROMPT: true sends prompts without the model's chat template, for base
+//     models or servers too old for /apply-template
+func New() *LlamaCpp {
+	l := &LlamaCpp{
+		url:      strings.TrimSuffix(os.Getenv("LLAMACPP_URL"), "/"),
+		apiKey:   os.Getenv("LLAMACPP_API_KEY"),
+		nPredict: defaultNPredict,
+	}
+	if l.url == "" {
+		l.url = DefaultURL
+	}
+	if v := os.Getenv("LLAMACPP_N_PREDICT"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
+			l.nPredict = n
+		} else {
+			slog.Warn("Ignoring invalid LLAMACPP_N_PREDICT", "value", v)
+		}
+	}
+	if v := os.Getenv("LLAMACPP_RAW_PROMPT"); v != "" {
+		if raw, err := strconv.ParseBool(v); err == nil {
+			l.raw = raw
+		} else {
+			slog.Warn("Ignoring invalid LLAMACPP_RAW_PROMPT", "value", v)
+		}
+	}
+	return l
+}
+
+// ExtractText sends a text prompt
+func (l *LlamaCpp) ExtractText(ctx context.Context, config providers.Config) (string, error) {
+	return l.complete(ctx, config, nil, nil)
+}
+
+// StreamText sends a text prompt, calling onChunk as the response arrives
+func (l *LlamaCpp) StreamText(ctx context.Context, config providers.Config, onChunk func(string)) (string, error) {
+	return l.complete(ctx, config, nil, onChunk)
+}
+
+// GenerateFromImage sends an image with the prompt to a vision model (a server started with
+// its --mmproj)
+func (l *LlamaCpp) GenerateFromImage(ctx context.Context, config providers.Config, image []byte) (string, error) {
+	return l.GenerateFromImages(ctx, config, [][]byte{image})
+}
+
+// GenerateFromImages sends several images with the prompt in one request
+func (l *LlamaCpp) GenerateFromImages(ctx context.Context, config providers.Config, images [][]byte) (string, error) {
+	encoded := make([]string, len(images))
+	for i, image := range images {
+		if mediaType := http.DetectContentType(image); !strings.HasPrefix(mediaType, "image/") {
+			return "", fmt.Errorf("unsupported image type for llamacpp: %s", mediaType)
+		}
+		encoded[i] = base64.StdEncoding.EncodeToString(image)
+	}
+	return l.complete(ctx, config, encoded, nil)
+}
+
+// complete sends the prompt, with a media marker ahead of it for each image, to /completion,
+// streaming the response to onChunk when it is not nil
+func (l *LlamaCpp) complete(ctx context.Context, config providers.Config, images []string, onChunk func(string)) (string, error) {
+	prompt := strings.Repeat(mediaMarker+"\n", len(images)) + config.Prompt
+	if !l.raw {
+		var err error
+		if prompt, err = l.applyTemplate(ctx, prompt); err != nil {
+			return "", err
+		}
+	}
+
+	body := map[string]any{
+		"model":        config.Model,
+		"prompt":       prompt,
+		"n_predict":    l.nPredict,
+		"temperature":  config.Temperature,
+		"stream":       onChunk != nil,
+		"cache_prompt": true,
+	}
+	if len(images) > 0 {
+		body["prompt"] = map[string]any{"prompt_string": prompt, "multimodal_data": images}
+	}
+	// The server turns a schema into a grammar, so output is constrained exactly
+	switch {
+	case config.ResponseSchema != nil:
+		body["json_schema"] = config.ResponseSchema
+	case config.JSONMode:
+		body["json_schema"] = map[string]any{"type": "object"}
+	}
+
+	resp, err := l.post(ctx, "/completion", body)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if onChunk != nil {
+		return readStream(resp.Body, onChunk)
+	}
+
+	var response struct {
+		Content string `json:"content"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return "", fmt.Errorf("failed to decode response body: %w", err)
+	}
+	return response.Content, nil
+}
+
+// applyTemplate formats the prompt as a user message in the model's chat template, which
+// /completion leaves to the client
+func (l *LlamaCpp) applyTemplate(ctx context.Context, prompt string) (string, error) {
+	resp, err := l.post(ctx, "/apply-template", map[string]any{
+		"messages": []map[string]string{{"role": "user", "content": prompt}},
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to apply the chat template: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var response struct {
+		Prompt string `json:"prompt"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return "", fmt.Errorf("failed to decode chat template: %w", err)
+	}
+	return response.Prompt, nil
+}
+
+// post sends a JSON request to the server, returning the response when it is 200
+func (l *LlamaCpp) post(ctx context.Context, path string, body map[string]any) (*http.Response, error) {
+	requestBody, err := json.Marshal(body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request body: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, "POST", l.url+path, bytes.NewReader(requestBody))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create new request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if l.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+l.apiKey)
+	}
+
+	resp, err := providers.HTTPClient().Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		data, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("received non-200 status code: %d - %s", resp.StatusCode, string(data))
+	}
+	return resp, nil
+}
+
+// readStream reads a streamed completion: server-sent events whose data is a piece of the
+// response, the last with stop set
+func readStream(r io.Reader, onChunk func(string)) (string, error) {
+	var text strings.Builder
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64<<10), 1<<20)
+	for scanner.Scan() {
+		data, ok := strings.CutPrefix(scanner.Text(), "data:")
+		if !ok {
+			continue
+		}
+		var chunk struct {
+			Content string `json:"content"`
+			Stop    bool   `json:"stop"`
+			Error   *struct {
+				Message string `json:"message"`
+			} `json:"error"`
+		}
+		if err := json.Unmarshal([]byte(strings.TrimSpace(data)), &chunk); err != nil {
+			return "", fmt.Errorf("failed to decode stream chunk: %w", err)
+		}
+		if chunk.Error != nil {
+			return "", fmt.Errorf("llamacpp error: %s", chunk.Error.Message)
+		}
+		if chunk.Content != "" {
+			text.WriteString(chunk.Content)
+			onChunk(chunk.Content)
+		}
+		if chunk.Stop {
+			return text.String(), nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return "", fmt.Errorf("failed to read stream: %w", err)
+	}
+	return "", fmt.Errorf("stream ended before the response was done")
+}
@@ -0,0 +1,79 @@
+package llamacpp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/lehigh-university-libraries/cataloger/internal/providers"
+)
+
+func TestLlamaCpp(t *testing.T) {
+	var got map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer key" {
+			t.Errorf("%s without the API key", r.URL.Path)
+		}
+		var body map[string]any
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Error(err)
+		}
+		switch r.URL.Path {
+		case "/apply-template":
+			content := body["messages"].([]any)[0].(map[string]any)["content"].(string)
+			fmt.Fprintf(w, `{"prompt": %q}`, "<|user|>"+content+"<|assistant|>")
+		case "/completion":
+			got = body
+			if body["stream"] == true {
+				_, _ = w.Write([]byte("data: {\"content\":\"WAL\",\"stop\":false}\n\ndata: {\"content\":\"DEN\",\"stop\":false}\n\ndata: {\"content\":\"\",\"stop\":true}\n\n"))
+				return
+			}
+			_, _ = w.Write([]byte(`{"content":"{\"title\":\"Walden\"}","stop":true}`))
+		default:
+			t.Errorf("unexpected request %s", r.URL.Path)
+		}
+	}))
+	defer server.Close()
+	t.Setenv("LLAMACPP_URL", server.URL+"/")
+	t.Setenv("LLAMACPP_API_KEY", "key")
+	ctx := context.Background()
+
+	l := New()
+	text, err := l.ExtractText(ctx, providers.Config{Model: "qwen", Prompt: "p", ResponseSchema: map[string]any{"type": "object"}})
+	if err != nil || text != `{"title":"Walden"}` {
+		t.Fatalf("ExtractText() = %q, %v", text, err)
+	}
+	if got["prompt"] != "<|user|>p<|assistant|>" || got["json_schema"] == nil || got["n_predict"] != float64(defaultNPredict) {
+		t.Errorf("completion request = %v", got)
+	}
+
+	png := []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+	if _, err := l.GenerateFromImages(ctx, providers.Config{Prompt: "Transcribe"}, [][]byte{png, png}); err != nil {
+		t.Fatal(err)
+	}
+	prompt, _ := got["prompt"].(map[string]any)
+	if s, _ := prompt["prompt_string"].(string); strings.Count(s, mediaMarker) != 2 || !strings.HasSuffix(s, "Transcribe<|assistant|>") {
+		t.Errorf("multimodal prompt = %v", got["prompt"])
+	}
+	if data, _ := prompt["multimodal_data"].([]any); len(data) != 2 {
+		t.Errorf("multimodal_data has %d images, want 2", len(data))
+	}
+	if _, err := l.GenerateFromImage(ctx, providers.Config{}, []byte("not an image")); err == nil {
+		t.Error("expected an error for data that isn't an image")
+	}
+
+	var chunks []string
+	text, err = l.StreamText(ctx, providers.Config{Prompt: "p"}, func(s string) { chunks = append(chunks, s) })
+	if err != nil || text != "WALDEN" || len(chunks) != 2 {
+		t.Errorf("StreamText() = %q, %v, chunks %q", text, err, chunks)
+	}
+
+	t.Setenv("LLAMACPP_RAW_PROMPT", "true")
+	if _, err := New().ExtractText(ctx, providers.Config{Prompt: "raw"}); err != nil || got["prompt"] != "raw" {
+		t.Errorf("raw prompt = %v, %v", got["prompt"], err)
+	}
+}
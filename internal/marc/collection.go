@@ -0,0 +1,79 @@
+package marc
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+)
+
+// Record selections for MARCXML payloads holding several records, such as a <collection> or
+// an OAI-PMH response with a holdings record beside the bibliographic one
+const (
+	SelectBibliographic = "bib" // The one bibliographic record; several are an error
+	SelectAll           = "all" // Every bibliographic record
+)
+
+// Selections lists the record selections
+var Selections = []string{SelectBibliographic, SelectAll}
+
+// IsBibliographic reports whether the leader's type of record (06) is bibliographic, rather
+// than holdings (u, v, x, y), authority (z) or community information (q)
+func (r *Record) IsBibliographic() bool {
+	if len(r.Leader) < 7 {
+		return false
+	}
+	switch r.Leader[6] {
+	case 'a', 'c', 'd', 'e', 'f', 'g', 'i', 'j', 'k', 'm', 'o', 'p', 'r', 't':
+		return true
+	}
+	return false
+}
+
+// ParseXMLRecords parses every <record> in a MARCXML document: a single record, a
+// <collection>, or records in any other wrapper
+func ParseXMLRecords(data []byte) ([]*Record, error) {
+	reader, err := NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	var records []*Record
+	for {
+		rec, err := reader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, rec)
+	}
+	if len(records) == 0 {
+		return nil, fmt.Errorf("failed to parse MARCXML: no <record> elements")
+	}
+	return records, nil
+}
+
+// SelectRecords picks the bibliographic records of a multi-record payload. Holdings, authority
+// and community information records are always dropped.
+func SelectRecords(records []*Record, selection string) ([]*Record, error) {
+	var bibs []*Record
+	for _, rec := range records {
+		if rec.IsBibliographic() {
+			bibs = append(bibs, rec)
+		}
+	}
+	if len(bibs) == 0 {
+		return nil, fmt.Errorf("no bibliographic record among %d records", len(records))
+	}
+	switch selection {
+	case SelectAll:
+		return bibs, nil
+	case SelectBibliographic, "":
+		if len(bibs) > 1 {
+			return nil, fmt.Errorf("%d bibliographic records where one was expected", len(bibs))
+		}
+		return bibs, nil
+	default:
+		return nil, fmt.Errorf("unknown record selection %q (bib or all)", selection)
+	}
+}
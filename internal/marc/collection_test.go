@@ -0,0 +1,58 @@
+package marc
+
+import (
+	"strings"
+	"testing"
+)
+
+const (
+	collectionBib      = `<record><leader>00000cam a2200000 i 4500</leader><controlfield tag="001">b1</controlfield><datafield tag="245" ind1="1" ind2="0"><subfield code="a">Title</subfield></datafield></record>`
+	collectionBib2     = `<record><leader>00000cam a2200000 i 4500</leader><controlfield tag="001">b2</controlfield></record>`
+	collectionHoldings = `<record><leader>00000cx  a2200000   4500</leader><controlfield tag="001">h1</controlfield><datafield tag="852" ind1="0" ind2=" "><subfield code="b">MAIN</subfield></datafield></record>`
+)
+
+func collection(records ...string) []byte {
+	return []byte(`<?xml version="1.0"?><collection xmlns="http://www.loc.gov/MARC21/slim">` + strings.Join(records, "") + `</collection>`)
+}
+
+func TestParseXMLCollection(t *testing.T) {
+	rec, err := ParseXML(collection(collectionHoldings, collectionBib))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rec.ControlField("001") != "b1" {
+		t.Errorf("ParseXML picked %s, want the bibliographic record", rec.ControlField("001"))
+	}
+
+	if _, err := ParseXML(collection(collectionBib, collectionBib2)); err == nil {
+		t.Error("ParseXML of two bibliographic records succeeded")
+	}
+	if _, err := ParseXML(collection(collectionHoldings, collectionHoldings)); err == nil {
+		t.Error("ParseXML of holdings only succeeded")
+	}
+	if _, err := ParseXML([]byte(`<oai_dc><title>Not MARC</title></oai_dc>`)); err == nil {
+		t.Error("ParseXML of a non-MARC document succeeded")
+	}
+}
+
+func TestSelectRecords(t *testing.T) {
+	records, err := ParseXMLRecords(collection(collectionBib, collectionHoldings, collectionBib2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 3 {
+		t.Fatalf("ParseXMLRecords() = %d records, want 3", len(records))
+	}
+
+	all, err := SelectRecords(records, SelectAll)
+	if err != nil || len(all) != 2 {
+		t.Errorf("SelectRecords(all) = %d records, %v; want the 2 bibliographic records", len(all), err)
+	}
+	if _, err := SelectRecords(records, SelectBibliographic); err == nil {
+		t.Error("SelectRecords(bib) of 2 bibliographic records succeeded")
+	}
+	bib, err := SelectRecords(records[:2], SelectBibliographic)
+	if err != nil || len(bib) != 1 || bib[0].ControlField("001") != "b1" {
+		t.Errorf("SelectRecords(bib) = %v, %v", bib, err)
+	}
+}
@@ -0,0 +1,102 @@
+package marc
+
+import (
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// IdentityTags are the control fields that identify a copy of a record rather than describe
+// the item: the control number (001), its assigning organization (003) and the latest
+// transaction timestamp (005). They are never scored in comparisons.
+var IdentityTags = []string{"001", "003", "005"}
+
+// ControlPolicy decides what generated records carry in 001, 003 and 005. A model cannot
+// know a local control number or when the record will be loaded, so placeholder values it
+// produces are removed; 003 and 005 can instead be filled from configuration.
+type ControlPolicy struct {
+	OrgCode   string // MARC organization code written to 003; placeholders are dropped when empty
+	Timestamp bool   // Write the current time to 005
+	Now       func() time.Time
+}
+
+// ControlPolicyFromEnv reads the policy from MARC_ORG_CODE and MARC_TIMESTAMP_005
+func ControlPolicyFromEnv() ControlPolicy {
+	timestamp, _ := strconv.ParseBool(os.Getenv("MARC_TIMESTAMP_005"))
+	return ControlPolicy{
+		OrgCode:   strings.TrimSpace(os.Getenv("MARC_ORG_CODE")),
+		Timestamp: timestamp,
+	}
+}
+
+var (
+	timestamp005      = regexp.MustCompile(`^\d{14}\.\d$`)
+	orgCodePattern    = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9:/-]{1,15}$`)
+	placeholderFiller = regexp.MustCompile(`^[0#xX?_.\-| ]*$`)
+)
+
+// Apply maps a generated record's 001/003/005 according to the policy
+func (p ControlPolicy) Apply(rec *Record) {
+	if v := rec.ControlField("001"); IsPlaceholder(v) {
+		rec.RemoveTags([]string{"001"})
+	}
+
+	switch v := rec.ControlField("003"); {
+	case p.OrgCode != "":
+		rec.SetControlField("003", p.OrgCode)
+	case IsPlaceholder(v) || !orgCodePattern.MatchString(v):
+		rec.RemoveTags([]string{"003"})
+	}
+
+	now := time.Now
+	if p.Now != nil {
+		now = p.Now
+	}
+	switch v := rec.ControlField("005"); {
+	case p.Timestamp:
+		rec.SetControlField("005", Timestamp005(now()))
+	case !timestamp005.MatchString(v) || strings.Trim(v, "0.") == "":
+		rec.RemoveTags([]string{"005"})
+	}
+}
+
+// Timestamp005 formats a time as a 005 date and time of latest transaction
+func Timestamp005(t time.Time) string {
+	return t.Format("20060102150405") + ".0"
+}
+
+// IsPlaceholder reports whether a control field value is empty or filler a model made up,
+// e.g. "00000000", "ocm00000000", "XXX" or "[control number]"
+func IsPlaceholder(v string) bool {
+	v = strings.TrimSpace(v)
+	lower := strings.ToLower(v)
+	if v == "" || placeholderFiller.MatchString(v) {
+		return true
+	}
+	for _, marker := range []string{"[", "<", "placeholder", "unknown", "n/a", "todo", "tbd"} {
+		if strings.Contains(lower, marker) {
+			return true
+		}
+	}
+	for _, prefix := range []string{"ocm", "ocn", "on"} {
+		if rest, ok := strings.CutPrefix(lower, prefix); ok && placeholderFiller.MatchString(rest) {
+			return true
+		}
+	}
+	return false
+}
+
+// SetControlField replaces the first control field with the tag, or inserts it in tag order
+func (r *Record) SetControlField(tag, value string) {
+	for i, cf := range r.ControlFields {
+		if cf.Tag == tag {
+			r.ControlFields[i].Value = value
+			return
+		}
+	}
+	r.ControlFields = append(r.ControlFields, ControlField{Tag: tag, Value: value})
+	sort.SliceStable(r.ControlFields, func(i, j int) bool { return r.ControlFields[i].Tag < r.ControlFields[j].Tag })
+}
@@ -0,0 +1,55 @@
+package marc
+
+import (
+	"testing"
+	"time"
+)
+
+func TestIsPlaceholder(t *testing.T) {
+	for _, v := range []string{"", "00000000", "ocm00000000", "XXX", "[control number]", "<001>", "unknown", "    "} {
+		if !IsPlaceholder(v) {
+			t.Errorf("IsPlaceholder(%q) = false, want true", v)
+		}
+	}
+	for _, v := range []string{"991234567", "ocm12345678", "PBL", "20240101120000.0"} {
+		if IsPlaceholder(v) {
+			t.Errorf("IsPlaceholder(%q) = true, want false", v)
+		}
+	}
+}
+
+func TestControlPolicyApply(t *testing.T) {
+	newRecord := func() *Record {
+		return &Record{ControlFields: []ControlField{
+			{Tag: "001", Value: "ocm00000000"},
+			{Tag: "003", Value: "XXX"},
+			{Tag: "005", Value: "00000000000000.0"},
+			{Tag: "008", Value: "240101s2024    xx            000 0 eng d"},
+		}}
+	}
+
+	rec := newRecord()
+	ControlPolicy{}.Apply(rec)
+	if len(rec.ControlFields) != 1 || rec.ControlFields[0].Tag != "008" {
+		t.Errorf("placeholders should be removed, got %+v", rec.ControlFields)
+	}
+
+	rec = newRecord()
+	now := time.Date(2024, 5, 6, 7, 8, 9, 0, time.UTC)
+	ControlPolicy{OrgCode: "PBL", Timestamp: true, Now: func() time.Time { return now }}.Apply(rec)
+	if got := rec.ControlField("003"); got != "PBL" {
+		t.Errorf("003 = %q, want PBL", got)
+	}
+	if got := rec.ControlField("005"); got != "20240506070809.0" {
+		t.Errorf("005 = %q, want 20240506070809.0", got)
+	}
+	if rec.ControlField("001") != "" {
+		t.Errorf("placeholder 001 should be removed")
+	}
+
+	rec = &Record{ControlFields: []ControlField{{Tag: "008", Value: "x"}}}
+	ControlPolicy{OrgCode: "PBL"}.Apply(rec)
+	if rec.ControlFields[0].Tag != "003" {
+		t.Errorf("003 should be inserted in tag order, got %+v", rec.ControlFields)
+	}
+}
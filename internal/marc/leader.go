@@ -0,0 +1,94 @@
+package marc
+
+import (
+	"fmt"
+	"strings"
+)
+
+// repairLeaderTemplate supplies the positions of a leader that are lost when it is too short:
+// a new language material monograph with the standard MARC 21 structure
+const repairLeaderTemplate = "00000nam a2200000 i 4500"
+
+// blankPlaceholders are characters some exports write for a blank in the leader
+const blankPlaceholders = "#^\\"
+
+// RepairLeader normalizes a malformed leader in place and describes each change made, or
+// returns nil when the leader needed none. Short leaders are padded, long ones trimmed,
+// numeric and structural positions reset, and status, type and level lowercased when that
+// makes them valid. A type or level that cannot be recovered is left for Validate to report.
+func (r *Record) RepairLeader() []string {
+	var repairs []string
+	leader := r.Leader
+
+	if strings.ContainsAny(leader, blankPlaceholders) {
+		leader = strings.Map(func(c rune) rune {
+			if strings.ContainsRune(blankPlaceholders, c) {
+				return ' '
+			}
+			return c
+		}, leader)
+		repairs = append(repairs, "replaced blank placeholders")
+	}
+
+	switch n := len(leader); {
+	case n > 24:
+		leader = strings.TrimLeft(leader, " \t\r\n")
+		if len(leader) > 24 {
+			leader = strings.TrimRight(leader, " \t\r\n")
+		}
+		if len(leader) > 24 {
+			leader = leader[:24]
+		}
+		if len(leader) < 24 {
+			leader += repairLeaderTemplate[len(leader):]
+		}
+		repairs = append(repairs, fmt.Sprintf("trimmed from %d characters", n))
+	case n < 24:
+		leader += repairLeaderTemplate[n:]
+		repairs = append(repairs, fmt.Sprintf("padded from %d characters", n))
+	}
+
+	b := []byte(leader)
+	digits := func(from, to int, name string) {
+		for i := from; i < to; i++ {
+			if b[i] < '0' || b[i] > '9' {
+				copy(b[from:to], repairLeaderTemplate[from:to])
+				repairs = append(repairs, "reset "+name)
+				return
+			}
+		}
+	}
+	fixed := func(pos int, valid, name string, fallback byte) {
+		if strings.IndexByte(valid, b[pos]) >= 0 {
+			return
+		}
+		lower := strings.ToLower(string(b[pos]))[0]
+		switch {
+		case strings.IndexByte(valid, lower) >= 0:
+			b[pos] = lower
+		case fallback != 0:
+			b[pos] = fallback
+		default:
+			return
+		}
+		repairs = append(repairs, fmt.Sprintf("set %s to %q", name, b[pos]))
+	}
+
+	digits(0, 5, "record length")
+	fixed(5, "acdnp", "record status", 'n')
+	fixed(6, "acdefgijkmoprt", "type of record", 0)
+	fixed(7, "abcdims", "bibliographic level", 0)
+	fixed(9, " a", "character coding scheme", 'a')
+	if string(b[10:12]) != "22" {
+		copy(b[10:12], "22")
+		repairs = append(repairs, "reset indicator and subfield code counts")
+	}
+	digits(12, 17, "base address")
+	if string(b[20:24]) != "4500" {
+		copy(b[20:24], "4500")
+		repairs = append(repairs, "reset entry map")
+	}
+
+	r.Leader = string(b)
+	return repairs
+}
@@ -0,0 +1,39 @@
+package marc
+
+import "testing"
+
+func TestRepairLeader(t *testing.T) {
+	tests := []struct {
+		leader  string
+		want    string
+		repairs int
+	}{
+		{"01234cam a2200289 i 4500", "01234cam a2200289 i 4500", 0},
+		{"01234cam a2200289 i 450", "01234cam a2200289 i 4500", 1},
+		{"cam", "00000nam a2200000 i 4500", 2},
+		{"\n  01234cam a2200289 i 4500  ", "01234cam a2200289 i 4500", 1},
+		{"01234cam#a2200289#i#4500", "01234cam a2200289 i 4500", 1},
+		{"01234CAM a2200289 i 4500", "01234cam a2200289 i 4500", 3},
+		{"     xzm 3      xxi ....", "00000nzm a2200000xi 4500", 6},
+	}
+	for _, tt := range tests {
+		rec := &Record{Leader: tt.leader}
+		repairs := rec.RepairLeader()
+		if rec.Leader != tt.want {
+			t.Errorf("RepairLeader(%q) leader = %q, want %q", tt.leader, rec.Leader, tt.want)
+		}
+		if len(repairs) != tt.repairs {
+			t.Errorf("RepairLeader(%q) = %q, want %d repairs", tt.leader, repairs, tt.repairs)
+		}
+	}
+}
+
+func TestRepairLeaderValidates(t *testing.T) {
+	rec := &Record{Leader: "01234NAM#a22"}
+	rec.RepairLeader()
+	for _, issue := range Validate(rec) {
+		if issue.Tag == "LDR" {
+			t.Errorf("repaired leader %q still invalid: %s", rec.Leader, issue.Message)
+		}
+	}
+}
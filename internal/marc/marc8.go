@@ -0,0 +1,177 @@
+package marc
+
+import (
+	"bytes"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// MARC-8 character sets handled by DecodeMARC8. Other sets (Hebrew, Cyrillic, Arabic, Greek
+// and East Asian) decode to U+FFFD.
+const (
+	marc8ASCII = iota
+	marc8ANSEL
+	marc8Subscript
+	marc8Superscript
+	marc8GreekSymbols
+	marc8EACC // Three bytes per character
+	marc8Unsupported
+)
+
+// marc8Finals maps the final byte of an escape sequence to the character set it designates
+var marc8Finals = map[byte]int{
+	'B': marc8ASCII,
+	's': marc8ASCII,
+	'E': marc8ANSEL,
+	'b': marc8Subscript,
+	'p': marc8Superscript,
+	'g': marc8GreekSymbols,
+	'1': marc8EACC,
+}
+
+// ansel maps the spacing graphics of ANSEL (MARC-8 extended Latin)
+var ansel = map[byte]rune{
+	0xA1: 'Ł', 0xA2: 'Ø', 0xA3: 'Đ', 0xA4: 'Þ', 0xA5: 'Æ', 0xA6: 'Œ', 0xA7: 'ʹ', 0xA8: '·',
+	0xA9: '♭', 0xAA: '®', 0xAB: '±', 0xAC: 'Ơ', 0xAD: 'Ư', 0xAE: 'ʼ', 0xB0: 'ʻ', 0xB1: 'ł',
+	0xB2: 'ø', 0xB3: 'đ', 0xB4: 'þ', 0xB5: 'æ', 0xB6: 'œ', 0xB7: 'ʺ', 0xB8: 'ı', 0xB9: '£',
+	0xBA: 'ð', 0xBC: 'ơ', 0xBD: 'ư', 0xC0: '°', 0xC1: 'ℓ', 0xC2: '℗', 0xC3: '©', 0xC4: '♯',
+	0xC5: '¿', 0xC6: '¡', 0xC7: 'ß', 0xC8: '€',
+}
+
+// anselCombining maps ANSEL's combining diacritics, which precede their base character
+var anselCombining = map[byte]rune{
+	0xE0: '\u0309', 0xE1: '\u0300', 0xE2: '\u0301', 0xE3: '\u0302', 0xE4: '\u0303',
+	0xE5: '\u0304', 0xE6: '\u0306', 0xE7: '\u0307', 0xE8: '\u0308', 0xE9: '\u030C',
+	0xEA: '\u030A', 0xEB: '\uFE20', 0xEC: '\uFE21', 0xED: '\u0315', 0xEE: '\u030B',
+	0xEF: '\u0310', 0xF0: '\u0327', 0xF1: '\u0328', 0xF2: '\u0323', 0xF3: '\u0324',
+	0xF4: '\u0325', 0xF5: '\u0333', 0xF6: '\u0332', 0xF7: '\u0326', 0xF8: '\u031C',
+	0xF9: '\u032E', 0xFA: '\uFE22', 0xFB: '\uFE23', 0xFE: '\u0313',
+}
+
+var subscripts = map[byte]rune{
+	'0': '₀', '1': '₁', '2': '₂', '3': '₃', '4': '₄', '5': '₅', '6': '₆', '7': '₇', '8': '₈', '9': '₉',
+	'+': '₊', '-': '₋', '(': '₍', ')': '₎',
+}
+
+var superscripts = map[byte]rune{
+	'0': '⁰', '1': '¹', '2': '²', '3': '³', '4': '⁴', '5': '⁵', '6': '⁶', '7': '⁷', '8': '⁸', '9': '⁹',
+	'+': '⁺', '-': '⁻', '(': '⁽', ')': '⁾',
+}
+
+var greekSymbols = map[byte]rune{'a': 'α', 'b': 'β', 'c': 'γ'}
+
+// IsMARC8 reports whether a binary record's field data needs MARC-8 decoding: Leader/09 is
+// blank and the data holds escape sequences or bytes that are not valid UTF-8. Records
+// labeled MARC-8 whose data is already UTF-8, a common export mistake, are left alone.
+func IsMARC8(leader string, data []byte) bool {
+	if len(leader) < 10 || leader[9] != ' ' {
+		return false
+	}
+	return bytes.IndexByte(data, 0x1B) >= 0 || !utf8.Valid(data)
+}
+
+// DecodeMARC8 converts MARC-8 text to NFC-normalized UTF-8. Basic and extended Latin,
+// subscripts, superscripts and the Greek symbols are converted; characters from other
+// MARC-8 sets become U+FFFD.
+func DecodeMARC8(data []byte) string {
+	var b strings.Builder
+	var combining []rune
+	g0, g1 := marc8ASCII, marc8ANSEL
+
+	emit := func(r rune) {
+		b.WriteRune(r)
+		for _, c := range combining {
+			b.WriteRune(c)
+		}
+		combining = combining[:0]
+	}
+
+	for i := 0; i < len(data); i++ {
+		c := data[i]
+		switch {
+		case c == 0x1B:
+			i = marc8Escape(data, i, &g0, &g1)
+		case c == 0x88 || c == 0x89: // Non-sort begin/end
+		case c == 0x8D:
+			emit('\u200D')
+		case c == 0x8E:
+			emit('\u200C')
+		case c < 0x80:
+			if g0 == marc8EACC && c > 0x20 {
+				i += 2
+				emit(utf8.RuneError)
+				continue
+			}
+			emit(marc8Graphic(g0, c))
+		case g1 == marc8ANSEL:
+			if r, ok := anselCombining[c]; ok {
+				combining = append(combining, r)
+			} else if r, ok := ansel[c]; ok {
+				emit(r)
+			} else {
+				emit(utf8.RuneError)
+			}
+		default:
+			emit(utf8.RuneError)
+		}
+	}
+	// Diacritics with no base character stay as they are
+	b.WriteString(string(combining))
+	return norm.NFC.String(b.String())
+}
+
+// marc8Escape applies the escape sequence at data[i] and returns the index of its last byte
+func marc8Escape(data []byte, i int, g0, g1 *int) int {
+	j := i + 1
+	target := g0
+	if j < len(data) && data[j] == '$' { // Multibyte set
+		j++
+	}
+	if j < len(data) {
+		switch data[j] {
+		case '(', ',':
+			j++
+		case ')', '-':
+			target = g1
+			j++
+		}
+	}
+	if j < len(data) && data[j] == '!' {
+		j++
+	}
+	if j >= len(data) {
+		return len(data) - 1
+	}
+	set, ok := marc8Finals[data[j]]
+	if !ok {
+		set = marc8Unsupported
+	}
+	if target == g1 && set == marc8ASCII {
+		set = marc8Unsupported
+	}
+	*target = set
+	return j
+}
+
+func marc8Graphic(set int, c byte) rune {
+	if c <= 0x20 {
+		return rune(c)
+	}
+	var table map[byte]rune
+	switch set {
+	case marc8ASCII:
+		return rune(c)
+	case marc8Subscript:
+		table = subscripts
+	case marc8Superscript:
+		table = superscripts
+	case marc8GreekSymbols:
+		table = greekSymbols
+	}
+	if r, ok := table[c]; ok {
+		return r
+	}
+	return utf8.RuneError
+}
@@ -0,0 +1,65 @@
+package marc
+
+import "testing"
+
+func TestDecodeMARC8(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"Plain ASCII", "Plain ASCII"},
+		{"Caf\xE2e", "Café"},
+		{"Dvo\xE9rak, Anton\xE2in", "Dvořak, Antonín"},
+		{"\xA1\xF2od\xB2", "Łọdø"},
+		{"Gr\xE8u\xC7e", "Grüße"},
+		{"H\x1Bb2\x1BsO", "H₂O"},
+		{"x\x1Bp2\x1Bs", "x²"},
+		{"\x1Bga\x1Bs-particles", "α-particles"},
+		{"\x88The \x89title", "The title"},
+		{"\x1B$1!0\"!0#\x1B(B end", "�� end"},
+		{"\x1B(NABC\x1B(B.", "���."},
+	}
+	for _, tt := range tests {
+		if got := DecodeMARC8([]byte(tt.in)); got != tt.want {
+			t.Errorf("DecodeMARC8(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestIsMARC8(t *testing.T) {
+	tests := []struct {
+		leader string
+		data   string
+		want   bool
+	}{
+		{"00000nam  2200000   4500", "Caf\xE2e", true},
+		{"00000nam  2200000   4500", "H\x1Bb2\x1BsO", true},
+		{"00000nam  2200000   4500", "Café", false}, // Mislabeled UTF-8
+		{"00000nam  2200000   4500", "ASCII", false},
+		{"00000nam a2200000   4500", "Caf\xE2e", false},
+	}
+	for _, tt := range tests {
+		if got := IsMARC8(tt.leader, []byte(tt.data)); got != tt.want {
+			t.Errorf("IsMARC8(%q, %q) = %v, want %v", tt.leader, tt.data, got, tt.want)
+		}
+	}
+}
+
+func TestParseISO2709MARC8(t *testing.T) {
+	data := encodeISO2709(&Record{
+		Leader: "00000nam  2200000   4500",
+		DataFields: []DataField{{Tag: "245", Ind1: "1", Ind2: "0", Subfields: []Subfield{
+			{Code: "a", Value: "Les mis\xE2erables"},
+		}}},
+	})
+	rec, err := ParseISO2709(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := rec.SubfieldValue("245", "a"); got != "Les misérables" {
+		t.Errorf("245 $a = %q, want converted to UTF-8", got)
+	}
+	if rec.Leader[9] != 'a' {
+		t.Errorf("Leader/09 = %q, want a after conversion", rec.Leader[9])
+	}
+}
@@ -0,0 +1,74 @@
+package marc
+
+// Material types returned by MaterialType, following the MARC 008 material configurations
+// with music split into scores and sound recordings
+const (
+	MaterialBook     = "book"
+	MaterialSerial   = "serial"
+	MaterialMap      = "map"
+	MaterialScore    = "score"
+	MaterialSound    = "sound"
+	MaterialVisual   = "visual"
+	MaterialComputer = "computer"
+	MaterialMixed    = "mixed"
+	MaterialUnknown  = ""
+)
+
+// MaterialTypes lists the material types in report order
+var MaterialTypes = []string{
+	MaterialBook, MaterialSerial, MaterialMap, MaterialScore,
+	MaterialSound, MaterialVisual, MaterialComputer, MaterialMixed,
+}
+
+// materialLeaders are the Leader/06-07 written to records generated as each material type
+var materialLeaders = map[string]string{
+	MaterialBook:     "am",
+	MaterialSerial:   "as",
+	MaterialMap:      "em",
+	MaterialScore:    "cm",
+	MaterialSound:    "jm",
+	MaterialVisual:   "gm",
+	MaterialComputer: "mm",
+	MaterialMixed:    "pc",
+}
+
+// MaterialType returns the material type described by the leader's type of record (06) and
+// bibliographic level (07), or MaterialUnknown for a short or invalid leader
+func (r *Record) MaterialType() string {
+	if len(r.Leader) < 8 {
+		return MaterialUnknown
+	}
+	switch r.Leader[6] {
+	case 'a':
+		switch r.Leader[7] {
+		case 'b', 'i', 's':
+			return MaterialSerial
+		}
+		return MaterialBook
+	case 't':
+		return MaterialBook
+	case 'e', 'f':
+		return MaterialMap
+	case 'c', 'd':
+		return MaterialScore
+	case 'i', 'j':
+		return MaterialSound
+	case 'g', 'k', 'o', 'r':
+		return MaterialVisual
+	case 'm':
+		return MaterialComputer
+	case 'p':
+		return MaterialMixed
+	}
+	return MaterialUnknown
+}
+
+// SetMaterialType sets Leader/06-07 to the usual values for a material type. Unknown material
+// types and short leaders are left alone.
+func (r *Record) SetMaterialType(material string) {
+	codes, ok := materialLeaders[material]
+	if !ok || len(r.Leader) < 8 {
+		return
+	}
+	r.Leader = r.Leader[:6] + codes + r.Leader[8:]
+}
@@ -0,0 +1,44 @@
+package marc
+
+import "testing"
+
+func TestMaterialType(t *testing.T) {
+	tests := []struct {
+		leader string
+		want   string
+	}{
+		{"00000nam a2200000 i 4500", MaterialBook},
+		{"00000ntm a2200000 i 4500", MaterialBook},
+		{"00000nas a2200000 i 4500", MaterialSerial},
+		{"00000nem a2200000 i 4500", MaterialMap},
+		{"00000ncm a2200000 i 4500", MaterialScore},
+		{"00000njm a2200000 i 4500", MaterialSound},
+		{"00000ngm a2200000 i 4500", MaterialVisual},
+		{"00000nmm a2200000 i 4500", MaterialComputer},
+		{"00000npc a2200000 i 4500", MaterialMixed},
+		{"00000nzm a2200000 i 4500", MaterialUnknown},
+		{"00000n", MaterialUnknown},
+	}
+	for _, tt := range tests {
+		rec := &Record{Leader: tt.leader}
+		if got := rec.MaterialType(); got != tt.want {
+			t.Errorf("MaterialType(%q) = %q, want %q", tt.leader, got, tt.want)
+		}
+	}
+}
+
+func TestSetMaterialType(t *testing.T) {
+	rec := &Record{Leader: "00000nam a2200000 i 4500"}
+	rec.SetMaterialType(MaterialMap)
+	if rec.Leader != "00000nem a2200000 i 4500" {
+		t.Errorf("Leader = %q, want map leader", rec.Leader)
+	}
+	if rec.MaterialType() != MaterialMap {
+		t.Errorf("MaterialType() = %q after SetMaterialType(map)", rec.MaterialType())
+	}
+
+	rec.SetMaterialType("globe")
+	if rec.Leader != "00000nem a2200000 i 4500" {
+		t.Errorf("unknown material changed leader to %q", rec.Leader)
+	}
+}
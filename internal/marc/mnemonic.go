@@ -0,0 +1,115 @@
+package marc
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ParseMnemonic parses a record in MarcEdit mnemonic (.mrk) text form:
+//
+//	=LDR  00000nam a2200000 i 4500
+//	=008  200101s2020\\\\nyu\\\\\\\\\\\000\0\eng\d
+//	=245  10$aTest title :$bsubtitle /$cby Someone.
+//
+// A backslash stands for a blank in the leader, control fields and indicators,
+// and {dollar} for a literal "$" in subfield values.
+func ParseMnemonic(text string) (*Record, error) {
+	rec := &Record{}
+
+	for n, line := range strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n") {
+		line = strings.TrimRight(line, " \t")
+		if line == "" {
+			continue
+		}
+		if len(line) < 5 || line[0] != '=' {
+			return nil, fmt.Errorf("line %d: expected \"=TAG  value\", got %q", n+1, line)
+		}
+
+		tag := line[1:4]
+		value := strings.TrimPrefix(line[4:], "  ")
+
+		switch {
+		case tag == "LDR":
+			rec.Leader = unescapeBlanks(value)
+		case isControlTag(tag):
+			rec.ControlFields = append(rec.ControlFields, ControlField{Tag: tag, Value: unescapeBlanks(value)})
+		default:
+			field, err := parseMnemonicDataField(tag, value)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", n+1, err)
+			}
+			rec.DataFields = append(rec.DataFields, field)
+		}
+	}
+
+	return rec, nil
+}
+
+func parseMnemonicDataField(tag, value string) (DataField, error) {
+	if len(value) < 2 {
+		return DataField{}, fmt.Errorf("field %s: missing indicators", tag)
+	}
+
+	field := DataField{
+		Tag:  tag,
+		Ind1: unescapeBlanks(value[0:1]),
+		Ind2: unescapeBlanks(value[1:2]),
+	}
+
+	parts := strings.Split(value[2:], "$")
+	if parts[0] != "" {
+		return DataField{}, fmt.Errorf("field %s: text before first subfield: %q", tag, parts[0])
+	}
+	for _, part := range parts[1:] {
+		if part == "" {
+			continue
+		}
+		field.Subfields = append(field.Subfields, Subfield{
+			Code:  part[0:1],
+			Value: strings.ReplaceAll(part[1:], "{dollar}", "$"),
+		})
+	}
+
+	return field, nil
+}
+
+// Mnemonic renders the record in MarcEdit mnemonic text form
+func (r *Record) Mnemonic() string {
+	var b strings.Builder
+
+	if r.Leader != "" {
+		fmt.Fprintf(&b, "=LDR  %s\n", escapeBlanks(r.Leader))
+	}
+	for _, cf := range r.ControlFields {
+		fmt.Fprintf(&b, "=%s  %s\n", cf.Tag, escapeBlanks(cf.Value))
+	}
+	for _, df := range r.DataFields {
+		fmt.Fprintf(&b, "=%s  %s%s", df.Tag, escapeIndicator(df.Ind1), escapeIndicator(df.Ind2))
+		for _, sf := range df.Subfields {
+			fmt.Fprintf(&b, "$%s%s", sf.Code, strings.ReplaceAll(sf.Value, "$", "{dollar}"))
+		}
+		b.WriteString("\n")
+	}
+
+	return b.String()
+}
+
+// isControlTag reports whether tag is a 00X control field
+func isControlTag(tag string) bool {
+	return strings.HasPrefix(tag, "00")
+}
+
+func unescapeBlanks(s string) string {
+	return strings.ReplaceAll(s, `\`, " ")
+}
+
+func escapeBlanks(s string) string {
+	return strings.ReplaceAll(s, " ", `\`)
+}
+
+func escapeIndicator(ind string) string {
+	if ind == "" || ind == " " {
+		return `\`
+	}
+	return ind
+}
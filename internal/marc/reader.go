@@ -0,0 +1,167 @@
+package marc
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// ISO 2709 delimiters
+const (
+	subfieldDelimiter = 0x1F
+	fieldTerminator   = 0x1E
+	recordTerminator  = 0x1D
+)
+
+// Reader reads a stream of records from binary MARC (ISO 2709, .mrc) or a MARCXML
+// collection, detecting the format from the first non-blank byte
+type Reader struct {
+	br  *bufio.Reader
+	dec *xml.Decoder
+
+	MARC8 int // Binary records converted from MARC-8 so far
+}
+
+// NewReader creates a reader for binary MARC or MARCXML
+func NewReader(r io.Reader) (*Reader, error) {
+	br := bufio.NewReader(r)
+	for {
+		b, err := br.Peek(1)
+		if err != nil {
+			if err == io.EOF {
+				return &Reader{br: br}, nil
+			}
+			return nil, fmt.Errorf("failed to read MARC data: %w", err)
+		}
+		switch b[0] {
+		case ' ', '\t', '\r', '\n', 0xEF, 0xBB, 0xBF: // Whitespace and UTF-8 BOM
+			_, _ = br.ReadByte()
+			continue
+		case '<':
+			return &Reader{dec: xml.NewDecoder(br)}, nil
+		}
+		return &Reader{br: br}, nil
+	}
+}
+
+// Next returns the next record, or io.EOF when the stream is exhausted
+func (r *Reader) Next() (*Record, error) {
+	if r.dec != nil {
+		return r.nextXML()
+	}
+	return r.nextBinary()
+}
+
+func (r *Reader) nextXML() (*Record, error) {
+	for {
+		tok, err := r.dec.Token()
+		if err != nil {
+			if err == io.EOF {
+				return nil, io.EOF
+			}
+			return nil, fmt.Errorf("failed to parse MARCXML: %w", err)
+		}
+		start, ok := tok.(xml.StartElement)
+		if !ok || start.Name.Local != "record" {
+			continue
+		}
+		var rec Record
+		if err := r.dec.DecodeElement(&rec, &start); err != nil {
+			return nil, fmt.Errorf("failed to parse MARCXML record: %w", err)
+		}
+		return &rec, nil
+	}
+}
+
+func (r *Reader) nextBinary() (*Record, error) {
+	// Skip stray line breaks some exports put between records
+	for {
+		b, err := r.br.Peek(1)
+		if err != nil {
+			return nil, io.EOF
+		}
+		if b[0] != '\r' && b[0] != '\n' {
+			break
+		}
+		_, _ = r.br.ReadByte()
+	}
+
+	lengthBytes := make([]byte, 5)
+	if _, err := io.ReadFull(r.br, lengthBytes); err != nil {
+		return nil, fmt.Errorf("truncated MARC record: %w", err)
+	}
+	length, err := strconv.Atoi(string(lengthBytes))
+	if err != nil || length < 25 {
+		return nil, fmt.Errorf("invalid MARC record length %q", lengthBytes)
+	}
+
+	data := make([]byte, length)
+	copy(data, lengthBytes)
+	if _, err := io.ReadFull(r.br, data[5:]); err != nil {
+		return nil, fmt.Errorf("truncated MARC record: %w", err)
+	}
+	rec, err := ParseISO2709(data)
+	if err == nil && data[9] == ' ' && rec.Leader[9] == 'a' {
+		r.MARC8++
+	}
+	return rec, err
+}
+
+// ParseISO2709 parses a single binary MARC record. MARC-8 records (Leader/09 blank) are
+// converted to UTF-8 and their Leader/09 set to a.
+func ParseISO2709(data []byte) (*Record, error) {
+	if len(data) < 25 {
+		return nil, fmt.Errorf("MARC record too short")
+	}
+	rec := &Record{Leader: string(data[:24])}
+
+	base, err := strconv.Atoi(string(data[12:17]))
+	if err != nil || base < 25 || base > len(data) {
+		return nil, fmt.Errorf("invalid MARC base address %q", data[12:17])
+	}
+
+	decode := func(b []byte) string { return string(b) }
+	if IsMARC8(rec.Leader, data[base:]) {
+		decode = DecodeMARC8
+		rec.Leader = rec.Leader[:9] + "a" + rec.Leader[10:]
+	}
+
+	directory := data[24 : base-1]
+	if len(directory)%12 != 0 {
+		return nil, fmt.Errorf("invalid MARC directory length %d", len(directory))
+	}
+
+	for i := 0; i < len(directory); i += 12 {
+		entry := directory[i : i+12]
+		tag := string(entry[:3])
+		length, err1 := strconv.Atoi(string(entry[3:7]))
+		start, err2 := strconv.Atoi(string(entry[7:12]))
+		if err1 != nil || err2 != nil || base+start+length > len(data) {
+			return nil, fmt.Errorf("invalid MARC directory entry %q", entry)
+		}
+
+		field := bytes.TrimRight(data[base+start:base+start+length], string([]byte{fieldTerminator, recordTerminator}))
+		if isControlTag(tag) {
+			rec.ControlFields = append(rec.ControlFields, ControlField{Tag: tag, Value: decode(field)})
+			continue
+		}
+
+		df := DataField{Tag: tag, Ind1: " ", Ind2: " "}
+		if len(field) >= 2 {
+			df.Ind1, df.Ind2 = string(field[0]), string(field[1])
+			field = field[2:]
+		}
+		for _, sf := range bytes.Split(field, []byte{subfieldDelimiter}) {
+			if len(sf) == 0 {
+				continue
+			}
+			df.Subfields = append(df.Subfields, Subfield{Code: string(sf[0]), Value: decode(sf[1:])})
+		}
+		rec.DataFields = append(rec.DataFields, df)
+	}
+
+	return rec, nil
+}
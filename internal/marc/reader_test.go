@@ -0,0 +1,94 @@
+package marc
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+)
+
+// encodeISO2709 is a minimal binary MARC writer for building test input
+func encodeISO2709(rec *Record) []byte {
+	var directory, body bytes.Buffer
+	add := func(tag string, field []byte) {
+		field = append(field, fieldTerminator)
+		fmt.Fprintf(&directory, "%s%04d%05d", tag, len(field), body.Len())
+		body.Write(field)
+	}
+	for _, cf := range rec.ControlFields {
+		add(cf.Tag, []byte(cf.Value))
+	}
+	for _, df := range rec.DataFields {
+		field := []byte(df.Ind1 + df.Ind2)
+		for _, sf := range df.Subfields {
+			field = append(field, subfieldDelimiter)
+			field = append(field, sf.Code+sf.Value...)
+		}
+		add(df.Tag, field)
+	}
+	directory.WriteByte(fieldTerminator)
+	body.WriteByte(recordTerminator)
+
+	base := 24 + directory.Len()
+	leader := []byte(rec.Leader)
+	copy(leader[0:5], fmt.Sprintf("%05d", base+body.Len()))
+	copy(leader[12:17], fmt.Sprintf("%05d", base))
+	return append(append(leader, directory.Bytes()...), body.Bytes()...)
+}
+
+func TestReaderBinary(t *testing.T) {
+	rec, err := ParseXML([]byte(sampleXML))
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := encodeISO2709(rec)
+	stream := append(append(append([]byte{}, data...), '\n'), data...)
+
+	r, err := NewReader(bytes.NewReader(stream))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 2; i++ {
+		got, err := r.Next()
+		if err != nil {
+			t.Fatalf("record %d: %v", i, err)
+		}
+		if got.ControlField("001") != "12345" || got.SubfieldValue("245", "b") != "a subtitle /" {
+			t.Errorf("record %d did not round-trip: %s", i, got.Mnemonic())
+		}
+		if f := got.Fields("245")[0]; f.Ind1 != "1" || f.Ind2 != "0" {
+			t.Errorf("record %d lost indicators: %q%q", i, f.Ind1, f.Ind2)
+		}
+	}
+	if _, err := r.Next(); err != io.EOF {
+		t.Errorf("expected io.EOF after last record, got %v", err)
+	}
+}
+
+func TestReaderXMLCollection(t *testing.T) {
+	record := strings.TrimPrefix(sampleXML, `<?xml version="1.0" encoding="UTF-8"?>`)
+	collection := `<?xml version="1.0"?><collection xmlns="http://www.loc.gov/MARC21/slim">` + record + record + `</collection>`
+
+	r, err := NewReader(strings.NewReader(collection))
+	if err != nil {
+		t.Fatal(err)
+	}
+	n := 0
+	for {
+		rec, err := r.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		if rec.ControlField("001") != "12345" {
+			t.Errorf("unexpected record %s", rec.Mnemonic())
+		}
+		n++
+	}
+	if n != 2 {
+		t.Errorf("expected 2 records, got %d", n)
+	}
+}
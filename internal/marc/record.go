@@ -0,0 +1,197 @@
+package marc
+
+import (
+	"encoding/xml"
+	"fmt"
+	"strings"
+)
+
+// Namespace is the MARC21 slim XML namespace
+const Namespace = "http://www.loc.gov/MARC21/slim"
+
+// Record is a MARC21 bibliographic record
+type Record struct {
+	XMLName       xml.Name
+	Leader        string         `xml:"leader"`
+	ControlFields []ControlField `xml:"controlfield"`
+	DataFields    []DataField    `xml:"datafield"`
+}
+
+// ControlField is a 00X field with no indicators or subfields
+type ControlField struct {
+	Tag   string `xml:"tag,attr"`
+	Value string `xml:",chardata"`
+}
+
+// DataField is a variable data field with indicators and subfields
+type DataField struct {
+	Tag       string     `xml:"tag,attr"`
+	Ind1      string     `xml:"ind1,attr"`
+	Ind2      string     `xml:"ind2,attr"`
+	Subfields []Subfield `xml:"subfield"`
+}
+
+// Subfield is a single coded subfield within a data field
+type Subfield struct {
+	Code  string `xml:"code,attr"`
+	Value string `xml:",chardata"`
+}
+
+// ParseXML parses a MARCXML <record> document. A <collection> or other wrapper of several
+// records yields its one bibliographic record, so holdings beside it are ignored.
+func ParseXML(data []byte) (*Record, error) {
+	var rec Record
+	if err := xml.Unmarshal(data, &rec); err != nil {
+		return nil, fmt.Errorf("failed to parse MARCXML: %w", err)
+	}
+	if rec.XMLName.Local == "record" {
+		return &rec, nil
+	}
+
+	records, err := ParseXMLRecords(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse MARCXML: expected <record> root element, got <%s>", rec.XMLName.Local)
+	}
+	if len(records) == 1 {
+		return records[0], nil
+	}
+	selected, err := SelectRecords(records, SelectBibliographic)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse MARCXML <%s>: %w", rec.XMLName.Local, err)
+	}
+	return selected[0], nil
+}
+
+// XML serializes the record as a standalone MARCXML document
+func (r *Record) XML() ([]byte, error) {
+	out := *r
+	out.XMLName = xml.Name{Space: Namespace, Local: "record"}
+
+	data, err := xml.MarshalIndent(out, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal MARCXML: %w", err)
+	}
+
+	return append([]byte(xml.Header), data...), nil
+}
+
+// ControlField returns the value of the first control field with the given tag
+func (r *Record) ControlField(tag string) string {
+	for _, cf := range r.ControlFields {
+		if cf.Tag == tag {
+			return cf.Value
+		}
+	}
+	return ""
+}
+
+// Fields returns all data fields with the given tag
+func (r *Record) Fields(tag string) []DataField {
+	var fields []DataField
+	for _, df := range r.DataFields {
+		if df.Tag == tag {
+			fields = append(fields, df)
+		}
+	}
+	return fields
+}
+
+// SubfieldValue returns the first value of a subfield in the first field with the given tag
+func (r *Record) SubfieldValue(tag, code string) string {
+	for _, df := range r.DataFields {
+		if df.Tag == tag {
+			if v := df.Subfield(code); v != "" {
+				return v
+			}
+		}
+	}
+	return ""
+}
+
+// RemoveTags deletes every control and data field whose tag matches one of the patterns
+// (see TagMatches) and returns the number of fields removed
+func (r *Record) RemoveTags(patterns []string) int {
+	removed := 0
+
+	controlFields := r.ControlFields[:0]
+	for _, cf := range r.ControlFields {
+		if matchesAny(patterns, cf.Tag) {
+			removed++
+			continue
+		}
+		controlFields = append(controlFields, cf)
+	}
+	r.ControlFields = controlFields
+
+	dataFields := r.DataFields[:0]
+	for _, df := range r.DataFields {
+		if matchesAny(patterns, df.Tag) {
+			removed++
+			continue
+		}
+		dataFields = append(dataFields, df)
+	}
+	r.DataFields = dataFields
+
+	return removed
+}
+
+// HasTag reports whether any field's tag matches one of the patterns (see TagMatches)
+func (r *Record) HasTag(patterns []string) bool {
+	for _, cf := range r.ControlFields {
+		if matchesAny(patterns, cf.Tag) {
+			return true
+		}
+	}
+	for _, df := range r.DataFields {
+		if matchesAny(patterns, df.Tag) {
+			return true
+		}
+	}
+	return false
+}
+
+// Subfield returns the first value of the given subfield code
+func (f DataField) Subfield(code string) string {
+	for _, sf := range f.Subfields {
+		if sf.Code == code {
+			return sf.Value
+		}
+	}
+	return ""
+}
+
+// Text joins all subfield values with spaces
+func (f DataField) Text() string {
+	parts := make([]string, 0, len(f.Subfields))
+	for _, sf := range f.Subfields {
+		parts = append(parts, sf.Value)
+	}
+	return strings.Join(parts, " ")
+}
+
+// TagMatches reports whether tag matches pattern, where X (or x) in the
+// pattern matches any character, e.g. "9XX" matches "949"
+func TagMatches(pattern, tag string) bool {
+	if len(pattern) != len(tag) {
+		return false
+	}
+	for i := 0; i < len(pattern); i++ {
+		if pattern[i] == 'X' || pattern[i] == 'x' {
+			continue
+		}
+		if pattern[i] != tag[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func matchesAny(patterns []string, tag string) bool {
+	for _, p := range patterns {
+		if TagMatches(p, tag) {
+			return true
+		}
+	}
+	return false
+}
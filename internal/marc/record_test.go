@@ -0,0 +1,148 @@
+package marc
+
+import (
+	"strings"
+	"testing"
+)
+
+const sampleXML = `<?xml version="1.0" encoding="UTF-8"?>
+<record xmlns="http://www.loc.gov/MARC21/slim">
+  <leader>00000nam a2200000 a 4500</leader>
+  <controlfield tag="001">12345</controlfield>
+  <controlfield tag="008">200101s2020    nyu           000 0 eng d</controlfield>
+  <datafield tag="020" ind1=" " ind2=" ">
+    <subfield code="a">9780306406157</subfield>
+  </datafield>
+  <datafield tag="035" ind1=" " ind2=" ">
+    <subfield code="a">(OCoLC)123</subfield>
+  </datafield>
+  <datafield tag="245" ind1="1" ind2="0">
+    <subfield code="a">Test title :</subfield>
+    <subfield code="b">a subtitle /</subfield>
+    <subfield code="c">by Someone.</subfield>
+  </datafield>
+  <datafield tag="949" ind1=" " ind2=" ">
+    <subfield code="i">39151001234567</subfield>
+  </datafield>
+</record>`
+
+func TestParseXML(t *testing.T) {
+	rec, err := ParseXML([]byte(sampleXML))
+	if err != nil {
+		t.Fatalf("ParseXML failed: %v", err)
+	}
+
+	if rec.Leader != "00000nam a2200000 a 4500" {
+		t.Errorf("Unexpected leader: %q", rec.Leader)
+	}
+	if got := rec.ControlField("001"); got != "12345" {
+		t.Errorf("Expected 001=12345, got %q", got)
+	}
+	if got := rec.SubfieldValue("245", "b"); got != "a subtitle /" {
+		t.Errorf("Expected 245$b, got %q", got)
+	}
+	if fields := rec.Fields("245"); len(fields) != 1 || fields[0].Ind1 != "1" || fields[0].Ind2 != "0" {
+		t.Errorf("Unexpected 245 fields: %+v", fields)
+	}
+	if got := rec.Fields("245")[0].Text(); got != "Test title : a subtitle / by Someone." {
+		t.Errorf("Unexpected 245 text: %q", got)
+	}
+}
+
+func TestXMLRoundTrip(t *testing.T) {
+	rec, err := ParseXML([]byte(sampleXML))
+	if err != nil {
+		t.Fatalf("ParseXML failed: %v", err)
+	}
+
+	data, err := rec.XML()
+	if err != nil {
+		t.Fatalf("XML failed: %v", err)
+	}
+	if !strings.Contains(string(data), `xmlns="http://www.loc.gov/MARC21/slim"`) {
+		t.Errorf("Expected MARC21 namespace in output:\n%s", data)
+	}
+
+	again, err := ParseXML(data)
+	if err != nil {
+		t.Fatalf("ParseXML of round-tripped record failed: %v", err)
+	}
+	if len(again.DataFields) != len(rec.DataFields) || len(again.ControlFields) != len(rec.ControlFields) {
+		t.Errorf("Round trip changed field counts: %d/%d -> %d/%d",
+			len(rec.ControlFields), len(rec.DataFields), len(again.ControlFields), len(again.DataFields))
+	}
+}
+
+func TestTagMatches(t *testing.T) {
+	tests := []struct {
+		pattern string
+		tag     string
+		want    bool
+	}{
+		{"035", "035", true},
+		{"035", "036", false},
+		{"9XX", "949", true},
+		{"9xx", "901", true},
+		{"9XX", "856", false},
+		{"86X", "866", true},
+		{"XXX", "245", true},
+		{"24", "245", false},
+	}
+
+	for _, tt := range tests {
+		if got := TagMatches(tt.pattern, tt.tag); got != tt.want {
+			t.Errorf("TagMatches(%q, %q) = %v, want %v", tt.pattern, tt.tag, got, tt.want)
+		}
+	}
+}
+
+func TestRemoveTags(t *testing.T) {
+	rec, err := ParseXML([]byte(sampleXML))
+	if err != nil {
+		t.Fatalf("ParseXML failed: %v", err)
+	}
+
+	removed := rec.RemoveTags([]string{"001", "035", "9XX"})
+	if removed != 3 {
+		t.Errorf("Expected 3 fields removed, got %d", removed)
+	}
+	if rec.ControlField("001") != "" {
+		t.Error("Expected 001 to be removed")
+	}
+	if len(rec.Fields("035")) != 0 || len(rec.Fields("949")) != 0 {
+		t.Error("Expected 035 and 949 to be removed")
+	}
+	if len(rec.Fields("245")) != 1 || len(rec.Fields("020")) != 1 {
+		t.Error("Expected 020 and 245 to be kept")
+	}
+}
+
+func TestMnemonicRoundTrip(t *testing.T) {
+	text := `=LDR  00000nam\a2200000\i\4500
+=001  12345
+=245  10$aPrices :$bin {dollar}US /$cby Someone.
+=650  \0$aEconomics.
+`
+	rec, err := ParseMnemonic(text)
+	if err != nil {
+		t.Fatalf("ParseMnemonic failed: %v", err)
+	}
+
+	if rec.Leader != "00000nam a2200000 i 4500" {
+		t.Errorf("Unexpected leader: %q", rec.Leader)
+	}
+	if got := rec.SubfieldValue("245", "b"); got != "in $US /" {
+		t.Errorf("Expected 245$b with literal dollar, got %q", got)
+	}
+	if f := rec.Fields("650"); len(f) != 1 || f[0].Ind1 != " " || f[0].Ind2 != "0" {
+		t.Errorf("Unexpected 650: %+v", f)
+	}
+
+	if got := rec.Mnemonic(); got != text {
+		t.Errorf("Round trip mismatch:\n%s\nwant:\n%s", got, text)
+	}
+
+	if _, err := ParseMnemonic("=245  10aNo subfield delimiter"); err == nil {
+		t.Error("Expected error for text before first subfield")
+	}
+}
@@ -0,0 +1,162 @@
+package marc
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Issue severities
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+)
+
+// Issue is a problem found by Validate
+type Issue struct {
+	Tag      string `json:",omitempty"`
+	Severity string
+	Code     string
+	Message  string
+}
+
+// nonRepeatable lists common bibliographic tags that may occur at most once
+var nonRepeatable = map[string]bool{
+	"001": true, "003": true, "005": true, "008": true, "010": true,
+	"100": true, "110": true, "111": true, "130": true, "240": true, "245": true, "250": true,
+}
+
+// IsNonRepeatable reports whether tag may occur at most once in a bibliographic record
+func IsNonRepeatable(tag string) bool {
+	return nonRepeatable[tag]
+}
+
+var (
+	indicatorPattern = regexp.MustCompile(`^[0-9 ]$`)
+	subfieldPattern  = regexp.MustCompile(`^[a-z0-9]$`)
+	yearOf264        = regexp.MustCompile(`\d{4}`)
+)
+
+// Validate checks a bibliographic record for structural errors and common content problems.
+// Records are never modified.
+func Validate(rec *Record) []Issue {
+	var issues []Issue
+	add := func(tag, severity, code, format string, args ...any) {
+		issues = append(issues, Issue{Tag: tag, Severity: severity, Code: code, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if len(rec.Leader) != 24 {
+		add("LDR", SeverityError, "leader_length", "leader is %d characters, expected 24", len(rec.Leader))
+	} else {
+		if !strings.ContainsRune("acdnp", rune(rec.Leader[5])) {
+			add("LDR", SeverityError, "leader_status", "invalid record status %q in Leader/05", rec.Leader[5])
+		}
+		if !strings.ContainsRune("acdefgijkmoprt", rune(rec.Leader[6])) {
+			add("LDR", SeverityError, "leader_type", "invalid type of record %q in Leader/06", rec.Leader[6])
+		}
+		if !strings.ContainsRune("abcdims", rune(rec.Leader[7])) {
+			add("LDR", SeverityError, "leader_level", "invalid bibliographic level %q in Leader/07", rec.Leader[7])
+		}
+	}
+
+	counts := make(map[string]int)
+	for _, cf := range rec.ControlFields {
+		counts[cf.Tag]++
+		if cf.Tag == "008" && len(cf.Value) != 40 {
+			add("008", SeverityError, "008_length", "008 is %d characters, expected 40", len(cf.Value))
+		}
+	}
+	if counts["008"] == 0 {
+		add("008", SeverityWarning, "missing_008", "no 008 fixed-length data elements")
+	}
+
+	for _, df := range rec.DataFields {
+		counts[df.Tag]++
+		if !indicatorPattern.MatchString(df.Ind1) || !indicatorPattern.MatchString(df.Ind2) {
+			add(df.Tag, SeverityError, "invalid_indicator", "invalid indicators %q%q", df.Ind1, df.Ind2)
+		}
+		if len(df.Subfields) == 0 {
+			add(df.Tag, SeverityError, "no_subfields", "field has no subfields")
+		}
+		for _, sf := range df.Subfields {
+			if !subfieldPattern.MatchString(sf.Code) {
+				add(df.Tag, SeverityError, "invalid_subfield_code", "invalid subfield code %q", sf.Code)
+			}
+			if strings.TrimSpace(sf.Value) == "" {
+				add(df.Tag, SeverityWarning, "empty_subfield", "$%s is empty", sf.Code)
+			}
+		}
+	}
+
+	for tag, n := range counts {
+		if n > 1 && nonRepeatable[tag] {
+			add(tag, SeverityError, "repeated_field", "non-repeatable field occurs %d times", n)
+		}
+	}
+	if counts["245"] == 0 {
+		add("245", SeverityError, "missing_title", "no 245 title statement")
+	}
+	if counts["100"]+counts["110"]+counts["111"]+counts["130"] > 1 {
+		add("1XX", SeverityError, "multiple_main_entries", "more than one 1XX main entry")
+	}
+
+	for _, f := range rec.Fields("020") {
+		if isbn := f.Subfield("a"); isbn != "" && !ValidISBN(isbn) {
+			add("020", SeverityWarning, "invalid_isbn", "ISBN %q fails its check digit; use $z for invalid ISBNs", isbn)
+		}
+	}
+
+	if f008 := rec.ControlField("008"); len(f008) == 40 && f008[6] == 's' {
+		imprint := rec.SubfieldValue("264", "c")
+		if imprint == "" {
+			imprint = rec.SubfieldValue("260", "c")
+		}
+		if year := yearOf264.FindString(imprint); year != "" && year != f008[7:11] {
+			add("008", SeverityWarning, "date_mismatch", "008 date %s does not match imprint date %s", f008[7:11], year)
+		}
+	}
+
+	return issues
+}
+
+// ValidISBN checks the check digit of an ISBN-10 or ISBN-13, ignoring hyphens and any
+// qualifier after the number
+func ValidISBN(s string) bool {
+	s = strings.TrimSpace(s)
+	if i := strings.IndexAny(s, " ("); i >= 0 {
+		s = s[:i]
+	}
+	s = strings.ToUpper(strings.ReplaceAll(s, "-", ""))
+
+	switch len(s) {
+	case 10:
+		sum := 0
+		for i, c := range s {
+			var d int
+			switch {
+			case c >= '0' && c <= '9':
+				d = int(c - '0')
+			case c == 'X' && i == 9:
+				d = 10
+			default:
+				return false
+			}
+			sum += d * (10 - i)
+		}
+		return sum%11 == 0
+	case 13:
+		sum := 0
+		for i, c := range s {
+			if c < '0' || c > '9' {
+				return false
+			}
+			d := int(c - '0')
+			if i%2 == 1 {
+				d *= 3
+			}
+			sum += d
+		}
+		return sum%10 == 0
+	}
+	return false
+}
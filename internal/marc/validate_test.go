@@ -0,0 +1,46 @@
+package marc
+
+import "testing"
+
+func TestValidate(t *testing.T) {
+	rec, err := ParseXML([]byte(sampleXML))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if issues := Validate(rec); len(issues) != 0 {
+		t.Errorf("sample record should be valid, got %+v", issues)
+	}
+
+	rec.Leader = "short"
+	rec.DataFields = append(rec.DataFields,
+		DataField{Tag: "245", Ind1: "1", Ind2: "0", Subfields: []Subfield{{Code: "a", Value: "Again"}}},
+		DataField{Tag: "020", Ind1: "x", Ind2: " ", Subfields: []Subfield{{Code: "a", Value: "0684801222"}}},
+	)
+
+	codes := make(map[string]bool)
+	for _, issue := range Validate(rec) {
+		codes[issue.Code] = true
+	}
+	for _, want := range []string{"leader_length", "repeated_field", "invalid_indicator", "invalid_isbn"} {
+		if !codes[want] {
+			t.Errorf("expected issue %s, got %v", want, codes)
+		}
+	}
+}
+
+func TestValidISBN(t *testing.T) {
+	for isbn, want := range map[string]bool{
+		"0684801221":        true,
+		"0-684-80122-1":     true,
+		"9780306406157":     true,
+		"080442957X":        true,
+		"0684801222":        false,
+		"9780306406158":     false,
+		"0684801221 (pbk.)": true,
+		"12345":             false,
+	} {
+		if got := ValidISBN(isbn); got != want {
+			t.Errorf("ValidISBN(%q) = %v, want %v", isbn, got, want)
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package marc
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+)
+
+// ISO 2709 limits on what the leader and directory can address
+const (
+	maxRecordLength = 99999
+	maxFieldLength  = 9999
+)
+
+// ISO2709 serializes the record as binary MARC (.mrc) in UTF-8. The leader's length, base
+// address and character coding (09) are filled in; the rest is kept.
+func (r *Record) ISO2709() ([]byte, error) {
+	if len(r.Leader) != 24 {
+		return nil, fmt.Errorf("invalid MARC leader length %d", len(r.Leader))
+	}
+
+	var directory, data bytes.Buffer
+	addField := func(tag string, field []byte) error {
+		if len(tag) != 3 {
+			return fmt.Errorf("invalid MARC tag %q", tag)
+		}
+		field = append(field, fieldTerminator)
+		if len(field) > maxFieldLength {
+			return fmt.Errorf("MARC field %s is %d bytes, over the %d limit", tag, len(field), maxFieldLength)
+		}
+		fmt.Fprintf(&directory, "%s%04d%05d", tag, len(field), data.Len())
+		data.Write(field)
+		return nil
+	}
+	for _, cf := range r.ControlFields {
+		if err := addField(cf.Tag, []byte(cf.Value)); err != nil {
+			return nil, err
+		}
+	}
+	for _, df := range r.DataFields {
+		var field bytes.Buffer
+		field.WriteString(indicator(df.Ind1))
+		field.WriteString(indicator(df.Ind2))
+		for _, sf := range df.Subfields {
+			field.WriteByte(subfieldDelimiter)
+			field.WriteString(sf.Code)
+			field.WriteString(sf.Value)
+		}
+		if err := addField(df.Tag, field.Bytes()); err != nil {
+			return nil, err
+		}
+	}
+	directory.WriteByte(fieldTerminator)
+	data.WriteByte(recordTerminator)
+
+	base := 24 + directory.Len()
+	length := base + data.Len()
+	if length > maxRecordLength {
+		return nil, fmt.Errorf("MARC record is %d bytes, over the %d limit", length, maxRecordLength)
+	}
+	leader := fmt.Sprintf("%05d%sa%s%05d%s", length, r.Leader[5:9], r.Leader[10:12], base, r.Leader[17:])
+
+	out := make([]byte, 0, length)
+	out = append(out, leader...)
+	out = append(out, directory.Bytes()...)
+	return append(out, data.Bytes()...), nil
+}
+
+// indicator returns a one-character indicator, blank when it is missing
+func indicator(ind string) string {
+	if len(ind) != 1 {
+		return " "
+	}
+	return ind
+}
+
+// CollectionXML serializes records as one MARCXML <collection> document
+func CollectionXML(records []*Record) ([]byte, error) {
+	collection := struct {
+		XMLName xml.Name `xml:"collection"`
+		Xmlns   string   `xml:"xmlns,attr"`
+		Records []Record `xml:"record"`
+	}{Xmlns: Namespace}
+	for _, r := range records {
+		rec := *r
+		rec.XMLName = xml.Name{Local: "record"}
+		collection.Records = append(collection.Records, rec)
+	}
+
+	data, err := xml.MarshalIndent(collection, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal MARCXML: %w", err)
+	}
+	return append([]byte(xml.Header), data...), nil
+}
@@ -0,0 +1,64 @@
+package marc
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestISO2709RoundTrip(t *testing.T) {
+	rec, err := ParseXML([]byte(sampleXML))
+	if err != nil {
+		t.Fatal(err)
+	}
+	rec.DataFields = append(rec.DataFields, DataField{Tag: "500", Ind1: " ", Ind2: " ", Subfields: []Subfield{{Code: "a", Value: "Übersetzt aus dem Französischen."}}})
+
+	data, err := rec.ISO2709()
+	if err != nil {
+		t.Fatalf("ISO2709 failed: %v", err)
+	}
+	if length, _ := strconv.Atoi(string(data[:5])); length != len(data) {
+		t.Errorf("Leader length %s, record is %d bytes", data[:5], len(data))
+	}
+	if data[9] != 'a' {
+		t.Errorf("Leader/09 = %q, want a", data[9])
+	}
+
+	parsed, err := ParseISO2709(data)
+	if err != nil {
+		t.Fatalf("ParseISO2709 failed: %v", err)
+	}
+	if !reflect.DeepEqual(parsed.ControlFields, rec.ControlFields) {
+		t.Errorf("Control fields = %+v, want %+v", parsed.ControlFields, rec.ControlFields)
+	}
+	if !reflect.DeepEqual(parsed.DataFields, rec.DataFields) {
+		t.Errorf("Data fields = %+v, want %+v", parsed.DataFields, rec.DataFields)
+	}
+
+	rec.Leader = "short"
+	if _, err := rec.ISO2709(); err == nil {
+		t.Error("Expected an error for a short leader")
+	}
+}
+
+func TestCollectionXML(t *testing.T) {
+	rec, err := ParseXML([]byte(sampleXML))
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := CollectionXML([]*Record{rec, rec})
+	if err != nil {
+		t.Fatalf("CollectionXML failed: %v", err)
+	}
+	if !strings.Contains(string(data), `<collection xmlns="http://www.loc.gov/MARC21/slim">`) {
+		t.Errorf("Missing collection element:\n%s", data)
+	}
+	records, err := ParseXMLRecords(data)
+	if err != nil {
+		t.Fatalf("ParseXMLRecords failed: %v", err)
+	}
+	if len(records) != 2 || records[1].SubfieldValue("245", "a") != "Test title :" {
+		t.Errorf("Parsed %d records back: %+v", len(records), records)
+	}
+}
@@ -0,0 +1,155 @@
+// Package mistral provides the Mistral API: its chat models, Pixtral vision models and the
+// Mistral OCR model
+package mistral
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/lehigh-university-libraries/cataloger/internal/openai"
+	"github.com/lehigh-university-libraries/cataloger/internal/providers"
+)
+
+// DefaultBaseURL is the Mistral API
+const DefaultBaseURL = "https://api.mistral.ai/v1"
+
+// Registration registers the provider as "mistral"
+var Registration = providers.Registration{
+	Name:         "mistral",
+	New:          func() providers.Provider { return New() },
+	DefaultModel: providers.EnvModel("MISTRAL_MODEL", "pixtral-large-latest"),
+	Configured:   func() bool { return os.Getenv("MISTRAL_API_KEY") != "" },
+	Vision:       true,
+}
+
+// Mistral is a provider for the Mistral API. Chat and Pixtral models go through its
+// OpenAI-compatible chat completions; OCR models (mistral-ocr-*) through /ocr.
+type Mistral struct {
+	*openai.OpenAI
+	baseURL string
+	apiKey  string
+}
+
+// New returns a new Mistral provider
+//
+// Configured with:
+//   - MISTRAL_API_KEY: API key (required)
+//   - MISTRAL_BASE_URL: API URL, for a proxy (default DefaultBaseURL)
+func New() *Mistral {
+	baseURL := strings.TrimSuffix(os.Getenv("MISTRAL_BASE_URL"), "/")
+	if baseURL == "" {
+		baseURL = DefaultBaseURL
+	}
+	apiKey := os.Getenv("MISTRAL_API_KEY")
+	return &Mistral{
+		OpenAI: openai.NewEndpoint(openai.Endpoint{
+			Name:       "mistral",
+			BaseURL:    baseURL,
+			APIKey:     apiKey,
+			KeyEnv:     "MISTRAL_API_KEY",
+			RequireKey: true,
+		}),
+		baseURL: baseURL,
+		apiKey:  apiKey,
+	}
+}
+
+// isOCRModel reports whether a model is one of the OCR models, which only take /ocr requests
+func isOCRModel(model string) bool {
+	return strings.HasPrefix(model, "mistral-ocr")
+}
+
+// ExtractText sends a text prompt to a chat model
+func (m *Mistral) ExtractText(ctx context.Context, config providers.Config) (string, error) {
+	if isOCRModel(config.Model) {
+		return "", fmt.Errorf("mistral model %s only reads images", config.Model)
+	}
+	return m.OpenAI.ExtractText(ctx, config)
+}
+
+// StreamText streams a chat model's response
+func (m *Mistral) StreamText(ctx context.Context, config providers.Config, onChunk func(string)) (string, error) {
+	if isOCRModel(config.Model) {
+		return "", fmt.Errorf("mistral model %s only reads images", config.Model)
+	}
+	return m.OpenAI.StreamText(ctx, config, onChunk)
+}
+
+// GenerateFromImage sends an image with the prompt to a vision model, or transcribes it with
+// an OCR model, which takes no prompt
+func (m *Mistral) GenerateFromImage(ctx context.Context, config providers.Config, image []byte) (string, error) {
+	if isOCRModel(config.Model) {
+		return m.ocr(ctx, config.Model, image)
+	}
+	return m.OpenAI.GenerateFromImage(ctx, config, image)
+}
+
+// GenerateFromImages sends several images to a vision model in one message
+func (m *Mistral) GenerateFromImages(ctx context.Context, config providers.Config, images [][]byte) (string, error) {
+	if isOCRModel(config.Model) {
+		if len(images) == 1 {
+			return m.ocr(ctx, config.Model, images[0])
+		}
+		return "", fmt.Errorf("mistral model %s takes one image per request", config.Model)
+	}
+	return m.OpenAI.GenerateFromImages(ctx, config, images)
+}
+
+// ocr transcribes an image with an OCR model, returning the Markdown of its page
+func (m *Mistral) ocr(ctx context.Context, model string, image []byte) (string, error) {
+	if m.apiKey == "" {
+		return "", fmt.Errorf("MISTRAL_API_KEY environment variable not set")
+	}
+	mediaType := http.DetectContentType(image)
+	if !strings.HasPrefix(mediaType, "image/") {
+		return "", fmt.Errorf("unsupported image type for mistral: %s", mediaType)
+	}
+	requestBody, err := json.Marshal(map[string]any{
+		"model": model,
+		"document": map[string]string{
+			"type":      "image_url",
+			"image_url": "data:" + mediaType + ";base64," + base64.StdEncoding.EncodeToString(image),
+		},
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal request body: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", m.baseURL+"/ocr", bytes.NewReader(requestBody))
+	if err != nil {
+		return "", fmt.Errorf("failed to create new request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+m.apiKey)
+
+	resp, err := providers.HTTPClient().Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return "", fmt.Errorf("received non-200 status code: %d - %s", resp.StatusCode, string(body))
+	}
+
+	var response struct {
+		Pages []struct {
+			Markdown string `json:"markdown"`
+		} `json:"pages"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return "", fmt.Errorf("failed to decode response body: %w", err)
+	}
+	pages := make([]string, len(response.Pages))
+	for i, page := range response.Pages {
+		pages[i] = page.Markdown
+	}
+	return strings.Join(pages, "\n\n"), nil
+}
@@ -0,0 +1,63 @@
+package mistral
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/lehigh-university-libraries/cataloger/internal/providers"
+)
+
+func TestMistral(t *testing.T) {
+	var got map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer mk-test" {
+			t.Errorf("%s without the API key", r.URL.Path)
+		}
+		got = nil
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Error(err)
+		}
+		switch r.URL.Path {
+		case "/v1/chat/completions":
+			_, _ = w.Write([]byte(`{"choices":[{"message":{"content":"WALDEN"},"finish_reason":"stop"}]}`))
+		case "/v1/ocr":
+			_, _ = w.Write([]byte(`{"pages":[{"index":0,"markdown":"# WALDEN"},{"index":1,"markdown":"by Henry D. Thoreau"}]}`))
+		default:
+			t.Errorf("unexpected request %s", r.URL.Path)
+		}
+	}))
+	defer server.Close()
+	t.Setenv("MISTRAL_API_KEY", "mk-test")
+	t.Setenv("MISTRAL_BASE_URL", server.URL+"/v1/")
+	ctx := context.Background()
+	png := []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+
+	m := New()
+	text, err := m.GenerateFromImage(ctx, providers.Config{Model: "pixtral-large-latest", Prompt: "Transcribe"}, png)
+	if err != nil || text != "WALDEN" {
+		t.Fatalf("Pixtral: %q, %v", text, err)
+	}
+	if messages, _ := got["messages"].([]any); len(messages) != 1 {
+		t.Errorf("chat request = %v", got)
+	}
+
+	text, err = m.GenerateFromImage(ctx, providers.Config{Model: "mistral-ocr-latest", Prompt: "ignored"}, png)
+	if err != nil || text != "# WALDEN\n\nby Henry D. Thoreau" {
+		t.Errorf("OCR model: %q, %v", text, err)
+	}
+	if document, _ := got["document"].(map[string]any); !strings.HasPrefix(document["image_url"].(string), "data:image/png;base64,") {
+		t.Errorf("OCR request = %v", got)
+	}
+	if _, err := m.ExtractText(ctx, providers.Config{Model: "mistral-ocr-latest", Prompt: "p"}); err == nil {
+		t.Error("expected an error sending a text prompt to an OCR model")
+	}
+
+	t.Setenv("MISTRAL_API_KEY", "")
+	if _, err := New().ExtractText(ctx, providers.Config{Model: "mistral-large-latest", Prompt: "p"}); err == nil || !strings.Contains(err.Error(), "MISTRAL_API_KEY") {
+		t.Errorf("without a key: err = %v", err)
+	}
+}
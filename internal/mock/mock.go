@@ -0,0 +1,422 @@
+package mock
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"math/rand/v2"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/lehigh-university-libraries/cataloger/internal/eval/metadata"
+	"github.com/lehigh-university-libraries/cataloger/internal/marc"
+	"github.com/lehigh-university-libraries/cataloger/internal/perturb"
+	"github.com/lehigh-university-libraries/cataloger/internal/providers"
+)
+
+// SampleTitlePage is returned by mock OCR when no text is configured for an image
+const SampleTitlePage = `THE ADVENTURES OF
+TOM SAWYER
+
+By Mark Twain
+
+New York
+Harper & Brothers Publishers
+1876`
+
+// ocrMarker precedes the OCR text in the metadata extraction prompt, after a description of
+// where it comes from ("a book title page:", "a map's title panel and margins:")
+const ocrMarker = "Here is the OCR text from "
+
+// copyrightMarker precedes the OCR text in the copyright page prompt
+const copyrightMarker = "Here is the OCR text from a book's copyright page:"
+
+// Registration registers the provider as "mock"
+var Registration = providers.Registration{
+	Name:         "mock",
+	New:          func() providers.Provider { return New() },
+	DefaultModel: func() string { return "mock" },
+	Vision:       true,
+}
+
+// Mock is an offline provider that returns a canned response or metadata derived from the
+// OCR text in the prompt, optionally perturbed, so the pipeline runs without an LLM backend
+type Mock struct {
+	Response  string  // Returned verbatim when set
+	ErrorRate float64 // Character noise applied to derived values
+	DropRate  float64 // Probability each derived value is left empty
+	// Probability a request is refused, as a provider's safety filter would
+	RefusalRate float64
+
+	mu   sync.Mutex
+	rng  *rand.Rand
+	seed uint64
+}
+
+// New returns a mock provider configured from MOCK_RESPONSE_FILE, MOCK_ERROR_RATE,
+// MOCK_DROP_RATE, MOCK_REFUSAL_RATE and MOCK_SEED
+func New() *Mock {
+	m := &Mock{
+		ErrorRate:   envFloat("MOCK_ERROR_RATE"),
+		DropRate:    envFloat("MOCK_DROP_RATE"),
+		RefusalRate: envFloat("MOCK_REFUSAL_RATE"),
+		seed:        uint64(envFloat("MOCK_SEED")),
+	}
+	if path := os.Getenv("MOCK_RESPONSE_FILE"); path != "" {
+		if data, err := os.ReadFile(path); err == nil {
+			m.Response = string(data)
+		}
+	}
+	m.rng = perturb.NewRand(m.seed)
+	return m
+}
+
+// ExtractText returns the canned response, or metadata JSON derived from the prompt's OCR text
+func (m *Mock) ExtractText(ctx context.Context, config providers.Config) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	if m.refuse(config.Prompt) {
+		return "", &providers.RefusalError{Provider: "mock", Category: providers.RefusalSafety, Reason: "MOCK_REFUSAL_RATE"}
+	}
+	if m.Response != "" {
+		return m.Response, nil
+	}
+
+	text := config.Prompt
+	if _, after, ok := strings.Cut(text, copyrightMarker); ok {
+		after, _, _ = strings.Cut(after, "\n\nExtract the copyright page data")
+		data, err := json.Marshal(CopyrightMetadataFromText(after))
+		if err != nil {
+			return "", fmt.Errorf("failed to marshal mock copyright metadata: %w", err)
+		}
+		return string(data), nil
+	}
+	if _, after, ok := strings.Cut(text, ocrMarker); ok {
+		if _, ocr, ok := strings.Cut(after, ":\n\n"); ok {
+			text = ocr
+		}
+	}
+	text, _, _ = strings.Cut(text, "\n\nExtract the bibliographic metadata")
+
+	md := m.perturb(MetadataFromText(text))
+	data, err := json.Marshal(md)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal mock metadata: %w", err)
+	}
+	return string(data), nil
+}
+
+// refuse reports whether to refuse a prompt. The draw depends on the prompt and seed, so each
+// record is refused or not the same way in every run, and a reworded retry draws again.
+func (m *Mock) refuse(prompt string) bool {
+	if m.RefusalRate <= 0 {
+		return false
+	}
+	h := fnv.New64a()
+	h.Write([]byte(prompt))
+	return perturb.NewRand(h.Sum64()^m.seed).Float64() < m.RefusalRate
+}
+
+func (m *Mock) perturb(md metadata.BookMetadata) metadata.BookMetadata {
+	if m.ErrorRate <= 0 && m.DropRate <= 0 {
+		return md
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, v := range []*string{&md.Title, &md.Author, &md.Publisher, &md.PublicationDate, &md.PublicationCity, &md.Edition} {
+		if m.DropRate > 0 && m.rng.Float64() < m.DropRate {
+			*v = ""
+			continue
+		}
+		*v = perturb.Text(*v, m.ErrorRate, m.rng)
+	}
+	return md
+}
+
+// GenerateFromImage returns mock OCR for the image at config.ImagePath (see OCRText)
+func (m *Mock) GenerateFromImage(ctx context.Context, config providers.Config, image []byte) (string, error) {
+	return OCRText(config.ImagePath), nil
+}
+
+// GenerateFromImages returns mock metadata JSON as if read from the images: that of the mock
+// OCR of the title page at config.ImagePath
+func (m *Mock) GenerateFromImages(ctx context.Context, config providers.Config, images [][]byte) (string, error) {
+	return m.ExtractText(ctx, providers.Config{Prompt: OCRText(config.ImagePath)})
+}
+
+// OCRText returns mock OCR for an image: a sidecar <image>.txt if present, else
+// MOCK_OCR_TEXT, else SampleTitlePage
+func OCRText(imagePath string) string {
+	for _, sidecar := range []string{imagePath + ".txt", strings.TrimSuffix(imagePath, filepath.Ext(imagePath)) + ".txt"} {
+		if data, err := os.ReadFile(sidecar); err == nil {
+			return string(data)
+		}
+	}
+	if text := os.Getenv("MOCK_OCR_TEXT"); text != "" {
+		return text
+	}
+	return SampleTitlePage
+}
+
+var (
+	yearPattern      = regexp.MustCompile(`\b(1[5-9]\d\d|20\d\d)\b`)
+	byPattern        = regexp.MustCompile(`(?i)^by\s+`)
+	editionPattern   = regexp.MustCompile(`(?i)\b(edition|ed\.)`)
+	publisherPattern = regexp.MustCompile(`(?i)(press|publish|books|&|company|\bco\b|\binc\b|verlag|éditions|editorial|sons)`)
+)
+
+// MetadataFromText derives metadata from title page text with simple layout heuristics:
+// title lines come before a "by" line, and place/publisher/year follow it
+func MetadataFromText(text string) metadata.BookMetadata {
+	var lines []string
+	for _, line := range strings.Split(text, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+
+	var md metadata.BookMetadata
+	if len(lines) == 0 {
+		return md
+	}
+
+	byIdx := -1
+	for i, line := range lines {
+		if byPattern.MatchString(line) {
+			byIdx = i
+			break
+		}
+	}
+
+	rest := lines[1:]
+	md.Title = lines[0]
+	if byIdx > 0 {
+		md.Title = strings.Join(lines[:byIdx], " ")
+		md.Author = byPattern.ReplaceAllString(lines[byIdx], "")
+		rest = lines[byIdx+1:]
+	}
+
+	var imprint []string
+	for _, line := range rest {
+		switch {
+		case yearPattern.MatchString(line) && len(line) <= 12:
+			md.PublicationDate = yearPattern.FindString(line)
+		case editionPattern.MatchString(line):
+			md.Edition = line
+		default:
+			imprint = append(imprint, line)
+		}
+	}
+
+	// Prefer a line that looks like a publisher; otherwise assume place then publisher
+	for i, line := range imprint {
+		if publisherPattern.MatchString(line) {
+			md.Publisher = line
+			imprint = append(imprint[:i:i], imprint[i+1:]...)
+			break
+		}
+	}
+	for _, line := range imprint {
+		switch {
+		case md.PublicationCity == "":
+			md.PublicationCity = line
+		case md.Publisher == "":
+			md.Publisher = line
+		}
+	}
+
+	return md
+}
+
+// TitlePageText renders a plausible title page transcription from a reference record,
+// so evaluations can run the text path end to end without images or OCR
+func TitlePageText(rec *marc.Record) string {
+	var lines []string
+	add := func(s string) {
+		if s = strings.TrimSpace(strings.TrimRight(s, " /:;,.=")); s != "" {
+			lines = append(lines, s)
+		}
+	}
+
+	add(rec.SubfieldValue("245", "a"))
+	add(rec.SubfieldValue("245", "b"))
+	if author := strings.TrimRight(rec.SubfieldValue("100", "a"), " ,."); author != "" {
+		lines = append(lines, "", "By "+author, "")
+	}
+	add(rec.SubfieldValue("250", "a"))
+
+	imprint := "264"
+	if len(rec.Fields("264")) == 0 {
+		imprint = "260"
+	}
+	add(rec.SubfieldValue(imprint, "a"))
+	add(rec.SubfieldValue(imprint, "b"))
+	add(rec.SubfieldValue(imprint, "c"))
+
+	return strings.Join(lines, "\n")
+}
+
+// CopyrightPageText renders a plausible copyright page from a reference record: the
+// copyright date and a CIP data block with the main entry, title, series, ISBNs, subject
+// tracings, LC and Dewey classification and LCCN
+func CopyrightPageText(rec *marc.Record) string {
+	trim := func(s string) string { return strings.TrimSpace(strings.TrimRight(s, " /:;,.=")) }
+
+	var lines []string
+	date := rec.SubfieldValue("264", "c")
+	for _, df := range rec.Fields("264") {
+		if df.Ind2 == "4" {
+			date = df.Subfield("c")
+		}
+	}
+	if date == "" {
+		date = rec.SubfieldValue("260", "c")
+	}
+	if year := yearPattern.FindString(date); year != "" {
+		line := "Copyright © " + year
+		if author := trim(rec.SubfieldValue("100", "a")); author != "" {
+			line += " by " + author
+		}
+		lines = append(lines, line, "")
+	}
+
+	lines = append(lines, "Library of Congress Cataloging-in-Publication Data")
+	if entry := trim(rec.SubfieldValue("100", "a")); entry != "" {
+		if dates := trim(rec.SubfieldValue("100", "d")); dates != "" {
+			entry += ", " + dates
+		}
+		lines = append(lines, entry)
+	}
+	title := trim(rec.SubfieldValue("245", "a"))
+	if sub := trim(rec.SubfieldValue("245", "b")); sub != "" {
+		title += " : " + sub
+	}
+	if title != "" {
+		lines = append(lines, title+".")
+	}
+	if series := trim(rec.SubfieldValue("490", "a")); series != "" {
+		lines = append(lines, "("+series+")")
+	}
+	for _, df := range rec.Fields("020") {
+		if isbn := df.Subfield("a"); isbn != "" {
+			line := "ISBN " + isbn
+			if q := strings.Trim(trim(df.Subfield("q")), "()"); q != "" {
+				line += " (" + q + ")"
+			}
+			lines = append(lines, line)
+		}
+	}
+
+	var tracings []string
+	for _, df := range rec.DataFields {
+		if df.Tag != "600" && df.Tag != "610" && df.Tag != "650" && df.Tag != "651" {
+			continue
+		}
+		var parts []string
+		for _, sf := range df.Subfields {
+			switch {
+			case sf.Code == "d" && len(parts) > 0:
+				parts[len(parts)-1] += ", " + trim(sf.Value)
+			case strings.Contains("axyzv", sf.Code):
+				parts = append(parts, trim(sf.Value))
+			}
+		}
+		if len(parts) > 0 {
+			tracings = append(tracings, fmt.Sprintf("%d. %s.", len(tracings)+1, strings.Join(parts, "--")))
+		}
+	}
+	if len(tracings) > 0 {
+		lines = append(lines, strings.Join(tracings, " "))
+	}
+
+	if class := trim(rec.SubfieldValue("050", "a")); class != "" {
+		if item := trim(rec.SubfieldValue("050", "b")); item != "" {
+			class += " " + item
+		}
+		lines = append(lines, class)
+	}
+	lccn := strings.TrimSpace(rec.SubfieldValue("010", "a"))
+	if ddc := strings.TrimSpace(rec.SubfieldValue("082", "a")); ddc != "" {
+		line := ddc
+		if ed := strings.TrimSpace(rec.SubfieldValue("082", "2")); ed != "" {
+			line += "--dc" + ed
+		}
+		if lccn != "" {
+			line += "    " + lccn
+			lccn = ""
+		}
+		lines = append(lines, line)
+	}
+	if lccn != "" {
+		lines = append(lines, "LCCN "+lccn)
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+var (
+	copyrightPattern = regexp.MustCompile(`(?i)(?:copyright|©|\(c\))`)
+	tracingPattern   = regexp.MustCompile(`(?:^|\s)\d+\.\s+`)
+	classPattern     = regexp.MustCompile(`^[A-Z]{1,3}\s?\d+(\.\d+)?\b`)
+	ddcPattern       = regexp.MustCompile(`^(\d{3}(?:[./'’\d]*\d)?)(?:--dc(\d+))?(?:\s+(\S+))?$`)
+)
+
+// CopyrightMetadataFromText parses a copyright page in the layout of CopyrightPageText
+func CopyrightMetadataFromText(text string) metadata.CopyrightMetadata {
+	var cm metadata.CopyrightMetadata
+	cip := &metadata.CIPData{}
+	inCIP := false
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		switch {
+		case line == "":
+		case strings.Contains(line, "Cataloging-in-Publication"):
+			inCIP = true
+		case !inCIP && copyrightPattern.MatchString(line):
+			cm.CopyrightDate = yearPattern.FindString(line)
+		case !inCIP:
+		case strings.HasPrefix(line, "ISBN"):
+			cip.ISBN = append(cip.ISBN, line)
+		case strings.HasPrefix(line, "LCCN"):
+			cip.LCCN = strings.TrimSpace(strings.TrimPrefix(line, "LCCN"))
+		case strings.HasPrefix(line, "1. "):
+			for _, t := range tracingPattern.Split(line, -1) {
+				if t = strings.TrimSpace(t); t != "" {
+					cip.Subjects = append(cip.Subjects, t)
+				}
+			}
+		case strings.HasPrefix(line, "(") && strings.HasSuffix(line, ")"):
+			cip.Series = strings.Trim(line, "()")
+		case ddcPattern.MatchString(line):
+			m := ddcPattern.FindStringSubmatch(line)
+			cip.DDC, cip.DDCEdition = m[1], m[2]
+			if m[3] != "" {
+				cip.LCCN = m[3]
+			}
+		case classPattern.MatchString(line) && cip.Title != "":
+			cip.LCClass = line
+		case cip.MainEntry == "" && cip.Title == "" && !strings.HasSuffix(line, "."):
+			cip.MainEntry = line
+		case cip.Title == "":
+			cip.Title = strings.TrimSuffix(line, ".")
+		}
+	}
+	if inCIP {
+		cm.CIP = cip
+		cm.ISBN = cip.ISBN
+		cm.LCCN = cip.LCCN
+	}
+	return cm
+}
+
+func envFloat(name string) float64 {
+	v, _ := strconv.ParseFloat(os.Getenv(name), 64)
+	return v
+}
@@ -0,0 +1,86 @@
+package mock
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"testing"
+
+	"github.com/lehigh-university-libraries/cataloger/internal/eval/metadata"
+	"github.com/lehigh-university-libraries/cataloger/internal/marc"
+	"github.com/lehigh-university-libraries/cataloger/internal/providers"
+)
+
+func TestMetadataFromText(t *testing.T) {
+	got := MetadataFromText(SampleTitlePage)
+	want := metadata.BookMetadata{
+		Title:           "THE ADVENTURES OF TOM SAWYER",
+		Author:          "Mark Twain",
+		Publisher:       "Harper & Brothers Publishers",
+		PublicationCity: "New York",
+		PublicationDate: "1876",
+	}
+	if got.Title != want.Title || got.Author != want.Author || got.Publisher != want.Publisher ||
+		got.PublicationCity != want.PublicationCity || got.PublicationDate != want.PublicationDate {
+		t.Errorf("MetadataFromText() = %+v, want %+v", got, want)
+	}
+}
+
+func TestCopyrightMetadataFromText(t *testing.T) {
+	rec, err := marc.ParseMnemonic(`=010  \\$a   96012345 
+=020  \\$a0684801221$q(hardcover)
+=050  00$aPS3537.A426$bC38 1996
+=082  00$a813/.54$220
+=100  1\$aSmith, Jane,$d1950-
+=245  10$aBridges :$ba history /$cJane Smith.
+=264  \4$c©1996
+=600  10$aTwain, Mark,$d1835-1910.
+=650  \0$aBridges$xHistory$y20th century.`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cm := CopyrightMetadataFromText(CopyrightPageText(rec))
+	if cm.CopyrightDate != "1996" || cm.CIP == nil {
+		t.Fatalf("CopyrightMetadataFromText() = %+v", cm)
+	}
+	cip := *cm.CIP
+	if cip.MainEntry != "Smith, Jane, 1950-" || cip.Title != "Bridges : a history" {
+		t.Errorf("main entry, title = %q, %q", cip.MainEntry, cip.Title)
+	}
+	if cip.LCClass != "PS3537.A426 C38 1996" || cip.DDC != "813/.54" || cip.DDCEdition != "20" || cip.LCCN != "96012345" {
+		t.Errorf("classification = %q, %q, %q, %q", cip.LCClass, cip.DDC, cip.DDCEdition, cip.LCCN)
+	}
+	wantSubjects := []string{"Twain, Mark, 1835-1910.", "Bridges--History--20th century."}
+	if !slices.Equal(cip.Subjects, wantSubjects) {
+		t.Errorf("subjects = %q, want %q", cip.Subjects, wantSubjects)
+	}
+	if !slices.Equal(cip.ISBN, []string{"ISBN 0684801221 (hardcover)"}) {
+		t.Errorf("isbn = %q", cip.ISBN)
+	}
+}
+
+func TestRefusalRate(t *testing.T) {
+	m := &Mock{RefusalRate: 0.5}
+	refused := 0
+	for i := range 200 {
+		prompt := fmt.Sprintf("Here is the OCR text from a book title page:\n\nTitle %d", i)
+		_, err := m.ExtractText(context.Background(), providers.Config{Prompt: prompt})
+		var refusal *providers.RefusalError
+		if errors.As(err, &refusal) {
+			refused++
+		} else if err != nil {
+			t.Fatal(err)
+		}
+
+		// The same prompt is refused the same way every time
+		_, again := m.ExtractText(context.Background(), providers.Config{Prompt: prompt})
+		if (err == nil) != (again == nil) {
+			t.Fatalf("prompt %d refused inconsistently", i)
+		}
+	}
+	if refused < 60 || refused > 140 {
+		t.Errorf("refused %d of 200 prompts at rate 0.5", refused)
+	}
+}
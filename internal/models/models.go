@@ -1,14 +1,97 @@
 package models
 
-import "time"
+import (
+	"slices"
+	"time"
+
+	"github.com/lehigh-university-libraries/cataloger/internal/holdings"
+	"github.com/lehigh-university-libraries/cataloger/internal/textdiff"
+)
 
 // CatalogSession represents a book cataloging session
 type CatalogSession struct {
-	ID        string      `json:"id"`
-	Images    []ImageItem `json:"images"`
-	Provider  string      `json:"provider,omitempty"`
-	Model     string      `json:"model,omitempty"`
-	CreatedAt time.Time   `json:"created_at"`
+	ID             string                  `json:"id"`
+	Images         []ImageItem             `json:"images"`
+	Provider       string                  `json:"provider,omitempty"`
+	Model          string                  `json:"model,omitempty"`
+	MARC           string                  `json:"marc,omitempty"`           // Generated MARCXML
+	Holdings       *holdings.FOLIOHoldings `json:"holdings,omitempty"`       // Scaffolded when HOLDINGS_FORMAT=folio
+	PromptVersion  string                  `json:"prompt_version,omitempty"` // Prompt used to generate MARC
+	Status         string                  `json:"status"`                   // One of Statuses
+	Batch          string                  `json:"batch,omitempty"`          // Batch file the approved record was exported in
+	OCRCorrections []OCRCorrection         `json:"ocr_corrections,omitempty"`
+	CreatedAt      time.Time               `json:"created_at"`
+}
+
+// Session statuses. Generating a record makes a session generated; the API moves it on
+// through review.
+const (
+	StatusUploaded  = "uploaded"  // Images but no record yet
+	StatusGenerated = "generated" // Has a machine-generated record
+	StatusInReview  = "in_review" // A cataloger is reviewing the record
+	StatusApproved  = "approved"  // Reviewed and ready to push
+	StatusPushed    = "pushed"    // In the library system
+	StatusRejected  = "rejected"  // Not to be pushed, e.g. wrong book or unusable images
+)
+
+// Statuses lists the session statuses in the order records move through them
+var Statuses = []string{StatusUploaded, StatusGenerated, StatusInReview, StatusApproved, StatusPushed, StatusRejected}
+
+// statusTransitions lists the statuses a session may be moved to from each status. Only an
+// approved record may be pushed, and a pushed one is final.
+var statusTransitions = map[string][]string{
+	StatusUploaded:  {StatusRejected},
+	StatusGenerated: {StatusInReview, StatusRejected},
+	StatusInReview:  {StatusApproved, StatusRejected},
+	StatusApproved:  {StatusPushed, StatusInReview},
+	StatusRejected:  {StatusInReview},
+}
+
+// NextStatuses returns the statuses a session may be moved to from status
+func NextStatuses(status string) []string {
+	return statusTransitions[status]
+}
+
+// CanTransition reports whether a session may be moved from one status to another
+func CanTransition(from, to string) bool {
+	return slices.Contains(statusTransitions[from], to)
+}
+
+// Locked reports whether the session's images, text and record can no longer change: an
+// approved record is moved back to review first, and a pushed one never changes
+func (s *CatalogSession) Locked() bool {
+	return s.Status == StatusApproved || s.Status == StatusPushed
+}
+
+// Clone returns a copy of the session that can be changed without affecting s. Corrections'
+// diffs are shared, as they are never changed once recorded.
+func (s *CatalogSession) Clone() *CatalogSession {
+	c := *s
+	c.Images = slices.Clone(s.Images)
+	c.OCRCorrections = slices.Clone(s.OCRCorrections)
+	if s.Holdings != nil {
+		h := *s.Holdings
+		h.Items = slices.Clone(s.Holdings.Items)
+		c.Holdings = &h
+	}
+	return &c
+}
+
+// generatable lists the statuses in which a session's record may be generated, leaving it
+// generated. A rejected session is moved back to review first.
+var generatable = []string{StatusUploaded, StatusGenerated, StatusInReview}
+
+// CanGenerate reports whether a record may be generated for the session
+func (s *CatalogSession) CanGenerate() bool {
+	return slices.Contains(generatable, s.Status)
+}
+
+// DefaultStatus returns the status of a session saved before statuses were recorded
+func (s *CatalogSession) DefaultStatus() string {
+	if s.MARC == "" {
+		return StatusUploaded
+	}
+	return StatusGenerated
 }
 
 // ImageItem represents an uploaded book image
@@ -21,3 +104,45 @@ type ImageItem struct {
 	ImageHeight int    `json:"image_height"`
 	OCRText     string `json:"ocr_text,omitempty"` // Extracted OCR text from the image
 }
+
+// ImageTypeOrder lists the image types and the order their OCR text is passed to MARC
+// generation: the title page is the chief source of information, the copyright page the next best
+var ImageTypeOrder = map[string]int{
+	"title_page": 0,
+	"copyright":  1,
+	"cover":      2,
+}
+
+// OCRCorrection records a user's edit of an image's OCR text for later analysis
+type OCRCorrection struct {
+	ImageID       string          `json:"image_id"`
+	Original      string          `json:"original"`
+	Corrected     string          `json:"corrected"`
+	Diff          []textdiff.Edit `json:"diff"`
+	WordsDeleted  int             `json:"words_deleted"`
+	WordsInserted int             `json:"words_inserted"`
+	CreatedAt     time.Time       `json:"created_at"`
+}
+
+// Audit actions recorded in a session's history
+const (
+	ActionUpload   = "upload"
+	ActionOCR      = "ocr"
+	ActionOCREdit  = "ocr_edit"
+	ActionGenerate = "generate"
+	ActionMARCEdit = "marc_edit" // Record edited by hand
+	ActionExport   = "export"    // Bundled for another instance
+	ActionImport   = "import"    // Created from another instance's bundle
+	ActionStatus   = "status"    // Moved to another status by hand
+	ActionBatch    = "batch"     // Exported in a batch file for the library system
+)
+
+// AuditEvent is an entry in a session's append-only audit log
+type AuditEvent struct {
+	Time     time.Time         `json:"time"`
+	Actor    string            `json:"actor"`
+	Action   string            `json:"action"`
+	Provider string            `json:"provider,omitempty"`
+	Model    string            `json:"model,omitempty"`
+	Details  map[string]string `json:"details,omitempty"`
+}
@@ -0,0 +1,207 @@
+// Package notify announces finished jobs, such as eval runs, on Slack, Matrix or email
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"net/http"
+	"net/smtp"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Config is the notification configuration file. Values may reference environment
+// variables as $NAME or ${NAME}, so secrets can stay out of the file.
+type Config struct {
+	// ReportURL links the notification to the report; {name} is replaced by the results
+	// file name, e.g. https://eval.example.edu/reports/{name}
+	ReportURL    string `yaml:"report_url"`
+	OnlyFailures bool   `yaml:"only_failures"` // Notify only runs that did not pass
+
+	Slack  *SlackConfig  `yaml:"slack"`
+	Matrix *MatrixConfig `yaml:"matrix"`
+	Email  *EmailConfig  `yaml:"email"`
+}
+
+// SlackConfig posts to a Slack incoming webhook
+type SlackConfig struct {
+	WebhookURL string `yaml:"webhook_url"`
+}
+
+// MatrixConfig sends a message to a Matrix room
+type MatrixConfig struct {
+	Homeserver  string `yaml:"homeserver"` // e.g. https://matrix.example.org
+	RoomID      string `yaml:"room_id"`    // e.g. !abc123:example.org
+	AccessToken string `yaml:"access_token"`
+}
+
+// EmailConfig sends mail through an SMTP server
+type EmailConfig struct {
+	SMTP     string   `yaml:"smtp"` // host:port
+	Username string   `yaml:"username"`
+	Password string   `yaml:"password"`
+	From     string   `yaml:"from"`
+	To       []string `yaml:"to"`
+}
+
+// Message is a finished job's notification
+type Message struct {
+	Subject string
+	Table   string // Summary table, shown in a fixed-width font
+	Link    string // Link to the full report, if any
+	Failed  bool   // The job did not pass
+}
+
+// Load reads a notification config from a YAML file
+func Load(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read notification config: %w", err)
+	}
+
+	var c Config
+	if err := yaml.Unmarshal([]byte(os.ExpandEnv(string(data))), &c); err != nil {
+		return nil, fmt.Errorf("failed to parse notification config %s: %w", path, err)
+	}
+	if c.Slack == nil && c.Matrix == nil && c.Email == nil {
+		return nil, fmt.Errorf("notification config %s has no slack, matrix or email section", path)
+	}
+	if c.Slack != nil && c.Slack.WebhookURL == "" {
+		return nil, fmt.Errorf("notification config %s: slack needs a webhook_url", path)
+	}
+	if c.Matrix != nil && (c.Matrix.Homeserver == "" || c.Matrix.RoomID == "" || c.Matrix.AccessToken == "") {
+		return nil, fmt.Errorf("notification config %s: matrix needs a homeserver, room_id and access_token", path)
+	}
+	if c.Email != nil && (c.Email.SMTP == "" || c.Email.From == "" || len(c.Email.To) == 0) {
+		return nil, fmt.Errorf("notification config %s: email needs smtp, from and to", path)
+	}
+	return &c, nil
+}
+
+// Link returns the report link for a results file, or "" when no report_url is set
+func (c *Config) Link(results string) string {
+	if c.ReportURL == "" {
+		return ""
+	}
+	name := results[strings.LastIndexAny(results, `/\`)+1:]
+	return strings.ReplaceAll(c.ReportURL, "{name}", url.PathEscape(name))
+}
+
+// Send delivers the message to every configured channel, returning the errors of those
+// that failed. Passing jobs are skipped with only_failures.
+func (c *Config) Send(ctx context.Context, msg Message) error {
+	if c.OnlyFailures && !msg.Failed {
+		return nil
+	}
+	var errs []error
+	if c.Slack != nil {
+		errs = append(errs, c.Slack.send(ctx, msg))
+	}
+	if c.Matrix != nil {
+		errs = append(errs, c.Matrix.send(ctx, msg))
+	}
+	if c.Email != nil {
+		errs = append(errs, c.Email.send(msg))
+	}
+	return errors.Join(errs...)
+}
+
+// plainText is the message as plain text, the table kept as a code block
+func (m Message) plainText() string {
+	text := m.Subject + "\n\n```\n" + strings.TrimRight(m.Table, "\n") + "\n```"
+	if m.Link != "" {
+		text += "\n" + m.Link
+	}
+	return text
+}
+
+func (s *SlackConfig) send(ctx context.Context, msg Message) error {
+	body, err := json.Marshal(map[string]string{"text": msg.plainText()})
+	if err != nil {
+		return fmt.Errorf("failed to marshal Slack message: %w", err)
+	}
+	return post(ctx, "POST", s.WebhookURL, "", body, "Slack")
+}
+
+func (m *MatrixConfig) send(ctx context.Context, msg Message) error {
+	formatted := "<p><strong>" + html.EscapeString(msg.Subject) + "</strong></p><pre>" + html.EscapeString(msg.Table) + "</pre>"
+	if msg.Link != "" {
+		formatted += `<p><a href="` + html.EscapeString(msg.Link) + `">` + html.EscapeString(msg.Link) + "</a></p>"
+	}
+	body, err := json.Marshal(map[string]string{
+		"msgtype":        "m.text",
+		"body":           msg.plainText(),
+		"format":         "org.matrix.custom.html",
+		"formatted_body": formatted,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal Matrix message: %w", err)
+	}
+	txnID := strconv.FormatInt(time.Now().UnixNano(), 10)
+	endpoint := strings.TrimSuffix(m.Homeserver, "/") + "/_matrix/client/v3/rooms/" + url.PathEscape(m.RoomID) + "/send/m.room.message/" + txnID
+	return post(ctx, "PUT", endpoint, m.AccessToken, body, "Matrix")
+}
+
+func post(ctx context.Context, method, endpoint, token string, body []byte, service string) error {
+	req, err := http.NewRequestWithContext(ctx, method, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create %s request: %w", service, err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	client := &http.Client{Timeout: 30 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send %s notification: %w", service, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		b, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("%s notification failed with status %d: %s", service, resp.StatusCode, strings.TrimSpace(string(b)))
+	}
+	return nil
+}
+
+func (e *EmailConfig) send(msg Message) error {
+	host := e.SMTP
+	if h, _, ok := strings.Cut(e.SMTP, ":"); ok {
+		host = h
+	}
+	var auth smtp.Auth
+	if e.Username != "" {
+		auth = smtp.PlainAuth("", e.Username, e.Password, host)
+	}
+	if err := smtp.SendMail(e.SMTP, auth, e.From, e.To, e.mail(msg)); err != nil {
+		return fmt.Errorf("failed to send email notification: %w", err)
+	}
+	return nil
+}
+
+// mail builds the message with headers, as a plain text email
+func (e *EmailConfig) mail(msg Message) []byte {
+	var b strings.Builder
+	fmt.Fprintf(&b, "From: %s\r\n", e.From)
+	fmt.Fprintf(&b, "To: %s\r\n", strings.Join(e.To, ", "))
+	fmt.Fprintf(&b, "Subject: %s\r\n", strings.NewReplacer("\r", " ", "\n", " ").Replace(msg.Subject))
+	fmt.Fprintf(&b, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\nContent-Type: text/plain; charset=utf-8\r\n\r\n")
+	body := msg.Table
+	if msg.Link != "" {
+		body += "\nFull report: " + msg.Link + "\n"
+	}
+	b.WriteString(strings.ReplaceAll(strings.ReplaceAll(body, "\r\n", "\n"), "\n", "\r\n"))
+	return []byte(b.String())
+}
@@ -0,0 +1,109 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeConfig(t *testing.T, yaml string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "notify.yaml")
+	if err := os.WriteFile(path, []byte(yaml), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoad(t *testing.T) {
+	t.Setenv("TEST_WEBHOOK", "https://hooks.example.com/abc")
+	c, err := Load(writeConfig(t, "report_url: https://r.example.edu/{name}\nslack:\n  webhook_url: ${TEST_WEBHOOK}\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Slack.WebhookURL != "https://hooks.example.com/abc" {
+		t.Errorf("webhook_url = %q, want it read from the environment", c.Slack.WebhookURL)
+	}
+	if got := c.Link("runs/2025 results.json"); got != "https://r.example.edu/2025%20results.json" {
+		t.Errorf("Link() = %q", got)
+	}
+
+	for _, bad := range []string{
+		"report_url: x\n",
+		"slack: {}\n",
+		"matrix:\n  homeserver: https://m.example.org\n",
+		"email:\n  smtp: localhost:25\n  from: a@example.edu\n",
+	} {
+		if _, err := Load(writeConfig(t, bad)); err == nil {
+			t.Errorf("Load(%q) succeeded, want an error", bad)
+		}
+	}
+}
+
+func TestSendSlackAndMatrix(t *testing.T) {
+	var slackText string
+	var matrix map[string]string
+	var matrixAuth, matrixPath string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/slack" && r.Method == "POST":
+			var body map[string]string
+			_ = json.NewDecoder(r.Body).Decode(&body)
+			slackText = body["text"]
+		case strings.HasPrefix(r.URL.Path, "/_matrix/") && r.Method == "PUT":
+			matrixAuth, matrixPath = r.Header.Get("Authorization"), r.URL.EscapedPath()
+			_ = json.NewDecoder(r.Body).Decode(&matrix)
+		default:
+			http.Error(w, "unexpected", http.StatusBadRequest)
+		}
+	}))
+	defer server.Close()
+
+	c := &Config{
+		Slack:  &SlackConfig{WebhookURL: server.URL + "/slack"},
+		Matrix: &MatrixConfig{Homeserver: server.URL + "/", RoomID: "!room:example.org", AccessToken: "tok"},
+	}
+	msg := Message{Subject: "Eval run pass", Table: "Mean score  84.1%\n", Link: "https://r.example.edu/x.json"}
+	if err := c.Send(context.Background(), msg); err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(slackText, "Eval run pass") || !strings.Contains(slackText, "```\nMean score  84.1%\n```") || !strings.HasSuffix(slackText, msg.Link) {
+		t.Errorf("Slack text = %q", slackText)
+	}
+	if matrixAuth != "Bearer tok" || !strings.HasPrefix(matrixPath, "/_matrix/client/v3/rooms/%21room:example.org/send/m.room.message/") {
+		t.Errorf("Matrix request = %s %s", matrixAuth, matrixPath)
+	}
+	if !strings.Contains(matrix["formatted_body"], "<pre>Mean score  84.1%") || matrix["msgtype"] != "m.text" {
+		t.Errorf("Matrix message = %v", matrix)
+	}
+
+	// Failures are reported; only_failures skips passing runs
+	c.Slack.WebhookURL = server.URL + "/missing"
+	if err := c.Send(context.Background(), msg); err == nil || !strings.Contains(err.Error(), "Slack notification failed with status 400") {
+		t.Errorf("Send() error = %v", err)
+	}
+	c.OnlyFailures = true
+	if err := c.Send(context.Background(), msg); err != nil {
+		t.Errorf("Send() of a passing run with only_failures = %v, want it skipped", err)
+	}
+}
+
+func TestMail(t *testing.T) {
+	e := &EmailConfig{From: "cataloger@example.edu", To: []string{"a@example.edu", "b@example.edu"}}
+	mail := string(e.mail(Message{Subject: "Eval run\nbelow_threshold", Table: "Records  2\n", Link: "https://r.example.edu/x"}))
+	for _, want := range []string{
+		"To: a@example.edu, b@example.edu\r\n",
+		"Subject: Eval run below_threshold\r\n",
+		"\r\n\r\nRecords  2\r\n\r\nFull report: https://r.example.edu/x\r\n",
+	} {
+		if !strings.Contains(mail, want) {
+			t.Errorf("mail missing %q:\n%s", want, mail)
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package oai
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// Window is an inclusive from/until datestamp range; zero times are open ends
+type Window struct {
+	From  time.Time
+	Until time.Time
+}
+
+// PlanWindows splits [from, until] into consecutive non-overlapping windows of at most size,
+// each ending one granularity step before the next begins
+func PlanWindows(from, until time.Time, size time.Duration, granularity string) []Window {
+	if size <= 0 || from.IsZero() || until.IsZero() || !from.Before(until) {
+		return []Window{{From: from, Until: until}}
+	}
+
+	step := 24 * time.Hour
+	if granularity == GranularitySecond {
+		step = time.Second
+	}
+
+	var windows []Window
+	for start := from; !start.After(until); start = start.Add(size) {
+		end := start.Add(size - step)
+		if end.After(until) {
+			end = until
+		}
+		windows = append(windows, Window{From: start, Until: end})
+	}
+	return windows
+}
+
+// Harvest harvests windows with up to workers windows in flight at once. fn is called for
+// every record, one call at a time; an error from fn or any window stops the harvest.
+func Harvest(ctx context.Context, c *Client, windows []Window, workers int, fn func(Record) error) error {
+	if workers < 1 {
+		workers = 1
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	jobs := make(chan Window)
+	var (
+		mu       sync.Mutex
+		firstErr error
+		wg       sync.WaitGroup
+	)
+	fail := func(err error) {
+		mu.Lock()
+		if firstErr == nil {
+			firstErr = err
+		}
+		mu.Unlock()
+		cancel()
+	}
+
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for w := range jobs {
+				n := 0
+				err := c.ListRecords(ctx, w, func(r Record) error {
+					mu.Lock()
+					defer mu.Unlock()
+					n++
+					return fn(r)
+				})
+				if err != nil {
+					fail(fmt.Errorf("window %s..%s: %w", c.formatDate(w.From), c.formatDate(w.Until), err))
+					return
+				}
+				slog.Info("Harvested window", "from", c.formatDate(w.From), "until", c.formatDate(w.Until), "records", n)
+			}
+		}()
+	}
+
+feed:
+	for _, w := range windows {
+		select {
+		case <-ctx.Done():
+			break feed
+		case jobs <- w:
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	return firstErr
+}
+
+// ParseDatestamp parses an OAI-PMH datestamp at day or second granularity
+func ParseDatestamp(s string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse(time.DateOnly, s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid datestamp %q", s)
+	}
+	return t, nil
+}
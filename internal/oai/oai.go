@@ -0,0 +1,220 @@
+package oai
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// Granularities a repository may declare in Identify
+const (
+	GranularityDay    = "YYYY-MM-DD"
+	GranularitySecond = "YYYY-MM-DDThh:mm:ssZ"
+)
+
+// Client is a minimal OAI-PMH harvester
+type Client struct {
+	BaseURL        string
+	MetadataPrefix string
+	Set            string
+	Granularity    string        // Date format for from/until (GranularityDay unless the repository supports seconds)
+	Delay          time.Duration // Pause between requests from one worker
+	MaxRetries     int           // Retries for 503 and transport errors
+	HTTPClient     *http.Client
+}
+
+// NewClient creates a client for a repository base URL harvesting MARCXML
+func NewClient(baseURL string) *Client {
+	return &Client{
+		BaseURL:        baseURL,
+		MetadataPrefix: "marc21",
+		Granularity:    GranularityDay,
+		MaxRetries:     3,
+		HTTPClient:     &http.Client{Timeout: 2 * time.Minute},
+	}
+}
+
+// Identity is the subset of the Identify response used to plan harvests
+type Identity struct {
+	RepositoryName    string `xml:"repositoryName"`
+	EarliestDatestamp string `xml:"earliestDatestamp"`
+	Granularity       string `xml:"granularity"`
+}
+
+// Record is one harvested record; Metadata holds the raw XML inside <metadata>
+type Record struct {
+	Identifier string
+	Datestamp  string
+	Deleted    bool
+	Metadata   []byte
+}
+
+type response struct {
+	Error *struct {
+		Code    string `xml:"code,attr"`
+		Message string `xml:",chardata"`
+	} `xml:"error"`
+	Identify    *Identity `xml:"Identify"`
+	ListRecords *struct {
+		Records []struct {
+			Header struct {
+				Status     string `xml:"status,attr"`
+				Identifier string `xml:"identifier"`
+				Datestamp  string `xml:"datestamp"`
+			} `xml:"header"`
+			Metadata struct {
+				Inner []byte `xml:",innerxml"`
+			} `xml:"metadata"`
+		} `xml:"record"`
+		ResumptionToken string `xml:"resumptionToken"`
+	} `xml:"ListRecords"`
+}
+
+// Identify fetches the repository's identity
+func (c *Client) Identify(ctx context.Context) (*Identity, error) {
+	resp, err := c.request(ctx, url.Values{"verb": {"Identify"}})
+	if err != nil {
+		return nil, err
+	}
+	if resp.Identify == nil {
+		return nil, fmt.Errorf("identify response has no Identify element")
+	}
+	return resp.Identify, nil
+}
+
+// ListRecords harvests every record in a window, following resumption tokens
+func (c *Client) ListRecords(ctx context.Context, w Window, fn func(Record) error) error {
+	params := url.Values{"verb": {"ListRecords"}, "metadataPrefix": {c.MetadataPrefix}}
+	if c.Set != "" {
+		params.Set("set", c.Set)
+	}
+	if !w.From.IsZero() {
+		params.Set("from", c.formatDate(w.From))
+	}
+	if !w.Until.IsZero() {
+		params.Set("until", c.formatDate(w.Until))
+	}
+
+	for {
+		resp, err := c.request(ctx, params)
+		if err != nil {
+			return err
+		}
+		if resp.ListRecords == nil {
+			return nil
+		}
+
+		for _, r := range resp.ListRecords.Records {
+			rec := Record{
+				Identifier: r.Header.Identifier,
+				Datestamp:  r.Header.Datestamp,
+				Deleted:    r.Header.Status == "deleted",
+				Metadata:   r.Metadata.Inner,
+			}
+			if err := fn(rec); err != nil {
+				return err
+			}
+		}
+
+		token := resp.ListRecords.ResumptionToken
+		if token == "" {
+			return nil
+		}
+		params = url.Values{"verb": {"ListRecords"}, "resumptionToken": {token}}
+
+		if c.Delay > 0 {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(c.Delay):
+			}
+		}
+	}
+}
+
+func (c *Client) formatDate(t time.Time) string {
+	if c.Granularity == GranularitySecond {
+		return t.UTC().Format(time.RFC3339)
+	}
+	return t.UTC().Format(time.DateOnly)
+}
+
+// request performs one OAI-PMH request, retrying on 503 (honoring Retry-After) and transport errors
+func (c *Client) request(ctx context.Context, params url.Values) (*response, error) {
+	u := c.BaseURL + "?" + params.Encode()
+
+	var lastErr error
+	for attempt := 0; attempt <= c.MaxRetries; attempt++ {
+		if attempt > 0 {
+			wait := time.Duration(attempt) * 5 * time.Second
+			if d, ok := lastErr.(retryAfterError); ok {
+				wait = time.Duration(d)
+			}
+			slog.Warn("Retrying OAI-PMH request", "attempt", attempt, "wait", wait, "error", lastErr)
+			select {
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			case <-time.After(wait):
+			}
+		}
+
+		body, err := c.get(ctx, u)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+
+		var resp response
+		if err := xml.Unmarshal(body, &resp); err != nil {
+			return nil, fmt.Errorf("failed to parse OAI-PMH response: %w", err)
+		}
+		if resp.Error != nil {
+			if resp.Error.Code == "noRecordsMatch" {
+				return &response{}, nil
+			}
+			return nil, fmt.Errorf("OAI-PMH error %s: %s", resp.Error.Code, resp.Error.Message)
+		}
+		return &resp, nil
+	}
+
+	return nil, fmt.Errorf("failed to query %s: %w", c.BaseURL, lastErr)
+}
+
+// retryAfterError is returned for 503 responses carrying a Retry-After delay
+type retryAfterError time.Duration
+
+func (e retryAfterError) Error() string {
+	return fmt.Sprintf("service unavailable, retry after %s", time.Duration(e))
+}
+
+func (c *Client) get(ctx context.Context, u string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusServiceUnavailable {
+		seconds, err := strconv.Atoi(resp.Header.Get("Retry-After"))
+		if err != nil || seconds < 0 {
+			seconds = 10
+		}
+		return nil, retryAfterError(time.Duration(seconds) * time.Second)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("OAI-PMH server returned status %d", resp.StatusCode)
+	}
+
+	return io.ReadAll(resp.Body)
+}
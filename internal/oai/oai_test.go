@@ -0,0 +1,127 @@
+package oai
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+const recordXML = `<record><header%s><identifier>oai:test:%s</identifier><datestamp>%s</datestamp></header>
+<metadata><marc:record xmlns:marc="http://www.loc.gov/MARC21/slim"><marc:controlfield tag="001">%s</marc:controlfield></marc:record></metadata></record>`
+
+func testServer(t *testing.T, unavailable *int32) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if unavailable != nil && atomic.AddInt32(unavailable, -1) >= 0 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+
+		q := r.URL.Query()
+		fmt.Fprint(w, `<OAI-PMH xmlns="http://www.openarchives.org/OAI/2.0/">`)
+		defer fmt.Fprint(w, `</OAI-PMH>`)
+
+		switch {
+		case q.Get("verb") == "Identify":
+			fmt.Fprint(w, `<Identify><repositoryName>Test</repositoryName><earliestDatestamp>2024-01-01</earliestDatestamp><granularity>YYYY-MM-DD</granularity></Identify>`)
+		case q.Get("resumptionToken") == "page2":
+			fmt.Fprint(w, `<ListRecords>`)
+			fmt.Fprintf(w, recordXML, ` status="deleted"`, "3", "2024-01-02", "")
+			fmt.Fprint(w, `<resumptionToken></resumptionToken></ListRecords>`)
+		case q.Get("from") == "2024-01-01":
+			fmt.Fprint(w, `<ListRecords>`)
+			fmt.Fprintf(w, recordXML, "", "1", "2024-01-01", "1")
+			fmt.Fprintf(w, recordXML, "", "2", "2024-01-02", "2")
+			fmt.Fprint(w, `<resumptionToken>page2</resumptionToken></ListRecords>`)
+		default:
+			fmt.Fprint(w, `<error code="noRecordsMatch">No records</error>`)
+		}
+	}))
+}
+
+func TestListRecords(t *testing.T) {
+	srv := testServer(t, nil)
+	defer srv.Close()
+
+	c := NewClient(srv.URL)
+	var got []Record
+	w := Window{From: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+	if err := c.ListRecords(context.Background(), w, func(r Record) error {
+		got = append(got, r)
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(got) != 3 {
+		t.Fatalf("expected 3 records across two pages, got %d", len(got))
+	}
+	if got[0].Identifier != "oai:test:1" || len(got[0].Metadata) == 0 {
+		t.Errorf("unexpected first record %+v", got[0])
+	}
+	if !got[2].Deleted {
+		t.Errorf("expected third record to be deleted")
+	}
+
+	// noRecordsMatch is an empty window, not an error
+	if err := c.ListRecords(context.Background(), Window{}, func(Record) error { return nil }); err != nil {
+		t.Errorf("noRecordsMatch should not fail: %v", err)
+	}
+}
+
+func TestRetryOnUnavailable(t *testing.T) {
+	unavailable := int32(2)
+	srv := testServer(t, &unavailable)
+	defer srv.Close()
+
+	identity, err := NewClient(srv.URL).Identify(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if identity.EarliestDatestamp != "2024-01-01" {
+		t.Errorf("unexpected identity %+v", identity)
+	}
+}
+
+func TestPlanWindows(t *testing.T) {
+	from := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	until := time.Date(2024, 1, 25, 0, 0, 0, 0, time.UTC)
+
+	windows := PlanWindows(from, until, 10*24*time.Hour, GranularityDay)
+	if len(windows) != 3 {
+		t.Fatalf("expected 3 windows, got %d", len(windows))
+	}
+	if !windows[0].Until.Equal(time.Date(2024, 1, 10, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("first window should end the day before the second begins, got %v", windows[0].Until)
+	}
+	if !windows[2].Until.Equal(until) {
+		t.Errorf("last window should end at until, got %v", windows[2].Until)
+	}
+
+	if got := PlanWindows(from, until, 0, GranularityDay); len(got) != 1 {
+		t.Errorf("zero size should give a single window, got %d", len(got))
+	}
+}
+
+func TestHarvest(t *testing.T) {
+	srv := testServer(t, nil)
+	defer srv.Close()
+
+	from := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	windows := PlanWindows(from, from.Add(40*24*time.Hour), 10*24*time.Hour, GranularityDay)
+
+	n := 0
+	if err := Harvest(context.Background(), NewClient(srv.URL), windows, 3, func(Record) error {
+		n++
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if n != 3 {
+		t.Errorf("expected 3 records from the first window only, got %d", n)
+	}
+}
@@ -0,0 +1,216 @@
+package objectstore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// ErrNotFound is returned when an object does not exist
+var ErrNotFound = errors.New("object not found")
+
+// Object describes a stored object
+type Object struct {
+	Key      string
+	Size     int64
+	Modified time.Time
+}
+
+// Store is a flat namespace of objects addressed by slash-separated keys, backed by a local
+// directory or an S3-compatible bucket (AWS S3, Google Cloud Storage, MinIO)
+type Store interface {
+	Put(ctx context.Context, key string, data []byte, contentType string) error
+	Get(ctx context.Context, key string) ([]byte, error)
+	Stat(ctx context.Context, key string) (Object, error)
+	Delete(ctx context.Context, key string) error
+	List(ctx context.Context, prefix string) ([]Object, error)
+	String() string // Location the store was opened from
+}
+
+// Open opens a store from a location: s3://bucket/prefix, gs://bucket/prefix, or a local
+// directory (optionally file:///path). Credentials for buckets come from the environment,
+// see NewS3FromEnv and NewGCSFromEnv.
+func Open(location string) (Store, error) {
+	scheme, rest, ok := strings.Cut(location, "://")
+	if !ok {
+		return NewLocal(location), nil
+	}
+
+	bucket, prefix, _ := strings.Cut(rest, "/")
+	switch scheme {
+	case "file":
+		return NewLocal(rest), nil
+	case "s3":
+		return NewS3FromEnv(bucket, prefix)
+	case "gs":
+		return NewGCSFromEnv(bucket, prefix)
+	default:
+		return nil, fmt.Errorf("unsupported storage location %q (use s3://, gs:// or a directory)", location)
+	}
+}
+
+// IsRemote reports whether location names a bucket rather than a local path
+func IsRemote(location string) bool {
+	return strings.HasPrefix(location, "s3://") || strings.HasPrefix(location, "gs://")
+}
+
+// WriteFile writes data to a local path or to an object URL such as s3://bucket/runs/results.json
+func WriteFile(ctx context.Context, location string, data []byte) error {
+	if !IsRemote(location) {
+		if err := os.WriteFile(location, data, 0644); err != nil {
+			return fmt.Errorf("failed to write %s: %w", location, err)
+		}
+		return nil
+	}
+
+	dir, key := path.Split(location)
+	store, err := Open(strings.TrimSuffix(dir, "/"))
+	if err != nil {
+		return err
+	}
+	return store.Put(ctx, key, data, ContentType(key))
+}
+
+// UploadDir copies every file under dir to the store, keyed by prefix plus the relative path,
+// and returns how many files were copied
+func UploadDir(ctx context.Context, store Store, dir, prefix string) (int, error) {
+	count := 0
+	err := filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return err
+		}
+		data, err := os.ReadFile(p)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", p, err)
+		}
+		key := path.Join(prefix, filepath.ToSlash(rel))
+		if err := store.Put(ctx, key, data, ContentType(key)); err != nil {
+			return err
+		}
+		count++
+		return nil
+	})
+	return count, err
+}
+
+// ContentType guesses a content type from a key's extension
+func ContentType(key string) string {
+	switch strings.ToLower(path.Ext(key)) {
+	case ".jpg", ".jpeg":
+		return "image/jpeg"
+	case ".png":
+		return "image/png"
+	case ".gif":
+		return "image/gif"
+	case ".webp":
+		return "image/webp"
+	case ".json":
+		return "application/json"
+	case ".xml":
+		return "application/xml"
+	default:
+		return "application/octet-stream"
+	}
+}
+
+// Local stores objects as files under a directory
+type Local struct {
+	Dir string
+}
+
+// NewLocal creates a store for a local directory
+func NewLocal(dir string) *Local {
+	return &Local{Dir: dir}
+}
+
+// Path returns the file path of a key
+func (l *Local) Path(key string) string {
+	return filepath.Join(l.Dir, filepath.FromSlash(key))
+}
+
+func (l *Local) Put(ctx context.Context, key string, data []byte, contentType string) error {
+	p := l.Path(key)
+	if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
+	}
+	if err := os.WriteFile(p, data, 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", key, err)
+	}
+	return nil
+}
+
+func (l *Local) Get(ctx context.Context, key string) ([]byte, error) {
+	data, err := os.ReadFile(l.Path(key))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, key)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", key, err)
+	}
+	return data, nil
+}
+
+func (l *Local) Stat(ctx context.Context, key string) (Object, error) {
+	info, err := os.Stat(l.Path(key))
+	if errors.Is(err, fs.ErrNotExist) {
+		return Object{}, fmt.Errorf("%w: %s", ErrNotFound, key)
+	}
+	if err != nil {
+		return Object{}, fmt.Errorf("failed to stat %s: %w", key, err)
+	}
+	return Object{Key: key, Size: info.Size(), Modified: info.ModTime()}, nil
+}
+
+func (l *Local) Delete(ctx context.Context, key string) error {
+	if err := os.Remove(l.Path(key)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("failed to delete %s: %w", key, err)
+	}
+	return nil
+}
+
+func (l *Local) List(ctx context.Context, prefix string) ([]Object, error) {
+	var objects []Object
+	err := filepath.WalkDir(l.Dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		rel, err := filepath.Rel(l.Dir, p)
+		if err != nil {
+			return err
+		}
+		key := filepath.ToSlash(rel)
+		if !strings.HasPrefix(key, prefix) {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return nil
+		}
+		objects = append(objects, Object{Key: key, Size: info.Size(), Modified: info.ModTime()})
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list %s: %w", l.Dir, err)
+	}
+	return objects, nil
+}
+
+func (l *Local) String() string {
+	return l.Dir
+}
@@ -3,53 +3,184 @@ package ollama
 import (
 	"bytes"
 	"context"
+	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"os"
+	"strconv"
+	"strings"
 
 	"github.com/lehigh-university-libraries/cataloger/internal/providers"
 )
 
-// Ollama is a provider for Ollama
+// Registration registers the provider as "ollama"
+var Registration = providers.Registration{
+	Name:         "ollama",
+	New:          func() providers.Provider { return New() },
+	DefaultModel: providers.EnvModel("OLLAMA_MODEL", "mistral-small3.2:24b"),
+	Vision:       true,
+}
+
+// Ollama is a provider for Ollama, through its chat API
 type Ollama struct{}
 
 // New returns a new Ollama provider
+//
+// Configured with:
+//   - OLLAMA_URL: server, or comma-separated servers (see hostPool)
+//   - OLLAMA_KEEP_ALIVE: how long the model stays loaded after a request, e.g. 30m or -1 for ever
+//   - OLLAMA_NUM_CTX: context window in tokens; Ollama's default truncates long OCR prompts
+//   - OLLAMA_TOP_P, OLLAMA_SEED: sampling options; a fixed seed makes results reproducible
+//   - OLLAMA_OPTIONS: any other model options as a JSON object, e.g. {"num_predict": 2048}
 func New() *Ollama {
 	return &Ollama{}
 }
 
 // ExtractText extracts text from the given prompt using Ollama
 func (o *Ollama) ExtractText(ctx context.Context, config providers.Config) (string, error) {
-	ollamaURL := os.Getenv("OLLAMA_URL")
-	if ollamaURL == "" {
-		ollamaURL = "http://localhost:11434"
-	}
-	url := ollamaURL + "/api/generate"
-
-	requestBody, err := json.Marshal(map[string]interface{}{
-		"model":  config.Model,
-		"prompt": config.Prompt,
-		"stream": false,
-		"options": map[string]interface{}{
-			"temperature": config.Temperature,
-		},
-	})
+	return o.generate(ctx, config, nil, nil)
+}
+
+// StreamText extracts text with a streamed response, calling onChunk as it arrives
+func (o *Ollama) StreamText(ctx context.Context, config providers.Config, onChunk func(string)) (string, error) {
+	return o.generate(ctx, config, nil, onChunk)
+}
+
+// GenerateFromImage sends an image with the prompt to a vision model
+func (o *Ollama) GenerateFromImage(ctx context.Context, config providers.Config, image []byte) (string, error) {
+	return o.GenerateFromImages(ctx, config, [][]byte{image})
+}
+
+// GenerateFromImages sends several images with the prompt in one message
+func (o *Ollama) GenerateFromImages(ctx context.Context, config providers.Config, images [][]byte) (string, error) {
+	encoded := make([]string, len(images))
+	for i, image := range images {
+		encoded[i] = base64.StdEncoding.EncodeToString(image)
+	}
+	return o.generate(ctx, config, encoded, nil)
+}
+
+// baseURL is OLLAMA_URL, or OLLAMA_HOST, or the local default. It may list several hosts,
+// separated by commas.
+func baseURL() string {
+	if u := os.Getenv("OLLAMA_URL"); u != "" {
+		return u
+	}
+	if u := os.Getenv("OLLAMA_HOST"); u != "" {
+		return u
+	}
+	return "http://localhost:11434"
+}
+
+// generate sends the prompt as a user message to /api/chat, streaming the response to onChunk
+// when it is not nil
+func (o *Ollama) generate(ctx context.Context, config providers.Config, images []string, onChunk func(string)) (string, error) {
+	options, err := modelOptions()
+	if err != nil {
+		return "", err
+	}
+	options["temperature"] = config.Temperature
+
+	message := map[string]any{"role": "user", "content": config.Prompt}
+	if len(images) > 0 {
+		message["images"] = images
+	}
+	body := map[string]any{
+		"model":    config.Model,
+		"messages": []map[string]any{message},
+		"stream":   onChunk != nil,
+		"options":  options,
+	}
+	switch {
+	case config.ResponseSchema != nil:
+		body["format"] = config.ResponseSchema
+	case config.JSONMode:
+		body["format"] = "json"
+	}
+	if keepAlive := os.Getenv("OLLAMA_KEEP_ALIVE"); keepAlive != "" {
+		body["keep_alive"] = keepAlive
+	}
+	requestBody, err := json.Marshal(body)
 	if err != nil {
 		return "", fmt.Errorf("failed to marshal request body: %w", err)
 	}
 
-	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(requestBody))
+	// Hosts that can't be reached are skipped for the next one in the pool
+	hosts := hostPool()
+	var tried []*host
+	for {
+		h := hosts.pick(tried)
+		text, err := o.send(ctx, h, requestBody, onChunk)
+		var unreachable *unreachableError
+		if err == nil || !errors.As(err, &unreachable) || ctx.Err() != nil {
+			return text, err
+		}
+		hosts.markDown(h, unreachable.err)
+		tried = append(tried, h)
+		if len(tried) == len(hosts.hosts) {
+			return "", err
+		}
+	}
+}
+
+// unreachableError is a request that got no response from its host
+type unreachableError struct {
+	err error
+}
+
+func (e *unreachableError) Error() string { return e.err.Error() }
+func (e *unreachableError) Unwrap() error { return e.err }
+
+// modelOptions returns the model options from OLLAMA_OPTIONS, OLLAMA_NUM_CTX, OLLAMA_TOP_P and
+// OLLAMA_SEED, the specific variables winning
+func modelOptions() (map[string]any, error) {
+	options := make(map[string]any)
+	if v := os.Getenv("OLLAMA_OPTIONS"); v != "" {
+		if err := json.Unmarshal([]byte(v), &options); err != nil {
+			return nil, fmt.Errorf("invalid OLLAMA_OPTIONS: %w", err)
+		}
+	}
+	if v := os.Getenv("OLLAMA_NUM_CTX"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			return nil, fmt.Errorf("invalid OLLAMA_NUM_CTX %q", v)
+		}
+		options["num_ctx"] = n
+	}
+	if v := os.Getenv("OLLAMA_TOP_P"); v != "" {
+		p, err := strconv.ParseFloat(v, 64)
+		if err != nil || p <= 0 || p > 1 {
+			return nil, fmt.Errorf("invalid OLLAMA_TOP_P %q", v)
+		}
+		options["top_p"] = p
+	}
+	if v := os.Getenv("OLLAMA_SEED"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid OLLAMA_SEED %q", v)
+		}
+		options["seed"] = n
+	}
+	return options, nil
+}
+
+// send posts a chat request to a host and reads its response
+func (o *Ollama) send(ctx context.Context, h *host, requestBody []byte, onChunk func(string)) (string, error) {
+	h.inFlight.Add(1)
+	defer h.inFlight.Add(-1)
+
+	req, err := http.NewRequestWithContext(ctx, "POST", h.url+"/api/chat", bytes.NewBuffer(requestBody))
 	if err != nil {
 		return "", fmt.Errorf("failed to create new request: %w", err)
 	}
 	req.Header.Set("Content-Type", "application/json")
 
-	client := &http.Client{}
-	resp, err := client.Do(req)
+	resp, err := providers.HTTPClient().Do(req)
 	if err != nil {
-		return "", fmt.Errorf("failed to send request: %w", err)
+		return "", fmt.Errorf("failed to send request: %w", &unreachableError{err})
 	}
 	defer resp.Body.Close()
 
@@ -58,12 +189,32 @@ func (o *Ollama) ExtractText(ctx context.Context, config providers.Config) (stri
 		return "", fmt.Errorf("received non-200 status code: %d - %s", resp.StatusCode, string(body))
 	}
 
-	var response struct {
-		Response string `json:"response"`
+	// A streamed response is a JSON object per line, the last with done set
+	var text strings.Builder
+	decoder := json.NewDecoder(resp.Body)
+	for {
+		var response struct {
+			Message struct {
+				Content string `json:"content"`
+			} `json:"message"`
+			Done  bool   `json:"done"`
+			Error string `json:"error"`
+		}
+		if err := decoder.Decode(&response); err != nil {
+			if err == io.EOF && onChunk != nil {
+				return "", fmt.Errorf("stream ended before the response was done")
+			}
+			return "", fmt.Errorf("failed to decode response body: %w", err)
+		}
+		if response.Error != "" {
+			return "", fmt.Errorf("ollama error: %s", response.Error)
+		}
+		text.WriteString(response.Message.Content)
+		if onChunk != nil && response.Message.Content != "" {
+			onChunk(response.Message.Content)
+		}
+		if response.Done || onChunk == nil {
+			return text.String(), nil
+		}
 	}
-	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
-		return "", fmt.Errorf("failed to decode response body: %w", err)
-	}
-
-	return response.Response, nil
 }
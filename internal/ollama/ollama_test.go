@@ -0,0 +1,110 @@
+package ollama
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/lehigh-university-libraries/cataloger/internal/providers"
+)
+
+func TestStreamText(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Stream bool `json:"stream"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		if !body.Stream {
+			fmt.Fprintln(w, `{"message":{"role":"assistant","content":"Walden"},"done":true}`)
+			return
+		}
+		for _, part := range []string{"Wal", "den"} {
+			fmt.Fprintf(w, "{\"message\":{\"content\":%q},\"done\":false}\n", part)
+		}
+		fmt.Fprintln(w, `{"message":{"content":""},"done":true}`)
+	}))
+	defer srv.Close()
+	t.Setenv("OLLAMA_URL", srv.URL)
+	t.Setenv("PROVIDER_MAX_RETRIES", "0")
+
+	var chunks []string
+	text, err := New().StreamText(context.Background(), providers.Config{Model: "m", Prompt: "p"}, func(s string) { chunks = append(chunks, s) })
+	if err != nil {
+		t.Fatal(err)
+	}
+	if text != "Walden" || len(chunks) != 2 {
+		t.Errorf("StreamText() = %q with chunks %q", text, chunks)
+	}
+
+	text, err = New().ExtractText(context.Background(), providers.Config{Model: "m", Prompt: "p"})
+	if err != nil || text != "Walden" {
+		t.Errorf("ExtractText() = %q, %v", text, err)
+	}
+}
+
+func TestChatRequest(t *testing.T) {
+	var got struct {
+		Messages []struct {
+			Role    string   `json:"role"`
+			Content string   `json:"content"`
+			Images  []string `json:"images"`
+		} `json:"messages"`
+		KeepAlive string         `json:"keep_alive"`
+		Options   map[string]any `json:"options"`
+		Format    any            `json:"format"`
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/chat" {
+			t.Errorf("request to %s", r.URL.Path)
+		}
+		_ = json.NewDecoder(r.Body).Decode(&got)
+		fmt.Fprintln(w, `{"message":{"role":"assistant","content":"WALDEN"},"done":true}`)
+	}))
+	defer srv.Close()
+	t.Setenv("OLLAMA_URL", srv.URL)
+	t.Setenv("OLLAMA_KEEP_ALIVE", "30m")
+	t.Setenv("OLLAMA_OPTIONS", `{"num_predict": 2048, "num_ctx": 4096}`)
+	t.Setenv("OLLAMA_NUM_CTX", "16384")
+	t.Setenv("OLLAMA_TOP_P", "0.9")
+	t.Setenv("OLLAMA_SEED", "42")
+
+	text, err := New().GenerateFromImage(context.Background(), providers.Config{Model: "m", Prompt: "transcribe"}, []byte("image"))
+	if err != nil || text != "WALDEN" {
+		t.Fatalf("GenerateFromImage() = %q, %v", text, err)
+	}
+	if len(got.Messages) != 1 || got.Messages[0].Role != "user" || got.Messages[0].Content != "transcribe" || len(got.Messages[0].Images) != 1 {
+		t.Errorf("messages = %+v", got.Messages)
+	}
+	want := map[string]any{"num_predict": float64(2048), "num_ctx": float64(16384), "top_p": 0.9, "seed": float64(42), "temperature": float64(0)}
+	for k, v := range want {
+		if got.Options[k] != v {
+			t.Errorf("option %s = %v, want %v", k, got.Options[k], v)
+		}
+	}
+	if got.KeepAlive != "30m" {
+		t.Errorf("keep_alive = %q", got.KeepAlive)
+	}
+
+	schema := map[string]any{"type": "object", "properties": map[string]any{"title": map[string]any{"type": "string"}}}
+	if _, err := New().ExtractText(context.Background(), providers.Config{Model: "m", JSONMode: true, ResponseSchema: schema}); err != nil {
+		t.Fatal(err)
+	}
+	if format, ok := got.Format.(map[string]any); !ok || format["type"] != "object" {
+		t.Errorf("format = %v, want the response schema", got.Format)
+	}
+	got.Format = nil
+	if _, err := New().ExtractText(context.Background(), providers.Config{Model: "m", JSONMode: true}); err != nil {
+		t.Fatal(err)
+	}
+	if got.Format != "json" {
+		t.Errorf("format = %v, want json", got.Format)
+	}
+
+	t.Setenv("OLLAMA_NUM_CTX", "lots")
+	if _, err := New().ExtractText(context.Background(), providers.Config{Model: "m"}); err == nil {
+		t.Error("expected an error for an invalid OLLAMA_NUM_CTX")
+	}
+}
@@ -0,0 +1,180 @@
+package ollama
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Dispatch strategies for OLLAMA_DISPATCH
+const (
+	DispatchLeastLoaded = "least-loaded"
+	DispatchRoundRobin  = "round-robin"
+)
+
+// DefaultHealthInterval is how often a host that stopped answering is checked
+const DefaultHealthInterval = 10 * time.Second
+
+// pool spreads requests across the Ollama hosts listed in OLLAMA_URL, so a lab with several
+// GPU machines can run one evaluation across all of them. A host that can't be reached is
+// taken out of rotation and checked every health interval until it answers again.
+type pool struct {
+	hosts       []*host
+	leastLoaded bool
+	interval    time.Duration
+	next        atomic.Uint64 // Rotation start, for round robin and to break ties
+	stop        chan struct{}
+}
+
+type host struct {
+	url      string
+	inFlight atomic.Int64
+
+	mu      sync.Mutex
+	down    bool
+	probing bool
+}
+
+var (
+	poolMu   sync.Mutex
+	poolSpec string
+	current  *pool
+)
+
+// hostPool returns the pool for the environment, rebuilding it when the configuration changes
+func hostPool() *pool {
+	spec := baseURL() + "|" + os.Getenv("OLLAMA_DISPATCH") + "|" + os.Getenv("OLLAMA_HEALTH_INTERVAL")
+	poolMu.Lock()
+	defer poolMu.Unlock()
+	if current != nil && spec == poolSpec {
+		return current
+	}
+	if current != nil {
+		close(current.stop)
+	}
+	poolSpec = spec
+	current = newPool(baseURL(), os.Getenv("OLLAMA_DISPATCH"), os.Getenv("OLLAMA_HEALTH_INTERVAL"))
+	return current
+}
+
+// newPool builds a pool from a comma-separated list of host URLs
+func newPool(urls, dispatch, interval string) *pool {
+	p := &pool{leastLoaded: true, interval: DefaultHealthInterval, stop: make(chan struct{})}
+	for _, u := range strings.Split(urls, ",") {
+		if u = strings.TrimRight(strings.TrimSpace(u), "/"); u != "" {
+			p.hosts = append(p.hosts, &host{url: u})
+		}
+	}
+	switch dispatch {
+	case "", DispatchLeastLoaded:
+	case DispatchRoundRobin:
+		p.leastLoaded = false
+	default:
+		slog.Warn("Ignoring unknown OLLAMA_DISPATCH", "value", dispatch)
+	}
+	if interval != "" {
+		if d, err := time.ParseDuration(interval); err == nil && d > 0 {
+			p.interval = d
+		} else {
+			slog.Warn("Ignoring invalid OLLAMA_HEALTH_INTERVAL", "value", interval)
+		}
+	}
+	return p
+}
+
+// pick chooses a host for a request, skipping those already tried. Healthy hosts are preferred;
+// when none is left, a host that is down is tried anyway, since it may be back.
+func (p *pool) pick(tried []*host) *host {
+	start := int(p.next.Add(1) - 1)
+	var best *host
+	bestHealthy := false
+	for i := range p.hosts {
+		h := p.hosts[(start+i)%len(p.hosts)]
+		if containsHost(tried, h) {
+			continue
+		}
+		healthy := h.healthy()
+		switch {
+		case best == nil, healthy && !bestHealthy:
+			best, bestHealthy = h, healthy
+		case healthy == bestHealthy && p.leastLoaded && h.inFlight.Load() < best.inFlight.Load():
+			best = h
+		}
+		if bestHealthy && !p.leastLoaded {
+			break
+		}
+	}
+	return best
+}
+
+func containsHost(hosts []*host, h *host) bool {
+	for _, t := range hosts {
+		if t == h {
+			return true
+		}
+	}
+	return false
+}
+
+func (h *host) healthy() bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return !h.down
+}
+
+// markDown takes a host out of rotation and checks it every interval until it answers
+func (p *pool) markDown(h *host, err error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if !h.down {
+		slog.Warn("Ollama host is unreachable, taking it out of rotation", "host", h.url, "error", err)
+	}
+	h.down = true
+	if h.probing {
+		return
+	}
+	h.probing = true
+	go p.probe(h)
+}
+
+// probe checks a down host until it answers or the pool is replaced
+func (p *pool) probe(h *host) {
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-p.stop:
+			return
+		case <-ticker.C:
+		}
+		if !ping(h.url) {
+			continue
+		}
+		h.mu.Lock()
+		h.down, h.probing = false, false
+		h.mu.Unlock()
+		slog.Info("Ollama host is back in rotation", "host", h.url)
+		return
+	}
+}
+
+// ping reports whether an Ollama server answers at url
+func ping(url string) bool {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, "GET", url+"/api/version", nil)
+	if err != nil {
+		return false
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return false
+	}
+	resp.Body.Close()
+	return resp.StatusCode == http.StatusOK
+}
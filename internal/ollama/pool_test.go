@@ -0,0 +1,89 @@
+package ollama
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/lehigh-university-libraries/cataloger/internal/providers"
+)
+
+func TestPoolPick(t *testing.T) {
+	p := newPool("http://a, http://b/,http://c", DispatchLeastLoaded, "")
+	if len(p.hosts) != 3 || p.hosts[1].url != "http://b" {
+		t.Fatalf("hosts = %+v", p.hosts)
+	}
+	p.hosts[0].inFlight.Store(2)
+	p.hosts[1].inFlight.Store(1)
+	p.hosts[2].inFlight.Store(3)
+	if h := p.pick(nil); h != p.hosts[1] {
+		t.Errorf("least loaded picked %s", h.url)
+	}
+	if h := p.pick([]*host{p.hosts[1]}); h != p.hosts[0] {
+		t.Errorf("least loaded without b picked %s", h.url)
+	}
+	p.hosts[1].down = true
+	if h := p.pick(nil); h != p.hosts[0] {
+		t.Errorf("picked %s over a healthy host", h.url)
+	}
+
+	rr := newPool("http://a,http://b", DispatchRoundRobin, "")
+	rr.hosts[0].inFlight.Store(5)
+	if a, b, c := rr.pick(nil), rr.pick(nil), rr.pick(nil); a == b || a != c {
+		t.Errorf("round robin picked %s, %s, %s", a.url, b.url, c.url)
+	}
+}
+
+func TestPoolFailover(t *testing.T) {
+	var up atomic.Bool
+	var served atomic.Int32
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/version" {
+			if !up.Load() {
+				w.WriteHeader(http.StatusServiceUnavailable)
+			}
+			return
+		}
+		served.Add(1)
+		fmt.Fprintln(w, `{"message":{"content":"ok"},"done":true}`)
+	}
+	live := httptest.NewServer(http.HandlerFunc(handler))
+	defer live.Close()
+	dead := httptest.NewServer(http.HandlerFunc(handler))
+	deadURL := dead.URL
+	dead.Close()
+
+	t.Setenv("OLLAMA_URL", deadURL+","+live.URL)
+	t.Setenv("OLLAMA_DISPATCH", DispatchRoundRobin)
+	t.Setenv("OLLAMA_HEALTH_INTERVAL", "10ms")
+	t.Setenv("PROVIDER_MAX_RETRIES", "0")
+
+	for range 4 {
+		text, err := New().ExtractText(context.Background(), providers.Config{Model: "m", Prompt: "p"})
+		if err != nil || text != "ok" {
+			t.Fatalf("ExtractText() = %q, %v", text, err)
+		}
+	}
+	if served.Load() != 4 {
+		t.Errorf("live host served %d requests, want 4", served.Load())
+	}
+	if hostPool().hosts[0].healthy() {
+		t.Error("unreachable host is still in rotation")
+	}
+
+	// A host that answers its health check again is put back
+	p := hostPool()
+	p.markDown(p.hosts[1], errors.New("connection refused"))
+	up.Store(true)
+	for deadline := time.Now().Add(time.Second); !p.hosts[1].healthy(); {
+		if time.Now().After(deadline) {
+			t.Fatal("recovered host was not put back in rotation")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
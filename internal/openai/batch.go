@@ -0,0 +1,524 @@
+package openai
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/lehigh-university-libraries/cataloger/internal/providers"
+)
+
+// The Batch API answers chat completions within a day at half the price, which suits
+// evaluations of thousands of items. A pipeline such as eval run makes its requests in stages
+// (OCR, then generation from its text), so it is replayed in rounds against a Batch: the
+// batch's provider answers from the responses collected so far and queues the requests it
+// can't answer, failing them with ErrPending, and Submit sends the queued requests and waits
+// for their responses before the next round.
+
+// ErrPending is returned for requests queued for the next batch
+var ErrPending = errors.New("queued for the next OpenAI batch")
+
+// BatchProviderName is the name a Batch's provider is registered under
+const BatchProviderName = "openai-batch"
+
+// Batch API limits on one batch's input file, with room to spare on its size
+const (
+	maxBatchRequests = 50000
+	maxBatchBytes    = 190 << 20
+)
+
+// Files a Batch keeps in its directory, so an interrupted run picks up where it stopped
+const (
+	batchResponsesFile = "responses.jsonl" // Every response collected, as lines of the output files
+	batchStateFile     = "batches.json"    // IDs of the batches submitted and not yet collected
+)
+
+// DefaultBatchPollInterval is how often Submit checks on its batches
+const DefaultBatchPollInterval = time.Minute
+
+// Batch queues chat completions for the Batch API and keeps their responses
+type Batch struct {
+	client       *OpenAI
+	dir          string
+	PollInterval time.Duration
+
+	mu        sync.Mutex
+	responses map[string]batchResult // By custom_id
+	queued    []batchRequest
+	queuedIDs map[string]bool
+}
+
+// batchRequest is a line of a batch's input file
+type batchRequest struct {
+	CustomID string          `json:"custom_id"`
+	Method   string          `json:"method"`
+	URL      string          `json:"url"`
+	Body     json.RawMessage `json:"body"`
+}
+
+// batchResult is a line of a batch's output or error file
+type batchResult struct {
+	CustomID string `json:"custom_id"`
+	Response *struct {
+		StatusCode int             `json:"status_code"`
+		Body       json.RawMessage `json:"body"`
+	} `json:"response"`
+	Error *struct {
+		Code    string `json:"code"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// NewBatch returns a batch sending requests to the OpenAI API (OPENAI_API_KEY,
+// OPENAI_BASE_URL), keeping its responses in dir
+func NewBatch(dir string) (*Batch, error) {
+	client := New()
+	if client.endpoint.RequireKey && client.endpoint.APIKey == "" {
+		return nil, fmt.Errorf("%s environment variable not set", client.endpoint.KeyEnv)
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create batch directory: %w", err)
+	}
+	b := &Batch{
+		client:       client,
+		dir:          dir,
+		PollInterval: DefaultBatchPollInterval,
+		responses:    make(map[string]batchResult),
+		queuedIDs:    make(map[string]bool),
+	}
+
+	f, err := os.Open(filepath.Join(dir, batchResponsesFile))
+	if os.IsNotExist(err) {
+		return b, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read batch responses: %w", err)
+	}
+	defer f.Close()
+	if err := b.readResults(f, nil); err != nil {
+		return nil, err
+	}
+	slog.Info("Loaded OpenAI batch responses", "dir", dir, "responses", len(b.responses))
+	return b, nil
+}
+
+// Registration registers the batch's provider as BatchProviderName, with openai's default model
+func (b *Batch) Registration() providers.Registration {
+	return providers.Registration{
+		Name:         BatchProviderName,
+		New:          func() providers.Provider { return batchProvider{b} },
+		DefaultModel: Registration.DefaultModel,
+		Configured:   Registration.Configured,
+		Vision:       true,
+	}
+}
+
+// Queued returns the number of requests waiting for the next batch
+func (b *Batch) Queued() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.queued)
+}
+
+// BatchWait records whether requests made with a context from WithBatchWait were queued, so a
+// pipeline knows its result waits on the next batch
+type BatchWait struct {
+	queued atomic.Bool
+}
+
+type batchWaitKey struct{}
+
+// WithBatchWait returns a context recording its queued requests in the returned BatchWait.
+// Once one is queued, later requests with the context fail with ErrPending without being
+// queued, since they likely depend on its answer.
+func WithBatchWait(ctx context.Context) (context.Context, *BatchWait) {
+	w := &BatchWait{}
+	return context.WithValue(ctx, batchWaitKey{}, w), w
+}
+
+// Waiting reports whether a request was queued
+func (w *BatchWait) Waiting() bool {
+	return w.queued.Load()
+}
+
+// answer returns the collected response to a chat completion, or queues it
+func (b *Batch) answer(ctx context.Context, body map[string]any) (string, error) {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal request body: %w", err)
+	}
+	sum := sha256.Sum256(data)
+	id := hex.EncodeToString(sum[:16])
+
+	b.mu.Lock()
+	result, ok := b.responses[id]
+	if !ok {
+		wait, _ := ctx.Value(batchWaitKey{}).(*BatchWait)
+		if (wait == nil || !wait.Waiting()) && !b.queuedIDs[id] {
+			b.queued = append(b.queued, batchRequest{CustomID: id, Method: "POST", URL: "/v1/chat/completions", Body: data})
+			b.queuedIDs[id] = true
+		}
+		if wait != nil {
+			wait.queued.Store(true)
+		}
+	}
+	b.mu.Unlock()
+	if !ok {
+		return "", ErrPending
+	}
+
+	name := b.client.endpoint.Name
+	switch {
+	case result.Error != nil:
+		return "", fmt.Errorf("batch request failed: %s: %s", result.Error.Code, result.Error.Message)
+	case result.Response == nil:
+		return "", fmt.Errorf("batch request %s has no response", id)
+	case result.Response.StatusCode != http.StatusOK:
+		if result.Response.StatusCode == http.StatusBadRequest && strings.Contains(string(result.Response.Body), "content_policy_violation") {
+			return "", &providers.RefusalError{Provider: name, Category: providers.RefusalContentFilter, Reason: "prompt rejected by the content policy"}
+		}
+		return "", fmt.Errorf("received non-200 status code: %d - %s", result.Response.StatusCode, string(result.Response.Body))
+	}
+	return readCompletion(name, bytes.NewReader(result.Response.Body))
+}
+
+// Submit sends the queued requests in as few batches as the limits allow, waits for them to
+// finish and keeps their responses. Batches an interrupted run left unfinished are waited for
+// first, so their requests aren't sent twice. Requests that expired unanswered are left for
+// the next round to queue again.
+func (b *Batch) Submit(ctx context.Context) error {
+	if ids, err := b.readState(); err != nil {
+		return err
+	} else if len(ids) > 0 {
+		slog.Info("Collecting OpenAI batches from an earlier run", "batches", len(ids))
+		if err := b.collect(ctx, ids); err != nil {
+			return err
+		}
+	}
+
+	b.mu.Lock()
+	var requests []batchRequest
+	for _, req := range b.queued {
+		if _, ok := b.responses[req.CustomID]; !ok {
+			requests = append(requests, req)
+		}
+	}
+	b.queued, b.queuedIDs = nil, make(map[string]bool)
+	b.mu.Unlock()
+	if len(requests) == 0 {
+		return nil
+	}
+
+	var ids []string
+	for len(requests) > 0 {
+		var input bytes.Buffer
+		n := 0
+		for n < len(requests) && n < maxBatchRequests {
+			line, err := json.Marshal(requests[n])
+			if err != nil {
+				return fmt.Errorf("failed to marshal batch request: %w", err)
+			}
+			if n > 0 && input.Len()+len(line)+1 > maxBatchBytes {
+				break
+			}
+			input.Write(line)
+			input.WriteByte('\n')
+			n++
+		}
+		id, err := b.create(ctx, input.Bytes())
+		if err != nil {
+			return err
+		}
+		slog.Info("Submitted OpenAI batch", "batch", id, "requests", n, "bytes", input.Len())
+		ids = append(ids, id)
+		if err := b.writeState(ids); err != nil {
+			return err
+		}
+		requests = requests[n:]
+	}
+	return b.collect(ctx, ids)
+}
+
+// create uploads a batch's input file and starts the batch, returning its ID
+func (b *Batch) create(ctx context.Context, input []byte) (string, error) {
+	var form bytes.Buffer
+	mw := multipart.NewWriter(&form)
+	if err := mw.WriteField("purpose", "batch"); err != nil {
+		return "", fmt.Errorf("failed to write batch upload: %w", err)
+	}
+	fw, err := mw.CreateFormFile("file", "batch.jsonl")
+	if err != nil {
+		return "", fmt.Errorf("failed to write batch upload: %w", err)
+	}
+	if _, err := fw.Write(input); err != nil {
+		return "", fmt.Errorf("failed to write batch upload: %w", err)
+	}
+	if err := mw.Close(); err != nil {
+		return "", fmt.Errorf("failed to write batch upload: %w", err)
+	}
+	var file struct {
+		ID string `json:"id"`
+	}
+	if err := b.call(ctx, "POST", "/files", mw.FormDataContentType(), &form, &file); err != nil {
+		return "", fmt.Errorf("failed to upload batch input: %w", err)
+	}
+
+	body, err := json.Marshal(map[string]string{
+		"input_file_id":     file.ID,
+		"endpoint":          "/v1/chat/completions",
+		"completion_window": "24h",
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal request body: %w", err)
+	}
+	var batch batchStatus
+	if err := b.call(ctx, "POST", "/batches", "application/json", bytes.NewReader(body), &batch); err != nil {
+		return "", fmt.Errorf("failed to create batch: %w", err)
+	}
+	return batch.ID, nil
+}
+
+// batchStatus is the Batch API's description of a batch
+type batchStatus struct {
+	ID            string `json:"id"`
+	Status        string `json:"status"`
+	OutputFileID  string `json:"output_file_id"`
+	ErrorFileID   string `json:"error_file_id"`
+	RequestCounts struct {
+		Total     int `json:"total"`
+		Completed int `json:"completed"`
+		Failed    int `json:"failed"`
+	} `json:"request_counts"`
+	Errors *struct {
+		Data []struct {
+			Message string `json:"message"`
+		} `json:"data"`
+	} `json:"errors"`
+}
+
+// collect waits for each batch to finish and keeps the responses in its output and error
+// files, then forgets the batches
+func (b *Batch) collect(ctx context.Context, ids []string) error {
+	for _, id := range ids {
+		status, err := b.wait(ctx, id)
+		if err != nil {
+			return err
+		}
+		for _, fileID := range []string{status.OutputFileID, status.ErrorFileID} {
+			if fileID == "" {
+				continue
+			}
+			if err := b.download(ctx, fileID); err != nil {
+				return err
+			}
+		}
+	}
+	return b.writeState(nil)
+}
+
+// wait polls a batch until it is done
+func (b *Batch) wait(ctx context.Context, id string) (batchStatus, error) {
+	for {
+		var status batchStatus
+		if err := b.call(ctx, "GET", "/batches/"+id, "", nil, &status); err != nil {
+			return status, fmt.Errorf("failed to check batch %s: %w", id, err)
+		}
+		switch status.Status {
+		case "completed", "expired", "cancelled":
+			slog.Info("OpenAI batch finished", "batch", id, "status", status.Status, "completed", status.RequestCounts.Completed, "failed", status.RequestCounts.Failed)
+			return status, nil
+		case "failed":
+			reason := "unknown error"
+			if status.Errors != nil && len(status.Errors.Data) > 0 {
+				reason = status.Errors.Data[0].Message
+			}
+			return status, fmt.Errorf("batch %s failed: %s", id, reason)
+		}
+		slog.Info("Waiting for OpenAI batch", "batch", id, "status", status.Status, "completed", status.RequestCounts.Completed, "total", status.RequestCounts.Total)
+
+		timer := time.NewTimer(b.PollInterval)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return status, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// download keeps the responses in a batch's output or error file
+func (b *Batch) download(ctx context.Context, fileID string) error {
+	req, err := b.request(ctx, "GET", "/files/"+fileID+"/content", "", nil)
+	if err != nil {
+		return err
+	}
+	resp, err := providers.HTTPClient().Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("failed to download batch file %s: %d - %s", fileID, resp.StatusCode, string(body))
+	}
+
+	f, err := os.OpenFile(filepath.Join(b.dir, batchResponsesFile), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open batch responses: %w", err)
+	}
+	defer f.Close()
+	return b.readResults(resp.Body, f)
+}
+
+// readResults keeps the results read from r, copying each line kept to w when it is not nil.
+// Requests that expired unanswered are skipped.
+func (b *Batch) readResults(r io.Reader, w io.Writer) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64<<10), 64<<20)
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(bytes.TrimSpace(line)) == 0 {
+			continue
+		}
+		var result batchResult
+		if err := json.Unmarshal(line, &result); err != nil {
+			return fmt.Errorf("failed to parse batch result: %w", err)
+		}
+		if result.Error != nil && result.Error.Code == "batch_expired" {
+			continue
+		}
+		b.responses[result.CustomID] = result
+		if w != nil {
+			if _, err := w.Write(append(line, '\n')); err != nil {
+				return fmt.Errorf("failed to write batch responses: %w", err)
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read batch results: %w", err)
+	}
+	return nil
+}
+
+// call sends a request to the API and decodes its JSON response into v
+func (b *Batch) call(ctx context.Context, method, path, contentType string, body io.Reader, v any) error {
+	req, err := b.request(ctx, method, path, contentType, body)
+	if err != nil {
+		return err
+	}
+	resp, err := providers.HTTPClient().Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		data, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("received non-200 status code: %d - %s", resp.StatusCode, string(data))
+	}
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("failed to decode response body: %w", err)
+	}
+	return nil
+}
+
+// request returns an authorized request to the API
+func (b *Batch) request(ctx context.Context, method, path, contentType string, body io.Reader) (*http.Request, error) {
+	e := b.client.endpoint
+	req, err := http.NewRequestWithContext(ctx, method, e.BaseURL+path, body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create new request: %w", err)
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	if e.APIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+e.APIKey)
+	}
+	for name, value := range e.Headers {
+		req.Header.Set(name, value)
+	}
+	return req, nil
+}
+
+// readState returns the batches submitted and not yet collected
+func (b *Batch) readState() ([]string, error) {
+	data, err := os.ReadFile(filepath.Join(b.dir, batchStateFile))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read batch state: %w", err)
+	}
+	var ids []string
+	if err := json.Unmarshal(data, &ids); err != nil {
+		return nil, fmt.Errorf("failed to parse batch state: %w", err)
+	}
+	return ids, nil
+}
+
+// writeState records the batches submitted and not yet collected, removing the record when
+// there are none
+func (b *Batch) writeState(ids []string) error {
+	path := filepath.Join(b.dir, batchStateFile)
+	if len(ids) == 0 {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove batch state: %w", err)
+		}
+		return nil
+	}
+	data, err := json.Marshal(ids)
+	if err != nil {
+		return fmt.Errorf("failed to marshal batch state: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write batch state: %w", err)
+	}
+	return nil
+}
+
+// batchProvider answers requests from a Batch's responses
+type batchProvider struct {
+	b *Batch
+}
+
+func (p batchProvider) ExtractText(ctx context.Context, config providers.Config) (string, error) {
+	return p.b.answer(ctx, p.b.client.chatBody(config, config.Prompt, false))
+}
+
+// StreamText answers in one chunk, since batches don't stream
+func (p batchProvider) StreamText(ctx context.Context, config providers.Config, onChunk func(string)) (string, error) {
+	text, err := p.ExtractText(ctx, config)
+	if err == nil && text != "" {
+		onChunk(text)
+	}
+	return text, err
+}
+
+func (p batchProvider) GenerateFromImage(ctx context.Context, config providers.Config, image []byte) (string, error) {
+	return p.GenerateFromImages(ctx, config, [][]byte{image})
+}
+
+func (p batchProvider) GenerateFromImages(ctx context.Context, config providers.Config, images [][]byte) (string, error) {
+	content, err := p.b.client.imageContent(config.Prompt, images)
+	if err != nil {
+		return "", err
+	}
+	return p.b.answer(ctx, p.b.client.chatBody(config, content, false))
+}
@@ -0,0 +1,153 @@
+package openai
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/lehigh-university-libraries/cataloger/internal/providers"
+)
+
+// fakeBatchAPI answers each chat completion in a batch with "echo: " and its prompt, except
+// prompts starting with "expire", which expire unanswered
+type fakeBatchAPI struct {
+	t       *testing.T
+	mu      sync.Mutex
+	inputs  map[string][]batchRequest // By file ID
+	batches int
+}
+
+func (f *fakeBatchAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if r.Header.Get("Authorization") != "Bearer sk-test" {
+		f.t.Errorf("%s %s without the API key", r.Method, r.URL.Path)
+	}
+	switch {
+	case r.Method == "POST" && r.URL.Path == "/files":
+		if r.FormValue("purpose") != "batch" {
+			f.t.Errorf("upload purpose = %q", r.FormValue("purpose"))
+		}
+		file, _, err := r.FormFile("file")
+		if err != nil {
+			f.t.Fatal(err)
+		}
+		var requests []batchRequest
+		scanner := bufio.NewScanner(file)
+		scanner.Buffer(nil, 1<<20)
+		for scanner.Scan() {
+			var req batchRequest
+			if err := json.Unmarshal(scanner.Bytes(), &req); err != nil {
+				f.t.Fatal(err)
+			}
+			requests = append(requests, req)
+		}
+		id := fmt.Sprintf("file-%d", len(f.inputs))
+		f.inputs[id] = requests
+		fmt.Fprintf(w, `{"id": %q}`, id)
+	case r.Method == "POST" && r.URL.Path == "/batches":
+		var body map[string]string
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		if body["endpoint"] != "/v1/chat/completions" || body["completion_window"] != "24h" {
+			f.t.Errorf("create batch = %v", body)
+		}
+		f.batches++
+		fmt.Fprintf(w, `{"id": "batch-%s", "status": "validating"}`, body["input_file_id"])
+	case r.Method == "GET" && strings.HasPrefix(r.URL.Path, "/batches/"):
+		file := strings.TrimPrefix(r.URL.Path, "/batches/batch-")
+		fmt.Fprintf(w, `{"id": "batch-%s", "status": "expired", "output_file_id": "out-%s", "error_file_id": "err-%s"}`, file, file, file)
+	case r.Method == "GET" && strings.HasPrefix(r.URL.Path, "/files/out-"):
+		for _, req := range f.inputs[strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/files/out-"), "/content")] {
+			var body struct {
+				Messages []struct {
+					Content any `json:"content"`
+				} `json:"messages"`
+			}
+			_ = json.Unmarshal(req.Body, &body)
+			prompt, _ := body.Messages[0].Content.(string)
+			if strings.HasPrefix(prompt, "expire") {
+				continue
+			}
+			completion, _ := json.Marshal(map[string]any{"choices": []any{map[string]any{"message": map[string]string{"content": "echo: " + prompt}}}})
+			fmt.Fprintf(w, `{"custom_id": %q, "response": {"status_code": 200, "body": %s}, "error": null}`+"\n", req.CustomID, completion)
+		}
+	case r.Method == "GET" && strings.HasPrefix(r.URL.Path, "/files/err-"):
+		for _, req := range f.inputs[strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/files/err-"), "/content")] {
+			if strings.Contains(string(req.Body), `"expire`) {
+				fmt.Fprintf(w, `{"custom_id": %q, "response": null, "error": {"code": "batch_expired", "message": "expired"}}`+"\n", req.CustomID)
+			}
+		}
+	default:
+		f.t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		http.NotFound(w, r)
+	}
+}
+
+func TestBatch(t *testing.T) {
+	api := &fakeBatchAPI{t: t, inputs: make(map[string][]batchRequest)}
+	server := httptest.NewServer(api)
+	defer server.Close()
+	t.Setenv("OPENAI_BASE_URL", server.URL)
+	t.Setenv("OPENAI_API_KEY", "sk-test")
+
+	dir := t.TempDir()
+	b, err := NewBatch(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b.PollInterval = 0
+	p := batchProvider{b}
+	config := func(prompt string) providers.Config {
+		return providers.Config{Model: "gpt-4o", Prompt: prompt}
+	}
+
+	// A second request with the same context waits on the first without being queued
+	ctx, wait := WithBatchWait(context.Background())
+	if _, err := p.ExtractText(ctx, config("title page")); !errors.Is(err, ErrPending) {
+		t.Fatalf("first request: err = %v, want ErrPending", err)
+	}
+	if _, err := p.ExtractText(ctx, config("depends on the title page")); !errors.Is(err, ErrPending) || !wait.Waiting() {
+		t.Fatalf("second request: err = %v, waiting %v", err, wait.Waiting())
+	}
+	if _, err := p.ExtractText(context.Background(), config("expire me")); !errors.Is(err, ErrPending) {
+		t.Fatal(err)
+	}
+	if b.Queued() != 2 {
+		t.Errorf("Queued() = %d, want 2", b.Queued())
+	}
+
+	if err := b.Submit(context.Background()); err != nil {
+		t.Fatalf("Submit failed: %v", err)
+	}
+	text, err := p.ExtractText(context.Background(), config("title page"))
+	if err != nil || text != "echo: title page" {
+		t.Errorf("after Submit: %q, %v", text, err)
+	}
+	if _, err := p.ExtractText(context.Background(), config("expire me")); !errors.Is(err, ErrPending) {
+		t.Errorf("expired request: err = %v, want ErrPending again", err)
+	}
+
+	// Responses survive the run
+	again, err := NewBatch(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var chunks []string
+	text, err = batchProvider{again}.StreamText(context.Background(), config("title page"), func(s string) { chunks = append(chunks, s) })
+	if err != nil || text != "echo: title page" || len(chunks) != 1 {
+		t.Errorf("reloaded: %q, %v, chunks %q", text, err, chunks)
+	}
+	if api.batches != 1 {
+		t.Errorf("created %d batches, want 1", api.batches)
+	}
+	if err := again.Submit(context.Background()); err != nil || api.batches != 1 {
+		t.Errorf("Submit with nothing queued: %v, %d batches", err, api.batches)
+	}
+}
@@ -1,44 +1,145 @@
 package openai
 
 import (
+	"bufio"
 	"bytes"
 	"context"
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"io"
+	"log/slog"
 	"net/http"
 	"os"
+	"strings"
 
 	"github.com/lehigh-university-libraries/cataloger/internal/providers"
 )
 
-// OpenAI is a provider for OpenAI
-type OpenAI struct{}
+// Registration registers the provider as "openai"
+var Registration = providers.Registration{
+	Name:         "openai",
+	New:          func() providers.Provider { return New() },
+	DefaultModel: providers.EnvModel("OPENAI_MODEL", "gpt-4o"),
+	Configured:   func() bool { return os.Getenv("OPENAI_API_KEY") != "" || os.Getenv("OPENAI_BASE_URL") != "" },
+	Vision:       true,
+}
+
+// DefaultBaseURL is the OpenAI API
+const DefaultBaseURL = "https://api.openai.com/v1"
+
+// Endpoint is an OpenAI-compatible chat completions API, such as OpenAI itself, vLLM,
+// LM Studio or OpenRouter
+type Endpoint struct {
+	Name       string            // Provider name in errors and refusals
+	BaseURL    string            // URL that /chat/completions is appended to
+	APIKey     string            // Sent as a bearer token when set
+	KeyEnv     string            // Environment variable the key comes from, for the missing key error
+	RequireKey bool              // Fail without a key instead of sending the request
+	Headers    map[string]string // Extra headers, e.g. OpenRouter's HTTP-Referer and X-Title
+	// JSONObjectOnly asks for any JSON object where a schema is given, for APIs whose models
+	// don't all take json_schema
+	JSONObjectOnly bool
+}
+
+// OpenAI is a provider for OpenAI and OpenAI-compatible APIs
+type OpenAI struct {
+	endpoint Endpoint
+}
 
 // New returns a new OpenAI provider
+//
+// Configured with:
+//   - OPENAI_API_KEY: API key (required for the OpenAI API, optional elsewhere)
+//   - OPENAI_BASE_URL: OpenAI-compatible API, e.g. http://localhost:8000/v1 for vLLM,
+//     http://localhost:1234/v1 for LM Studio or https://openrouter.ai/api/v1 (default DefaultBaseURL)
+//   - OPENAI_HEADERS: extra headers as Name=value pairs separated by semicolons
 func New() *OpenAI {
-	return &OpenAI{}
+	baseURL := strings.TrimSuffix(os.Getenv("OPENAI_BASE_URL"), "/")
+	if baseURL == "" {
+		baseURL = DefaultBaseURL
+	}
+	return NewEndpoint(Endpoint{
+		Name:       "openai",
+		BaseURL:    baseURL,
+		APIKey:     os.Getenv("OPENAI_API_KEY"),
+		KeyEnv:     "OPENAI_API_KEY",
+		RequireKey: baseURL == DefaultBaseURL,
+		Headers:    ParseHeaders(os.Getenv("OPENAI_HEADERS")),
+	})
+}
+
+// NewEndpoint returns a provider for an OpenAI-compatible API
+func NewEndpoint(e Endpoint) *OpenAI {
+	return &OpenAI{endpoint: e}
+}
+
+// ParseHeaders parses Name=value pairs separated by semicolons, skipping malformed pairs
+func ParseHeaders(s string) map[string]string {
+	headers := make(map[string]string)
+	for _, pair := range strings.Split(s, ";") {
+		name, value, ok := strings.Cut(pair, "=")
+		if name = strings.TrimSpace(name); !ok || name == "" {
+			if strings.TrimSpace(pair) != "" {
+				slog.Warn("Ignoring malformed header", "header", pair)
+			}
+			continue
+		}
+		headers[name] = strings.TrimSpace(value)
+	}
+	return headers
 }
 
 // ExtractText extracts text from the given prompt using OpenAI
 func (o *OpenAI) ExtractText(ctx context.Context, config providers.Config) (string, error) {
-	apiKey := os.Getenv("OPENAI_API_KEY")
-	if apiKey == "" {
-		return "", fmt.Errorf("OPENAI_API_KEY environment variable not set")
+	return o.send(ctx, config, config.Prompt, nil)
+}
+
+// StreamText extracts text with a streamed response, calling onChunk as it arrives
+func (o *OpenAI) StreamText(ctx context.Context, config providers.Config, onChunk func(string)) (string, error) {
+	return o.send(ctx, config, config.Prompt, onChunk)
+}
+
+// GenerateFromImage sends an image as a data URL with the prompt, for title page OCR
+func (o *OpenAI) GenerateFromImage(ctx context.Context, config providers.Config, image []byte) (string, error) {
+	return o.GenerateFromImages(ctx, config, [][]byte{image})
+}
+
+// GenerateFromImages sends several images as data URLs after the prompt, in one message
+func (o *OpenAI) GenerateFromImages(ctx context.Context, config providers.Config, images [][]byte) (string, error) {
+	content, err := o.imageContent(config.Prompt, images)
+	if err != nil {
+		return "", err
 	}
+	return o.send(ctx, config, content, nil)
+}
 
-	url := "https://api.openai.com/v1/chat/completions"
+// imageContent returns the parts of a message of the prompt followed by images as data URLs
+func (o *OpenAI) imageContent(prompt string, images [][]byte) ([]map[string]any, error) {
+	content := []map[string]any{{"type": "text", "text": prompt}}
+	for _, image := range images {
+		mediaType := http.DetectContentType(image)
+		switch mediaType {
+		case "image/jpeg", "image/png", "image/gif", "image/webp":
+		default:
+			return nil, fmt.Errorf("unsupported image type for %s: %s", o.endpoint.Name, mediaType)
+		}
+		content = append(content, map[string]any{"type": "image_url", "image_url": map[string]string{"url": "data:" + mediaType + ";base64," + base64.StdEncoding.EncodeToString(image)}})
+	}
+	return content, nil
+}
 
-	requestBody, err := json.Marshal(map[string]interface{}{
-		"model": config.Model,
-		"messages": []map[string]string{
-			{
-				"role":    "user",
-				"content": config.Prompt,
-			},
-		},
-		"temperature": config.Temperature,
-	})
+// send posts a chat completion with one user message, whose content is text or parts,
+// streaming the response to onChunk when it is not nil
+func (o *OpenAI) send(ctx context.Context, config providers.Config, content any, onChunk func(string)) (string, error) {
+	e := o.endpoint
+	if e.RequireKey && e.APIKey == "" {
+		return "", fmt.Errorf("%s environment variable not set", e.KeyEnv)
+	}
+
+	url := e.BaseURL + "/chat/completions"
+
+	requestBody, err := json.Marshal(o.chatBody(config, content, onChunk != nil))
 	if err != nil {
 		return "", fmt.Errorf("failed to marshal request body: %w", err)
 	}
@@ -48,10 +149,14 @@ func (o *OpenAI) ExtractText(ctx context.Context, config providers.Config) (stri
 		return "", fmt.Errorf("failed to create new request: %w", err)
 	}
 	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", "Bearer "+apiKey)
+	if e.APIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+e.APIKey)
+	}
+	for name, value := range e.Headers {
+		req.Header.Set(name, value)
+	}
 
-	client := &http.Client{}
-	resp, err := client.Do(req)
+	resp, err := providers.HTTPClient().Do(req)
 	if err != nil {
 		return "", fmt.Errorf("failed to send request: %w", err)
 	}
@@ -59,23 +164,118 @@ func (o *OpenAI) ExtractText(ctx context.Context, config providers.Config) (stri
 
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
+		if resp.StatusCode == http.StatusBadRequest && strings.Contains(string(body), "content_policy_violation") {
+			return "", &providers.RefusalError{Provider: e.Name, Category: providers.RefusalContentFilter, Reason: "prompt rejected by the content policy"}
+		}
 		return "", fmt.Errorf("received non-200 status code: %d - %s", resp.StatusCode, string(body))
 	}
 
+	if onChunk != nil {
+		return readStream(e.Name, resp.Body, onChunk)
+	}
+	return readCompletion(e.Name, resp.Body)
+}
+
+// chatBody returns the body of a chat completion request with one user message, whose
+// content is text or parts
+func (o *OpenAI) chatBody(config providers.Config, content any, stream bool) map[string]any {
+	body := map[string]any{
+		"model": config.Model,
+		"messages": []map[string]any{
+			{
+				"role":    "user",
+				"content": content,
+			},
+		},
+		"temperature": config.Temperature,
+		"stream":      stream,
+	}
+	switch {
+	case config.ResponseSchema != nil && !o.endpoint.JSONObjectOnly:
+		body["response_format"] = map[string]any{
+			"type":        "json_schema",
+			"json_schema": map[string]any{"name": "metadata", "strict": true, "schema": config.ResponseSchema},
+		}
+	case config.JSONMode, config.ResponseSchema != nil:
+		body["response_format"] = map[string]string{"type": "json_object"}
+	}
+	return body
+}
+
+// readCompletion reads the message of a chat completion, or the refusal in its place
+func readCompletion(provider string, r io.Reader) (string, error) {
 	var response struct {
 		Choices []struct {
 			Message struct {
 				Content string `json:"content"`
+				Refusal string `json:"refusal"`
 			} `json:"message"`
+			FinishReason string `json:"finish_reason"`
 		} `json:"choices"`
 	}
-	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+	if err := json.NewDecoder(r).Decode(&response); err != nil {
 		return "", fmt.Errorf("failed to decode response body: %w", err)
 	}
 
 	if len(response.Choices) == 0 {
-		return "", fmt.Errorf("no choices returned from OpenAI")
+		return "", fmt.Errorf("no choices returned from %s", provider)
 	}
 
-	return response.Choices[0].Message.Content, nil
+	choice := response.Choices[0]
+	if choice.Message.Refusal != "" {
+		return "", &providers.RefusalError{Provider: provider, Category: providers.RefusalModel, Reason: choice.Message.Refusal}
+	}
+	if choice.FinishReason == "content_filter" {
+		return "", &providers.RefusalError{Provider: provider, Category: providers.RefusalContentFilter, Reason: "response stopped by the content filter"}
+	}
+	return choice.Message.Content, nil
+}
+
+// readStream reads a streamed chat completion: server-sent events whose data is a chunk
+// with a delta of the message, ending with [DONE]
+func readStream(provider string, r io.Reader, onChunk func(string)) (string, error) {
+	var text strings.Builder
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64<<10), 1<<20)
+	for scanner.Scan() {
+		data, ok := strings.CutPrefix(scanner.Text(), "data:")
+		if !ok {
+			continue
+		}
+		data = strings.TrimSpace(data)
+		if data == "[DONE]" {
+			return text.String(), nil
+		}
+
+		var chunk struct {
+			Choices []struct {
+				Delta struct {
+					Content string `json:"content"`
+					Refusal string `json:"refusal"`
+				} `json:"delta"`
+				FinishReason string `json:"finish_reason"`
+			} `json:"choices"`
+		}
+		if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+			return "", fmt.Errorf("failed to decode stream chunk: %w", err)
+		}
+		if len(chunk.Choices) == 0 {
+			continue
+		}
+		choice := chunk.Choices[0]
+		if choice.Delta.Refusal != "" {
+			return "", &providers.RefusalError{Provider: provider, Category: providers.RefusalModel, Reason: choice.Delta.Refusal}
+		}
+		if choice.FinishReason == "content_filter" {
+			return "", &providers.RefusalError{Provider: provider, Category: providers.RefusalContentFilter, Reason: "response stopped by the content filter"}
+		}
+		if choice.Delta.Content != "" {
+			text.WriteString(choice.Delta.Content)
+			onChunk(choice.Delta.Content)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return "", fmt.Errorf("failed to read stream: %w", err)
+	}
+	return "", fmt.Errorf("stream ended before [DONE]")
 }
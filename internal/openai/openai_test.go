@@ -0,0 +1,151 @@
+package openai
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/lehigh-university-libraries/cataloger/internal/providers"
+)
+
+func TestReadStream(t *testing.T) {
+	stream := `data: {"choices":[{"delta":{"role":"assistant","content":""}}]}
+
+data: {"choices":[{"delta":{"content":"{\"title\":"}}]}
+
+data: {"choices":[{"delta":{"content":"\"Walden\"}"},"finish_reason":null}]}
+
+data: {"choices":[{"delta":{},"finish_reason":"stop"}]}
+
+data: [DONE]
+`
+	var chunks []string
+	text, err := readStream("openai", strings.NewReader(stream), func(s string) { chunks = append(chunks, s) })
+	if err != nil {
+		t.Fatal(err)
+	}
+	if text != `{"title":"Walden"}` || len(chunks) != 2 {
+		t.Errorf("readStream() = %q with chunks %q", text, chunks)
+	}
+
+	var refusal *providers.RefusalError
+	_, err = readStream("openai", strings.NewReader(`data: {"choices":[{"delta":{"refusal":"I can't help with that."}}]}`+"\n"), func(string) {})
+	if !errors.As(err, &refusal) || refusal.Category != providers.RefusalModel {
+		t.Errorf("refusal delta: err = %v", err)
+	}
+	_, err = readStream("openai", strings.NewReader(`data: {"choices":[{"delta":{},"finish_reason":"content_filter"}]}`+"\n"), func(string) {})
+	if !errors.As(err, &refusal) || refusal.Category != providers.RefusalContentFilter {
+		t.Errorf("content filter: err = %v", err)
+	}
+	if _, err := readStream("openai", strings.NewReader(`data: {"choices":[{"delta":{"content":"{"}}]}`+"\n"), func(string) {}); err == nil {
+		t.Error("expected an error for a stream cut off before [DONE]")
+	}
+}
+
+func TestCompatibleEndpoint(t *testing.T) {
+	var got map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/chat/completions" || r.Header.Get("Authorization") != "" || r.Header.Get("X-Title") != "Cataloger" || r.Header.Get("HTTP-Referer") != "https://library.example.edu" {
+			t.Errorf("unexpected request %s %v", r.URL.Path, r.Header)
+		}
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Error(err)
+		}
+		_, _ = w.Write([]byte(`{"choices":[{"message":{"content":"Walden"},"finish_reason":"stop"}]}`))
+	}))
+	defer server.Close()
+	t.Setenv("OPENAI_API_KEY", "")
+	t.Setenv("OPENAI_BASE_URL", server.URL+"/v1/")
+	t.Setenv("OPENAI_HEADERS", "HTTP-Referer=https://library.example.edu; X-Title=Cataloger")
+
+	text, err := New().ExtractText(context.Background(), providers.Config{Model: "qwen2.5-vl", Prompt: "p"})
+	if err != nil || text != "Walden" {
+		t.Fatalf("ExtractText() = %q, %v", text, err)
+	}
+	if got["model"] != "qwen2.5-vl" {
+		t.Errorf("request = %v", got)
+	}
+
+	t.Setenv("OPENAI_BASE_URL", "")
+	if _, err := New().ExtractText(context.Background(), providers.Config{Model: "m", Prompt: "p"}); err == nil || !strings.Contains(err.Error(), "OPENAI_API_KEY") {
+		t.Errorf("the OpenAI API without a key: err = %v", err)
+	}
+}
+
+func TestResponseFormat(t *testing.T) {
+	var got map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = nil
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Error(err)
+		}
+		_, _ = w.Write([]byte(`{"choices":[{"message":{"content":"{}"},"finish_reason":"stop"}]}`))
+	}))
+	defer server.Close()
+	t.Setenv("OPENAI_BASE_URL", server.URL)
+
+	type metadata struct {
+		Title string   `json:"title"`
+		ISBN  []string `json:"isbn,omitempty"`
+	}
+	schema := providers.SchemaFor(metadata{})
+	if _, err := New().ExtractText(context.Background(), providers.Config{Model: "m", Prompt: "p", JSONMode: true, ResponseSchema: schema}); err != nil {
+		t.Fatal(err)
+	}
+	// Strict, so the model can't leave out a field or add one
+	format, _ := got["response_format"].(map[string]any)
+	jsonSchema, _ := format["json_schema"].(map[string]any)
+	sent, _ := jsonSchema["schema"].(map[string]any)
+	if format["type"] != "json_schema" || jsonSchema["strict"] != true || sent["additionalProperties"] != false ||
+		!reflect.DeepEqual(sent["required"], []any{"title", "isbn"}) {
+		t.Errorf("response_format = %v", got["response_format"])
+	}
+
+	if _, err := New().ExtractText(context.Background(), providers.Config{Model: "m", Prompt: "p", JSONMode: true}); err != nil {
+		t.Fatal(err)
+	}
+	if format, _ := got["response_format"].(map[string]any); format["type"] != "json_object" {
+		t.Errorf("response_format = %v", got["response_format"])
+	}
+}
+
+func TestGenerateFromImages(t *testing.T) {
+	var got struct {
+		Messages []struct {
+			Content []map[string]any `json:"content"`
+		} `json:"messages"`
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Error(err)
+		}
+		_, _ = w.Write([]byte(`{"choices":[{"message":{"content":"{}"},"finish_reason":"stop"}]}`))
+	}))
+	defer server.Close()
+	t.Setenv("OPENAI_BASE_URL", server.URL)
+
+	png := []byte("\x89PNG\r\n\x1a\n0000")
+	jpeg := []byte("\xff\xd8\xff\xe0000000")
+	if _, err := New().GenerateFromImages(context.Background(), providers.Config{Model: "m", Prompt: "p"}, [][]byte{jpeg, png}); err != nil {
+		t.Fatal(err)
+	}
+	content := got.Messages[0].Content
+	if len(content) != 3 || content[0]["text"] != "p" {
+		t.Fatalf("content = %v", content)
+	}
+	for i, prefix := range []string{"data:image/jpeg;base64,", "data:image/png;base64,"} {
+		url, _ := content[i+1]["image_url"].(map[string]any)["url"].(string)
+		if !strings.HasPrefix(url, prefix) {
+			t.Errorf("image %d url = %.40q, want prefix %s", i+1, url, prefix)
+		}
+	}
+
+	if _, err := New().GenerateFromImages(context.Background(), providers.Config{Model: "m"}, [][]byte{jpeg, []byte("not an image")}); err == nil {
+		t.Error("expected an error for an unsupported image")
+	}
+}
@@ -0,0 +1,102 @@
+package perturb
+
+import (
+	"math/rand/v2"
+	"strings"
+	"unicode"
+
+	"github.com/lehigh-university-libraries/cataloger/internal/marc"
+)
+
+// Options controls how much noise is applied
+type Options struct {
+	CharRate float64 // Probability that each letter or digit is substituted, deleted, duplicated or transposed
+	DropRate float64 // Probability that each data field is dropped from a record
+}
+
+// NewRand returns a deterministic random source for the given seed
+func NewRand(seed uint64) *rand.Rand {
+	return rand.New(rand.NewPCG(seed, seed^0x9e3779b97f4a7c15))
+}
+
+// Text applies OCR-like character noise to s. Only letters and digits are altered,
+// so punctuation and spacing stay intact.
+func Text(s string, rate float64, rng *rand.Rand) string {
+	if rate <= 0 || s == "" {
+		return s
+	}
+
+	in := []rune(s)
+	out := make([]rune, 0, len(in)+4)
+	for i := 0; i < len(in); i++ {
+		r := in[i]
+		if !(unicode.IsLetter(r) || unicode.IsDigit(r)) || rng.Float64() >= rate {
+			out = append(out, r)
+			continue
+		}
+
+		switch rng.IntN(4) {
+		case 0: // substitute
+			out = append(out, similarRune(r, rng))
+		case 1: // delete
+		case 2: // duplicate
+			out = append(out, r, r)
+		case 3: // transpose with the next character
+			if i+1 < len(in) {
+				out = append(out, in[i+1], r)
+				i++
+			} else {
+				out = append(out, r)
+			}
+		}
+	}
+	return string(out)
+}
+
+// confusions are common OCR misreadings
+var confusions = map[rune][]rune{
+	'l': {'1', 'I'}, '1': {'l', 'I'}, 'I': {'l', '1'},
+	'o': {'0', 'c'}, 'O': {'0', 'Q'}, '0': {'O', 'o'},
+	'e': {'c'}, 'c': {'e'}, 'm': {'n'}, 'n': {'m', 'h'},
+	'S': {'5'}, '5': {'S'}, 'B': {'8'}, '8': {'B'}, 'u': {'v'}, 'v': {'u'},
+}
+
+func similarRune(r rune, rng *rand.Rand) rune {
+	if options := confusions[r]; len(options) > 0 {
+		return options[rng.IntN(len(options))]
+	}
+	if unicode.IsDigit(r) {
+		return rune('0' + rng.IntN(10))
+	}
+	letter := rune('a' + rng.IntN(26))
+	if unicode.IsUpper(r) {
+		letter = unicode.ToUpper(letter)
+	}
+	return letter
+}
+
+// Record returns a perturbed copy of rec. The leader and control fields are kept as-is.
+func Record(rec *marc.Record, opts Options, rng *rand.Rand) *marc.Record {
+	out := &marc.Record{
+		Leader:        rec.Leader,
+		ControlFields: append([]marc.ControlField(nil), rec.ControlFields...),
+	}
+
+	for _, df := range rec.DataFields {
+		if opts.DropRate > 0 && rng.Float64() < opts.DropRate {
+			continue
+		}
+		field := marc.DataField{Tag: df.Tag, Ind1: df.Ind1, Ind2: df.Ind2}
+		for _, sf := range df.Subfields {
+			value := sf.Value
+			// Leave control subfields (links, identifiers) untouched
+			if !strings.ContainsAny(sf.Code, "0123456789") {
+				value = Text(value, opts.CharRate, rng)
+			}
+			field.Subfields = append(field.Subfields, marc.Subfield{Code: sf.Code, Value: value})
+		}
+		out.DataFields = append(out.DataFields, field)
+	}
+
+	return out
+}
@@ -0,0 +1,48 @@
+package perturb
+
+import (
+	"testing"
+
+	"github.com/lehigh-university-libraries/cataloger/internal/marc"
+)
+
+func TestTextZeroRate(t *testing.T) {
+	s := "The old man and the sea / Ernest Hemingway."
+	if got := Text(s, 0, NewRand(1)); got != s {
+		t.Errorf("Text() with rate 0 = %q, want unchanged", got)
+	}
+}
+
+func TestTextDeterministic(t *testing.T) {
+	s := "The old man and the sea / Ernest Hemingway."
+	a := Text(s, 0.2, NewRand(42))
+	b := Text(s, 0.2, NewRand(42))
+	if a != b {
+		t.Errorf("same seed gave different output: %q vs %q", a, b)
+	}
+	if a == s {
+		t.Errorf("rate 0.2 left text unchanged")
+	}
+}
+
+func TestRecordKeepsControlSubfields(t *testing.T) {
+	rec := &marc.Record{DataFields: []marc.DataField{{
+		Tag: "100",
+		Subfields: []marc.Subfield{
+			{Code: "a", Value: "Morrison, Toni."},
+			{Code: "0", Value: "http://id.loc.gov/authorities/names/n80131379"},
+		},
+	}}}
+
+	out := Record(rec, Options{CharRate: 1}, NewRand(7))
+	if got := out.DataFields[0].Subfield("0"); got != rec.DataFields[0].Subfield("0") {
+		t.Errorf("$0 was perturbed: %q", got)
+	}
+	if rec.DataFields[0].Subfield("a") != "Morrison, Toni." {
+		t.Error("original record was modified")
+	}
+
+	if dropped := Record(rec, Options{DropRate: 1}, NewRand(7)); len(dropped.DataFields) != 0 {
+		t.Errorf("DropRate 1 kept %d fields", len(dropped.DataFields))
+	}
+}
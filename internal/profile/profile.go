@@ -0,0 +1,144 @@
+package profile
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/lehigh-university-libraries/cataloger/internal/marc"
+	"gopkg.in/yaml.v3"
+)
+
+// Profile describes the cataloging institution, so generated records carry its
+// cataloging-source fields without post-editing
+type Profile struct {
+	Name                   string `yaml:"name"`
+	OrgCode                string `yaml:"org_code"`                // MARC organization code: 003, and 040 $a/$c unless overridden
+	CatalogingAgency       string `yaml:"cataloging_agency"`       // 040 $a
+	TranscribingAgency     string `yaml:"transcribing_agency"`     // 040 $c
+	Language               string `yaml:"language"`                // Language of cataloging, 040 $b
+	DescriptionConventions string `yaml:"description_conventions"` // 040 $e, e.g. rda
+	Location               string `yaml:"location"`                // Default holdings location
+	// ProvenanceNote is a 500 note added to every generated record, e.g. "Description
+	// generated from title page image."; no note is added when empty
+	ProvenanceNote string `yaml:"provenance_note"`
+}
+
+// Load reads a profile from a YAML file
+func Load(path string) (*Profile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read profile: %w", err)
+	}
+
+	var p Profile
+	if err := yaml.Unmarshal(data, &p); err != nil {
+		return nil, fmt.Errorf("failed to parse profile %s: %w", path, err)
+	}
+	if p.OrgCode == "" && p.CatalogingAgency == "" {
+		return nil, fmt.Errorf("profile %s needs an org_code or cataloging_agency", path)
+	}
+	if p.Language == "" {
+		p.Language = "eng"
+	}
+	return &p, nil
+}
+
+// FromEnv loads the profile named by CATALOGER_PROFILE, or returns nil when unset
+func FromEnv() (*Profile, error) {
+	path := os.Getenv("CATALOGER_PROFILE")
+	if path == "" {
+		return nil, nil
+	}
+	return Load(path)
+}
+
+// Field040 builds the cataloging source field
+func (p *Profile) Field040() marc.DataField {
+	f := marc.DataField{Tag: "040", Ind1: " ", Ind2: " "}
+	add := func(code, value string) {
+		if value != "" {
+			f.Subfields = append(f.Subfields, marc.Subfield{Code: code, Value: value})
+		}
+	}
+	add("a", firstNonEmpty(p.CatalogingAgency, p.OrgCode))
+	add("b", p.Language)
+	add("e", p.DescriptionConventions)
+	add("c", firstNonEmpty(p.TranscribingAgency, p.CatalogingAgency, p.OrgCode))
+	return f
+}
+
+// Apply replaces any 040 in a generated record with the profile's cataloging source
+func (p *Profile) Apply(rec *marc.Record) {
+	rec.RemoveTags([]string{"040"})
+
+	f := p.Field040()
+	i := 0
+	for i < len(rec.DataFields) && rec.DataFields[i].Tag < f.Tag {
+		i++
+	}
+	rec.DataFields = append(rec.DataFields[:i], append([]marc.DataField{f}, rec.DataFields[i:]...)...)
+
+	if p.ProvenanceNote != "" && !p.hasProvenanceNote(rec) {
+		note := marc.DataField{Tag: "500", Ind1: " ", Ind2: " ", Subfields: []marc.Subfield{{Code: "a", Value: p.ProvenanceNote}}}
+		i = 0
+		for i < len(rec.DataFields) && rec.DataFields[i].Tag <= note.Tag {
+			i++
+		}
+		rec.DataFields = append(rec.DataFields[:i], append([]marc.DataField{note}, rec.DataFields[i:]...)...)
+	}
+}
+
+// isProvenanceNote reports whether a field is the profile's provenance note
+func (p *Profile) isProvenanceNote(f marc.DataField) bool {
+	return p.ProvenanceNote != "" && f.Tag == "500" && strings.TrimSpace(f.Subfield("a")) == strings.TrimSpace(p.ProvenanceNote)
+}
+
+func (p *Profile) hasProvenanceNote(rec *marc.Record) bool {
+	for _, f := range rec.DataFields {
+		if p.isProvenanceNote(f) {
+			return true
+		}
+	}
+	return false
+}
+
+// WithoutProvenanceNote returns a copy of the record without the provenance note, so it
+// does not count in comparisons against reference records; the record is returned as is
+// when the profile has no note or the record does not carry it
+func (p *Profile) WithoutProvenanceNote(rec *marc.Record) *marc.Record {
+	if p == nil || !p.hasProvenanceNote(rec) {
+		return rec
+	}
+	stripped := *rec
+	stripped.DataFields = make([]marc.DataField, 0, len(rec.DataFields)-1)
+	for _, f := range rec.DataFields {
+		if !p.isProvenanceNote(f) {
+			stripped.DataFields = append(stripped.DataFields, f)
+		}
+	}
+	return &stripped
+}
+
+// PromptContext describes the institution for the metadata extraction prompt
+func (p *Profile) PromptContext() string {
+	var b strings.Builder
+	b.WriteString("CATALOGING CONTEXT:\n")
+	if p.Name != "" {
+		fmt.Fprintf(&b, "- Cataloging institution: %s\n", p.Name)
+	}
+	fmt.Fprintf(&b, "- Language of cataloging: %s (write notes and supplied text in this language; transcribe title page data as it appears)\n", p.Language)
+	if p.DescriptionConventions != "" {
+		fmt.Fprintf(&b, "- Description conventions: %s\n", p.DescriptionConventions)
+	}
+	return b.String()
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
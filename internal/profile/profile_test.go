@@ -0,0 +1,92 @@
+package profile
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/lehigh-university-libraries/cataloger/internal/marc"
+)
+
+func TestLoadAndApply(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "profile.yaml")
+	data := "name: Lehigh University Libraries\norg_code: PBL\ndescription_conventions: rda\n"
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	p, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Language != "eng" {
+		t.Errorf("language should default to eng, got %q", p.Language)
+	}
+
+	rec := &marc.Record{DataFields: []marc.DataField{
+		{Tag: "020", Subfields: []marc.Subfield{{Code: "a", Value: "0684801221"}}},
+		{Tag: "040", Subfields: []marc.Subfield{{Code: "a", Value: "XXX"}}},
+		{Tag: "245", Subfields: []marc.Subfield{{Code: "a", Value: "Title"}}},
+	}}
+	p.Apply(rec)
+
+	if len(rec.DataFields) != 3 || rec.DataFields[1].Tag != "040" {
+		t.Fatalf("expected one 040 between 020 and 245, got %+v", rec.DataFields)
+	}
+	want := []marc.Subfield{{Code: "a", Value: "PBL"}, {Code: "b", Value: "eng"}, {Code: "e", Value: "rda"}, {Code: "c", Value: "PBL"}}
+	got := rec.DataFields[1].Subfields
+	if len(got) != len(want) {
+		t.Fatalf("040 = %+v, want %+v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("040 subfield %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestLoadRequiresAgency(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "profile.yaml")
+	if err := os.WriteFile(path, []byte("name: Somewhere\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Load(path); err == nil {
+		t.Error("expected an error for a profile without an org code")
+	}
+}
+
+func TestProvenanceNote(t *testing.T) {
+	p := &Profile{OrgCode: "PBL", ProvenanceNote: "Description generated from title page image."}
+	rec := &marc.Record{DataFields: []marc.DataField{
+		{Tag: "245", Subfields: []marc.Subfield{{Code: "a", Value: "Title"}}},
+		{Tag: "500", Subfields: []marc.Subfield{{Code: "a", Value: "Includes index."}}},
+		{Tag: "650", Subfields: []marc.Subfield{{Code: "a", Value: "Subject"}}},
+	}}
+	p.Apply(rec)
+	p.Apply(rec)
+
+	var tags []string
+	for _, f := range rec.DataFields {
+		tags = append(tags, f.Tag)
+	}
+	if got := strings.Join(tags, " "); got != "040 245 500 500 650" {
+		t.Fatalf("tags = %s, want one provenance note after the existing 500", got)
+	}
+	if rec.DataFields[3].Subfield("a") != p.ProvenanceNote {
+		t.Errorf("second 500 = %+v, want the provenance note", rec.DataFields[3])
+	}
+
+	stripped := p.WithoutProvenanceNote(rec)
+	if len(stripped.DataFields) != 4 || stripped.Fields("500")[0].Subfield("a") != "Includes index." {
+		t.Errorf("stripped record = %+v, want only the other 500 kept", stripped.DataFields)
+	}
+	if len(rec.DataFields) != 5 {
+		t.Error("WithoutProvenanceNote should not modify the record")
+	}
+
+	var none *Profile
+	if none.WithoutProvenanceNote(rec) != rec {
+		t.Error("a nil profile should return the record as is")
+	}
+}
@@ -0,0 +1,214 @@
+package prompts
+
+import (
+	"crypto/sha256"
+	"embed"
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Prompt IDs used by the services
+const (
+	OCR                = "ocr"
+	MetadataExtraction = "metadata_extraction"
+	CopyrightPage      = "copyright_page"
+	MetadataFromImages = "metadata_from_images"
+)
+
+//go:embed library/*/*.txt
+var builtin embed.FS
+
+// Prompt is one version of a prompt template
+type Prompt struct {
+	ID      string
+	Version string
+	Text    string
+	Hash    string // First 12 hex chars of the SHA-256 of Text
+}
+
+// Ref identifies the exact prompt text used, e.g. "ocr@v1+3f2a9c1b7e4d".
+// The hash guards against a version file being edited in place.
+func (p Prompt) Ref() string {
+	return p.ID + "@" + p.Version + "+" + p.Hash
+}
+
+// Library holds prompt versions laid out as <id>/<version>.txt
+type Library struct {
+	prompts map[string]map[string]Prompt
+}
+
+// Builtin returns the prompts shipped with cataloger
+func Builtin() *Library {
+	lib := &Library{prompts: make(map[string]map[string]Prompt)}
+	sub, _ := fs.Sub(builtin, "library")
+	if err := lib.add(sub); err != nil {
+		panic(fmt.Sprintf("invalid builtin prompt library: %v", err))
+	}
+	return lib
+}
+
+// LoadDir returns the builtin prompts overlaid with the versions found in dir.
+// A file in dir replaces a builtin prompt with the same ID and version.
+func LoadDir(dir string) (*Library, error) {
+	lib := Builtin()
+	if err := lib.add(os.DirFS(dir)); err != nil {
+		return nil, fmt.Errorf("failed to load prompts from %s: %w", dir, err)
+	}
+	return lib, nil
+}
+
+func (l *Library) add(fsys fs.FS) error {
+	files, err := fs.Glob(fsys, "*/*.txt")
+	if err != nil {
+		return err
+	}
+
+	for _, file := range files {
+		data, err := fs.ReadFile(fsys, file)
+		if err != nil {
+			return err
+		}
+
+		id := path.Dir(file)
+		version := strings.TrimSuffix(path.Base(file), ".txt")
+		text := strings.TrimRight(string(data), "\n")
+		sum := sha256.Sum256([]byte(text))
+
+		if l.prompts[id] == nil {
+			l.prompts[id] = make(map[string]Prompt)
+		}
+		l.prompts[id][version] = Prompt{
+			ID:      id,
+			Version: version,
+			Text:    text,
+			Hash:    hex.EncodeToString(sum[:])[:12],
+		}
+	}
+
+	return nil
+}
+
+// Get returns a prompt version, or the latest version when version is empty
+func (l *Library) Get(id, version string) (Prompt, error) {
+	versions := l.Versions(id)
+	if len(versions) == 0 {
+		return Prompt{}, fmt.Errorf("unknown prompt %q", id)
+	}
+
+	if version == "" {
+		version = versions[len(versions)-1]
+	}
+
+	p, ok := l.prompts[id][version]
+	if !ok {
+		return Prompt{}, fmt.Errorf("unknown version %q of prompt %q (available: %s)", version, id, strings.Join(versions, ", "))
+	}
+	return p, nil
+}
+
+// Versions lists the versions of a prompt, oldest first. Versions named vN sort numerically.
+func (l *Library) Versions(id string) []string {
+	versions := make([]string, 0, len(l.prompts[id]))
+	for v := range l.prompts[id] {
+		versions = append(versions, v)
+	}
+	sort.Slice(versions, func(i, j int) bool {
+		ni, okI := versionNumber(versions[i])
+		nj, okJ := versionNumber(versions[j])
+		if okI && okJ {
+			return ni < nj
+		}
+		return versions[i] < versions[j]
+	})
+	return versions
+}
+
+func versionNumber(v string) (int, bool) {
+	n, err := strconv.Atoi(strings.TrimPrefix(v, "v"))
+	return n, err == nil && strings.HasPrefix(v, "v")
+}
+
+// ParsePins parses "id=version" pins, e.g. from --prompt-version ocr=v1
+func ParsePins(specs []string) (map[string]string, error) {
+	pins := make(map[string]string, len(specs))
+	for _, spec := range specs {
+		id, version, ok := strings.Cut(spec, "=")
+		if !ok || id == "" || version == "" {
+			return nil, fmt.Errorf("invalid prompt pin %q (expected id=version)", spec)
+		}
+		pins[strings.TrimSpace(id)] = strings.TrimSpace(version)
+	}
+	return pins, nil
+}
+
+// Selection resolves prompts from a library honoring per-run version pins
+type Selection struct {
+	Library *Library
+	Pins    map[string]string
+}
+
+// Default returns the builtin library (or CATALOGER_PROMPTS_DIR overlay) with pins from
+// CATALOGER_PROMPT_VERSIONS ("ocr=v1,metadata_extraction=v2")
+func Default() (*Selection, error) {
+	lib := Builtin()
+	if dir := os.Getenv("CATALOGER_PROMPTS_DIR"); dir != "" {
+		var err error
+		if lib, err = LoadDir(dir); err != nil {
+			return nil, err
+		}
+	}
+
+	var specs []string
+	if env := os.Getenv("CATALOGER_PROMPT_VERSIONS"); env != "" {
+		specs = strings.Split(env, ",")
+	}
+	pins, err := ParsePins(specs)
+	if err != nil {
+		return nil, err
+	}
+
+	sel := &Selection{Library: lib, Pins: pins}
+	if err := sel.Validate(); err != nil {
+		return nil, err
+	}
+	return sel, nil
+}
+
+// Get returns the pinned (or latest) version of a prompt
+func (s *Selection) Get(id string) (Prompt, error) {
+	return s.Library.Get(id, s.Pins[id])
+}
+
+// MaterialPrompt returns the ID of a prompt's variant for a material type, e.g.
+// "metadata_extraction_map". Books, and unknown material, use the prompt itself.
+func MaterialPrompt(id, material string) string {
+	if material == "" || material == "book" {
+		return id
+	}
+	return id + "_" + material
+}
+
+// GetFor returns the pinned (or latest) version of a prompt's variant for a material type,
+// falling back to the prompt itself when the library has no such variant
+func (s *Selection) GetFor(id, material string) (Prompt, error) {
+	if variant := MaterialPrompt(id, material); len(s.Library.Versions(variant)) > 0 {
+		return s.Get(variant)
+	}
+	return s.Get(id)
+}
+
+// Validate checks that every pinned prompt version exists
+func (s *Selection) Validate() error {
+	for id, version := range s.Pins {
+		if _, err := s.Library.Get(id, version); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,86 @@
+package prompts
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestBuiltin(t *testing.T) {
+	lib := Builtin()
+	for _, id := range []string{OCR, MetadataExtraction} {
+		p, err := lib.Get(id, "")
+		if err != nil {
+			t.Fatalf("Get(%q) error: %v", id, err)
+		}
+		if p.Text == "" || len(p.Hash) != 12 {
+			t.Errorf("Get(%q) = %+v, want text and 12-char hash", id, p)
+		}
+	}
+}
+
+func TestLoadDirLatestVersion(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, OCR), 0755); err != nil {
+		t.Fatal(err)
+	}
+	for _, v := range []string{"v2", "v10"} {
+		if err := os.WriteFile(filepath.Join(dir, OCR, v+".txt"), []byte("prompt "+v+"\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	lib, err := LoadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p, err := lib.Get(OCR, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Version != "v10" || p.Text != "prompt v10" {
+		t.Errorf("latest = %s %q, want v10 %q", p.Version, p.Text, "prompt v10")
+	}
+
+	sel := &Selection{Library: lib, Pins: map[string]string{OCR: "v1"}}
+	if p, _ := sel.Get(OCR); p.Version != "v1" {
+		t.Errorf("pinned version = %s, want v1", p.Version)
+	}
+
+	if _, err := lib.Get(OCR, "v3"); err == nil {
+		t.Error("expected error for missing version")
+	}
+}
+
+func TestParsePins(t *testing.T) {
+	pins, err := ParsePins([]string{"ocr=v1", " metadata_extraction = v2"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pins[OCR] != "v1" || pins[MetadataExtraction] != "v2" {
+		t.Errorf("ParsePins() = %v", pins)
+	}
+	if _, err := ParsePins([]string{"ocr"}); err == nil {
+		t.Error("expected error for pin without version")
+	}
+}
+
+func TestGetFor(t *testing.T) {
+	sel := &Selection{Library: Builtin()}
+	for material, want := range map[string]string{
+		"":         MetadataExtraction,
+		"book":     MetadataExtraction,
+		"map":      MetadataExtraction + "_map",
+		"score":    MetadataExtraction + "_score",
+		"computer": MetadataExtraction, // No variant: falls back to the book prompt
+	} {
+		p, err := sel.GetFor(MetadataExtraction, material)
+		if err != nil {
+			t.Fatalf("GetFor(%q) error: %v", material, err)
+		}
+		if p.ID != want {
+			t.Errorf("GetFor(%q) = %s, want %s", material, p.ID, want)
+		}
+	}
+}
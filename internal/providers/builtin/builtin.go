@@ -0,0 +1,30 @@
+// Package builtin registers the built-in LLM providers; import it for its side effects
+package builtin
+
+import (
+	"github.com/lehigh-university-libraries/cataloger/internal/azure"
+	"github.com/lehigh-university-libraries/cataloger/internal/claude"
+	"github.com/lehigh-university-libraries/cataloger/internal/gemini"
+	"github.com/lehigh-university-libraries/cataloger/internal/groq"
+	"github.com/lehigh-university-libraries/cataloger/internal/huggingface"
+	"github.com/lehigh-university-libraries/cataloger/internal/llamacpp"
+	"github.com/lehigh-university-libraries/cataloger/internal/mistral"
+	"github.com/lehigh-university-libraries/cataloger/internal/mock"
+	"github.com/lehigh-university-libraries/cataloger/internal/ollama"
+	"github.com/lehigh-university-libraries/cataloger/internal/openai"
+	"github.com/lehigh-university-libraries/cataloger/internal/providers"
+)
+
+// Registered in the order clients list them
+func init() {
+	providers.Register(ollama.Registration)
+	providers.Register(openai.Registration)
+	providers.Register(azure.Registration)
+	providers.Register(gemini.Registration)
+	providers.Register(claude.Registration)
+	providers.Register(groq.Registration)
+	providers.Register(huggingface.Registration)
+	providers.Register(llamacpp.Registration)
+	providers.Register(mistral.Registration)
+	providers.Register(mock.Registration)
+}
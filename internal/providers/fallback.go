@@ -0,0 +1,206 @@
+package providers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Link is one provider of a fallback chain
+type Link struct {
+	Name  string
+	Model string // Model to ask a fallback for; its default model when empty
+}
+
+// String returns the link as "provider/model", or the provider's name without a model
+func (l Link) String() string {
+	if l.Model == "" {
+		return l.Name
+	}
+	return l.Name + "/" + l.Model
+}
+
+// FallbackFromEnv reads PROVIDER_FALLBACK, the order providers are tried in when one fails:
+// comma-separated provider names, each optionally with a model, e.g.
+// "ollama,openai/gpt-4o-mini,gemini"
+func FallbackFromEnv() []Link {
+	var links []Link
+	for _, entry := range strings.Split(os.Getenv("PROVIDER_FALLBACK"), ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		name, model, _ := strings.Cut(entry, "/")
+		links = append(links, Link{Name: strings.TrimSpace(name), Model: strings.TrimSpace(model)})
+	}
+	return links
+}
+
+// Chain sends each request to its first provider and, when that times out or fails, to the
+// next, and so on. A refusal or the caller's context ending stops the chain: another provider
+// would refuse the same prompt, and nobody is waiting for the answer. The first provider is
+// asked for the request's model, fallbacks for their own.
+type Chain struct {
+	links   []chainLink
+	timeout time.Duration
+}
+
+type chainLink struct {
+	Link
+	provider Provider
+	vision   bool
+}
+
+// NewChain returns the named provider followed by its fallbacks: the providers after it in
+// PROVIDER_FALLBACK that are configured. A provider not in PROVIDER_FALLBACK has none. Each
+// attempt is bounded by timeout (0 for none), so a provider that hangs leaves the others time.
+func NewChain(name string, timeout time.Duration) (*Chain, error) {
+	return newChain(name, FallbackFromEnv(), timeout)
+}
+
+func newChain(name string, order []Link, timeout time.Duration) (*Chain, error) {
+	first, err := New(name)
+	if err != nil {
+		return nil, err
+	}
+	reg, _ := Lookup(name)
+	c := &Chain{links: []chainLink{{Link: Link{Name: name}, provider: first, vision: reg.Vision}}, timeout: timeout}
+
+	start := slices.IndexFunc(order, func(l Link) bool { return l.Name == name })
+	if start < 0 {
+		return c, nil
+	}
+	for _, l := range order[start+1:] {
+		reg, ok := Lookup(l.Name)
+		if !ok {
+			slog.Warn("Ignoring unknown provider in PROVIDER_FALLBACK", "provider", l.Name)
+			continue
+		}
+		if reg.Configured != nil && !reg.Configured() {
+			slog.Debug("Skipping fallback provider that is not configured", "provider", l.Name)
+			continue
+		}
+		if l.Model == "" {
+			l.Model = DefaultModel(l.Name)
+		}
+		c.links = append(c.links, chainLink{Link: l, provider: newMetered(reg), vision: reg.Vision})
+	}
+	return c, nil
+}
+
+// Links returns the chain's providers in the order they are tried
+func (c *Chain) Links() []Link {
+	links := make([]Link, len(c.links))
+	for i, l := range c.links {
+		links[i] = l.Link
+	}
+	return links
+}
+
+// ExtractText sends a text prompt down the chain
+func (c *Chain) ExtractText(ctx context.Context, config Config) (string, error) {
+	return c.try(ctx, config, false, func(ctx context.Context, p Provider, config Config) (string, error) {
+		return p.ExtractText(ctx, config)
+	})
+}
+
+// GenerateFromImage sends an image with the prompt down the chain, skipping fallbacks
+// without vision
+func (c *Chain) GenerateFromImage(ctx context.Context, config Config, image []byte) (string, error) {
+	return c.try(ctx, config, true, func(ctx context.Context, p Provider, config Config) (string, error) {
+		return p.GenerateFromImage(ctx, config, image)
+	})
+}
+
+// GenerateFromImages sends several images with the prompt down the chain, skipping
+// fallbacks without vision
+func (c *Chain) GenerateFromImages(ctx context.Context, config Config, images [][]byte) (string, error) {
+	return c.try(ctx, config, true, func(ctx context.Context, p Provider, config Config) (string, error) {
+		return GenerateFromImages(ctx, p, config, images)
+	})
+}
+
+// StreamText streams a text prompt's response down the chain. A fallback streams its
+// response after whatever the failed provider sent.
+func (c *Chain) StreamText(ctx context.Context, config Config, onChunk func(string)) (string, error) {
+	return c.try(ctx, config, false, func(ctx context.Context, p Provider, config Config) (string, error) {
+		return Stream(ctx, p, config, onChunk)
+	})
+}
+
+// try makes the request with each provider in turn until one answers. With a single provider
+// its error is returned as is; otherwise the errors of all the providers tried are joined.
+func (c *Chain) try(ctx context.Context, config Config, vision bool, call func(context.Context, Provider, Config) (string, error)) (string, error) {
+	var (
+		errs   []error
+		failed string
+	)
+	for i, l := range c.links {
+		if i > 0 {
+			if vision && !l.vision {
+				continue
+			}
+			config.Model = l.Model
+			slog.Warn("Provider failed, falling back", "failed", failed, "provider", l.Name, "model", l.Model, "error", errs[len(errs)-1])
+		}
+
+		attemptCtx, cancel := WithTimeout(ctx, c.timeout)
+		text, err := call(attemptCtx, l.provider, config)
+		cancel()
+		if err == nil {
+			if i > 0 {
+				recordFallback(ctx, Link{Name: l.Name, Model: config.Model})
+			}
+			return text, nil
+		}
+
+		var refusal *RefusalError
+		if len(c.links) == 1 || errors.As(err, &refusal) || ctx.Err() != nil {
+			return text, err
+		}
+		errs = append(errs, fmt.Errorf("%s: %w", l.Name, err))
+		failed = l.Name
+	}
+	return "", errors.Join(errs...)
+}
+
+// Served collects the fallbacks that answered requests made with a context from WithServed,
+// so callers can record when a provider other than the one they asked for produced output
+type Served struct {
+	mu        sync.Mutex
+	fallbacks []string
+}
+
+type servedKey struct{}
+
+// WithServed returns a context in which chains record their fallbacks' answers in served
+func WithServed(ctx context.Context) (context.Context, *Served) {
+	served := &Served{}
+	return context.WithValue(ctx, servedKey{}, served), served
+}
+
+// Fallbacks returns the fallbacks that answered, as "provider/model", in the order they first
+// did
+func (s *Served) Fallbacks() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return slices.Clone(s.fallbacks)
+}
+
+func recordFallback(ctx context.Context, l Link) {
+	served, ok := ctx.Value(servedKey{}).(*Served)
+	if !ok {
+		return
+	}
+	served.mu.Lock()
+	defer served.mu.Unlock()
+	if !slices.Contains(served.fallbacks, l.String()) {
+		served.fallbacks = append(served.fallbacks, l.String())
+	}
+}
@@ -0,0 +1,123 @@
+package providers
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// scripted answers with its model name, fails with err, or hangs until its context ends
+type scripted struct {
+	err  error
+	hang bool
+}
+
+func (s scripted) ExtractText(ctx context.Context, config Config) (string, error) {
+	if s.hang {
+		<-ctx.Done()
+		return "", ctx.Err()
+	}
+	if s.err != nil {
+		return "", s.err
+	}
+	return config.Model, nil
+}
+
+func (s scripted) GenerateFromImage(ctx context.Context, config Config, image []byte) (string, error) {
+	return s.ExtractText(ctx, config)
+}
+
+func init() {
+	for name, p := range map[string]scripted{
+		"fallback-down":    {err: errors.New("connection refused")},
+		"fallback-hang":    {hang: true},
+		"fallback-refuses": {err: &RefusalError{Category: "safety"}},
+		"fallback-up":      {},
+		"fallback-blind":   {},
+	} {
+		Register(Registration{Name: name, New: func() Provider { return p }, Vision: name != "fallback-blind", DefaultModel: func() string { return name + "-model" }})
+	}
+	Register(Registration{Name: "fallback-unset", New: func() Provider { return scripted{} }, Configured: func() bool { return false }})
+}
+
+func TestFallbackFromEnv(t *testing.T) {
+	t.Setenv("PROVIDER_FALLBACK", " ollama, openai/gpt-4o-mini ,,gemini")
+	want := []Link{{Name: "ollama"}, {Name: "openai", Model: "gpt-4o-mini"}, {Name: "gemini"}}
+	if got := FallbackFromEnv(); !slices.Equal(got, want) {
+		t.Errorf("FallbackFromEnv() = %v, want %v", got, want)
+	}
+}
+
+func TestChain(t *testing.T) {
+	order := []Link{{Name: "fallback-hang"}, {Name: "fallback-down"}, {Name: "fallback-unset"}, {Name: "unknown"}, {Name: "fallback-blind"}, {Name: "fallback-up", Model: "big"}}
+
+	c, err := newChain("fallback-hang", order, 10*time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, l := range c.Links() {
+		names = append(names, l.String())
+	}
+	if want := []string{"fallback-hang", "fallback-down/fallback-down-model", "fallback-blind/fallback-blind-model", "fallback-up/big"}; !slices.Equal(names, want) {
+		t.Errorf("Links() = %v, want %v", names, want)
+	}
+
+	// A timeout and an error fall through to the first provider that answers, with its model
+	ctx, served := WithServed(context.Background())
+	text, err := c.ExtractText(ctx, Config{Model: "small"})
+	if err != nil || text != "fallback-blind-model" {
+		t.Errorf("ExtractText() = %q, %v", text, err)
+	}
+	// Providers without vision are skipped for images
+	text, err = c.GenerateFromImage(ctx, Config{Model: "small"}, []byte("page"))
+	if err != nil || text != "big" {
+		t.Errorf("GenerateFromImage() = %q, %v", text, err)
+	}
+	if got, want := served.Fallbacks(), []string{"fallback-blind/fallback-blind-model", "fallback-up/big"}; !slices.Equal(got, want) {
+		t.Errorf("Fallbacks() = %v, want %v", got, want)
+	}
+
+	// The first provider answering records no fallback and keeps the request's model
+	c, _ = newChain("fallback-up", order, 0)
+	ctx, served = WithServed(context.Background())
+	if text, err := c.ExtractText(ctx, Config{Model: "small"}); err != nil || text != "small" {
+		t.Errorf("ExtractText() = %q, %v", text, err)
+	}
+	if got := served.Fallbacks(); len(got) != 0 {
+		t.Errorf("Fallbacks() = %v, want none", got)
+	}
+}
+
+func TestChainStops(t *testing.T) {
+	order := []Link{{Name: "fallback-refuses"}, {Name: "fallback-down"}, {Name: "fallback-up"}}
+
+	// A refusal is returned without trying the fallbacks
+	c, _ := newChain("fallback-refuses", order, 0)
+	var refusal *RefusalError
+	if _, err := c.ExtractText(context.Background(), Config{}); !errors.As(err, &refusal) {
+		t.Errorf("err = %v, want the refusal", err)
+	}
+
+	// So is the caller's context ending
+	c, _ = newChain("fallback-down", order, 0)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := c.ExtractText(ctx, Config{}); err == nil || strings.Contains(err.Error(), "fallback-up") {
+		t.Errorf("err = %v, want the first provider's error", err)
+	}
+
+	// Without fallbacks the provider's error is returned as is; with them, every error is
+	c, _ = newChain("fallback-down", nil, 0)
+	if _, err := c.ExtractText(context.Background(), Config{}); err == nil || err.Error() != "connection refused" {
+		t.Errorf("err = %v, want the provider's error", err)
+	}
+	c, _ = newChain("fallback-down", []Link{{Name: "fallback-down"}, {Name: "fallback-hang"}}, time.Millisecond)
+	_, err := c.ExtractText(context.Background(), Config{})
+	if !errors.Is(err, context.DeadlineExceeded) || !strings.Contains(err.Error(), "fallback-down: connection refused") {
+		t.Errorf("err = %v, want both providers' errors", err)
+	}
+}
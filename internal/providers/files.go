@@ -0,0 +1,98 @@
+package providers
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// FileUploadsEnabled reports whether PROVIDER_FILE_UPLOADS asks providers with a files API
+// to upload each image once and refer to it by ID, instead of sending it inline with every
+// request. Uploads are kept on the provider's side, so this is opt-in.
+func FileUploadsEnabled() bool {
+	enabled, _ := strconv.ParseBool(os.Getenv("PROVIDER_FILE_UPLOADS"))
+	return enabled
+}
+
+// ImageKey identifies an image uploaded to a provider's endpoint by its content, so the same
+// image sent again (a retry, another model of a matrix run) reuses the upload
+func ImageKey(endpoint string, image []byte) string {
+	sum := sha256.Sum256(image)
+	return endpoint + "|" + hex.EncodeToString(sum[:])
+}
+
+// FileCache remembers the IDs of uploaded files. Concurrent requests for a file being
+// uploaded wait for the one upload.
+type FileCache struct {
+	mu      sync.Mutex
+	entries map[string]fileEntry
+	pending map[string]*pendingUpload
+}
+
+type fileEntry struct {
+	id      string
+	expires time.Time // Zero when the provider keeps files until deleted
+}
+
+type pendingUpload struct {
+	done chan struct{}
+	id   string
+	err  error
+}
+
+// Files is the process-wide cache of uploaded files
+var Files = NewFileCache()
+
+// NewFileCache returns an empty cache
+func NewFileCache() *FileCache {
+	return &FileCache{entries: make(map[string]fileEntry), pending: make(map[string]*pendingUpload)}
+}
+
+// Get returns the ID of the file under key, calling upload when there is none or it expired.
+// ttl is how long the provider keeps an upload (0 for until deleted); the ID is reused for a
+// little less, so a request doesn't refer to a file about to expire.
+func (c *FileCache) Get(ctx context.Context, key string, ttl time.Duration, upload func(context.Context) (string, error)) (string, error) {
+	c.mu.Lock()
+	if e, ok := c.entries[key]; ok && (e.expires.IsZero() || time.Now().Before(e.expires)) {
+		c.mu.Unlock()
+		return e.id, nil
+	}
+	if p, ok := c.pending[key]; ok {
+		c.mu.Unlock()
+		select {
+		case <-p.done:
+			return p.id, p.err
+		case <-ctx.Done():
+			return "", ctx.Err()
+		}
+	}
+	p := &pendingUpload{done: make(chan struct{})}
+	c.pending[key] = p
+	c.mu.Unlock()
+
+	p.id, p.err = upload(ctx)
+
+	c.mu.Lock()
+	delete(c.pending, key)
+	if p.err == nil {
+		e := fileEntry{id: p.id}
+		if ttl > 0 {
+			e.expires = time.Now().Add(ttl * 9 / 10)
+		}
+		c.entries[key] = e
+	}
+	c.mu.Unlock()
+	close(p.done)
+	return p.id, p.err
+}
+
+// Forget drops the file under key, after the provider reports it gone
+func (c *FileCache) Forget(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.entries, key)
+}
@@ -0,0 +1,52 @@
+package providers
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestFileCache(t *testing.T) {
+	c := NewFileCache()
+	var uploads atomic.Int32
+	upload := func(context.Context) (string, error) {
+		uploads.Add(1)
+		time.Sleep(5 * time.Millisecond)
+		return "file-1", nil
+	}
+
+	key := ImageKey("claude", []byte("image"))
+	if key == ImageKey("claude", []byte("other image")) || key == ImageKey("gemini", []byte("image")) {
+		t.Fatal("keys of different images or endpoints collide")
+	}
+
+	var wg sync.WaitGroup
+	for range 5 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if id, err := c.Get(context.Background(), key, 0, upload); err != nil || id != "file-1" {
+				t.Errorf("Get() = %q, %v", id, err)
+			}
+		}()
+	}
+	wg.Wait()
+	if n := uploads.Load(); n != 1 {
+		t.Errorf("%d uploads for concurrent requests, want 1", n)
+	}
+
+	c.Forget(key)
+	_, _ = c.Get(context.Background(), key, 0, upload)
+	if n := uploads.Load(); n != 2 {
+		t.Errorf("a forgotten file was not uploaded again")
+	}
+
+	expiring := ImageKey("gemini", []byte("image"))
+	_, _ = c.Get(context.Background(), expiring, time.Nanosecond, upload)
+	_, _ = c.Get(context.Background(), expiring, time.Nanosecond, upload)
+	if n := uploads.Load(); n != 4 {
+		t.Errorf("an expired file was reused")
+	}
+}
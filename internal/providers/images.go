@@ -0,0 +1,46 @@
+package providers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/lehigh-university-libraries/cataloger/internal/usage"
+)
+
+// MultiImageProvider is implemented by providers that can send several images in one request
+type MultiImageProvider interface {
+	// GenerateFromImages sends the images, in order, with the prompt
+	GenerateFromImages(ctx context.Context, config Config, images [][]byte) (string, error)
+}
+
+// ErrSingleImage is returned by GenerateFromImages for a provider that takes one image per
+// request
+var ErrSingleImage = errors.New("provider takes one image per request")
+
+// GenerateFromImages sends several images with the prompt in one request. A single image goes
+// to GenerateFromImage, so every provider can take one.
+func GenerateFromImages(ctx context.Context, p Provider, config Config, images [][]byte) (string, error) {
+	if m, ok := p.(MultiImageProvider); ok {
+		return m.GenerateFromImages(ctx, config, images)
+	}
+	if len(images) == 1 {
+		return p.GenerateFromImage(ctx, config, images[0])
+	}
+	return "", fmt.Errorf("%w: cannot send %d images", ErrSingleImage, len(images))
+}
+
+// GenerateFromImages sends the images through the wrapped provider, when it can take them
+func (m metered) GenerateFromImages(ctx context.Context, config Config, images [][]byte) (string, error) {
+	if err := m.limiter.wait(ctx, estimateTokens(config.Prompt, len(images))); err != nil {
+		return "", err
+	}
+	text, err := GenerateFromImages(ctx, m.Provider, config, images)
+	m.limiter.spend(estimateTokens(text, 0))
+	sent := len(config.Prompt)
+	for _, image := range images {
+		sent += len(image)
+	}
+	usage.AddLLMCall(int64(sent), int64(len(text)))
+	return text, err
+}
@@ -0,0 +1,31 @@
+package providers
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+)
+
+type multiImageEcho struct{ echo }
+
+func (multiImageEcho) GenerateFromImages(ctx context.Context, config Config, images [][]byte) (string, error) {
+	return string(bytes.Join(images, []byte("+"))), nil
+}
+
+func TestGenerateFromImages(t *testing.T) {
+	images := [][]byte{[]byte("title"), []byte("verso")}
+	for _, p := range []Provider{multiImageEcho{}, metered{Provider: multiImageEcho{}}} {
+		if text, err := GenerateFromImages(context.Background(), p, Config{}, images); err != nil || text != "title+verso" {
+			t.Errorf("%T: GenerateFromImages() = %q, %v", p, text, err)
+		}
+	}
+
+	// A provider taking one image at a time takes one, but not several
+	if text, err := GenerateFromImages(context.Background(), metered{Provider: echo{}}, Config{}, images[:1]); err != nil || text != "title" {
+		t.Errorf("one image: GenerateFromImages() = %q, %v", text, err)
+	}
+	if _, err := GenerateFromImages(context.Background(), metered{Provider: echo{}}, Config{}, images); !errors.Is(err, ErrSingleImage) {
+		t.Errorf("several images: err = %v, want ErrSingleImage", err)
+	}
+}
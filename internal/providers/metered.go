@@ -0,0 +1,39 @@
+package providers
+
+import (
+	"context"
+
+	"github.com/lehigh-university-libraries/cataloger/internal/usage"
+)
+
+// metered counts the size of a provider's requests and responses in the run's usage, and
+// holds requests to the provider's rate limits (PROVIDER_RATE_LIMITS)
+type metered struct {
+	Provider
+	limiter *rateLimiter // nil for no limits
+}
+
+// newMetered wraps a registered provider
+func newMetered(r Registration) metered {
+	return metered{Provider: r.New(), limiter: limiterFor(r.Name)}
+}
+
+func (m metered) ExtractText(ctx context.Context, config Config) (string, error) {
+	if err := m.limiter.wait(ctx, estimateTokens(config.Prompt, 0)); err != nil {
+		return "", err
+	}
+	text, err := m.Provider.ExtractText(ctx, config)
+	m.limiter.spend(estimateTokens(text, 0))
+	usage.AddLLMCall(int64(len(config.Prompt)), int64(len(text)))
+	return text, err
+}
+
+func (m metered) GenerateFromImage(ctx context.Context, config Config, image []byte) (string, error) {
+	if err := m.limiter.wait(ctx, estimateTokens(config.Prompt, 1)); err != nil {
+		return "", err
+	}
+	text, err := m.Provider.GenerateFromImage(ctx, config, image)
+	m.limiter.spend(estimateTokens(text, 0))
+	usage.AddLLMCall(int64(len(config.Prompt)+len(image)), int64(len(text)))
+	return text, err
+}
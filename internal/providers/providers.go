@@ -9,9 +9,20 @@ type Config struct {
 	Model       string
 	Temperature float64
 	Prompt      string
+	// JSONMode asks the provider to constrain output to a single JSON object
+	JSONMode bool
+	// ResponseSchema optionally describes the expected JSON object as a JSON Schema
+	// (type, properties, items, required, description, enum). Providers without
+	// schema support fall back to plain JSON mode.
+	ResponseSchema map[string]any
+	// ImagePath is the file GenerateFromImage's image was read from, when there is one
+	ImagePath string
 }
 
 // Provider defines the interface for an LLM provider
 type Provider interface {
+	// ExtractText sends a text prompt and returns the model's response
 	ExtractText(ctx context.Context, config Config) (string, error)
+	// GenerateFromImage sends an image with the prompt, e.g. a title page for OCR
+	GenerateFromImage(ctx context.Context, config Config, image []byte) (string, error)
 }
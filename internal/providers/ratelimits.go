@@ -0,0 +1,155 @@
+package providers
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// RateLimitsEnvVar sets the rate limits of providers
+const RateLimitsEnvVar = "PROVIDER_RATE_LIMITS"
+
+// Token estimates for rate limiting: providers only report usage after a call, so requests
+// are charged by size before they are sent and responses after they arrive
+const (
+	charsPerToken = 4    // Roughly, for English text
+	imageTokens   = 1000 // About what a title page photo costs at OpenAI's and Claude's resolutions
+)
+
+// RateLimit caps a provider's calls across all goroutines of the process
+type RateLimit struct {
+	RequestsPerMinute int // 0 for no limit
+	TokensPerMinute   int // Estimated prompt and response tokens; 0 for no limit
+}
+
+// ParseRateLimits parses per-provider rate limits such as "openai:rpm=500,tpm=30000;gemini:rpm=15"
+func ParseRateLimits(spec string) (map[string]RateLimit, error) {
+	limits := make(map[string]RateLimit)
+	for _, entry := range strings.Split(spec, ";") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		name, settings, ok := strings.Cut(entry, ":")
+		if !ok {
+			return nil, fmt.Errorf("invalid rate limits %q: want provider:key=value,...", entry)
+		}
+		name = strings.TrimSpace(name)
+		l := limits[name]
+		for _, setting := range strings.Split(settings, ",") {
+			key, value, ok := strings.Cut(strings.TrimSpace(setting), "=")
+			if !ok {
+				return nil, fmt.Errorf("invalid setting %q for %s: want key=value", setting, name)
+			}
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 0 {
+				return nil, fmt.Errorf("invalid %s for %s: %q", key, name, value)
+			}
+			switch key {
+			case "rpm":
+				l.RequestsPerMinute = n
+			case "tpm":
+				l.TokensPerMinute = n
+			default:
+				return nil, fmt.Errorf("unknown setting %q for %s (rpm, tpm)", key, name)
+			}
+		}
+		limits[name] = l
+	}
+	return limits, nil
+}
+
+// rateLimiter holds a provider's token buckets. Requests are spaced evenly; tokens may be
+// spent a minute's worth at once.
+type rateLimiter struct {
+	provider string
+	requests *rate.Limiter // nil for no limit
+	tokens   *rate.Limiter // nil for no limit
+}
+
+var (
+	limitersOnce sync.Once
+	limitersMu   sync.Mutex
+	limiters     map[string]*rateLimiter
+)
+
+// SetRateLimits replaces the rate limits of all providers, which are read from
+// PROVIDER_RATE_LIMITS otherwise. Providers created before keep their limits.
+func SetRateLimits(limits map[string]RateLimit) {
+	limitersOnce.Do(func() {})
+	setRateLimits(limits)
+}
+
+func setRateLimits(limits map[string]RateLimit) {
+	limitersMu.Lock()
+	defer limitersMu.Unlock()
+	limiters = make(map[string]*rateLimiter, len(limits))
+	for name, l := range limits {
+		rl := &rateLimiter{provider: name}
+		if l.RequestsPerMinute > 0 {
+			rl.requests = rate.NewLimiter(rate.Limit(float64(l.RequestsPerMinute)/60), 1)
+		}
+		if l.TokensPerMinute > 0 {
+			rl.tokens = rate.NewLimiter(rate.Limit(float64(l.TokensPerMinute)/60), l.TokensPerMinute)
+		}
+		limiters[name] = rl
+	}
+}
+
+// limiterFor returns the shared limiter of a provider, or nil when it has no limits
+func limiterFor(name string) *rateLimiter {
+	limitersOnce.Do(func() {
+		limits, err := ParseRateLimits(os.Getenv(RateLimitsEnvVar))
+		if err != nil {
+			slog.Warn("Ignoring invalid "+RateLimitsEnvVar, "error", err)
+			limits = nil
+		}
+		setRateLimits(limits)
+	})
+	limitersMu.Lock()
+	defer limitersMu.Unlock()
+	return limiters[name]
+}
+
+// wait blocks until the provider may send a request of about tokens tokens. It fails without
+// waiting when ctx would end first.
+func (l *rateLimiter) wait(ctx context.Context, tokens int) error {
+	if l == nil {
+		return nil
+	}
+	start := time.Now()
+	if l.requests != nil {
+		if err := l.requests.Wait(ctx); err != nil {
+			return fmt.Errorf("request rate limit: %w", err)
+		}
+	}
+	if l.tokens != nil {
+		if err := l.tokens.WaitN(ctx, min(tokens, l.tokens.Burst())); err != nil {
+			return fmt.Errorf("token rate limit: %w", err)
+		}
+	}
+	if waited := time.Since(start); waited >= time.Second {
+		slog.Debug("Waited for provider rate limit", "provider", l.provider, "waited", waited)
+	}
+	return nil
+}
+
+// spend charges the tokens of a response, delaying later requests instead of this one
+func (l *rateLimiter) spend(tokens int) {
+	if l == nil || l.tokens == nil || tokens <= 0 {
+		return
+	}
+	l.tokens.ReserveN(time.Now(), min(tokens, l.tokens.Burst()))
+}
+
+// estimateTokens estimates the tokens of a text and images
+func estimateTokens(text string, images int) int {
+	return len(text)/charsPerToken + images*imageTokens
+}
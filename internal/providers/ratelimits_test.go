@@ -0,0 +1,69 @@
+package providers
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestParseRateLimits(t *testing.T) {
+	limits, err := ParseRateLimits(" openai:rpm=500,tpm=30000; gemini:rpm=15 ;")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := limits["openai"]; got != (RateLimit{RequestsPerMinute: 500, TokensPerMinute: 30000}) {
+		t.Errorf("openai = %+v", got)
+	}
+	if got := limits["gemini"]; got != (RateLimit{RequestsPerMinute: 15}) {
+		t.Errorf("gemini = %+v", got)
+	}
+
+	for _, spec := range []string{"openai", "openai:rpm", "openai:rpm=fast", "openai:rpm=-1", "openai:burst=5"} {
+		if _, err := ParseRateLimits(spec); err == nil {
+			t.Errorf("ParseRateLimits(%q) should fail", spec)
+		}
+	}
+}
+
+func TestRateLimits(t *testing.T) {
+	Register(Registration{Name: "rate-test", New: func() Provider { return echo{} }})
+	SetRateLimits(map[string]RateLimit{"rate-test": {RequestsPerMinute: 1200, TokensPerMinute: 600}})
+	t.Cleanup(func() { SetRateLimits(nil) })
+
+	// Requests are spaced evenly across goroutines and provider instances: 20 a second
+	start := time.Now()
+	var wg sync.WaitGroup
+	for range 3 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			p, _ := New("rate-test")
+			if _, err := p.ExtractText(context.Background(), Config{Prompt: "hi"}); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+	if elapsed := time.Since(start); elapsed < 90*time.Millisecond {
+		t.Errorf("3 requests took %s, want at least 100ms at 1200 per minute", elapsed)
+	}
+
+	// A response spends the rest of the minute's tokens, so the next request can't be sent
+	// before its deadline
+	p, _ := New("rate-test")
+	if _, err := p.ExtractText(context.Background(), Config{Prompt: strings.Repeat("token ", 400)}); err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	if _, err := p.ExtractText(ctx, Config{Prompt: "more"}); err == nil || !strings.Contains(err.Error(), "token rate limit") {
+		t.Errorf("err = %v, want the token rate limit", err)
+	}
+
+	// Other providers are not limited
+	if l := limiterFor("echo-test"); l != nil {
+		t.Errorf("limiterFor(echo-test) = %+v, want nil", l)
+	}
+}
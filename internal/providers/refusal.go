@@ -0,0 +1,26 @@
+package providers
+
+import "fmt"
+
+// Refusal categories
+const (
+	RefusalSafety        = "safety"         // A safety filter blocked the prompt or response
+	RefusalContentFilter = "content_filter" // A content policy filter stopped the response
+	RefusalRecitation    = "recitation"     // Blocked for reciting copyrighted text
+	RefusalModel         = "refusal"        // The model declined to answer
+)
+
+// RefusalError is returned when a provider refuses a request or a safety filter blocks it,
+// rather than the request failing
+type RefusalError struct {
+	Provider string
+	Category string
+	Reason   string // The provider's reason or the model's refusal message
+}
+
+func (e *RefusalError) Error() string {
+	if e.Reason == "" {
+		return fmt.Sprintf("%s refused the request (%s)", e.Provider, e.Category)
+	}
+	return fmt.Sprintf("%s refused the request (%s): %s", e.Provider, e.Category, e.Reason)
+}
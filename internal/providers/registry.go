@@ -0,0 +1,97 @@
+package providers
+
+import (
+	"fmt"
+	"os"
+	"sync"
+)
+
+// Registration describes a provider to the registry. Each provider package exports its
+// Registration, and internal/providers/builtin registers them all.
+type Registration struct {
+	Name string
+	New  func() Provider
+	// DefaultModel returns the model used when none is requested
+	DefaultModel func() string
+	// Configured reports whether the provider's credentials or endpoint are set; nil when
+	// it needs none
+	Configured func() bool
+	Vision     bool // Accepts images (title page OCR)
+}
+
+var (
+	mu       sync.RWMutex
+	registry []Registration
+)
+
+// Register adds a provider to the registry, panicking when the name is taken
+func Register(r Registration) {
+	mu.Lock()
+	defer mu.Unlock()
+	for _, existing := range registry {
+		if existing.Name == r.Name {
+			panic("providers: Register called twice for " + r.Name)
+		}
+	}
+	registry = append(registry, r)
+}
+
+// Lookup returns a registered provider's registration
+func Lookup(name string) (Registration, bool) {
+	mu.RLock()
+	defer mu.RUnlock()
+	for _, r := range registry {
+		if r.Name == name {
+			return r, true
+		}
+	}
+	return Registration{}, false
+}
+
+// New returns a new instance of a registered provider
+func New(name string) (Provider, error) {
+	r, ok := Lookup(name)
+	if !ok {
+		return nil, fmt.Errorf("unsupported LLM provider: %s", name)
+	}
+	return newMetered(r), nil
+}
+
+// Registered returns the registered providers in registration order
+func Registered() []Registration {
+	mu.RLock()
+	defer mu.RUnlock()
+	return append([]Registration(nil), registry...)
+}
+
+// DefaultModel returns a provider's default model, or "" for unknown providers
+func DefaultModel(name string) string {
+	r, ok := Lookup(name)
+	if !ok || r.DefaultModel == nil {
+		return ""
+	}
+	return r.DefaultModel()
+}
+
+// Default returns the provider used when none is requested: CATALOGING_PROVIDER, or ollama
+func Default() string {
+	if name := os.Getenv("CATALOGING_PROVIDER"); name != "" {
+		return name
+	}
+	return "ollama"
+}
+
+// EnvModel returns a DefaultModel reading an environment variable, with a fallback
+func EnvModel(env, fallback string) func() string {
+	return func() string {
+		if model := os.Getenv(env); model != "" {
+			return model
+		}
+		return fallback
+	}
+}
+
+// EnvSet returns a Configured check that the environment variable is set
+func EnvSet(env string) func() bool {
+	return func() bool { return os.Getenv(env) != "" }
+}
@@ -0,0 +1,46 @@
+package providers
+
+import (
+	"context"
+	"testing"
+)
+
+type echo struct{}
+
+func (echo) ExtractText(ctx context.Context, config Config) (string, error) {
+	return config.Prompt, nil
+}
+
+func (echo) GenerateFromImage(ctx context.Context, config Config, image []byte) (string, error) {
+	return string(image), nil
+}
+
+func TestRegistry(t *testing.T) {
+	t.Setenv("ECHO_MODEL", "")
+	Register(Registration{Name: "echo-test", New: func() Provider { return echo{} }, DefaultModel: EnvModel("ECHO_MODEL", "echo-1")})
+
+	p, err := New("echo-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if text, _ := p.ExtractText(context.Background(), Config{Prompt: "hi"}); text != "hi" {
+		t.Errorf("ExtractText() = %q", text)
+	}
+	if got := DefaultModel("echo-test"); got != "echo-1" {
+		t.Errorf("DefaultModel() = %q, want echo-1", got)
+	}
+	t.Setenv("ECHO_MODEL", "echo-2")
+	if got := DefaultModel("echo-test"); got != "echo-2" {
+		t.Errorf("DefaultModel() = %q, want the environment's model", got)
+	}
+
+	if _, err := New("nonexistent"); err == nil {
+		t.Error("expected an error for an unregistered provider")
+	}
+	defer func() {
+		if recover() == nil {
+			t.Error("registering a name twice should panic")
+		}
+	}()
+	Register(Registration{Name: "echo-test"})
+}
@@ -0,0 +1,177 @@
+package providers
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/lehigh-university-libraries/cataloger/internal/usage"
+)
+
+// Defaults for RetryPolicy
+const (
+	DefaultMaxRetries     = 3
+	DefaultRetryBaseDelay = time.Second
+	DefaultRetryMaxDelay  = 30 * time.Second
+)
+
+// RetryPolicy retries transient provider failures with jittered exponential backoff
+type RetryPolicy struct {
+	MaxRetries int           // Retries after the first attempt; 0 disables retrying
+	BaseDelay  time.Duration // Backoff ceiling of the first retry, doubled for each one after
+	MaxDelay   time.Duration // Highest backoff ceiling
+}
+
+// RetryPolicyFromEnv reads PROVIDER_MAX_RETRIES, PROVIDER_RETRY_BASE_DELAY and
+// PROVIDER_RETRY_MAX_DELAY, falling back to the defaults for unset or invalid values
+func RetryPolicyFromEnv() RetryPolicy {
+	p := RetryPolicy{MaxRetries: DefaultMaxRetries, BaseDelay: DefaultRetryBaseDelay, MaxDelay: DefaultRetryMaxDelay}
+	if v := os.Getenv("PROVIDER_MAX_RETRIES"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n >= 0 {
+			p.MaxRetries = n
+		} else {
+			slog.Warn("Ignoring invalid PROVIDER_MAX_RETRIES", "value", v)
+		}
+	}
+	for env, d := range map[string]*time.Duration{"PROVIDER_RETRY_BASE_DELAY": &p.BaseDelay, "PROVIDER_RETRY_MAX_DELAY": &p.MaxDelay} {
+		if v := os.Getenv(env); v != "" {
+			if parsed, err := time.ParseDuration(v); err == nil && parsed > 0 {
+				*d = parsed
+			} else {
+				slog.Warn("Ignoring invalid retry delay", "env", env, "value", v)
+			}
+		}
+	}
+	return p
+}
+
+// Backoff is the wait before retry number attempt (from 0): a random duration up to
+// BaseDelay*2^attempt, capped at MaxDelay ("full jitter"), so parallel workers hitting the
+// same rate limit don't retry in lockstep. A server's Retry-After wins when it is longer.
+func (p RetryPolicy) Backoff(attempt int, retryAfter time.Duration) time.Duration {
+	ceiling := p.MaxDelay
+	if attempt < 30 && p.BaseDelay<<attempt < p.MaxDelay {
+		ceiling = p.BaseDelay << attempt
+	}
+	d := time.Duration(0)
+	if ceiling > 0 {
+		d = rand.N(ceiling + 1)
+	}
+	return max(d, retryAfter)
+}
+
+// Do calls fn until it succeeds, fails with an error retryable rejects, runs out of retries,
+// or ctx is done
+func (p RetryPolicy) Do(ctx context.Context, retryable func(error) bool, fn func() error) error {
+	for attempt := 0; ; attempt++ {
+		err := fn()
+		if err == nil || attempt >= p.MaxRetries || ctx.Err() != nil || !retryable(err) {
+			return err
+		}
+		d := p.Backoff(attempt, 0)
+		slog.Warn("Retrying provider request", "attempt", attempt+1, "delay", d, "error", err)
+		if !sleep(ctx, d) {
+			return err
+		}
+	}
+}
+
+// RetryTransport is an http.RoundTripper that retries 429, 500, 502, 503 and 504 responses
+// and timeouts, honoring Retry-After. Requests with a body are only retried when the body
+// can be replayed (GetBody is set, as it is for bytes and string readers).
+type RetryTransport struct {
+	Base   http.RoundTripper // http.DefaultTransport when nil
+	Policy RetryPolicy
+}
+
+// HTTPClient returns a client for provider APIs that retries transient failures under the
+// policy in the environment
+func HTTPClient() *http.Client {
+	return &http.Client{Transport: &RetryTransport{Base: &usage.CountingTransport{}, Policy: RetryPolicyFromEnv()}}
+}
+
+// RoundTrip sends the request, retrying transient failures
+func (t *RetryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	ctx := req.Context()
+	for attempt := 0; ; attempt++ {
+		resp, err := base.RoundTrip(req)
+		retryAfter, retry := retryable(resp, err)
+		if !retry || attempt >= t.Policy.MaxRetries || ctx.Err() != nil || (req.Body != nil && req.GetBody == nil) {
+			return resp, err
+		}
+
+		d := t.Policy.Backoff(attempt, retryAfter)
+		if resp != nil {
+			slog.Warn("Retrying provider request", "url", req.URL.Redacted(), "status", resp.StatusCode, "attempt", attempt+1, "delay", d)
+			// Drain so the connection can be reused
+			_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+			resp.Body.Close()
+		} else {
+			slog.Warn("Retrying provider request", "url", req.URL.Redacted(), "error", err, "attempt", attempt+1, "delay", d)
+		}
+		if !sleep(ctx, d) {
+			return nil, ctx.Err()
+		}
+
+		if req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			req = req.Clone(ctx)
+			req.Body = body
+		}
+	}
+}
+
+// retryable reports whether a response or error is transient, with the response's
+// Retry-After when it has one
+func retryable(resp *http.Response, err error) (time.Duration, bool) {
+	if err != nil {
+		var netErr net.Error
+		return 0, errors.As(err, &netErr) && netErr.Timeout()
+	}
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests, http.StatusInternalServerError, http.StatusBadGateway,
+		http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return parseRetryAfter(resp.Header.Get("Retry-After")), true
+	}
+	return 0, false
+}
+
+// parseRetryAfter reads a Retry-After header in seconds or as an HTTP date
+func parseRetryAfter(v string) time.Duration {
+	if v == "" {
+		return 0
+	}
+	if secs, err := strconv.Atoi(v); err == nil && secs > 0 {
+		return time.Duration(secs) * time.Second
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		return max(time.Until(t), 0)
+	}
+	return 0
+}
+
+// sleep waits for d, reporting false when ctx is done first
+func sleep(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
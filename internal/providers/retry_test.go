@@ -0,0 +1,110 @@
+package providers
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRetryTransport(t *testing.T) {
+	var calls int
+	var bodies []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		body, _ := io.ReadAll(r.Body)
+		bodies = append(bodies, string(body))
+		switch calls {
+		case 1:
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+		case 2:
+			w.WriteHeader(http.StatusServiceUnavailable)
+		default:
+			_, _ = w.Write([]byte("ok"))
+		}
+	}))
+	defer srv.Close()
+
+	client := &http.Client{Transport: &RetryTransport{Policy: RetryPolicy{MaxRetries: 3, BaseDelay: time.Millisecond, MaxDelay: time.Millisecond}}}
+	req, _ := http.NewRequest("POST", srv.URL, strings.NewReader("prompt"))
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || calls != 3 {
+		t.Fatalf("status %d after %d calls, want 200 after 3", resp.StatusCode, calls)
+	}
+	for _, b := range bodies {
+		if b != "prompt" {
+			t.Errorf("replayed body = %q", b)
+		}
+	}
+}
+
+func TestRetryTransportGivesUp(t *testing.T) {
+	var calls int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if r.URL.Path == "/bad" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+
+	client := &http.Client{Transport: &RetryTransport{Policy: RetryPolicy{MaxRetries: 2, BaseDelay: time.Millisecond, MaxDelay: time.Millisecond}}}
+	resp, err := client.Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusInternalServerError || calls != 3 {
+		t.Errorf("status %d after %d calls, want 500 after 3", resp.StatusCode, calls)
+	}
+
+	calls = 0
+	resp, err = client.Get(srv.URL + "/bad")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if calls != 1 {
+		t.Errorf("400 was sent %d times, want no retry", calls)
+	}
+}
+
+func TestRetryPolicy(t *testing.T) {
+	p := RetryPolicy{MaxRetries: 5, BaseDelay: time.Second, MaxDelay: 4 * time.Second}
+	for attempt := range 10 {
+		if d := p.Backoff(attempt, 0); d < 0 || d > 4*time.Second {
+			t.Errorf("backoff %d = %s, want within the 4s cap", attempt, d)
+		}
+	}
+	if d := p.Backoff(0, time.Minute); d != time.Minute {
+		t.Errorf("backoff with Retry-After = %s, want 1m", d)
+	}
+	if d := parseRetryAfter("7"); d != 7*time.Second {
+		t.Errorf("parseRetryAfter = %s", d)
+	}
+
+	p.BaseDelay, p.MaxDelay = time.Millisecond, time.Millisecond
+	transient := errors.New("unavailable")
+	var calls int
+	err := p.Do(context.Background(), func(err error) bool { return err == transient }, func() error {
+		calls++
+		if calls < 3 {
+			return transient
+		}
+		return nil
+	})
+	if err != nil || calls != 3 {
+		t.Errorf("Do = %v after %d calls", err, calls)
+	}
+}
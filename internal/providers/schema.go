@@ -0,0 +1,49 @@
+package providers
+
+import (
+	"reflect"
+	"strings"
+)
+
+// SchemaFor returns the JSON Schema of the JSON encoding of v, a struct, for use as a
+// Config.ResponseSchema. Properties are named by their json tags. Objects list every property
+// as required and allow no others, as OpenAI's strict structured outputs need, so a model
+// answers a field it can't fill with an empty value. Strings, numbers, booleans, slices,
+// nested structs and pointers to them are described; other kinds are treated as strings.
+func SchemaFor(v any) map[string]any {
+	return schemaOf(reflect.TypeOf(v))
+}
+
+func schemaOf(t reflect.Type) map[string]any {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.Struct:
+		properties := map[string]any{}
+		required := []string{}
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+			if !f.IsExported() || name == "-" {
+				continue
+			}
+			if name == "" {
+				name = f.Name
+			}
+			properties[name] = schemaOf(f.Type)
+			required = append(required, name)
+		}
+		return map[string]any{"type": "object", "properties": properties, "required": required, "additionalProperties": false}
+	case reflect.Slice, reflect.Array:
+		return map[string]any{"type": "array", "items": schemaOf(t.Elem())}
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]any{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	}
+	return map[string]any{"type": "string"}
+}
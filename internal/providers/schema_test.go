@@ -0,0 +1,42 @@
+package providers
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSchemaFor(t *testing.T) {
+	type cip struct {
+		Subjects []string `json:"subjects,omitempty"`
+	}
+	type record struct {
+		Title   string   `json:"title"`
+		ISBN    []string `json:"isbn,omitempty"`
+		Pages   int      `json:"pages"`
+		CIP     *cip     `json:"cip,omitempty"`
+		Skipped string   `json:"-"`
+		private string
+	}
+
+	want := map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"title": map[string]any{"type": "string"},
+			"isbn":  map[string]any{"type": "array", "items": map[string]any{"type": "string"}},
+			"pages": map[string]any{"type": "integer"},
+			"cip": map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"subjects": map[string]any{"type": "array", "items": map[string]any{"type": "string"}},
+				},
+				"required":             []string{"subjects"},
+				"additionalProperties": false,
+			},
+		},
+		"required":             []string{"title", "isbn", "pages", "cip"},
+		"additionalProperties": false,
+	}
+	if got := SchemaFor(record{private: ""}); !reflect.DeepEqual(got, want) {
+		t.Errorf("SchemaFor() = %v, want %v", got, want)
+	}
+}
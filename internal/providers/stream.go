@@ -0,0 +1,38 @@
+package providers
+
+import (
+	"context"
+
+	"github.com/lehigh-university-libraries/cataloger/internal/usage"
+)
+
+// Streamer is implemented by providers that can stream a response as it is generated
+type Streamer interface {
+	// StreamText is ExtractText, calling onChunk with each piece of the response as it arrives.
+	// It returns the whole response.
+	StreamText(ctx context.Context, config Config, onChunk func(string)) (string, error)
+}
+
+// Stream sends a text prompt, streaming the response to onChunk when the provider can, or
+// else passing the whole response to onChunk at once
+func Stream(ctx context.Context, p Provider, config Config, onChunk func(string)) (string, error) {
+	if s, ok := p.(Streamer); ok {
+		return s.StreamText(ctx, config, onChunk)
+	}
+	text, err := p.ExtractText(ctx, config)
+	if err == nil && text != "" {
+		onChunk(text)
+	}
+	return text, err
+}
+
+// StreamText streams the wrapped provider's response, when it can stream
+func (m metered) StreamText(ctx context.Context, config Config, onChunk func(string)) (string, error) {
+	if err := m.limiter.wait(ctx, estimateTokens(config.Prompt, 0)); err != nil {
+		return "", err
+	}
+	text, err := Stream(ctx, m.Provider, config, onChunk)
+	m.limiter.spend(estimateTokens(text, 0))
+	usage.AddLLMCall(int64(len(config.Prompt)), int64(len(text)))
+	return text, err
+}
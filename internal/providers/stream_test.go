@@ -0,0 +1,35 @@
+package providers
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+type streamingEcho struct{ echo }
+
+func (streamingEcho) StreamText(ctx context.Context, config Config, onChunk func(string)) (string, error) {
+	for _, word := range strings.SplitAfter(config.Prompt, " ") {
+		onChunk(word)
+	}
+	return config.Prompt, nil
+}
+
+func TestStream(t *testing.T) {
+	for _, p := range []Provider{echo{}, streamingEcho{}, metered{Provider: streamingEcho{}}} {
+		var chunks []string
+		text, err := Stream(context.Background(), p, Config{Prompt: "a b c"}, func(s string) { chunks = append(chunks, s) })
+		if err != nil {
+			t.Fatal(err)
+		}
+		if text != "a b c" || strings.Join(chunks, "") != text {
+			t.Errorf("%T: Stream() = %q with chunks %q", p, text, chunks)
+		}
+	}
+
+	var chunks int
+	_, _ = Stream(context.Background(), streamingEcho{}, Config{Prompt: "a b c"}, func(string) { chunks++ })
+	if chunks != 3 {
+		t.Errorf("a streaming provider sent %d chunks, want 3", chunks)
+	}
+}
@@ -0,0 +1,36 @@
+package providers
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"time"
+)
+
+// DefaultTimeout bounds each provider call when PROVIDER_TIMEOUT is unset. Local models on a
+// CPU can take minutes for a title page, so it is generous.
+const DefaultTimeout = 5 * time.Minute
+
+// TimeoutFromEnv reads PROVIDER_TIMEOUT (e.g. 90s; 0 disables the timeout), falling back to
+// DefaultTimeout when it is unset or invalid
+func TimeoutFromEnv() time.Duration {
+	v := os.Getenv("PROVIDER_TIMEOUT")
+	if v == "" {
+		return DefaultTimeout
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil || d < 0 {
+		slog.Warn("Ignoring invalid PROVIDER_TIMEOUT", "value", v)
+		return DefaultTimeout
+	}
+	return d
+}
+
+// WithTimeout bounds ctx by timeout, unless timeout is 0. An earlier deadline on ctx still
+// applies, so callers can give a single call less time.
+func WithTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, timeout)
+}
@@ -0,0 +1,32 @@
+package providers
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestTimeoutFromEnv(t *testing.T) {
+	for v, want := range map[string]time.Duration{"": DefaultTimeout, "90s": 90 * time.Second, "0": 0, "soon": DefaultTimeout, "-1s": DefaultTimeout} {
+		t.Setenv("PROVIDER_TIMEOUT", v)
+		if got := TimeoutFromEnv(); got != want {
+			t.Errorf("PROVIDER_TIMEOUT=%q: got %s, want %s", v, got, want)
+		}
+	}
+}
+
+func TestWithTimeout(t *testing.T) {
+	ctx, cancel := WithTimeout(context.Background(), 0)
+	defer cancel()
+	if _, ok := ctx.Deadline(); ok {
+		t.Error("a 0 timeout set a deadline")
+	}
+
+	parent, cancelParent := context.WithTimeout(context.Background(), time.Second)
+	defer cancelParent()
+	ctx, cancel = WithTimeout(parent, time.Hour)
+	defer cancel()
+	if d, _ := ctx.Deadline(); time.Until(d) > time.Second {
+		t.Error("the caller's earlier deadline was lost")
+	}
+}
@@ -0,0 +1,185 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// idleTimeout is how long a client's bucket is kept after its last request
+const idleTimeout = 10 * time.Minute
+
+// Limiter is a token bucket per client, where a client is an IP address (see ClientKey)
+type Limiter struct {
+	limit rate.Limit
+	burst int
+
+	mu         sync.Mutex
+	clients    map[string]*client
+	lastPruned time.Time
+}
+
+type client struct {
+	limiter *rate.Limiter
+	seen    time.Time
+}
+
+// New creates a limiter allowing perMinute requests per client, in bursts of up to burst
+func New(perMinute float64, burst int) *Limiter {
+	if burst < 1 {
+		burst = 1
+	}
+	return &Limiter{
+		limit:   rate.Limit(perMinute / 60),
+		burst:   burst,
+		clients: make(map[string]*client),
+	}
+}
+
+// Allow reports whether the client may make a request now and, if not, how long until it may
+func (l *Limiter) Allow(key string) (bool, time.Duration) {
+	return l.allowAt(key, time.Now())
+}
+
+func (l *Limiter) allowAt(key string, now time.Time) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if now.Sub(l.lastPruned) > idleTimeout {
+		for k, c := range l.clients {
+			if now.Sub(c.seen) > idleTimeout {
+				delete(l.clients, k)
+			}
+		}
+		l.lastPruned = now
+	}
+
+	c, ok := l.clients[key]
+	if !ok {
+		c = &client{limiter: rate.NewLimiter(l.limit, l.burst)}
+		l.clients[key] = c
+	}
+	c.seen = now
+
+	r := c.limiter.ReserveN(now, 1)
+	if delay := r.DelayFrom(now); delay > 0 {
+		r.CancelAt(now)
+		return false, delay
+	}
+	return true, 0
+}
+
+// Clients returns the number of clients being tracked
+func (l *Limiter) Clients() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return len(l.clients)
+}
+
+// ClientKey identifies the client of a request by its IP address. API keys the client sends
+// aren't used, as the server doesn't check them: a new key on each request would get a new
+// bucket. With trustProxy the address is the last in X-Forwarded-For, the one the reverse
+// proxy added; the ones before it are whatever the client sent.
+func ClientKey(r *http.Request, trustProxy bool) string {
+	if trustProxy {
+		if values := r.Header.Values("X-Forwarded-For"); len(values) > 0 {
+			last := values[len(values)-1]
+			if ip := strings.TrimSpace(last[strings.LastIndex(last, ",")+1:]); ip != "" {
+				return "ip:" + ip
+			}
+		}
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	return "ip:" + host
+}
+
+// ErrBusy is returned by Gate.Acquire when no slot frees up in time
+var ErrBusy = errors.New("too many concurrent generations")
+
+// Gate caps how many operations run at once. Callers wait up to Wait for a free slot.
+type Gate struct {
+	Wait time.Duration
+
+	slots chan struct{}
+
+	mu      sync.Mutex
+	average time.Duration // Moving average of how long a slot is held
+}
+
+// NewGate creates a gate allowing max concurrent operations
+func NewGate(max int, wait time.Duration) *Gate {
+	return &Gate{Wait: wait, slots: make(chan struct{}, max)}
+}
+
+// Acquire takes a slot, waiting up to Wait, and returns the function that releases it
+func (g *Gate) Acquire(ctx context.Context) (func(), error) {
+	select {
+	case g.slots <- struct{}{}:
+	default:
+		timer := time.NewTimer(g.Wait)
+		defer timer.Stop()
+		select {
+		case g.slots <- struct{}{}:
+		case <-timer.C:
+			return nil, ErrBusy
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+
+	start := time.Now()
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			g.observe(time.Since(start))
+			<-g.slots
+		})
+	}, nil
+}
+
+func (g *Gate) observe(held time.Duration) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.average == 0 {
+		g.average = held
+		return
+	}
+	g.average = (g.average*4 + held) / 5
+}
+
+// InUse returns the number of slots taken
+func (g *Gate) InUse() int {
+	return len(g.slots)
+}
+
+// Full reports whether every slot is taken, so Acquire would wait
+func (g *Gate) Full() bool {
+	return len(g.slots) == cap(g.slots)
+}
+
+// RetryAfter estimates when a slot will be free: the average time a slot is held, or 30s
+// before any operation has finished
+func (g *Gate) RetryAfter() time.Duration {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.average == 0 {
+		return 30 * time.Second
+	}
+	return g.average
+}
+
+// RetryAfterSeconds formats a delay for the Retry-After header, rounded up to whole seconds
+func RetryAfterSeconds(d time.Duration) string {
+	return strconv.Itoa(max(1, int(math.Ceil(d.Seconds()))))
+}
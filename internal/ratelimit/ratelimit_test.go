@@ -0,0 +1,113 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestLimiterAllow(t *testing.T) {
+	l := New(60, 2) // One request a second after a burst of two
+	now := time.Now()
+
+	for i := range 2 {
+		if ok, _ := l.allowAt("a", now); !ok {
+			t.Fatalf("request %d within burst was rejected", i+1)
+		}
+	}
+	ok, retryAfter := l.allowAt("a", now)
+	if ok {
+		t.Fatal("request beyond burst was allowed")
+	}
+	if retryAfter <= 0 || retryAfter > time.Second {
+		t.Errorf("retryAfter = %v, want (0, 1s]", retryAfter)
+	}
+
+	if ok, _ := l.allowAt("b", now); !ok {
+		t.Error("other client was limited")
+	}
+	if ok, _ := l.allowAt("a", now.Add(time.Second)); !ok {
+		t.Error("request after refill was rejected")
+	}
+}
+
+func TestLimiterPrunesIdleClients(t *testing.T) {
+	l := New(60, 1)
+	now := time.Now()
+	l.allowAt("a", now)
+	l.allowAt("b", now.Add(idleTimeout+time.Minute))
+	if got := l.Clients(); got != 1 {
+		t.Errorf("Clients() = %d, want 1 after pruning", got)
+	}
+}
+
+func TestClientKey(t *testing.T) {
+	tests := []struct {
+		name       string
+		headers    map[string]string
+		trustProxy bool
+		want       string
+	}{
+		{"remote address", nil, false, "ip:192.0.2.1"},
+		{"unchecked api key", map[string]string{"X-API-Key": "abc"}, false, "ip:192.0.2.1"},
+		{"unchecked bearer token", map[string]string{"Authorization": "Bearer xyz"}, false, "ip:192.0.2.1"},
+		{"untrusted proxy", map[string]string{"X-Forwarded-For": "203.0.113.9"}, false, "ip:192.0.2.1"},
+		{"trusted proxy", map[string]string{"X-Forwarded-For": "203.0.113.9"}, true, "ip:203.0.113.9"},
+		{"forged leftmost address", map[string]string{"X-Forwarded-For": "198.51.100.7, 203.0.113.9"}, true, "ip:203.0.113.9"},
+		{"empty forwarded entry", map[string]string{"X-Forwarded-For": "203.0.113.9, "}, true, "ip:192.0.2.1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("POST", "/api/sessions", nil)
+			r.RemoteAddr = "192.0.2.1:5000"
+			for k, v := range tt.headers {
+				r.Header.Set(k, v)
+			}
+			if got := ClientKey(r, tt.trustProxy); got != tt.want {
+				t.Errorf("ClientKey() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGate(t *testing.T) {
+	g := NewGate(1, 20*time.Millisecond)
+	ctx := context.Background()
+
+	release, err := g.Acquire(ctx)
+	if err != nil {
+		t.Fatalf("Acquire() error = %v", err)
+	}
+	if _, err := g.Acquire(ctx); !errors.Is(err, ErrBusy) {
+		t.Fatalf("Acquire() on a full gate error = %v, want ErrBusy", err)
+	}
+
+	go func() {
+		time.Sleep(5 * time.Millisecond)
+		release()
+	}()
+	release2, err := g.Acquire(ctx)
+	if err != nil {
+		t.Fatalf("Acquire() after release error = %v", err)
+	}
+	release2()
+	release2() // Releasing twice must not free a second slot
+	if got := g.InUse(); got != 0 {
+		t.Errorf("InUse() = %d, want 0", got)
+	}
+}
+
+func TestRetryAfterSeconds(t *testing.T) {
+	for d, want := range map[time.Duration]string{
+		0:                       "1",
+		300 * time.Millisecond:  "1",
+		1500 * time.Millisecond: "2",
+		time.Minute:             "60",
+	} {
+		if got := RetryAfterSeconds(d); got != want {
+			t.Errorf("RetryAfterSeconds(%v) = %q, want %q", d, got, want)
+		}
+	}
+}
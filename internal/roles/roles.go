@@ -0,0 +1,201 @@
+// Package roles decides what the users an authenticating proxy vouches for may do: catalogers
+// create and edit sessions, reviewers also approve or reject their records, and admins also
+// push records to the library system and choose providers
+package roles
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Role is what a user may do; each role may do everything the ones before it may
+type Role int
+
+const (
+	None      Role = iota // May only read
+	Cataloger             // May create, transcribe, generate and edit sessions
+	Reviewer              // May also approve and reject records
+	Admin                 // May also push records, import sessions and choose providers
+)
+
+var names = []string{"none", "cataloger", "reviewer", "admin"}
+
+func (r Role) String() string {
+	if r < None || int(r) >= len(names) {
+		return fmt.Sprintf("Role(%d)", int(r))
+	}
+	return names[r]
+}
+
+// Parse returns the role named s
+func Parse(s string) (Role, error) {
+	for i, name := range names {
+		if strings.EqualFold(strings.TrimSpace(s), name) {
+			return Role(i), nil
+		}
+	}
+	return None, fmt.Errorf("unknown role %q (none, cataloger, reviewer, admin)", s)
+}
+
+// UserHeader is set by the authenticating proxy to the signed-in user
+const UserHeader = "X-Remote-User"
+
+// User returns the request's UserHeader, or "" when there is none. Only a trusted proxy's
+// header names a user (see Static.User).
+func User(r *http.Request) string {
+	return r.Header.Get(UserHeader)
+}
+
+// Resolver finds the user of a request and their role. Implement it to take roles from
+// elsewhere, such as a directory.
+type Resolver interface {
+	User(r *http.Request) string
+	Role(r *http.Request) Role
+}
+
+// Config is a roles file
+//
+//	default: cataloger              # Role of users not listed; none when empty
+//	users:
+//	  jdoe: reviewer
+//	groups_header: X-Remote-Groups  # Comma-separated groups set by the proxy
+//	groups:
+//	  cataloging-leads: reviewer
+//	  library-systems: admin
+//	trusted_proxies: [10.0.4.0/24]  # Proxy addresses; loopback when empty
+type Config struct {
+	Default        string            `yaml:"default"`
+	Users          map[string]string `yaml:"users"`
+	GroupsHeader   string            `yaml:"groups_header"`
+	Groups         map[string]string `yaml:"groups"`
+	TrustedProxies []string          `yaml:"trusted_proxies"`
+}
+
+// loopback is where the proxy is trusted from when a Config lists no trusted proxies
+var loopback = []netip.Prefix{netip.MustParsePrefix("127.0.0.0/8"), netip.MustParsePrefix("::1/128")}
+
+// Static resolves roles from a Config: a user has the highest of their own role and their
+// groups' roles, or the default role when none is given. The user and groups headers are
+// only read from requests sent by a trusted proxy, as anyone reaching the server directly
+// could set them.
+type Static struct {
+	def          Role
+	users        map[string]Role
+	groupsHeader string
+	groups       map[string]Role
+	proxies      []netip.Prefix
+}
+
+// Load reads a roles file
+func Load(path string) (*Static, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read roles file: %w", err)
+	}
+	var c Config
+	if err := yaml.Unmarshal(data, &c); err != nil {
+		return nil, fmt.Errorf("failed to parse roles file %s: %w", path, err)
+	}
+	return New(c)
+}
+
+// New returns the resolver of a Config
+func New(c Config) (*Static, error) {
+	s := &Static{
+		users:        make(map[string]Role, len(c.Users)),
+		groupsHeader: c.GroupsHeader,
+		groups:       make(map[string]Role, len(c.Groups)),
+		proxies:      loopback,
+	}
+	if len(c.TrustedProxies) > 0 {
+		s.proxies = nil
+	}
+	for _, p := range c.TrustedProxies {
+		prefix, err := netip.ParsePrefix(p)
+		if err != nil {
+			addr, addrErr := netip.ParseAddr(p)
+			if addrErr != nil {
+				return nil, fmt.Errorf("trusted_proxies: invalid address or CIDR range %q", p)
+			}
+			prefix = netip.PrefixFrom(addr, addr.BitLen())
+		}
+		s.proxies = append(s.proxies, prefix)
+	}
+	var err error
+	if c.Default != "" {
+		if s.def, err = Parse(c.Default); err != nil {
+			return nil, fmt.Errorf("default: %w", err)
+		}
+	}
+	for user, name := range c.Users {
+		if s.users[user], err = Parse(name); err != nil {
+			return nil, fmt.Errorf("user %s: %w", user, err)
+		}
+	}
+	for group, name := range c.Groups {
+		if s.groups[group], err = Parse(name); err != nil {
+			return nil, fmt.Errorf("group %s: %w", group, err)
+		}
+	}
+	return s, nil
+}
+
+// fromProxy reports whether the request was sent by a trusted proxy
+func (s *Static) fromProxy(r *http.Request) bool {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	addr, err := netip.ParseAddr(host)
+	if err != nil {
+		return false
+	}
+	addr = addr.Unmap()
+	for _, p := range s.proxies {
+		if p.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// User returns the request's user, or "" when there is none or the request didn't come
+// through a trusted proxy
+func (s *Static) User(r *http.Request) string {
+	if !s.fromProxy(r) {
+		return ""
+	}
+	return User(r)
+}
+
+// Role returns the role of the request's user, the default role for requests that didn't
+// come through a trusted proxy
+func (s *Static) Role(r *http.Request) Role {
+	if !s.fromProxy(r) {
+		return s.def
+	}
+	var (
+		role  Role
+		found bool
+	)
+	if user := User(r); user != "" {
+		role, found = s.users[user]
+	}
+	if s.groupsHeader != "" {
+		for _, group := range strings.Split(r.Header.Get(s.groupsHeader), ",") {
+			if g, ok := s.groups[strings.TrimSpace(group)]; ok {
+				role, found = max(role, g), true
+			}
+		}
+	}
+	if !found {
+		return s.def
+	}
+	return role
+}
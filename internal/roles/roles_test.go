@@ -0,0 +1,92 @@
+package roles
+
+import (
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestStatic(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "roles.yaml")
+	config := `default: cataloger
+users:
+  jdoe: reviewer
+  guest: none
+groups_header: X-Remote-Groups
+groups:
+  library-systems: admin
+`
+	if err := os.WriteFile(path, []byte(config), 0644); err != nil {
+		t.Fatal(err)
+	}
+	s, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range []struct {
+		user, groups string
+		want         Role
+	}{
+		{"jdoe", "", Reviewer},
+		{"guest", "", None},
+		{"someone", "", Cataloger},
+		{"", "", Cataloger},
+		{"jdoe", "staff, library-systems", Admin},
+		{"guest", "staff", None},
+	} {
+		r := httptest.NewRequest("GET", "/", nil)
+		r.RemoteAddr = "127.0.0.1:40000" // The proxy, on the same host
+		r.Header.Set(UserHeader, tc.user)
+		r.Header.Set("X-Remote-Groups", tc.groups)
+		if got := s.Role(r); got != tc.want {
+			t.Errorf("Role(%q, %q) = %s, want %s", tc.user, tc.groups, got, tc.want)
+		}
+	}
+
+	if _, err := New(Config{Users: map[string]string{"jdoe": "editor"}}); err == nil {
+		t.Error("expected an error for an unknown role")
+	}
+}
+
+func TestStaticTrustsOnlyProxies(t *testing.T) {
+	s, err := New(Config{
+		Default:        "none",
+		Users:          map[string]string{"boss": "admin"},
+		GroupsHeader:   "X-Remote-Groups",
+		Groups:         map[string]string{"library-systems": "admin"},
+		TrustedProxies: []string{"10.0.4.0/24", "2001:db8::7"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range []struct {
+		remoteAddr string
+		want       Role
+	}{
+		{"10.0.4.20:5000", Admin},
+		{"[2001:db8::7]:5000", Admin},
+		{"[::ffff:10.0.4.20]:5000", Admin},
+		{"192.0.2.1:5000", None}, // Reaching the server directly, around the proxy
+		{"127.0.0.1:5000", None}, // Loopback is only trusted when no proxies are listed
+		{"[2001:db8::8]:5000", None},
+	} {
+		for _, header := range []string{UserHeader, "X-Remote-Groups"} {
+			r := httptest.NewRequest("GET", "/", nil)
+			r.RemoteAddr = tc.remoteAddr
+			r.Header.Set(header, map[string]string{UserHeader: "boss", "X-Remote-Groups": "library-systems"}[header])
+			if got := s.Role(r); got != tc.want {
+				t.Errorf("Role() from %s with %s = %s, want %s", tc.remoteAddr, header, got, tc.want)
+			}
+			if user := s.User(r); (user != "") != (tc.want == Admin && header == UserHeader) {
+				t.Errorf("User() from %s with %s = %q", tc.remoteAddr, header, user)
+			}
+		}
+	}
+
+	if _, err := New(Config{TrustedProxies: []string{"proxy.example.edu"}}); err == nil {
+		t.Error("expected an error for a trusted proxy that isn't an address")
+	}
+}
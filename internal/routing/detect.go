@@ -0,0 +1,150 @@
+package routing
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Script names returned by DetectScript
+const (
+	ScriptLatin      = "latin"
+	ScriptCyrillic   = "cyrillic"
+	ScriptGreek      = "greek"
+	ScriptCJK        = "cjk"
+	ScriptArabic     = "arabic"
+	ScriptHebrew     = "hebrew"
+	ScriptDevanagari = "devanagari"
+	ScriptOther      = "other"
+	ScriptUnknown    = ""
+)
+
+var scriptTables = []struct {
+	name   string
+	tables []*unicode.RangeTable
+}{
+	{ScriptLatin, []*unicode.RangeTable{unicode.Latin}},
+	{ScriptCyrillic, []*unicode.RangeTable{unicode.Cyrillic}},
+	{ScriptGreek, []*unicode.RangeTable{unicode.Greek}},
+	{ScriptCJK, []*unicode.RangeTable{unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul}},
+	{ScriptArabic, []*unicode.RangeTable{unicode.Arabic}},
+	{ScriptHebrew, []*unicode.RangeTable{unicode.Hebrew}},
+	{ScriptDevanagari, []*unicode.RangeTable{unicode.Devanagari}},
+}
+
+// DetectScript returns the dominant writing system of the letters in text.
+// Because title pages often mix a non-Latin title with Latin imprint data, a
+// non-Latin script wins once it makes up at least 20% of the letters.
+func DetectScript(text string) string {
+	counts := make(map[string]int)
+	letters := 0
+
+	for _, r := range text {
+		if !unicode.IsLetter(r) {
+			continue
+		}
+		letters++
+		matched := false
+		for _, st := range scriptTables {
+			for _, t := range st.tables {
+				if unicode.Is(t, r) {
+					counts[st.name]++
+					matched = true
+					break
+				}
+			}
+			if matched {
+				break
+			}
+		}
+		if !matched {
+			counts[ScriptOther]++
+		}
+	}
+
+	if letters == 0 {
+		return ScriptUnknown
+	}
+
+	best, bestCount := ScriptUnknown, 0
+	for _, st := range scriptTables {
+		if st.name == ScriptLatin {
+			continue
+		}
+		if c := counts[st.name]; c > bestCount {
+			best, bestCount = st.name, c
+		}
+	}
+	if bestCount > 0 && float64(bestCount)/float64(letters) >= 0.2 {
+		return best
+	}
+
+	if counts[ScriptLatin] >= counts[ScriptOther] {
+		return ScriptLatin
+	}
+	return ScriptOther
+}
+
+// stopwords are frequent function words used to guess the language of Latin-script text.
+// Keys are MARC language codes (ISO 639-2/B).
+var stopwords = map[string][]string{
+	"eng": {"the", "and", "of", "by", "with", "for", "from", "published", "press", "edition"},
+	"fre": {"le", "la", "les", "et", "des", "du", "par", "avec", "une", "éditions"},
+	"ger": {"der", "die", "das", "und", "von", "mit", "für", "verlag", "herausgegeben", "auflage"},
+	"spa": {"el", "los", "las", "y", "del", "por", "con", "una", "editorial", "edición"},
+	"ita": {"il", "gli", "della", "di", "e", "per", "con", "una", "editore", "edizione"},
+	"por": {"o", "os", "as", "da", "do", "e", "com", "uma", "editora", "edição"},
+	"dut": {"de", "het", "een", "en", "van", "met", "voor", "uitgegeven", "uitgeverij", "druk"},
+	"lat": {"et", "in", "ad", "cum", "est", "quae", "qui", "apud", "typis", "editio"},
+}
+
+// scriptLanguages maps scripts that strongly imply a language family to a
+// representative MARC language code when no finer detection is possible
+var scriptLanguages = map[string]string{
+	ScriptGreek:  "gre",
+	ScriptHebrew: "heb",
+}
+
+// DetectLanguage makes a best-effort guess at the MARC language code of the text.
+// Latin-script text is scored against small stopword lists; other scripts map to a
+// representative language where one exists. Returns "" when no confident guess can be made.
+func DetectLanguage(text string) string {
+	script := DetectScript(text)
+	if lang, ok := scriptLanguages[script]; ok {
+		return lang
+	}
+	if script != ScriptLatin {
+		return ""
+	}
+
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r)
+	})
+	if len(words) == 0 {
+		return ""
+	}
+
+	seen := make(map[string]int, len(words))
+	for _, w := range words {
+		seen[w]++
+	}
+
+	best, bestScore, secondScore := "", 0, 0
+	for lang, list := range stopwords {
+		score := 0
+		for _, w := range list {
+			score += seen[w]
+		}
+		switch {
+		case score > bestScore:
+			best, secondScore, bestScore = lang, bestScore, score
+		case score > secondScore:
+			secondScore = score
+		}
+	}
+
+	// Require a clear winner so short or mixed title pages aren't misrouted
+	if bestScore < 2 || bestScore == secondScore {
+		return ""
+	}
+	return best
+}
@@ -0,0 +1,118 @@
+package routing
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Rule routes matching material to a provider and/or model. A rule matches when the
+// detected script is in Scripts or the detected language is in Languages.
+type Rule struct {
+	Scripts   []string `yaml:"scripts,omitempty"`
+	Languages []string `yaml:"languages,omitempty"`
+	Provider  string   `yaml:"provider,omitempty"`
+	Model     string   `yaml:"model,omitempty"`
+}
+
+// Config is the routing mapping file:
+//
+//	rules:
+//	  - scripts: [cjk]
+//	    provider: openai
+//	    model: gpt-4o
+//	  - scripts: [cyrillic]
+//	    model: qwen2.5vl:32b
+//	  - languages: [ger, lat]
+//	    provider: gemini
+type Config struct {
+	Rules []Rule `yaml:"rules"`
+}
+
+// Decision records why a record was sent to a provider/model
+type Decision struct {
+	Script   string
+	Language string
+	Rule     int // Index of the matching rule, -1 when no rule matched
+	Provider string
+	Model    string
+}
+
+// Matched reports whether a routing rule applied
+func (d Decision) Matched() bool {
+	return d.Rule >= 0
+}
+
+// String describes the decision for results and logs, e.g. "script=cjk lang= rule=0"
+func (d Decision) String() string {
+	return fmt.Sprintf("script=%s lang=%s rule=%d", d.Script, d.Language, d.Rule)
+}
+
+// Router picks a provider/model from the language or script of title page text
+type Router struct {
+	config Config
+}
+
+// LoadConfig reads a routing mapping file
+func LoadConfig(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read routing config: %w", err)
+	}
+
+	var config Config
+	if err := yaml.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("failed to parse routing config: %w", err)
+	}
+
+	for i, rule := range config.Rules {
+		if len(rule.Scripts) == 0 && len(rule.Languages) == 0 {
+			return nil, fmt.Errorf("routing rule %d has no scripts or languages", i)
+		}
+		if rule.Provider == "" && rule.Model == "" {
+			return nil, fmt.Errorf("routing rule %d has no provider or model", i)
+		}
+	}
+
+	return &config, nil
+}
+
+// NewRouter creates a router from a routing config
+func NewRouter(config Config) *Router {
+	return &Router{config: config}
+}
+
+// Route detects the script and language of text and returns the first matching rule's target.
+// Rules are evaluated in file order.
+func (r *Router) Route(text string) Decision {
+	decision := Decision{
+		Script:   DetectScript(text),
+		Language: DetectLanguage(text),
+		Rule:     -1,
+	}
+
+	for i, rule := range r.config.Rules {
+		if containsFold(rule.Scripts, decision.Script) || containsFold(rule.Languages, decision.Language) {
+			decision.Rule = i
+			decision.Provider = rule.Provider
+			decision.Model = rule.Model
+			break
+		}
+	}
+
+	return decision
+}
+
+func containsFold(list []string, value string) bool {
+	if value == "" {
+		return false
+	}
+	for _, v := range list {
+		if strings.EqualFold(v, value) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,66 @@
+package routing
+
+import "testing"
+
+func TestDetectScript(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want string
+	}{
+		{"english", "The Adventures of Tom Sawyer\nBy Mark Twain\nNew York", ScriptLatin},
+		{"russian", "Война и мир\nЛев Толстой\nМосква 1869", ScriptCyrillic},
+		{"chinese with latin imprint", "紅樓夢 曹雪芹\nBeijing: Renmin, 1982", ScriptCJK},
+		{"japanese", "こころ 夏目漱石", ScriptCJK},
+		{"hebrew", "ספר בראשית", ScriptHebrew},
+		{"digits only", "1876 12 34", ScriptUnknown},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := DetectScript(tt.text); got != tt.want {
+				t.Errorf("DetectScript() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDetectLanguage(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+	}{
+		{"The history of the decline and fall of the Roman empire, by Edward Gibbon, with notes", "eng"},
+		{"Die Leiden des jungen Werther, herausgegeben von der Verlag und der Universität", "ger"},
+		{"Les misérables, par Victor Hugo, avec des illustrations et les notes du traducteur", "fre"},
+		{"Title", ""},
+	}
+
+	for _, tt := range tests {
+		if got := DetectLanguage(tt.text); got != tt.want {
+			t.Errorf("DetectLanguage(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestRoute(t *testing.T) {
+	router := NewRouter(Config{Rules: []Rule{
+		{Scripts: []string{"cjk"}, Provider: "openai", Model: "gpt-4o"},
+		{Languages: []string{"ger"}, Model: "german-model"},
+	}})
+
+	decision := router.Route("紅樓夢 曹雪芹")
+	if !decision.Matched() || decision.Rule != 0 || decision.Provider != "openai" || decision.Model != "gpt-4o" {
+		t.Errorf("Expected CJK rule, got %+v", decision)
+	}
+
+	decision = router.Route("Die Geschichte der Stadt und der Universität, herausgegeben von dem Verlag")
+	if decision.Rule != 1 || decision.Model != "german-model" || decision.Provider != "" {
+		t.Errorf("Expected German rule, got %+v", decision)
+	}
+
+	decision = router.Route("The history of the world and of the people, by the author")
+	if decision.Matched() {
+		t.Errorf("Expected no rule for English text, got %+v", decision)
+	}
+}
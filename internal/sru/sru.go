@@ -0,0 +1,574 @@
+// Package sru implements a minimal SRU 1.2 (Search/Retrieve via URL) interface over stored
+// records: explain, and searchRetrieve with a subset of CQL returning MARCXML
+package sru
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/lehigh-university-libraries/cataloger/internal/marc"
+	"github.com/lehigh-university-libraries/cataloger/internal/storage"
+)
+
+// Version is the SRU version of responses
+const Version = "1.2"
+
+// Namespaces of responses
+const (
+	Namespace           = "http://www.loc.gov/zing/srw/"
+	diagnosticNamespace = "http://www.loc.gov/zing/srw/diagnostic/"
+	explainNamespace    = "http://explain.z3950.org/dtd/2.0/"
+)
+
+// Record schemas
+const (
+	MARCXMLSchema    = "info:srw/schema/1/marcxml-v1.1"
+	diagnosticSchema = "info:srw/schema/1/diagnostics-v1.1"
+)
+
+// Operations
+const (
+	OperationExplain        = "explain"
+	OperationSearchRetrieve = "searchRetrieve"
+)
+
+// Paging limits of searchRetrieve
+const (
+	DefaultMaximumRecords = 10
+	MaxMaximumRecords     = 100
+)
+
+// Diagnostic codes (info:srw/diagnostic/1/N) the server reports
+const (
+	DiagUnsupportedOperation      = 4
+	DiagUnsupportedVersion        = 5
+	DiagUnsupportedParameterValue = 6
+	DiagMissingParameter          = 7
+	DiagQuerySyntax               = 10
+	DiagUnsupportedIndex          = 16
+	DiagUnsupportedRelation       = 19
+	DiagUnsupportedBoolean        = 37
+	DiagUnsupportedQueryFeature   = 48
+	DiagFirstRecordOutOfRange     = 61
+	DiagRecordUnavailable         = 64
+	DiagUnsupportedSchema         = 66
+	DiagUnsupportedPacking        = 71
+)
+
+// Diagnostic is an SRU error, reported in the response rather than by HTTP status
+type Diagnostic struct {
+	Code    int
+	Message string
+	Details string
+}
+
+func (d *Diagnostic) Error() string {
+	if d.Details == "" {
+		return d.Message
+	}
+	return d.Message + ": " + d.Details
+}
+
+// URI identifies the diagnostic
+func (d *Diagnostic) URI() string {
+	return "info:srw/diagnostic/1/" + strconv.Itoa(d.Code)
+}
+
+// Request is a parsed SRU request
+type Request struct {
+	Operation      string
+	Query          storage.Query // The CQL query of a searchRetrieve
+	StartRecord    int           // 1-based
+	MaximumRecords int
+	StringPacking  bool // Records escaped as strings rather than embedded as XML
+}
+
+// ParseRequest reads an SRU request from its URL parameters. A request without an operation
+// or query is an explain.
+func ParseRequest(values url.Values) (Request, error) {
+	req := Request{Operation: values.Get("operation"), StartRecord: 1, MaximumRecords: DefaultMaximumRecords}
+	if v := values.Get("version"); v != "" && v != "1.1" && v != Version {
+		return req, &Diagnostic{DiagUnsupportedVersion, "Unsupported version", v}
+	}
+	if req.Operation == "" {
+		req.Operation = OperationExplain
+		if values.Has("query") {
+			req.Operation = OperationSearchRetrieve
+		}
+	}
+	switch req.Operation {
+	case OperationExplain:
+		return req, nil
+	case OperationSearchRetrieve:
+	default:
+		return req, &Diagnostic{DiagUnsupportedOperation, "Unsupported operation", req.Operation}
+	}
+
+	if !values.Has("query") {
+		return req, &Diagnostic{DiagMissingParameter, "Mandatory parameter not supplied", "query"}
+	}
+	var err error
+	if req.Query, err = ParseQuery(values.Get("query")); err != nil {
+		return req, err
+	}
+	for name, n := range map[string]*int{"startRecord": &req.StartRecord, "maximumRecords": &req.MaximumRecords} {
+		v := values.Get(name)
+		if v == "" {
+			continue
+		}
+		parsed, err := strconv.Atoi(v)
+		if err != nil || parsed < 0 || (name == "startRecord" && parsed < 1) {
+			return req, &Diagnostic{DiagUnsupportedParameterValue, "Unsupported parameter value", name + "=" + v}
+		}
+		*n = parsed
+	}
+	req.MaximumRecords = min(req.MaximumRecords, MaxMaximumRecords)
+	switch schema := values.Get("recordSchema"); schema {
+	case "", "marcxml", "marc21", MARCXMLSchema:
+	default:
+		return req, &Diagnostic{DiagUnsupportedSchema, "Unknown schema for retrieval", schema}
+	}
+	switch packing := values.Get("recordPacking"); packing {
+	case "", "xml":
+	case "string":
+		req.StringPacking = true
+	default:
+		return req, &Diagnostic{DiagUnsupportedPacking, "Unsupported record packing", packing}
+	}
+	return req, nil
+}
+
+// indexes maps the CQL index names, lowercase, to what they search; cql.serverChoice and
+// terms without an index search titles
+var indexes = map[string]string{
+	"bath.isbn":        "isbn",
+	"isbn":             "isbn",
+	"dc.title":         "title",
+	"bath.title":       "title",
+	"title":            "title",
+	"cql.serverchoice": "title",
+	"status":           "status",
+	"cql.allrecords":   "all",
+}
+
+// ParseQuery parses a CQL query of index/relation/term clauses joined by and, such as
+// bath.isbn=9780131103627 and dc.title="programming language". Titles match as in
+// storage.Query; other booleans, relations and modifiers are reported as unsupported.
+func ParseQuery(cql string) (storage.Query, error) {
+	tokens, err := tokenize(cql)
+	if err != nil {
+		return storage.Query{}, err
+	}
+	p := parser{tokens: tokens}
+	var q storage.Query
+	if err := p.query(&q); err != nil {
+		return storage.Query{}, err
+	}
+	if p.pos < len(p.tokens) {
+		return storage.Query{}, &Diagnostic{DiagQuerySyntax, "Query syntax error", "unexpected " + p.tokens[p.pos].text}
+	}
+	return q, nil
+}
+
+// token is a CQL word, quoted string, parenthesis or relation symbol
+type token struct {
+	text   string
+	quoted bool
+}
+
+func tokenize(cql string) ([]token, error) {
+	var tokens []token
+	for i := 0; i < len(cql); {
+		c := cql[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case c == '(' || c == ')' || c == '/':
+			tokens = append(tokens, token{text: string(c)})
+			i++
+		case c == '=' || c == '<' || c == '>':
+			j := i + 1
+			for j < len(cql) && strings.IndexByte("=<>", cql[j]) >= 0 {
+				j++
+			}
+			tokens = append(tokens, token{text: cql[i:j]})
+			i = j
+		case c == '"':
+			var b strings.Builder
+			j := i + 1
+			for ; j < len(cql) && cql[j] != '"'; j++ {
+				if cql[j] == '\\' && j+1 < len(cql) {
+					j++
+				}
+				b.WriteByte(cql[j])
+			}
+			if j == len(cql) {
+				return nil, &Diagnostic{DiagQuerySyntax, "Query syntax error", "unterminated quoted string"}
+			}
+			tokens = append(tokens, token{text: b.String(), quoted: true})
+			i = j + 1
+		default:
+			j := i
+			for j < len(cql) && strings.IndexByte(" \t\n\r()/=<>\"", cql[j]) < 0 {
+				j++
+			}
+			tokens = append(tokens, token{text: cql[i:j]})
+			i = j
+		}
+	}
+	if len(tokens) == 0 {
+		return nil, &Diagnostic{DiagQuerySyntax, "Query syntax error", "empty query"}
+	}
+	return tokens, nil
+}
+
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+func (p *parser) peek() (token, bool) {
+	if p.pos < len(p.tokens) {
+		return p.tokens[p.pos], true
+	}
+	return token{}, false
+}
+
+// keyword reports whether the next token is the unquoted word, ignoring case
+func (p *parser) keyword(word string) bool {
+	t, ok := p.peek()
+	return ok && !t.quoted && strings.EqualFold(t.text, word)
+}
+
+// query parses clauses joined by and into q
+func (p *parser) query(q *storage.Query) error {
+	for {
+		if err := p.clause(q); err != nil {
+			return err
+		}
+		t, ok := p.peek()
+		if !ok || (!t.quoted && t.text == ")") {
+			return nil
+		}
+		switch {
+		case p.keyword("and"):
+			p.pos++
+		case p.keyword("or"), p.keyword("not"), p.keyword("prox"):
+			return &Diagnostic{DiagUnsupportedBoolean, "Unsupported boolean operator", strings.ToLower(t.text)}
+		default:
+			return &Diagnostic{DiagQuerySyntax, "Query syntax error", "expected a boolean operator before " + t.text}
+		}
+		if t, ok := p.peek(); ok && !t.quoted && t.text == "/" {
+			return &Diagnostic{DiagUnsupportedQueryFeature, "Query feature unsupported", "boolean modifiers"}
+		}
+	}
+}
+
+// clause parses a parenthesized query, an index/relation/term clause or a bare term into q
+func (p *parser) clause(q *storage.Query) error {
+	t, ok := p.peek()
+	if !ok {
+		return &Diagnostic{DiagQuerySyntax, "Query syntax error", "missing search term"}
+	}
+	if !t.quoted && t.text == "(" {
+		p.pos++
+		if err := p.query(q); err != nil {
+			return err
+		}
+		if t, ok := p.peek(); !ok || t.quoted || t.text != ")" {
+			return &Diagnostic{DiagQuerySyntax, "Query syntax error", "missing )"}
+		}
+		p.pos++
+		return nil
+	}
+	if !t.quoted && (t.text == ")" || t.text == "/" || strings.ContainsAny(t.text[:1], "=<>")) {
+		return &Diagnostic{DiagQuerySyntax, "Query syntax error", "unexpected " + t.text}
+	}
+	p.pos++
+
+	index, relation := "cql.serverChoice", "="
+	term := t.text
+	if next, ok := p.peek(); ok && !next.quoted && !t.quoted && (strings.ContainsAny(next.text[:1], "=<>") || isWordRelation(next.text)) {
+		index, relation = t.text, next.text
+		p.pos++
+		if t, ok := p.peek(); ok && !t.quoted && t.text == "/" {
+			return &Diagnostic{DiagUnsupportedQueryFeature, "Query feature unsupported", "relation modifiers"}
+		}
+		value, ok := p.peek()
+		if !ok || (!value.quoted && (value.text == "(" || value.text == ")")) {
+			return &Diagnostic{DiagQuerySyntax, "Query syntax error", "missing search term"}
+		}
+		term = value.text
+		p.pos++
+	}
+	return apply(q, index, relation, term)
+}
+
+// isWordRelation reports whether a word is a CQL relation such as any or all
+func isWordRelation(word string) bool {
+	switch strings.ToLower(word) {
+	case "any", "all", "adj", "within", "encloses":
+		return true
+	}
+	return false
+}
+
+// apply adds a clause to q
+func apply(q *storage.Query, index, relation, term string) error {
+	name, ok := indexes[strings.ToLower(index)]
+	if !ok {
+		return &Diagnostic{DiagUnsupportedIndex, "Unsupported index", index}
+	}
+	relation = strings.ToLower(relation)
+	supported := relation == "=" || relation == "=="
+	if name == "title" {
+		// Titles match every word, which is what all and adj ask for with prefix matching
+		supported = supported || relation == "all" || relation == "adj"
+	}
+	if !supported {
+		return &Diagnostic{DiagUnsupportedRelation, "Unsupported relation", relation}
+	}
+
+	switch name {
+	case "all":
+		return nil
+	case "title":
+		q.Title = strings.TrimSpace(q.Title + " " + term)
+	case "isbn":
+		if q.ISBN != "" && storage.NormalizeISBN(q.ISBN) != storage.NormalizeISBN(term) {
+			return &Diagnostic{DiagUnsupportedQueryFeature, "Query feature unsupported", "two different ISBNs"}
+		}
+		q.ISBN = term
+	case "status":
+		if q.Status != "" && q.Status != term {
+			return &Diagnostic{DiagUnsupportedQueryFeature, "Query feature unsupported", "two different statuses"}
+		}
+		q.Status = term
+	}
+	return nil
+}
+
+// WriteSearchRetrieve writes the searchRetrieve response of the page of records the request
+// asks for, records being the MARCXML of every match in order
+func WriteSearchRetrieve(w io.Writer, req Request, records []string) error {
+	response := searchRetrieveResponse{Xmlns: Namespace, Version: Version, NumberOfRecords: len(records)}
+	if req.StartRecord > len(records) && len(records) > 0 {
+		response.Diagnostics = diagnostics(&Diagnostic{DiagFirstRecordOutOfRange, "First record position out of range", strconv.Itoa(req.StartRecord)})
+		return write(w, response)
+	}
+
+	end := min(req.StartRecord-1+req.MaximumRecords, len(records))
+	if end >= req.StartRecord {
+		response.Records = &responseRecords{}
+	}
+	for i := req.StartRecord - 1; i < end; i++ {
+		record := responseRecord{Schema: MARCXMLSchema, Packing: "xml", Position: i + 1}
+		if req.StringPacking {
+			record.Packing = "string"
+		}
+		data, err := recordXML(records[i])
+		if err != nil {
+			record.Schema = diagnosticSchema
+			data, _ = xml.Marshal(diagnosticXML(&Diagnostic{DiagRecordUnavailable, "Record temporarily unavailable", err.Error()}))
+		}
+		if req.StringPacking {
+			record.Data.Text = string(data)
+		} else {
+			record.Data.XML = string(data)
+		}
+		response.Records.Records = append(response.Records.Records, record)
+	}
+	if end < len(records) && req.MaximumRecords > 0 {
+		response.NextRecordPosition = end + 1
+	}
+	return write(w, response)
+}
+
+// WriteDiagnostic writes a searchRetrieve response reporting the diagnostic, or a generic one
+// for any other error
+func WriteDiagnostic(w io.Writer, err error) error {
+	d, ok := err.(*Diagnostic)
+	if !ok {
+		d = &Diagnostic{Code: 1, Message: "General system error", Details: err.Error()}
+	}
+	return write(w, searchRetrieveResponse{Xmlns: Namespace, Version: Version, Diagnostics: diagnostics(d)})
+}
+
+// Explain describes the server in an explain response
+type Explain struct {
+	Host     string
+	Port     int
+	Database string // Path of the endpoint, without the leading slash
+	Title    string
+}
+
+// WriteExplain writes the explain response
+func WriteExplain(w io.Writer, e Explain) error {
+	record := explainRecord{
+		Xmlns: explainNamespace,
+		Server: explainServer{
+			Protocol: "SRU",
+			Version:  Version,
+			Host:     e.Host,
+			Port:     e.Port,
+			Database: e.Database,
+		},
+		Title: e.Title,
+		Indexes: explainIndexInfo{
+			Sets: []explainSet{
+				{Name: "bath", Identifier: "http://zing.z3950.org/cql/bath/2.0/"},
+				{Name: "dc", Identifier: "info:srw/cql-context-set/1/dc-v1.1"},
+				{Name: "cql", Identifier: "info:srw/cql-context-set/1/cql-v1.2"},
+			},
+			Indexes: []explainIndex{
+				{Title: "ISBN", Names: []explainName{{"bath", "isbn"}}},
+				{Title: "Title", Names: []explainName{{"dc", "title"}, {"bath", "title"}, {"cql", "serverChoice"}}},
+				{Title: "Status", Names: []explainName{{"", "status"}}},
+				{Title: "All records", Names: []explainName{{"cql", "allRecords"}}},
+			},
+		},
+		Schemas:  []explainSchema{{Identifier: MARCXMLSchema, Name: "marcxml", Title: "MARCXML"}},
+		Defaults: []explainSetting{{Type: "numberOfRecords", Value: DefaultMaximumRecords}},
+		Settings: []explainSetting{{Type: "maximumRecords", Value: MaxMaximumRecords}},
+	}
+	data, err := xml.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("failed to marshal explain record: %w", err)
+	}
+	response := explainResponse{Xmlns: Namespace, Version: Version}
+	response.Record.Schema = explainNamespace
+	response.Record.Packing = "xml"
+	response.Record.Data.XML = string(data)
+	return write(w, response)
+}
+
+// recordXML returns a stored record as a MARCXML <record> element without an XML declaration
+func recordXML(marcxml string) ([]byte, error) {
+	rec, err := marc.ParseXML([]byte(marcxml))
+	if err != nil {
+		return nil, err
+	}
+	data, err := rec.XML()
+	if err != nil {
+		return nil, err
+	}
+	return bytes.TrimPrefix(data, []byte(xml.Header)), nil
+}
+
+func write(w io.Writer, v any) error {
+	data, err := xml.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal SRU response: %w", err)
+	}
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	_, err = w.Write(append(data, '\n'))
+	return err
+}
+
+func diagnostics(d *Diagnostic) *responseDiagnostics {
+	return &responseDiagnostics{Diagnostics: []diagnostic{diagnosticXML(d)}}
+}
+
+func diagnosticXML(d *Diagnostic) diagnostic {
+	return diagnostic{Xmlns: diagnosticNamespace, URI: d.URI(), Details: d.Details, Message: d.Message}
+}
+
+type searchRetrieveResponse struct {
+	XMLName            xml.Name             `xml:"searchRetrieveResponse"`
+	Xmlns              string               `xml:"xmlns,attr"`
+	Version            string               `xml:"version"`
+	NumberOfRecords    int                  `xml:"numberOfRecords"`
+	Records            *responseRecords     `xml:"records"`
+	NextRecordPosition int                  `xml:"nextRecordPosition,omitempty"`
+	Diagnostics        *responseDiagnostics `xml:"diagnostics,omitempty"`
+}
+
+type responseRecord struct {
+	Schema  string `xml:"recordSchema"`
+	Packing string `xml:"recordPacking"`
+	Data    struct {
+		XML  string `xml:",innerxml"`
+		Text string `xml:",chardata"`
+	} `xml:"recordData"`
+	Position int `xml:"recordPosition,omitempty"`
+}
+
+type responseRecords struct {
+	Records []responseRecord `xml:"record"`
+}
+
+type responseDiagnostics struct {
+	Diagnostics []diagnostic `xml:"diagnostic"`
+}
+
+type diagnostic struct {
+	XMLName xml.Name `xml:"diagnostic"`
+	Xmlns   string   `xml:"xmlns,attr"`
+	URI     string   `xml:"uri"`
+	Details string   `xml:"details,omitempty"`
+	Message string   `xml:"message"`
+}
+
+type explainResponse struct {
+	XMLName xml.Name       `xml:"explainResponse"`
+	Xmlns   string         `xml:"xmlns,attr"`
+	Version string         `xml:"version"`
+	Record  responseRecord `xml:"record"`
+}
+
+type explainRecord struct {
+	XMLName  xml.Name         `xml:"explain"`
+	Xmlns    string           `xml:"xmlns,attr"`
+	Server   explainServer    `xml:"serverInfo"`
+	Title    string           `xml:"databaseInfo>title"`
+	Indexes  explainIndexInfo `xml:"indexInfo"`
+	Schemas  []explainSchema  `xml:"schemaInfo>schema"`
+	Defaults []explainSetting `xml:"configInfo>default"`
+	Settings []explainSetting `xml:"configInfo>setting"`
+}
+
+type explainServer struct {
+	Protocol string `xml:"protocol,attr"`
+	Version  string `xml:"version,attr"`
+	Host     string `xml:"host"`
+	Port     int    `xml:"port"`
+	Database string `xml:"database"`
+}
+
+type explainIndexInfo struct {
+	Sets    []explainSet   `xml:"set"`
+	Indexes []explainIndex `xml:"index"`
+}
+
+type explainIndex struct {
+	Title string        `xml:"title"`
+	Names []explainName `xml:"map>name"`
+}
+
+type explainName struct {
+	Set  string `xml:"set,attr,omitempty"`
+	Name string `xml:",chardata"`
+}
+
+type explainSet struct {
+	Name       string `xml:"name,attr"`
+	Identifier string `xml:"identifier,attr"`
+}
+
+type explainSchema struct {
+	Identifier string `xml:"identifier,attr"`
+	Name       string `xml:"name,attr"`
+	Title      string `xml:"title"`
+}
+
+type explainSetting struct {
+	Type  string `xml:"type,attr"`
+	Value int    `xml:",chardata"`
+}
@@ -0,0 +1,135 @@
+package sru
+
+import (
+	"encoding/xml"
+	"errors"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/lehigh-university-libraries/cataloger/internal/storage"
+)
+
+func TestParseQuery(t *testing.T) {
+	for cql, want := range map[string]storage.Query{
+		`walden`:                        {Title: "walden"},
+		`dc.title = "Walden; or, Life"`: {Title: "Walden; or, Life"},
+		`bath.isbn=0-13-110362-8`:       {ISBN: "0-13-110362-8"},
+		`title all "c programming" AND (isbn == 9780131103627 and status=approved)`: {Title: "c programming", ISBN: "9780131103627", Status: "approved"},
+		`dc.title=walden and dc.title=pond`:                                         {Title: "walden pond"},
+		`cql.allRecords = 1`:                                                        {},
+	} {
+		got, err := ParseQuery(cql)
+		if err != nil || got != want {
+			t.Errorf("ParseQuery(%s) = %+v, %v; want %+v", cql, got, err, want)
+		}
+	}
+
+	for cql, code := range map[string]int{
+		``:                                    DiagQuerySyntax,
+		`title="walden`:                       DiagQuerySyntax,
+		`(title=walden`:                       DiagQuerySyntax,
+		`title=walden pond`:                   DiagQuerySyntax,
+		`dc.creator=thoreau`:                  DiagUnsupportedIndex,
+		`isbn any 123`:                        DiagUnsupportedRelation,
+		`title=walden or title=x`:             DiagUnsupportedBoolean,
+		`title =/stem walden`:                 DiagUnsupportedQueryFeature,
+		`isbn=0131103628 and isbn=0684801221`: DiagUnsupportedQueryFeature,
+	} {
+		var d *Diagnostic
+		if _, err := ParseQuery(cql); !errors.As(err, &d) || d.Code != code {
+			t.Errorf("ParseQuery(%s) err = %v, want diagnostic %d", cql, err, code)
+		}
+	}
+}
+
+func TestParseRequest(t *testing.T) {
+	req, err := ParseRequest(url.Values{})
+	if err != nil || req.Operation != OperationExplain {
+		t.Errorf("empty request = %+v, %v; want explain", req, err)
+	}
+	req, err = ParseRequest(url.Values{"query": {"walden"}, "maximumRecords": {"1000"}, "recordPacking": {"string"}})
+	if err != nil || req.Operation != OperationSearchRetrieve || req.MaximumRecords != MaxMaximumRecords || !req.StringPacking {
+		t.Errorf("searchRetrieve = %+v, %v", req, err)
+	}
+	for _, values := range []url.Values{
+		{"version": {"2.0"}, "query": {"x"}},
+		{"operation": {"scan"}},
+		{"operation": {"searchRetrieve"}},
+		{"query": {"x"}, "startRecord": {"0"}},
+		{"query": {"x"}, "recordSchema": {"dc"}},
+	} {
+		if _, err := ParseRequest(values); err == nil {
+			t.Errorf("ParseRequest(%v) accepted", values)
+		}
+	}
+}
+
+func TestWriteSearchRetrieve(t *testing.T) {
+	record := `<?xml version="1.0" encoding="UTF-8"?><record xmlns="http://www.loc.gov/MARC21/slim"><leader>00000nam a2200000 a 4500</leader><datafield tag="245" ind1="1" ind2="0"><subfield code="a">Walden &amp; more</subfield></datafield></record>`
+	records := []string{record, "not a record", record}
+
+	var b strings.Builder
+	if err := WriteSearchRetrieve(&b, Request{StartRecord: 2, MaximumRecords: 1}, records); err != nil {
+		t.Fatal(err)
+	}
+	var response struct {
+		NumberOfRecords    int `xml:"numberOfRecords"`
+		NextRecordPosition int `xml:"nextRecordPosition"`
+		Records            []struct {
+			Schema   string `xml:"recordSchema"`
+			Position int    `xml:"recordPosition"`
+		} `xml:"records>record"`
+	}
+	if err := xml.Unmarshal([]byte(b.String()), &response); err != nil {
+		t.Fatalf("invalid response: %v\n%s", err, b.String())
+	}
+	if response.NumberOfRecords != 3 || response.NextRecordPosition != 3 || len(response.Records) != 1 ||
+		response.Records[0].Position != 2 || response.Records[0].Schema != diagnosticSchema {
+		t.Errorf("page = %+v", response)
+	}
+
+	b.Reset()
+	if err := WriteSearchRetrieve(&b, Request{StartRecord: 1, MaximumRecords: 10}, records[:1]); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(b.String(), `<recordData><record xmlns="http://www.loc.gov/MARC21/slim">`) || strings.Contains(b.String(), "nextRecordPosition") {
+		t.Errorf("embedded record:\n%s", b.String())
+	}
+
+	b.Reset()
+	if err := WriteSearchRetrieve(&b, Request{StartRecord: 1, MaximumRecords: 10, StringPacking: true}, records[:1]); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(b.String(), `<recordData>&lt;record`) || !strings.Contains(b.String(), "Walden &amp;amp; more") {
+		t.Errorf("string packing:\n%s", b.String())
+	}
+
+	b.Reset()
+	if err := WriteSearchRetrieve(&b, Request{StartRecord: 5, MaximumRecords: 10}, records); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(b.String(), "info:srw/diagnostic/1/61") {
+		t.Errorf("out of range:\n%s", b.String())
+	}
+}
+
+func TestWriteExplain(t *testing.T) {
+	var b strings.Builder
+	if err := WriteExplain(&b, Explain{Host: "localhost", Port: 8888, Database: "sru", Title: "Cataloger"}); err != nil {
+		t.Fatal(err)
+	}
+	var response struct {
+		XMLName xml.Name
+		Explain struct {
+			Host    string   `xml:"serverInfo>host"`
+			Indexes []string `xml:"indexInfo>index>title"`
+		} `xml:"record>recordData>explain"`
+	}
+	if err := xml.Unmarshal([]byte(b.String()), &response); err != nil {
+		t.Fatalf("invalid response: %v\n%s", err, b.String())
+	}
+	if response.XMLName.Local != "explainResponse" || response.Explain.Host != "localhost" || len(response.Explain.Indexes) != 4 {
+		t.Errorf("explain = %+v\n%s", response, b.String())
+	}
+}
@@ -0,0 +1,186 @@
+package storage
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+
+	"github.com/lehigh-university-libraries/cataloger/internal/marc"
+	"github.com/lehigh-university-libraries/cataloger/internal/models"
+)
+
+// indexFile holds the search index of a persisted store. Its extension keeps it out of the
+// session glob.
+const indexFile = "sessions.idx"
+
+// IndexEntry is what the store knows about a session for searching
+type IndexEntry struct {
+	ID        string    `json:"id"`
+	Title     string    `json:"title,omitempty"` // 245 $a of the session's record
+	ISBNs     []string  `json:"isbns,omitempty"` // 020 $a of the session's record, as ISBN-13s
+	Status    string    `json:"status"`
+	CreatedAt time.Time `json:"created_at"`
+	IndexedAt time.Time `json:"indexed_at"`
+}
+
+// Query selects sessions. Empty fields match every session.
+type Query struct {
+	ISBN   string // ISBN-10 or ISBN-13, with or without hyphens
+	Title  string // Words each starting a word of the title, ignoring case and accents
+	Status string
+}
+
+// Search returns the index entries of the sessions matching q, newest first
+func (s *SessionStore) Search(q Query) []IndexEntry {
+	isbn := NormalizeISBN(q.ISBN)
+	words := titleWords(q.Title)
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var results []IndexEntry
+	for _, e := range s.index {
+		if q.ISBN != "" && !slices.Contains(e.ISBNs, isbn) {
+			continue
+		}
+		if q.Status != "" && e.Status != q.Status {
+			continue
+		}
+		if len(words) > 0 && !matchesWords(titleWords(e.Title), words) {
+			continue
+		}
+		e.ISBNs = slices.Clone(e.ISBNs)
+		results = append(results, e)
+	}
+	slices.SortFunc(results, func(a, b IndexEntry) int {
+		if c := b.CreatedAt.Compare(a.CreatedAt); c != 0 {
+			return c
+		}
+		return strings.Compare(a.ID, b.ID)
+	})
+	return results
+}
+
+// indexSession returns a session's index entry
+func indexSession(session *models.CatalogSession) IndexEntry {
+	e := IndexEntry{
+		ID:        session.ID,
+		Status:    session.Status,
+		CreatedAt: session.CreatedAt,
+		IndexedAt: time.Now(),
+	}
+	if session.MARC == "" {
+		return e
+	}
+	rec, err := marc.ParseXML([]byte(session.MARC))
+	if err != nil {
+		return e
+	}
+	e.Title = strings.TrimRight(rec.SubfieldValue("245", "a"), " /:;.,")
+	for _, f := range rec.Fields("020") {
+		if isbn := NormalizeISBN(f.Subfield("a")); isbn != "" && !slices.Contains(e.ISBNs, isbn) {
+			e.ISBNs = append(e.ISBNs, isbn)
+		}
+	}
+	return e
+}
+
+// NormalizeISBN returns an ISBN as 13 digits, converting an ISBN-10, or "" when s is not an
+// ISBN. Qualifiers such as "(pbk.)" are dropped.
+func NormalizeISBN(s string) string {
+	if !marc.ValidISBN(s) {
+		return ""
+	}
+	s = strings.TrimSpace(s)
+	if i := strings.IndexAny(s, " ("); i >= 0 {
+		s = s[:i]
+	}
+	s = strings.ToUpper(strings.ReplaceAll(s, "-", ""))
+	if len(s) == 13 {
+		return s
+	}
+
+	s = "978" + s[:9]
+	sum := 0
+	for i, c := range s {
+		d := int(c - '0')
+		if i%2 == 1 {
+			d *= 3
+		}
+		sum += d
+	}
+	return s + string(rune('0'+(10-sum%10)%10))
+}
+
+// titleWords splits a title into lowercase words without accents or punctuation
+func titleWords(title string) []string {
+	var b strings.Builder
+	for _, r := range norm.NFD.String(title) {
+		if !unicode.Is(unicode.Mn, r) {
+			b.WriteRune(unicode.ToLower(r))
+		}
+	}
+	return strings.FieldsFunc(b.String(), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+}
+
+// matchesWords reports whether every query word starts a word of the title
+func matchesWords(title, query []string) bool {
+	for _, q := range query {
+		if !slices.ContainsFunc(title, func(w string) bool { return strings.HasPrefix(w, q) }) {
+			return false
+		}
+	}
+	return true
+}
+
+// readIndex loads the entries of a persisted index, keyed by session
+func readIndex(path string) (map[string]IndexEntry, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read session index: %w", err)
+	}
+	var entries []IndexEntry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("failed to parse session index %s: %w", path, err)
+	}
+	index := make(map[string]IndexEntry, len(entries))
+	for _, e := range entries {
+		index[e.ID] = e
+	}
+	return index, nil
+}
+
+// writeIndex writes the index through a temp file, like writeSession
+func (s *SessionStore) writeIndex() error {
+	entries := make([]IndexEntry, 0, len(s.index))
+	for _, e := range s.index {
+		entries = append(entries, e)
+	}
+	slices.SortFunc(entries, func(a, b IndexEntry) int { return strings.Compare(a.ID, b.ID) })
+	data, err := json.Marshal(entries)
+	if err != nil {
+		return fmt.Errorf("failed to marshal session index: %w", err)
+	}
+
+	path := filepath.Join(s.dir, indexFile)
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("failed to write session index: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("failed to replace session index: %w", err)
+	}
+	return nil
+}
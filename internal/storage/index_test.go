@@ -0,0 +1,68 @@
+package storage
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/lehigh-university-libraries/cataloger/internal/models"
+)
+
+const indexedRecord = `<record xmlns="http://www.loc.gov/MARC21/slim">
+<datafield tag="020" ind1=" " ind2=" "><subfield code="a">0-306-40615-2 (pbk.)</subfield></datafield>
+<datafield tag="245" ind1="1" ind2="0"><subfield code="a">Les misérables /</subfield></datafield>
+</record>`
+
+func TestNormalizeISBN(t *testing.T) {
+	for in, want := range map[string]string{
+		"0-306-40615-2":     "9780306406157",
+		"978-0-306-40615-7": "9780306406157",
+		"0306406152 (pbk.)": "9780306406157",
+		"0306406153":        "",
+		"not an isbn":       "",
+	} {
+		if got := NormalizeISBN(in); got != want {
+			t.Errorf("NormalizeISBN(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestSearch(t *testing.T) {
+	dir := t.TempDir()
+	s, err := Open(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	s.Set("a", &models.CatalogSession{ID: "a", MARC: indexedRecord, CreatedAt: now})
+	s.Set("b", &models.CatalogSession{ID: "b", CreatedAt: now.Add(time.Minute)})
+
+	// The index is read back from disk
+	s, err = Open(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tc := range []struct {
+		q    Query
+		want []string
+	}{
+		{Query{}, []string{"b", "a"}},
+		{Query{ISBN: "9780306406157"}, []string{"a"}},
+		{Query{Title: "MISER"}, []string{"a"}},
+		{Query{Title: "les x"}, nil},
+		{Query{Status: models.StatusUploaded}, []string{"b"}},
+	} {
+		var got []string
+		for _, e := range s.Search(tc.q) {
+			got = append(got, e.ID)
+		}
+		if !slices.Equal(got, tc.want) {
+			t.Errorf("Search(%+v) = %v, want %v", tc.q, got, tc.want)
+		}
+	}
+
+	s.Delete("a")
+	if got := s.Search(Query{ISBN: "0306406152"}); len(got) != 0 {
+		t.Errorf("Search() after Delete = %v, want none", got)
+	}
+}
@@ -1,48 +1,273 @@
 package storage
 
 import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
 	"sync"
 
 	"github.com/lehigh-university-libraries/cataloger/internal/models"
 )
 
+// historySuffix names a session's append-only audit log next to its JSON file
+const historySuffix = ".history.jsonl"
+
 type SessionStore struct {
 	sessions map[string]*models.CatalogSession
+	history  map[string][]models.AuditEvent
+	index    map[string]IndexEntry  // For Search
+	dir      string                 // When set, sessions and history are persisted here
+	locks    map[string]*sync.Mutex // Held by Lock while a session is being changed
 	mu       sync.RWMutex
 }
 
 func New() *SessionStore {
 	return &SessionStore{
 		sessions: make(map[string]*models.CatalogSession),
+		history:  make(map[string][]models.AuditEvent),
+		index:    make(map[string]IndexEntry),
+		locks:    make(map[string]*sync.Mutex),
+	}
+}
+
+// Open creates a session store persisted in dir, loading any sessions and history already there.
+// Each session is stored as <id>.json with its audit log in <id>.history.jsonl. The search
+// index in sessions.idx is brought up to date with sessions changed since it was written.
+func Open(dir string) (*SessionStore, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create session directory: %w", err)
+	}
+
+	s := New()
+	s.dir = dir
+
+	paths, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list sessions: %w", err)
+	}
+
+	index, err := readIndex(filepath.Join(dir, indexFile))
+	if err != nil {
+		return nil, err
+	}
+	stale := len(index) != len(paths)
+
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to stat session %s: %w", path, err)
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read session %s: %w", path, err)
+		}
+		var session models.CatalogSession
+		if err := json.Unmarshal(data, &session); err != nil {
+			return nil, fmt.Errorf("failed to parse session %s: %w", path, err)
+		}
+		if session.Status == "" {
+			session.Status = session.DefaultStatus()
+		}
+		s.sessions[session.ID] = &session
+		if e, ok := index[session.ID]; ok && !info.ModTime().After(e.IndexedAt) {
+			s.index[session.ID] = e
+		} else {
+			s.index[session.ID] = indexSession(&session)
+			stale = true
+		}
+
+		events, err := readHistory(strings.TrimSuffix(path, ".json") + historySuffix)
+		if err != nil {
+			return nil, err
+		}
+		s.history[session.ID] = events
 	}
+
+	if stale {
+		if err := s.writeIndex(); err != nil {
+			return nil, err
+		}
+	}
+
+	slog.Info("Loaded sessions", "dir", dir, "count", len(s.sessions))
+	return s, nil
 }
 
+// Get returns a copy of a session; changes to it are kept only once passed to Set
 func (s *SessionStore) Get(sessionID string) (*models.CatalogSession, bool) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 	session, exists := s.sessions[sessionID]
-	return session, exists
+	if !exists {
+		return nil, false
+	}
+	return session.Clone(), true
 }
 
+// Set stores a copy of a session, giving it its default status when it has none
 func (s *SessionStore) Set(sessionID string, session *models.CatalogSession) {
+	if session.Status == "" {
+		session.Status = session.DefaultStatus()
+	}
+	session = session.Clone()
 	s.mu.Lock()
 	defer s.mu.Unlock()
 	s.sessions[sessionID] = session
+
+	if s.dir == "" {
+		s.index[sessionID] = indexSession(session)
+		return
+	}
+	if err := s.writeSession(sessionID, session); err != nil {
+		slog.Error("Failed to persist session", "session", sessionID, "error", err)
+	}
+	// Indexed after the write, so Open finds the entry no older than the file
+	s.index[sessionID] = indexSession(session)
+	if err := s.writeIndex(); err != nil {
+		slog.Error("Failed to persist session index", "session", sessionID, "error", err)
+	}
 }
 
+// GetAll returns copies of all sessions by ID
 func (s *SessionStore) GetAll() map[string]*models.CatalogSession {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
 	result := make(map[string]*models.CatalogSession, len(s.sessions))
 	for k, v := range s.sessions {
-		result[k] = v
+		result[k] = v.Clone()
 	}
 	return result
 }
 
+// Lock waits until no one else is changing the session and returns the function that lets
+// them. Holding it from Get to Set keeps concurrent changes from overwriting each other.
+func (s *SessionStore) Lock(sessionID string) (unlock func()) {
+	s.mu.Lock()
+	l, ok := s.locks[sessionID]
+	if !ok {
+		l = &sync.Mutex{}
+		s.locks[sessionID] = l
+	}
+	s.mu.Unlock()
+
+	l.Lock()
+	return l.Unlock
+}
+
+// Delete removes a session. Its audit history is kept.
 func (s *SessionStore) Delete(sessionID string) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 	delete(s.sessions, sessionID)
+	delete(s.index, sessionID)
+
+	if s.dir == "" {
+		return
+	}
+	if err := os.Remove(s.sessionPath(sessionID)); err != nil && !os.IsNotExist(err) {
+		slog.Error("Failed to delete session file", "session", sessionID, "error", err)
+	}
+	if err := s.writeIndex(); err != nil {
+		slog.Error("Failed to persist session index", "session", sessionID, "error", err)
+	}
+}
+
+// AppendEvent adds an event to a session's append-only audit log
+func (s *SessionStore) AppendEvent(sessionID string, event models.AuditEvent) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.history[sessionID] = append(s.history[sessionID], event)
+
+	if s.dir == "" {
+		return
+	}
+	if err := s.appendHistory(sessionID, event); err != nil {
+		slog.Error("Failed to persist audit event", "session", sessionID, "action", event.Action, "error", err)
+	}
+}
+
+// History returns a session's audit events, oldest first
+func (s *SessionStore) History(sessionID string) []models.AuditEvent {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	events := make([]models.AuditEvent, len(s.history[sessionID]))
+	copy(events, s.history[sessionID])
+	return events
+}
+
+func (s *SessionStore) sessionPath(sessionID string) string {
+	return filepath.Join(s.dir, filepath.Base(sessionID)+".json")
+}
+
+// writeSession writes the session through a temp file so a crash never leaves partial JSON
+func (s *SessionStore) writeSession(sessionID string, session *models.CatalogSession) error {
+	data, err := json.MarshalIndent(session, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal session: %w", err)
+	}
+
+	path := s.sessionPath(sessionID)
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("failed to write session: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("failed to replace session: %w", err)
+	}
+	return nil
+}
+
+func (s *SessionStore) appendHistory(sessionID string, event models.AuditEvent) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to marshal audit event: %w", err)
+	}
+
+	path := filepath.Join(s.dir, filepath.Base(sessionID)+historySuffix)
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open audit log: %w", err)
+	}
+	defer f.Close()
+
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("failed to write audit event: %w", err)
+	}
+	return nil
+}
+
+func readHistory(path string) ([]models.AuditEvent, error) {
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open audit log: %w", err)
+	}
+	defer f.Close()
+
+	var events []models.AuditEvent
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+		var event models.AuditEvent
+		if err := json.Unmarshal(line, &event); err != nil {
+			return nil, fmt.Errorf("failed to parse audit log %s: %w", path, err)
+		}
+		events = append(events, event)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read audit log %s: %w", path, err)
+	}
+	return events, nil
 }
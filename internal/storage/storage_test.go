@@ -0,0 +1,113 @@
+package storage
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/lehigh-university-libraries/cataloger/internal/models"
+)
+
+func TestOpenReloadsSessionsAndHistory(t *testing.T) {
+	dir := t.TempDir()
+	s, err := Open(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	created := time.Date(2026, 10, 18, 9, 0, 0, 0, time.UTC)
+	s.Set("a", &models.CatalogSession{ID: "a", MARC: indexedRecord, Provider: "ollama", CreatedAt: created})
+	s.AppendEvent("a", models.AuditEvent{Time: created, Actor: "jdoe", Action: models.ActionUpload})
+	s.AppendEvent("a", models.AuditEvent{Time: created.Add(time.Minute), Actor: "jdoe", Action: models.ActionStatus, Details: map[string]string{"from": "generated", "to": "in_review"}})
+	s.Set("b", &models.CatalogSession{ID: "b", CreatedAt: created})
+	s.AppendEvent("b", models.AuditEvent{Time: created, Actor: "asmith", Action: models.ActionUpload})
+	s.Delete("b")
+
+	s, err = Open(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	session, ok := s.Get("a")
+	if !ok || session.MARC != indexedRecord || session.Provider != "ollama" || !session.CreatedAt.Equal(created) || session.Status != models.StatusGenerated {
+		t.Fatalf("reloaded session = %+v, %v", session, ok)
+	}
+	history := s.History("a")
+	if len(history) != 2 || history[0].Action != models.ActionUpload || history[1].Details["to"] != "in_review" || !history[1].Time.Equal(created.Add(time.Minute)) {
+		t.Errorf("reloaded history = %+v", history)
+	}
+
+	// A deleted session is gone, but its audit log stays on disk
+	if _, ok := s.Get("b"); ok {
+		t.Error("deleted session was reloaded")
+	}
+	if _, err := os.Stat(filepath.Join(dir, "b"+historySuffix)); err != nil {
+		t.Errorf("deleted session's audit log: %v", err)
+	}
+
+	// Events appended after reopening go to the same log
+	s.AppendEvent("a", models.AuditEvent{Time: created.Add(time.Hour), Actor: "jdoe", Action: models.ActionMARCEdit})
+	s, err = Open(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if history := s.History("a"); len(history) != 3 || history[2].Action != models.ActionMARCEdit {
+		t.Errorf("history after reopening twice = %+v", history)
+	}
+}
+
+func TestOpenRejectsCorruptHistory(t *testing.T) {
+	dir := t.TempDir()
+	s, err := Open(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.Set("a", &models.CatalogSession{ID: "a"})
+	if err := os.WriteFile(filepath.Join(dir, "a"+historySuffix), []byte("{not json\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Open(dir); err == nil {
+		t.Error("Open accepted a corrupt audit log")
+	}
+}
+
+func TestGetReturnsCopies(t *testing.T) {
+	s := New()
+	s.Set("a", &models.CatalogSession{ID: "a", Images: []models.ImageItem{{ID: "one"}}})
+
+	session, _ := s.Get("a")
+	session.Status = models.StatusApproved
+	session.Images[0].OCRText = "changed"
+	session.Images = append(session.Images, models.ImageItem{ID: "two"})
+	if stored, _ := s.Get("a"); stored.Status != models.StatusUploaded || len(stored.Images) != 1 || stored.Images[0].OCRText != "" {
+		t.Errorf("change to an unsaved copy reached the store: %+v", stored)
+	}
+
+	s.Set("a", session)
+	session.MARC = "after set"
+	if stored, _ := s.Get("a"); stored.Status != models.StatusApproved || len(stored.Images) != 2 || stored.MARC != "" {
+		t.Errorf("stored session = %+v", stored)
+	}
+}
+
+func TestLockSerializesChanges(t *testing.T) {
+	s := New()
+	s.Set("a", &models.CatalogSession{ID: "a"})
+
+	var wg sync.WaitGroup
+	for range 50 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			unlock := s.Lock("a")
+			defer unlock()
+			session, _ := s.Get("a")
+			session.Images = append(session.Images, models.ImageItem{})
+			s.Set("a", session)
+		}()
+	}
+	wg.Wait()
+	if session, _ := s.Get("a"); len(session.Images) != 50 {
+		t.Errorf("session has %d images after 50 locked appends", len(session.Images))
+	}
+}
@@ -0,0 +1,85 @@
+package textdiff
+
+import "strings"
+
+// Operation kinds returned by Words
+const (
+	OpEqual  = "equal"
+	OpInsert = "insert"
+	OpDelete = "delete"
+)
+
+// Edit is a run of words that were kept, inserted or deleted
+type Edit struct {
+	Op   string `json:"op"`
+	Text string `json:"text"`
+}
+
+// Words computes a word-level diff from a to b using a longest common subsequence.
+// Adjacent words with the same operation are merged into a single edit.
+func Words(a, b string) []Edit {
+	aw := strings.Fields(a)
+	bw := strings.Fields(b)
+
+	// lcs[i][j] is the LCS length of aw[i:] and bw[j:]
+	lcs := make([][]int, len(aw)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(bw)+1)
+	}
+	for i := len(aw) - 1; i >= 0; i-- {
+		for j := len(bw) - 1; j >= 0; j-- {
+			if aw[i] == bw[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var edits []Edit
+	add := func(op, word string) {
+		if n := len(edits); n > 0 && edits[n-1].Op == op {
+			edits[n-1].Text += " " + word
+			return
+		}
+		edits = append(edits, Edit{Op: op, Text: word})
+	}
+
+	i, j := 0, 0
+	for i < len(aw) && j < len(bw) {
+		switch {
+		case aw[i] == bw[j]:
+			add(OpEqual, aw[i])
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			add(OpDelete, aw[i])
+			i++
+		default:
+			add(OpInsert, bw[j])
+			j++
+		}
+	}
+	for ; i < len(aw); i++ {
+		add(OpDelete, aw[i])
+	}
+	for ; j < len(bw); j++ {
+		add(OpInsert, bw[j])
+	}
+
+	return edits
+}
+
+// Changed counts the words deleted and inserted by a diff
+func Changed(edits []Edit) (deleted, inserted int) {
+	for _, e := range edits {
+		n := len(strings.Fields(e.Text))
+		switch e.Op {
+		case OpDelete:
+			deleted += n
+		case OpInsert:
+			inserted += n
+		}
+	}
+	return deleted, inserted
+}
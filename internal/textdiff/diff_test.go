@@ -0,0 +1,61 @@
+package textdiff
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestWords(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b string
+		want []Edit
+	}{
+		{
+			name: "identical",
+			a:    "The old man",
+			b:    "The  old\nman",
+			want: []Edit{{OpEqual, "The old man"}},
+		},
+		{
+			name: "substitution",
+			a:    "THE 0LD MAN AND THE SEA",
+			b:    "THE OLD MAN AND THE SEA",
+			want: []Edit{{OpEqual, "THE"}, {OpDelete, "0LD"}, {OpInsert, "OLD"}, {OpEqual, "MAN AND THE SEA"}},
+		},
+		{
+			name: "insert at end",
+			a:    "New York",
+			b:    "New York Scribner 1952",
+			want: []Edit{{OpEqual, "New York"}, {OpInsert, "Scribner 1952"}},
+		},
+		{
+			name: "empty original",
+			a:    "",
+			b:    "text",
+			want: []Edit{{OpInsert, "text"}},
+		},
+		{
+			name: "both empty",
+			a:    "",
+			b:    "",
+			want: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Words(tt.a, tt.b)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Words(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestChanged(t *testing.T) {
+	deleted, inserted := Changed(Words("a b c d", "a x y d"))
+	if deleted != 2 || inserted != 2 {
+		t.Errorf("Changed() = %d, %d, want 2, 2", deleted, inserted)
+	}
+}
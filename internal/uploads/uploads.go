@@ -0,0 +1,280 @@
+package uploads
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/lehigh-university-libraries/cataloger/internal/objectstore"
+)
+
+// Defaults used when the UPLOADS_* environment variables are unset
+const (
+	DefaultDir             = "uploads"
+	DefaultCleanupInterval = time.Hour
+	DefaultOrphanAge       = 24 * time.Hour
+)
+
+// ErrQuotaExceeded is matched by errors.Is for every QuotaError
+var ErrQuotaExceeded = errors.New("uploads quota exceeded")
+
+// QuotaError reports an upload rejected because it would exceed the quota
+type QuotaError struct {
+	Size  int64 // Bytes of the rejected upload
+	Used  int64 // Bytes already stored
+	Quota int64
+}
+
+func (e *QuotaError) Error() string {
+	return fmt.Sprintf("uploads quota exceeded: %s already stored of %s, cannot add %s",
+		FormatSize(e.Used), FormatSize(e.Quota), FormatSize(e.Size))
+}
+
+func (e *QuotaError) Is(target error) bool {
+	return target == ErrQuotaExceeded
+}
+
+// Store keeps uploaded images in an object store (a local directory or a bucket), within an
+// optional total-size quota
+type Store struct {
+	Objects         objectstore.Store
+	Quota           int64         // Maximum total bytes; 0 for no limit
+	CleanupInterval time.Duration // How often Run removes orphans; 0 disables cleanup
+	OrphanAge       time.Duration // Unreferenced files younger than this are kept
+
+	mu sync.Mutex // Serializes quota checks with writes and cleanup
+}
+
+// FromEnv configures a store from UPLOADS_DIR (a directory, s3://bucket/prefix or
+// gs://bucket/prefix), UPLOADS_QUOTA (e.g. "5GB"), UPLOADS_CLEANUP_INTERVAL and
+// UPLOADS_ORPHAN_AGE. A non-empty location overrides UPLOADS_DIR.
+func FromEnv(location string) (*Store, error) {
+	if location == "" {
+		location = os.Getenv("UPLOADS_DIR")
+	}
+	if location == "" {
+		location = DefaultDir
+	}
+	objects, err := objectstore.Open(location)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open uploads storage: %w", err)
+	}
+
+	s := &Store{
+		Objects:         objects,
+		CleanupInterval: DefaultCleanupInterval,
+		OrphanAge:       DefaultOrphanAge,
+	}
+	if v := os.Getenv("UPLOADS_QUOTA"); v != "" {
+		quota, err := ParseSize(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid UPLOADS_QUOTA: %w", err)
+		}
+		s.Quota = quota
+	}
+	for name, d := range map[string]*time.Duration{
+		"UPLOADS_CLEANUP_INTERVAL": &s.CleanupInterval,
+		"UPLOADS_ORPHAN_AGE":       &s.OrphanAge,
+	} {
+		if v := os.Getenv(name); v != "" {
+			parsed, err := time.ParseDuration(v)
+			if err != nil {
+				return nil, fmt.Errorf("invalid %s: %w", name, err)
+			}
+			*d = parsed
+		}
+	}
+	return s, nil
+}
+
+// NewLocal creates a store in a local directory with no quota or cleanup
+func NewLocal(dir string) *Store {
+	return &Store{Objects: objectstore.NewLocal(dir)}
+}
+
+// String returns the storage location
+func (s *Store) String() string {
+	return s.Objects.String()
+}
+
+// Save stores data as name and returns its path: a file path for local storage, otherwise
+// an object URL. Rewriting an existing name (uploads are content-addressed) is always
+// allowed; a new file must fit the quota.
+func (s *Store) Save(ctx context.Context, name string, data []byte) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, err := s.Objects.Stat(ctx, name); err == nil {
+		return s.path(name), nil
+	} else if !errors.Is(err, objectstore.ErrNotFound) {
+		return "", err
+	}
+
+	if s.Quota > 0 {
+		used, err := s.usage(ctx)
+		if err != nil {
+			return "", err
+		}
+		if used+int64(len(data)) > s.Quota {
+			return "", &QuotaError{Size: int64(len(data)), Used: used, Quota: s.Quota}
+		}
+	}
+
+	if err := s.Objects.Put(ctx, name, data, objectstore.ContentType(name)); err != nil {
+		return "", fmt.Errorf("failed to write upload: %w", err)
+	}
+	return s.path(name), nil
+}
+
+// Get returns the contents of a stored file
+func (s *Store) Get(ctx context.Context, name string) ([]byte, error) {
+	return s.Objects.Get(ctx, name)
+}
+
+// Stat describes a stored file
+func (s *Store) Stat(ctx context.Context, name string) (objectstore.Object, error) {
+	return s.Objects.Stat(ctx, name)
+}
+
+// LocalFile returns a local path to a stored file for tools that read files, such as OCR.
+// Files in a bucket are downloaded to a temporary file that release removes.
+func (s *Store) LocalFile(ctx context.Context, name string) (path string, release func(), err error) {
+	if local, ok := s.Objects.(*objectstore.Local); ok {
+		return local.Path(name), func() {}, nil
+	}
+
+	data, err := s.Objects.Get(ctx, name)
+	if err != nil {
+		return "", nil, err
+	}
+	f, err := os.CreateTemp("", "upload-*"+filepath.Ext(name))
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to create temporary file: %w", err)
+	}
+	defer f.Close()
+	if _, err := f.Write(data); err != nil {
+		os.Remove(f.Name())
+		return "", nil, fmt.Errorf("failed to write temporary file: %w", err)
+	}
+	return f.Name(), func() { os.Remove(f.Name()) }, nil
+}
+
+// path is the ImagePath recorded for a stored file
+func (s *Store) path(name string) string {
+	if local, ok := s.Objects.(*objectstore.Local); ok {
+		return local.Path(name)
+	}
+	return s.Objects.String() + "/" + name
+}
+
+// Usage returns the total bytes stored
+func (s *Store) Usage(ctx context.Context) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.usage(ctx)
+}
+
+func (s *Store) usage(ctx context.Context) (int64, error) {
+	objects, err := s.Objects.List(ctx, "")
+	if err != nil {
+		return 0, err
+	}
+	var total int64
+	for _, obj := range objects {
+		total += obj.Size
+	}
+	return total, nil
+}
+
+// Cleanup removes files whose names are not in referenced and that are older than OrphanAge,
+// returning how many files and bytes were removed
+func (s *Store) Cleanup(ctx context.Context, referenced map[string]bool) (int, int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	objects, err := s.Objects.List(ctx, "")
+	if err != nil {
+		return 0, 0, err
+	}
+
+	cutoff := time.Now().Add(-s.OrphanAge)
+	removed, freed := 0, int64(0)
+	for _, obj := range objects {
+		if referenced[obj.Key] || obj.Modified.After(cutoff) {
+			continue
+		}
+		if err := s.Objects.Delete(ctx, obj.Key); err != nil {
+			slog.Warn("Failed to remove orphaned upload", "file", obj.Key, "error", err)
+			continue
+		}
+		removed++
+		freed += obj.Size
+	}
+	return removed, freed, nil
+}
+
+// Run removes orphaned files every CleanupInterval until ctx is done. referenced returns
+// the file names still in use.
+func (s *Store) Run(ctx context.Context, referenced func() map[string]bool) {
+	if s.CleanupInterval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(s.CleanupInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			removed, freed, err := s.Cleanup(ctx, referenced())
+			if err != nil {
+				slog.Error("Uploads cleanup failed", "error", err)
+			} else if removed > 0 {
+				slog.Info("Removed orphaned uploads", "files", removed, "freed", FormatSize(freed))
+			}
+		}
+	}
+}
+
+var sizeUnits = []struct {
+	suffix string
+	bytes  int64
+}{
+	{"TB", 1 << 40}, {"GB", 1 << 30}, {"MB", 1 << 20}, {"KB", 1 << 10}, {"B", 1},
+}
+
+// ParseSize parses a byte size such as "500MB", "2GB" or "1048576". Units are binary (1KB = 1024 bytes).
+func ParseSize(s string) (int64, error) {
+	v := strings.ToUpper(strings.TrimSpace(s))
+	multiplier := int64(1)
+	for _, unit := range sizeUnits {
+		if strings.HasSuffix(v, unit.suffix) {
+			v, multiplier = strings.TrimSpace(strings.TrimSuffix(v, unit.suffix)), unit.bytes
+			break
+		}
+	}
+
+	n, err := strconv.ParseFloat(v, 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid size %q", s)
+	}
+	return int64(n * float64(multiplier)), nil
+}
+
+// FormatSize formats a byte count with the largest whole unit, e.g. "1.5GB"
+func FormatSize(n int64) string {
+	for _, unit := range sizeUnits {
+		if n >= unit.bytes && unit.bytes > 1 {
+			return strconv.FormatFloat(float64(n)/float64(unit.bytes), 'f', 1, 64) + unit.suffix
+		}
+	}
+	return strconv.FormatInt(n, 10) + "B"
+}
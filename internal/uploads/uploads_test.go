@@ -0,0 +1,88 @@
+package uploads
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestSaveQuota(t *testing.T) {
+	s := NewLocal(t.TempDir())
+	s.Quota = 10
+	ctx := context.Background()
+
+	if _, err := s.Save(ctx, "a.jpg", []byte("123456")); err != nil {
+		t.Fatalf("Save() error: %v", err)
+	}
+	// Re-saving content already stored does not count against the quota
+	if _, err := s.Save(ctx, "a.jpg", []byte("123456")); err != nil {
+		t.Fatalf("Save() of existing file error: %v", err)
+	}
+
+	_, err := s.Save(ctx, "b.jpg", []byte("123456"))
+	var quotaErr *QuotaError
+	if !errors.Is(err, ErrQuotaExceeded) || !errors.As(err, &quotaErr) {
+		t.Fatalf("Save() error = %v, want QuotaError", err)
+	}
+	if quotaErr.Used != 6 || quotaErr.Size != 6 || quotaErr.Quota != 10 {
+		t.Errorf("QuotaError = %+v", quotaErr)
+	}
+
+	if used, _ := s.Usage(ctx); used != 6 {
+		t.Errorf("Usage() = %d, want 6", used)
+	}
+}
+
+func TestCleanup(t *testing.T) {
+	dir := t.TempDir()
+	s := NewLocal(dir)
+	s.OrphanAge = time.Hour
+	old := time.Now().Add(-2 * time.Hour)
+	for _, name := range []string{"kept.jpg", "orphan.jpg", "recent.jpg"} {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte("data"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if name != "recent.jpg" {
+			if err := os.Chtimes(path, old, old); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	removed, freed, err := s.Cleanup(context.Background(), map[string]bool{"kept.jpg": true})
+	if err != nil {
+		t.Fatalf("Cleanup() error: %v", err)
+	}
+	if removed != 1 || freed != 4 {
+		t.Errorf("Cleanup() = %d files, %d bytes; want 1, 4", removed, freed)
+	}
+	for name, want := range map[string]bool{"kept.jpg": true, "orphan.jpg": false, "recent.jpg": true} {
+		if _, err := os.Stat(filepath.Join(dir, name)); (err == nil) != want {
+			t.Errorf("%s exists = %v, want %v", name, err == nil, want)
+		}
+	}
+}
+
+func TestParseSize(t *testing.T) {
+	tests := map[string]int64{
+		"1048576": 1 << 20,
+		"500MB":   500 << 20,
+		"2gb":     2 << 30,
+		"1.5 KB":  1536,
+	}
+	for in, want := range tests {
+		if got, err := ParseSize(in); err != nil || got != want {
+			t.Errorf("ParseSize(%q) = %d, %v; want %d", in, got, err, want)
+		}
+	}
+	if _, err := ParseSize("lots"); err == nil {
+		t.Error("ParseSize(\"lots\") should fail")
+	}
+	if got := FormatSize(3 << 29); got != "1.5GB" {
+		t.Errorf("FormatSize() = %q, want 1.5GB", got)
+	}
+}
@@ -0,0 +1,201 @@
+// Package usage measures the resources a run uses: peak memory and goroutines, bytes
+// downloaded, and the size of LLM requests and responses. Counters are process-wide so the
+// HTTP clients and providers that feed them need no plumbing; a Tracker reports what was
+// counted between its Start and Stop.
+package usage
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"runtime"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// DefaultInterval is how often a Tracker samples memory and goroutines
+const DefaultInterval = time.Second
+
+// Usage is the resources used by a run
+type Usage struct {
+	Duration        time.Duration
+	PeakHeapBytes   uint64 // Highest heap in use, sampled
+	PeakSysBytes    uint64 // Highest memory obtained from the OS by the Go runtime, sampled
+	PeakGoroutines  int
+	DownloadedBytes int64 // Response bytes read through CountingTransport: images, datasets, LLM APIs
+
+	LLMRequests         int64
+	LLMSentBytes        int64 // Prompts and images sent
+	LLMReceivedBytes    int64 // Responses received
+	MaxLLMRequestBytes  int64
+	MaxLLMResponseBytes int64
+}
+
+var (
+	downloaded   atomic.Int64
+	llmRequests  atomic.Int64
+	llmSent      atomic.Int64
+	llmReceived  atomic.Int64
+	maxLLMSent   atomic.Int64
+	maxLLMRecved atomic.Int64
+)
+
+// AddLLMCall counts an LLM request of sent bytes (prompt and images) and its response
+func AddLLMCall(sent, received int64) {
+	llmRequests.Add(1)
+	llmSent.Add(sent)
+	llmReceived.Add(received)
+	storeMax(&maxLLMSent, sent)
+	storeMax(&maxLLMRecved, received)
+}
+
+func storeMax(v *atomic.Int64, n int64) {
+	for {
+		cur := v.Load()
+		if n <= cur || v.CompareAndSwap(cur, n) {
+			return
+		}
+	}
+}
+
+// Tracker samples memory and goroutines until stopped
+type Tracker struct {
+	start time.Time
+	base  Usage // Counters at Start
+	done  chan struct{}
+	wg    sync.WaitGroup
+
+	mu   sync.Mutex
+	peak Usage
+}
+
+// Start begins tracking, sampling every interval (DefaultInterval when not positive)
+func Start(interval time.Duration) *Tracker {
+	if interval <= 0 {
+		interval = DefaultInterval
+	}
+	// The largest payloads can't be told apart by run, so a new run starts them over
+	maxLLMSent.Store(0)
+	maxLLMRecved.Store(0)
+	t := &Tracker{start: time.Now(), base: counters(), done: make(chan struct{})}
+	t.sample()
+	t.wg.Add(1)
+	go func() {
+		defer t.wg.Done()
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				t.sample()
+			case <-t.done:
+				return
+			}
+		}
+	}()
+	return t
+}
+
+func (t *Tracker) sample() {
+	var m runtime.MemStats
+	runtime.ReadMemStats(&m)
+	goroutines := runtime.NumGoroutine()
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.peak.PeakHeapBytes = max(t.peak.PeakHeapBytes, m.HeapInuse)
+	t.peak.PeakSysBytes = max(t.peak.PeakSysBytes, m.Sys)
+	t.peak.PeakGoroutines = max(t.peak.PeakGoroutines, goroutines)
+}
+
+// Stop ends tracking and returns the usage since Start
+func (t *Tracker) Stop() Usage {
+	t.sample()
+	close(t.done)
+	t.wg.Wait()
+
+	t.mu.Lock()
+	u := t.peak
+	t.mu.Unlock()
+
+	c := counters()
+	u.Duration = time.Since(t.start)
+	u.DownloadedBytes = c.DownloadedBytes - t.base.DownloadedBytes
+	u.LLMRequests = c.LLMRequests - t.base.LLMRequests
+	u.LLMSentBytes = c.LLMSentBytes - t.base.LLMSentBytes
+	u.LLMReceivedBytes = c.LLMReceivedBytes - t.base.LLMReceivedBytes
+	u.MaxLLMRequestBytes = c.MaxLLMRequestBytes
+	u.MaxLLMResponseBytes = c.MaxLLMResponseBytes
+	return u
+}
+
+func counters() Usage {
+	return Usage{
+		DownloadedBytes:     downloaded.Load(),
+		LLMRequests:         llmRequests.Load(),
+		LLMSentBytes:        llmSent.Load(),
+		LLMReceivedBytes:    llmReceived.Load(),
+		MaxLLMRequestBytes:  maxLLMSent.Load(),
+		MaxLLMResponseBytes: maxLLMRecved.Load(),
+	}
+}
+
+// CountingTransport counts the response bytes read through it as downloaded
+type CountingTransport struct {
+	Base http.RoundTripper // http.DefaultTransport when nil
+}
+
+// RoundTrip sends the request and counts its response body as it is read
+func (t *CountingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	resp, err := base.RoundTrip(req)
+	if resp != nil && resp.Body != nil {
+		resp.Body = &countingBody{ReadCloser: resp.Body}
+	}
+	return resp, err
+}
+
+type countingBody struct {
+	io.ReadCloser
+}
+
+func (b *countingBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	downloaded.Add(int64(n))
+	return n, err
+}
+
+// Print writes the RESOURCE USAGE section of a run summary
+func (u *Usage) Print(w io.Writer) {
+	fmt.Fprintln(w, "RESOURCE USAGE")
+	fmt.Fprintln(w, strings.Repeat("-", 70))
+	fmt.Fprintf(w, "Wall Time: %s\n", u.Duration.Round(time.Millisecond))
+	fmt.Fprintf(w, "Peak Heap: %s (%s from the OS)\n", FormatBytes(int64(u.PeakHeapBytes)), FormatBytes(int64(u.PeakSysBytes)))
+	fmt.Fprintf(w, "Peak Goroutines: %d\n", u.PeakGoroutines)
+	fmt.Fprintf(w, "Downloaded: %s\n", FormatBytes(u.DownloadedBytes))
+	if u.LLMRequests > 0 {
+		fmt.Fprintf(w, "LLM Requests: %d\n", u.LLMRequests)
+		fmt.Fprintf(w, "  Sent: %s (largest %s)\n", FormatBytes(u.LLMSentBytes), FormatBytes(u.MaxLLMRequestBytes))
+		fmt.Fprintf(w, "  Received: %s (largest %s)\n", FormatBytes(u.LLMReceivedBytes), FormatBytes(u.MaxLLMResponseBytes))
+	}
+	fmt.Fprintln(w)
+}
+
+// FormatBytes formats a byte count with a binary unit
+func FormatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
@@ -0,0 +1,48 @@
+package usage
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTracker(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(strings.Repeat("x", 1000)))
+	}))
+	defer srv.Close()
+
+	AddLLMCall(10, 10) // Before the run, not counted
+	tracker := Start(time.Millisecond)
+	client := &http.Client{Transport: &CountingTransport{}}
+	resp, err := client.Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _ = io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+	AddLLMCall(300, 20)
+	AddLLMCall(100, 50)
+	u := tracker.Stop()
+
+	if u.DownloadedBytes != 1000 {
+		t.Errorf("downloaded = %d, want 1000", u.DownloadedBytes)
+	}
+	if u.LLMRequests != 2 || u.LLMSentBytes != 400 || u.LLMReceivedBytes != 70 || u.MaxLLMRequestBytes != 300 || u.MaxLLMResponseBytes != 50 {
+		t.Errorf("LLM usage = %+v", u)
+	}
+	if u.PeakHeapBytes == 0 || u.PeakGoroutines == 0 || u.Duration <= 0 {
+		t.Errorf("samples = %+v", u)
+	}
+}
+
+func TestFormatBytes(t *testing.T) {
+	for n, want := range map[int64]string{512: "512 B", 1536: "1.5 KiB", 3 << 30: "3.0 GiB"} {
+		if got := FormatBytes(n); got != want {
+			t.Errorf("FormatBytes(%d) = %q, want %q", n, got, want)
+		}
+	}
+}
@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"errors"
 	"os"
 
 	"github.com/charmbracelet/fang"
@@ -20,6 +21,11 @@ func main() {
 		fang.WithVersion(version),
 		fang.WithNotifySignal(os.Interrupt, os.Kill),
 	); err != nil {
+		// Commands such as eval run report their outcome in the exit code
+		var coded interface{ ExitCode() int }
+		if errors.As(err, &coded) {
+			os.Exit(coded.ExitCode())
+		}
 		os.Exit(1)
 	}
 }
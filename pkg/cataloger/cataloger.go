@@ -0,0 +1,146 @@
+// Package cataloger generates MARC records from photographs or OCR text of a book's title
+// page with an LLM. Providers, models, prompts, the institution profile and identifier
+// lookups are configured from the same environment variables as the cataloger CLI (see
+// sample.env).
+package cataloger
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/lehigh-university-libraries/cataloger/internal/cataloging"
+	"github.com/lehigh-university-libraries/cataloger/internal/eval/metadata"
+	"github.com/lehigh-university-libraries/cataloger/internal/models"
+	"github.com/lehigh-university-libraries/cataloger/internal/ocr"
+	"github.com/lehigh-university-libraries/cataloger/pkg/marc"
+)
+
+// Image types, in the order their text is passed to generation: the title page is the chief
+// source of information, the copyright page the next best
+const (
+	ImageTitlePage = "title_page"
+	ImageCopyright = "copyright"
+	ImageCover     = "cover"
+)
+
+// Metadata is the bibliographic metadata an LLM extracts from OCR text
+type Metadata = metadata.BookMetadata
+
+// Options selects the LLM used for generation and OCR. Empty fields fall back to
+// CATALOGING_PROVIDER and each provider's default model.
+type Options struct {
+	Provider string // "ollama", "openai", "azure-openai", "gemini", "claude", "groq", "huggingface", "llamacpp", "mistral" or "mock"
+	Model    string
+
+	OCRProvider string // Defaults to Provider
+	OCRModel    string // Defaults to Model when OCRProvider is empty
+
+	// Timeout bounds each LLM call: PROVIDER_TIMEOUT (default 5m) when 0, none when negative.
+	// A deadline on the context passed to a method applies as well.
+	Timeout time.Duration
+}
+
+// Image is a photographed page on disk
+type Image struct {
+	Path string
+	Type string // ImageTitlePage (default), ImageCopyright or ImageCover
+}
+
+// Result is a record generated from images
+type Result struct {
+	Record        *marc.Record
+	OCRText       []string // Transcriptions in the order they were passed to generation
+	PromptVersion string   // Metadata extraction prompt used, e.g. "metadata_extraction@v1+3f2a9c1b7e4d"
+}
+
+// Cataloger generates records. It is safe for concurrent use.
+type Cataloger struct {
+	opts    Options
+	catalog *cataloging.Service
+	ocr     *ocr.Service
+}
+
+// New creates a cataloger, reading prompt, profile and identifier configuration from the
+// environment
+func New(opts Options) *Cataloger {
+	if opts.OCRProvider == "" {
+		opts.OCRProvider = opts.Provider
+		opts.OCRModel = cmp.Or(opts.OCRModel, opts.Model)
+	}
+	c := &Cataloger{opts: opts, catalog: cataloging.NewService(), ocr: ocr.NewService()}
+	if opts.Timeout != 0 {
+		c.catalog.SetTimeout(max(opts.Timeout, 0))
+		c.ocr.SetTimeout(max(opts.Timeout, 0))
+	}
+	return c
+}
+
+// Transcribe returns the text of an image using the OCR provider's vision model
+func (c *Cataloger) Transcribe(ctx context.Context, imagePath string) (string, error) {
+	return c.ocr.ExtractTextFromImage(ctx, imagePath, c.opts.OCRProvider, c.opts.OCRModel)
+}
+
+// ExtractMetadata extracts bibliographic metadata from OCR text
+func (c *Cataloger) ExtractMetadata(ctx context.Context, ocrText string) (Metadata, error) {
+	resp, err := c.catalog.ExtractMetadataFromOCR(ctx, ocrText, c.opts.Provider, c.opts.Model)
+	if err != nil {
+		return Metadata{}, err
+	}
+	return cataloging.ParseMetadataJSON(resp)
+}
+
+// GenerateFromOCR generates a record from OCR text, applying the institution profile,
+// control field policy and identifier lookups configured in the environment
+func (c *Cataloger) GenerateFromOCR(ctx context.Context, ocrText string) (*marc.Record, error) {
+	return c.catalog.GenerateMARCFromOCR(ctx, ocrText, c.opts.Provider, c.opts.Model)
+}
+
+// GenerateFromImages transcribes the images and generates a record from their text, title
+// page first, then copyright page, then cover. ctx is checked before each LLM call; a call
+// in progress is not interrupted.
+func (c *Cataloger) GenerateFromImages(ctx context.Context, images ...Image) (*Result, error) {
+	if len(images) == 0 {
+		return nil, fmt.Errorf("no images")
+	}
+	images = slices.Clone(images)
+	for i := range images {
+		images[i].Type = cmp.Or(images[i].Type, ImageTitlePage)
+		if _, ok := models.ImageTypeOrder[images[i].Type]; !ok {
+			return nil, fmt.Errorf("invalid image type %q", images[i].Type)
+		}
+	}
+	slices.SortStableFunc(images, func(a, b Image) int {
+		return models.ImageTypeOrder[a.Type] - models.ImageTypeOrder[b.Type]
+	})
+
+	result := &Result{PromptVersion: c.catalog.PromptVersion()}
+	for _, img := range images {
+		text, err := c.Transcribe(ctx, img.Path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to transcribe %s: %w", img.Path, err)
+		}
+		if strings.TrimSpace(text) != "" {
+			result.OCRText = append(result.OCRText, text)
+		}
+	}
+	if len(result.OCRText) == 0 {
+		return nil, fmt.Errorf("no text found in the images")
+	}
+
+	rec, err := c.GenerateFromOCR(ctx, strings.Join(result.OCRText, "\n\n"))
+	if err != nil {
+		return nil, err
+	}
+	result.Record = rec
+	return result, nil
+}
+
+// MetadataToMARC maps extracted metadata to a record without calling an LLM or applying the
+// institution profile
+func MetadataToMARC(md Metadata) *marc.Record {
+	return cataloging.MetadataToMARC(md)
+}
@@ -0,0 +1,38 @@
+package cataloger_test
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/lehigh-university-libraries/cataloger/pkg/cataloger"
+)
+
+func ExampleCataloger_GenerateFromOCR() {
+	c := cataloger.New(cataloger.Options{Provider: "mock"})
+
+	rec, err := c.GenerateFromOCR(context.Background(), "THE HISTORY OF BRIDGES\nby Jane Smith\nNew York\nAcme Press\n1999")
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println(rec.SubfieldValue("245", "a"))
+	// Output: THE HISTORY OF BRIDGES
+}
+
+func ExampleMetadataToMARC() {
+	rec := cataloger.MetadataToMARC(cataloger.Metadata{
+		Title:           "The history of bridges",
+		Author:          "Jane Smith",
+		Publisher:       "Acme Press",
+		PublicationCity: "New York",
+		PublicationDate: "1999",
+		Language:        "English",
+	})
+	for _, df := range rec.DataFields {
+		fmt.Println(df.Tag, df.Text())
+	}
+	// Output:
+	// 100 Jane Smith
+	// 245 The history of bridges
+	// 264 New York Acme Press 1999
+}
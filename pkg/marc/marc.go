@@ -0,0 +1,81 @@
+// Package marc reads, writes and validates MARC 21 bibliographic records in MARCXML and
+// binary (ISO 2709) form.
+package marc
+
+import (
+	"io"
+
+	"github.com/lehigh-university-libraries/cataloger/internal/marc"
+)
+
+// Namespace is the MARC21 slim XML namespace
+const Namespace = marc.Namespace
+
+// Issue severities
+const (
+	SeverityError   = marc.SeverityError
+	SeverityWarning = marc.SeverityWarning
+)
+
+// Record is a MARC21 bibliographic record. Record.XML serializes it as MARCXML, and
+// Record.Mnemonic as MarcEdit mnemonic text.
+type Record = marc.Record
+
+// ControlField is a 00X field with no indicators or subfields
+type ControlField = marc.ControlField
+
+// DataField is a variable data field with indicators and subfields
+type DataField = marc.DataField
+
+// Subfield is a single coded subfield within a data field
+type Subfield = marc.Subfield
+
+// Issue is a problem found by Validate
+type Issue = marc.Issue
+
+// Reader reads a stream of records from binary MARC or a MARCXML collection
+type Reader = marc.Reader
+
+// NewReader creates a reader for binary MARC or MARCXML, detecting the format from the
+// first non-blank byte. Reader.Next returns io.EOF after the last record.
+func NewReader(r io.Reader) (*Reader, error) {
+	return marc.NewReader(r)
+}
+
+// ParseXML parses a MARCXML <record> document. A <collection> or other wrapper of several
+// records yields its one bibliographic record, so holdings beside it are ignored.
+func ParseXML(data []byte) (*Record, error) {
+	return marc.ParseXML(data)
+}
+
+// ParseXMLRecords parses every <record> in a MARCXML document: a single record, a
+// <collection>, or records in any other wrapper
+func ParseXMLRecords(data []byte) ([]*Record, error) {
+	return marc.ParseXMLRecords(data)
+}
+
+// ParseISO2709 parses a single binary MARC record
+func ParseISO2709(data []byte) (*Record, error) {
+	return marc.ParseISO2709(data)
+}
+
+// ParseMnemonic parses a record in MarcEdit mnemonic (.mrk) text form, e.g. "=245  10$aTitle"
+func ParseMnemonic(text string) (*Record, error) {
+	return marc.ParseMnemonic(text)
+}
+
+// Validate checks a record for structural errors and common content problems. Records are
+// never modified.
+func Validate(rec *Record) []Issue {
+	return marc.Validate(rec)
+}
+
+// HasErrors reports whether any issue has error severity
+func HasErrors(issues []Issue) bool {
+	for _, issue := range issues {
+		if issue.Severity == SeverityError {
+			return true
+		}
+	}
+	return false
+}
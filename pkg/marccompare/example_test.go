@@ -0,0 +1,31 @@
+package marccompare_test
+
+import (
+	"fmt"
+
+	"github.com/lehigh-university-libraries/cataloger/pkg/marc"
+	"github.com/lehigh-university-libraries/cataloger/pkg/marccompare"
+)
+
+func ExampleCompare() {
+	reference := &marc.Record{DataFields: []marc.DataField{
+		{Tag: "245", Ind1: "1", Ind2: "4", Subfields: []marc.Subfield{{Code: "a", Value: "The history of bridges /"}}},
+		{Tag: "300", Ind1: " ", Ind2: " ", Subfields: []marc.Subfield{{Code: "a", Value: "xii, 300 pages"}}},
+	}}
+	generated := &marc.Record{DataFields: []marc.DataField{
+		{Tag: "245", Ind1: "1", Ind2: "0", Subfields: []marc.Subfield{{Code: "a", Value: "The history of bridges"}}},
+	}}
+
+	c := marccompare.Compare(reference, generated)
+	fmt.Printf("score %.2f, matched %d, missing %d\n", c.Score, c.Matched, c.Missing)
+	for _, tag := range c.Tags() {
+		fmt.Println(tag, c.Fields[tag].Match)
+	}
+	accuracy, _ := c.IndicatorAccuracy()
+	fmt.Printf("indicators %.0f%%\n", accuracy*100)
+	// Output:
+	// score 0.75, matched 1, missing 1
+	// 245 exact
+	// 300 actual_missing
+	// indicators 0%
+}
@@ -0,0 +1,89 @@
+// Package marccompare scores a generated MARC record against a reference record, the same
+// way the cataloger evaluation does.
+package marccompare
+
+import (
+	"maps"
+
+	"github.com/lehigh-university-libraries/cataloger/internal/eval/marceval"
+	"github.com/lehigh-university-libraries/cataloger/pkg/marc"
+)
+
+// Match classifications for a field
+const (
+	MatchExact         = marceval.MatchExact
+	MatchFuzzyHigh     = marceval.MatchFuzzyHigh
+	MatchFuzzyMedium   = marceval.MatchFuzzyMedium
+	MatchNone          = marceval.MatchNone
+	MatchActualMissing = marceval.MatchActualMissing
+)
+
+// Comparison is the field-by-field comparison of a generated record with a reference.
+// Score is the weighted mean of the field scores, from 0 to 1.
+type Comparison = marceval.Comparison
+
+// FieldScore is the comparison of one tag between reference and generated records
+type FieldScore = marceval.FieldScore
+
+// Penalties optionally lower a comparison's score for duplicated non-repeatable fields and
+// fields out of tag order. The zero value applies no penalty.
+type Penalties = marceval.Penalties
+
+// CompletenessProfile is a named set of elements a record is expected to carry
+type CompletenessProfile = marceval.CompletenessProfile
+
+// Extension customizes how some tags are compared, e.g. a local call number scheme: it adds
+// its tags to the scored weights and may replace their normalization or scoring. Register
+// it to apply it to every comparison in the process.
+type Extension = marceval.Extension
+
+// ErrSkip is returned by an Extension's Normalize or Score to use builtin comparison for a tag
+var ErrSkip = marceval.ErrSkip
+
+// Register adds an extension to every subsequent comparison. A tag may be handled by only
+// one extension.
+func Register(ext Extension) error {
+	return marceval.Register(ext)
+}
+
+// Unregister removes an extension by name
+func Unregister(name string) {
+	marceval.Unregister(name)
+}
+
+// DefaultWeights returns the tags scored by Compare, weighted by how much they matter for
+// discovery. The map is a copy and may be modified.
+func DefaultWeights() map[string]float64 {
+	return maps.Clone(marceval.DefaultWeights)
+}
+
+// Compare scores generated against reference using DefaultWeights. Indicators, identifiers
+// (010/035), field order and duplicate fields are reported on the Comparison but do not
+// affect Score unless Penalties are applied.
+func Compare(reference, generated *marc.Record) *Comparison {
+	return marceval.Compare(reference, generated)
+}
+
+// CompareWeighted scores the tags in weights that appear in the reference record
+func CompareWeighted(reference, generated *marc.Record, weights map[string]float64) *Comparison {
+	return marceval.CompareWeighted(reference, generated, weights)
+}
+
+// Completeness checks which elements of a profile a record carries. Builtin profiles are
+// "core" and "pcc-bsr"; other names are read as YAML files. score is the share of required
+// elements present.
+func Completeness(rec *marc.Record, profile string) (score float64, present, missing []string, err error) {
+	p, err := marceval.LoadProfile(profile)
+	if err != nil {
+		return 0, nil, nil, err
+	}
+	score, present, missing = p.Check(rec)
+	return score, present, missing, nil
+}
+
+// Consistency runs the cross-field checks that apply to a record (008 dates against the
+// imprint, 008 language against 041, 1XX against the 245 first indicator, 020 check digits)
+// and returns the share passed, 1 when none apply, with the names of the failed checks.
+func Consistency(rec *marc.Record) (score float64, failed []string) {
+	return marceval.CheckConsistency(rec)
+}